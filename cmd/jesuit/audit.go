@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/audit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditDB      string
+	auditSince   string
+	auditUntil   string
+	auditOutcome string
+	auditDomain  string
+	auditCode    string
+	auditLimit   int
+	auditJSON    bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Query the verification audit log",
+	Long: `The audit log records every verification made by verify or serve with
+--audit-db: the PTX hash, domain, nullifier hash, outcome, error code,
+latency and caller. It is stored in SQLite (sqlite:<path>) or Postgres
+(postgres://...).`,
+}
+
+var auditQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "List recorded verifications, most recent first",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		since, err := parseAuditTime(auditSince, now)
+		if err != nil {
+			printError("Invalid --since: " + err.Error())
+			os.Exit(1)
+		}
+		until, err := parseAuditTime(auditUntil, now)
+		if err != nil {
+			printError("Invalid --until: " + err.Error())
+			os.Exit(1)
+		}
+
+		log, err := audit.Open(auditDB)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		defer log.Close()
+
+		records, err := log.Query(context.Background(), audit.Filter{
+			Since:   since,
+			Until:   until,
+			Outcome: auditOutcome,
+			Domain:  auditDomain,
+			Code:    auditCode,
+			Limit:   auditLimit,
+		})
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		if auditJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, r := range records {
+				enc.Encode(r)
+			}
+			return
+		}
+
+		for _, r := range records {
+			outcome := color.GreenString("PASS")
+			if !r.Success {
+				outcome = color.RedString("FAIL")
+			}
+			fmt.Printf("%s  %s  %-24s  %8.1f ms  %-24s  %s  %s\n",
+				r.Time.Format(time.RFC3339), outcome, r.Code, r.LatencyMs, r.Domain, shortHash(r.PTXHash), r.Caller)
+		}
+		fmt.Fprintf(ui, "%d records\n", len(records))
+	},
+}
+
+// parseAuditTime accepts an RFC 3339 timestamp, a date, or a duration before
+// now such as 24h
+func parseAuditTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// newEventEmitter publishes verification outcomes to a webhook and the audit
+// log, whichever are configured. It returns nil if neither is.
+func newEventEmitter(webhookURL, webhookSecret, auditURL string) (*events.Emitter, error) {
+	var sinks []events.Sink
+	if webhookURL != "" {
+		sinks = append(sinks, &events.Webhook{URL: webhookURL, Secret: []byte(webhookSecret)})
+	}
+	if auditURL != "" {
+		log, err := audit.Open(auditURL)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, log)
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return events.NewEmitter(events.Fanout(sinks...), events.WithErrorHandler(func(err error) {
+		printWarning("Event delivery failed: " + err.Error())
+	})), nil
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func init() {
+	auditQueryCmd.Flags().StringVar(&auditDB, "db", "sqlite:audit.db", "audit database (sqlite:<path> or postgres://...)")
+	auditQueryCmd.Flags().StringVar(&auditSince, "since", "", "only records at or after this time (RFC 3339, date, or duration ago such as 24h)")
+	auditQueryCmd.Flags().StringVar(&auditUntil, "until", "", "only records before this time")
+	auditQueryCmd.Flags().StringVar(&auditOutcome, "outcome", "", "only "+strings.Join([]string{audit.OutcomeSuccess, audit.OutcomeFailure}, " or ")+" records")
+	auditQueryCmd.Flags().StringVar(&auditDomain, "domain", "", "only records for this domain")
+	auditQueryCmd.Flags().StringVar(&auditCode, "code", "", "only records with this error code")
+	auditQueryCmd.Flags().IntVar(&auditLimit, "limit", 100, "maximum number of records")
+	auditQueryCmd.Flags().BoolVar(&auditJSON, "json", false, "print records as JSON lines")
+	auditCmd.AddCommand(auditQueryCmd)
+	rootCmd.AddCommand(auditCmd)
+}
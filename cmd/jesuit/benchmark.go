@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -9,12 +11,14 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
 	"github.com/spf13/cobra"
 )
 
 var (
-	numRuns    int
-	executable string
+	numRuns     int
+	executable  string
+	legacyLines bool
 )
 
 var benchmarkCmd = &cobra.Command{
@@ -36,12 +40,18 @@ var benchmarkCmd = &cobra.Command{
 			// I'll stick to ./verify but maybe add a check.
 		}
 
+		fullArgs := []string{proofFile, "--machine", "json"}
+		zkArgs := []string{proofFile, "--time-skip-dev", "--machine", "json"}
+		if legacyLines {
+			// Verifiers older than --machine only print the positional lines
+			fullArgs = []string{proofFile, "--time-dev"}
+			zkArgs = []string{proofFile, "--time-skip-dev"}
+		}
+
 		// --- Run Full Verification Benchmark ---
-		fullArgs := []string{proofFile, "--time-dev"}
 		runBenchmark("Full Verification", executable, fullArgs, numRuns)
 
 		// --- Run ZK-Only Verification Benchmark ---
-		zkArgs := []string{proofFile, "--time-skip-dev"}
 		runBenchmark("ZK-Only (Raw Proof)", executable, zkArgs, numRuns)
 	},
 }
@@ -52,10 +62,10 @@ func runBenchmark(mode, exe string, args []string, n int) {
 	var totalTimes []float64
 	var statuses []int
 
-	fmt.Printf("\nRunning benchmark for: %s %s\n", exe, strings.Join(args, " "))
+	fmt.Fprintf(ui, "\nRunning benchmark for: %s %s\n", exe, strings.Join(args, " "))
 
 	for i := 0; i < n; i++ {
-		fmt.Printf("\r  Run %d/%d...", i+1, n)
+		fmt.Fprintf(ui, "\r  Run %d/%d...", i+1, n)
 
 		cmd := exec.Command(exe, args...)
 		var stdout, stderr bytes.Buffer
@@ -67,28 +77,18 @@ func runBenchmark(mode, exe string, args []string, n int) {
 			// This shouldn't happen if err != nil
 		}
 
-		output := strings.TrimSpace(stdout.String())
-		lines := strings.Split(output, "\n")
-
-		if len(lines) < 3 {
-			fmt.Printf("\n[WARN] Run %d produced insufficient output. Skipping.\n", i+1)
+		report, err := parseRunOutput(stdout.String())
+		if err != nil {
+			fmt.Fprintf(ui, "\n[WARN] Run %d: %v. Skipping.\n", i+1, err)
 			if stderr.Len() > 0 {
-				fmt.Printf("Stderr: %s\n", stderr.String())
+				fmt.Fprintf(ui, "Stderr: %s\n", stderr.String())
 			}
 			continue
 		}
-
-		dnsTimeStr := lines[len(lines)-3]
-		proofTimeStr := lines[len(lines)-2]
-		statusStr := lines[len(lines)-1]
-
-		dt, errD := strconv.ParseFloat(strings.TrimSpace(dnsTimeStr), 64)
-		pt, errP := strconv.ParseFloat(strings.TrimSpace(proofTimeStr), 64)
-		s, errS := strconv.Atoi(strings.TrimSpace(statusStr))
-
-		if errD != nil || errP != nil || errS != nil {
-			fmt.Printf("\n[ERROR] Failed to parse output on run %d\n", i+1)
-			continue
+		dt, pt := report.DNSSeconds, report.ProofSeconds
+		s := 0
+		if report.OK {
+			s = 1
 		}
 
 		dnsTimes = append(dnsTimes, dt)
@@ -97,17 +97,44 @@ func runBenchmark(mode, exe string, args []string, n int) {
 		statuses = append(statuses, s)
 	}
 
-	fmt.Printf("\r%-40s\r", "")
-	fmt.Println("Benchmark complete.")
+	fmt.Fprintf(ui, "\r%-40s\r", "")
+	fmt.Fprintln(ui, "Benchmark complete.")
 
 	printStats(mode, dnsTimes, proofTimes, totalTimes, statuses, n)
 }
 
+// parseRunOutput reads the timings and status a verifier run printed: the
+// JSON report of --machine json, or the three trailing lines of --time-dev
+func parseRunOutput(out string) (verifier.MachineReport, error) {
+	var report verifier.MachineReport
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if !legacyLines {
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &report); err != nil {
+			return report, fmt.Errorf("no machine report in output (%v)", err)
+		}
+		if report.Error != "" {
+			return report, errors.New(report.Error)
+		}
+		return report, nil
+	}
+
+	if len(lines) < 3 {
+		return report, errors.New("insufficient output")
+	}
+	dt, errD := strconv.ParseFloat(strings.TrimSpace(lines[len(lines)-3]), 64)
+	pt, errP := strconv.ParseFloat(strings.TrimSpace(lines[len(lines)-2]), 64)
+	s, errS := strconv.Atoi(strings.TrimSpace(lines[len(lines)-1]))
+	if errD != nil || errP != nil || errS != nil {
+		return report, errors.New("failed to parse output")
+	}
+	return verifier.MachineReport{DNSSeconds: dt, ProofSeconds: pt, OK: s == 1}, nil
+}
+
 func printStats(mode string, dnsTimes, proofTimes, totalTimes []float64, statuses []int, totalRuns int) {
 	fmt.Printf("\n--- Statistics for '%s' Mode ---\n", mode)
 
 	if len(proofTimes) == 0 {
-		fmt.Println("ERROR: No successful runs were recorded. Cannot compute statistics.")
+		fmt.Fprintln(ui, "ERROR: No successful runs were recorded. Cannot compute statistics.")
 		return
 	}
 
@@ -174,5 +201,6 @@ func printMetricStats(label string, times []float64) {
 func init() {
 	benchmarkCmd.Flags().IntVarP(&numRuns, "num-runs", "n", 10, "number of times to run the verifier")
 	benchmarkCmd.Flags().StringVarP(&executable, "executable", "e", "", "path to the verifier executable (default: self)")
+	benchmarkCmd.Flags().BoolVar(&legacyLines, "legacy-lines", false, "parse the positional --time-dev output, for verifiers without --machine")
 	rootCmd.AddCommand(benchmarkCmd)
 }
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/bundle"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleVKPath       string
+	bundleEvidencePath string
+	bundleOutPath      string
+	bundleOffline      bool
+	bundleTrustedVK    string
+	bundleVKSHA256     string
+	bundleEvidenceKey  string
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Package a PTX file with its verification key and evidence",
+	Long: `A bundle is a single tar archive holding a PTX file, its verification key, the
+circuit ID and optionally an evidence bundle, listed with their SHA-256 digests in
+manifest.json.`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create <file.ptx>",
+	Short: "Create a bundle from a PTX file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ptxData, err := os.ReadFile(args[0])
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		vkData, err := os.ReadFile(bundleVKPath)
+		if err != nil {
+			printError("Failed to read verification key: " + err.Error())
+			os.Exit(1)
+		}
+
+		var evData []byte
+		if bundleEvidencePath != "" {
+			evData, err = os.ReadFile(bundleEvidencePath)
+			if err != nil {
+				printError("Failed to read evidence: " + err.Error())
+				os.Exit(1)
+			}
+			if err := checkBundleEvidence(evData, ptxData, nil); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		b, err := bundle.New(ptxData, vkData, evData)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		out := bundleOutPath
+		if out == "" {
+			out = strings.TrimSuffix(args[0], ".ptx") + ".bundle.tar"
+		}
+		f, err := os.Create(out)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		if _, err := b.WriteTo(f); err != nil {
+			f.Close()
+			printError("Failed to write bundle: " + err.Error())
+			os.Exit(1)
+		}
+		if err := f.Close(); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		printSuccess("Bundle written to " + out)
+		fmt.Fprintf(ui, "   Circuit ID: %s\n", b.Manifest.CircuitID)
+		for _, file := range b.Manifest.Files {
+			fmt.Fprintf(ui, "   %-16s %s (%d bytes)\n", file.Name, file.SHA256, file.Size)
+		}
+	},
+}
+
+var bundleVerifyCmd = &cobra.Command{
+	Use:   "verify <bundle.tar>",
+	Short: "Verify the PTX file inside a bundle",
+	Long: `Verify the PTX file inside a bundle against a trusted verification key.
+
+The bundle and its manifest are not trusted: anyone can run a setup of their
+own and bundle a key and proof for any published commitment. The bundled key
+must therefore equal the key given with --vk or pinned with --vk-sha256.
+--offline takes the TXT records from the bundled evidence, which must be
+signed with the key given with --evidence-pubkey.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if bundleTrustedVK == "" && bundleVKSHA256 == "" {
+			printError("bundle verify requires a trusted verification key (--vk or --vk-sha256)")
+			os.Exit(1)
+		}
+		if bundleOffline && bundleEvidenceKey == "" {
+			printError("--offline requires --evidence-pubkey, the key the evidence must be signed with")
+			os.Exit(1)
+		}
+		var trusted ed25519.PublicKey
+		if bundleEvidenceKey != "" {
+			key, err := evidence.LoadPublicKey(bundleEvidenceKey)
+			if err != nil {
+				printError("Failed to load evidence public key: " + err.Error())
+				os.Exit(1)
+			}
+			trusted = key
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		b, err := bundle.Read(f)
+		f.Close()
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		printHeader("PTX Bundle Verification")
+		printSection("1. Bundle")
+		printSuccess("Manifest digests match")
+		fmt.Fprintf(ui, "   Circuit ID: %s\n", b.Manifest.CircuitID)
+		if err := checkBundleVK(b.VK); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		printSuccess("Verification key is the trusted key")
+
+		opts := verifier.VerificationOptions{
+			PTXData: b.PTX,
+			VKData:  b.VK,
+			Verbose: verbose,
+		}
+
+		if b.Evidence != nil {
+			if err := checkBundleEvidence(b.Evidence, b.PTX, trusted); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			if trusted != nil {
+				printSuccess("Evidence matches PTX file, signed by the trusted key")
+			} else {
+				printSuccess("Evidence matches PTX file")
+			}
+		} else if trusted != nil {
+			printError("--evidence-pubkey given, but the bundle holds no evidence")
+			os.Exit(1)
+		}
+
+		if bundleOffline {
+			records, err := evidenceRecords(b.Evidence)
+			if err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			opts.DNSResolver = dns.StaticResolver{Records: records}
+			fmt.Fprintf(ui, "%s  Offline: using TXT records recorded in the evidence\n", color.BlueString(glyphInfo))
+		}
+
+		res, err := verifier.NewPTXVerifier(opts).Verify()
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		printSection("2. DNS Anchor")
+		if res.Dns.Valid {
+			printSuccess("DNS anchor verified")
+		} else {
+			printError(res.Dns.Error)
+		}
+
+		printSection("3. ZK-SNARK")
+		if res.Zk.Valid {
+			printSuccess("Proof valid")
+		} else {
+			printError("Proof invalid: " + res.Zk.Error)
+		}
+
+		for _, e := range res.Errors {
+			printError(e)
+		}
+
+		if !res.Success {
+			os.Exit(1)
+		}
+		printHeader("Verification Successful")
+	},
+}
+
+// checkBundleVK ensures the bundled verification key is the trusted key of
+// --vk or --vk-sha256
+func checkBundleVK(vkData []byte) error {
+	if bundleVKSHA256 != "" && !strings.EqualFold(evidence.Hash(vkData), bundleVKSHA256) {
+		return fmt.Errorf("bundled verification key has SHA-256 %s, want %s", evidence.Hash(vkData), strings.ToLower(bundleVKSHA256))
+	}
+	if bundleTrustedVK != "" {
+		want, err := os.ReadFile(bundleTrustedVK)
+		if err != nil {
+			return fmt.Errorf("failed to read trusted verification key: %w", err)
+		}
+		if !bytes.Equal(vkData, want) {
+			return fmt.Errorf("bundled verification key differs from %s", bundleTrustedVK)
+		}
+	}
+	return nil
+}
+
+// checkBundleEvidence ensures an evidence bundle refers to ptxData and, if it
+// is signed, that the signature is intact. With a trusted key the evidence
+// must be signed by it; an unsigned or self-signed bundle is rejected.
+func checkBundleEvidence(evData, ptxData []byte, trusted ed25519.PublicKey) error {
+	ev, err := evidence.Parse(evData)
+	if err != nil {
+		return fmt.Errorf("invalid evidence: %w", err)
+	}
+	if ev.PTX.SHA256 != evidence.Hash(ptxData) {
+		return errors.New("evidence was recorded for a different PTX file")
+	}
+	if ev.Signature != nil || trusted != nil {
+		if err := ev.VerifySignature(trusted); err != nil {
+			return fmt.Errorf("evidence: %w", err)
+		}
+	}
+	return nil
+}
+
+// evidenceRecords returns the TXT records of the last successful lookup in
+// the evidence
+func evidenceRecords(evData []byte) ([]string, error) {
+	if evData == nil {
+		return nil, errors.New("--offline requires a bundle with evidence")
+	}
+	ev, err := evidence.Parse(evData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid evidence: %w", err)
+	}
+	if ev.DNS == nil {
+		return nil, errors.New("evidence has no DNS lookups")
+	}
+	for i := len(ev.DNS.Lookups) - 1; i >= 0; i-- {
+		if l := ev.DNS.Lookups[i]; l.Error == "" {
+			return l.Records, nil
+		}
+	}
+	return nil, errors.New("evidence has no successful DNS lookup")
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringVar(&bundleVKPath, "vk", "native.vk", "verification key to include")
+	bundleCreateCmd.Flags().StringVar(&bundleEvidencePath, "evidence", "", "evidence bundle to include (from verify --evidence)")
+	bundleCreateCmd.Flags().StringVarP(&bundleOutPath, "out", "o", "", "output path (default <file>.bundle.tar)")
+	bundleVerifyCmd.Flags().BoolVar(&bundleOffline, "offline", false, "use the TXT records recorded in the bundled evidence instead of querying DNS (requires --evidence-pubkey)")
+	bundleVerifyCmd.Flags().StringVar(&bundleTrustedVK, "vk", "", "trusted verification key the bundled key must equal")
+	bundleVerifyCmd.Flags().StringVar(&bundleVKSHA256, "vk-sha256", "", "SHA-256 (hex) the bundled verification key must have")
+	bundleVerifyCmd.Flags().StringVar(&bundleEvidenceKey, "evidence-pubkey", "", "PEM ed25519 public key the bundled evidence must be signed with")
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCmd.AddCommand(bundleVerifyCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
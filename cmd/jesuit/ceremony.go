@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ceremony"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ceremonyDir    string
+	ceremonyKeyID  string
+	ceremonySRS    string
+	ceremonyName   string
+	ceremonyPhase  int
+	ceremonyIndex  int
+	ceremonyBeacon string
+)
+
+var ceremonyCmd = &cobra.Command{
+	Use:   "ceremony",
+	Short: "Run a multi-party trusted setup of the native Groth16 keys",
+	Long: `Production keys must not come from a single machine: whoever ran the setup
+could forge proofs. A ceremony chains contributions of several independent
+participants; the keys are sound as long as one of them discarded their
+randomness.
+
+  1. The coordinator runs "ceremony init" (phase 1 starts, or is imported with
+     --srs from an earlier ceremony of the same domain size).
+  2. Each participant in turn runs "ceremony contribute" on the directory and
+     publishes the hash it prints.
+  3. The coordinator runs "ceremony finalize" with a public random beacon
+     published after the last contribution. Finalizing phase 1 starts
+     phase 2, which takes contributions the same way; finalizing phase 2
+     writes the keys.
+
+transcript.json records every contribution, beacon and output hash. Anyone
+can check the whole chain with "ceremony verify-contribution".`,
+}
+
+var ceremonyInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Start a ceremony for the circuit of a verification key ID",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := ceremony.Init(ceremonyDir, ceremonyKeyID, ceremonySRS)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		printSuccess(fmt.Sprintf("Ceremony for %s started in %s (domain size %d, phase %d)",
+			c.Transcript.KeyID, c.Dir, c.Transcript.DomainSize, c.CurrentPhase()))
+		fmt.Fprintf(ui, "  Circuit hash: %s\n", c.Transcript.CircuitHash)
+	},
+}
+
+var ceremonyContributeCmd = &cobra.Command{
+	Use:   "contribute",
+	Short: "Add a contribution to the current phase",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := openCeremony()
+		phase := c.CurrentPhase()
+		fmt.Fprintf(ui, "Contributing to phase %d of %s...\n", phase, c.Transcript.KeyID)
+		contribution, err := c.Contribute(ceremonyName)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		printSuccess(fmt.Sprintf("Contribution %d of phase %d written to %s", contribution.Index, phase, filepath.Join(c.Dir, contribution.File)))
+		fmt.Fprintf(ui, "  Publish this hash: %s\n", contribution.Hash)
+		fmt.Println(contribution.Hash)
+	},
+}
+
+var ceremonyVerifyCmd = &cobra.Command{
+	Use:   "verify-contribution",
+	Short: "Verify the contributions and outputs of a ceremony",
+	Long: `Without --index, verify the whole transcript: the initial states, every
+contribution, and for sealed phases that sealing again with the recorded
+beacon reproduces the SRS and the keys. With --phase and --index, verify a
+single contribution against its predecessor.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := openCeremony()
+		if ceremonyIndex >= 0 {
+			phase := ceremonyPhase
+			if phase == 0 {
+				phase = c.CurrentPhase()
+			}
+			if phase == 0 {
+				phase = 2 // finalized
+			}
+			if err := c.VerifyContribution(phase, ceremonyIndex); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			printSuccess(fmt.Sprintf("Contribution %d of phase %d is valid", ceremonyIndex, phase))
+			return
+		}
+
+		if err := c.Verify(); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		for _, p := range []struct {
+			n     int
+			phase ceremony.Phase
+		}{{1, c.Transcript.Phase1}, {2, c.Transcript.Phase2}} {
+			printSection(fmt.Sprintf("PHASE %d", p.n))
+			if p.phase.Imported != "" {
+				fmt.Fprintf(ui, "  Imported from %s\n", p.phase.Imported)
+			}
+			for _, contribution := range p.phase.Contributions {
+				printCheck(fmt.Sprintf("%d %-20s %s", contribution.Index, contribution.Contributor, contribution.Hash), true)
+			}
+			if p.phase.Sealed() && p.phase.Imported == "" {
+				fmt.Fprintf(ui, "  Sealed with beacon %q\n", p.phase.Beacon)
+			}
+			for _, file := range slices.Sorted(maps.Keys(p.phase.Outputs)) {
+				fmt.Fprintf(ui, "  %s: %s\n", file, p.phase.Outputs[file])
+			}
+		}
+		printSuccess("Ceremony transcript is valid")
+	},
+}
+
+var ceremonyFinalizeCmd = &cobra.Command{
+	Use:   "finalize",
+	Short: "Seal the current phase with a public random beacon",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := openCeremony()
+		phase := c.CurrentPhase()
+		if err := c.Finalize(ceremonyBeacon); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		printSuccess(fmt.Sprintf("Phase %d sealed", phase))
+		outputs := c.Phase(phase).Outputs
+		for _, file := range slices.Sorted(maps.Keys(outputs)) {
+			fmt.Fprintf(ui, "  %s: %s\n", filepath.Join(c.Dir, file), outputs[file])
+		}
+		if phase == 1 {
+			fmt.Fprintln(ui, "Phase 2 is open for contributions.")
+		}
+	},
+}
+
+func openCeremony() *ceremony.Ceremony {
+	c, err := ceremony.Open(ceremonyDir)
+	if err != nil {
+		printError("Failed to open ceremony: " + err.Error())
+		os.Exit(1)
+	}
+	return c
+}
+
+func init() {
+	ceremonyCmd.PersistentFlags().StringVar(&ceremonyDir, "dir", "ceremony", "ceremony directory holding the transcript and contributions")
+	ceremonyInitCmd.Flags().StringVar(&ceremonyKeyID, "key-id", signals.DefaultVerificationKeyID, "verification key ID of the circuit")
+	ceremonyInitCmd.Flags().StringVar(&ceremonySRS, "srs", "", "import phase 1 from this sealed SRS (srs.bin of an earlier ceremony)")
+	ceremonyContributeCmd.Flags().StringVar(&ceremonyName, "name", "", "contributor name recorded in the transcript")
+	ceremonyContributeCmd.MarkFlagRequired("name")
+	ceremonyVerifyCmd.Flags().IntVar(&ceremonyPhase, "phase", 0, "phase of --index (default: the current phase)")
+	ceremonyVerifyCmd.Flags().IntVar(&ceremonyIndex, "index", -1, "verify only this contribution")
+	ceremonyFinalizeCmd.Flags().StringVar(&ceremonyBeacon, "beacon", "", "public randomness published after the last contribution, e.g. a drand round")
+	ceremonyFinalizeCmd.MarkFlagRequired("beacon")
+	ceremonyCmd.AddCommand(ceremonyInitCmd, ceremonyContributeCmd, ceremonyVerifyCmd, ceremonyFinalizeCmd)
+	rootCmd.AddCommand(ceremonyCmd)
+}
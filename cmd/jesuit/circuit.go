@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsKeyID      string
+	statsSolve      bool
+	statsProfileDir string
+	statsJSON       bool
+)
+
+var circuitCmd = &cobra.Command{
+	Use:   "circuit",
+	Short: "Inspect the circuits of the registered verification keys",
+}
+
+var circuitStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report constraint and variable counts and compile time per circuit",
+	Long: `Compile the circuit of every registered verification key ID (or only
+--key-id) and report its constraints, public, secret and internal variables,
+coefficients and compile time. --solve also times the witness solver on sample
+inputs. --profile-dir writes pprof profiles attributing the constraints to
+circuit source lines and, with --solve, the solver CPU time:
+
+  go tool pprof -top stats/sdv_poseidon_v1.constraints.pprof
+
+Compare the output before and after a circuit change to judge whether it is
+worth a new trusted setup.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := prover.StatsOptions{Solve: statsSolve, ProfileDir: statsProfileDir}
+
+		var all []*prover.CircuitStats
+		if statsKeyID != "" {
+			stats, err := prover.CircuitStatsFor(statsKeyID, opts)
+			if err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			all = append(all, stats)
+		} else {
+			var err error
+			if all, err = prover.AllCircuitStats(opts); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if statsJSON {
+			out, _ := json.MarshalIndent(all, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+
+		printHeader("CIRCUIT STATS")
+		for _, s := range all {
+			printSection(s.KeyID)
+			fmt.Fprintf(ui, "  Constraints:        %d\n", s.Constraints)
+			fmt.Fprintf(ui, "  Public variables:   %d\n", s.PublicVariables)
+			fmt.Fprintf(ui, "  Secret variables:   %d\n", s.SecretVariables)
+			fmt.Fprintf(ui, "  Internal variables: %d\n", s.InternalVariables)
+			fmt.Fprintf(ui, "  Coefficients:       %d\n", s.Coefficients)
+			fmt.Fprintf(ui, "  Compile time:       %s\n", s.CompileTime.Round(time.Millisecond))
+			if statsSolve {
+				fmt.Fprintf(ui, "  Solve time:         %s\n", s.SolveTime.Round(time.Microsecond))
+			}
+			if s.ConstraintProfile != "" {
+				fmt.Fprintf(ui, "  Constraint profile: %s\n", s.ConstraintProfile)
+			}
+			if s.SolverProfile != "" {
+				fmt.Fprintf(ui, "  Solver profile:     %s\n", s.SolverProfile)
+			}
+		}
+	},
+}
+
+func init() {
+	circuitStatsCmd.Flags().StringVar(&statsKeyID, "key-id", "", "only report the circuit of this verification key ID")
+	circuitStatsCmd.Flags().BoolVar(&statsSolve, "solve", false, "also time the witness solver on sample inputs")
+	circuitStatsCmd.Flags().StringVar(&statsProfileDir, "profile-dir", "", "write pprof constraint (and with --solve, solver) profiles to this directory")
+	circuitStatsCmd.Flags().BoolVar(&statsJSON, "json", false, "print the stats as JSON")
+	circuitCmd.AddCommand(circuitStatsCmd)
+	rootCmd.AddCommand(circuitCmd)
+}
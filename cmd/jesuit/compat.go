@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/compat"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compatVectorsPath string
+	compatJSON        bool
+)
+
+var compatCmd = &cobra.Command{
+	Use:   "compat",
+	Short: "Check this build against golden cross-implementation vectors",
+	Long: `Run the golden vectors (Poseidon hashes, field encodings, metadata limbs,
+commitments, anchor hostnames and PTX bytes) through the Go implementation and
+report every mismatch. Without --vectors the set embedded in the binary is used;
+pass a file produced by the JS implementation to compare against it directly.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var v *compat.Vectors
+		var err error
+		if compatVectorsPath != "" {
+			v, err = compat.Load(compatVectorsPath)
+		} else {
+			v, err = compat.Golden()
+		}
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		r := compat.Check(v)
+		failed := r.Failed()
+
+		if compatJSON {
+			json.NewEncoder(os.Stdout).Encode(r)
+		} else {
+			printHeader("Compatibility Vectors")
+			for _, res := range r.Results {
+				if res.OK {
+					if verbose {
+						printSuccess(fmt.Sprintf("%-12s %s", res.Kind, res.Name))
+					}
+					continue
+				}
+				printError(fmt.Sprintf("%-12s %s (source %s): %s", res.Kind, res.Name, res.Source, res.Detail))
+			}
+			fmt.Fprintf(ui, "%s  %d/%d vectors match\n", color.BlueString(glyphInfo), len(r.Results)-len(failed), len(r.Results))
+		}
+
+		if len(failed) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	compatCmd.Flags().StringVar(&compatVectorsPath, "vectors", "", "golden vector JSON file (defaults to the embedded set)")
+	compatCmd.Flags().BoolVar(&compatJSON, "json", false, "print the report as JSON")
+	rootCmd.AddCommand(compatCmd)
+}
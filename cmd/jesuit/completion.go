@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate the shell completion script",
+	Long: `Print the completion script of jesuit for the given shell. Besides commands
+and long flag names, it completes the values of enumerated flags (--output,
+--dns-outage, --trustMethod, ...) and .ptx arguments.
+
+Bash (requires bash-completion):
+  source <(jesuit completion bash)
+  jesuit completion bash > /etc/bash_completion.d/jesuit
+
+Zsh:
+  jesuit completion zsh > "${fpath[1]}/_jesuit"
+
+Fish:
+  jesuit completion fish > ~/.config/fish/completions/jesuit.fish
+
+PowerShell:
+  jesuit completion powershell | Out-String | Invoke-Expression`,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			err = rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			err = rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		if err != nil {
+			printError(fmt.Sprintf("Failed to generate %s completion: %v", args[0], err))
+			os.Exit(1)
+		}
+	},
+}
+
+// flagValues lists the values offered for enumerated flags, per command
+var flagValues = map[*cobra.Command]map[string][]string{
+	verifyCmd: {
+		"output":        {"text", "json"},
+		"machine":       {"json", "lines"},
+		"dns-match":     {"exact", "prefix"},
+		"dns-outage":    {"fail-closed", "fail-open", "accept-if-cached"},
+		"domain-policy": {"all", "any", "primary"},
+		"doh-format":    {"json", "wire"},
+		"doh-network":   {"tcp4", "tcp6"},
+	},
+	serveCmd: {
+		"dns-outage":    {"fail-closed", "fail-open", "accept-if-cached"},
+		"domain-policy": {"all", "any", "primary"},
+	},
+	convertProofCmd: {
+		"to": {"native", "snarkjs"},
+	},
+	proveCmd: {
+		"output":        {"text", "json"},
+		"trustMethod":   {"doh", "gist", "well-known", "ipfs", "ethereum"},
+		"backend":       {"native", "snarkjs", "rapidsnark", "remote"},
+		"anchor":        {"well-known", "ipfs", "ethereum"},
+		"anchor-policy": {"any", "all"},
+		"fixed-label":   {"doh", "well-known"},
+	},
+}
+
+// ptxArgs are the commands taking a PTX file as their argument
+var ptxArgs = []*cobra.Command{verifyCmd, benchmarkCmd, bundleCreateCmd, convertProofCmd}
+
+func init() {
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
+
+// registerCompletions registers the value completions once every command has
+// defined its flags
+func registerCompletions() {
+	for cmd, flags := range flagValues {
+		for name, values := range flags {
+			if cmd.Flags().Lookup(name) == nil {
+				continue
+			}
+			cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+		}
+	}
+	for _, cmd := range ptxArgs {
+		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return []string{"ptx"}, cobra.ShellCompDirectiveFilterFileExt
+		}
+	}
+}
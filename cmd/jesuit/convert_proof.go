@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/convert"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	convertTo         string
+	convertProofPath  string
+	convertPublicPath string
+	convertOutPath    string
+	convertTSA        string
+	convertExportDir  string
+	convertVKPath     string
+)
+
+var convertProofCmd = &cobra.Command{
+	Use:   "convert-proof <file.ptx>",
+	Short: "Convert the proof of a PTX file between snarkjs and native gnark formats",
+	Long: `Rewrite the proof embedded in a PTX file as a native gnark proof (--to native),
+which this verifier and verifiers loading binary gnark keys accept, or as a
+snarkjs proof.json (--to snarkjs) for the JS pipeline. Without --to the proof
+is converted to the other format.
+
+--proof replaces the embedded proof with a snarkjs proof.json, for tokens whose
+proof was produced separately, and --public with the signals of its
+public.json (default: the signals already in the PTX file).
+
+--export-dir additionally writes the snarkjs proof.json and public.json of the
+token, and with --vk the verification_key.json of the native key, so tokens
+proved natively can be checked by "snarkjs groth16 verify" and the legacy JS
+verifier during the migration.
+
+The public signals, metadata and anchors are kept, so the converted file
+verifies under the same key and DNS record. The proof envelope is rewritten to
+declare the new format, and added to files written without one. An RFC 3161 timestamp covers the
+proof and is dropped unless --tsa-url timestamps the converted file again.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		ptxFile, err := ptxloader.ParsePTX(data, ptxloader.DefaultLoadOptions())
+		if err != nil {
+			printError("Failed to load PTX: " + err.Error())
+			os.Exit(1)
+		}
+		if ptxFile.GetProof() == nil {
+			printError("PTX file carries no proof")
+			os.Exit(1)
+		}
+		proofData := ptxFile.GetProof().GetProofData()
+		current, err := proofdata.Parse(proofData)
+		if err != nil {
+			printError("Invalid proof data: " + err.Error())
+			os.Exit(1)
+		}
+
+		if convertProofPath != "" {
+			proofJSON, err := os.ReadFile(convertProofPath)
+			if err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			var public []string
+			if convertPublicPath != "" {
+				raw, err := os.ReadFile(convertPublicPath)
+				if err == nil {
+					public, err = convert.ParsePublicSignals(raw)
+				}
+				if err != nil {
+					printError(err.Error())
+					os.Exit(1)
+				}
+				if !slices.Equal(public, current.PublicSignals) {
+					printWarning("The public signals differ from the PTX file; its anchor and metadata may no longer match the proof")
+				}
+			}
+			if proofData, err = convert.WithSnarkJSProof(proofData, proofJSON, public); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		} else if convertPublicPath != "" {
+			printError("--public requires --proof")
+			os.Exit(1)
+		}
+
+		to := convertTo
+		if to == "" {
+			to = convert.FormatNative
+			if current.IsNative() {
+				to = convert.FormatSnarkJS
+			}
+		}
+		if convertExportDir != "" && to != convert.FormatSnarkJS {
+			printError("--export-dir requires --to snarkjs")
+			os.Exit(1)
+		}
+		if convertVKPath != "" && convertExportDir == "" {
+			printError("--vk requires --export-dir")
+			os.Exit(1)
+		}
+		proofData, err = convert.ProofData(proofData, to)
+		if err != nil {
+			printError("Failed to convert proof: " + err.Error())
+			os.Exit(1)
+		}
+		circuitID := ptxFile.Proof.GetEnvelope().GetCircuitId()
+		if circuitID == "" {
+			circuitID = ptxFile.Proof.GetVerificationKeyId()
+		}
+		envelope, err := proofdata.NewEnvelope(proofData, circuitID)
+		if err != nil {
+			printError("Failed to describe proof: " + err.Error())
+			os.Exit(1)
+		}
+		// The proof is converted, not re-proven: its circuit stays the same
+		envelope.CircuitHash = ptxFile.Proof.GetEnvelope().GetCircuitHash()
+		ptxFile.Proof.ProofData, ptxFile.Proof.Envelope = proofData, envelope
+
+		if len(ptxFile.TimestampToken) > 0 || convertTSA != "" {
+			ptxFile.TimestampToken = nil
+			if convertTSA == "" {
+				printWarning("Dropped the RFC 3161 timestamp, which covered the previous proof (use --tsa-url to timestamp the converted file)")
+			} else {
+				digest, err := tsa.Imprint(ptxFile)
+				if err == nil {
+					client := &tsa.Client{URL: convertTSA}
+					ptxFile.TimestampToken, err = client.Timestamp(context.Background(), digest)
+				}
+				if err != nil {
+					printError("Failed to timestamp PTX: " + err.Error())
+					os.Exit(1)
+				}
+			}
+		}
+
+		payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(ptxFile)
+		if err != nil {
+			printError("Failed to serialize PTX: " + err.Error())
+			os.Exit(1)
+		}
+		out := convertOutPath
+		if out == "" {
+			out = strings.TrimSuffix(args[0], ".ptx") + "." + to + ".ptx"
+		}
+		// Keep the magic header and reserved byte of the input
+		if err := os.WriteFile(out, append(append([]byte{}, data[:5]...), payload...), 0o644); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		printSuccess(fmt.Sprintf("Wrote %s proof to %s", to, out))
+		if convertExportDir != "" {
+			if err := exportSnarkJS(proofData, convertExportDir, convertVKPath); err != nil {
+				printError("Failed to export snarkjs files: " + err.Error())
+				os.Exit(1)
+			}
+			printSuccess("Wrote snarkjs files to " + convertExportDir)
+		}
+		if to == convert.FormatSnarkJS {
+			fmt.Fprintf(ui, "%s  snarkjs proofs are checked with the snarkjs verification key, not by jesuit verify\n", color.BlueString(glyphInfo))
+		}
+	},
+}
+
+func init() {
+	convertProofCmd.Flags().StringVar(&convertTo, "to", "", "target proof format: native or snarkjs (default: the other format)")
+	convertProofCmd.Flags().StringVar(&convertProofPath, "proof", "", "snarkjs proof.json replacing the embedded proof")
+	convertProofCmd.Flags().StringVar(&convertPublicPath, "public", "", "snarkjs public.json with the signals of --proof")
+	convertProofCmd.Flags().StringVarP(&convertOutPath, "out", "o", "", "output path (default <file>.<format>.ptx)")
+	convertProofCmd.Flags().StringVar(&convertTSA, "tsa-url", "", "RFC 3161 Time-Stamping Authority timestamping the converted file")
+	convertProofCmd.Flags().StringVar(&convertExportDir, "export-dir", "", "also write the snarkjs proof.json and public.json to this directory (with --to snarkjs)")
+	convertProofCmd.Flags().StringVar(&convertVKPath, "vk", "", "native verification key to export as verification_key.json (with --export-dir)")
+	rootCmd.AddCommand(convertProofCmd)
+}
+
+// exportSnarkJS writes the snarkjs proof.json and public.json of proofData,
+// and the verification_key.json of the native key at vkPath if set, to dir
+func exportSnarkJS(proofData []byte, dir, vkPath string) error {
+	proofJSON, publicJSON, err := convert.ExportSnarkJS(proofData)
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{"proof.json": proofJSON, "public.json": publicJSON}
+	if vkPath != "" {
+		vkData, err := os.ReadFile(vkPath)
+		if err != nil {
+			return err
+		}
+		if files["verification_key.json"], err = convert.ExportSnarkJSKey(vkData); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
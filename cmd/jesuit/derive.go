@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ipfs"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deriveCommitment  string
+	deriveDomain      string
+	deriveMetadata    string
+	deriveRawMetadata bool
+	derivePTX         string
+	deriveWellKnown   bool
+	deriveAnchorKey   string
+	deriveIPFS        bool
+	deriveEthereum    bool
+	deriveFormat      uint32
+)
+
+var deriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Print the DNS TXT record that anchors a commitment",
+	Long: `Derive the TXT hostname and expected record value for a commitment, domain and metadata.
+
+Domain owners can use this to publish the anchor record before generating a token,
+or to debug "No matching TXT record found" failures. With --ptx the values are read
+from an existing PTX file, otherwise --format-version selects the hostname
+derivation of the token (1 for tokens created before format version 2).
+
+With --anchor-key it prints instead the fixed label record (_ptx.<domain>) that
+anchors every token signed by the key, which only needs --domain.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if deriveAnchorKey != "" {
+			printFixedAnchor()
+			return
+		}
+		record, metaRaw, err := resolveAnchorRecord(derivePTX, deriveCommitment, deriveDomain, deriveMetadata, deriveRawMetadata, deriveFormat)
+		if err != nil {
+			fmt.Fprintf(ui, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// A multi-domain PTX needs the record under each further domain
+		extra, err := additionalAnchorRecords(derivePTX, metaRaw)
+		if err != nil {
+			fmt.Fprintf(ui, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Hostname:  %s\n", record.Hostname)
+		fmt.Printf("TXT Value: %s\n", record.Value)
+		if verbose {
+			fmt.Printf("Metadata:  %s\n", metaRaw)
+		}
+		fmt.Println("\n--- Zone File Entry ---")
+		fmt.Printf("%s. 300 IN TXT \"%s\"\n", record.Hostname, record.Value)
+		for _, r := range extra {
+			fmt.Printf("%s. 300 IN TXT \"%s\"\n", r.Hostname, r.Value)
+		}
+		if deriveWellKnown {
+			fmt.Println("\n--- Well-Known Anchor ---")
+			fmt.Printf("URL:       %s\n", record.WellKnownURL())
+			fmt.Printf("Content:   %s\n", record.Value)
+		}
+		if deriveIPFS {
+			fmt.Println("\n--- IPFS Anchor ---")
+			fmt.Printf("CID:       %s\n", ipfs.CID(record.Document()))
+			fmt.Printf("Pin with:  printf '%s' | ipfs add --cid-version 1 -Q\n", strings.ReplaceAll(string(record.Document()), "\n", `\n`))
+		}
+		if deriveEthereum {
+			_, name, _ := strings.Cut(record.Hostname, ".")
+			fmt.Println("\n--- Ethereum Anchor ---")
+			fmt.Printf("ENS Name:  %s\n", name)
+			fmt.Printf("Text Key:  %s\n", record.ENSTextKey())
+			fmt.Printf("Text:      %s\n", record.Value)
+			fmt.Printf("Registry:  anchors(%s) = 0x%s\n", commitmentOf(derivePTX, deriveCommitment), record.Value)
+		}
+	},
+}
+
+// printFixedAnchor prints the fixed label record publishing --anchor-key
+// under --domain
+func printFixedAnchor() {
+	if deriveDomain == "" {
+		fmt.Fprintln(ui, "Error: --domain is required with --anchor-key")
+		os.Exit(1)
+	}
+	key, err := evidence.LoadSigningKey(deriveAnchorKey)
+	if err != nil {
+		fmt.Fprintf(ui, "Error: anchor key: %v\n", err)
+		os.Exit(1)
+	}
+	record, err := utils.FixedAnchorRecord(deriveDomain, key.Public().(ed25519.PublicKey))
+	if err != nil {
+		fmt.Fprintf(ui, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Hostname:  %s\n", record.Hostname)
+	fmt.Printf("TXT Value: %s\n", record.Value)
+	fmt.Println("\n--- Zone File Entry ---")
+	fmt.Printf("%s. 300 IN TXT \"%s\"\n", record.Hostname, record.Value)
+	if deriveWellKnown {
+		fmt.Println("\n--- Well-Known Anchor ---")
+		fmt.Printf("URL:       %s\n", record.WellKnownURL())
+		fmt.Printf("Content:   %s\n", record.Value)
+	}
+}
+
+// additionalAnchorRecords derives the anchor records of the additional
+// domains of a PTX file, if any
+func additionalAnchorRecords(ptxPath, metaRaw string) ([]*utils.AnchorRecord, error) {
+	if ptxPath == "" {
+		return nil, nil
+	}
+	ptxFile, err := ptxloader.LoadPTX(ptxPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading PTX file: %w", err)
+	}
+	var pd struct {
+		PublicSignals []string `json:"publicSignals"`
+	}
+	json.Unmarshal(ptxFile.GetProof().GetProofData(), &pd)
+
+	var records []*utils.AnchorRecord
+	for _, d := range ptxFile.GetDohDetails().GetAdditionalDomainNames() {
+		r, err := utils.DeriveAnchorRecord(pd.PublicSignals[1], d, metaRaw, utils.FormatHostnameVersion(ptxFile.Version()))
+		if err != nil {
+			return nil, fmt.Errorf("deriving anchor for %s: %w", d, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// commitmentOf returns the commitment of the PTX file at ptxPath, or
+// commitment when no file is given
+func commitmentOf(ptxPath, commitment string) string {
+	if ptxPath == "" {
+		return commitment
+	}
+	ptxFile, err := ptxloader.LoadPTX(ptxPath)
+	if err != nil {
+		return commitment
+	}
+	var pd struct {
+		PublicSignals []string `json:"publicSignals"`
+	}
+	if err := json.Unmarshal(ptxFile.GetProof().GetProofData(), &pd); err != nil || len(pd.PublicSignals) < 2 {
+		return commitment
+	}
+	return pd.PublicSignals[1]
+}
+
+// resolveAnchorRecord derives the anchor record either from a PTX file or from
+// an explicit commitment, domain and metadata of a token in the given PTX
+// format version. It also returns the metadata string the record value was
+// computed from.
+func resolveAnchorRecord(ptxPath, commitment, domain, metaRaw string, rawMetadata bool, version uint32) (*utils.AnchorRecord, string, error) {
+	if ptxPath != "" {
+		ptxFile, err := ptxloader.LoadPTX(ptxPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("loading PTX file: %w", err)
+		}
+		var pd struct {
+			PublicSignals []string `json:"publicSignals"`
+		}
+		if err := json.Unmarshal(ptxFile.GetProof().GetProofData(), &pd); err != nil || len(pd.PublicSignals) < 2 {
+			return nil, "", errors.New("PTX proof does not carry a commitment")
+		}
+		commitment = pd.PublicSignals[1]
+		domain = ptxFile.GetDohDetails().GetDomainName()
+		version = ptxFile.Version()
+		if ptxFile.GetDohDetails().GetLabelMode() == ptx.LabelMode_LABEL_FIXED {
+			return nil, "", fmt.Errorf("PTX is anchored at the fixed label %s.%s, derive its record with --anchor-key", utils.FixedAnchorLabel, domain)
+		}
+		metaRaw, err = verifier.SignedMetadata(ptxFile)
+		if err != nil {
+			return nil, "", err
+		}
+	} else {
+		if commitment == "" || domain == "" {
+			return nil, "", errors.New("--commitment and --domain are required (or use --ptx)")
+		}
+		if !rawMetadata {
+			// Serialize the metadata exactly as the prover does
+			metadata := make(map[string]interface{})
+			if metaRaw != "" {
+				if err := json.Unmarshal([]byte(metaRaw), &metadata); err != nil {
+					return nil, "", fmt.Errorf("invalid metadata JSON: %w", err)
+				}
+			}
+			metaBytes, err := crypto.MarshalCanonical(metadata)
+			if err != nil {
+				return nil, "", err
+			}
+			metaRaw = string(metaBytes)
+		}
+	}
+
+	if version < ptxloader.MinFormatVersion || version > ptxloader.FormatVersion {
+		return nil, "", fmt.Errorf("unsupported PTX format version %d", version)
+	}
+	record, err := utils.DeriveAnchorRecord(commitment, domain, metaRaw, utils.FormatHostnameVersion(version))
+	if err != nil {
+		return nil, "", fmt.Errorf("deriving anchor: %w", err)
+	}
+	return record, metaRaw, nil
+}
+
+func init() {
+	rootCmd.AddCommand(deriveCmd)
+
+	deriveCmd.Flags().StringVar(&deriveCommitment, "commitment", "", "Commitment (decimal string)")
+	deriveCmd.Flags().StringVar(&deriveDomain, "domain", "", "Anchor domain name")
+	deriveCmd.Flags().StringVar(&deriveMetadata, "metadata", "", "Metadata JSON string")
+	deriveCmd.Flags().BoolVar(&deriveRawMetadata, "raw-metadata", false, "Hash --metadata as given instead of re-serializing it like the prover")
+	deriveCmd.Flags().StringVar(&derivePTX, "ptx", "", "Read commitment, domain and metadata from a PTX file")
+	deriveCmd.Flags().BoolVar(&deriveWellKnown, "well-known", false, "Also print the HTTPS URL and content of the well-known anchor")
+	deriveCmd.Flags().BoolVar(&deriveIPFS, "ipfs", false, "Also print the CID of the IPFS anchor document and how to pin it")
+	deriveCmd.Flags().BoolVar(&deriveEthereum, "ethereum", false, "Also print the ENS text record and registry entry of the Ethereum anchor")
+	deriveCmd.Flags().Uint32Var(&deriveFormat, "format-version", ptxloader.FormatVersion, "PTX format version of the token, which selects the hostname derivation (without --ptx)")
+	deriveCmd.Flags().StringVar(&deriveAnchorKey, "anchor-key", "", "Print the fixed label record publishing this ed25519 anchor key (PEM) under --domain")
+}
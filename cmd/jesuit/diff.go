@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxdiff"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffAll  bool
+	diffJSON bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <a.ptx> <b.ptx>",
+	Short: "Compare two PTX files and the values derived from them",
+	Long: `Compare the headers, trust methods, anchors, signed metadata (path by path),
+public signals and verification key IDs of two PTX files, and the values a
+verifier derives from each: normalized domain, fqdn hash, metadata hash limbs,
+anchor hostname and value. derived.signalMismatches lists the public signals
+of each file that do not match its own derived values, which is where a proof
+fails its semantic checks.
+
+Like diff(1), the exit code is 0 if the files match, 1 if they differ and 2
+on errors.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := os.ReadFile(args[0])
+		if err == nil {
+			var b []byte
+			if b, err = os.ReadFile(args[1]); err == nil {
+				var r *ptxdiff.Report
+				if r, err = ptxdiff.Compare(a, b); err == nil {
+					printDiff(r, args[0], args[1])
+					if !r.Equal() {
+						os.Exit(1)
+					}
+					return
+				}
+			}
+		}
+		printError(err.Error())
+		os.Exit(2)
+	},
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffAll, "all", false, "also list the fields that match")
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "print every compared field as JSON on stdout")
+	diffCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return []string{"ptx"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	rootCmd.AddCommand(diffCmd)
+}
+
+func printDiff(r *ptxdiff.Report, nameA, nameB string) {
+	if diffJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(r)
+		return
+	}
+
+	printHeader("PTX Diff")
+	fmt.Fprintf(ui, "   a: %s\n   b: %s\n", nameA, nameB)
+	section := ""
+	for _, f := range r.Fields {
+		if f.Equal && !diffAll {
+			continue
+		}
+		if f.Section != section {
+			section = f.Section
+			printSection(section)
+		}
+		if f.Equal {
+			fmt.Fprintf(ui, "   %s %s: %s\n", color.GreenString(glyphOK), f.Name, f.A)
+			continue
+		}
+		fmt.Fprintf(ui, "   %s %s\n", color.RedString(glyphFail), f.Name)
+		fmt.Fprintf(ui, "      a: %s\n", color.RedString(f.A))
+		fmt.Fprintf(ui, "      b: %s\n", color.GreenString(f.B))
+	}
+
+	if n := len(r.Differences()); n > 0 {
+		fmt.Fprintf(ui, "\n%s  %d of %d fields differ\n", color.YellowString(glyphWarn), n, len(r.Fields))
+	} else {
+		printSuccess(fmt.Sprintf("All %d fields match", len(r.Fields)))
+	}
+}
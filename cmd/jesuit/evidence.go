@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/spf13/cobra"
+)
+
+var evidencePubKeyPath string
+
+var evidenceCmd = &cobra.Command{
+	Use:   "evidence",
+	Short: "Inspect verification evidence bundles",
+}
+
+var evidenceVerifyCmd = &cobra.Command{
+	Use:   "verify <bundle.json>",
+	Short: "Check the signature of an evidence bundle",
+	Long: `Check that an evidence bundle written by "verify --evidence" has not been altered.
+
+Without --pubkey the key embedded in the bundle is used, which only shows the bundle
+is intact. Pass the verifier's public key to also check who signed it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := evidence.ReadFile(args[0])
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		var trusted ed25519.PublicKey
+		if evidencePubKeyPath != "" {
+			trusted, err = evidence.LoadPublicKey(evidencePubKeyPath)
+			if err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if err := b.VerifySignature(trusted); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		printSuccess("Evidence signature valid")
+		fmt.Fprintf(ui, "   PTX SHA-256: %s\n", b.PTX.SHA256)
+		fmt.Fprintf(ui, "   Verified:    %s\n", b.FinishedAt.Format("2006-01-02 15:04:05 MST"))
+		fmt.Fprintf(ui, "   Verdict:     success=%v\n", b.Verdict.Success)
+	},
+}
+
+func init() {
+	evidenceVerifyCmd.Flags().StringVar(&evidencePubKeyPath, "pubkey", "", "PEM ed25519 public key the bundle must be signed with")
+	evidenceCmd.AddCommand(evidenceVerifyCmd)
+	rootCmd.AddCommand(evidenceCmd)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	genDocsDir    string
+	genDocsFormat string
+)
+
+var genDocsCmd = &cobra.Command{
+	Use:   "gen-docs",
+	Short: "Generate the man pages or Markdown reference of every command",
+	Long: `Write one page per command, with its usage and the description of every
+flag, to --dir: man pages in section 1 (jesuit-verify.1, ...) or Markdown
+(jesuit_verify.md, ...).
+
+  jesuit gen-docs --dir /usr/local/share/man/man1
+  jesuit gen-docs --format markdown --dir docs/cli
+
+The man page date is taken from SOURCE_DATE_EPOCH when set, for reproducible
+builds.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var gen func(*cobra.Command, string) error
+		switch genDocsFormat {
+		case "man":
+			gen = writeManPage
+		case "markdown", "md":
+			gen = writeMarkdownPage
+		default:
+			printError(fmt.Sprintf("Unknown format %q (expected man or markdown)", genDocsFormat))
+			os.Exit(1)
+		}
+		if err := os.MkdirAll(genDocsDir, 0o755); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		n := 0
+		err := walkCommands(rootCmd, func(c *cobra.Command) error {
+			n++
+			return gen(c, genDocsDir)
+		})
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		printSuccess(fmt.Sprintf("Wrote %d pages to %s", n, genDocsDir))
+	},
+}
+
+func init() {
+	genDocsCmd.Flags().StringVar(&genDocsDir, "dir", ".", "directory the pages are written to")
+	genDocsCmd.Flags().StringVar(&genDocsFormat, "format", "man", "page format: man or markdown")
+	genDocsCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"man", "markdown"}, cobra.ShellCompDirectiveNoFileComp))
+	genDocsCmd.MarkFlagDirname("dir")
+	rootCmd.AddCommand(genDocsCmd)
+}
+
+// walkCommands calls fn on c and its documented subcommands, depth first
+func walkCommands(c *cobra.Command, fn func(*cobra.Command) error) error {
+	if err := fn(c); err != nil {
+		return err
+	}
+	for _, sub := range documented(c) {
+		if err := walkCommands(sub, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documented returns the subcommands of c that get a page, leaving out help
+// and hidden commands
+func documented(c *cobra.Command) []*cobra.Command {
+	var cmds []*cobra.Command
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() && !sub.IsAdditionalHelpTopicCommand() {
+			cmds = append(cmds, sub)
+		}
+	}
+	return cmds
+}
+
+// writeMarkdownPage writes the Markdown page of c, named after its command
+// path (jesuit_bundle_create.md)
+func writeMarkdownPage(c *cobra.Command, dir string) error {
+	c.InitDefaultHelpFlag()
+	name := c.CommandPath()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "## %s\n\n%s\n\n", name, c.Short)
+	if c.Long != "" {
+		fmt.Fprintf(&b, "### Synopsis\n\n%s\n\n", c.Long)
+	}
+	if c.Runnable() {
+		fmt.Fprintf(&b, "```\n%s\n```\n\n", c.UseLine())
+	}
+	if c.Example != "" {
+		fmt.Fprintf(&b, "### Examples\n\n```\n%s\n```\n\n", c.Example)
+	}
+	if flags := c.NonInheritedFlags(); flags.HasAvailableFlags() {
+		fmt.Fprintf(&b, "### Options\n\n```\n%s```\n\n", flags.FlagUsages())
+	}
+	if flags := c.InheritedFlags(); flags.HasAvailableFlags() {
+		fmt.Fprintf(&b, "### Options inherited from parent commands\n\n```\n%s```\n\n", flags.FlagUsages())
+	}
+
+	b.WriteString("### See also\n\n")
+	if p := c.Parent(); p != nil {
+		fmt.Fprintf(&b, "* [%s](%s)\t - %s\n", p.CommandPath(), markdownFile(p), p.Short)
+	}
+	for _, sub := range documented(c) {
+		fmt.Fprintf(&b, "* [%s](%s)\t - %s\n", sub.CommandPath(), markdownFile(sub), sub.Short)
+	}
+	return os.WriteFile(filepath.Join(dir, markdownFile(c)), b.Bytes(), 0o644)
+}
+
+func markdownFile(c *cobra.Command) string {
+	return strings.ReplaceAll(c.CommandPath(), " ", "_") + ".md"
+}
+
+// writeManPage writes the section 1 man page of c, named after its command
+// path (jesuit-bundle-create.1)
+func writeManPage(c *cobra.Command, dir string) error {
+	c.InitDefaultHelpFlag()
+	name := manName(c)
+	date, err := manDate()
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, ".TH %q 1 %q \"Jesuit\" \"Jesuit Manual\"\n", strings.ToUpper(name), date.Format("Jan 2006"))
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", name, roffEscape(c.Short))
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n\\fB%s\\fP\n", roffEscape(c.UseLine()))
+	if c.Long != "" {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n.nf\n%s\n.fi\n", roffEscape(c.Long))
+	}
+	if c.Example != "" {
+		fmt.Fprintf(&b, ".SH EXAMPLES\n.nf\n%s\n.fi\n", roffEscape(c.Example))
+	}
+	manFlags(&b, "OPTIONS", c.NonInheritedFlags())
+	manFlags(&b, "OPTIONS INHERITED FROM PARENT COMMANDS", c.InheritedFlags())
+
+	var also []string
+	if p := c.Parent(); p != nil {
+		also = append(also, `\fB`+manName(p)+`\fP(1)`)
+	}
+	for _, sub := range documented(c) {
+		also = append(also, `\fB`+manName(sub)+`\fP(1)`)
+	}
+	if len(also) > 0 {
+		fmt.Fprintf(&b, ".SH SEE ALSO\n%s\n", strings.Join(also, ", "))
+	}
+	return os.WriteFile(filepath.Join(dir, name+".1"), b.Bytes(), 0o644)
+}
+
+// manFlags writes the flags of a man page section, one tagged paragraph each
+func manFlags(b *bytes.Buffer, section string, flags *pflag.FlagSet) {
+	if !flags.HasAvailableFlags() {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", section)
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		b.WriteString(".TP\n")
+		if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+			fmt.Fprintf(b, `\fB\-%s\fP, `, f.Shorthand)
+		}
+		fmt.Fprintf(b, `\fB\-\-%s\fP`, roffEscape(f.Name))
+		varname, usage := pflag.UnquoteUsage(f)
+		if varname != "" {
+			fmt.Fprintf(b, ` \fI%s\fP`, roffEscape(varname))
+		}
+		b.WriteString("\n" + roffEscape(usage))
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "[]" && f.DefValue != "0" {
+			fmt.Fprintf(b, " (default %s)", roffEscape(f.DefValue))
+		}
+		b.WriteString("\n")
+	})
+}
+
+func manName(c *cobra.Command) string {
+	return strings.ReplaceAll(c.CommandPath(), " ", "-")
+}
+
+// manDate returns SOURCE_DATE_EPOCH, or the current time
+func manDate() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now().UTC(), nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH: %w", err)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// roffEscape escapes backslashes and the control characters starting a line
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
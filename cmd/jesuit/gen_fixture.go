@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixtureSeed        int64
+	fixtureStyle       string
+	fixtureDomain      string
+	fixtureMetadata    string
+	fixtureTrustMethod string
+	fixtureOutDir      string
+)
+
+var genFixtureCmd = &cobra.Command{
+	Use:   "gen-fixture",
+	Short: "Generate a deterministic PTX test fixture",
+	Long: `Generate a valid PTX file, its verification key and the DNS answer it is
+anchored by, all derived from --seed. The same seed always produces the same files.
+
+Files written to --out-dir:
+  token.ptx               the PTX file
+  native.vk               gnark verification key
+  verification_key.json   snarkjs verification key (--style snarkjs only)
+  dns.json                {"<anchor hostname>": ["<TXT value>"]}
+  inputs.json             circuit inputs, including the private nullifier and secret
+
+The keys come from an insecure setup derived from the seed. Use them for tests only.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var metadata map[string]interface{}
+		if fixtureMetadata != "" {
+			if err := json.Unmarshal([]byte(fixtureMetadata), &metadata); err != nil {
+				printError("Invalid metadata JSON: " + err.Error())
+				os.Exit(1)
+			}
+		}
+
+		trustMethod, err := ptx.ParseTrustMethod(fixtureTrustMethod)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		f, err := fixture.Generate(fixture.Options{
+			Seed:        fixtureSeed,
+			Style:       fixture.Style(fixtureStyle),
+			Domain:      fixtureDomain,
+			Metadata:    metadata,
+			TrustMethod: int(trustMethod),
+		})
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		if err := f.WriteDir(fixtureOutDir); err != nil {
+			printError("Failed to write fixture: " + err.Error())
+			os.Exit(1)
+		}
+
+		printSuccess(fmt.Sprintf("Fixture written to %s", filepath.Clean(fixtureOutDir)))
+		fmt.Fprintf(ui, "   Anchor: %s TXT %q\n", f.Anchor.Hostname, f.Anchor.Value)
+	},
+}
+
+func init() {
+	genFixtureCmd.Flags().Int64Var(&fixtureSeed, "seed", 1, "seed all keys, secrets and proof randomness are derived from")
+	genFixtureCmd.Flags().StringVar(&fixtureStyle, "style", string(fixture.StyleNative), "proof encoding: native (gnark) or snarkjs")
+	genFixtureCmd.Flags().StringVar(&fixtureDomain, "domain", "example.com", "domain of interest")
+	genFixtureCmd.Flags().StringVar(&fixtureMetadata, "metadata", "", `metadata JSON (default {"role":"validator"})`)
+	genFixtureCmd.Flags().StringVar(&fixtureTrustMethod, "trust-method", "doh", "trust method: doh, gist, well-known, ipfs or ethereum")
+	genFixtureCmd.Flags().StringVarP(&fixtureOutDir, "out-dir", "o", "fixture", "output directory")
+	rootCmd.AddCommand(genFixtureCmd)
+}
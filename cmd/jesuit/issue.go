@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dnsprovider"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/issuer"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/renewd"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	issueDomain      string
+	issueMetadata    string
+	issueTrustMethod string
+	issueKeyID       string
+	issueExpiresIn   time.Duration
+	issueNullifier   string
+	issueSecret      string
+	issueHex         bool
+	issueSecretsFile string
+	issueRequestPath string
+	issueResponse    string
+	issueIssuerURL   string
+	issueToken       string
+	issueOut         string
+	issueAddr        string
+	issuePolicy      string
+	issueAnchorKey   string
+)
+
+var issueCmd = &cobra.Command{
+	Use:   "issue",
+	Short: "Issue tokens through an issuer without revealing the holder's secrets",
+	Long: `Split issuance between the holder of the secrets and the issuer owning the
+anchor domain, like a certificate signing request:
+
+  1. The holder runs "issue request": it computes the commitment of the
+     token over the requested metadata and sends it to the issuer (or writes
+     the request to a file). The nullifier and secret never leave the holder.
+  2. The issuer, running "issue serve", checks the request against its
+     policy and anchors the commitment: it signs it with its anchor key
+     (fixed label _ptx.<domain>) or publishes the DNS record.
+  3. The holder runs "issue assemble" to prove the token and write the PTX.`,
+}
+
+var issueRequestCmd = &cobra.Command{
+	Use:   "request",
+	Short: "Create an issuance request, and send it with --issuer",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tm, err := ptx.ParseTrustMethod(issueTrustMethod)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		metadata := make(map[string]interface{})
+		if issueMetadata != "" {
+			if err := json.Unmarshal([]byte(issueMetadata), &metadata); err != nil {
+				printError("Invalid metadata JSON: " + err.Error())
+				os.Exit(1)
+			}
+		}
+		if issueExpiresIn > 0 {
+			metadata["expiration_timestamp"] = time.Now().Add(issueExpiresIn).Unix()
+		}
+		if err := metaschema.Stamp(metadata); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		nullifier, secret, err := issueSecrets()
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		if nullifier == "" {
+			n, _ := crypto.GenerateSecureRandomBigInt()
+			s, _ := crypto.GenerateSecureRandomBigInt()
+			nullifier, secret = n.String(), s.String()
+			path := strings.TrimSuffix(issueRequestPath, ".bin") + renewd.SecretsSuffix
+			data, _ := json.MarshalIndent(map[string]string{"nullifier": nullifier, "secret": secret}, "", "  ")
+			if err := ioutil.WriteFile(path, append(data, '\n'), 0600); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			fmt.Fprintf(ui, "Generated a nullifier and secret, keep them safe: %s\n", path)
+		}
+
+		req, err := issuer.NewRequest(issueKeyID, issueDomain, metadata, nullifier, secret, int(tm))
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		writeProto(issueRequestPath, req)
+		printSuccess(fmt.Sprintf("Issuance request for %s written to %s", req.DomainName, issueRequestPath))
+		fmt.Fprintf(ui, "  Commitment: %s\n", req.Commitment)
+
+		if issueIssuerURL == "" {
+			return
+		}
+		token := issueToken
+		if token == "" {
+			token = os.Getenv("PTX_ISSUER_TOKEN")
+		}
+		client := &issuer.Client{URL: issueIssuerURL, Token: token}
+		resp, err := client.Request(context.Background(), req)
+		if err != nil {
+			printError("Issuer did not approve the request: " + err.Error())
+			os.Exit(1)
+		}
+		writeProto(issueResponse, resp)
+		printSuccess(fmt.Sprintf("Approved by %s, response written to %s", issueIssuerURL, issueResponse))
+	},
+}
+
+var issueAssembleCmd = &cobra.Command{
+	Use:   "assemble",
+	Short: "Prove an approved issuance request and write the PTX file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var req ptx.IssuanceRequest
+		var resp ptx.IssuanceResponse
+		readProto(issueRequestPath, &req)
+		readProto(issueResponse, &resp)
+
+		nullifier, secret, err := issueSecrets()
+		if err == nil && nullifier == "" {
+			err = errors.New("give --nullifier and --secret, or --secrets-file")
+		}
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Fprintln(ui, "Generating proof...")
+		data, err := issuer.Assemble(context.Background(), &req, &resp, nullifier, secret, nil)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		if err := ioutil.WriteFile(issueOut, data, 0644); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		printSuccess(fmt.Sprintf("PTX file written to %s", issueOut))
+		if resp.LabelMode == ptx.LabelMode_LABEL_COMMITMENT && !resp.AnchorPublished {
+			printWarning("The issuer did not publish the anchor record; it must be published before the token is used")
+		}
+	},
+}
+
+var issueServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Approve issuance requests over HTTP",
+	Long: `Serve POST /v1/issue: the body is a binary IssuanceRequest and the reply an
+IssuanceResponse. Requests are checked against --policy. With --anchor-key,
+approved commitments are signed for the fixed label _ptx.<domain>, which must
+publish the key; otherwise the anchor record is published through --provider
+(same provider flags as publish-anchor), or left to be published out of band.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		policy, err := issuer.LoadPolicy(issuePolicy)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		iss := &issuer.Issuer{Policy: policy, TTL: publishTTL}
+		if issueAnchorKey != "" {
+			if iss.AnchorKey, err = evidence.LoadSigningKey(issueAnchorKey); err != nil {
+				printError("anchor key: " + err.Error())
+				os.Exit(1)
+			}
+		} else if publishProvider != "" {
+			if iss.Publisher, err = newDNSProvider(); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		}
+		token := issueToken
+		if token == "" {
+			token = os.Getenv("PTX_ISSUER_TOKEN")
+		}
+
+		httpSrv := &http.Server{
+			Addr:              issueAddr,
+			Handler:           iss.Handler(token),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			httpSrv.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Fprintf(ui, "%s  Issuing for %s on %s\n", color.BlueString(glyphInfo), strings.Join(policy.Domains, ", "), issueAddr)
+		if token == "" {
+			printWarning("No --token: anyone reaching the server can request tokens the policy allows")
+		}
+		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			printError(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+// issueSecrets returns the nullifier and secret of the flags or the secrets
+// file, empty if none were given
+func issueSecrets() (nullifier, secret string, err error) {
+	nullifier, secret = issueNullifier, issueSecret
+	if issueSecretsFile != "" {
+		data, err := ioutil.ReadFile(issueSecretsFile)
+		if err != nil {
+			return "", "", err
+		}
+		var s struct {
+			Nullifier string `json:"nullifier"`
+			Secret    string `json:"secret"`
+		}
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", "", fmt.Errorf("invalid secrets file: %w", err)
+		}
+		nullifier, secret = s.Nullifier, s.Secret
+	}
+	if (nullifier == "") != (secret == "") {
+		return "", "", errors.New("the nullifier and secret must be given together")
+	}
+	if nullifier != "" && issueHex {
+		if nullifier, err = prover.DecimalFromHex("nullifier", nullifier); err == nil {
+			secret, err = prover.DecimalFromHex("secret", secret)
+		}
+	}
+	return nullifier, secret, err
+}
+
+func writeProto(path string, m proto.Message) {
+	data, err := proto.Marshal(m)
+	if err == nil {
+		err = ioutil.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+}
+
+func readProto(path string, m proto.Message) {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = proto.Unmarshal(data, m)
+	}
+	if err != nil {
+		printError(fmt.Sprintf("%s: %v", path, err))
+		os.Exit(1)
+	}
+}
+
+func init() {
+	issueRequestCmd.Flags().StringVar(&issueDomain, "domain", "", "domain of the issuer anchoring the token")
+	issueRequestCmd.MarkFlagRequired("domain")
+	issueRequestCmd.Flags().StringVar(&issueMetadata, "metadata", "", "requested metadata JSON")
+	issueRequestCmd.Flags().StringVar(&issueTrustMethod, "trustMethod", "doh", "Trust method: doh, gist, well-known, ipfs or ethereum (or its number)")
+	issueRequestCmd.Flags().StringVar(&issueKeyID, "key-id", signals.DefaultVerificationKeyID, "circuit the token will be proven with")
+	issueRequestCmd.Flags().DurationVar(&issueExpiresIn, "expires-in", 0, "request an expiration this far from now (sets expiration_timestamp)")
+	issueRequestCmd.Flags().StringVar(&issueIssuerURL, "issuer", "", "URL of the issuer to send the request to")
+	for _, c := range []*cobra.Command{issueRequestCmd, issueAssembleCmd} {
+		c.Flags().StringVar(&issueNullifier, "nullifier", "", "nullifier of the token (default: generated with the request)")
+		c.Flags().StringVar(&issueSecret, "secret", "", "secret of the token")
+		c.Flags().BoolVar(&issueHex, "hex", false, "the nullifier and secret are hexadecimal")
+		c.Flags().StringVar(&issueSecretsFile, "secrets-file", "", "read the nullifier and secret from this JSON file")
+		c.Flags().StringVar(&issueRequestPath, "request", "request.bin", "issuance request file")
+		c.Flags().StringVar(&issueResponse, "response", "response.bin", "issuance response file")
+	}
+	issueAssembleCmd.Flags().StringVar(&issueOut, "out", "output.ptx", "output path of the PTX file")
+
+	issueServeCmd.Flags().StringVar(&issueAddr, "addr", ":8081", "listen address")
+	issueServeCmd.Flags().StringVar(&issuePolicy, "policy", "", "JSON issuer policy (see README)")
+	issueServeCmd.MarkFlagRequired("policy")
+	issueServeCmd.Flags().StringVar(&issueAnchorKey, "anchor-key", "", "ed25519 private key (PEM) published at _ptx.<domain>, signing approved commitments")
+	issueServeCmd.Flags().StringVar(&publishProvider, "provider", "", "DNS provider publishing the anchor records: cloudflare, route53 or rfc2136")
+	issueServeCmd.Flags().IntVar(&publishTTL, "ttl", dnsprovider.DefaultTTL, "TTL of the TXT records in seconds")
+	issueServeCmd.Flags().StringVar(&publishCFZoneID, "cf-zone-id", "", "Cloudflare zone ID")
+	issueServeCmd.Flags().StringVar(&publishCFToken, "cf-token", "", "Cloudflare API token (default $CLOUDFLARE_API_TOKEN)")
+	issueServeCmd.Flags().StringVar(&publishR53ZoneID, "route53-zone-id", "", "Route 53 hosted zone ID")
+	issueServeCmd.Flags().StringVar(&publishNSServer, "rfc2136-server", "", "Authoritative server for RFC 2136 updates (host[:port])")
+	issueServeCmd.Flags().StringVar(&publishNSZone, "rfc2136-zone", "", "Zone for RFC 2136 updates")
+	issueServeCmd.Flags().StringVar(&publishNSKeyFile, "rfc2136-key-file", "", "TSIG key file passed to nsupdate -k")
+	for _, c := range []*cobra.Command{issueRequestCmd, issueServeCmd} {
+		c.Flags().StringVar(&issueToken, "token", "", "bearer token authenticating holders to the issuer (default $PTX_ISSUER_TOKEN)")
+	}
+
+	issueCmd.AddCommand(issueRequestCmd, issueAssembleCmd, issueServeCmd)
+	rootCmd.AddCommand(issueCmd)
+}
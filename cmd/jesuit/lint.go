@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxlint"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintJSON   bool
+	lintFailOn string
+)
+
+// lintResult is the --json report of one file
+type lintResult struct {
+	File string `json:"file"`
+	*ptxlint.Report
+	Error string `json:"error,omitempty"`
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <file.ptx>...",
+	Short: "Check PTX files for common issuance mistakes",
+	Long: `Check PTX files statically, without DNS lookups or proof verification:
+missing expiration or nonce, oversized or non-canonical metadata, unknown
+trust method or verification key ID, public signals that do not fit the
+key's layout or exceed the BN254 field, and more. Each finding has a stable
+check ID and a severity (error, warning or info).
+
+The exit code is 0 if no finding reaches --fail-on, 1 if one does and 2 if a
+file could not be read or parsed.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		threshold, err := ptxlint.ParseSeverity(lintFailOn)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(2)
+		}
+
+		results := make([]lintResult, 0, len(args))
+		failed, broken := false, false
+		for _, path := range args {
+			res := lintResult{File: path}
+			data, err := os.ReadFile(path)
+			if err == nil {
+				res.Report, err = ptxlint.Lint(data)
+			}
+			if err != nil {
+				res.Error = err.Error()
+				broken = true
+			} else if res.Fails(threshold) {
+				failed = true
+			}
+			results = append(results, res)
+		}
+
+		if lintJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(results)
+		} else {
+			printLint(results)
+		}
+		switch {
+		case broken:
+			os.Exit(2)
+		case failed:
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintJSON, "json", false, "print the findings of every file as JSON on stdout")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "lowest severity that fails the lint: error, warning or info")
+	lintCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"ptx"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	rootCmd.AddCommand(lintCmd)
+}
+
+func printLint(results []lintResult) {
+	printHeader("PTX Lint")
+	for _, res := range results {
+		printSection(res.File)
+		if res.Error != "" {
+			fmt.Fprintf(ui, "   %s %s\n", color.RedString(glyphFail), res.Error)
+			continue
+		}
+		if len(res.Findings) == 0 {
+			fmt.Fprintf(ui, "   %s no findings\n", color.GreenString(glyphOK))
+			continue
+		}
+		for _, f := range res.Findings {
+			glyph := color.BlueString(glyphInfo)
+			switch f.Severity {
+			case ptxlint.SeverityError:
+				glyph = color.RedString(glyphFail)
+			case ptxlint.SeverityWarning:
+				glyph = color.YellowString(glyphWarn)
+			}
+			fmt.Fprintf(ui, "   %s %s: %s\n", glyph, f.Check, f.Message)
+		}
+		fmt.Fprintf(ui, "   %d error(s), %d warning(s)\n", res.Errors, res.Warnings)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark/logger"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/rs/zerolog"
+)
+
+// Commands write human-readable progress and reports to ui (stderr) and
+// machine-readable data, such as JSON results, CSV and anchor records, to
+// stdout, so scripts can read stdout without scraping decorated sections.
+var ui io.Writer = os.Stderr
+
+var (
+	// quiet discards the human-readable output of verify and prove, leaving
+	// the exit code and any JSON output
+	quiet bool
+	// noColor disables ANSI colors
+	noColor bool
+	// asciiOutput replaces symbols and box drawing with ASCII
+	asciiOutput bool
+)
+
+// Symbols of the human-readable output, replaced by setupOutput in ASCII mode
+var (
+	glyphOK   = "✔"
+	glyphFail = "✖"
+	glyphWarn = "⚠"
+	glyphInfo = "ℹ"
+	glyphWait = "⏳"
+	glyphDone = "✓"
+	glyphRule = "─"
+	glyphPM   = "±"
+	glyphSD   = "σ"
+)
+
+// setupOutput applies the output flags once they are parsed. Colors and
+// Unicode symbols are also dropped when stderr is not a terminal (files, CI
+// logs), and symbols when the locale is not UTF-8. gnark logs to stdout by
+// default, so its log follows ui.
+func setupOutput() {
+	tty := isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd())
+	if noColor || !tty || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		color.NoColor = true
+	}
+	if asciiOutput || !tty || !utf8Locale() {
+		glyphOK, glyphFail, glyphWarn, glyphInfo = "+", "x", "!", "i"
+		glyphWait, glyphDone, glyphRule = "*", "+", "-"
+		glyphPM, glyphSD = "+/-", "sd"
+	}
+	color.Output = ui
+
+	if quiet {
+		ui = io.Discard
+		color.Output = ui
+		logger.Disable()
+		return
+	}
+	logger.SetOutput(zerolog.ConsoleWriter{Out: ui, TimeFormat: "15:04:05", NoColor: color.NoColor})
+}
+
+// utf8Locale reports whether the locale, if any is set, uses UTF-8
+func utf8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return true
+}
@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/confidential"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
 	"github.com/spf13/cobra"
 )
 
@@ -19,14 +31,45 @@ var (
 	metaHex       string
 	nullifier     string
 	secret        string
+	secretsHex    bool
 	proofFile     string
 	outFile       string
 	trustMethod   int
+	trustName     string
 	zkeyPath      string
 	wasmPath      string
 	r1csPath      string
 	doBenchmark   bool
 	benchmarkRuns int
+	expiresIn     time.Duration
+
+	proverBackend string
+	proverTimeout time.Duration
+	rapidsnarkBin string
+	witnessBin    string
+	proverURL     string
+	proverToken   string
+	proveGPU      bool
+	compactProof  bool
+	externalSigs  bool
+	proveKeyID    string
+	proveAnchors  []string
+	anchorPolicy  string
+	challenge     string
+	proveTSA      string
+	proveDomains  []string
+	fixedLabels   []string
+	anchorKeyPath string
+	proveFormat   uint32
+	ethRegistry   string
+	proveOutput   string
+	encryptClaims []string
+	encryptTo     []string
+
+	batchFile         string
+	batchParallelism  int
+	batchOutDir       string
+	batchManifestPath string
 )
 
 var proveCmd = &cobra.Command{
@@ -34,8 +77,13 @@ var proveCmd = &cobra.Command{
 	Short: "Generate proof inputs or a PTX file",
 	Long:  `Generate the necessary inputs for ZK-SNARK proof generation, or create a final .ptx file if a proof is provided.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if batchFile != "" {
+			runProveBatch()
+			return
+		}
+
 		if domain == "" && fqdn == "" {
-			fmt.Println("Error: --domain or --fqdn is required")
+			fmt.Fprintln(ui, "Error: --domain or --fqdn is required")
 			os.Exit(1)
 		}
 
@@ -43,42 +91,120 @@ var proveCmd = &cobra.Command{
 			domain = fqdn
 		}
 
+		tm, err := ptx.ParseTrustMethod(trustName)
+		if err != nil {
+			fmt.Fprintf(ui, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		trustMethod = int(tm)
+
 		// 1. Parse Metadata
 		var metadata map[string]interface{}
 		if metaHex != "" {
 			decoded, err := hex.DecodeString(metaHex)
 			if err != nil {
-				fmt.Printf("Error: Invalid hex-encoded metadata: %v\n", err)
+				fmt.Fprintf(ui, "Error: Invalid hex-encoded metadata: %v\n", err)
 				os.Exit(1)
 			}
 			metadataStr = string(decoded)
 		}
 		if metadataStr != "" {
 			if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
-				fmt.Printf("Error: Invalid metadata JSON: %v\n", err)
+				fmt.Fprintf(ui, "Error: Invalid metadata JSON: %v\n", err)
 				os.Exit(1)
 			}
 		} else {
 			metadata = make(map[string]interface{})
 		}
+		if len(proveDomains) > 0 {
+			// Bound by the proof through the metadata; CreatePtxFile mirrors
+			// them into the DoH anchor
+			metadata["additional_domains"] = proveDomains
+		}
+		if expiresIn > 0 {
+			// Stored in the metadata so the expiration is bound by the proof;
+			// CreatePtxFile mirrors it into the typed expires_at field
+			metadata["expiration_timestamp"] = time.Now().Add(expiresIn).Unix()
+		}
+		if err := metaschema.Stamp(metadata); err != nil {
+			fmt.Fprintf(ui, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(encryptClaims) > 0 {
+			// Sealed before the inputs are generated, so the proof binds the
+			// ciphertext
+			if err := sealClaims(metadata); err != nil {
+				fmt.Fprintf(ui, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if proveOutput != "text" && proveOutput != "json" {
+			fmt.Fprintf(ui, "Error: unknown output format %q (want text or json)\n", proveOutput)
+			os.Exit(1)
+		}
 
 		// 2. Handle Secrets
-		if nullifier == "" || secret == "" {
-			fmt.Println("No nullifier or secret provided. Generating secure random values...")
+		var result proveResult
+		if (nullifier == "") != (secret == "") {
+			fmt.Fprintln(ui, "Error: --nullifier and --secret must be given together")
+			os.Exit(1)
+		}
+		if nullifier != "" && secretsHex {
+			if nullifier, err = prover.DecimalFromHex("nullifier", nullifier); err == nil {
+				secret, err = prover.DecimalFromHex("secret", secret)
+			}
+			if err != nil {
+				fmt.Fprintf(ui, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		for _, in := range []struct{ name, value string }{{"nullifier", nullifier}, {"secret", secret}} {
+			if in.value == "" {
+				continue
+			}
+			if err := prover.ValidateSecretInput(in.name, in.value); err != nil {
+				if !secretsHex && strings.ContainsAny(strings.ToLower(in.value), "abcdefx") {
+					err = fmt.Errorf("%w (use --hex for hexadecimal values)", err)
+				}
+				fmt.Fprintf(ui, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if nullifier == "" {
+			fmt.Fprintln(ui, "No nullifier or secret provided. Generating secure random values...")
 			n, _ := crypto.GenerateSecureRandomBigInt()
 			s, _ := crypto.GenerateSecureRandomBigInt()
 			nullifier = n.String()
 			secret = s.String()
-			fmt.Printf("Nullifier: %s\n", nullifier)
-			fmt.Printf("Secret:    %s\n", secret)
+			fmt.Fprintf(ui, "Nullifier: %s\n", nullifier)
+			fmt.Fprintf(ui, "Secret:    %s\n", secret)
+			result.Nullifier, result.Secret = nullifier, secret
 		}
 
 		p := prover.NewProver()
+		p.GPU = proveGPU
+		p.KeyID = proveKeyID
+		if err := configureAnchors(p); err != nil {
+			fmt.Fprintf(ui, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if proveTSA != "" {
+			p.TSA = &tsa.Client{URL: proveTSA}
+		}
+		if proveGPU && !prover.HasGPU {
+			fmt.Fprintln(ui, "WARNING: --gpu requires a build with -tags icicle; proving on the CPU")
+		}
 
-		// 3. Generate Inputs
-		inputs, err := p.GenerateCircuitInputs(domain, metadata, nullifier, secret, trustMethod)
+		// 3. Generate Inputs, answering the verifier's challenge if given
+		var inputs *prover.CircuitInputs
+		if challenge != "" {
+			inputs, metadata, err = p.GenerateCircuitInputsWithChallenge(domain, metadata, nullifier, secret, trustMethod, challenge)
+		} else {
+			inputs, err = p.GenerateCircuitInputs(domain, metadata, nullifier, secret, trustMethod)
+		}
 		if err != nil {
-			fmt.Printf("Error generating circuit inputs: %v\n", err)
+			fmt.Fprintf(ui, "Error generating circuit inputs: %v\n", err)
 			os.Exit(1)
 		}
 
@@ -86,77 +212,124 @@ var proveCmd = &cobra.Command{
 		// commitment, _ := new(fr.Element).SetString(inputs.Commitment)
 		// Wait, I'll just print the inputs JSON
 		inputsJSON, _ := json.MarshalIndent(inputs, "", "  ")
-		fmt.Println("\n--- Circuit Inputs (for snarkjs) ---")
-		fmt.Println(string(inputsJSON))
+		fmt.Fprintln(ui, "\n--- Circuit Inputs (for snarkjs) ---")
+		fmt.Fprintln(ui, string(inputsJSON))
 
 		// 4. Handle Proof and PTX creation
 		var proofData []byte
 
-		if zkeyPath != "" && wasmPath != "" {
-			fmt.Println("Generating ZK Proof using gnark (snarkjs wrapper)...")
-			proofData, err = p.GenerateProof(inputs, wasmPath, zkeyPath)
+		backendName := proverBackend
+		if backendName == "" && zkeyPath != "" && wasmPath != "" {
+			backendName = "snarkjs"
+		}
+
+		switch {
+		case backendName != "" && backendName != "native":
+			backend, err := newProverBackend(backendName)
+			if err != nil {
+				fmt.Fprintf(ui, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(ui, "Generating ZK Proof using the %s backend...\n", backend.Name())
+			proofData, err = backend.Prove(context.Background(), inputs)
 			if err != nil {
-				fmt.Printf("Error generating proof: %v\n", err)
+				fmt.Fprintf(ui, "Error generating proof: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Println("Proof generated successfully!")
-		} else if proofFile != "" {
+			fmt.Fprintln(ui, "Proof generated successfully!")
+		case proofFile != "":
 			proofData, err = ioutil.ReadFile(proofFile)
 			if err != nil {
-				fmt.Printf("Error reading proof file: %v\n", err)
+				fmt.Fprintf(ui, "Error reading proof file: %v\n", err)
 				os.Exit(1)
 			}
-		} else {
-			// Default to Native Go
-			if doBenchmark {
-				fmt.Printf("Starting benchmarking (native Gnark) for %d runs...\n", benchmarkRuns)
-				var totalCompile, totalWitness, totalProve float64
-
-				for i := 0; i < benchmarkRuns; i++ {
-					res, pData, err := p.BenchmarkNative(inputs)
-					if err != nil {
-						fmt.Printf("Benchmark run %d failed: %v\n", i+1, err)
-						os.Exit(1)
-					}
-					totalCompile += res.CompileTimeMs
-					totalWitness += res.WitnessTimeMs
-					totalProve += res.ProveTimeMs
-					proofData = pData // Keep the last one
-					fmt.Printf("Run %d/%d completed\n", i+1, benchmarkRuns)
-				}
+		case doBenchmark:
+			fmt.Fprintf(ui, "Starting benchmarking (native Gnark) for %d runs...\n", benchmarkRuns)
+			var totalCompile, totalWitness, totalProve float64
+			var backend string
 
-				fmt.Println("\n--- Proving Benchmarks (Average) ---")
-				fmt.Printf("Circuit Compilation: %.2f ms\n", totalCompile/float64(benchmarkRuns))
-				fmt.Printf("Witness Generation:  %.2f ms\n", totalWitness/float64(benchmarkRuns))
-				fmt.Printf("Proof Generation:    %.2f ms\n", totalProve/float64(benchmarkRuns))
-				fmt.Printf("Total Time:          %.2f ms\n", (totalCompile+totalWitness+totalProve)/float64(benchmarkRuns))
-			} else {
-				fmt.Println("No external artifacts provided. Using native Gnark prover...")
-				proofData, err = p.GenerateProofNative(inputs)
+			for i := 0; i < benchmarkRuns; i++ {
+				res, pData, err := p.BenchmarkNative(inputs)
 				if err != nil {
-					fmt.Printf("Error generating native proof: %v\n", err)
+					fmt.Fprintf(ui, "Benchmark run %d failed: %v\n", i+1, err)
 					os.Exit(1)
 				}
-				fmt.Println("Native Proof generated successfully!")
+				totalCompile += res.CompileTimeMs
+				totalWitness += res.WitnessTimeMs
+				totalProve += res.ProveTimeMs
+				backend = res.Backend
+				proofData = pData // Keep the last one
+				fmt.Fprintf(ui, "Run %d/%d completed\n", i+1, benchmarkRuns)
+			}
+
+			fmt.Fprintln(ui, "\n--- Proving Benchmarks (Average) ---")
+			fmt.Fprintf(ui, "Proving Backend:     %s\n", backend)
+			fmt.Fprintf(ui, "Circuit Compilation: %.2f ms\n", totalCompile/float64(benchmarkRuns))
+			fmt.Fprintf(ui, "Witness Generation:  %.2f ms\n", totalWitness/float64(benchmarkRuns))
+			fmt.Fprintf(ui, "Proof Generation:    %.2f ms\n", totalProve/float64(benchmarkRuns))
+			fmt.Fprintf(ui, "Total Time:          %.2f ms\n", (totalCompile+totalWitness+totalProve)/float64(benchmarkRuns))
+		default:
+			fmt.Fprintln(ui, "No external artifacts provided. Using native Gnark prover...")
+			proofData, err = p.GenerateProofNative(inputs)
+			if err != nil {
+				fmt.Fprintf(ui, "Error generating native proof: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintln(ui, "Native Proof generated successfully!")
+		}
+
+		warnGPUFallback(ui, proveGPU)
+
+		if len(proofData) > 0 && compactProof {
+			proofData, err = proofdata.Compact(proofData)
+			if err != nil {
+				fmt.Fprintf(ui, "Error compacting proof: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if outFile == "" {
+			outFile = "output.ptx"
+		}
+		if len(proofData) > 0 && externalSigs {
+			var public []string
+			proofData, public, err = proofdata.WithoutSignals(proofData)
+			if err == nil {
+				var raw []byte
+				if raw, err = json.MarshalIndent(public, "", "  "); err == nil {
+					signalsFile := strings.TrimSuffix(outFile, ".ptx") + ".signals.json"
+					if err = ioutil.WriteFile(signalsFile, append(raw, '\n'), 0644); err == nil {
+						fmt.Fprintf(ui, "Public signals for the relying party: %s\n", signalsFile)
+					}
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(ui, "Error externalizing public signals: %v\n", err)
+				os.Exit(1)
 			}
 		}
 
 		if len(proofData) > 0 {
 			ptxData, err := p.CreatePtxFile(proofData, metadata, domain, trustMethod)
 			if err != nil {
-				fmt.Printf("Error creating PTX file: %v\n", err)
+				fmt.Fprintf(ui, "Error creating PTX file: %v\n", err)
 				os.Exit(1)
 			}
 			// ... (rest of writing file)
-			if outFile == "" {
-				outFile = "output.ptx"
-			}
-
 			if err := ioutil.WriteFile(outFile, ptxData, 0644); err != nil {
-				fmt.Printf("Error writing PTX file: %v\n", err)
+				fmt.Fprintf(ui, "Error writing PTX file: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Printf("\nSuccessfully generated PTX file: %s\n", outFile)
+			fmt.Fprintf(ui, "\nSuccessfully generated PTX file: %s\n", outFile)
+
+			if proveOutput == "json" {
+				result.Out = outFile
+				result.NullifierHash = inputs.NullifierHash
+				result.Commitment = inputs.Commitment
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				enc.Encode(result)
+			}
 		} else {
 			// Since we default to native, this else might not be reached unless error?
 			// But logic above covers all cases now.
@@ -164,6 +337,16 @@ var proveCmd = &cobra.Command{
 	},
 }
 
+// proveResult is the --output json result of prove. The nullifier and secret
+// are only included when prove generated them.
+type proveResult struct {
+	Out           string `json:"out"`
+	NullifierHash string `json:"nullifierHash"`
+	Commitment    string `json:"commitment"`
+	Nullifier     string `json:"nullifier,omitempty"`
+	Secret        string `json:"secret,omitempty"`
+}
+
 func init() {
 	rootCmd.AddCommand(proveCmd)
 
@@ -171,14 +354,152 @@ func init() {
 	proveCmd.Flags().StringVar(&fqdn, "fqdn", "", "Fully Qualified Domain Name (alias for --domain)")
 	proveCmd.Flags().StringVar(&metadataStr, "metadata", "", "Metadata JSON string")
 	proveCmd.Flags().StringVar(&metaHex, "metadataString", "", "Hex-encoded metadata JSON string")
-	proveCmd.Flags().StringVar(&nullifier, "nullifier", "", "Nullifier (decimal string)")
-	proveCmd.Flags().StringVar(&secret, "secret", "", "Secret (decimal string)")
+	proveCmd.Flags().StringVar(&nullifier, "nullifier", "", "Nullifier (decimal, non-zero, below the BN254 field modulus)")
+	proveCmd.Flags().StringVar(&secret, "secret", "", "Secret (decimal, non-zero, below the BN254 field modulus)")
+	proveCmd.Flags().BoolVar(&secretsHex, "hex", false, "--nullifier and --secret are hexadecimal (0x prefix optional)")
 	proveCmd.Flags().StringVar(&proofFile, "proof", "", "Path to snarkjs proof JSON file")
+	proveCmd.Flags().StringVar(&proveOutput, "output", "text", "result format: text, or json (the PTX path, commitment and any generated secrets on stdout)")
+	proveCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "print nothing but the --output json result; the exit code tells the outcome")
 	proveCmd.Flags().StringVar(&outFile, "out", "output.ptx", "Output path for the generated .ptx file")
-	proveCmd.Flags().IntVar(&trustMethod, "trustMethod", 1, "Trust method (1=DOH, 2=GIST)")
+	proveCmd.Flags().DurationVar(&expiresIn, "expires-in", 0, "Expire the PTX after this duration (sets expiration_timestamp)")
+	proveCmd.Flags().StringSliceVar(&encryptClaims, "encrypt-claim", nil, "Metadata claim to encrypt for the --encrypt-to verifiers (repeatable)")
+	proveCmd.Flags().StringSliceVar(&encryptTo, "encrypt-to", nil, "PEM X25519 public key of a verifier allowed to read the encrypted claims (repeatable)")
+	proveCmd.Flags().StringVar(&challenge, "challenge", "", "Challenge issued by the verifier (e.g. POST /v1/challenge), bound as the metadata nonce")
+	proveCmd.Flags().StringVar(&trustName, "trustMethod", "doh", "Trust method: doh, gist, well-known, ipfs or ethereum (or its number)")
 	proveCmd.Flags().StringVar(&zkeyPath, "zkey", "", "Path to .zkey file (optional, defaults to native Go prover)")
 	proveCmd.Flags().StringVar(&wasmPath, "wasm", "", "Path to .wasm file (optional, defaults to native Go prover)")
+	proveCmd.Flags().StringVar(&proverBackend, "backend", "", "Prover backend: native, snarkjs, rapidsnark or remote (default native, or snarkjs with --wasm and --zkey)")
+	proveCmd.Flags().DurationVar(&proverTimeout, "prover-timeout", prover.DefaultExecTimeout, "Kill each snarkjs or rapidsnark invocation after this duration")
+	proveCmd.Flags().StringVar(&rapidsnarkBin, "rapidsnark", "rapidsnark", "Path to the rapidsnark prover binary")
+	proveCmd.Flags().StringVar(&witnessBin, "witness-bin", "", "Circom C++ witness generator for rapidsnark (default: snarkjs with --wasm)")
+	proveCmd.Flags().StringVar(&proverURL, "prover-url", "", "URL of the remote prover service")
+	proveCmd.Flags().StringVar(&proverToken, "prover-token", "", "Bearer token for the remote prover (default $PTX_PROVER_TOKEN)")
 	proveCmd.Flags().StringVar(&r1csPath, "r1cs", "", "Path to .r1cs file (optional)")
 	proveCmd.Flags().BoolVar(&doBenchmark, "benchmark", false, "Enable benchmarking")
+	proveCmd.Flags().BoolVar(&proveGPU, "gpu", false, "Prove natively on the GPU via icicle (requires a build with -tags icicle), falling back to CPU")
+	proveCmd.Flags().BoolVar(&externalSigs, "external-signals", false, "Leave the public signals out of the PTX and write them to <out>.signals.json for the relying party (verify --signals)")
+	proveCmd.Flags().BoolVar(&compactProof, "compact-proof", false, "Store the proof base64url encoded with compressed points (native) or gzipped (snarkjs) for QR/NFC transports")
+	proveCmd.Flags().StringVar(&proveKeyID, "key-id", signals.DefaultVerificationKeyID, "Circuit to prove with: "+signals.DefaultVerificationKeyID+", "+signals.MetadataSHA256KeyID+" to hash the metadata in-circuit (native only, larger keys), or "+signals.ScopeBoundKeyID+" to bind the audience and scopes in the commitment (native only)")
+	proveCmd.Flags().StringSliceVar(&proveDomains, "additional-domain", nil, "Further domain of the issuer publishing the anchor record under its own name (repeatable)")
+	proveCmd.Flags().StringVar(&proveTSA, "tsa-url", "", "RFC 3161 Time-Stamping Authority timestamping the PTX file (e.g. https://freetsa.org/tsr)")
+	proveCmd.Flags().StringSliceVar(&proveAnchors, "anchor", nil, "Additional anchor publishing the DNS record on the same domain (well-known, ipfs or ethereum)")
+	proveCmd.Flags().StringVar(&ethRegistry, "eth-registry", "", "With --anchor ethereum, registry contract mapping the commitment to the metadata hash (default: ENS text record of the domain)")
+	proveCmd.Flags().StringVar(&anchorPolicy, "anchor-policy", "any", "With --anchor, whether any or all anchors must hold the record")
+	proveCmd.Flags().StringSliceVar(&fixedLabels, "fixed-label", nil, "Trust methods anchored at the fixed label _ptx.<domain> instead of a derived label (doh, well-known)")
+	proveCmd.Flags().StringVar(&anchorKeyPath, "anchor-key", "", "ed25519 private key (PEM) published at _ptx.<domain>, signing tokens with --fixed-label")
+	proveCmd.Flags().Uint32Var(&proveFormat, "format-version", ptxloader.FormatVersion, "PTX format version to write; 1 keeps the Base27 anchor label that verifiers predating version 2 look up")
+	proveCmd.Flags().StringVar(&batchFile, "input-file", "", "Prove every request in a JSONL file (- for stdin) natively and write a manifest (see README)")
+	proveCmd.Flags().StringVar(&batchFile, "batch-file", "", "Prove every request in a JSONL file natively")
+	proveCmd.Flags().MarkDeprecated("batch-file", "use --input-file")
+	proveCmd.Flags().StringVar(&batchManifestPath, "manifest", "", "Manifest of --input-file mapping each request to its PTX file, nullifier hash and DNS anchor record (default <out-dir>/manifest.json)")
+	proveCmd.Flags().IntVar(&batchParallelism, "parallelism", 0, "Concurrent proofs in --input-file mode (default: number of CPUs)")
+	proveCmd.Flags().StringVar(&batchOutDir, "out-dir", ".", "Directory for PTX files of batch requests without an \"out\" path")
 	proveCmd.Flags().IntVar(&benchmarkRuns, "benchmark-runs", 10, "Number of runs for benchmarking")
 }
+
+// configureAnchors sets the additional anchors, anchor policy, fixed label
+// anchors and format version of p from --anchor, --anchor-policy,
+// --fixed-label, --anchor-key and --format-version
+func configureAnchors(p *prover.Prover) error {
+	p.FormatVersion = proveFormat
+	for _, name := range proveAnchors {
+		m, err := ptx.ParseTrustMethod(name)
+		if err != nil {
+			return err
+		}
+		if m != ptx.TrustMethod_WELL_KNOWN && m != ptx.TrustMethod_IPFS && m != ptx.TrustMethod_ETHEREUM {
+			return fmt.Errorf("unsupported anchor %q (only well-known, ipfs and ethereum can be added to the DNS anchor)", name)
+		}
+		p.AdditionalAnchors = append(p.AdditionalAnchors, m)
+	}
+	p.EthereumRegistry = ethRegistry
+	switch strings.ToLower(anchorPolicy) {
+	case "any":
+		p.AnchorPolicy = ptx.AnchorPolicy_ANCHOR_POLICY_ANY
+	case "all":
+		p.AnchorPolicy = ptx.AnchorPolicy_ANCHOR_POLICY_ALL
+	default:
+		return fmt.Errorf("unknown anchor policy %q (want any or all)", anchorPolicy)
+	}
+
+	for _, name := range fixedLabels {
+		m, err := ptx.ParseTrustMethod(name)
+		if err != nil {
+			return err
+		}
+		if m != ptx.TrustMethod_DOH && m != ptx.TrustMethod_WELL_KNOWN {
+			return fmt.Errorf("unsupported fixed label anchor %q (want doh or well-known)", name)
+		}
+		p.FixedLabels = append(p.FixedLabels, m)
+	}
+	if len(p.FixedLabels) > 0 {
+		if anchorKeyPath == "" {
+			return fmt.Errorf("--fixed-label requires --anchor-key")
+		}
+		key, err := evidence.LoadSigningKey(anchorKeyPath)
+		if err != nil {
+			return fmt.Errorf("anchor key: %w", err)
+		}
+		p.AnchorKey = key
+	}
+	return nil
+}
+
+// sealClaims encrypts the --encrypt-claim claims of metadata for the
+// --encrypt-to keys
+func sealClaims(metadata map[string]interface{}) error {
+	var recipients []confidential.Recipient
+	for _, path := range encryptTo {
+		r, err := confidential.LoadRecipient(path)
+		if err != nil {
+			return fmt.Errorf("recipient key %s: %w", path, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return confidential.Seal(metadata, encryptClaims, recipients...)
+}
+
+// warnGPUFallback tells the user when --gpu was requested but a device error
+// sent proving back to the CPU
+func warnGPUFallback(w io.Writer, gpu bool) {
+	if err := prover.GPUError(); gpu && err != nil {
+		fmt.Fprintf(w, "WARNING: GPU proving failed, fell back to CPU: %v\n", err)
+	}
+}
+
+func newProverBackend(name string) (prover.ProverBackend, error) {
+	opts := prover.ExecOptions{Timeout: proverTimeout}
+	switch name {
+	case "native":
+		n := prover.NewNative()
+		n.GPU = proveGPU
+		return n, nil
+	case "snarkjs":
+		if wasmPath == "" || zkeyPath == "" {
+			return nil, fmt.Errorf("--wasm and --zkey are required for the snarkjs backend")
+		}
+		return prover.NewSnarkJS(wasmPath, zkeyPath, opts), nil
+	case "rapidsnark":
+		if zkeyPath == "" || (wasmPath == "" && witnessBin == "") {
+			return nil, fmt.Errorf("--zkey and either --wasm or --witness-bin are required for the rapidsnark backend")
+		}
+		r := prover.NewRapidsnark(rapidsnarkBin, wasmPath, zkeyPath, opts)
+		r.WitnessBinary = witnessBin
+		return r, nil
+	case "remote":
+		if proverURL == "" {
+			return nil, fmt.Errorf("--prover-url is required for the remote backend")
+		}
+		r := prover.NewRemote(proverURL)
+		token := proverToken
+		if token == "" {
+			token = os.Getenv("PTX_PROVER_TOKEN")
+		}
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("unknown prover backend %q", name)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/consensys/gnark/logger"
+)
+
+// batchRequest is one line of an --input-file
+type batchRequest struct {
+	// ID is copied to the result and manifest entry of the request
+	ID          string                 `json:"id,omitempty"`
+	Domain      string                 `json:"domain"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Nullifier   string                 `json:"nullifier,omitempty"`
+	Secret      string                 `json:"secret,omitempty"`
+	TrustMethod batchTrustMethod       `json:"trustMethod,omitempty"`
+	ExpiresIn   string                 `json:"expiresIn,omitempty"`
+	Out         string                 `json:"out,omitempty"`
+}
+
+// batchTrustMethod is a trust method given by name ("gist") or number
+type batchTrustMethod int
+
+func (m *batchTrustMethod) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	tm, err := ptx.ParseTrustMethod(fmt.Sprint(v))
+	if err != nil {
+		return err
+	}
+	*m = batchTrustMethod(tm)
+	return nil
+}
+
+// manifestEntry describes the outcome of one request in the manifest. It
+// leaves out the secrets, which are only printed on stdout.
+type manifestEntry struct {
+	Line          int    `json:"line"`
+	ID            string `json:"id,omitempty"`
+	Out           string `json:"out,omitempty"`
+	Domain        string `json:"domain,omitempty"`
+	NullifierHash string `json:"nullifierHash,omitempty"`
+	Commitment    string `json:"commitment,omitempty"`
+	// Hostname and TXTValue are the DNS anchor record to publish
+	Hostname string `json:"hostname,omitempty"`
+	TXTValue string `json:"txtValue,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// batchManifest is written to --manifest once every request is done
+type batchManifest struct {
+	Input   string          `json:"input"`
+	Created time.Time       `json:"created"`
+	Proved  int             `json:"proved"`
+	Failed  int             `json:"failed"`
+	Entries []manifestEntry `json:"entries"`
+}
+
+// batchResult is printed as one JSON line per request
+type batchResult struct {
+	manifestEntry
+	Nullifier string `json:"nullifier,omitempty"`
+	Secret    string `json:"secret,omitempty"`
+}
+
+// runProveBatch proves every request of --input-file in one process, prints
+// a JSON result line per request as soon as its PTX is written and finally
+// writes the manifest
+func runProveBatch() {
+	in := os.Stdin
+	if batchFile != "-" {
+		f, err := os.Open(batchFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+	if err := os.MkdirAll(batchOutDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	manifestPath := batchManifestPath
+	if manifestPath == "" {
+		manifestPath = filepath.Join(batchOutDir, "manifest.json")
+	}
+
+	// stdout carries the JSON result lines, keep gnark's log off it
+	logger.Disable()
+
+	p := prover.NewProver()
+	p.GPU = proveGPU
+	p.KeyID = proveKeyID
+	if err := configureAnchors(p); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if proveTSA != "" {
+		p.TSA = &tsa.Client{URL: proveTSA}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	var entries []manifestEntry
+	report := func(res batchResult) {
+		enc.Encode(res)
+		entries = append(entries, res.manifestEntry)
+	}
+
+	var reqs []batchRequest
+	var lines []int
+	var inputs []*prover.CircuitInputs
+	failed, invalid := 0, 0
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		req, ci, err := parseBatchRequest(p, scanner.Bytes(), n)
+		if err != nil {
+			report(batchResult{manifestEntry: manifestEntry{Line: n, ID: req.ID, Error: err.Error()}})
+			invalid++
+			failed++
+			continue
+		}
+		reqs = append(reqs, req)
+		lines = append(lines, n)
+		inputs = append(inputs, ci)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input file: %v\n", err)
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	results, err := p.GenerateProofs(inputs, batchParallelism)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for r := range results {
+		req, in := reqs[r.Index], inputs[r.Index]
+		res := batchResult{manifestEntry: manifestEntry{Line: lines[r.Index], ID: req.ID, Domain: req.Domain}}
+		data, err := r.ProofData, r.Err
+		if err == nil && compactProof {
+			data, err = proofdata.Compact(data)
+		}
+		var anchor *utils.AnchorRecord
+		if err == nil {
+			var ptxData []byte
+			ptxData, err = p.CreatePtxFile(data, req.Metadata, req.Domain, int(req.TrustMethod))
+			if err == nil {
+				anchor, err = verifier.DeriveAnchor(ptxData)
+			}
+			if err == nil {
+				res.Out = req.Out
+				err = os.WriteFile(req.Out, ptxData, 0644)
+			}
+		}
+		if err != nil {
+			res.Error = err.Error()
+			failed++
+		} else {
+			res.Nullifier, res.Secret, res.Commitment = in.Nullifier, in.Secret, in.Commitment
+			res.NullifierHash = in.NullifierHash
+			res.Hostname, res.TXTValue = anchor.Hostname, anchor.Value
+		}
+		report(res)
+	}
+
+	warnGPUFallback(os.Stderr, proveGPU)
+
+	slices.SortFunc(entries, func(a, b manifestEntry) int { return a.Line - b.Line })
+	total := len(lines) + invalid
+	manifest := batchManifest{
+		Input:   batchFile,
+		Created: time.Now().UTC(),
+		Proved:  total - failed,
+		Failed:  failed,
+		Entries: entries,
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(manifestPath, append(raw, '\n'), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Proved %d/%d requests in %v, manifest: %s\n", total-failed, total, time.Since(start).Round(time.Millisecond), manifestPath)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseBatchRequest decodes line n of the batch file, fills in defaults and
+// random secrets, and computes its circuit inputs
+func parseBatchRequest(p *prover.Prover, line []byte, n int) (batchRequest, *prover.CircuitInputs, error) {
+	var req batchRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return req, nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if req.Domain == "" {
+		return req, nil, fmt.Errorf("domain is required")
+	}
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]interface{})
+	}
+	if req.TrustMethod == 0 {
+		req.TrustMethod = 1
+	}
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			return req, nil, fmt.Errorf("invalid expiresIn: %w", err)
+		}
+		req.Metadata["expiration_timestamp"] = time.Now().Add(d).Unix()
+	}
+	if err := metaschema.Stamp(req.Metadata); err != nil {
+		return req, nil, err
+	}
+	if req.Out == "" {
+		req.Out = filepath.Join(batchOutDir, fmt.Sprintf("%d.ptx", n))
+	}
+	if req.Nullifier == "" || req.Secret == "" {
+		nb, err := crypto.GenerateSecureRandomBigInt()
+		if err != nil {
+			return req, nil, err
+		}
+		sb, err := crypto.GenerateSecureRandomBigInt()
+		if err != nil {
+			return req, nil, err
+		}
+		req.Nullifier, req.Secret = nb.String(), sb.String()
+	}
+
+	inputs, err := p.GenerateCircuitInputs(req.Domain, req.Metadata, req.Nullifier, req.Secret, int(req.TrustMethod))
+	return req, inputs, err
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dnsprovider"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/spf13/cobra"
+)
+
+var (
+	publishProvider     string
+	publishCommitment   string
+	publishDomain       string
+	publishMetadata     string
+	publishTTL          int
+	publishWait         bool
+	publishWaitTimeout  time.Duration
+	publishPollInterval time.Duration
+	publishCFZoneID     string
+	publishCFToken      string
+	publishR53ZoneID    string
+	publishNSServer     string
+	publishNSZone       string
+	publishNSKeyFile    string
+)
+
+var publishAnchorCmd = &cobra.Command{
+	Use:   "publish-anchor [file.ptx]",
+	Short: "Create the DNS TXT anchor record through a DNS provider",
+	Long: `Create the TXT record that anchors a PTX file (or raw circuit inputs) and optionally
+wait until it is visible through DoH.
+
+Providers:
+  - cloudflare: --cf-zone-id and --cf-token (or CLOUDFLARE_API_TOKEN)
+  - route53:    --route53-zone-id, credentials from AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY
+  - rfc2136:    --rfc2136-server, optional --rfc2136-zone and --rfc2136-key-file (uses nsupdate)`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ptxPath := ""
+		if len(args) == 1 {
+			ptxPath = args[0]
+		}
+
+		record, _, err := resolveAnchorRecord(ptxPath, publishCommitment, publishDomain, publishMetadata, false, ptxloader.FormatVersion)
+		if err != nil {
+			fmt.Fprintf(ui, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		provider, err := newDNSProvider()
+		if err != nil {
+			fmt.Fprintf(ui, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(ui, "Publishing TXT record via %s\n", provider.Name())
+		fmt.Fprintf(ui, "  Hostname: %s\n", record.Hostname)
+		fmt.Fprintf(ui, "  Value:    %s\n", record.Value)
+
+		ctx := context.Background()
+		if err := provider.UpsertTXT(ctx, record.Hostname, record.Value, publishTTL); err != nil {
+			fmt.Fprintf(ui, "Error publishing record: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(ui, "Record published.")
+
+		if !publishWait {
+			return
+		}
+
+		fmt.Fprintf(ui, "Waiting for propagation (timeout %s)...\n", publishWaitTimeout)
+		waitCtx, cancel := context.WithTimeout(ctx, publishWaitTimeout)
+		defer cancel()
+
+		res, err := dnsprovider.WaitForTXT(waitCtx, record.Hostname, record.Value, publishPollInterval)
+		if err != nil {
+			fmt.Fprintf(ui, "Anchor not ready: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(ui, "Anchor ready after %d lookup(s) (%.1fs)\n", res.Attempts, res.Elapsed.Seconds())
+	},
+}
+
+func newDNSProvider() (dnsprovider.Provider, error) {
+	switch publishProvider {
+	case "cloudflare":
+		token := publishCFToken
+		if token == "" {
+			token = os.Getenv("CLOUDFLARE_API_TOKEN")
+		}
+		return dnsprovider.NewCloudflare(publishCFZoneID, token), nil
+	case "route53":
+		return dnsprovider.NewRoute53FromEnv(publishR53ZoneID), nil
+	case "rfc2136":
+		return dnsprovider.NewRFC2136(publishNSServer, publishNSZone, publishNSKeyFile), nil
+	case "":
+		return nil, fmt.Errorf("--provider is required (cloudflare, route53 or rfc2136)")
+	default:
+		return nil, fmt.Errorf("unknown provider %q", publishProvider)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(publishAnchorCmd)
+
+	publishAnchorCmd.Flags().StringVar(&publishProvider, "provider", "", "DNS provider: cloudflare, route53 or rfc2136")
+	publishAnchorCmd.Flags().StringVar(&publishCommitment, "commitment", "", "Commitment (decimal string), when no PTX file is given")
+	publishAnchorCmd.Flags().StringVar(&publishDomain, "domain", "", "Anchor domain name, when no PTX file is given")
+	publishAnchorCmd.Flags().StringVar(&publishMetadata, "metadata", "", "Metadata JSON string, when no PTX file is given")
+	publishAnchorCmd.Flags().IntVar(&publishTTL, "ttl", dnsprovider.DefaultTTL, "TTL of the TXT record in seconds")
+	publishAnchorCmd.Flags().BoolVar(&publishWait, "wait", true, "Wait until the record is visible via DoH")
+	publishAnchorCmd.Flags().DurationVar(&publishWaitTimeout, "wait-timeout", 5*time.Minute, "Maximum time to wait for propagation")
+	publishAnchorCmd.Flags().DurationVar(&publishPollInterval, "poll-interval", 10*time.Second, "Interval between DoH lookups while waiting")
+	publishAnchorCmd.Flags().StringVar(&publishCFZoneID, "cf-zone-id", "", "Cloudflare zone ID")
+	publishAnchorCmd.Flags().StringVar(&publishCFToken, "cf-token", "", "Cloudflare API token (default $CLOUDFLARE_API_TOKEN)")
+	publishAnchorCmd.Flags().StringVar(&publishR53ZoneID, "route53-zone-id", "", "Route 53 hosted zone ID")
+	publishAnchorCmd.Flags().StringVar(&publishNSServer, "rfc2136-server", "", "Authoritative server for RFC 2136 updates (host[:port])")
+	publishAnchorCmd.Flags().StringVar(&publishNSZone, "rfc2136-zone", "", "Zone for RFC 2136 updates")
+	publishAnchorCmd.Flags().StringVar(&publishNSKeyFile, "rfc2136-key-file", "", "TSIG key file passed to nsupdate -k")
+}
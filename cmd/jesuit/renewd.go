@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dnsprovider"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/renewd"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/wallet"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renewdDir           string
+	renewdExportDir     string
+	renewdInterval      time.Duration
+	renewdBefore        time.Duration
+	renewdExpiresIn     time.Duration
+	renewdAnchorKey     string
+	renewdTSA           string
+	renewdWaitTimeout   time.Duration
+	renewdOnFailure     string
+	renewdWebhook       string
+	renewdWebhookSecret string
+	renewdOnce          bool
+	renewdJSON          bool
+)
+
+var renewdCmd = &cobra.Command{
+	Use:   "renewd",
+	Short: "Renew tokens before they expire and republish their anchors",
+	Long: `Watch the tokens of the wallet (or, with --dir, the PTX files of a directory)
+and renew each one when it gets close to its expiration: prove it again with
+its stored nullifier and secret, publish the anchor record of the renewed
+token through --provider, and replace the old token once the record is out.
+
+Tokens are renewed --renew-before their expiration, by default once a third
+of their lifetime is left. In a directory, the secrets of name.ptx are read
+from name.secrets.json, e.g. the output of prove --output json.
+
+Failures are retried on every check. --on-failure runs a shell command and
+--webhook POSTs the failure as JSON, once per token and error.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		d := &renewd.Daemon{
+			Renew:       wallet.RenewOptions{ExpiresIn: renewdExpiresIn},
+			RenewBefore: renewdBefore,
+			Interval:    renewdInterval,
+			TTL:         publishTTL,
+			WaitTimeout: renewdWaitTimeout,
+		}
+		if renewdDir != "" {
+			d.Source = &renewd.DirSource{Dir: renewdDir}
+		} else {
+			passphrase, err := walletPassphrase()
+			if err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			// Fail now on a wrong passphrase rather than on every check
+			if _, err := wallet.Open(walletPath, passphrase); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			d.Source = &renewd.WalletSource{Path: walletPath, Passphrase: passphrase, ExportDir: renewdExportDir}
+		}
+
+		var err error
+		if renewdAnchorKey != "" {
+			if d.Renew.AnchorKey, err = evidence.LoadSigningKey(renewdAnchorKey); err != nil {
+				printError("anchor key: " + err.Error())
+				os.Exit(1)
+			}
+		}
+		if renewdTSA != "" {
+			d.Renew.TSA = &tsa.Client{URL: renewdTSA}
+		}
+		if publishProvider != "" {
+			if d.Publisher, err = newDNSProvider(); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		}
+		if renewdOnFailure != "" {
+			d.Hooks = append(d.Hooks, &renewd.CommandHook{Command: renewdOnFailure})
+		}
+		if renewdWebhook != "" {
+			d.Hooks = append(d.Hooks, &renewd.WebhookHook{URL: renewdWebhook, Secret: []byte(renewdWebhookSecret)})
+		}
+		d.OnResult = printRenewResult
+		d.OnError = func(err error) { printWarning(err.Error()) }
+
+		if renewdOnce {
+			for _, r := range d.Check(context.Background()) {
+				if r.Failed() {
+					os.Exit(1)
+				}
+			}
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		interval := d.Interval
+		if interval <= 0 {
+			interval = renewd.DefaultInterval
+		}
+		fmt.Fprintf(ui, "%s  Checking tokens every %s\n", color.BlueString(glyphInfo), interval)
+		d.Run(ctx)
+	},
+}
+
+func printRenewResult(r renewd.Result) {
+	if renewdJSON {
+		json.NewEncoder(os.Stdout).Encode(r)
+		return
+	}
+	if r.Failed() {
+		name := r.Token
+		if name == "" {
+			name = "tokens"
+		}
+		printError(fmt.Sprintf("%s: %s failed: %s", name, r.Stage, r.Error))
+		return
+	}
+	printSuccess(fmt.Sprintf("%s renewed, expires %s", r.Token, r.ExpiresAt.Format(time.RFC3339)))
+	if r.Unpublished {
+		printWarning(fmt.Sprintf("Publish the anchor record of %s: %s", r.Token, r.Anchor))
+	}
+}
+
+func init() {
+	renewdCmd.Flags().StringVar(&walletPath, "wallet", wallet.DefaultPath(), "wallet file whose tokens are renewed")
+	renewdCmd.Flags().StringVar(&walletPassphraseFile, "passphrase-file", "", "read the wallet passphrase from this file (default $"+wallet.PassphraseEnv+" or a prompt)")
+	renewdCmd.Flags().StringVar(&renewdDir, "dir", "", "renew the PTX files of this directory in place instead of the wallet")
+	renewdCmd.Flags().StringVar(&renewdExportDir, "export-dir", "", "write <name>.ptx for every renewed wallet token to this directory")
+	renewdCmd.Flags().DurationVar(&renewdInterval, "interval", renewd.DefaultInterval, "time between checks")
+	renewdCmd.Flags().DurationVar(&renewdBefore, "renew-before", 0, "renew tokens expiring within this duration (default: a third of their lifetime)")
+	renewdCmd.Flags().DurationVar(&renewdExpiresIn, "expires-in", 0, "lifetime of renewed tokens (default: the lifetime of the old one)")
+	renewdCmd.Flags().StringVar(&renewdAnchorKey, "anchor-key", "", "anchor signing key, for tokens anchored under a fixed label")
+	renewdCmd.Flags().StringVar(&renewdTSA, "tsa-url", "", "RFC 3161 Time-Stamping Authority timestamping renewed PTX files")
+	renewdCmd.Flags().StringVar(&publishProvider, "provider", "", "DNS provider publishing the anchors: cloudflare, route53 or rfc2136 (default: print them)")
+	renewdCmd.Flags().IntVar(&publishTTL, "ttl", dnsprovider.DefaultTTL, "TTL of the TXT records in seconds")
+	renewdCmd.Flags().StringVar(&publishCFZoneID, "cf-zone-id", "", "Cloudflare zone ID")
+	renewdCmd.Flags().StringVar(&publishCFToken, "cf-token", "", "Cloudflare API token (default $CLOUDFLARE_API_TOKEN)")
+	renewdCmd.Flags().StringVar(&publishR53ZoneID, "route53-zone-id", "", "Route 53 hosted zone ID")
+	renewdCmd.Flags().StringVar(&publishNSServer, "rfc2136-server", "", "Authoritative server for RFC 2136 updates (host[:port])")
+	renewdCmd.Flags().StringVar(&publishNSZone, "rfc2136-zone", "", "Zone for RFC 2136 updates")
+	renewdCmd.Flags().StringVar(&publishNSKeyFile, "rfc2136-key-file", "", "TSIG key file passed to nsupdate -k")
+	renewdCmd.Flags().DurationVar(&renewdWaitTimeout, "wait-timeout", 0, "wait up to this long for a published record to be visible via DoH before replacing the token (0 = don't wait)")
+	renewdCmd.Flags().StringVar(&renewdOnFailure, "on-failure", "", "shell command run on a failed renewal, with the failure JSON on stdin and PTX_RENEW_* variables")
+	renewdCmd.Flags().StringVar(&renewdWebhook, "webhook", "", "POST failed renewals as JSON to this URL")
+	renewdCmd.Flags().StringVar(&renewdWebhookSecret, "webhook-secret", "", "HMAC-SHA256 secret used to sign webhook bodies")
+	renewdCmd.Flags().BoolVar(&renewdOnce, "once", false, "check once and exit, with status 1 if a renewal failed (for cron)")
+	renewdCmd.Flags().BoolVar(&renewdJSON, "json", false, "print every result as a JSON line to stdout")
+	rootCmd.AddCommand(renewdCmd)
+}
@@ -3,10 +3,16 @@ package main
 import (
 	"os"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
 	"github.com/spf13/cobra"
 )
 
-var verbose bool
+var (
+	verbose        bool
+	outboundProxy  string
+	outboundCAFile string
+	outboundPath   string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "jesuit",
@@ -15,6 +21,7 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
+	registerCompletions()
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -23,4 +30,33 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable ANSI colors (also with NO_COLOR, or when stderr is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&asciiOutput, "ascii", false, "use ASCII instead of Unicode symbols (also when stderr is not a terminal or the locale is not UTF-8)")
+	rootCmd.PersistentFlags().StringVar(&outboundProxy, "proxy", "", "proxy URL for all outbound HTTP, or \""+outbound.Direct+"\" (default HTTPS_PROXY/HTTP_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&outboundCAFile, "ca-file", "", "PEM CA bundle trusted for all outbound HTTPS instead of the system roots")
+	rootCmd.PersistentFlags().StringVar(&outboundPath, "outbound-config", "", "JSON outbound HTTP configuration with per-destination proxies and CA bundles (see README)")
+	cobra.OnInitialize(setupOutput, setupOutbound)
+}
+
+// setupOutbound installs the proxy and CA configuration shared by every
+// outbound HTTP client. --proxy and --ca-file override the file.
+func setupOutbound() {
+	cfg := &outbound.Config{}
+	if outboundPath != "" {
+		var err error
+		if cfg, err = outbound.LoadConfig(outboundPath); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+	}
+	if outboundProxy != "" {
+		cfg.ProxyURL = outboundProxy
+	}
+	if outboundCAFile != "" {
+		cfg.CAFile = outboundCAFile
+	}
+	if err := outbound.Configure(cfg); err != nil {
+		printError("Outbound HTTP: " + err.Error())
+		os.Exit(1)
+	}
 }
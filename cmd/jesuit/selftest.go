@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/selftest"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	selftestPKPath  string
+	selftestVKPath  string
+	selftestDomain  string
+	selftestStubDNS bool
+	selftestJSON    bool
+)
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Prove and verify a throwaway credential end to end",
+	Long: `Generate a random credential, prove it with the native proving key, write the
+PTX to a temporary file and verify it with the native verification key. Each
+stage is reported with its timing and the command exits non-zero if any stage
+fails, so it can be used as a deployment health check.
+
+With --stub-dns=false the DNS stage queries the DoH resolver for the anchor
+hostname and only checks that the resolver answers; the throwaway anchor is
+never published, so verification itself always uses a stubbed answer.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		r, err := selftest.Run(context.Background(), selftest.Config{
+			PKPath:  selftestPKPath,
+			VKPath:  selftestVKPath,
+			Domain:  selftestDomain,
+			StubDNS: selftestStubDNS,
+		})
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		if selftestJSON {
+			json.NewEncoder(os.Stdout).Encode(r)
+		} else {
+			printHeader("Self-Test")
+			for _, s := range r.Stages {
+				switch {
+				case s.Skipped:
+					fmt.Fprintf(ui, "%s  %-10s skipped\n", color.YellowString("-"), s.Name)
+				case s.OK:
+					line := fmt.Sprintf("%-10s %9.2fms", s.Name, s.DurationMs)
+					if s.Detail != "" {
+						line += "  " + s.Detail
+					}
+					printSuccess(line)
+				default:
+					printError(fmt.Sprintf("%-10s %9.2fms  %s", s.Name, s.DurationMs, s.Error))
+				}
+			}
+			fmt.Fprintf(ui, "%s  Total: %.2fms\n", color.BlueString(glyphInfo), r.TotalMs)
+		}
+
+		if !r.OK {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	selftestCmd.Flags().StringVar(&selftestPKPath, "pk", "native.pk", "native proving key")
+	selftestCmd.Flags().StringVar(&selftestVKPath, "vk", "native.vk", "native verification key")
+	selftestCmd.Flags().StringVar(&selftestDomain, "domain", selftest.DefaultDomain, "domain the throwaway credential is issued for")
+	selftestCmd.Flags().BoolVar(&selftestStubDNS, "stub-dns", true, "answer the anchor lookup locally instead of querying the DoH resolver")
+	selftestCmd.Flags().BoolVar(&selftestJSON, "json", false, "print the report as JSON")
+	rootCmd.AddCommand(selftestCmd)
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/discovery"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ipfs"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ratelimit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/resilience"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/server"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/session"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr        string
+	serveVKPath      string
+	serveKeySetPath  string
+	serveRedisURL    string
+	serveNonceDB     string
+	serveTrackNulls  bool
+	serveMaxLifetime time.Duration
+	serveMaxNonceTTL time.Duration
+	serveMaxDuration time.Duration
+	serveWarmup      time.Duration
+	servePolicyPath  string
+	serveWebhookURL  string
+	serveWebhookKey  string
+	serveAuditDB     string
+	nullifierLimit   int
+	nullifierWindow  time.Duration
+	domainLimit      int
+	domainWindow     time.Duration
+	serveMaxBodySize int64
+	serveDiscover    bool
+	serveTenantsPath string
+	serveTenantHdr   string
+	serveDNSOutage   string
+	serveDomains     string
+	serveIPFSGateway string
+	serveEthRPC      string
+	serveEthRegs     map[string]string
+	serveAnchorTTL   time.Duration
+	serveWatch       time.Duration
+	serveChallenge   time.Duration
+	serveSessionKey  string
+	serveSessionTTL  time.Duration
+	serveSessionIss  string
+	serveRetries     int
+	serveBreakerAt   int
+	serveBreakerCool time.Duration
+	serveSOCKSProxy  string
+	serveWorkers     int
+	serveQueueDepth  int
+	serveReqTimeout  time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an HTTP verification server",
+	Long: `Serve POST /v1/verify. The body is either the raw PTX file
+(Content-Type: application/octet-stream) or JSON:
+
+  {"ptx": "<base64>", "intendedScope": ["..."], "intendedAudience": ["..."], "strict": true}
+
+Presentations are rate limited per nullifier hash and per domain with token
+buckets kept in Redis (--redis-url) or in memory. Rejected requests get a 429
+with a typed error body and a Retry-After header. Metadata nonces are
+recorded in Redis, or in the embedded database --nonce-db on a single
+instance, so replays stay rejected across restarts.
+
+At most --workers verifications run at once and --queue-depth more wait for
+one; requests beyond that get a 429 server_overloaded, and requests still
+waiting when --request-timeout passes a 503 request_timeout, both with a
+Retry-After header.
+
+With --tenants the server is multi-tenant: each request names its tenant in
+the X-PTX-Tenant header or as POST /v1/tenants/{tenant}/verify, and is
+verified with that tenant's keys, defaults, nonce namespace and policy.
+
+GET /healthz (liveness) checks that the verification keys load and the
+circuit compiles; GET /readyz (readiness) also checks Redis and the DoH
+resolver. Both return per-component statuses and 503 on failure. GET /metrics
+exposes the circuit breaker state and retry counters of the DoH resolver, the
+nonce store and anchor gateways in the Prometheus text format.
+
+SIGHUP reloads the verification key, key set, policy and tenant files without
+a restart, as does any change to them with --watch-interval. A reload that
+fails keeps the previous configuration.
+
+With --challenge-ttl, POST /v1/challenge (or /v1/tenants/{tenant}/challenge)
+issues a one-time challenge, and every PTX must be proven fresh with it as the
+metadata nonce (jesuit prove --challenge).
+
+With --session-key, every accepted PTX also gets a short-lived session JWT
+(EdDSA) carrying the verified claims, with the nullifier hash as subject.
+POST /v1/introspect {"token": "..."} reports whether a session token is
+active and returns its claims.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := server.Config{
+			VKPath:         serveVKPath,
+			KeySetPath:     serveKeySetPath,
+			PolicyPath:     servePolicyPath,
+			TenantsPath:    serveTenantsPath,
+			TenantHeader:   serveTenantHdr,
+			RedisURL:       serveRedisURL,
+			NonceDB:        serveNonceDB,
+			NullifierLimit: ratelimit.Limit{Requests: nullifierLimit, Per: nullifierWindow},
+			DomainLimit:    ratelimit.Limit{Requests: domainLimit, Per: domainWindow},
+			MaxBodySize:    serveMaxBodySize,
+			ChallengeTTL:   serveChallenge,
+			Workers:        serveWorkers,
+			QueueDepth:     serveQueueDepth,
+			RequestTimeout: serveReqTimeout,
+		}
+		if serveDiscover {
+			cfg.Options.Discovery = discovery.NewClient()
+		}
+		outagePolicy, err := dns.ParseOutagePolicy(serveDNSOutage)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		cfg.Options.DNSOutagePolicy = outagePolicy
+		cfg.Options.DomainPolicy, err = verifier.ParseDomainPolicy(serveDomains)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		cfg.Options.AnchorCache = dns.NewAnchorCache(serveAnchorTTL)
+		cfg.Options.TrackNullifiers = serveTrackNulls
+		cfg.Options.MaxTokenLifetime = serveMaxLifetime
+		cfg.Options.MaxNonceTTL = serveMaxNonceTTL
+		cfg.Options.MaxDuration = serveMaxDuration
+		cfg.Options.IPFSGateway = serveIPFSGateway
+		cfg.Options.EthereumRPC = serveEthRPC
+		cfg.Options.EthereumRegistries, err = parseRegistries(serveEthRegs)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		cfg.Options.SOCKSProxy = serveSOCKSProxy
+		cfg.Options.Resilience = &resilience.Policy{
+			Retries:          serveRetries,
+			FailureThreshold: serveBreakerAt,
+			Cooldown:         serveBreakerCool,
+		}
+		if err := cfg.Options.Validate(); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		emitter, err := newEventEmitter(serveWebhookURL, serveWebhookKey, serveAuditDB)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		cfg.Events = emitter
+		if serveSessionKey != "" {
+			key, err := evidence.LoadSigningKey(serveSessionKey)
+			if err != nil {
+				printError("Failed to load session key: " + err.Error())
+				os.Exit(1)
+			}
+			cfg.Session = &session.Minter{Key: key, Issuer: serveSessionIss, TTL: serveSessionTTL}
+		}
+
+		srv, err := server.New(cfg)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		defer srv.Close()
+
+		// Load keys, compile circuits and connect to the resolver before
+		// the first request rather than during it
+		warmCtx, cancel := context.WithTimeout(context.Background(), serveWarmup)
+		if err := srv.Warmup(warmCtx); err != nil {
+			printWarning("Warm-up incomplete: " + err.Error())
+		}
+		cancel()
+
+		httpSrv := &http.Server{
+			Addr:              serveAddr,
+			Handler:           srv,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			httpSrv.Shutdown(shutdownCtx)
+		}()
+
+		// Reload keys, policy and tenants on SIGHUP, and on file changes
+		// with --watch-interval
+		onReload := func(err error) {
+			if err != nil {
+				printWarning("Reload failed, keeping the previous configuration: " + err.Error())
+				return
+			}
+			fmt.Fprintf(ui, "%s  Configuration reloaded\n", color.BlueString(glyphInfo))
+		}
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+					onReload(srv.Reload())
+				}
+			}
+		}()
+		if serveWatch > 0 {
+			go srv.Watch(ctx, serveWatch, onReload)
+		}
+
+		fmt.Fprintf(ui, "%s  Listening on %s (nullifier limit %s, domain limit %s)\n",
+			color.BlueString(glyphInfo), serveAddr, cfg.NullifierLimit, cfg.DomainLimit)
+		if serveTenantsPath != "" {
+			fmt.Fprintf(ui, "%s  Serving tenants from %s\n", color.BlueString(glyphInfo), serveTenantsPath)
+		}
+		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			printError(err.Error())
+			os.Exit(1)
+		}
+
+		if cfg.Events != nil {
+			flushCtx, cancel := context.WithTimeout(context.Background(), events.DefaultPublishTimeout)
+			cfg.Events.Close(flushCtx)
+			cancel()
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "listen address")
+	serveCmd.Flags().StringVar(&serveVKPath, "vk", "native.vk", "native verification key")
+	serveCmd.Flags().StringVar(&serveKeySetPath, "keyset", "", "JSON key set of rotating verification keys (replaces --vk)")
+	serveCmd.Flags().StringVar(&serveDNSOutage, "dns-outage", "fail-closed", "when the DoH resolver is unreachable: fail-closed, fail-open (accept with a warning) or accept-if-cached")
+	serveCmd.Flags().StringVar(&serveDomains, "domain-policy", "all", "which domains of a multi-domain PTX must publish the anchor: all, any or primary")
+	serveCmd.Flags().StringVar(&serveIPFSGateway, "ipfs-gateway", ipfs.DefaultGateway, "IPFS gateway fetching the documents of IPFS anchors")
+	serveCmd.Flags().StringVar(&serveEthRPC, "eth-rpc", "", "Ethereum JSON-RPC endpoint reading ETHEREUM anchors (requires a build with -tags ethereum)")
+	serveCmd.Flags().StringToStringVar(&serveEthRegs, "eth-registry", nil, "registry contract trusted to anchor a domain's tokens, as domain=0xaddress (repeatable)")
+	serveCmd.Flags().StringVar(&serveSOCKSProxy, "socks-proxy", "", "SOCKS5 proxy (e.g. Tor at socks5://127.0.0.1:9050) for DoH queries and anchor fetches, hiding the checked hostnames from the network")
+	serveCmd.Flags().IntVar(&serveRetries, "retries", resilience.DefaultPolicy.Retries, "retries of transient DoH, nonce store and anchor fetch failures, with jittered backoff")
+	serveCmd.Flags().IntVar(&serveBreakerAt, "breaker-threshold", 0, "consecutive failures of a dependency endpoint that open its circuit breaker (0 = off)")
+	serveCmd.Flags().DurationVar(&serveBreakerCool, "breaker-cooldown", resilience.DefaultCooldown, "how long an open circuit breaker rejects calls before probing the endpoint again")
+	serveCmd.Flags().DurationVar(&serveAnchorTTL, "dns-outage-max-age", dns.DefaultAnchorMaxAge, "with accept-if-cached, how recently an anchor must have been found in DNS")
+	serveCmd.Flags().BoolVar(&serveDiscover, "discover", false, "select keys from each issuer's /.well-known/ptx-configuration (ignored with --keyset)")
+	serveCmd.Flags().StringVar(&serveTenantsPath, "tenants", "", "JSON tenant definitions (enables multi-tenant mode)")
+	serveCmd.Flags().StringVar(&serveTenantHdr, "tenant-header", server.DefaultTenantHeader, "request header selecting the tenant")
+	serveCmd.Flags().DurationVar(&serveWatch, "watch-interval", 0, "reload keys, policy and tenants when their files change, checking this often (0 = only on SIGHUP)")
+	serveCmd.Flags().DurationVar(&serveChallenge, "challenge-ttl", 0, "require proof-of-possession: issue challenges valid this long at POST /v1/challenge (0 = off)")
+	serveCmd.Flags().StringVar(&serveSessionKey, "session-key", "", "PEM ed25519 private key signing session tokens for accepted PTX files (enables /v1/introspect)")
+	serveCmd.Flags().DurationVar(&serveSessionTTL, "session-ttl", session.DefaultTTL, "session token lifetime (never beyond the PTX expiration)")
+	serveCmd.Flags().StringVar(&serveSessionIss, "session-issuer", "ptx-jesuit", "iss claim of session tokens")
+	serveCmd.Flags().StringVar(&serveRedisURL, "redis-url", "", "redis url for nonces and shared rate limits")
+	serveCmd.Flags().StringVar(&serveNonceDB, "nonce-db", "", "embedded nonce database file, used for nonces instead of Redis")
+	serveCmd.Flags().DurationVar(&serveMaxLifetime, "max-token-lifetime", 0, "reject tokens expiring later than this from now, or never (0 = any expiration)")
+	serveCmd.Flags().DurationVar(&serveMaxNonceTTL, "max-nonce-ttl", verifier.DefaultMaxNonceTTL, "remember nonces at most this long")
+	serveCmd.Flags().DurationVar(&serveWarmup, "warmup-timeout", time.Minute, "bound on loading keys, compiling circuits and connecting to the DoH resolver before serving")
+	serveCmd.Flags().DurationVar(&serveMaxDuration, "max-duration", 0, "budget of each verification; stages still running are cut and the PTX rejected with verification_timeout (0 = unbounded; requests may lower it with maxDurationMs)")
+	serveCmd.Flags().BoolVar(&serveTrackNulls, "track-nullifiers", false, "accept each token once: record its nullifier hash with its nonce (needs --redis-url or --nonce-db)")
+	serveCmd.Flags().StringVar(&servePolicyPath, "policy", "", "JSON acceptance policy applied after all checks pass")
+	serveCmd.Flags().StringVar(&serveWebhookURL, "webhook", "", "POST every verification outcome as JSON to this URL")
+	serveCmd.Flags().StringVar(&serveWebhookKey, "webhook-secret", "", "HMAC-SHA256 secret used to sign webhook bodies")
+	serveCmd.Flags().StringVar(&serveAuditDB, "audit-db", "", "record every verification in this audit database (sqlite:<path> or postgres://...)")
+	serveCmd.Flags().IntVar(&nullifierLimit, "nullifier-limit", 10, "presentations allowed per nullifier hash per window (0 = unlimited)")
+	serveCmd.Flags().DurationVar(&nullifierWindow, "nullifier-window", time.Minute, "nullifier rate limit window")
+	serveCmd.Flags().IntVar(&domainLimit, "domain-limit", 0, "presentations allowed per domain per window (0 = unlimited)")
+	serveCmd.Flags().DurationVar(&domainWindow, "domain-window", time.Minute, "domain rate limit window")
+	serveCmd.Flags().IntVar(&serveWorkers, "workers", 0, "verifications running at once (0 = GOMAXPROCS)")
+	serveCmd.Flags().IntVar(&serveQueueDepth, "queue-depth", server.DefaultQueueDepth, "requests waiting for a worker before new ones are refused with 429")
+	serveCmd.Flags().DurationVar(&serveReqTimeout, "request-timeout", 30*time.Second, "deadline of each verification request, queueing included (0 = none)")
+	serveCmd.Flags().Int64Var(&serveMaxBodySize, "max-body-size", server.DefaultMaxBodySize, "maximum request body size in bytes")
+	rootCmd.AddCommand(serveCmd)
+}
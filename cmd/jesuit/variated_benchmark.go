@@ -12,6 +12,7 @@ import (
 
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +23,7 @@ var (
 	benchRuns   int
 	benchOutput string
 	benchStats  bool
+	benchGPU    bool
 )
 
 var variatedBenchmarkCmd = &cobra.Command{
@@ -32,7 +34,7 @@ var variatedBenchmarkCmd = &cobra.Command{
 Available targets:
   - fqdn: Vary FQDN string length (tests SHA256 hashing overhead)
   - metadata: Vary metadata JSON size (tests SHA256 hashing overhead)
-  - trust-method: Test different trust method values (1=DOH, 2=GIST, etc.)
+  - trust-method: Test different trust method values (1=DOH, 2=GIST, 3=WELL_KNOWN)
   
 Reports Circuit Compilation, Witness Generation, and Proof Generation times with statistical analysis.`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -65,16 +67,23 @@ Reports Circuit Compilation, Witness Generation, and Proof Generation times with
 			color.Red("Error: step must be positive")
 			os.Exit(1)
 		}
+		if benchTarget == "trust-method" {
+			for l := min; l <= max; l += step {
+				if !ptx.TrustMethod(l).IsValid() {
+					color.Red("Error: trust method %d is not defined (valid values are 1 to 5)", l)
+					os.Exit(1)
+				}
+			}
+		}
 
 		// Print header
-		color.Cyan("\n╔════════════════════════════════════════════════════════════╗")
-		color.Cyan("║         Comprehensive Prover Benchmark Suite              ║")
-		color.Cyan("╚════════════════════════════════════════════════════════════╝\n")
+		printHeader("Comprehensive Prover Benchmark Suite")
+		fmt.Fprintln(ui)
 
-		fmt.Printf("  Target:        %s\n", color.YellowString(benchTarget))
-		fmt.Printf("  Range:         %s\n", color.YellowString("%d to %d (step %d)", min, max, step))
-		fmt.Printf("  Runs/step:     %s\n", color.YellowString("%d", benchRuns))
-		fmt.Printf("  Statistics:    %s\n\n", color.YellowString("%t", benchStats))
+		fmt.Fprintf(ui, "  Target:        %s\n", color.YellowString(benchTarget))
+		fmt.Fprintf(ui, "  Range:         %s\n", color.YellowString("%d to %d (step %d)", min, max, step))
+		fmt.Fprintf(ui, "  Runs/step:     %s\n", color.YellowString("%d", benchRuns))
+		fmt.Fprintf(ui, "  Statistics:    %s\n\n", color.YellowString("%t", benchStats))
 
 		// Setup Output
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
@@ -86,14 +95,16 @@ Reports Circuit Compilation, Witness Generation, and Proof Generation times with
 			}
 		} else {
 			if benchStats {
-				fmt.Fprintln(w, "Value\tCompile (Avg±σ)\tWitness (Avg±σ)\tProve (Avg±σ)\tTotal")
+				fmt.Fprintln(w, "Value\tCompile (Avg"+glyphPM+glyphSD+")\tWitness (Avg"+glyphPM+glyphSD+")\tProve (Avg"+glyphPM+glyphSD+")\tTotal")
 			} else {
 				fmt.Fprintln(w, "Value\tCompile\tWitness\tProve\tTotal")
 			}
-			fmt.Fprintln(w, strings.Repeat("─", 80))
+			fmt.Fprintln(w, strings.Repeat(glyphRule, 80))
 		}
 
 		p := prover.NewProver()
+		p.GPU = benchGPU
+		backend := ""
 
 		// Base params
 		nullifierBig, _ := crypto.GenerateSecureRandomBigInt()
@@ -113,7 +124,7 @@ Reports Circuit Compilation, Witness Generation, and Proof Generation times with
 			// Progress indicator
 			if benchOutput != "csv" {
 				fmt.Fprintf(os.Stderr, "\r%s Processing step %d/%d...",
-					color.BlueString("⏳"), currentStep, totalSteps)
+					color.BlueString(glyphWait), currentStep, totalSteps)
 			}
 
 			var compileResults, witnessResults, proveResults []float64
@@ -148,6 +159,7 @@ Reports Circuit Compilation, Witness Generation, and Proof Generation times with
 					os.Exit(1)
 				}
 
+				backend = res.Backend
 				compileResults = append(compileResults, res.CompileTimeMs)
 				witnessResults = append(witnessResults, res.WitnessTimeMs)
 				proveResults = append(proveResults, res.ProveTimeMs)
@@ -170,9 +182,9 @@ Reports Circuit Compilation, Witness Generation, and Proof Generation times with
 				}
 			} else {
 				if benchStats {
-					fmt.Fprintf(w, "%d\t%.2f±%.2f\t%.2f±%.2f\t%.2f±%.2f\t%.2f ms\n",
-						l, compileAvg, compileStdDev, witnessAvg, witnessStdDev,
-						proveAvg, proveStdDev, totalAvg)
+					fmt.Fprintf(w, "%d\t%.2f%s%.2f\t%.2f%s%.2f\t%.2f%s%.2f\t%.2f ms\n",
+						l, compileAvg, glyphPM, compileStdDev, witnessAvg, glyphPM, witnessStdDev,
+						proveAvg, glyphPM, proveStdDev, totalAvg)
 				} else {
 					fmt.Fprintf(w, "%d\t%.2f ms\t%.2f ms\t%.2f ms\t%.2f ms\n",
 						l, compileAvg, witnessAvg, proveAvg, totalAvg)
@@ -183,8 +195,11 @@ Reports Circuit Compilation, Witness Generation, and Proof Generation times with
 
 		if benchOutput != "csv" {
 			fmt.Fprintf(os.Stderr, "\r%s Benchmark complete!%s\n",
-				color.GreenString("✓"), strings.Repeat(" ", 30))
+				color.GreenString(glyphDone), strings.Repeat(" ", 30))
 		}
+		// Reported on stderr so CSV output stays machine-readable
+		fmt.Fprintf(os.Stderr, "Proving backend: %s\n", backend)
+		warnGPUFallback(os.Stderr, benchGPU)
 	},
 }
 
@@ -198,6 +213,8 @@ func init() {
 		"Number of runs per step for averaging")
 	variatedBenchmarkCmd.Flags().StringVar(&benchOutput, "output", "table",
 		"Output format: 'table' or 'csv'")
+	variatedBenchmarkCmd.Flags().BoolVar(&benchGPU, "gpu", false,
+		"Prove on the GPU via icicle (requires a build with -tags icicle), falling back to CPU")
 	variatedBenchmarkCmd.Flags().BoolVar(&benchStats, "stats", false,
 		"Include min/max/stddev statistics")
 }
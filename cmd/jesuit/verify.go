@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/vk"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/confidential"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/convert"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/discovery"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ipfs"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/resilience"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
-	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/vk"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/vocdoni/circom2gnark/parser"
@@ -20,73 +33,363 @@ var (
 	intendedScope    []string
 	intendedAudience []string
 	strictMode       bool
+	dnsAfterProof    bool
 	redisURL         string
+	nonceDB          string
+	trackNullifiers  bool
+	maxLifetime      time.Duration
+	maxNonceTTL      time.Duration
+	maxDuration      time.Duration
+	signalsPath      string
+	metadataPath     string
+	metadataKeyPaths []string
 	timeDev          bool
 	timeSkipDev      bool
+	benchInternal    bool
+	benchInternalN   int
+	machineFormat    string
+	verifyOutput     string
+	legacySignals    bool
+	dnsRetries       int
+	dnsRetryBackoff  time.Duration
+	verifyRetries    int
+	dnsMatch         string
+	dnsOutage        string
+	domainPolicy     string
+	ipfsGateway      string
+	ethRPC           string
+	ethRegistries    map[string]string
+	dohURL           string
+	dohTimeout       time.Duration
+	dohNetwork       string
+	dohProxy         string
+	socksProxy       string
+	dohPrivacy       bool
+	dohResolvers     []string
+	dohStagger       time.Duration
+	dohCAFile        string
+	dohHTTP1         bool
+	dohFormat        string
+	evidencePath     string
+	evidenceKeyPath  string
+	keySetPath       string
+	vkURL            string
+	vkSHA256         string
+	vkCacheDir       string
+	discoverKeys     bool
+	policyPath       string
+	proofSystems     []string
+	curves           []string
+	minCircuit       int
+	webhookURL       string
+	webhookSecret    string
+	auditDBURL       string
+	tsaRootsPath     string
+	requireTimestamp bool
 )
 
 var verifyCmd = &cobra.Command{
-	Use:   "verify <file.ptx>",
+	Use:   "verify <file.ptx | ->",
 	Short: "Verify a PTX proof",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		filePath := args[0]
 
+		switch machineFormat {
+		case "":
+		case "json", "lines":
+			// --machine selects the output of --time-skip-dev, or implies
+			// --time-dev
+			timeDev = !timeSkipDev
+		default:
+			msg := fmt.Sprintf("unknown --machine format %q (want json or lines)", machineFormat)
+			machineFormat = ""
+			exitSetup(msg)
+		}
+		switch verifyOutput {
+		case "text":
+		case "json":
+			if machineFormat != "" || timeDev || timeSkipDev {
+				verifyOutput = "text"
+				exitSetup("--output json cannot be combined with --machine or the time-dev modes")
+			}
+		default:
+			msg := fmt.Sprintf("unknown --output format %q (want text or json)", verifyOutput)
+			verifyOutput = "text"
+			exitSetup(msg)
+		}
+		humanOutput := !timeDev && verifyOutput == "text"
+
+		// "-" reads the PTX from stdin, e.g. curl ... | jesuit verify -
+		var ptxData []byte
+		if filePath == "-" {
+			var err error
+			ptxData, err = ptxloader.ReadAll(os.Stdin, ptxloader.DefaultLoadOptions())
+			if err != nil {
+				exitSetup("failed to read PTX from stdin: " + err.Error())
+			}
+			filePath = ""
+		}
+
+		matchMode, err := dns.ParseMatchMode(dnsMatch)
+		if err != nil {
+			exitSetup(err.Error())
+		}
+
+		outagePolicy, err := dns.ParseOutagePolicy(dnsOutage)
+		if err != nil {
+			exitSetup(err.Error())
+		}
+
+		domains, err := verifier.ParseDomainPolicy(domainPolicy)
+		if err != nil {
+			exitSetup(err.Error())
+		}
+
+		format, err := dns.ParseFormat(dohFormat)
+		if err != nil {
+			exitSetup(err.Error())
+		}
+
+		// The SOCKS proxy carries the DoH queries of this client; the
+		// verifier routes anchor fetches through it
+		if socksProxy != "" {
+			if !strings.HasPrefix(socksProxy, "socks5://") && !strings.HasPrefix(socksProxy, "socks5h://") {
+				exitSetup("--socks-proxy must be a socks5:// URL")
+			}
+			if dohProxy != "" {
+				exitSetup("--socks-proxy and --doh-proxy are mutually exclusive")
+			}
+			dohProxy = socksProxy
+		}
+		var privacy *dns.Privacy
+		if dohPrivacy {
+			privacy = &dns.Privacy{Endpoints: dohResolvers, MaxStagger: dohStagger}
+		} else if len(dohResolvers) > 0 || dohStagger > 0 {
+			exitSetup("--doh-privacy-resolver and --doh-stagger require --doh-privacy")
+		}
+		dnsClient, err := dns.NewClient(dns.ClientConfig{
+			Endpoint:     dohURL,
+			Format:       format,
+			Timeout:      dohTimeout,
+			Network:      dohNetwork,
+			ProxyURL:     dohProxy,
+			CAFile:       dohCAFile,
+			DisableHTTP2: dohHTTP1,
+			Privacy:      privacy,
+		})
+		if err != nil {
+			exitSetup(err.Error())
+		}
+
 		opts := verifier.VerificationOptions{
 			FilePath:         filePath,
+			PTXData:          ptxData,
 			IntendedScope:    intendedScope,
 			IntendedAudience: intendedAudience,
 			StrictMode:       strictMode,
+			DNSAfterProof:    dnsAfterProof,
+			MaxDuration:      maxDuration,
 			RedisURL:         redisURL,
+			NonceDB:          nonceDB,
+			TrackNullifiers:  trackNullifiers,
+			MaxTokenLifetime: maxLifetime,
+			MaxNonceTTL:      maxNonceTTL,
 			Verbose:          verbose,
+			LegacySignalScan: legacySignals,
+			DNSRetries:       dnsRetries,
+			DNSRetryBackoff:  dnsRetryBackoff,
+			Resilience:       &resilience.Policy{Retries: verifyRetries},
+			DNSMatchMode:     matchMode,
+			DNSOutagePolicy:  outagePolicy,
+			DomainPolicy:     domains,
+			DNSResolver:      dnsClient,
+			SOCKSProxy:       socksProxy,
+			IPFSGateway:      ipfsGateway,
+			EthereumRPC:      ethRPC,
+			Evidence:         evidencePath != "",
+		}
+		if opts.EthereumRegistries, err = parseRegistries(ethRegistries); err != nil {
+			exitSetup(err.Error())
+		}
+		if opts.ProofPolicy, err = parseProofPolicy(proofSystems, curves, minCircuit); err != nil {
+			exitSetup(err.Error())
+		}
+		if signalsPath != "" {
+			raw, err := os.ReadFile(signalsPath)
+			if err == nil {
+				opts.ExternalSignals, err = convert.ParsePublicSignals(raw)
+			}
+			if err != nil {
+				exitSetup(err.Error())
+			}
+		}
+		if metadataPath != "" {
+			raw, err := os.ReadFile(metadataPath)
+			if err != nil {
+				exitSetup(err.Error())
+			}
+			opts.ExternalMetadata = strings.TrimRight(string(raw), "\r\n")
+		}
+		for _, path := range metadataKeyPaths {
+			key, err := confidential.LoadKey(path)
+			if err != nil {
+				exitSetup("Failed to load metadata key: " + err.Error())
+			}
+			opts.MetadataKeys = append(opts.MetadataKeys, key)
+		}
+		if vkCacheDir != "" {
+			vk.SetDefaultFetcher(vk.NewVKFetcher(vkCacheDir))
+		}
+		if vkURL != "" {
+			if vkSHA256 == "" {
+				exitSetup("--vk-url requires --vk-sha256")
+			}
+			key, err := vk.DefaultFetcher().Fetch(context.Background(), vk.KeySource{URL: vkURL, SHA256: vkSHA256})
+			if err != nil {
+				exitSetup(err.Error())
+			}
+			if key.Offline {
+				printWarning("Key server unreachable, using cached verification key from " + key.FetchedAt.Format(time.RFC3339))
+			}
+			opts.VKData = key.Data
+		}
+		if discoverKeys {
+			opts.Discovery = discovery.NewClient()
+		}
+		if keySetPath != "" {
+			ks, err := verifier.LoadKeySet(keySetPath)
+			if err != nil {
+				exitSetup("Failed to load key set: " + err.Error())
+			}
+			opts.KeySet = ks
+		}
+		if policyPath != "" {
+			policy, err := verifier.LoadPolicy(policyPath)
+			if err != nil {
+				exitSetup("Failed to load policy: " + err.Error())
+			}
+			opts.PolicyFunc = policy.Func()
+		}
+		if tsaRootsPath != "" {
+			roots, err := tsa.LoadRoots(tsaRootsPath)
+			if err != nil {
+				exitSetup(err.Error())
+			}
+			opts.TSARoots = roots
+		}
+		opts.RequireTimestamp = requireTimestamp
+		if evidenceKeyPath != "" {
+			key, err := evidence.LoadSigningKey(evidenceKeyPath)
+			if err != nil {
+				exitSetup("Failed to load evidence key: " + err.Error())
+			}
+			opts.EvidenceKey = key
 		}
 
 		if timeSkipDev {
-			runTimeSkipDev(filePath)
+			runTimeSkipDev(filePath, ptxData)
+			return
+		}
+		if benchInternal {
+			runBenchInternal(opts)
 			return
 		}
 
+		opts.Events, err = newEventEmitter(webhookURL, webhookSecret, auditDBURL)
+		if err != nil {
+			exitSetup(err.Error())
+		}
+
 		v := verifier.NewPTXVerifier(opts)
 
 		// CLI Output similar to JS
-		if !timeDev {
+		if humanOutput {
 			printHeader("PTX Verification Tool")
-			fmt.Printf("%s  Reading: %s\n", color.BlueString("ℹ"), filePath)
+			if filePath == "" {
+				fmt.Fprintf(ui, "%s  Reading: <stdin>\n", color.BlueString(glyphInfo))
+			} else {
+				fmt.Fprintf(ui, "%s  Reading: %s\n", color.BlueString(glyphInfo), filePath)
+			}
 		}
 
 		res, err := v.Verify()
+		if opts.Events != nil {
+			// Flush the event before any exit below
+			ctx, cancel := context.WithTimeout(context.Background(), events.DefaultPublishTimeout)
+			opts.Events.Close(ctx)
+			cancel()
+		}
 		if err != nil {
-			printError(err.Error())
-			os.Exit(1)
+			exitSetup(err.Error())
 		}
 
-		if !timeDev {
+		if humanOutput {
 			// Print Results
 			printSection("1. PTX Header")
 			printSuccess("Header validated")
+			if ts := res.Timestamp; ts != nil && ts.Valid {
+				printSuccess("Timestamped " + ts.Time.UTC().Format(time.RFC3339) + " by " + ts.Authority)
+			}
 
 			for _, e := range res.Errors {
 				printError(e)
 			}
 
 			printSection("3. DNS Anchor")
-			if res.Dns.Valid {
+			if res.Dns.Outage != nil && res.Dns.Valid {
+				for _, w := range res.Warnings {
+					printWarning(w)
+				}
+			} else if res.Dns.Valid {
 				printSuccess("DNS anchor verified")
 			} else {
 				printError(res.Dns.Error)
 			}
+			if verbose && res.Dns.Attempts > 1 {
+				fmt.Fprintf(ui, "   Attempts: %d\n", res.Dns.Attempts)
+				for i, ms := range res.Dns.AttemptTimesMs {
+					fmt.Fprintf(ui, "      #%d: %.1f ms\n", i+1, ms)
+				}
+			}
+			if verbose && res.Dns.Timing != nil {
+				printDNSTiming(res.Dns.Timing)
+			}
+			for _, d := range res.Domains {
+				if d.Valid {
+					printSuccess("DNS anchor of " + d.Domain + " verified")
+				} else {
+					printError(d.Domain + ": " + d.Error)
+				}
+			}
+			for _, a := range res.Anchors {
+				if a.Valid {
+					printSuccess(a.TrustMethod + " anchor verified")
+				} else {
+					printError(a.TrustMethod + " anchor: " + a.Error)
+				}
+				if verbose && a.Location != "" {
+					fmt.Fprintf(ui, "   URL: %s\n", a.Location)
+				}
+			}
 
 			printSection("4. ZK-SNARK")
 			if res.Zk.Skipped {
-				fmt.Printf("%s  Skipped (not Groth16)\n", color.BlueString("ℹ"))
+				fmt.Fprintf(ui, "%s  Skipped (not Groth16)\n", color.BlueString(glyphInfo))
 			} else if res.Zk.Valid {
 				printSuccess("Proof valid")
+				if res.Zk.KeyID != "" {
+					fmt.Fprintf(ui, "   Key: %s\n", res.Zk.KeyID)
+				}
 			} else {
 				printError("Proof invalid (Check verbose for details)")
 				if verbose && res.Zk.Error != "" {
-					fmt.Printf("   Reason: %s\n", res.Zk.Error)
+					fmt.Fprintf(ui, "   Reason: %s\n", res.Zk.Error)
 				}
 			}
+			if verbose && res.Zk.SemanticReport != nil {
+				printSemanticReport(res.Zk.SemanticReport)
+			}
 
 			if res.Success {
 				printHeader("Verification Successful")
@@ -95,41 +398,53 @@ var verifyCmd = &cobra.Command{
 
 			if verbose {
 				printSection("5. Verified Value Details")
-				fmt.Printf("   %s\n", color.CyanString("FQDN (ASCII):"))
-				fmt.Printf("      %s\n", res.Details.Fqdn)
-				fmt.Printf("   %s\n", color.CyanString("FQDN Hash (Decimal):"))
-				fmt.Printf("      %s\n", res.Details.FqdnHash)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("FQDN (ASCII):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.Fqdn)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("FQDN Hash (Decimal):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.FqdnHash)
+
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Metadata JSON (ASCII):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.MetadataJSON)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Metadata Hash P1 (Decimal):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.MetadataHashP1)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Metadata Hash P2 (Decimal):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.MetadataHashP2)
+				if len(res.Details.DecryptedClaims) > 0 {
+					fmt.Fprintf(ui, "   %s\n", color.CyanString("Decrypted Claims:"))
+					fmt.Fprintf(ui, "      %s\n", strings.Join(res.Details.DecryptedClaims, ", "))
+				}
 
-				fmt.Printf("   %s\n", color.CyanString("Metadata JSON (ASCII):"))
-				fmt.Printf("      %s\n", res.Details.MetadataJSON)
-				fmt.Printf("   %s\n", color.CyanString("Metadata Hash P1 (Decimal):"))
-				fmt.Printf("      %s\n", res.Details.MetadataHashP1)
-				fmt.Printf("   %s\n", color.CyanString("Metadata Hash P2 (Decimal):"))
-				fmt.Printf("      %s\n", res.Details.MetadataHashP2)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Nullifier Hash (Decimal):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.NullifierHash)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Commitment (Decimal):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.Commitment)
 
-				fmt.Printf("   %s\n", color.CyanString("Nullifier Hash (Decimal):"))
-				fmt.Printf("      %s\n", res.Details.NullifierHash)
-				fmt.Printf("   %s\n", color.CyanString("Commitment (Decimal):"))
-				fmt.Printf("      %s\n", res.Details.Commitment)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Trust Method (Value):"))
+				fmt.Fprintf(ui, "      %s\n", res.Details.TrustMethod)
 
-				fmt.Printf("   %s\n", color.CyanString("Trust Method (Value):"))
-				fmt.Printf("      %s\n", res.Details.TrustMethod)
+				if res.Details.IssuedAt != nil {
+					fmt.Fprintf(ui, "   %s\n", color.CyanString("Issued At:"))
+					fmt.Fprintf(ui, "      %s\n", res.Details.IssuedAt.UTC().Format(time.RFC3339))
+				}
+				if res.Details.ExpiresAt != nil {
+					fmt.Fprintf(ui, "   %s\n", color.CyanString("Expires At:"))
+					fmt.Fprintf(ui, "      %s\n", res.Details.ExpiresAt.UTC().Format(time.RFC3339))
+				}
 
-				fmt.Printf("   %s\n", color.CyanString("Derived Hostname (from Commitment):"))
-				fmt.Printf("      %s\n", res.Dns.DerivedHostname)
-				fmt.Printf("   %s\n", color.CyanString("Expected TXT Record Content (SHA256):"))
-				fmt.Printf("      %s\n", crypto.Sha256Hex([]byte(res.Details.MetadataJSON)))
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Derived Hostname (from Commitment):"))
+				fmt.Fprintf(ui, "      %s\n", res.Dns.DerivedHostname)
+				fmt.Fprintf(ui, "   %s\n", color.CyanString("Expected TXT Record Content (SHA256):"))
+				fmt.Fprintf(ui, "      %s\n", crypto.Sha256Hex([]byte(res.Details.MetadataJSON)))
 			}
 		}
 
 		// Time-dev output
-		if timeDev {
-			fmt.Printf("%.4f\n", res.Dns.FetchTimeMs/1000)
-			if res.Zk.ProofTimeMs > 0 {
-				fmt.Printf("%.4f\n", res.Zk.ProofTimeMs/1000)
-			} else {
-				fmt.Printf("%.4f\n", 0.0)
-			}
+		report := verifier.NewMachineReport(res)
+		if machineFormat == "json" {
+			printMachineReport(report)
+		} else if timeDev {
+			fmt.Printf("%.4f\n", report.DNSSeconds)
+			fmt.Printf("%.4f\n", report.ProofSeconds)
 			if res.Success {
 				fmt.Println("1")
 			} else {
@@ -137,75 +452,304 @@ var verifyCmd = &cobra.Command{
 			}
 		}
 
+		if res.Evidence != nil {
+			if err := res.Evidence.WriteFile(evidencePath); err != nil {
+				printError("Failed to write evidence bundle: " + err.Error())
+				os.Exit(1)
+			}
+			if humanOutput {
+				fmt.Fprintf(ui, "%s  Evidence written to %s\n", color.BlueString(glyphInfo), evidencePath)
+			}
+		}
+
+		if verifyOutput == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(res)
+		}
+
+		if machineFormat == "json" || verifyOutput == "json" {
+			os.Exit(report.ExitCode())
+		}
 		if !res.Success {
 			os.Exit(1)
 		}
 	},
 }
 
-func runTimeSkipDev(filePath string) {
-	ptxFile, err := ptxloader.LoadPTX(filePath)
-	if err != nil {
+// exitSetup reports an error that prevents verification and exits. In the
+// JSON output modes it prints a JSON result and exits with
+// verifier.ExitError.
+func exitSetup(msg string) {
+	if machineFormat == "json" {
+		report := verifier.MachineReport{ErrorCode: verifier.CodeLoadFailed, Error: msg}
+		printMachineReport(report)
+		os.Exit(report.ExitCode())
+	}
+	if verifyOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(verifier.VerificationResult{Errors: []string{msg}, Code: verifier.CodeLoadFailed})
+		os.Exit(verifier.ExitError)
+	}
+	printError(msg)
+	os.Exit(1)
+}
+
+// parseRegistries parses --eth-registry domain=0xaddress pairs into
+// verifier.VerificationOptions.EthereumRegistries
+func parseRegistries(pairs map[string]string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	if !verifier.HasEthereum {
+		return nil, fmt.Errorf("--eth-registry requires a build with -tags ethereum")
+	}
+	registries := make(map[string]string, len(pairs))
+	for d, addr := range pairs {
+		domain, err := crypto.NormalizeDomain(d)
+		if err != nil {
+			return nil, fmt.Errorf("--eth-registry %s: %w", d, err)
+		}
+		if _, err := hex.DecodeString(strings.TrimPrefix(addr, "0x")); err != nil || len(addr) != 42 || !strings.HasPrefix(addr, "0x") {
+			return nil, fmt.Errorf("--eth-registry %s: invalid contract address %q", d, addr)
+		}
+		registries[domain] = addr
+	}
+	return registries, nil
+}
+
+// parseProofPolicy builds the proof policy of the --allow-proof-system,
+// --allow-curve and --min-circuit-version flags, or nil if none is set
+func parseProofPolicy(systems, curves []string, minVersion int) (*verifier.ProofPolicy, error) {
+	if len(systems) == 0 && len(curves) == 0 && minVersion == 0 {
+		return nil, nil
+	}
+	p := &verifier.ProofPolicy{MinCircuitVersion: minVersion}
+	for _, name := range systems {
+		s, err := ptx.ParseProofSystem(name)
+		if err != nil {
+			return nil, fmt.Errorf("--allow-proof-system: %w", err)
+		}
+		p.AllowedProofSystems = append(p.AllowedProofSystems, s)
+	}
+	for _, name := range curves {
+		c, err := ptx.ParseCurve(name)
+		if err != nil {
+			return nil, fmt.Errorf("--allow-curve: %w", err)
+		}
+		p.AllowedCurves = append(p.AllowedCurves, c)
+	}
+	return p, p.Validate()
+}
+
+// printMachineReport prints the --machine json output: a single JSON line
+func printMachineReport(r verifier.MachineReport) {
+	json.NewEncoder(os.Stdout).Encode(r)
+}
+
+func runTimeSkipDev(filePath string, data []byte) {
+	report := timeSkipDevReport(filePath, data)
+	if machineFormat == "json" {
+		printMachineReport(report)
+		os.Exit(report.ExitCode())
+	}
+
+	// Legacy lines: a lone status when the proof could not be checked
+	if report.Error != "" {
 		fmt.Println("0")
 		os.Exit(1)
 	}
+	fmt.Printf("%.5f\n", 0.0) // DNS Time (Skipped)
+	fmt.Printf("%.5f\n", report.ProofSeconds)
+	if report.OK {
+		fmt.Println("1")
+		os.Exit(0)
+	} else {
+		fmt.Println("0")
+		os.Exit(1)
+	}
+}
+
+// runBenchInternal times the pairing check of the PTX alone, see
+// verifier.PairingBench. --machine reports the median as the proof time.
+func runBenchInternal(opts verifier.VerificationOptions) {
+	b, err := verifier.NewPairingBench(opts)
+	if err != nil {
+		exitSetup(err.Error())
+	}
+	res, err := b.Run(benchInternalN)
+	if err != nil {
+		exitSetup(err.Error())
+	}
+
+	switch {
+	case machineFormat == "json":
+		printMachineReport(verifier.MachineReport{ProofSeconds: res.MedianMs / 1000, OK: true})
+	case machineFormat == "lines":
+		fmt.Printf("%.5f\n%.5f\n1\n", 0.0, res.MedianMs/1000)
+	case verifyOutput == "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+	default:
+		printHeader("Pairing Check Benchmark")
+		fmt.Fprintf(ui, "  Runs:          %d\n", res.Runs)
+		if res.KeyID != "" {
+			fmt.Fprintf(ui, "  Key:           %s\n", res.KeyID)
+		}
+		fmt.Fprintf(ui, "  Setup:         %.3f ms (load, parse, key, witness; once)\n", res.SetupMs)
+		fmt.Fprintf(ui, "  Median:        %.3f ms\n", res.MedianMs)
+		fmt.Fprintf(ui, "  Mean:          %.3f %s %.3f ms\n", res.MeanMs, glyphPM, res.StdDevMs)
+		fmt.Fprintf(ui, "  Min / Max:     %.3f / %.3f ms\n", res.MinMs, res.MaxMs)
+		fmt.Fprintf(ui, "  Allocations:   %.1f per run, %.0f bytes\n", res.AllocsPerRun, res.BytesPerRun)
+	}
+}
+
+// timeSkipDevReport verifies only the raw snarkjs proof of a PTX file against
+// verification_key.json, skipping DNS and semantic checks. data, when set,
+// holds the PTX file read from stdin.
+func timeSkipDevReport(filePath string, data []byte) verifier.MachineReport {
+	failed := func(code verifier.ErrorCode, err error) verifier.MachineReport {
+		return verifier.MachineReport{ErrorCode: code, Error: err.Error()}
+	}
+
+	var ptxFile *ptx.PtxFile
+	var err error
+	if data != nil {
+		ptxFile, err = ptxloader.ParsePTX(data, ptxloader.DefaultLoadOptions())
+	} else {
+		ptxFile, err = ptxloader.LoadPTX(filePath)
+	}
+	if err != nil {
+		return failed(verifier.CodeLoadFailed, err)
+	}
 
 	proof := ptxFile.GetProof()
 	var wrapper struct {
 		PublicSignals []string        `json:"publicSignals"`
 		Proof         json.RawMessage `json:"proof"`
 	}
-	if err := json.Unmarshal(proof.ProofData, &wrapper); err != nil {
-		fmt.Println("0")
-		os.Exit(1)
+	if err := json.Unmarshal(proof.GetProofData(), &wrapper); err != nil {
+		return failed(verifier.CodeProofInvalid, err)
 	}
 
 	circomProof, err := parser.UnmarshalCircomProofJSON(wrapper.Proof)
 	if err != nil {
-		fmt.Println("0")
-		os.Exit(1)
+		return failed(verifier.CodeProofInvalid, err)
 	}
 
 	circomVk, err := vk.LoadCircomKey("verification_key.json")
 	if err != nil {
-		fmt.Println("0")
-		os.Exit(1)
+		return failed(verifier.CodeLoadFailed, err)
 	}
 
 	gnarkProof, err := parser.ConvertCircomToGnark(circomProof, circomVk, wrapper.PublicSignals)
 	if err != nil {
-		fmt.Println("0")
-		os.Exit(1)
+		return failed(verifier.CodeProofInvalid, err)
 	}
 
 	start := time.Now()
 	valid, err := parser.VerifyProof(gnarkProof)
-	elapsed := time.Since(start).Seconds()
-
-	fmt.Printf("%.5f\n", 0.0) // DNS Time (Skipped)
-	fmt.Printf("%.5f\n", elapsed)
-	if valid && err == nil {
-		fmt.Println("1")
-		os.Exit(0)
-	} else {
-		fmt.Println("0")
-		os.Exit(1)
+	report := verifier.MachineReport{ProofSeconds: time.Since(start).Seconds(), OK: valid && err == nil}
+	if !report.OK {
+		report.ErrorCode = verifier.CodeProofInvalid
 	}
+	return report
 }
 
 func init() {
 	verifyCmd.Flags().StringSliceVar(&intendedScope, "intended-scope", nil, "intended scope")
 	verifyCmd.Flags().StringSliceVar(&intendedAudience, "intended-audience", nil, "intended audience")
 	verifyCmd.Flags().BoolVar(&strictMode, "strict", false, "enable strict mode")
+	verifyCmd.Flags().BoolVar(&dnsAfterProof, "dns-after-proof", false, "only look up the anchor once the ZK proof has verified (implied by --strict)")
+	verifyCmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "budget of the whole verification; rejects with verification_timeout and lists the stages that did not complete (0 = unbounded)")
 	verifyCmd.Flags().StringVar(&redisURL, "redis-url", "", "redis url for caching")
+	verifyCmd.Flags().BoolVar(&trackNullifiers, "track-nullifiers", false, "accept each token once: record its nullifier hash with its nonce (needs --redis-url or --nonce-db)")
+	verifyCmd.Flags().DurationVar(&maxLifetime, "max-token-lifetime", 0, "reject tokens expiring later than this from now, or never (0 = any expiration)")
+	verifyCmd.Flags().DurationVar(&maxNonceTTL, "max-nonce-ttl", verifier.DefaultMaxNonceTTL, "remember nonces at most this long")
+	verifyCmd.Flags().StringVar(&signalsPath, "signals", "", "expected public signals (JSON array) for PTX files proven with --external-signals")
+	verifyCmd.Flags().StringVar(&metadataPath, "expected-metadata", "", "file with the signed metadata JSON the PTX must carry, or stands in for when it carries none")
+	verifyCmd.Flags().StringSliceVar(&metadataKeyPaths, "metadata-key", nil, "PEM X25519 private key opening the encrypted claims of PTX files sealed for it (repeatable)")
+	verifyCmd.Flags().StringVar(&nonceDB, "nonce-db", "", "embedded nonce database file for replay protection without Redis")
 	verifyCmd.Flags().BoolVar(&timeDev, "time-dev", false, "output only time and status")
 	verifyCmd.Flags().BoolVar(&timeSkipDev, "time-skip-dev", false, "skip semantic checks, output time and status")
+	verifyCmd.Flags().BoolVar(&benchInternal, "bench-internal", false, "time only the pairing check, with the PTX, key and witness prepared once (no DNS, no nonce store)")
+	verifyCmd.Flags().IntVar(&benchInternalN, "bench-runs", 100, "pairing checks timed by --bench-internal")
+	verifyCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "print nothing but the --output json result; the exit code tells the outcome")
+	verifyCmd.Flags().StringVar(&verifyOutput, "output", "text", "result format: text, or json (the full result on stdout, exit 0 accepted, 1 rejected, 2 error)")
+	verifyCmd.Flags().StringVar(&machineFormat, "machine", "", "machine-readable time and status: json (one object, exit 0 accepted, 1 rejected, 2 error) or lines (as --time-dev)")
+	verifyCmd.Flags().IntVar(&dnsRetries, "dns-retries", 0, "additional DNS lookups when the anchor record is not found yet")
+	verifyCmd.Flags().IntVar(&verifyRetries, "retries", resilience.DefaultPolicy.Retries, "retries of transient DoH, nonce store and anchor fetch failures, with jittered backoff")
+	verifyCmd.Flags().DurationVar(&dnsRetryBackoff, "dns-retry-backoff", time.Second, "delay before the first DNS retry (doubles per attempt)")
+	verifyCmd.Flags().StringVar(&dnsMatch, "dns-match", "exact", "TXT anchor match mode: exact or prefix")
+	verifyCmd.Flags().StringVar(&dnsOutage, "dns-outage", "fail-closed", "when the DoH resolver is unreachable: fail-closed, fail-open (accept with a warning) or accept-if-cached")
+	verifyCmd.Flags().StringVar(&domainPolicy, "domain-policy", "all", "which domains of a multi-domain PTX must publish the anchor: all, any or primary")
+	verifyCmd.Flags().StringVar(&ipfsGateway, "ipfs-gateway", ipfs.DefaultGateway, "IPFS gateway fetching the documents of IPFS anchors")
+	verifyCmd.Flags().StringVar(&ethRPC, "eth-rpc", "", "Ethereum JSON-RPC endpoint reading ETHEREUM anchors (requires a build with -tags ethereum)")
+	verifyCmd.Flags().StringToStringVar(&ethRegistries, "eth-registry", nil, "registry contract trusted to anchor a domain's tokens, as domain=0xaddress (repeatable)")
+	verifyCmd.Flags().StringVar(&dohURL, "doh-url", dns.DefaultEndpoint, "DoH resolver URL")
+	verifyCmd.Flags().StringVar(&dohFormat, "doh-format", "json", "DoH dialect: json (dns-json API) or wire (RFC 8484 POST)")
+	verifyCmd.Flags().DurationVar(&dohTimeout, "doh-timeout", 10*time.Second, "timeout for a single DoH query")
+	verifyCmd.Flags().StringVar(&dohNetwork, "doh-network", "", "restrict DoH connections to tcp4 or tcp6")
+	verifyCmd.Flags().StringVar(&dohProxy, "doh-proxy", "", "proxy URL for DoH queries (defaults to --proxy)")
+	verifyCmd.Flags().StringVar(&socksProxy, "socks-proxy", "", "SOCKS5 proxy (e.g. Tor at socks5://127.0.0.1:9050) for DoH queries and anchor fetches, hiding the checked hostnames from the network")
+	verifyCmd.Flags().BoolVar(&dohPrivacy, "doh-privacy", false, "DoH privacy mode: pad queries to 128-byte blocks (RFC 8467) and spread them over --doh-privacy-resolver")
+	verifyCmd.Flags().StringSliceVar(&dohResolvers, "doh-privacy-resolver", nil, "further DoH resolver picked at random per query in privacy mode (repeatable)")
+	verifyCmd.Flags().DurationVar(&dohStagger, "doh-stagger", 0, "in privacy mode, delay each DoH query by a random duration up to this")
+	verifyCmd.Flags().StringVar(&dohCAFile, "doh-ca-file", "", "PEM CA bundle used to verify the DoH resolver (defaults to --ca-file)")
+	verifyCmd.Flags().BoolVar(&dohHTTP1, "doh-http1", false, "disable HTTP/2 for DoH queries")
+	verifyCmd.Flags().StringVar(&keySetPath, "keyset", "", "JSON key set of rotating verification keys (replaces native.vk)")
+	verifyCmd.Flags().StringVar(&vkURL, "vk-url", "", "fetch the native verification key from this https URL (replaces native.vk)")
+	verifyCmd.Flags().StringVar(&vkSHA256, "vk-sha256", "", "pin the key fetched with --vk-url to this SHA-256 (hex, required with --vk-url)")
+	verifyCmd.Flags().StringVar(&vkCacheDir, "vk-cache-dir", "", "cache fetched verification keys in this directory for offline use")
+	verifyCmd.Flags().BoolVar(&discoverKeys, "discover", false, "select the key from the issuer's /.well-known/ptx-configuration (ignored with --keyset)")
+	verifyCmd.Flags().StringVar(&policyPath, "policy", "", "JSON acceptance policy applied after all checks pass")
+	verifyCmd.Flags().StringSliceVar(&proofSystems, "allow-proof-system", nil, "accept only proofs of this proof system, e.g. groth16 (repeatable)")
+	verifyCmd.Flags().StringSliceVar(&curves, "allow-curve", nil, "accept only proofs on this curve, e.g. bn254 (repeatable)")
+	verifyCmd.Flags().IntVar(&minCircuit, "min-circuit-version", 0, "refuse proofs of circuits older than this revision, e.g. 3 refuses the v1 circuits (0 = any)")
+	verifyCmd.Flags().StringVar(&webhookURL, "webhook", "", "POST the verification outcome as JSON to this URL")
+	verifyCmd.Flags().StringVar(&webhookSecret, "webhook-secret", "", "HMAC-SHA256 secret used to sign webhook bodies ("+events.SignatureHeader+")")
+	verifyCmd.Flags().StringVar(&auditDBURL, "audit-db", "", "record the outcome in this audit database (sqlite:<path> or postgres://...)")
+	verifyCmd.Flags().StringVar(&evidencePath, "evidence", "", "write an audit evidence bundle (JSON) to this path")
+	verifyCmd.Flags().StringVar(&evidenceKeyPath, "evidence-key", "", "PEM ed25519 private key used to sign the evidence bundle")
+	verifyCmd.Flags().StringVar(&tsaRootsPath, "tsa-roots", "", "PEM roots RFC 3161 timestamps must chain to (default: system roots)")
+	verifyCmd.Flags().BoolVar(&requireTimestamp, "require-timestamp", false, "reject PTX files without an RFC 3161 timestamp")
+	verifyCmd.Flags().BoolVar(&legacySignals, "legacy-signal-scan", false, "match public signals by value scan (for proofs with an unregistered key ID; ignored with --strict)")
 	rootCmd.AddCommand(verifyCmd)
 }
 
+func printSemanticReport(r *signals.VerificationResult) {
+	fmt.Fprintf(ui, "   %s\n", color.CyanString("Semantic Checks:"))
+	printCheck("FQDN Hash", r.FqdnHash)
+	printCheck("Metadata Hash P1", r.MetadataPart1)
+	printCheck("Metadata Hash P2", r.MetadataPart2)
+	printCheck("Trust Method", r.TrustMethod)
+}
+
+func printDNSTiming(t *dns.Timing) {
+	fmt.Fprintf(ui, "   %s\n", color.CyanString("DoH Connection:"))
+	fmt.Fprintf(ui, "      Protocol: %s (reused: %v)\n", t.Protocol, t.Reused)
+	if t.RemoteAddr != "" {
+		fmt.Fprintf(ui, "      Remote:   %s\n", t.RemoteAddr)
+	}
+	fmt.Fprintf(ui, "      DNS: %v  Connect: %v  TLS: %v  First byte: %v  Total: %v\n",
+		t.DNSLookup.Round(time.Microsecond), t.Connect.Round(time.Microsecond),
+		t.TLSHandshake.Round(time.Microsecond), t.FirstByte.Round(time.Microsecond),
+		t.Total.Round(time.Microsecond))
+}
+
+func printCheck(label string, ok bool) {
+	if ok {
+		fmt.Fprintf(ui, "      %s %s\n", color.GreenString(glyphOK), label)
+	} else {
+		fmt.Fprintf(ui, "      %s %s\n", color.RedString(glyphFail), label)
+	}
+}
+
 func printHeader(msg string) {
 	cyan := color.New(color.FgCyan).SprintFunc()
-	fmt.Printf("\n%s\n%s%s\n%s\n",
+	fmt.Fprintf(ui, "\n%s\n%s%s\n%s\n",
 		cyan(strings.Repeat("=", 64)),
 		strings.Repeat(" ", (64-len(msg))/2), msg,
 		cyan(strings.Repeat("=", 64)))
@@ -213,16 +757,20 @@ func printHeader(msg string) {
 
 func printSection(msg string) {
 	blue := color.New(color.FgBlue).SprintFunc()
-	fmt.Printf("\n%s %s %s\n",
+	fmt.Fprintf(ui, "\n%s %s %s\n",
 		blue(strings.Repeat("=", (64-len(msg)-2)/2)),
 		msg,
 		blue(strings.Repeat("=", (64-len(msg)-2)/2)))
 }
 
 func printSuccess(msg string) {
-	fmt.Printf("%s✔  %s\n", color.GreenString(""), msg)
+	fmt.Fprintf(ui, "%s  %s\n", color.GreenString(glyphOK), msg)
 }
 
 func printError(msg string) {
-	fmt.Printf("%s✖  [ERROR] %s\n", color.RedString(""), msg)
+	fmt.Fprintf(ui, "%s  [ERROR] %s\n", color.RedString(glyphFail), msg)
+}
+
+func printWarning(msg string) {
+	fmt.Fprintf(ui, "%s  [WARN] %s\n", color.YellowString(glyphWarn), msg)
 }
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/bundle"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/compat"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// version is set at release time with -ldflags "-X main.version=v1.2.3".
+// Otherwise the module version recorded by go install is used.
+var version = "dev"
+
+var (
+	versionJSON   bool
+	versionVKPath string
+	versionKeySet string
+	versionCCS    bool
+)
+
+// buildReport describes the binary and what it can verify, so prover and
+// verifier builds of a fleet can be compared
+type buildReport struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+	Tags      string `json:"tags,omitempty"`
+	// Dependencies holds the versions of the proving libraries, whose
+	// serialization formats the keys and proofs depend on
+	Dependencies map[string]string `json:"dependencies"`
+	Features     struct {
+		Ethereum bool `json:"ethereum"`
+		GPU      bool `json:"gpu"`
+	} `json:"features"`
+	Formats struct {
+		PTX           []int `json:"ptx"`
+		Evidence      int   `json:"evidence"`
+		Bundle        int   `json:"bundle"`
+		CompatVectors int   `json:"compatVectors"`
+	} `json:"formats"`
+	Circuits         []circuitInfo `json:"circuits"`
+	VerificationKeys []keyInfo     `json:"verificationKeys"`
+}
+
+// circuitInfo is a circuit ID with a registered public signal layout
+type circuitInfo struct {
+	ID             string `json:"id"`
+	PublicSignals  int    `json:"publicSignals"`
+	MetadataChunks int    `json:"metadataChunks,omitempty"`
+	BindsScope     bool   `json:"bindsScope,omitempty"`
+	// Identity is the circuit identity of this build, see circuit.Identity.
+	// It is only computed with --circuit-identity.
+	Identity string `json:"identity,omitempty"`
+}
+
+// keyInfo fingerprints a verification key and lists the circuits whose layout
+// has as many public signals as the key has public inputs
+type keyInfo struct {
+	ID           string `json:"id,omitempty"`
+	Source       string `json:"source"`
+	SHA256       string `json:"sha256,omitempty"`
+	PublicInputs int    `json:"publicInputs,omitempty"`
+	Circuit      string `json:"circuit,omitempty"`
+	// CircuitIdentity is the circuit identity the key set declares for the
+	// key
+	CircuitIdentity string   `json:"circuitIdentity,omitempty"`
+	Matches         []string `json:"matches,omitempty"`
+	// Compatible reports, for keys declaring their circuit, whether that
+	// circuit is among Matches
+	Compatible *bool  `json:"compatible,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the build, supported formats, circuits and key fingerprints",
+	Long: `Report the binary version and commit, the gnark and gnark-crypto versions,
+the PTX, evidence and bundle format versions, the circuit IDs this build knows
+the public signals of, and the SHA-256 fingerprint of the verification keys
+it would load (--vk, --keyset), matched against those circuits.
+
+--circuit-identity compiles every circuit and reports its identity, the
+SHA-256 of its constraint system, which changes with any change to the
+circuit definition. Key set keys declaring a circuitHash are then checked
+against it.
+
+Keys given by URL in a key set are not fetched; their pinned SHA-256 is shown.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		r := newBuildReport()
+		if versionVKPath != "" {
+			data, err := os.ReadFile(versionVKPath)
+			if err == nil || cmd.Flags().Changed("vk") {
+				r.VerificationKeys = append(r.VerificationKeys, describeKey(keyInfo{Source: versionVKPath}, data, err))
+			}
+		}
+		if versionKeySet != "" {
+			keys, err := keySetInfo(versionKeySet)
+			if err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			r.VerificationKeys = append(r.VerificationKeys, keys...)
+		}
+		if versionCCS {
+			if err := addCircuitIdentities(r); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if versionJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(r)
+			return
+		}
+		printBuildReport(r)
+	},
+}
+
+func init() {
+	rootCmd.Version = buildVersion()
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "print the report as JSON on stdout")
+	versionCmd.Flags().StringVar(&versionVKPath, "vk", "native.vk", "native verification key to fingerprint (skipped if the default is absent)")
+	versionCmd.Flags().StringVar(&versionKeySet, "keyset", "", "JSON key set whose keys are fingerprinted")
+	versionCmd.Flags().BoolVar(&versionCCS, "circuit-identity", false, "compile the circuits and report their constraint system hash (takes seconds)")
+	rootCmd.AddCommand(versionCmd)
+}
+
+// buildVersion returns version, or the module version of a go install build
+func buildVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
+
+func newBuildReport() *buildReport {
+	r := &buildReport{
+		Version:          buildVersion(),
+		GoVersion:        runtime.Version(),
+		Platform:         runtime.GOOS + "/" + runtime.GOARCH,
+		Dependencies:     map[string]string{},
+		VerificationKeys: []keyInfo{},
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				r.Commit = s.Value
+			case "vcs.time":
+				r.BuildTime = s.Value
+			case "vcs.modified":
+				r.Modified = s.Value == "true"
+			case "-tags":
+				r.Tags = s.Value
+			}
+		}
+		for _, dep := range info.Deps {
+			switch dep.Path {
+			case "github.com/consensys/gnark", "github.com/consensys/gnark-crypto":
+				if dep.Replace != nil {
+					dep = dep.Replace
+				}
+				r.Dependencies[dep.Path[strings.LastIndex(dep.Path, "/")+1:]] = dep.Version
+			}
+		}
+	}
+	r.Features.Ethereum = verifier.HasEthereum
+	r.Features.GPU = prover.HasGPU
+	for v := ptxloader.MinFormatVersion; v <= ptxloader.FormatVersion; v++ {
+		r.Formats.PTX = append(r.Formats.PTX, v)
+	}
+	r.Formats.Evidence = evidence.Version
+	r.Formats.Bundle = bundle.Version
+	r.Formats.CompatVectors = compat.Version
+
+	for _, id := range signals.KeyIDs() {
+		l, _ := signals.LayoutFor(id)
+		r.Circuits = append(r.Circuits, circuitInfo{
+			ID:             id,
+			PublicSignals:  l.Len(),
+			MetadataChunks: l.MetadataChunks,
+			BindsScope:     l.BindsScope(),
+		})
+	}
+	return r
+}
+
+// keySetInfo describes the keys of a key set, reading its key files
+func keySetInfo(path string) ([]keyInfo, error) {
+	ks, err := verifier.LoadKeySet(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key set: %w", err)
+	}
+	keys := make([]keyInfo, 0, len(ks.Keys))
+	for _, k := range ks.Keys {
+		circuitID := k.Circuit
+		if circuitID == "" {
+			circuitID = signals.DefaultVerificationKeyID
+		}
+		info := keyInfo{ID: k.ID, Source: k.Path, Circuit: circuitID}
+		if k.CircuitHash != "" {
+			info.CircuitIdentity = circuit.Identity(circuitID, k.CircuitHash)
+		}
+		switch {
+		case len(k.VK) > 0:
+			info.Source = "inline"
+			keys = append(keys, describeKey(info, k.VK, nil))
+		case k.URL != "":
+			info.Source = k.URL
+			info.SHA256 = k.SHA256
+			keys = append(keys, info)
+		default:
+			data, err := os.ReadFile(k.Path)
+			keys = append(keys, describeKey(info, data, err))
+		}
+	}
+	return keys, nil
+}
+
+// addCircuitIdentities compiles every circuit of r and records its identity.
+// Keys declaring another identity for their circuit are marked incompatible.
+func addCircuitIdentities(r *buildReport) error {
+	identities := map[string]string{}
+	for i := range r.Circuits {
+		c := &r.Circuits[i]
+		hash, err := prover.CircuitHash(c.ID)
+		if err != nil {
+			return fmt.Errorf("circuit %s: %w", c.ID, err)
+		}
+		c.Identity = circuit.Identity(c.ID, hash)
+		identities[c.ID] = c.Identity
+	}
+	for i := range r.VerificationKeys {
+		k := &r.VerificationKeys[i]
+		if want, ok := identities[k.Circuit]; ok && k.CircuitIdentity != "" && k.CircuitIdentity != want {
+			compatible := false
+			k.Compatible = &compatible
+		}
+	}
+	return nil
+}
+
+// describeKey fingerprints a serialized native key and matches its number of
+// public inputs against the registered circuits
+func describeKey(info keyInfo, data []byte, err error) keyInfo {
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.SHA256 = evidence.Hash(data)
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(bytes.NewReader(data)); err != nil {
+		info.Error = "not a native BN254 verification key: " + err.Error()
+		return info
+	}
+	info.PublicInputs = vk.NbPublicWitness()
+	for _, id := range signals.KeyIDs() {
+		if l, _ := signals.LayoutFor(id); l.Len() == info.PublicInputs {
+			info.Matches = append(info.Matches, id)
+		}
+	}
+	if info.Circuit != "" {
+		ok := slices.Contains(info.Matches, info.Circuit)
+		info.Compatible = &ok
+	}
+	return info
+}
+
+func printBuildReport(r *buildReport) {
+	printHeader("Jesuit " + r.Version)
+	commit := r.Commit
+	if commit == "" {
+		commit = "unknown"
+	} else if r.Modified {
+		commit += " (modified)"
+	}
+	fmt.Fprintf(ui, "   Commit:       %s\n", commit)
+	if r.BuildTime != "" {
+		fmt.Fprintf(ui, "   Built:        %s\n", r.BuildTime)
+	}
+	fmt.Fprintf(ui, "   Go:           %s %s\n", r.GoVersion, r.Platform)
+	if r.Tags != "" {
+		fmt.Fprintf(ui, "   Build tags:   %s\n", r.Tags)
+	}
+	fmt.Fprintf(ui, "   gnark:        %s\n", dependencyVersion(r, "gnark"))
+	fmt.Fprintf(ui, "   gnark-crypto: %s\n", dependencyVersion(r, "gnark-crypto"))
+	fmt.Fprintf(ui, "   Features:     ethereum anchors %s, GPU proving %s\n", yesNo(r.Features.Ethereum), yesNo(r.Features.GPU))
+	fmt.Fprintf(ui, "   Formats:      PTX v%d, evidence v%d, bundle v%d, compat vectors v%d\n",
+		r.Formats.PTX[0], r.Formats.Evidence, r.Formats.Bundle, r.Formats.CompatVectors)
+
+	printSection("Circuits")
+	for _, c := range r.Circuits {
+		extra := ""
+		if c.MetadataChunks > 0 {
+			extra += fmt.Sprintf(", %d metadata chunks", c.MetadataChunks)
+		}
+		if c.BindsScope {
+			extra += ", binds audience and scopes"
+		}
+		fmt.Fprintf(ui, "   %-24s %2d public signals%s\n", c.ID, c.PublicSignals, extra)
+		if c.Identity != "" {
+			fmt.Fprintf(ui, "      Identity: %s\n", c.Identity)
+		}
+	}
+
+	if len(r.VerificationKeys) == 0 {
+		return
+	}
+	printSection("Verification Keys")
+	for _, k := range r.VerificationKeys {
+		name := k.Source
+		if k.ID != "" {
+			name = k.ID + " (" + k.Source + ")"
+		}
+		if k.Error != "" {
+			printError(fmt.Sprintf("%s: %s", name, k.Error))
+			continue
+		}
+		fmt.Fprintf(ui, "   %s\n", color.CyanString(name))
+		if k.PublicInputs == 0 {
+			pin := k.SHA256
+			if pin == "" {
+				pin = "not pinned"
+			}
+			fmt.Fprintf(ui, "      SHA-256:  %s (pinned, not fetched)\n", pin)
+			continue
+		}
+		fmt.Fprintf(ui, "      SHA-256:  %s\n", k.SHA256)
+		matches := "none"
+		if len(k.Matches) > 0 {
+			matches = strings.Join(k.Matches, ", ")
+		}
+		fmt.Fprintf(ui, "      Inputs:   %d public (matches %s)\n", k.PublicInputs, matches)
+		if k.CircuitIdentity != "" {
+			fmt.Fprintf(ui, "      Identity: %s\n", k.CircuitIdentity)
+		}
+		if k.Compatible != nil {
+			printCheck("Circuit "+k.Circuit, *k.Compatible)
+		}
+	}
+}
+
+func dependencyVersion(r *buildReport, name string) string {
+	if v := r.Dependencies[name]; v != "" {
+		return v
+	}
+	return "unknown"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
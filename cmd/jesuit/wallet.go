@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/wallet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	walletPath           string
+	walletPassphraseFile string
+	walletName           string
+	walletNullifier      string
+	walletSecret         string
+	walletHex            bool
+	walletJSON           bool
+	walletReveal         bool
+	walletExport         string
+	walletExpiresIn      time.Duration
+	walletOut            string
+	walletAnchorKey      string
+	walletTSA            string
+)
+
+var walletCmd = &cobra.Command{
+	Use:   "wallet",
+	Short: "Keep PTX tokens and the secrets they were minted with",
+	Long: `The wallet stores PTX tokens together with their domain, expiry, audience,
+scopes and the nullifier and secret they were proven with, in one file
+encrypted with a passphrase (AES-256-GCM, key derived with scrypt).
+
+The passphrase is read from $` + wallet.PassphraseEnv + `, else from --passphrase-file,
+else prompted for on the terminal.`,
+}
+
+var walletAddCmd = &cobra.Command{
+	Use:   "add <file.ptx>",
+	Short: "Add a PTX file to the wallet",
+	Long: `Add a PTX file to the wallet. Give the --nullifier and --secret it was
+proven with to be able to renew it; they are checked against the commitment
+of the token before they are stored.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := ptxloader.ReadFile(args[0], ptxloader.DefaultLoadOptions())
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		nullifier, secret := walletNullifier, walletSecret
+		if walletHex && nullifier != "" {
+			if nullifier, err = prover.DecimalFromHex("nullifier", nullifier); err == nil {
+				secret, err = prover.DecimalFromHex("secret", secret)
+			}
+			if err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+		}
+		t, err := wallet.TokenFromPTX(data, nullifier, secret)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		t.Name = walletName
+
+		w := openWallet()
+		if err := w.Add(t); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		saveWallet(w)
+		printSuccess(fmt.Sprintf("Added %s as %q", args[0], t.Name))
+		if !t.HasSecrets() {
+			fmt.Fprintln(ui, "  No nullifier and secret stored: the token cannot be renewed.")
+		}
+	},
+}
+
+var walletListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tokens in the wallet",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := openWallet()
+		if walletJSON {
+			redacted := make([]walletEntry, len(w.Tokens))
+			for i, t := range w.Tokens {
+				redacted[i] = newWalletEntry(t)
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(redacted)
+			return
+		}
+		if len(w.Tokens) == 0 {
+			fmt.Fprintln(ui, "The wallet is empty.")
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tDOMAIN\tAUDIENCE\tSCOPES\tEXPIRES\tRENEWABLE")
+		now := time.Now()
+		for _, t := range w.Tokens {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", t.Name, t.Domain, orDash(t.Audience),
+				orDash(strings.Join(t.Scopes, ",")), expiryLabel(t, now), yesNo(t.HasSecrets()))
+		}
+		tw.Flush()
+	},
+}
+
+var walletShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a token of the wallet",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := openWallet()
+		t, err := w.Get(args[0])
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		if walletExport != "" {
+			if err := ioutil.WriteFile(walletExport, t.PTX, 0644); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			printSuccess(fmt.Sprintf("Wrote %s", walletExport))
+		}
+
+		entry := newWalletEntry(t)
+		if walletReveal {
+			entry.Nullifier, entry.Secret = t.Nullifier, t.Secret
+		}
+		if walletJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(entry)
+			return
+		}
+
+		printHeader("TOKEN " + t.Name)
+		fmt.Printf("  Domain:         %s\n", t.Domain)
+		fmt.Printf("  Trust method:   %d\n", t.TrustMethod)
+		fmt.Printf("  Key ID:         %s\n", t.KeyID)
+		fmt.Printf("  Audience:       %s\n", orDash(t.Audience))
+		fmt.Printf("  Scopes:         %s\n", orDash(strings.Join(t.Scopes, ", ")))
+		fmt.Printf("  Issued:         %s\n", t.IssuedAt.Format(time.RFC3339))
+		fmt.Printf("  Expires:        %s\n", expiryLabel(t, time.Now()))
+		fmt.Printf("  Commitment:     %s\n", t.Commitment)
+		fmt.Printf("  Nullifier hash: %s\n", t.NullifierHash)
+		if record, err := t.Anchor(); err == nil {
+			fmt.Printf("  Anchor:         %s TXT %q\n", record.Hostname, record.Value)
+		}
+		for i, c := range t.Previous {
+			fmt.Printf("  Renewed from:   %s (%d)\n", c, len(t.Previous)-i)
+		}
+		switch {
+		case !t.HasSecrets():
+			fmt.Println("  Secrets:        not stored")
+		case walletReveal:
+			fmt.Printf("  Nullifier:      %s\n", t.Nullifier)
+			fmt.Printf("  Secret:         %s\n", t.Secret)
+		default:
+			fmt.Println("  Secrets:        stored (--reveal to print)")
+		}
+	},
+}
+
+var walletRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a token and its secrets from the wallet",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := openWallet()
+		if err := w.Remove(args[0]); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		saveWallet(w)
+		printSuccess(fmt.Sprintf("Removed %q", args[0]))
+	},
+}
+
+var walletRenewCmd = &cobra.Command{
+	Use:   "renew <name>",
+	Short: "Re-issue a token with a new expiration and the same nullifier",
+	Long: `Prove the token again with its stored nullifier and secret and a new
+expiration (the old lifetime unless --expires-in is given), keeping its
+metadata, anchors and circuit. The renewed token keeps the nullifier hash, so
+relying parties see the same holder, but its commitment changes: publish the
+anchor record printed before using it. The wallet entry is replaced and the
+old commitment recorded in its lineage.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := openWallet()
+		t, err := w.Get(args[0])
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		opts := wallet.RenewOptions{ExpiresIn: walletExpiresIn}
+		if walletAnchorKey != "" {
+			if opts.AnchorKey, err = evidence.LoadSigningKey(walletAnchorKey); err != nil {
+				printError("anchor key: " + err.Error())
+				os.Exit(1)
+			}
+		}
+		if walletTSA != "" {
+			opts.TSA = &tsa.Client{URL: walletTSA}
+		}
+
+		fmt.Fprintf(ui, "Renewing %q...\n", t.Name)
+		renewed, err := wallet.Renew(context.Background(), t, opts)
+		if err != nil {
+			printError("Renewal failed: " + err.Error())
+			os.Exit(1)
+		}
+		if err := w.Replace(renewed); err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		saveWallet(w)
+		printSuccess(fmt.Sprintf("Renewed %q, expires %s", renewed.Name, expiryLabel(renewed, time.Now())))
+
+		if walletOut != "" {
+			if err := ioutil.WriteFile(walletOut, renewed.PTX, 0644); err != nil {
+				printError(err.Error())
+				os.Exit(1)
+			}
+			fmt.Fprintf(ui, "  PTX file: %s\n", walletOut)
+		}
+		record, err := renewed.Anchor()
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Fprintln(ui, "  Publish the anchor record:")
+		fmt.Fprintf(ui, "    %s TXT %q\n", record.Hostname, record.Value)
+	},
+}
+
+// walletEntry is the JSON form of a token without its PTX bytes, and without
+// its secrets unless revealed
+type walletEntry struct {
+	Name          string     `json:"name"`
+	Domain        string     `json:"domain"`
+	TrustMethod   int        `json:"trustMethod"`
+	KeyID         string     `json:"keyId"`
+	Audience      string     `json:"audience,omitempty"`
+	Scopes        []string   `json:"scopes,omitempty"`
+	IssuedAt      time.Time  `json:"issuedAt"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	Commitment    string     `json:"commitment"`
+	NullifierHash string     `json:"nullifierHash"`
+	Renewable     bool       `json:"renewable"`
+	Previous      []string   `json:"previous,omitempty"`
+	Nullifier     string     `json:"nullifier,omitempty"`
+	Secret        string     `json:"secret,omitempty"`
+}
+
+func newWalletEntry(t *wallet.Token) walletEntry {
+	return walletEntry{
+		Name:          t.Name,
+		Domain:        t.Domain,
+		TrustMethod:   t.TrustMethod,
+		KeyID:         t.KeyID,
+		Audience:      t.Audience,
+		Scopes:        t.Scopes,
+		IssuedAt:      t.IssuedAt,
+		ExpiresAt:     t.ExpiresAt,
+		Commitment:    t.Commitment,
+		NullifierHash: t.NullifierHash,
+		Renewable:     t.HasSecrets(),
+		Previous:      t.Previous,
+	}
+}
+
+// openWallet opens the wallet of --wallet, exiting on failure
+func openWallet() *wallet.Wallet {
+	passphrase, err := walletPassphrase()
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	w, err := wallet.Open(walletPath, passphrase)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	return w
+}
+
+func saveWallet(w *wallet.Wallet) {
+	if err := w.Save(); err != nil {
+		printError("Failed to save wallet: " + err.Error())
+		os.Exit(1)
+	}
+}
+
+// walletPassphrase reads the passphrase from the environment, the passphrase
+// file or standard input, in that order
+func walletPassphrase() ([]byte, error) {
+	if p := os.Getenv(wallet.PassphraseEnv); p != "" {
+		return []byte(p), nil
+	}
+	if walletPassphraseFile != "" {
+		data, err := ioutil.ReadFile(walletPassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	}
+	fmt.Fprint(ui, "Wallet passphrase: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, errors.New("no wallet passphrase given")
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+func expiryLabel(t *wallet.Token, now time.Time) string {
+	switch {
+	case t.ExpiresAt == nil:
+		return "never"
+	case t.Expired(now):
+		return t.ExpiresAt.Format(time.RFC3339) + " (expired)"
+	default:
+		return t.ExpiresAt.Format(time.RFC3339)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	walletCmd.PersistentFlags().StringVar(&walletPath, "wallet", wallet.DefaultPath(), "wallet file")
+	walletCmd.PersistentFlags().StringVar(&walletPassphraseFile, "passphrase-file", "", "read the wallet passphrase from this file (default $"+wallet.PassphraseEnv+" or a prompt)")
+	walletAddCmd.Flags().StringVar(&walletName, "name", "", "name of the token in the wallet (default: its domain)")
+	walletAddCmd.Flags().StringVar(&walletNullifier, "nullifier", "", "nullifier the token was proven with")
+	walletAddCmd.Flags().StringVar(&walletSecret, "secret", "", "secret the token was proven with")
+	walletAddCmd.Flags().BoolVar(&walletHex, "hex", false, "--nullifier and --secret are hexadecimal")
+	walletListCmd.Flags().BoolVar(&walletJSON, "json", false, "print JSON to stdout")
+	walletShowCmd.Flags().BoolVar(&walletJSON, "json", false, "print JSON to stdout")
+	walletShowCmd.Flags().BoolVar(&walletReveal, "reveal", false, "print the nullifier and secret")
+	walletShowCmd.Flags().StringVar(&walletExport, "export", "", "write the PTX file of the token to this path")
+	walletRenewCmd.Flags().DurationVar(&walletExpiresIn, "expires-in", 0, "lifetime of the renewed token (default: the lifetime of the old one)")
+	walletRenewCmd.Flags().StringVar(&walletOut, "out", "", "also write the renewed PTX file to this path")
+	walletRenewCmd.Flags().StringVar(&walletAnchorKey, "anchor-key", "", "anchor signing key, for tokens anchored under a fixed label")
+	walletRenewCmd.Flags().StringVar(&walletTSA, "tsa-url", "", "RFC 3161 Time-Stamping Authority timestamping the renewed PTX file")
+	walletCmd.AddCommand(walletAddCmd, walletListCmd, walletShowCmd, walletRemoveCmd, walletRenewCmd)
+	rootCmd.AddCommand(walletCmd)
+}
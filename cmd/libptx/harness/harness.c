@@ -0,0 +1,59 @@
+/*
+ * Minimal C harness for libptx.
+ *
+ *   go build -buildmode=c-shared -o libptx.so ./cmd/libptx
+ *   cc -o harness cmd/libptx/harness/harness.c -I. -L. -lptx
+ *   LD_LIBRARY_PATH=. ./harness token.ptx '{"vkPath":"native.vk"}'
+ *
+ * Prints the JSON result and exits 0 if verification succeeded.
+ */
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "libptx.h"
+
+static unsigned char *read_file(const char *path, long *len) {
+	FILE *f = fopen(path, "rb");
+	if (!f) {
+		return NULL;
+	}
+	fseek(f, 0, SEEK_END);
+	*len = ftell(f);
+	fseek(f, 0, SEEK_SET);
+
+	unsigned char *buf = malloc(*len > 0 ? *len : 1);
+	if (buf && fread(buf, 1, *len, f) != (size_t)*len) {
+		free(buf);
+		buf = NULL;
+	}
+	fclose(f);
+	return buf;
+}
+
+int main(int argc, char **argv) {
+	if (argc < 2) {
+		fprintf(stderr, "usage: %s <file.ptx> [options_json]\n", argv[0]);
+		return 2;
+	}
+
+	long len = 0;
+	unsigned char *data = read_file(argv[1], &len);
+	if (!data) {
+		perror(argv[1]);
+		return 2;
+	}
+
+	char *version = ptx_version();
+	fprintf(stderr, "libptx ABI %s\n", version);
+	ptx_free(version);
+
+	/* The library copies its inputs, so data can be freed right away */
+	char *result = ptx_verify(data, (int)len, argc > 2 ? argv[2] : NULL);
+	free(data);
+
+	printf("%s\n", result);
+	int ok = strstr(result, "\"success\":true") != NULL;
+	ptx_free(result);
+	return ok ? 0 : 1;
+}
@@ -0,0 +1,37 @@
+"""Call libptx from Python via ctypes.
+
+    go build -buildmode=c-shared -o libptx.so ./cmd/libptx
+    python3 cmd/libptx/harness/ptx_verify.py ./libptx.so token.ptx native.vk
+"""
+
+import ctypes
+import json
+import sys
+
+
+def load(path):
+    lib = ctypes.CDLL(path)
+    lib.ptx_verify.argtypes = [ctypes.c_char_p, ctypes.c_int, ctypes.c_char_p]
+    # Keep the raw pointer so it can be handed back to ptx_free
+    lib.ptx_verify.restype = ctypes.c_void_p
+    lib.ptx_free.argtypes = [ctypes.c_void_p]
+    lib.ptx_free.restype = None
+    return lib
+
+
+def verify(lib, ptx, options=None):
+    opts = json.dumps(options).encode() if options is not None else None
+    ptr = lib.ptx_verify(ptx, len(ptx), opts)
+    try:
+        return json.loads(ctypes.string_at(ptr).decode())
+    finally:
+        lib.ptx_free(ptr)
+
+
+if __name__ == "__main__":
+    lib = load(sys.argv[1])
+    with open(sys.argv[2], "rb") as f:
+        data = f.read()
+    result = verify(lib, data, {"vkPath": sys.argv[3]} if len(sys.argv) > 3 else None)
+    print(json.dumps(result, indent=2))
+    sys.exit(0 if result.get("success") else 1)
@@ -0,0 +1,129 @@
+//go:build cgo
+
+// Command libptx builds the verifier as a C shared library:
+//
+//	go build -buildmode=c-shared -o libptx.so ./cmd/libptx
+//
+// This also writes libptx.h. The exported API is:
+//
+//	char *ptx_verify(void *ptx, int ptxLen, char *optionsJSON);
+//	void  ptx_free(char *result);
+//	char *ptx_version(void);
+//
+// Memory ownership:
+//   - ptx and optionsJSON are only read during the call and are never
+//     retained; the caller keeps ownership and may free them on return.
+//   - Every char* returned by the library is allocated with malloc and owned
+//     by the caller, who must release it with ptx_free (or free). It is never
+//     NULL.
+//
+// optionsJSON may be NULL. It accepts the fields of verifier.BytesOptions
+// plus:
+//
+//	"vkPath":   native verification key file (default "native.vk")
+//	"vk":       base64 encoded key, used instead of vkPath
+//	"redisUrl": nonce store for replay protection
+//	"nonceDb":  embedded nonce database file, used instead of redisUrl
+//	"trackNullifiers": accept each token once (needs redisUrl or nonceDb)
+//
+// The result is the JSON encoded verification result, or {"error": "..."}
+// when the PTX could not be processed at all. All functions are safe to call
+// from multiple threads.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/consensys/gnark/logger"
+)
+
+// version is the library ABI version reported by ptx_version
+const version = "1"
+
+func init() {
+	// The host process owns stdout; keep gnark's debug logging off it
+	logger.Disable()
+}
+
+type options struct {
+	verifier.BytesOptions
+	VKPath   string `json:"vkPath,omitempty"`
+	VK       []byte `json:"vk,omitempty"`
+	RedisURL string `json:"redisUrl,omitempty"`
+	NonceDB  string `json:"nonceDb,omitempty"`
+	// TrackNullifiers sets VerificationOptions.TrackNullifiers
+	TrackNullifiers bool `json:"trackNullifiers,omitempty"`
+}
+
+//export ptx_verify
+func ptx_verify(ptx unsafe.Pointer, ptxLen C.int, optionsJSON *C.char) (result *C.char) {
+	// A panic crossing the cgo boundary aborts the host process
+	defer func() {
+		if r := recover(); r != nil {
+			result = errorResult(fmt.Errorf("verification failed: %v", r))
+		}
+	}()
+	if ptx == nil || ptxLen <= 0 {
+		return errorResult(errors.New("empty PTX input"))
+	}
+	// Copy the input so nothing refers to caller memory after returning
+	data := C.GoBytes(ptx, ptxLen)
+
+	var opts options
+	if optionsJSON != nil {
+		if err := json.Unmarshal([]byte(C.GoString(optionsJSON)), &opts); err != nil {
+			return errorResult(err)
+		}
+	}
+
+	vopts, err := opts.VerificationOptions()
+	if err != nil {
+		return errorResult(err)
+	}
+	vopts.PTXData = data
+	vopts.VKPath = opts.VKPath
+	vopts.VKData = opts.VK
+	vopts.RedisURL = opts.RedisURL
+	vopts.NonceDB = opts.NonceDB
+	vopts.TrackNullifiers = opts.TrackNullifiers
+
+	res, err := verifier.NewPTXVerifier(vopts).Verify()
+	if err != nil {
+		return errorResult(err)
+	}
+	return jsonResult(res)
+}
+
+//export ptx_free
+func ptx_free(result *C.char) {
+	C.free(unsafe.Pointer(result))
+}
+
+//export ptx_version
+func ptx_version() *C.char {
+	return C.CString(version)
+}
+
+func jsonResult(v any) *C.char {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errorResult(err)
+	}
+	return C.CString(string(data))
+}
+
+func errorResult(err error) *C.char {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return C.CString(string(data))
+}
+
+func main() {}
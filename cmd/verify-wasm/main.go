@@ -0,0 +1,135 @@
+//go:build js && wasm
+
+// Command verify-wasm exposes the PTX verifier to JavaScript.
+//
+// Build with:
+//
+//	GOOS=js GOARCH=wasm go build -o ptx-verify.wasm ./cmd/verify-wasm
+//
+// and load it with the wasm_exec.js shipped in $(go env GOROOT)/lib/wasm.
+// Two globals are registered:
+//
+//	ptxDeriveAnchor(ptx: Uint8Array): string
+//	    JSON {"hostname", "value"} of the TXT record to fetch.
+//	ptxVerify(ptx: Uint8Array, vk: Uint8Array, options?: string): Promise<string>
+//	    Resolves to the JSON verification result. options is the JSON form
+//	    of verifier.BytesOptions; pass "txtRecords" to use an answer fetched
+//	    by the host instead of a DoH query from inside the module.
+//
+// Errors are reported as JSON {"error": "..."}.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func main() {
+	js.Global().Set("ptxVerify", js.FuncOf(verify))
+	js.Global().Set("ptxDeriveAnchor", js.FuncOf(deriveAnchor))
+
+	// Keep the exported functions alive
+	select {}
+}
+
+func deriveAnchor(_ js.Value, args []js.Value) (result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = panicJSON(r)
+		}
+	}()
+	if len(args) < 1 {
+		return errorJSON(errors.New("usage: ptxDeriveAnchor(ptx)"))
+	}
+
+	record, err := verifier.DeriveAnchor(bytesArg(args[0]))
+	if err != nil {
+		return errorJSON(err)
+	}
+	return toJSON(map[string]string{
+		"hostname": record.Hostname,
+		"value":    record.Value,
+	})
+}
+
+// verify returns a Promise since a DoH lookup blocks on the Fetch API, which
+// cannot complete while the calling JS function is still on the stack
+func verify(_ js.Value, args []js.Value) any {
+	var ptxData, vkData []byte
+	var optsJSON string
+	if len(args) >= 2 {
+		ptxData = bytesArg(args[0])
+		vkData = bytesArg(args[1])
+	}
+	if len(args) >= 3 && args[2].Type() == js.TypeString {
+		optsJSON = args[2].String()
+	}
+
+	handler := js.FuncOf(func(_ js.Value, p []js.Value) any {
+		resolve := p[0]
+		go func() {
+			resolve.Invoke(runVerify(ptxData, vkData, optsJSON))
+		}()
+		return nil
+	})
+	defer handler.Release()
+
+	return js.Global().Get("Promise").New(handler)
+}
+
+func runVerify(ptxData, vkData []byte, optsJSON string) (result string) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = panicJSON(r)
+		}
+	}()
+	if ptxData == nil || vkData == nil {
+		return errorJSON(errors.New("usage: ptxVerify(ptx, vk, options?)"))
+	}
+
+	var opts verifier.BytesOptions
+	if optsJSON != "" {
+		if err := json.Unmarshal([]byte(optsJSON), &opts); err != nil {
+			return errorJSON(err)
+		}
+	}
+
+	res, err := verifier.VerifyBytes(ptxData, vkData, opts)
+	if err != nil {
+		return errorJSON(err)
+	}
+	return toJSON(res)
+}
+
+// panicJSON reports a recovered panic as an error result. Unrecovered, it
+// would stop the module for every later call.
+func panicJSON(r any) string {
+	return errorJSON(fmt.Errorf("verification failed: %v", r))
+}
+
+// bytesArg copies a Uint8Array into Go memory
+func bytesArg(v js.Value) []byte {
+	if v.Type() != js.TypeObject {
+		return nil
+	}
+	b := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(b, v)
+	return b
+}
+
+func toJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errorJSON(err)
+	}
+	return string(data)
+}
+
+func errorJSON(err error) string {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(data)
+}
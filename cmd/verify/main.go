@@ -7,9 +7,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/vk"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
-	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/vk"
 	"github.com/fatih/color"
 	"github.com/vocdoni/circom2gnark/parser"
 )
@@ -17,56 +18,33 @@ import (
 func main() {
 	opts := parseArgs()
 	if opts.FilePath == "" {
-		fmt.Println("Usage: verify <file.ptx> [-v] [--intended-scope x,y] [--intended-audience a,b] [--strict] [--redis-url url] [--time-dev] [--time-skip-dev]")
+		fmt.Println("Usage: verify <file.ptx> [-v] [--intended-scope x,y] [--intended-audience a,b] [--strict] [--redis-url url] [--time-dev] [--time-skip-dev] [--machine json|lines] [--legacy-signal-scan]")
+		os.Exit(1)
+	}
+
+	switch opts.Machine {
+	case "":
+	case "json", "lines":
+		opts.TimeDev = !opts.TimeSkipDev
+	default:
+		fmt.Printf("Unknown --machine format %q (want json or lines)\n", opts.Machine)
 		os.Exit(1)
 	}
 
 	// Time-skip-dev
 	if opts.TimeSkipDev {
-		ptxFile, err := ptxloader.LoadPTX(opts.FilePath)
-		if err != nil {
-			fmt.Println("0")
-			os.Exit(1)
-		}
-
-		proof := ptxFile.GetProof()
-		// Extract wrapper
-		var wrapper struct {
-			PublicSignals []string        `json:"publicSignals"`
-			Proof         json.RawMessage `json:"proof"`
-		}
-		if err := json.Unmarshal(proof.ProofData, &wrapper); err != nil {
-			fmt.Println("0")
-			os.Exit(1)
-		}
-
-		// Parse Proof with circom2gnark
-		circomProof, err := parser.UnmarshalCircomProofJSON(wrapper.Proof)
-		if err != nil {
-			fmt.Println("0")
-			os.Exit(1)
+		report := timeSkipDevReport(opts.FilePath)
+		if opts.Machine == "json" {
+			json.NewEncoder(os.Stdout).Encode(report)
+			os.Exit(report.ExitCode())
 		}
-
-		circomVk, err := vk.LoadCircomKey("verification_key.json")
-		if err != nil {
+		if report.Error != "" {
 			fmt.Println("0")
 			os.Exit(1)
 		}
-
-		// Convert to GnarkProof
-		gnarkProof, err := parser.ConvertCircomToGnark(circomProof, circomVk, wrapper.PublicSignals)
-		if err != nil {
-			fmt.Println("0")
-			os.Exit(1)
-		}
-
-		start := time.Now()
-		valid, err := parser.VerifyProof(gnarkProof)
-		elapsed := time.Since(start).Seconds()
-
 		fmt.Printf("%.5f\n", 0.0) // DNS Time (Skipped)
-		fmt.Printf("%.5f\n", elapsed)
-		if valid && err == nil {
+		fmt.Printf("%.5f\n", report.ProofSeconds)
+		if report.OK {
 			fmt.Println("1")
 			os.Exit(0)
 		} else {
@@ -85,6 +63,11 @@ func main() {
 
 	res, err := v.Verify()
 	if err != nil {
+		if opts.Machine == "json" {
+			report := verifier.MachineReport{ErrorCode: verifier.CodeLoadFailed, Error: err.Error()}
+			json.NewEncoder(os.Stdout).Encode(report)
+			os.Exit(report.ExitCode())
+		}
 		printError(err.Error())
 		os.Exit(1)
 	}
@@ -105,6 +88,13 @@ func main() {
 		} else {
 			printError(res.Dns.Error)
 		}
+		for _, a := range res.Anchors {
+			if a.Valid {
+				printSuccess(a.TrustMethod + " anchor verified")
+			} else {
+				printError(a.TrustMethod + " anchor: " + a.Error)
+			}
+		}
 
 		// ZK
 		printSection("4. ZK-SNARK")
@@ -118,6 +108,9 @@ func main() {
 				fmt.Printf("   Reason: %s\n", res.Zk.Error)
 			}
 		}
+		if opts.Verbose && res.Zk.SemanticReport != nil {
+			printSemanticReport(res.Zk.SemanticReport)
+		}
 
 		// Success
 		if res.Success {
@@ -127,13 +120,14 @@ func main() {
 	}
 
 	// Time-dev output
+	report := verifier.NewMachineReport(res)
+	if opts.Machine == "json" {
+		json.NewEncoder(os.Stdout).Encode(report)
+		os.Exit(report.ExitCode())
+	}
 	if opts.TimeDev {
-		fmt.Printf("%.4f\n", res.Dns.FetchTimeMs/1000)
-		if res.Zk.ProofTimeMs > 0 {
-			fmt.Printf("%.4f\n", res.Zk.ProofTimeMs/1000)
-		} else {
-			fmt.Printf("0.0000\n")
-		}
+		fmt.Printf("%.4f\n", report.DNSSeconds)
+		fmt.Printf("%.4f\n", report.ProofSeconds)
 		if res.Success {
 			fmt.Println("1")
 		} else {
@@ -148,10 +142,60 @@ func main() {
 	}
 }
 
+// timeSkipDevReport verifies only the raw snarkjs proof of a PTX file against
+// verification_key.json, skipping DNS and semantic checks
+func timeSkipDevReport(filePath string) verifier.MachineReport {
+	failed := func(code verifier.ErrorCode, err error) verifier.MachineReport {
+		return verifier.MachineReport{ErrorCode: code, Error: err.Error()}
+	}
+
+	ptxFile, err := ptxloader.LoadPTX(filePath)
+	if err != nil {
+		return failed(verifier.CodeLoadFailed, err)
+	}
+
+	proof := ptxFile.GetProof()
+	// Extract wrapper
+	var wrapper struct {
+		PublicSignals []string        `json:"publicSignals"`
+		Proof         json.RawMessage `json:"proof"`
+	}
+	if err := json.Unmarshal(proof.GetProofData(), &wrapper); err != nil {
+		return failed(verifier.CodeProofInvalid, err)
+	}
+
+	// Parse Proof with circom2gnark
+	circomProof, err := parser.UnmarshalCircomProofJSON(wrapper.Proof)
+	if err != nil {
+		return failed(verifier.CodeProofInvalid, err)
+	}
+
+	circomVk, err := vk.LoadCircomKey("verification_key.json")
+	if err != nil {
+		return failed(verifier.CodeLoadFailed, err)
+	}
+
+	// Convert to GnarkProof
+	gnarkProof, err := parser.ConvertCircomToGnark(circomProof, circomVk, wrapper.PublicSignals)
+	if err != nil {
+		return failed(verifier.CodeProofInvalid, err)
+	}
+
+	start := time.Now()
+	valid, err := parser.VerifyProof(gnarkProof)
+	report := verifier.MachineReport{ProofSeconds: time.Since(start).Seconds(), OK: valid && err == nil}
+	if !report.OK {
+		report.ErrorCode = verifier.CodeProofInvalid
+	}
+	return report
+}
+
 type Options struct {
 	verifier.VerificationOptions
 	TimeDev     bool
 	TimeSkipDev bool
+	// Machine is the --machine output format: json, or lines as --time-dev
+	Machine string
 }
 
 func parseArgs() Options {
@@ -183,6 +227,13 @@ func parseArgs() Options {
 			opts.TimeDev = true
 		} else if arg == "--time-skip-dev" {
 			opts.TimeSkipDev = true
+		} else if arg == "--machine" && i+1 < len(args) {
+			opts.Machine = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--machine=") {
+			opts.Machine = strings.TrimPrefix(arg, "--machine=")
+		} else if arg == "--legacy-signal-scan" {
+			opts.LegacySignalScan = true
 		} else if !strings.HasPrefix(arg, "-") {
 			opts.FilePath = arg
 		}
@@ -190,6 +241,22 @@ func parseArgs() Options {
 	return opts
 }
 
+func printSemanticReport(r *signals.VerificationResult) {
+	fmt.Printf("   %s\n", color.CyanString("Semantic Checks:"))
+	printCheck("FQDN Hash", r.FqdnHash)
+	printCheck("Metadata Hash P1", r.MetadataPart1)
+	printCheck("Metadata Hash P2", r.MetadataPart2)
+	printCheck("Trust Method", r.TrustMethod)
+}
+
+func printCheck(label string, ok bool) {
+	if ok {
+		fmt.Printf("      %s %s\n", color.GreenString("✔"), label)
+	} else {
+		fmt.Printf("      %s %s\n", color.RedString("✖"), label)
+	}
+}
+
 func printHeader(msg string) {
 	cyan := color.New(color.FgCyan).SprintFunc()
 	fmt.Printf("\n%s\n%s%s\n%s\n",
@@ -0,0 +1,222 @@
+package signals
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// Public signal names, matching the circuit input names used by the prover
+const (
+	SignalNullifierHash  = "nullifierHash"
+	SignalCommitment     = "commitment"
+	SignalFqdn           = "fqdn"
+	SignalMetadataHashP1 = "metadataHash_p1"
+	SignalMetadataHashP2 = "metadataHash_p2"
+	SignalTrustMethod    = "trustMethod"
+	// SignalMetadataLength is the length in bytes of the metadata hashed
+	// in-circuit; the bytes themselves are the MetadataSignal(i) signals
+	SignalMetadataLength = "metadataLength"
+	// SignalAudienceHash and SignalScopeHash bind the audience and scopes
+	// of the token, see crypto.MetadataBinding
+	SignalAudienceHash = "audienceHash"
+	SignalScopeHash    = "scopeHash"
+)
+
+// MetadataSignal returns the name of the i-th packed metadata chunk signal
+func MetadataSignal(i int) string {
+	return fmt.Sprintf("metadata_%d", i)
+}
+
+// DefaultVerificationKeyID is the key ID written by the prover for the SDV circuit
+const DefaultVerificationKeyID = "sdv_poseidon_v1"
+
+// MetadataSHA256KeyID is the key ID of the circuit variant that takes the
+// metadata bytes as public inputs and hashes them in-circuit with SHA256,
+// instead of taking the digest limbs computed out of circuit
+const MetadataSHA256KeyID = "sdv_poseidon_sha256_v1"
+
+// ScopeBoundKeyID is the key ID of the circuit revision (v3) that takes the
+// audience and scope hashes of the token as public inputs and folds them into
+// the context hash, so the commitment itself binds them
+const ScopeBoundKeyID = "sdv_poseidon_v3"
+
+// MetadataSHA256Chunks is the number of packed metadata chunks of the
+// MetadataSHA256KeyID circuit, each holding crypto.PackedChunkBytes bytes
+const MetadataSHA256Chunks = 8
+
+// SignalLayout describes the position of each named public signal in a
+// proof's public signal array
+type SignalLayout struct {
+	Names []string
+	// Limbs is how the circuit splits the metadata digest into
+	// metadataHash_p1 and metadataHash_p2
+	Limbs crypto.LimbEncoding
+	// MetadataChunks is the number of packed metadata chunk signals for
+	// circuits that hash the metadata in-circuit, 0 for circuits taking the
+	// digest limbs
+	MetadataChunks int
+	index          map[string]int
+}
+
+// NewSignalLayout creates a layout from the ordered signal names, using the
+// canonical limb encoding
+func NewSignalLayout(names ...string) *SignalLayout {
+	l := &SignalLayout{
+		Names: names,
+		Limbs: crypto.CanonicalLimbEncoding,
+		index: make(map[string]int, len(names)),
+	}
+	for i, n := range names {
+		l.index[n] = i
+	}
+	return l
+}
+
+// WithLimbs sets the limb encoding of the layout
+func (l *SignalLayout) WithLimbs(enc crypto.LimbEncoding) *SignalLayout {
+	l.Limbs = enc
+	return l
+}
+
+// WithMetadataChunks declares that the layout carries the metadata as n
+// packed chunks
+func (l *SignalLayout) WithMetadataChunks(n int) *SignalLayout {
+	l.MetadataChunks = n
+	return l
+}
+
+// BindsScope reports whether the layout carries the audience and scope hash
+// signals
+func (l *SignalLayout) BindsScope() bool {
+	_, ok := l.Index(SignalAudienceHash)
+	return ok
+}
+
+// Index returns the position of the named signal
+func (l *SignalLayout) Index(name string) (int, bool) {
+	i, ok := l.index[name]
+	return i, ok
+}
+
+// Len returns the number of public signals in the layout
+func (l *SignalLayout) Len() int {
+	return len(l.Names)
+}
+
+// Lookup returns the named signal from publicSignals, or false if the layout
+// does not define it or the array is too short
+func (l *SignalLayout) Lookup(publicSignals []string, name string) (string, bool) {
+	i, ok := l.Index(name)
+	if !ok || i >= len(publicSignals) {
+		return "", false
+	}
+	return publicSignals[i], true
+}
+
+// FillMetadata sets the metadataLength and packed metadata chunk signals of
+// vec, laid out as l, to the values a circuit hashing metaRaw in-circuit was
+// proven with. It fails if metaRaw does not fit in the chunks.
+func (l *SignalLayout) FillMetadata(vec []fr.Element, metaRaw string) error {
+	if l.MetadataChunks == 0 {
+		return fmt.Errorf("layout has no metadata chunks")
+	}
+	chunks := make([]fr.Element, l.MetadataChunks)
+	if err := crypto.PackBytes(chunks, []byte(metaRaw)); err != nil {
+		return fmt.Errorf("metadata does not fit the circuit: %w", err)
+	}
+	if i, ok := l.Index(SignalMetadataLength); ok && i < len(vec) {
+		vec[i].SetUint64(uint64(len(metaRaw)))
+	}
+	for c := range chunks {
+		if i, ok := l.Index(MetadataSignal(c)); ok && i < len(vec) {
+			vec[i] = chunks[c]
+		}
+	}
+	return nil
+}
+
+// FillBinding sets the audienceHash and scopeHash signals of vec, laid out as
+// l, to the values bound by metaRaw
+func (l *SignalLayout) FillBinding(vec []fr.Element, metaRaw string) error {
+	audience, scope, err := crypto.MetadataBinding(metaRaw)
+	if err != nil {
+		return err
+	}
+	if i, ok := l.Index(SignalAudienceHash); ok && i < len(vec) {
+		vec[i].Set(audience)
+	}
+	if i, ok := l.Index(SignalScopeHash); ok && i < len(vec) {
+		vec[i].Set(scope)
+	}
+	return nil
+}
+
+var (
+	layoutsMu sync.RWMutex
+	layouts   = map[string]*SignalLayout{
+		DefaultVerificationKeyID: NewSignalLayout(
+			SignalNullifierHash,
+			SignalCommitment,
+			SignalFqdn,
+			SignalMetadataHashP1,
+			SignalMetadataHashP2,
+			SignalTrustMethod,
+		).WithLimbs(crypto.LimbsLowHigh),
+		MetadataSHA256KeyID: NewSignalLayout(append([]string{
+			SignalNullifierHash,
+			SignalCommitment,
+			SignalFqdn,
+			SignalTrustMethod,
+			SignalMetadataLength,
+		}, metadataSignals(MetadataSHA256Chunks)...)...).WithMetadataChunks(MetadataSHA256Chunks),
+		ScopeBoundKeyID: NewSignalLayout(
+			SignalNullifierHash,
+			SignalCommitment,
+			SignalFqdn,
+			SignalMetadataHashP1,
+			SignalMetadataHashP2,
+			SignalTrustMethod,
+			SignalAudienceHash,
+			SignalScopeHash,
+		),
+	}
+)
+
+func metadataSignals(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = MetadataSignal(i)
+	}
+	return names
+}
+
+// RegisterLayout associates a signal layout with a verification key ID
+func RegisterLayout(vkID string, layout *SignalLayout) {
+	layoutsMu.Lock()
+	defer layoutsMu.Unlock()
+	layouts[vkID] = layout
+}
+
+// LayoutFor returns the signal layout registered for a verification key ID
+func LayoutFor(vkID string) (*SignalLayout, bool) {
+	layoutsMu.RLock()
+	defer layoutsMu.RUnlock()
+	l, ok := layouts[vkID]
+	return l, ok
+}
+
+// KeyIDs returns the verification key IDs with a registered layout, sorted
+func KeyIDs() []string {
+	layoutsMu.RLock()
+	defer layoutsMu.RUnlock()
+	ids := make([]string, 0, len(layouts))
+	for id := range layouts {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,291 @@
+package signals
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+type VerificationResult struct {
+	FqdnHash      bool `json:"fqdnHash"`
+	MetadataPart1 bool `json:"metadataPart1"`
+	MetadataPart2 bool `json:"metadataPart2"`
+	TrustMethod   bool `json:"trustMethod"`
+	// AudienceHash and ScopeHash are only checked for layouts binding the
+	// audience and scopes, and are true for the others
+	AudienceHash bool `json:"audienceHash"`
+	ScopeHash    bool `json:"scopeHash"`
+	AllValid     bool `json:"allValid"`
+}
+
+// Mismatches returns the names of the checked components that did not match
+func (r VerificationResult) Mismatches() []string {
+	var names []string
+	if !r.FqdnHash {
+		names = append(names, "fqdnHash")
+	}
+	if !r.MetadataPart1 {
+		names = append(names, "metadataHashP1")
+	}
+	if !r.MetadataPart2 {
+		names = append(names, "metadataHashP2")
+	}
+	if !r.TrustMethod {
+		names = append(names, "trustMethod")
+	}
+	if !r.AudienceHash {
+		names = append(names, "audienceHash")
+	}
+	if !r.ScopeHash {
+		names = append(names, "scopeHash")
+	}
+	return names
+}
+
+type PTXSignals struct {
+	Domain      string
+	MetadataRaw string
+	TrustMethod ptx.TrustMethod
+
+	// Layout pins each expected value to its index in the public signals.
+	// Defaults to the layout of the default verification key.
+	Layout *SignalLayout
+	// LegacyScan restores the value scan used before signal layouts existed.
+	// It is only meant for proofs whose layout is unknown.
+	LegacyScan bool
+	// Pinned only accepts values at their layout index: LegacyScan is
+	// ignored and a missing layout fails every check. Without it a trust
+	// method such as 1 passes the scan whenever any other signal equals 1.
+	Pinned bool
+}
+
+func NewPTXSignals(domain string, metadataRaw string, trustMethod ptx.TrustMethod) *PTXSignals {
+	layout, _ := LayoutFor(DefaultVerificationKeyID)
+	return &PTXSignals{
+		Domain:      domain,
+		MetadataRaw: metadataRaw,
+		TrustMethod: trustMethod,
+		Layout:      layout,
+	}
+}
+
+// PublicSignals is a proof's public signal array parsed into field elements
+// once, so that the checks on the verification path compare elements instead
+// of re-parsing decimal strings
+type PublicSignals struct {
+	Raw    []string
+	Values []fr.Element
+	// Valid marks the signals that are canonical field elements. Other
+	// signals never match an expected value.
+	Valid []bool
+}
+
+// ParsePublicSignals parses every signal. It reports the first signal that is
+// not a canonical field element, but still parses the rest.
+func ParsePublicSignals(raw []string) (*PublicSignals, error) {
+	ps := &PublicSignals{
+		Raw:    raw,
+		Values: make([]fr.Element, len(raw)),
+		Valid:  make([]bool, len(raw)),
+	}
+	var firstErr error
+	for i, r := range raw {
+		v, err := crypto.ParseFr(r)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("signal %d: %w", i, err)
+			}
+			continue
+		}
+		ps.Values[i] = v
+		ps.Valid[i] = true
+	}
+	return ps, firstErr
+}
+
+// Lookup returns the named signal of layout, or false if the layout does not
+// define it, the array is too short or the signal is not canonical
+func (ps *PublicSignals) Lookup(layout *SignalLayout, name string) (*fr.Element, bool) {
+	i, ok := layout.Index(name)
+	if !ok || i >= len(ps.Values) || !ps.Valid[i] {
+		return nil, false
+	}
+	return &ps.Values[i], true
+}
+
+// VerifyAgainstProof checks the public signals against the values re-derived
+// from the PTX contents
+func (s *PTXSignals) VerifyAgainstProof(publicSignals []string) VerificationResult {
+	ps, _ := ParsePublicSignals(publicSignals)
+	return s.VerifyParsed(ps)
+}
+
+// VerifyParsed is VerifyAgainstProof for signals parsed with
+// ParsePublicSignals
+func (s *PTXSignals) VerifyParsed(ps *PublicSignals) VerificationResult {
+	if s.Pinned {
+		if s.Layout == nil {
+			return VerificationResult{}
+		}
+		return s.verifyIndexed(ps)
+	}
+	if s.LegacyScan || s.Layout == nil {
+		return s.verifyLegacyScan(ps)
+	}
+	return s.verifyIndexed(ps)
+}
+
+// trustMethodElement returns the trust method as a field element. Negative
+// values have none and never match.
+func (s *PTXSignals) trustMethodElement() (fr.Element, bool) {
+	var e fr.Element
+	if s.TrustMethod < 0 {
+		return e, false
+	}
+	e.SetUint64(uint64(s.TrustMethod))
+	return e, true
+}
+
+// verifyIndexed compares each expected value with the signal at its layout
+// index, using the same derivation as the prover
+func (s *PTXSignals) verifyIndexed(ps *PublicSignals) VerificationResult {
+	fqdnHash, fqdnErr := crypto.HashDomain(s.Domain)
+	trustMethod, trustOK := s.trustMethodElement()
+
+	matches := func(name string, expected *fr.Element) bool {
+		sig, ok := ps.Lookup(s.Layout, name)
+		return ok && sig.Equal(expected)
+	}
+
+	res := VerificationResult{
+		FqdnHash:     fqdnErr == nil && matches(SignalFqdn, fqdnHash),
+		TrustMethod:  trustOK && matches(SignalTrustMethod, &trustMethod),
+		AudienceHash: true,
+		ScopeHash:    true,
+	}
+	if s.Layout.BindsScope() {
+		audience, scope, err := crypto.MetadataBinding(s.MetadataRaw)
+		res.AudienceHash = err == nil && matches(SignalAudienceHash, audience)
+		res.ScopeHash = err == nil && matches(SignalScopeHash, scope)
+	}
+	if s.Layout.MetadataChunks > 0 {
+		// The circuit hashes the metadata itself, so both parts stand for
+		// the length and every chunk matching
+		ok := s.metadataChunksMatch(ps)
+		res.MetadataPart1, res.MetadataPart2 = ok, ok
+	} else {
+		metaP1, metaP2, err := crypto.SplitMetadataHashWith(s.MetadataRaw, s.Layout.Limbs)
+		if err != nil {
+			return VerificationResult{}
+		}
+		res.MetadataPart1 = matches(SignalMetadataHashP1, metaP1)
+		res.MetadataPart2 = matches(SignalMetadataHashP2, metaP2)
+	}
+	res.AllValid = res.FqdnHash && res.MetadataPart1 && res.MetadataPart2 && res.TrustMethod &&
+		res.AudienceHash && res.ScopeHash
+	return res
+}
+
+// metadataChunksMatch compares the metadata length and chunk signals with the
+// packed PTX metadata
+func (s *PTXSignals) metadataChunksMatch(ps *PublicSignals) bool {
+	expected := make([]fr.Element, s.Layout.Len())
+	if err := s.Layout.FillMetadata(expected, s.MetadataRaw); err != nil {
+		return false
+	}
+	names := append([]string{SignalMetadataLength}, metadataSignals(s.Layout.MetadataChunks)...)
+	for _, name := range names {
+		i, _ := s.Layout.Index(name)
+		sig, ok := ps.Lookup(s.Layout, name)
+		if !ok || !sig.Equal(&expected[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyLegacyScan searches all public signals for the expected values. It can
+// report false positives when an unrelated signal happens to hold a matching
+// value, so it is only used for proofs without a registered layout and never
+// for Pinned signals.
+func (s *PTXSignals) verifyLegacyScan(ps *PublicSignals) VerificationResult {
+	// Reconstruct expected signals
+	// 1. Metadata Hash. The scan ignores positions, so it accepts proofs
+	// using either limb encoding.
+	metaP1, metaP2 := crypto.SplitMetadataHash(s.MetadataRaw)
+
+	// 2. Domain Hash (FQDN)
+	// Assuming SHA256 of domain string
+	domainHashBytes := sha256.Sum256([]byte(s.Domain))
+	// FQDN hash might be a single field element if masked or truncated,
+	// or split. However, usually domain hash is just one input if used for commitment.
+	// Let's assume it fits in one field element or we use one part.
+	// For now, let's look at publicSignals indices.
+
+	// TODO: Without the JS file, we are guessing the indices checking logic.
+	// Based on "nPublic: 6" and verifying "fqdnHash, metadataPart1, metadataPart2, trustMethod"
+	// We might have:
+	// 0: output?
+	// 1: commitment (used for DNS, verified separately in verifyDNS)
+	// 2: trustMethod?
+	// 3: fqdnHash?
+	// 4: metaP1?
+	// 5: metaP2?
+
+	// Let's rely on string comparison if possible, or try to match values.
+
+	// FQDN Hash check - this is tricky without knowing exact derivation.
+	// The unreduced digest is compared, so a digest that is not itself a
+	// field element never matches.
+	fqdn, fqdnErr := fr.BigEndian.Element(&domainHashBytes)
+	trustMethod, trustOK := s.trustMethodElement()
+
+	res := VerificationResult{AudienceHash: true, ScopeHash: true}
+
+	// We scan the public signals for our expected values.
+	// This is a robust way if we don't know exact indices.
+	// Non-canonical signals never match.
+	for i := range ps.Values {
+		if !ps.Valid[i] {
+			continue
+		}
+		sig := &ps.Values[i]
+		if trustOK && sig.Equal(&trustMethod) {
+			res.TrustMethod = true
+		}
+		if sig.Equal(metaP1) {
+			res.MetadataPart1 = true
+		}
+		if sig.Equal(metaP2) {
+			res.MetadataPart2 = true
+		}
+		if fqdnErr == nil && sig.Equal(&fqdn) {
+			res.FqdnHash = true
+		}
+	}
+
+	res.AllValid = res.TrustMethod && res.MetadataPart1 && res.MetadataPart2
+	// FQDN match might be optional or part of commitment.
+	// The JS code: `logDetail("FQDN Hash", semantic.fqdnHash ...)` implies it is checked.
+
+	return res
+}
+
+func (s *PTXSignals) DeriveSignals(publicSignals []string) ([]*big.Int, error) {
+	// This function is for Groth16 verify input.
+	// Groth16 verify in gnark takes witness (public assignment).
+	// We construct it from the proof's public signals.
+	res := make([]*big.Int, len(publicSignals))
+	for i, ps := range publicSignals {
+		n, err := crypto.ParseFieldElement(ps)
+		if err != nil {
+			return nil, fmt.Errorf("signal %d: %w", i, err)
+		}
+		res[i] = n
+	}
+	return res, nil
+}
@@ -0,0 +1,196 @@
+package signals
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+type falsePositiveFixture struct {
+	VerificationKeyID string   `json:"verificationKeyId"`
+	Domain            string   `json:"domain"`
+	Metadata          string   `json:"metadata"`
+	TrustMethod       int32    `json:"trustMethod"`
+	PublicSignals     []string `json:"publicSignals"`
+}
+
+// TestTrustMethodFalsePositive replays a proof whose trust method slot
+// disagrees with the PTX while another signal holds the claimed value
+func TestTrustMethodFalsePositive(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "trustmethod_false_positive.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fx falsePositiveFixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		t.Fatal(err)
+	}
+	layout, ok := LayoutFor(fx.VerificationKeyID)
+	if !ok {
+		t.Fatalf("no layout for %q", fx.VerificationKeyID)
+	}
+	sig := &PTXSignals{
+		Domain:      fx.Domain,
+		MetadataRaw: fx.Metadata,
+		TrustMethod: ptx.TrustMethod(fx.TrustMethod),
+		Layout:      layout,
+	}
+
+	// The prior behaviour: the scan finds the 1 in the nullifier hash
+	sig.LegacyScan = true
+	if res := sig.VerifyAgainstProof(fx.PublicSignals); !res.AllValid || !res.TrustMethod {
+		t.Fatalf("legacy scan no longer reproduces the false positive: %+v", res)
+	}
+
+	for _, mode := range []struct {
+		name       string
+		legacy     bool
+		pinned     bool
+		withLayout bool
+	}{
+		{"indexed", false, false, true},
+		{"pinned", false, true, true},
+		{"pinned over legacy scan", true, true, true},
+		{"pinned without layout", false, true, false},
+	} {
+		t.Run(mode.name, func(t *testing.T) {
+			s := *sig
+			s.LegacyScan, s.Pinned = mode.legacy, mode.pinned
+			if !mode.withLayout {
+				s.Layout = nil
+			}
+			res := s.VerifyAgainstProof(fx.PublicSignals)
+			if res.AllValid || res.TrustMethod {
+				t.Fatalf("accepted: %+v", res)
+			}
+		})
+	}
+
+	// Every other value sits at its index
+	res := sig.verifyIndexed(mustParse(t, fx.PublicSignals))
+	if got := res.Mismatches(); !slices.Equal(got, []string{"trustMethod"}) {
+		t.Errorf("mismatches = %v, want [trustMethod]", got)
+	}
+}
+
+// TestPinnedRejectsDisplacedValues checks, for random signals and every
+// registered layout, that an expected value only counts at its own index
+func TestPinnedRejectsDisplacedValues(t *testing.T) {
+	const domain = "example.com"
+	const meta = `{"sub":"alice","aud":["api.example.com"],"scope":["read"]}`
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, id := range KeyIDs() {
+		layout, _ := LayoutFor(id)
+		t.Run(id, func(t *testing.T) {
+			for range 50 {
+				method := ptx.TrustMethod(1 + rng.IntN(5))
+				valid := expectedSignals(t, layout, domain, meta, method, rng)
+				sig := &PTXSignals{Domain: domain, MetadataRaw: meta, TrustMethod: method, Layout: layout, Pinned: true}
+				if res := sig.VerifyAgainstProof(valid); !res.AllValid {
+					t.Fatalf("valid signals rejected: %+v", res)
+				}
+
+				// Move the trust method to another slot and put a different
+				// method in its own
+				tm, _ := layout.Index(SignalTrustMethod)
+				other := rng.IntN(layout.Len() - 1)
+				if other >= tm {
+					other++
+				}
+				displaced := slices.Clone(valid)
+				displaced[other] = valid[tm]
+				displaced[tm] = strconv.Itoa(int(method)%5 + 1)
+				if res := sig.VerifyAgainstProof(displaced); res.AllValid || res.TrustMethod {
+					t.Fatalf("trust method at index %d accepted: %+v", other, res)
+				}
+
+				// Swapping two distinct signals breaks both
+				i, j := rng.IntN(layout.Len()), rng.IntN(layout.Len())
+				if valid[i] == valid[j] {
+					continue
+				}
+				swapped := slices.Clone(valid)
+				swapped[i], swapped[j] = swapped[j], swapped[i]
+				if isChecked(layout, i) || isChecked(layout, j) {
+					if res := sig.VerifyAgainstProof(swapped); res.AllValid {
+						t.Fatalf("signals %d and %d swapped accepted", i, j)
+					}
+				}
+			}
+		})
+	}
+}
+
+// expectedSignals builds the public signals a proof for the given values has
+// under layout, with random nullifier hash and commitment
+func expectedSignals(t *testing.T, layout *SignalLayout, domain, meta string, method ptx.TrustMethod, rng *rand.Rand) []string {
+	t.Helper()
+	vec := make([]fr.Element, layout.Len())
+	set := func(name string, e *fr.Element) {
+		if i, ok := layout.Index(name); ok {
+			vec[i].Set(e)
+		}
+	}
+	var n fr.Element
+	n.SetUint64(rng.Uint64())
+	set(SignalNullifierHash, &n)
+	n.SetUint64(rng.Uint64())
+	set(SignalCommitment, &n)
+
+	fqdn, err := crypto.HashDomain(domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set(SignalFqdn, fqdn)
+	var tm fr.Element
+	tm.SetUint64(uint64(method))
+	set(SignalTrustMethod, &tm)
+	if layout.MetadataChunks > 0 {
+		if err := layout.FillMetadata(vec, meta); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		p1, p2, err := crypto.SplitMetadataHashWith(meta, layout.Limbs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set(SignalMetadataHashP1, p1)
+		set(SignalMetadataHashP2, p2)
+	}
+	if layout.BindsScope() {
+		if err := layout.FillBinding(vec, meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := make([]string, len(vec))
+	for i := range vec {
+		out[i] = vec[i].String()
+	}
+	return out
+}
+
+// isChecked reports whether the signal at index i is compared with a value
+// derived from the PTX, as opposed to the nullifier hash and commitment
+func isChecked(layout *SignalLayout, i int) bool {
+	name := layout.Names[i]
+	return name != SignalNullifierHash && name != SignalCommitment
+}
+
+func mustParse(t *testing.T, raw []string) *PublicSignals {
+	t.Helper()
+	ps, err := ParsePublicSignals(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ps
+}
@@ -0,0 +1,353 @@
+package vk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+const (
+	// DefaultMaxAge is how long a fetched key is used before it is
+	// revalidated with the server
+	DefaultMaxAge = time.Hour
+	// DefaultMaxKeySize bounds a fetched key or signature
+	DefaultMaxKeySize = 1 << 20
+	// SignatureSuffix is appended to a key URL to locate its detached
+	// signature
+	SignatureSuffix = ".sig"
+)
+
+var (
+	ErrPinMismatch      = errors.New("verification key does not match pinned hash")
+	ErrInvalidSignature = errors.New("verification key signature is invalid")
+)
+
+// KeySource addresses a verification key served over HTTPS. Without a pin the
+// key is trusted on the strength of the TLS connection alone.
+type KeySource struct {
+	URL string
+	// SHA256 pins the key bytes (hex)
+	SHA256 string
+	// SignatureKey verifies a detached ed25519 signature over the key,
+	// served base64 encoded at URL + SignatureSuffix
+	SignatureKey ed25519.PublicKey
+}
+
+// FetchedKey is a verification key returned by VKFetcher
+type FetchedKey struct {
+	// Data is the serialized key as served
+	Data      []byte
+	ETag      string
+	FetchedAt time.Time
+	// Offline is set when the server could not be reached and a previously
+	// fetched copy was returned
+	Offline bool
+}
+
+// VKFetcher downloads verification keys and caches them in memory and,
+// optionally, on disk. Cached keys are served without a request for MaxAge,
+// then revalidated with If-None-Match. When the server cannot be reached the
+// last good copy is used, so verifiers keep working through issuer outages.
+// Every copy, fresh or cached, is checked against the source's pins.
+type VKFetcher struct {
+	Client *http.Client
+	// CacheDir persists keys across restarts. Empty keeps them in memory only.
+	CacheDir string
+	// MaxAge defaults to DefaultMaxAge
+	MaxAge time.Duration
+	// MaxSize defaults to DefaultMaxKeySize
+	MaxSize int64
+
+	mu    sync.Mutex
+	cache map[string]*cachedKey
+}
+
+// cachedKey is the in-memory and on-disk cache entry
+type cachedKey struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Signature is kept so that cached copies can be re-verified offline
+	Signature []byte `json:"signature,omitempty"`
+	Data      []byte `json:"data"`
+}
+
+// NewVKFetcher returns a fetcher caching keys under cacheDir, or in memory
+// only if cacheDir is empty
+func NewVKFetcher(cacheDir string) *VKFetcher {
+	return &VKFetcher{
+		Client:   outbound.Client(30 * time.Second),
+		CacheDir: cacheDir,
+	}
+}
+
+var (
+	defaultFetcherMu sync.Mutex
+	defaultFetcher   *VKFetcher
+)
+
+// DefaultFetcher returns the process-wide in-memory fetcher
+func DefaultFetcher() *VKFetcher {
+	defaultFetcherMu.Lock()
+	defer defaultFetcherMu.Unlock()
+	if defaultFetcher == nil {
+		defaultFetcher = NewVKFetcher("")
+	}
+	return defaultFetcher
+}
+
+// SetDefaultFetcher replaces the fetcher returned by DefaultFetcher
+func SetDefaultFetcher(f *VKFetcher) {
+	defaultFetcherMu.Lock()
+	defaultFetcher = f
+	defaultFetcherMu.Unlock()
+}
+
+// Fetch returns the key for src, from cache when it is fresh
+func (f *VKFetcher) Fetch(ctx context.Context, src KeySource) (*FetchedKey, error) {
+	if err := checkKeyURL(src.URL); err != nil {
+		return nil, err
+	}
+
+	cached := f.lookup(src.URL)
+	if cached != nil && verifyKey(src, cached.Data, cached.Signature) != nil {
+		// Cached under different pins
+		cached = nil
+	}
+	if cached != nil && time.Since(cached.FetchedAt) < f.maxAge() {
+		return cached.key(false), nil
+	}
+
+	fresh, err := f.download(ctx, src, cached)
+	if err == nil {
+		err = verifyKey(src, fresh.Data, fresh.Signature)
+	}
+	if err != nil {
+		if errors.Is(err, ErrPinMismatch) || errors.Is(err, ErrInvalidSignature) || cached == nil {
+			return nil, fmt.Errorf("failed to fetch verification key %s: %w", src.URL, err)
+		}
+		// Offline fallback to the last good copy
+		return cached.key(true), nil
+	}
+
+	f.store(fresh)
+	return fresh.key(false), nil
+}
+
+// lookup returns the cached entry for rawURL, loading it from disk on first
+// use
+func (f *VKFetcher) lookup(rawURL string) *cachedKey {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.cache[rawURL]; ok {
+		return c
+	}
+	if f.CacheDir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.cachePath(rawURL))
+	if err != nil {
+		return nil
+	}
+	var c cachedKey
+	if err := json.Unmarshal(data, &c); err != nil || c.URL != rawURL {
+		return nil
+	}
+	if f.cache == nil {
+		f.cache = map[string]*cachedKey{}
+	}
+	f.cache[rawURL] = &c
+	return &c
+}
+
+func (f *VKFetcher) store(c *cachedKey) {
+	f.mu.Lock()
+	if f.cache == nil {
+		f.cache = map[string]*cachedKey{}
+	}
+	f.cache[c.URL] = c
+	f.mu.Unlock()
+
+	if f.CacheDir == "" {
+		return
+	}
+	// The disk cache is best effort: a failure only costs a refetch
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(f.CacheDir, 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(f.CacheDir, ".vk-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), f.cachePath(c.URL)) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// download fetches the key, revalidating cached with its ETag. A 304 returns
+// a copy of cached with a new fetch time.
+func (f *VKFetcher) download(ctx context.Context, src KeySource, cached *cachedKey) (*cachedKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		c := *cached
+		c.FetchedAt = time.Now()
+		return &c, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := f.readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	c := &cachedKey{
+		URL:       src.URL,
+		ETag:      resp.Header.Get("ETag"),
+		FetchedAt: time.Now(),
+		Data:      data,
+	}
+	if len(src.SignatureKey) > 0 {
+		if c.Signature, err = f.downloadSignature(ctx, src.URL+SignatureSuffix); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (f *VKFetcher) downloadSignature(ctx context.Context, sigURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sigURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signature: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := f.readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return sig, nil
+}
+
+func (f *VKFetcher) readBody(r io.Reader) ([]byte, error) {
+	limit := f.MaxSize
+	if limit <= 0 {
+		limit = DefaultMaxKeySize
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response exceeds %d bytes", limit)
+	}
+	return data, nil
+}
+
+func (f *VKFetcher) client() *http.Client {
+	if f.Client != nil {
+		return f.Client
+	}
+	return outbound.DefaultClient
+}
+
+func (f *VKFetcher) maxAge() time.Duration {
+	if f.MaxAge > 0 {
+		return f.MaxAge
+	}
+	return DefaultMaxAge
+}
+
+func (f *VKFetcher) cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(f.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *cachedKey) key(offline bool) *FetchedKey {
+	return &FetchedKey{Data: c.Data, ETag: c.ETag, FetchedAt: c.FetchedAt, Offline: offline}
+}
+
+// verifyKey checks data against the pins of src
+func verifyKey(src KeySource, data, sig []byte) error {
+	if src.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), src.SHA256) {
+			return ErrPinMismatch
+		}
+	}
+	if len(src.SignatureKey) > 0 {
+		if len(src.SignatureKey) != ed25519.PublicKeySize || !ed25519.Verify(src.SignatureKey, data, sig) {
+			return ErrInvalidSignature
+		}
+	}
+	return nil
+}
+
+// checkKeyURL requires https, except for loopback addresses
+func checkKeyURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid key URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if u.Hostname() == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(u.Hostname()); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return fmt.Errorf("key URL %s must use https", rawURL)
+	default:
+		return fmt.Errorf("unsupported key URL scheme %q", u.Scheme)
+	}
+}
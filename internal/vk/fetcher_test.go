@@ -0,0 +1,258 @@
+package vk
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keyServer serves a key with an ETag and its detached signature
+type keyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	data     []byte
+	sig      string
+	status   int
+	requests int
+	// conditional counts requests answered with 304
+	conditional int
+}
+
+func newKeyServer(t *testing.T, data []byte) *keyServer {
+	s := &keyServer{data: data}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			return
+		}
+		if r.URL.Path == "/native.vk"+SignatureSuffix {
+			if s.sig == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(s.sig))
+			return
+		}
+		etag := `"` + hashOf(s.data) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			s.conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(s.data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *keyServer) set(data []byte, sig string, status int) {
+	s.mu.Lock()
+	s.data, s.sig, s.status = data, sig, status
+	s.mu.Unlock()
+}
+
+func (s *keyServer) counts() (requests, conditional int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests, s.conditional
+}
+
+func (s *keyServer) url() string {
+	return s.URL + "/native.vk"
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func newFetcher(s *keyServer, cacheDir string) *VKFetcher {
+	return &VKFetcher{Client: s.Client(), CacheDir: cacheDir}
+}
+
+func TestFetchCache(t *testing.T) {
+	key := []byte("key v1")
+	s := newKeyServer(t, key)
+	f := newFetcher(s, "")
+	src := KeySource{URL: s.url(), SHA256: hashOf(key)}
+
+	got, err := f.Fetch(context.Background(), src)
+	if err != nil || string(got.Data) != "key v1" || got.Offline || got.ETag == "" {
+		t.Fatalf("Fetch = %+v, %v", got, err)
+	}
+	if _, err := f.Fetch(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := s.counts(); n != 1 {
+		t.Errorf("fresh key fetched again: %d requests", n)
+	}
+
+	// Once stale, the key is revalidated and a 304 keeps the cached copy
+	f.MaxAge = time.Nanosecond
+	before := got.FetchedAt
+	got, err = f.Fetch(context.Background(), src)
+	if err != nil || string(got.Data) != "key v1" || got.Offline || !got.FetchedAt.After(before) {
+		t.Fatalf("revalidated Fetch = %+v, %v", got, err)
+	}
+	if n, conditional := s.counts(); n != 2 || conditional != 1 {
+		t.Errorf("%d requests, %d answered 304", n, conditional)
+	}
+}
+
+func TestFetchDiskCache(t *testing.T) {
+	key := []byte("key v1")
+	s := newKeyServer(t, key)
+	dir := t.TempDir()
+	src := KeySource{URL: s.url(), SHA256: hashOf(key)}
+	if _, err := newFetcher(s, dir).Fetch(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new process starts from the disk cache
+	got, err := newFetcher(s, dir).Fetch(context.Background(), src)
+	if err != nil || string(got.Data) != "key v1" {
+		t.Fatalf("Fetch = %+v, %v", got, err)
+	}
+	if n, _ := s.counts(); n != 1 {
+		t.Errorf("%d requests, want the disk copy", n)
+	}
+
+	// A copy cached under other pins is not used, not even offline
+	other := []byte("key v2")
+	s.set(other, "", http.StatusServiceUnavailable)
+	otherSrc := KeySource{URL: s.url(), SHA256: hashOf(other)}
+	if _, err := newFetcher(s, dir).Fetch(context.Background(), otherSrc); err == nil {
+		t.Error("key cached under another pin was returned")
+	}
+	s.set(other, "", 0)
+	got, err = newFetcher(s, dir).Fetch(context.Background(), otherSrc)
+	if err != nil || string(got.Data) != "key v2" {
+		t.Fatalf("Fetch under the new pin = %+v, %v", got, err)
+	}
+}
+
+func TestFetchOffline(t *testing.T) {
+	key := []byte("key v1")
+	s := newKeyServer(t, key)
+	f := newFetcher(s, "")
+	src := KeySource{URL: s.url(), SHA256: hashOf(key)}
+	if _, err := f.Fetch(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	f.MaxAge = time.Nanosecond
+
+	s.set(key, "", http.StatusBadGateway)
+	got, err := f.Fetch(context.Background(), src)
+	if err != nil || !got.Offline || string(got.Data) != "key v1" {
+		t.Errorf("server error: Fetch = %+v, %v", got, err)
+	}
+
+	s.Close()
+	got, err = f.Fetch(context.Background(), src)
+	if err != nil || !got.Offline || string(got.Data) != "key v1" {
+		t.Errorf("server down: Fetch = %+v, %v", got, err)
+	}
+
+	// Nothing to fall back to
+	if _, err := newFetcher(s, "").Fetch(context.Background(), src); err == nil {
+		t.Error("fetched from a server that is down")
+	}
+}
+
+func TestFetchPinMismatch(t *testing.T) {
+	key := []byte("key v1")
+	s := newKeyServer(t, key)
+	f := newFetcher(s, "")
+	src := KeySource{URL: s.url(), SHA256: hashOf(key)}
+
+	if _, err := f.Fetch(context.Background(), KeySource{URL: s.url(), SHA256: hashOf([]byte("other"))}); !errors.Is(err, ErrPinMismatch) {
+		t.Errorf("wrong pin: err = %v", err)
+	}
+
+	// A replaced key fails its pin instead of falling back to the cache
+	if _, err := f.Fetch(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	f.MaxAge = time.Nanosecond
+	s.set([]byte("key v2"), "", 0)
+	if got, err := f.Fetch(context.Background(), src); !errors.Is(err, ErrPinMismatch) {
+		t.Errorf("replaced key: Fetch = %+v, %v", got, err)
+	}
+}
+
+func TestFetchSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, _ := ed25519.GenerateKey(nil)
+	key := []byte("key v1")
+	sign := func(k ed25519.PrivateKey, data []byte) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(k, data)) + "\n"
+	}
+	s := newKeyServer(t, key)
+	src := KeySource{URL: s.url(), SignatureKey: pub}
+
+	s.set(key, sign(priv, key), 0)
+	if got, err := newFetcher(s, "").Fetch(context.Background(), src); err != nil || string(got.Data) != "key v1" {
+		t.Fatalf("Fetch = %+v, %v", got, err)
+	}
+
+	for name, sig := range map[string]string{
+		"other signer": sign(other, key),
+		"other key":    sign(priv, []byte("key v2")),
+		"not base64":   "!!",
+		"truncated":    base64.StdEncoding.EncodeToString(ed25519.Sign(priv, key)[:32]),
+		"empty":        "\n",
+	} {
+		s.set(key, sig, 0)
+		if _, err := newFetcher(s, "").Fetch(context.Background(), src); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+
+	s.set(key, "", 0)
+	if _, err := newFetcher(s, "").Fetch(context.Background(), src); err == nil {
+		t.Error("key accepted without its signature")
+	}
+
+	// A bad signature never falls back to the cached copy
+	f := newFetcher(s, "")
+	s.set(key, sign(priv, key), 0)
+	if _, err := f.Fetch(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	f.MaxAge = time.Nanosecond
+	s.set([]byte("key v2"), sign(other, []byte("key v2")), 0)
+	if _, err := f.Fetch(context.Background(), src); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("replaced key: err = %v", err)
+	}
+}
+
+func TestCheckKeyURL(t *testing.T) {
+	for url, ok := range map[string]bool{
+		"https://issuer.example/native.vk": true,
+		"http://127.0.0.1:8080/native.vk":  true,
+		"http://[::1]/native.vk":           true,
+		"http://localhost/native.vk":       true,
+		"http://issuer.example/native.vk":  false,
+		"file:///etc/native.vk":            false,
+		"ftp://issuer.example/native.vk":   false,
+	} {
+		if err := checkKeyURL(url); (err == nil) != ok {
+			t.Errorf("checkKeyURL(%q) = %v", url, err)
+		}
+	}
+}
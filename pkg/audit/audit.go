@@ -0,0 +1,219 @@
+// Package audit persists every verification decision in a SQL database
+// (SQLite or Postgres) so that it can be queried long after the fact. A Log
+// is an events.Sink: attach it to the verifier's Emitter to record outcomes.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Outcome filters
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Record is one stored verification
+type Record struct {
+	ID int64 `json:"id"`
+	events.Event
+}
+
+// Filter selects records. Zero fields do not filter.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+	// Outcome is OutcomeSuccess, OutcomeFailure or empty for both
+	Outcome string
+	Domain  string
+	Code    string
+	// Limit defaults to 100
+	Limit int
+}
+
+// Log is an audit log backed by database/sql
+type Log struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+type dialect struct {
+	driver string
+	idType string
+	// placeholder returns the n-th (1-based) bind parameter
+	placeholder func(n int) string
+}
+
+var (
+	sqliteDialect = dialect{
+		driver:      "sqlite3",
+		idType:      "INTEGER PRIMARY KEY AUTOINCREMENT",
+		placeholder: func(int) string { return "?" },
+	}
+	postgresDialect = dialect{
+		driver:      "postgres",
+		idType:      "BIGSERIAL PRIMARY KEY",
+		placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	}
+)
+
+// Open connects to the audit database and creates the table if needed. url
+// is either a postgres:// URL or sqlite:<path>.
+func Open(url string) (*Log, error) {
+	var d dialect
+	dsn := url
+	switch {
+	case strings.HasPrefix(url, "postgres://"), strings.HasPrefix(url, "postgresql://"):
+		d = postgresDialect
+	case strings.HasPrefix(url, "sqlite:"):
+		d = sqliteDialect
+		dsn = strings.TrimPrefix(strings.TrimPrefix(url, "sqlite:"), "//")
+		if dsn == "" {
+			return nil, errors.New("audit: sqlite path is empty")
+		}
+	default:
+		return nil, fmt.Errorf("audit: unsupported database URL %q (use postgres://... or sqlite:<path>)", url)
+	}
+
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	if d.driver == sqliteDialect.driver {
+		// SQLite allows a single writer
+		db.SetMaxOpenConns(1)
+	}
+
+	l := &Log{db: db, dialect: d}
+	if err := l.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) migrate(ctx context.Context) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ptx_audit (
+			id ` + l.dialect.idType + `,
+			ts_ms BIGINT NOT NULL,
+			ptx_hash TEXT NOT NULL DEFAULT '',
+			domain TEXT NOT NULL DEFAULT '',
+			nullifier_hash TEXT NOT NULL DEFAULT '',
+			success BOOLEAN NOT NULL,
+			code TEXT NOT NULL DEFAULT '',
+			errors TEXT NOT NULL DEFAULT '[]',
+			latency_ms DOUBLE PRECISION NOT NULL,
+			caller TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS ptx_audit_ts ON ptx_audit (ts_ms)`,
+		`CREATE INDEX IF NOT EXISTS ptx_audit_domain ON ptx_audit (domain, ts_ms)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := l.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("audit: failed to create schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Publish implements events.Sink
+func (l *Log) Publish(ctx context.Context, ev events.Event) error {
+	errs, err := json.Marshal(nonNil(ev.Errors))
+	if err != nil {
+		return err
+	}
+	p := l.dialect.placeholder
+	_, err = l.db.ExecContext(ctx,
+		`INSERT INTO ptx_audit (ts_ms, ptx_hash, domain, nullifier_hash, success, code, errors, latency_ms, caller)
+		VALUES (`+p(1)+`, `+p(2)+`, `+p(3)+`, `+p(4)+`, `+p(5)+`, `+p(6)+`, `+p(7)+`, `+p(8)+`, `+p(9)+`)`,
+		ev.Time.UnixMilli(), ev.PTXHash, strings.ToLower(ev.Domain), ev.NullifierHash, ev.Success, ev.Code, string(errs), ev.LatencyMs, ev.Caller)
+	if err != nil {
+		return fmt.Errorf("audit: failed to write record: %w", err)
+	}
+	return nil
+}
+
+// Query returns matching records, most recent first
+func (l *Log) Query(ctx context.Context, f Filter) ([]Record, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, l.dialect.placeholder(len(args))))
+	}
+
+	if !f.Since.IsZero() {
+		add("ts_ms >= %s", f.Since.UnixMilli())
+	}
+	if !f.Until.IsZero() {
+		add("ts_ms < %s", f.Until.UnixMilli())
+	}
+	switch f.Outcome {
+	case "":
+	case OutcomeSuccess:
+		add("success = %s", true)
+	case OutcomeFailure:
+		add("success = %s", false)
+	default:
+		return nil, fmt.Errorf("audit: unknown outcome %q", f.Outcome)
+	}
+	if f.Domain != "" {
+		add("domain = %s", strings.ToLower(f.Domain))
+	}
+	if f.Code != "" {
+		add("code = %s", f.Code)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT id, ts_ms, ptx_hash, domain, nullifier_hash, success, code, errors, latency_ms, caller FROM ptx_audit`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY ts_ms DESC, id DESC LIMIT %d", limit)
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Record
+	for rows.Next() {
+		var r Record
+		var ts int64
+		var errs string
+		if err := rows.Scan(&r.ID, &ts, &r.PTXHash, &r.Domain, &r.NullifierHash, &r.Success, &r.Code, &errs, &r.LatencyMs, &r.Caller); err != nil {
+			return nil, fmt.Errorf("audit: query failed: %w", err)
+		}
+		r.Time = time.UnixMilli(ts).UTC()
+		json.Unmarshal([]byte(errs), &r.Errors)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Close closes the database
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,275 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+)
+
+// A bundle is a tar archive holding a PTX file together with everything
+// needed to verify it: the verification key, the circuit ID and optionally an
+// evidence bundle. manifest.json lists every member with its SHA-256.
+
+// Version is the manifest format version
+const Version = 1
+
+// Member names inside the archive
+const (
+	ManifestName = "manifest.json"
+	PTXName      = "token.ptx"
+	VKName       = "verification.vk"
+	EvidenceName = "evidence.json"
+)
+
+// Roles of bundle members
+const (
+	RolePTX             = "ptx"
+	RoleVerificationKey = "verification-key"
+	RoleEvidence        = "evidence"
+)
+
+// maxMemberSize bounds each archive member
+const maxMemberSize = ptxloader.DefaultMaxFileSize
+
+var (
+	ErrMissingManifest = errors.New("bundle has no manifest")
+	ErrMissingMember   = errors.New("bundle member missing")
+	ErrDigestMismatch  = errors.New("bundle member digest mismatch")
+	ErrUnexpected      = errors.New("unexpected bundle member")
+	ErrMemberTooLarge  = errors.New("bundle member exceeds maximum size")
+)
+
+// Manifest describes the contents of a bundle
+type Manifest struct {
+	Version   int       `json:"version"`
+	CircuitID string    `json:"circuitId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Files     []File    `json:"files"`
+}
+
+// File is a manifest entry
+type File struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Bundle is the decoded content of a bundle archive
+type Bundle struct {
+	Manifest Manifest
+	PTX      []byte
+	VK       []byte
+	// Evidence is nil if the bundle carries no evidence
+	Evidence []byte
+}
+
+// New builds a bundle for a PTX file and its verification key. The circuit ID
+// is taken from the PTX file's verification key ID.
+func New(ptxData, vkData, evidence []byte) (*Bundle, error) {
+	ptxFile, err := ptxloader.ParsePTX(ptxData, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, fmt.Errorf("invalid PTX file: %w", err)
+	}
+	if len(vkData) == 0 {
+		return nil, errors.New("verification key is empty")
+	}
+
+	return &Bundle{
+		Manifest: Manifest{
+			Version:   Version,
+			CircuitID: ptxFile.GetProof().GetVerificationKeyId(),
+			CreatedAt: time.Now().UTC(),
+		},
+		PTX:      ptxData,
+		VK:       vkData,
+		Evidence: evidence,
+	}, nil
+}
+
+type member struct {
+	name, role string
+	data       []byte
+}
+
+// members returns the archive members other than the manifest
+func (b *Bundle) members() []member {
+	m := []member{
+		{PTXName, RolePTX, b.PTX},
+		{VKName, RoleVerificationKey, b.VK},
+	}
+	if b.Evidence != nil {
+		m = append(m, member{EvidenceName, RoleEvidence, b.Evidence})
+	}
+	return m
+}
+
+// WriteTo writes the bundle as a tar archive. The manifest file list is
+// regenerated from the contents.
+func (b *Bundle) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	tw := tar.NewWriter(cw)
+
+	members := b.members()
+	b.Manifest.Files = b.Manifest.Files[:0]
+	for _, m := range members {
+		b.Manifest.Files = append(b.Manifest.Files, File{
+			Name:   m.name,
+			Role:   m.role,
+			Size:   len(m.data),
+			SHA256: digest(m.data),
+		})
+	}
+
+	manifest, err := json.MarshalIndent(&b.Manifest, "", "  ")
+	if err != nil {
+		return cw.n, err
+	}
+
+	// The manifest goes first so readers can validate members as they stream
+	if err := writeMember(tw, ManifestName, manifest, b.Manifest.CreatedAt); err != nil {
+		return cw.n, err
+	}
+	for _, m := range members {
+		if err := writeMember(tw, m.name, m.data, b.Manifest.CreatedAt); err != nil {
+			return cw.n, err
+		}
+	}
+	err = tw.Close()
+	return cw.n, err
+}
+
+func writeMember(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+		Format:  tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Read decodes a bundle archive and checks every member against the manifest
+func Read(r io.Reader) (*Bundle, error) {
+	tr := tar.NewReader(r)
+	files := map[string][]byte{}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%w: %s is not a regular file", ErrUnexpected, hdr.Name)
+		}
+		if _, dup := files[hdr.Name]; dup {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrUnexpected, hdr.Name)
+		}
+		if hdr.Size > maxMemberSize {
+			return nil, fmt.Errorf("%w: %s", ErrMemberTooLarge, hdr.Name)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxMemberSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+		files[hdr.Name] = data
+	}
+
+	raw, ok := files[ManifestName]
+	if !ok {
+		return nil, ErrMissingManifest
+	}
+	delete(files, ManifestName)
+
+	b := &Bundle{}
+	if err := json.Unmarshal(raw, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if b.Manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.Manifest.Version)
+	}
+
+	for _, f := range b.Manifest.Files {
+		data, ok := files[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingMember, f.Name)
+		}
+		delete(files, f.Name)
+
+		if len(data) != f.Size || digest(data) != f.SHA256 {
+			return nil, fmt.Errorf("%w: %s", ErrDigestMismatch, f.Name)
+		}
+
+		switch f.Role {
+		case RolePTX:
+			b.PTX = data
+		case RoleVerificationKey:
+			b.VK = data
+		case RoleEvidence:
+			b.Evidence = data
+		default:
+			return nil, fmt.Errorf("%w: unknown role %q", ErrUnexpected, f.Role)
+		}
+	}
+
+	// Members not covered by the manifest are not integrity protected
+	for name := range files {
+		return nil, fmt.Errorf("%w: %s is not listed in the manifest", ErrUnexpected, name)
+	}
+
+	if b.PTX == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMissingMember, RolePTX)
+	}
+	if b.VK == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMissingMember, RoleVerificationKey)
+	}
+
+	// The circuit ID must describe the PTX it ships with
+	ptxFile, err := ptxloader.ParsePTX(b.PTX, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, fmt.Errorf("invalid PTX file in bundle: %w", err)
+	}
+	if id := ptxFile.GetProof().GetVerificationKeyId(); id != b.Manifest.CircuitID {
+		return nil, fmt.Errorf("bundle circuit ID %q does not match PTX verification key ID %q", b.Manifest.CircuitID, id)
+	}
+
+	return b, nil
+}
+
+// ReadBytes decodes an in-memory bundle
+func ReadBytes(data []byte) (*Bundle, error) {
+	return Read(bytes.NewReader(data))
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,559 @@
+// Package ceremony runs the multi-party trusted setup of the native Groth16
+// keys, so that no single machine ever knows the toxic waste of a production
+// key. It wraps gnark's MPC setup (https://eprint.iacr.org/2017/1050):
+//
+//   - phase 1 (powers of tau) is circuit independent and may be imported
+//     from an earlier ceremony of the same domain size,
+//   - phase 2 is specific to the circuit of a verification key ID.
+//
+// Each participant runs Contribute on the latest file of the current phase,
+// in turn, and publishes the hash it prints. Finalize seals the phase with a
+// public random beacon chosen after the last contribution. Every step is
+// recorded in transcript.json next to the contribution files; anyone can
+// re-run Verify on the directory to check the chain of contributions and the
+// hashes of the resulting keys.
+package ceremony
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	cs "github.com/consensys/gnark/constraint/bn254"
+)
+
+// TranscriptFile is the transcript of a ceremony directory
+const TranscriptFile = "transcript.json"
+
+// SRSFile holds the sealed phase 1 output
+const SRSFile = "srs.bin"
+
+// Transcript is the auditable record of a ceremony
+type Transcript struct {
+	KeyID string `json:"keyId"`
+	// CircuitHash is the SHA-256 of the compiled constraint system, which
+	// ties the keys to the circuit of this build
+	CircuitHash string `json:"circuitHash"`
+	DomainSize  uint64 `json:"domainSize"`
+	Phase1      Phase  `json:"phase1"`
+	Phase2      Phase  `json:"phase2"`
+}
+
+// Phase records the contributions to one phase and how it was sealed
+type Phase struct {
+	// Contributions start with the deterministic initial state, index 0
+	Contributions []Contribution `json:"contributions"`
+	// Imported is the source of a phase 1 taken from an earlier ceremony
+	Imported string `json:"imported,omitempty"`
+	// Beacon is the public randomness the phase was sealed with
+	Beacon   string     `json:"beacon,omitempty"`
+	SealedAt *time.Time `json:"sealedAt,omitempty"`
+	// Outputs maps the files the phase produced to their SHA-256
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+// Contribution is one file of the chain
+type Contribution struct {
+	Index       int       `json:"index"`
+	Contributor string    `json:"contributor"`
+	File        string    `json:"file"`
+	Hash        string    `json:"hash"`
+	Time        time.Time `json:"time"`
+}
+
+// Sealed reports whether the phase is complete
+func (p *Phase) Sealed() bool {
+	return p.SealedAt != nil
+}
+
+// Ceremony is a ceremony directory
+type Ceremony struct {
+	Dir        string
+	Transcript Transcript
+}
+
+// Init starts a ceremony for the circuit of keyID in dir, which must not hold
+// one yet. With srsPath, phase 1 is imported from that sealed SRS file, which
+// must match the domain size of the circuit; otherwise the ceremony starts
+// with phase 1.
+func Init(dir, keyID, srsPath string) (*Ceremony, error) {
+	if _, err := os.Stat(filepath.Join(dir, TranscriptFile)); err == nil {
+		return nil, fmt.Errorf("%s already holds a ceremony", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	r1cs, circuitHash, err := compile(keyID)
+	if err != nil {
+		return nil, err
+	}
+	c := &Ceremony{Dir: dir, Transcript: Transcript{
+		KeyID:       keyID,
+		CircuitHash: circuitHash,
+		DomainSize:  ecc.NextPowerOfTwo(uint64(r1cs.GetNbConstraints())),
+	}}
+
+	if srsPath == "" {
+		if err := c.addContribution(1, "initial", mpcsetup.NewPhase1(c.Transcript.DomainSize)); err != nil {
+			return nil, err
+		}
+		return c, c.save()
+	}
+
+	data, err := os.ReadFile(srsPath)
+	if err != nil {
+		return nil, err
+	}
+	var commons mpcsetup.SrsCommons
+	if _, err := commons.ReadFrom(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("invalid SRS %s: %w", srsPath, err)
+	}
+	if n := uint64(len(commons.G1.AlphaTau)); n != c.Transcript.DomainSize {
+		return nil, fmt.Errorf("SRS %s has domain size %d, the circuit needs %d", srsPath, n, c.Transcript.DomainSize)
+	}
+	if err := writeFile(filepath.Join(dir, SRSFile), data); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	c.Transcript.Phase1 = Phase{
+		Imported: srsPath,
+		SealedAt: &now,
+		Outputs:  map[string]string{SRSFile: hashBytes(data)},
+	}
+	if err := c.startPhase2(r1cs, &commons); err != nil {
+		return nil, err
+	}
+	return c, c.save()
+}
+
+// Open loads the ceremony in dir
+func Open(dir string) (*Ceremony, error) {
+	data, err := os.ReadFile(filepath.Join(dir, TranscriptFile))
+	if err != nil {
+		return nil, err
+	}
+	c := &Ceremony{Dir: dir}
+	if err := json.Unmarshal(data, &c.Transcript); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", TranscriptFile, err)
+	}
+	return c, nil
+}
+
+// CurrentPhase returns the phase taking contributions, 1 or 2, or 0 once the
+// ceremony is finalized
+func (c *Ceremony) CurrentPhase() int {
+	switch {
+	case !c.Transcript.Phase1.Sealed():
+		return 1
+	case !c.Transcript.Phase2.Sealed():
+		return 2
+	}
+	return 0
+}
+
+// Phase returns phase 1 or 2 of the transcript
+func (c *Ceremony) Phase(n int) *Phase {
+	if n == 1 {
+		return &c.Transcript.Phase1
+	}
+	return &c.Transcript.Phase2
+}
+
+// Contribute checks the latest contribution of the current phase against its
+// predecessor, adds fresh randomness on top of it and records the result
+// under contributor. The randomness only lives in memory during the call.
+func (c *Ceremony) Contribute(contributor string) (*Contribution, error) {
+	if contributor == "" {
+		return nil, errors.New("contributor name is empty")
+	}
+	n := c.CurrentPhase()
+	if n == 0 {
+		return nil, errors.New("ceremony is already finalized")
+	}
+	p := c.Phase(n)
+	last := len(p.Contributions) - 1
+	if last > 0 {
+		if err := c.VerifyContribution(n, last); err != nil {
+			return nil, err
+		}
+	}
+
+	var next interface {
+		io.WriterTo
+		Contribute()
+	}
+	if n == 1 {
+		p1 := new(mpcsetup.Phase1)
+		if err := c.read(p.Contributions[last], p1); err != nil {
+			return nil, err
+		}
+		next = p1
+	} else {
+		p2 := new(mpcsetup.Phase2)
+		if err := c.read(p.Contributions[last], p2); err != nil {
+			return nil, err
+		}
+		next = p2
+	}
+	next.Contribute()
+
+	if err := c.addContribution(n, contributor, next); err != nil {
+		return nil, err
+	}
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return &p.Contributions[len(p.Contributions)-1], nil
+}
+
+// VerifyContribution checks contribution index of phase n: its file must
+// match the transcript hash and, for index 0, the deterministic initial
+// state; later contributions must carry valid proofs of an update of their
+// predecessor.
+func (c *Ceremony) VerifyContribution(n, index int) error {
+	p := c.Phase(n)
+	if index < 0 || index >= len(p.Contributions) {
+		return fmt.Errorf("phase %d has no contribution %d", n, index)
+	}
+	cur := p.Contributions[index]
+
+	if index == 0 {
+		initial, err := c.initial(n)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		if _, err := initial.WriteTo(h); err != nil {
+			return err
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != cur.Hash {
+			return fmt.Errorf("phase %d: %s is not the initial state (hash %s, expected %s)", n, cur.File, cur.Hash, got)
+		}
+		_, err = c.load(cur)
+		return err
+	}
+
+	prev := p.Contributions[index-1]
+	var err error
+	if n == 1 {
+		a, b := new(mpcsetup.Phase1), new(mpcsetup.Phase1)
+		if err = c.read(prev, a); err == nil {
+			if err = c.read(cur, b); err == nil {
+				err = a.Verify(b)
+			}
+		}
+	} else {
+		a, b := new(mpcsetup.Phase2), new(mpcsetup.Phase2)
+		if err = c.read(prev, a); err == nil {
+			if err = c.read(cur, b); err == nil {
+				err = a.Verify(b)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("phase %d contribution %d (%s): %w", n, index, cur.Contributor, err)
+	}
+	return nil
+}
+
+// Verify checks every contribution of the transcript and, for sealed phases,
+// that sealing again with the recorded beacon reproduces the outputs
+func (c *Ceremony) Verify() error {
+	r1cs, err := c.circuit()
+	if err != nil {
+		return err
+	}
+	for _, n := range []int{1, 2} {
+		p := c.Phase(n)
+		for i := range p.Contributions {
+			if err := c.VerifyContribution(n, i); err != nil {
+				return err
+			}
+		}
+		if !p.Sealed() {
+			continue
+		}
+		outputs := make(map[string][]byte)
+		if p.Imported == "" {
+			if outputs, err = c.seal(n, p.Beacon, r1cs); err != nil {
+				return err
+			}
+		}
+		for file, want := range p.Outputs {
+			data, ok := outputs[file]
+			if !ok {
+				if data, err = os.ReadFile(filepath.Join(c.Dir, file)); err != nil {
+					return err
+				}
+			}
+			if got := hashBytes(data); got != want {
+				return fmt.Errorf("phase %d: %s has hash %s, transcript %s", n, file, got, want)
+			}
+		}
+	}
+	return nil
+}
+
+// Finalize seals the current phase with beacon, public randomness that no
+// contributor could predict (e.g. a drand round published after the last
+// contribution). Sealing phase 1 writes the SRS and starts phase 2; sealing
+// phase 2 writes the proving and verification keys, named as
+// prover.KeyPaths, to the ceremony directory.
+func (c *Ceremony) Finalize(beacon string) error {
+	if beacon == "" {
+		return errors.New("beacon is empty")
+	}
+	n := c.CurrentPhase()
+	if n == 0 {
+		return errors.New("ceremony is already finalized")
+	}
+	p := c.Phase(n)
+	if len(p.Contributions) < 2 {
+		return fmt.Errorf("phase %d has no contributions", n)
+	}
+	r1cs, err := c.circuit()
+	if err != nil {
+		return err
+	}
+	outputs, err := c.seal(n, beacon, r1cs)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	p.Beacon, p.SealedAt = beacon, &now
+	p.Outputs = make(map[string]string, len(outputs))
+	for file, data := range outputs {
+		if err := writeFile(filepath.Join(c.Dir, file), data); err != nil {
+			return err
+		}
+		p.Outputs[file] = hashBytes(data)
+	}
+	if n == 1 {
+		var commons mpcsetup.SrsCommons
+		if _, err := commons.ReadFrom(bytes.NewReader(outputs[SRSFile])); err != nil {
+			return err
+		}
+		if err := c.startPhase2(r1cs, &commons); err != nil {
+			return err
+		}
+	}
+	return c.save()
+}
+
+// seal verifies the contributions of phase n and applies beacon to the last
+// one. It returns the serialized outputs by file name.
+func (c *Ceremony) seal(n int, beacon string, r1cs *cs.R1CS) (map[string][]byte, error) {
+	p := c.Phase(n)
+	var buf bytes.Buffer
+	if n == 1 {
+		contributions := make([]*mpcsetup.Phase1, len(p.Contributions)-1)
+		for i := range contributions {
+			contributions[i] = new(mpcsetup.Phase1)
+			if err := c.read(p.Contributions[i+1], contributions[i]); err != nil {
+				return nil, err
+			}
+		}
+		commons, err := mpcsetup.VerifyPhase1(c.Transcript.DomainSize, []byte(beacon), contributions...)
+		if err != nil {
+			return nil, fmt.Errorf("phase 1: %w", err)
+		}
+		if _, err := commons.WriteTo(&buf); err != nil {
+			return nil, err
+		}
+		return map[string][]byte{SRSFile: buf.Bytes()}, nil
+	}
+
+	var commons mpcsetup.SrsCommons
+	if err := c.readFile(SRSFile, &commons); err != nil {
+		return nil, err
+	}
+	contributions := make([]*mpcsetup.Phase2, len(p.Contributions)-1)
+	for i := range contributions {
+		contributions[i] = new(mpcsetup.Phase2)
+		if err := c.read(p.Contributions[i+1], contributions[i]); err != nil {
+			return nil, err
+		}
+	}
+	pk, vk, err := mpcsetup.VerifyPhase2(r1cs, &commons, []byte(beacon), contributions...)
+	if err != nil {
+		return nil, fmt.Errorf("phase 2: %w", err)
+	}
+	pkPath, vkPath := prover.KeyPaths(c.Transcript.KeyID)
+	if _, err := pk.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	pkData := bytes.Clone(buf.Bytes())
+	buf.Reset()
+	if _, err := vk.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return map[string][]byte{pkPath: pkData, vkPath: buf.Bytes()}, nil
+}
+
+// startPhase2 writes the initial phase 2 state of the circuit
+func (c *Ceremony) startPhase2(r1cs *cs.R1CS, commons *mpcsetup.SrsCommons) error {
+	var p2 mpcsetup.Phase2
+	p2.Initialize(r1cs, commons)
+	return c.addContribution(2, "initial", &p2)
+}
+
+// initial recomputes the initial state of phase n
+func (c *Ceremony) initial(n int) (io.WriterTo, error) {
+	if n == 1 {
+		return mpcsetup.NewPhase1(c.Transcript.DomainSize), nil
+	}
+	r1cs, err := c.circuit()
+	if err != nil {
+		return nil, err
+	}
+	var commons mpcsetup.SrsCommons
+	if err := c.readFile(SRSFile, &commons); err != nil {
+		return nil, err
+	}
+	var p2 mpcsetup.Phase2
+	p2.Initialize(r1cs, &commons)
+	return &p2, nil
+}
+
+// addContribution writes v as the next file of phase n and records it
+func (c *Ceremony) addContribution(n int, contributor string, v io.WriterTo) error {
+	p := c.Phase(n)
+	index := len(p.Contributions)
+	file := fmt.Sprintf("phase%d_%04d.bin", n, index)
+	hash, err := c.writeTo(file, v)
+	if err != nil {
+		return err
+	}
+	p.Contributions = append(p.Contributions, Contribution{
+		Index:       index,
+		Contributor: contributor,
+		File:        file,
+		Hash:        hash,
+		Time:        time.Now().UTC(),
+	})
+	return nil
+}
+
+// read decodes a contribution file after checking its hash
+func (c *Ceremony) read(contribution Contribution, v io.ReaderFrom) error {
+	data, err := c.load(contribution)
+	if err != nil {
+		return err
+	}
+	if _, err := v.ReadFrom(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("invalid %s: %w", contribution.File, err)
+	}
+	return nil
+}
+
+// load reads a contribution file and checks it against the transcript hash
+func (c *Ceremony) load(contribution Contribution) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(c.Dir, contribution.File))
+	if err != nil {
+		return nil, err
+	}
+	if got := hashBytes(data); got != contribution.Hash {
+		return nil, fmt.Errorf("%s has hash %s, transcript %s", contribution.File, got, contribution.Hash)
+	}
+	return data, nil
+}
+
+func (c *Ceremony) readFile(file string, v io.ReaderFrom) error {
+	f, err := os.Open(filepath.Join(c.Dir, file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := v.ReadFrom(f); err != nil {
+		return fmt.Errorf("invalid %s: %w", file, err)
+	}
+	return nil
+}
+
+// writeTo serializes v to file and returns its SHA-256
+func (c *Ceremony) writeTo(file string, v io.WriterTo) (string, error) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return "", err
+	}
+	if err := writeFile(filepath.Join(c.Dir, file), buf.Bytes()); err != nil {
+		return "", err
+	}
+	return hashBytes(buf.Bytes()), nil
+}
+
+func (c *Ceremony) save() error {
+	data, err := json.MarshalIndent(c.Transcript, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(c.Dir, TranscriptFile), append(data, '\n'))
+}
+
+// circuit compiles the circuit of the ceremony and checks that it is still
+// the one the transcript was started for
+func (c *Ceremony) circuit() (*cs.R1CS, error) {
+	r1cs, circuitHash, err := compile(c.Transcript.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if circuitHash != c.Transcript.CircuitHash {
+		return nil, fmt.Errorf("circuit of %s changed since the ceremony started (hash %s, transcript %s)", c.Transcript.KeyID, circuitHash, c.Transcript.CircuitHash)
+	}
+	return r1cs, nil
+}
+
+// compile compiles the circuit of keyID and hashes the constraint system
+func compile(keyID string) (*cs.R1CS, string, error) {
+	ccs, err := prover.CompileCircuitFor(keyID)
+	if err != nil {
+		return nil, "", err
+	}
+	r1cs, ok := ccs.(*cs.R1CS)
+	if !ok {
+		return nil, "", fmt.Errorf("circuit of %s is not a BN254 R1CS", keyID)
+	}
+	h := sha256.New()
+	if _, err := r1cs.WriteTo(h); err != nil {
+		return nil, "", err
+	}
+	return r1cs, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeFile replaces path atomically, so that an interrupted contribution
+// never leaves a truncated file behind
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ceremony-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func hashBytes(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
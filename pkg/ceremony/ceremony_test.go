@@ -0,0 +1,252 @@
+package ceremony_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ceremony"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+var (
+	finishedOnce sync.Once
+	finishedDir  string
+	finishedErr  error
+)
+
+// finished runs a ceremony with two contributors per phase once per process
+// and returns a copy of its directory
+func finished(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("a ceremony over the full circuit takes about a minute")
+	}
+	finishedOnce.Do(func() {
+		if finishedDir, finishedErr = os.MkdirTemp("", "ceremony-test-"); finishedErr == nil {
+			finishedErr = runCeremony(finishedDir)
+		}
+	})
+	if finishedErr != nil {
+		t.Fatal(finishedErr)
+	}
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(finishedDir)); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func runCeremony(dir string) error {
+	c, err := ceremony.Init(dir, signals.DefaultVerificationKeyID, "")
+	if err != nil {
+		return err
+	}
+	for _, beacon := range []string{"beacon-1", "beacon-2"} {
+		for _, who := range []string{"alice", "bob"} {
+			if _, err := c.Contribute(who); err != nil {
+				return err
+			}
+		}
+		if err := c.Finalize(beacon); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if finishedDir != "" {
+		os.RemoveAll(finishedDir)
+	}
+	os.Exit(code)
+}
+
+func open(t *testing.T, dir string) *ceremony.Ceremony {
+	t.Helper()
+	c, err := ceremony.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func readKey(t *testing.T, path string, key io.ReaderFrom) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := key.ReadFrom(f); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
+
+func TestCeremonyRoundTrip(t *testing.T) {
+	dir := finished(t)
+	c := open(t, dir)
+	if c.CurrentPhase() != 0 {
+		t.Fatalf("phase %d after finalizing both phases", c.CurrentPhase())
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	for n := 1; n <= 2; n++ {
+		for i := range c.Phase(n).Contributions {
+			if err := c.VerifyContribution(n, i); err != nil {
+				t.Errorf("phase %d contribution %d: %v", n, i, err)
+			}
+		}
+	}
+	if _, err := c.Contribute("mallory"); err == nil {
+		t.Error("contribution accepted after the ceremony was finalized")
+	}
+
+	// The keys of the ceremony prove and verify
+	pkPath, vkPath := prover.KeyPaths(c.Transcript.KeyID)
+	pk, vk := groth16.NewProvingKey(ecc.BN254), groth16.NewVerifyingKey(ecc.BN254)
+	readKey(t, filepath.Join(dir, pkPath), pk)
+	readKey(t, filepath.Join(dir, vkPath), vk)
+
+	inputs, err := prover.NewProver().GenerateCircuitInputs("example.com", map[string]interface{}{"role": "validator"}, "1234", "5678", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccs, err := prover.CompileCircuitFor(c.Transcript.KeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := frontend.NewWitness(inputs.Circuit(), ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := w.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, public); err != nil {
+		t.Fatalf("proof under the ceremony keys: %v", err)
+	}
+}
+
+// TestTranscriptChain checks that every transcript entry pins its file and
+// that each contribution is checked against the one before it
+func TestTranscriptChain(t *testing.T) {
+	dir := finished(t)
+	c := open(t, dir)
+	for n := 1; n <= 2; n++ {
+		p := c.Phase(n)
+		if len(p.Contributions) != 3 {
+			t.Fatalf("phase %d has %d contributions, want the initial state and 2", n, len(p.Contributions))
+		}
+		for i, contribution := range p.Contributions {
+			data, err := os.ReadFile(filepath.Join(dir, contribution.File))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != contribution.Hash || contribution.Index != i {
+				t.Errorf("phase %d entry %d does not pin %s", n, i, contribution.File)
+			}
+		}
+		for file, hash := range p.Outputs {
+			data, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+				t.Errorf("phase %d output %s does not match the transcript", n, file)
+			}
+		}
+	}
+
+	// Dropping a link leaves bob's contribution without its predecessor
+	p := c.Phase(2)
+	p.Contributions = append(p.Contributions[:1], p.Contributions[2])
+	if err := c.VerifyContribution(2, 1); err == nil {
+		t.Error("contribution verified against a state it was not built on")
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, dir string, c *ceremony.Ceremony)
+	}{
+		{
+			name: "file replaced",
+			tamper: func(t *testing.T, dir string, c *ceremony.Ceremony) {
+				// Alice's file in place of bob's, the transcript unchanged
+				p := c.Phase(1)
+				data, err := os.ReadFile(filepath.Join(dir, p.Contributions[1].File))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, p.Contributions[2].File), data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "file and hash replaced",
+			tamper: func(t *testing.T, dir string, c *ceremony.Ceremony) {
+				// A state without a valid update proof, pinned in the transcript
+				p := c.Phase(2)
+				p.Contributions[2].File, p.Contributions[2].Hash = p.Contributions[0].File, p.Contributions[0].Hash
+			},
+		},
+		{
+			name: "reordered",
+			tamper: func(t *testing.T, dir string, c *ceremony.Ceremony) {
+				p := c.Phase(1)
+				a, b := p.Contributions[1], p.Contributions[2]
+				p.Contributions[1].File, p.Contributions[1].Hash = b.File, b.Hash
+				p.Contributions[2].File, p.Contributions[2].Hash = a.File, a.Hash
+			},
+		},
+		{
+			name: "initial state",
+			tamper: func(t *testing.T, dir string, c *ceremony.Ceremony) {
+				p := c.Phase(2)
+				p.Contributions[0] = p.Contributions[1]
+				p.Contributions[0].Index = 0
+			},
+		},
+		{
+			name: "beacon",
+			tamper: func(t *testing.T, dir string, c *ceremony.Ceremony) {
+				c.Transcript.Phase2.Beacon = "beacon-3"
+			},
+		},
+		{
+			name: "verification key",
+			tamper: func(t *testing.T, dir string, c *ceremony.Ceremony) {
+				_, vkPath := prover.KeyPaths(c.Transcript.KeyID)
+				c.Transcript.Phase2.Outputs[vkPath] = c.Transcript.Phase1.Outputs[ceremony.SRSFile]
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := finished(t)
+			c := open(t, dir)
+			tt.tamper(t, dir, c)
+			if err := c.Verify(); err == nil {
+				t.Error("tampered ceremony verified")
+			}
+		})
+	}
+}
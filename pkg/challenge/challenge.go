@@ -0,0 +1,116 @@
+// Package challenge keeps the one-time challenges of proof-of-possession
+// verification. A verifier issues a random challenge, the holder proves a
+// fresh PTX with the challenge as the metadata nonce, and the verifier
+// consumes the challenge when the PTX is presented. A challenge is accepted
+// once, only within its lifetime and only in the scope it was issued for.
+package challenge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long an issued challenge can be answered
+const DefaultTTL = 2 * time.Minute
+
+// Store issues and consumes challenges. Scope namespaces them, e.g. per
+// tenant.
+type Store interface {
+	// Issue returns a new random challenge that can be consumed for ttl
+	Issue(ctx context.Context, scope string, ttl time.Duration) (string, error)
+	// Consume reports whether challenge was issued in scope and has not
+	// expired or been consumed yet, and consumes it
+	Consume(ctx context.Context, scope, challenge string) (bool, error)
+	// Ping checks that the challenge store is reachable
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// newChallenge returns 32 random bytes, base64url encoded
+func newChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// memoryStore keeps challenges in process memory. It is used when no Redis
+// server is configured, so a challenge must be answered on the instance that
+// issued it.
+type memoryStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// maxMemoryChallenges triggers a sweep of expired challenges
+const maxMemoryChallenges = 10000
+
+// NewMemory returns an in-process Store
+func NewMemory() Store {
+	return &memoryStore{expires: make(map[string]time.Time)}
+}
+
+func (m *memoryStore) Issue(_ context.Context, scope string, ttl time.Duration) (string, error) {
+	c, err := newChallenge()
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if len(m.expires) >= maxMemoryChallenges {
+		m.sweep(now)
+	}
+	m.expires[scope+c] = now.Add(ttl)
+	return c, nil
+}
+
+func (m *memoryStore) Consume(_ context.Context, scope, challenge string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exp, ok := m.expires[scope+challenge]
+	if !ok {
+		return false, nil
+	}
+	delete(m.expires, scope+challenge)
+	return time.Now().Before(exp), nil
+}
+
+// sweep drops expired challenges
+func (m *memoryStore) sweep(now time.Time) {
+	for k, exp := range m.expires {
+		if !now.Before(exp) {
+			delete(m.expires, k)
+		}
+	}
+}
+
+func (m *memoryStore) Ping(context.Context) error {
+	return nil
+}
+
+func (m *memoryStore) Close() error {
+	return nil
+}
+
+// Scoped binds a Store to one scope. It consumes challenges as
+// verifier.VerificationOptions.Challenges expects.
+type Scoped struct {
+	Store Store
+	Scope string
+}
+
+// Issue returns a new challenge in the scope
+func (s Scoped) Issue(ctx context.Context, ttl time.Duration) (string, error) {
+	return s.Store.Issue(ctx, s.Scope, ttl)
+}
+
+// Consume consumes a challenge of the scope
+func (s Scoped) Consume(ctx context.Context, challenge string) (bool, error) {
+	return s.Store.Consume(ctx, s.Scope, challenge)
+}
@@ -0,0 +1,55 @@
+package challenge
+
+import (
+	"context"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/redisconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix namespaces challenge keys in Redis
+const KeyPrefix = "ptx:challenge:"
+
+type redisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedis returns a Store sharing challenges across instances through the
+// Redis deployment at url (see redisconn.Open)
+func NewRedis(url string) (Store, error) {
+	client, err := redisconn.Open(url)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{client: client}, nil
+}
+
+func (r *redisStore) Issue(ctx context.Context, scope string, ttl time.Duration) (string, error) {
+	c, err := newChallenge()
+	if err != nil {
+		return "", err
+	}
+	if err := r.client.Set(ctx, KeyPrefix+scope+c, "1", ttl).Err(); err != nil {
+		return "", err
+	}
+	return c, nil
+}
+
+// Consume deletes the challenge key, which succeeds for exactly one caller
+// while the key has not expired
+func (r *redisStore) Consume(ctx context.Context, scope, challenge string) (bool, error) {
+	n, err := r.client.Del(ctx, KeyPrefix+scope+challenge).Result()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *redisStore) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *redisStore) Close() error {
+	return r.client.Close()
+}
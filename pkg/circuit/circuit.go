@@ -1,6 +1,9 @@
 package circuit
 
 import (
+	"fmt"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit/poseidon"
 	"github.com/consensys/gnark/frontend"
 )
@@ -22,27 +25,55 @@ type DoHCircuit struct {
 
 // Define declares the circuit constraints
 func (c *DoHCircuit) Define(api frontend.API) error {
+	return bindCommitment(api, c.Fqdn, c.MetadataHashP1, c.MetadataHashP2, c.TrustMethod,
+		c.Nullifier, c.Secret, c.NullifierHash, c.Commitment)
+}
+
+// bindCommitment constrains nullifierHash and commitment to the SDV
+// derivation from the context (fqdn, metadata digest limbs, trust method) and
+// the private nullifier and secret
+func bindCommitment(api frontend.API, fqdn, metaP1, metaP2, trustMethod, nullifier, secret, nullifierHash, commitment frontend.Variable) error {
 	// 1. Context Hash = Poseidon(fqdn, metadataHash_p1, metadataHash_p2, trustMethod)
-	contextHash, err := poseidon.Hash4(api, c.Fqdn, c.MetadataHashP1, c.MetadataHashP2, c.TrustMethod)
+	contextHash, err := poseidon.Hash4(api, fqdn, metaP1, metaP2, trustMethod)
 	if err != nil {
 		return err
 	}
+	return bindContext(api, contextHash, nullifier, secret, nullifierHash, commitment)
+}
 
+// bindContext constrains nullifierHash and commitment to the SDV derivation
+// from a context hash and the private nullifier and secret
+func bindContext(api frontend.API, contextHash, nullifier, secret, nullifierHash, commitment frontend.Variable) error {
 	// 2. Nullifier Hash = Poseidon(nullifier)
-	calcNullifierHash, err := poseidon.Hash1(api, c.Nullifier)
+	calcNullifierHash, err := poseidon.Hash1(api, nullifier)
 	if err != nil {
 		return err
 	}
 
 	// 3. Commitment = Poseidon(nullifier, secret, contextHash)
-	calcCommitment, err := poseidon.Hash3(api, c.Nullifier, c.Secret, contextHash)
+	calcCommitment, err := poseidon.Hash3(api, nullifier, secret, contextHash)
 	if err != nil {
 		return err
 	}
 
 	// 4. Constraints
-	api.AssertIsEqual(c.NullifierHash, calcNullifierHash)
-	api.AssertIsEqual(c.Commitment, calcCommitment)
+	api.AssertIsEqual(nullifierHash, calcNullifierHash)
+	api.AssertIsEqual(commitment, calcCommitment)
 
 	return nil
 }
+
+// ForKeyID returns an empty instance of the circuit a verification key ID
+// belongs to, for compilation
+func ForKeyID(keyID string) (frontend.Circuit, error) {
+	switch keyID {
+	case signals.DefaultVerificationKeyID:
+		return &DoHCircuit{}, nil
+	case signals.MetadataSHA256KeyID:
+		return &MetadataSHA256Circuit{}, nil
+	case signals.ScopeBoundKeyID:
+		return &ScopeBoundCircuit{}, nil
+	default:
+		return nil, fmt.Errorf("no circuit for verification key %q", keyID)
+	}
+}
@@ -0,0 +1,51 @@
+package circuit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark/constraint"
+)
+
+// identityHashPrefix separates the circuit ID from the constraint system
+// hash in an identity string
+const identityHashPrefix = "@sha256:"
+
+// Hash returns the hex SHA-256 of a compiled constraint system in gnark's
+// serialization. Compiling the same circuit definition with the same gnark
+// version always gives the same hash, so any change to the constraints,
+// their order or the public inputs shows as a different hash.
+func Hash(ccs constraint.ConstraintSystem) (string, error) {
+	h := sha256.New()
+	if _, err := ccs.WriteTo(h); err != nil {
+		return "", fmt.Errorf("failed to serialize constraint system: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Identity is the identity string of a circuit, its key ID and the hash of
+// its constraint system: "sdv_poseidon_v1@sha256:<hex>"
+func Identity(keyID, hash string) string {
+	return keyID + identityHashPrefix + hash
+}
+
+// ParseIdentity splits an identity string into its key ID and constraint
+// system hash
+func ParseIdentity(s string) (keyID, hash string, err error) {
+	keyID, hash, ok := strings.Cut(s, identityHashPrefix)
+	if !ok || keyID == "" || !IsHash(hash) {
+		return "", "", fmt.Errorf("invalid circuit identity %q (want <key ID>%s<hex>)", s, identityHashPrefix)
+	}
+	return keyID, hash, nil
+}
+
+// IsHash reports whether s is a constraint system hash as returned by Hash
+func IsHash(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
@@ -0,0 +1,60 @@
+package circuit
+
+import (
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// pinnedHashes are the constraint system hashes of the circuits. A change
+// here invalidates every key set up for the circuit: only update a hash
+// together with a new key ID and setup.
+var pinnedHashes = map[string]string{
+	signals.DefaultVerificationKeyID: "0244a3e5c613a745d5f29a65c295c157565383eacf3a1720caba4755d3444338",
+	signals.MetadataSHA256KeyID:      "55cbc5040d4a1f6f34e84311109017641d0c2bee91e554d117504d8d5c66c07b",
+	signals.ScopeBoundKeyID:          "aad07477e599ec8e491c7bc9df6207569c922d493238072994e8ff024b787d24",
+}
+
+func TestPinnedHashes(t *testing.T) {
+	for _, id := range signals.KeyIDs() {
+		want, ok := pinnedHashes[id]
+		if !ok {
+			t.Errorf("no pinned hash for circuit %s", id)
+			continue
+		}
+		if id == signals.MetadataSHA256KeyID && testing.Short() {
+			continue
+		}
+		c, err := ForKeyID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Hash(ccs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("circuit %s hashes to %s, pinned %s: its definition changed", id, got, want)
+		}
+	}
+}
+
+func TestParseIdentity(t *testing.T) {
+	hash := pinnedHashes[signals.DefaultVerificationKeyID]
+	id, h, err := ParseIdentity(Identity(signals.DefaultVerificationKeyID, hash))
+	if err != nil || id != signals.DefaultVerificationKeyID || h != hash {
+		t.Errorf("round trip = %q, %q, %v", id, h, err)
+	}
+	for _, s := range []string{"", signals.DefaultVerificationKeyID, "@sha256:" + hash, signals.DefaultVerificationKeyID + "@sha256:ABC"} {
+		if _, _, err := ParseIdentity(s); err == nil {
+			t.Errorf("ParseIdentity(%q) accepted", s)
+		}
+	}
+}
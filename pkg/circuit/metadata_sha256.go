@@ -0,0 +1,81 @@
+package circuit
+
+import (
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// MaxMetadataBytes is the longest metadata MetadataSHA256Circuit can hash
+const MaxMetadataBytes = signals.MetadataSHA256Chunks * crypto.PackedChunkBytes
+
+// MetadataSHA256Circuit is the SDV circuit with the metadata hashed
+// in-circuit (key ID signals.MetadataSHA256KeyID). DoHCircuit takes the
+// SHA256 digest of the metadata as two public limbs computed out of circuit;
+// this variant takes the metadata bytes themselves, packed as by
+// crypto.PackBytes, and derives the limbs with the sha2 gadget, so the proof
+// attests to the metadata content directly. Hashing up to MaxMetadataBytes
+// bytes takes the circuit from under a thousand constraints to about 274k,
+// which is why it is a separate circuit with its own keys.
+type MetadataSHA256Circuit struct {
+	// Public inputs
+	NullifierHash  frontend.Variable                               `gnark:",public"`
+	Commitment     frontend.Variable                               `gnark:",public"`
+	Fqdn           frontend.Variable                               `gnark:",public"`
+	TrustMethod    frontend.Variable                               `gnark:",public"`
+	MetadataLength frontend.Variable                               `gnark:",public"`
+	Metadata       [signals.MetadataSHA256Chunks]frontend.Variable `gnark:",public"`
+
+	// Private inputs
+	Nullifier frontend.Variable
+	Secret    frontend.Variable
+}
+
+// Define declares the circuit constraints
+func (c *MetadataSHA256Circuit) Define(api frontend.API) error {
+	bapi, err := uints.NewBytes(api)
+	if err != nil {
+		return err
+	}
+
+	// 1. Unpack the chunks into bytes. Decomposing each chunk into
+	// 8*PackedChunkBytes bits also range checks it.
+	data := make([]uints.U8, 0, MaxMetadataBytes)
+	for _, chunk := range c.Metadata {
+		bits := api.ToBinary(chunk, 8*crypto.PackedChunkBytes)
+		for j := 0; j < crypto.PackedChunkBytes; j++ {
+			// Chunks are big-endian, bits little-endian
+			off := 8 * (crypto.PackedChunkBytes - 1 - j)
+			data = append(data, bapi.ValueOf(api.FromBinary(bits[off:off+8]...)))
+		}
+	}
+
+	// 2. SHA256 over the first MetadataLength bytes. The gadget asserts
+	// that the length does not exceed MaxMetadataBytes.
+	h, err := sha2.New(api)
+	if err != nil {
+		return err
+	}
+	h.Write(data)
+	digest := h.FixedLengthSum(c.MetadataLength)
+
+	// 3. Split the digest as crypto.LimbsLowHigh does: p1 is the last 16
+	// bytes, p2 the first 16, both read big-endian
+	metaP1 := packBytes(api, bapi, digest[16:])
+	metaP2 := packBytes(api, bapi, digest[:16])
+
+	// 4. Same context, commitment and nullifier hash as DoHCircuit
+	return bindCommitment(api, c.Fqdn, metaP1, metaP2, c.TrustMethod,
+		c.Nullifier, c.Secret, c.NullifierHash, c.Commitment)
+}
+
+// packBytes returns the big-endian integer value of bytes
+func packBytes(api frontend.API, bapi *uints.Bytes, bytes []uints.U8) frontend.Variable {
+	var acc frontend.Variable = 0
+	for _, b := range bytes {
+		acc = api.Add(api.Mul(acc, 256), bapi.Value(b))
+	}
+	return acc
+}
@@ -1,24 +0,0 @@
-package poseidon
-
-// MDS matrices
-
-var M_T2 = [][]string{
-    {"0x66f6f85d6f68a85ec10345351a23a3aaf07f38af8c952a7bceca70bd2af7ad5", "0xcc57cdbb08507d62bf67a4493cc262fb6c09d557013fff1f573f431221f8ff9"},
-    {"0x2b9d4b4110c9ae997782e1509b1d0fdb20a7c02bbd8bea7305462b9f8125b1e8", "0x1274e649a32ed355a31a6ed69724e1adade857e86eb5c3a121bcd147943203c8"},
-}
-
-var M_T4 = [][]string{
-    {"0x236d13393ef85cc48a351dd786dd7a1de5e39942296127fd87947223ae5108ad", "0x2a75a171563b807db525be259699ab28fe9bc7fb1f70943ff049bc970e841a0c", "0x2070679e798782ef592a52ca9cef820d497ad2eecbaa7e42f366b3e521c4ed42", "0x2f545e578202c9732488540e41f783b68ff0613fd79375f8ba8b3d30958e7677"},
-    {"0x277686494f7644bbc4a9b194e10724eb967f1dc58718e59e3cedc821b2a7ae19", "0x83abff5e10051f078e2827d092e1ae808b4dd3e15ccc3706f38ce4157b6770e", "0x2e18c8570d20bf5df800739a53da75d906ece318cd224ab6b3a2be979e2d7eab", "0x23810bf82877fc19bff7eefeae3faf4bb8104c32ba4cd701596a15623d01476e"},
-    {"0x23db68784e3f0cc0b85618826a9b3505129c16479973b0a84a4529e66b09c62", "0x1a5ad71bbbecd8a97dc49cfdbae303ad24d5c4741eab8b7568a9ff8253a1eb6f", "0xfa86f0f27e4d3dd7f3367ce86f684f1f2e4386d3e5b9f38fa283c6aa723b608", "0x14fcd5eb0be6d5beeafc4944034cf321c068ef930f10be2207ed58d2a34cdd6"},
-    {"0x1d359d245f286c12d50d663bae733f978af08cdbd63017c57b3a75646ff382c1", "0xd745fd00dd167fb86772133640f02ce945004a7bc2c59e8790f725c5d84f0af", "0x3f3e6fab791f16628168e4b14dbaeb657035ee3da6b2ca83f0c2491e0b403eb", "0xc15fc3a1d5733dd835eae0823e377f8ba4a8b627627cc2bb661c25d20fb52a"},
-}
-
-var M_T5 = [][]string{
-    {"0x251e7fdf99591080080b0af133b9e4369f22e57ace3cd7f64fc6fdbcf38d7da1", "0x2a70b9f1d4bbccdbc03e17c1d1dcdb02052903dc6609ea6969f661b2eb74c839", "0x2f69a7198e1fbcc7dea43265306a37ed55b91bff652ad69aa4fa8478970d401d", "0xc3f050a6bf5af151981e55e3e1a29a13c3ffa4550bd2514f1afd6c5f721f830", "0x2a20e3a4a0e57d92f97c9d6186c6c3ea7c5e55c20146259be2f78c2ccc2e3595"},
-    {"0x25fb50b65acf4fb047cbd3b1c17d97c7fe26ea9ca238d6e348550486e91c7765", "0x281154651c921e746315a9934f1b8a1bba9f92ad8ef4b979115b8e2e991ccd7a", "0x1c1edd62645b73ad931ab80e37bbb267ba312b34140e716d6a3747594d3052", "0xdec54e6dbf75205fa75ba7992bd34f08b2efe2ecd424a73eda7784320a1a36e", "0x1049f8210566b51faafb1e9a5d63c0ee701673aed820d9c4403b01feb727a549"},
-    {"0x293d617d7da72102355f39ebf62f91b06deb5325f367a4556ea1e31ed5767833", "0x28c2be2f8264f95f0b53c732134efa338ccd8fdb9ee2b45fb86a894f7db36c37", "0x15b98ce93e47bc64ce2f2c96c69663c439c40c603049466fa7f9a4b228bfc32b", "0x1c482a25a729f5df20225815034b196098364a11f4d988fb7cc75cf32d8136fa", "0x2ecac687ef5b4b568002bd9d1b96b4bef357a69e3e86b5561b9299b82d69c8e"},
-    {"0x104d0295ab00c85e960111ac25da474366599e575a9b7edf6145f14ba6d3c1c4", "0x21888041e6febd546d427c890b1883bb9b626d8cb4dc18dcc4ec8fa75e530a13", "0x12c7e2adfa524e5958f65be2fbac809fcba8458b28e44d9265051de33163cf9c", "0x2625ce48a7b39a4252732624e4ab94360812ac2fc9a14a5fb8b607ae9fd8514a", "0x2d3a1aea2e6d44466808f88c9ba903d3bdcb6b58ba40441ed4ebcf11bbe1e37b"},
-    {"0xaaa35e2c84baf117dea3e336cd96a39792b3813954fe9bf3ed5b90f2f69c977", "0x14ddb5fada0171db80195b9592d8cf2be810930e3ea4574a350d65e2cbff4941", "0x2efc2b90d688134849018222e7b8922eaf67ce79816ef468531ec2de53bbd167", "0x7f017a7ebd56dd086f7cd4fd710c509ed7ef8e300b9a8bb9fb9f28af710251f", "0x14074bb14c982c81c9ad171e4f35fe49b39c4a7a72dbb6d9c98d803bfed65e64"},
-}
-
@@ -4,57 +4,18 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/frontend"
 )
 
-// Parameters for Poseidon hash
-type Parameters struct {
-	T        int        // State width (nInputs + 1)
-	NRoundsF int        // Full rounds
-	NRoundsP int        // Partial rounds
-	C        []string   // Round constants
-	M        [][]string // MDS matrix
-	P        [][]string // Pre-sparse matrix
-	S        []string   // Sparse matrix elements
-}
+// Parameters for Poseidon hash. The constants are generated into pkg/crypto
+// and shared with the off-circuit implementation.
+type Parameters = crypto.PoseidonParams
 
 // GetParams returns parameters for given t value
 func GetParams(t int) (*Parameters, error) {
-	nRoundsP := []int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65, 70, 60, 64, 68}
-
-	var c []string
-	var m, p [][]string
-	var s []string
-
-	switch t {
-	case 2:
-		c = C_T2
-		m = M_T2
-		p = P_T2
-		s = S_T2
-	case 4:
-		c = C_T4
-		m = M_T4
-		p = P_T4
-		s = S_T4
-	case 5:
-		c = C_T5
-		m = M_T5
-		p = P_T5
-		s = S_T5
-	default:
-		return nil, fmt.Errorf("unsupported t value: %d", t)
-	}
-
-	return &Parameters{
-		T:        t,
-		NRoundsF: 8,
-		NRoundsP: nRoundsP[t-2],
-		C:        c,
-		M:        m,
-		P:        p,
-		S:        s,
-	}, nil
+	return crypto.PoseidonParameters(t)
 }
 
 // Hasher implements Poseidon hash for gnark circuits
@@ -73,6 +34,11 @@ func NewHasher(api frontend.API, nInputs int) (*Hasher, error) {
 	return &Hasher{api: api, params: params}, nil
 }
 
+// constant converts a table element to a circuit constant
+func constant(e *fr.Element) *big.Int {
+	return e.BigInt(new(big.Int))
+}
+
 // sBox applies x^5 S-box
 func (h *Hasher) sBox(x frontend.Variable) frontend.Variable {
 	x2 := h.api.Mul(x, x)
@@ -84,23 +50,19 @@ func (h *Hasher) sBox(x frontend.Variable) frontend.Variable {
 func (h *Hasher) arkAtOffset(state []frontend.Variable, offset int) {
 	t := h.params.T
 	for i := 0; i < t; i++ {
-		c := new(big.Int)
-		c.SetString(h.params.C[offset+i][2:], 16) // Strip 0x prefix
-		state[i] = h.api.Add(state[i], c)
+		state[i] = h.api.Add(state[i], constant(&h.params.C[offset+i]))
 	}
 }
 
 // mix applies MDS matrix multiplication
-func (h *Hasher) mix(state []frontend.Variable, matrix [][]string) []frontend.Variable {
+func (h *Hasher) mix(state []frontend.Variable, matrix [][]fr.Element) []frontend.Variable {
 	t := h.params.T
 	result := make([]frontend.Variable, t)
 
 	for i := 0; i < t; i++ {
 		acc := frontend.Variable(0)
 		for j := 0; j < t; j++ {
-			m := new(big.Int)
-			m.SetString(matrix[j][i][2:], 16)
-			term := h.api.Mul(state[j], m)
+			term := h.api.Mul(state[j], constant(&matrix[j][i]))
 			acc = h.api.Add(acc, term)
 		}
 		result[i] = acc
@@ -117,18 +79,14 @@ func (h *Hasher) mixS(state []frontend.Variable, round int) []frontend.Variable
 	// First element is a dot product
 	acc := frontend.Variable(0)
 	for i := 0; i < t; i++ {
-		s := new(big.Int)
-		s.SetString(h.params.S[sOffset+i][2:], 16)
-		term := h.api.Mul(state[i], s)
+		term := h.api.Mul(state[i], constant(&h.params.S[sOffset+i]))
 		acc = h.api.Add(acc, term)
 	}
 	result[0] = acc
 
 	// Remaining elements
 	for i := 1; i < t; i++ {
-		s := new(big.Int)
-		s.SetString(h.params.S[sOffset+t+i-1][2:], 16)
-		term := h.api.Mul(state[0], s)
+		term := h.api.Mul(state[0], constant(&h.params.S[sOffset+t+i-1]))
 		result[i] = h.api.Add(state[i], term)
 	}
 
@@ -176,9 +134,7 @@ func (h *Hasher) Hash(inputs ...frontend.Variable) (frontend.Variable, error) {
 		state[0] = h.sBox(state[0])
 		// Add round constant to first element only
 		cIdx := (rf/2+1)*t + r
-		c := new(big.Int)
-		c.SetString(h.params.C[cIdx][2:], 16)
-		state[0] = h.api.Add(state[0], c)
+		state[0] = h.api.Add(state[0], constant(&h.params.C[cIdx]))
 		state = h.mixS(state, r)
 	}
 
@@ -226,3 +182,12 @@ func Hash4(api frontend.API, a, b, c, d frontend.Variable) (frontend.Variable, e
 	}
 	return h.Hash(a, b, c, d)
 }
+
+// Hash6 is a convenience function for hashing 6 inputs
+func Hash6(api frontend.API, a, b, c, d, e, f frontend.Variable) (frontend.Variable, error) {
+	h, err := NewHasher(api, 6)
+	if err != nil {
+		return nil, err
+	}
+	return h.Hash(a, b, c, d, e, f)
+}
@@ -0,0 +1,126 @@
+package poseidon
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// hashCircuit asserts that the Hasher maps In to Out
+type hashCircuit struct {
+	In  []frontend.Variable
+	Out frontend.Variable `gnark:",public"`
+}
+
+func (c *hashCircuit) Define(api frontend.API) error {
+	h, err := NewHasher(api, len(c.In))
+	if err != nil {
+		return err
+	}
+	out, err := h.Hash(c.In...)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(out, c.Out)
+	return nil
+}
+
+// solve runs the Hasher on inputs in gnark's test engine and reports whether
+// it outputs want
+func solve(inputs []fr.Element, want fr.Element) error {
+	circuit := &hashCircuit{In: make([]frontend.Variable, len(inputs))}
+	assignment := &hashCircuit{In: make([]frontend.Variable, len(inputs)), Out: want}
+	for i := range inputs {
+		assignment.In[i] = inputs[i]
+	}
+	return test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+}
+
+// TestHasherReferenceVectors checks the circuit against the circomlibjs
+// vectors of pkg/crypto
+func TestHasherReferenceVectors(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "crypto", "testdata", "poseidon_vectors.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Inputs []string `json:"inputs"`
+		Hash   string   `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range vectors {
+		inputs := make([]fr.Element, len(v.Inputs))
+		for i, s := range v.Inputs {
+			if inputs[i], err = crypto.ParseFr(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := crypto.ParseFr(v.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := solve(inputs, want); err != nil {
+			t.Errorf("poseidon(%v) != %s in circuit: %v", v.Inputs, v.Hash, err)
+		}
+	}
+}
+
+// TestHasherMatchesNative asserts that the circuit computes
+// crypto.PoseidonHash for random inputs of every supported arity. A
+// divergence makes every proof fail, or worse, bind other values than the
+// verifier derives.
+func TestHasherMatchesNative(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	runs := 4
+	if testing.Short() {
+		runs = 1
+	}
+
+	for n := 1; n <= 16; n++ {
+		for range runs {
+			inputs := make([]fr.Element, n)
+			ptrs := make([]*fr.Element, n)
+			for i := range inputs {
+				var b [fr.Bytes]byte
+				for j := range b {
+					b[j] = byte(rng.Uint32())
+				}
+				inputs[i].SetBytes(b[:])
+				ptrs[i] = &inputs[i]
+			}
+			want, err := crypto.PoseidonHash(ptrs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := solve(inputs, *want); err != nil {
+				t.Fatalf("%d inputs %v: circuit differs from native hash %s: %v", n, inputs, want, err)
+			}
+			one := fr.One()
+			var wrong fr.Element
+			wrong.Add(want, &one)
+			if solve(inputs, wrong) == nil {
+				t.Fatalf("%d inputs: circuit accepts a wrong hash", n)
+			}
+		}
+	}
+}
+
+func TestHasherArity(t *testing.T) {
+	for _, n := range []int{0, 17} {
+		if _, err := NewHasher(nil, n); err == nil {
+			t.Errorf("NewHasher accepted %d inputs", n)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package circuit
+
+import (
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit/poseidon"
+	"github.com/consensys/gnark/frontend"
+)
+
+// ScopeBoundCircuit is the v3 SDV circuit (key ID signals.ScopeBoundKeyID).
+// It extends DoHCircuit with the audience and scope hashes of the token (see
+// crypto.MetadataBinding) as public inputs and folds them into the context
+// hash. The metadata digest already covers them, but only for verifiers that
+// re-derive and check the metadata; here the commitment itself binds them,
+// so a proof cannot be presented with a different audienceHash signal.
+type ScopeBoundCircuit struct {
+	// Public inputs
+	NullifierHash  frontend.Variable `gnark:",public"`
+	Commitment     frontend.Variable `gnark:",public"`
+	Fqdn           frontend.Variable `gnark:",public"`
+	MetadataHashP1 frontend.Variable `gnark:",public"`
+	MetadataHashP2 frontend.Variable `gnark:",public"`
+	TrustMethod    frontend.Variable `gnark:",public"`
+	AudienceHash   frontend.Variable `gnark:",public"`
+	ScopeHash      frontend.Variable `gnark:",public"`
+
+	// Private inputs
+	Nullifier frontend.Variable
+	Secret    frontend.Variable
+}
+
+// Define declares the circuit constraints
+func (c *ScopeBoundCircuit) Define(api frontend.API) error {
+	// Context Hash = Poseidon(fqdn, metadataHash_p1, metadataHash_p2,
+	// trustMethod, audienceHash, scopeHash)
+	contextHash, err := poseidon.Hash6(api, c.Fqdn, c.MetadataHashP1, c.MetadataHashP2, c.TrustMethod,
+		c.AudienceHash, c.ScopeHash)
+	if err != nil {
+		return err
+	}
+	return bindContext(api, contextHash, c.Nullifier, c.Secret, c.NullifierHash, c.Commitment)
+}
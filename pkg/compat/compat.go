@@ -0,0 +1,409 @@
+// Package compat checks the Go implementation against golden vectors
+// produced by the reference JS implementation, so that any divergence in
+// hashing, domain normalization, JSON canonicalization, limb splitting,
+// hostname derivation or PTX encoding is caught byte-for-byte.
+package compat
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the vector file format version
+const Version = 1
+
+//go:embed vectors/golden.json
+var golden []byte
+
+// Vectors is a golden vector file. Every vector records where its expected
+// values came from in Source.
+type Vectors struct {
+	Version int `json:"version"`
+	// Generator describes the tool and version that produced the file
+	Generator      string                `json:"generator"`
+	Poseidon       []PoseidonVector      `json:"poseidon"`
+	FieldStrings   []FieldStringVector   `json:"fieldStrings"`
+	Domains        []DomainVector        `json:"domains"`
+	CanonicalJSON  []CanonicalJSONVector `json:"canonicalJson"`
+	MetadataHashes []MetadataHashVector  `json:"metadataHashes"`
+	Commitments    []CommitmentVector    `json:"commitments"`
+	Hostnames      []HostnameVector      `json:"hostnames"`
+	PTX            []PTXVector           `json:"ptx"`
+}
+
+// PoseidonVector is a circomlib Poseidon hash of decimal field elements
+type PoseidonVector struct {
+	Source string   `json:"source"`
+	Inputs []string `json:"inputs"`
+	Output string   `json:"output"`
+}
+
+// FieldStringVector maps a string (e.g. a domain) to its field element
+// encoding, SHA-256 reduced modulo the BN254 scalar field
+type FieldStringVector struct {
+	Source string `json:"source"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// DomainVector is a domain name and its normalized form, which is what gets
+// hashed and used in anchor hostnames. Error marks names that must be
+// rejected.
+type DomainVector struct {
+	Source     string `json:"source"`
+	Input      string `json:"input"`
+	Normalized string `json:"normalized,omitempty"`
+	Error      bool   `json:"error,omitempty"`
+}
+
+// CanonicalJSONVector is a JSON document and its canonical serialization,
+// which is what the metadata hash covers. Error marks documents that must be
+// rejected.
+type CanonicalJSONVector struct {
+	Source string `json:"source"`
+	Input  string `json:"input"`
+	Output string `json:"output,omitempty"`
+	Error  bool   `json:"error,omitempty"`
+}
+
+// MetadataHashVector is the SHA-256 of a metadata string and its two
+// 128-bit limbs as circuit inputs
+type MetadataHashVector struct {
+	Source string `json:"source"`
+	// Limbs is the limb encoding, "low-high" (default) or "high-low"
+	Limbs    string `json:"limbs,omitempty"`
+	Metadata string `json:"metadata"`
+	SHA256   string `json:"sha256"`
+	P1       string `json:"p1"`
+	P2       string `json:"p2"`
+}
+
+// CommitmentVector is a full set of circuit inputs. Metadata must be the
+// canonical JSON the prover signs (see crypto.CanonicalJSON). KeyID selects
+// the circuit, the default one when empty; for circuits binding the audience
+// and scopes AudienceHash and ScopeHash are their expected signals.
+type CommitmentVector struct {
+	Source        string `json:"source"`
+	KeyID         string `json:"keyId,omitempty"`
+	Domain        string `json:"domain"`
+	Metadata      string `json:"metadata"`
+	Nullifier     string `json:"nullifier"`
+	Secret        string `json:"secret"`
+	TrustMethod   int    `json:"trustMethod"`
+	NullifierHash string `json:"nullifierHash"`
+	Commitment    string `json:"commitment"`
+	AudienceHash  string `json:"audienceHash,omitempty"`
+	ScopeHash     string `json:"scopeHash,omitempty"`
+}
+
+// HostnameVector is the anchor hostname derived from a commitment
+type HostnameVector struct {
+	Source     string `json:"source"`
+	Commitment string `json:"commitment"`
+	Domain     string `json:"domain"`
+	// Version is the hostname derivation, 1 if unset
+	Version  int    `json:"version,omitempty"`
+	Hostname string `json:"hostname"`
+}
+
+// PTXVector is an encoded PTX file and the values it must decode to
+type PTXVector struct {
+	Source            string   `json:"source"`
+	Name              string   `json:"name"`
+	Hex               string   `json:"hex"`
+	Domain            string   `json:"domain"`
+	Metadata          string   `json:"metadata"`
+	TrustMethod       int      `json:"trustMethod"`
+	VerificationKeyID string   `json:"verificationKeyId"`
+	PublicSignals     []string `json:"publicSignals"`
+	AnchorHostname    string   `json:"anchorHostname"`
+	AnchorValue       string   `json:"anchorValue"`
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report holds the results of Check
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Failed returns the results that did not match
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if !res.OK {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+func (r *Report) add(kind, name, source string, err error) {
+	res := Result{Kind: kind, Name: name, Source: source, OK: err == nil}
+	if err != nil {
+		res.Detail = err.Error()
+	}
+	r.Results = append(r.Results, res)
+}
+
+// Golden returns the vectors shipped with this package
+func Golden() (*Vectors, error) {
+	return Parse(golden)
+}
+
+// Load reads a vector file
+func Load(path string) (*Vectors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a vector file
+func Parse(data []byte) (*Vectors, error) {
+	var v Vectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse vectors: %w", err)
+	}
+	if v.Version != Version {
+		return nil, fmt.Errorf("unsupported vector file version %d", v.Version)
+	}
+	return &v, nil
+}
+
+// Check runs every vector against the Go implementation
+func Check(v *Vectors) *Report {
+	r := &Report{}
+	for i, pv := range v.Poseidon {
+		r.add("poseidon", fmt.Sprintf("#%d %v", i, pv.Inputs), pv.Source, checkPoseidon(pv))
+	}
+	for i, fv := range v.FieldStrings {
+		r.add("fieldString", fmt.Sprintf("#%d %q", i, fv.Input), fv.Source, checkFieldString(fv))
+	}
+	for i, dv := range v.Domains {
+		r.add("domain", fmt.Sprintf("#%d %q", i, dv.Input), dv.Source, checkDomain(dv))
+	}
+	for i, cv := range v.CanonicalJSON {
+		r.add("canonicalJson", fmt.Sprintf("#%d", i), cv.Source, checkCanonicalJSON(cv))
+	}
+	for i, mv := range v.MetadataHashes {
+		r.add("metadataHash", fmt.Sprintf("#%d %q", i, mv.Metadata), mv.Source, checkMetadataHash(mv))
+	}
+	for i, cv := range v.Commitments {
+		r.add("commitment", fmt.Sprintf("#%d %s", i, cv.Domain), cv.Source, checkCommitment(cv))
+	}
+	for i, hv := range v.Hostnames {
+		r.add("hostname", fmt.Sprintf("#%d %s", i, hv.Domain), hv.Source, checkHostname(hv))
+	}
+	for _, pv := range v.PTX {
+		r.add("ptx", pv.Name, pv.Source, checkPTX(pv))
+	}
+	return r
+}
+
+func checkPoseidon(v PoseidonVector) error {
+	inputs := make([]*fr.Element, len(v.Inputs))
+	for i, in := range v.Inputs {
+		e, err := parseElement(in)
+		if err != nil {
+			return err
+		}
+		inputs[i] = e
+	}
+	got, err := crypto.PoseidonHash(inputs)
+	if err != nil {
+		return err
+	}
+	return expect("output", got.String(), v.Output)
+}
+
+func checkFieldString(v FieldStringVector) error {
+	got, err := crypto.PoseidonHashString(v.Input)
+	if err != nil {
+		return err
+	}
+	return expect("output", got.String(), v.Output)
+}
+
+func checkDomain(v DomainVector) error {
+	got, err := crypto.NormalizeDomain(v.Input)
+	if v.Error {
+		if err == nil {
+			return fmt.Errorf("normalized to %q, want an error", got)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return expect("normalized", got, v.Normalized)
+}
+
+func checkCanonicalJSON(v CanonicalJSONVector) error {
+	got, err := crypto.CanonicalJSON([]byte(v.Input))
+	if v.Error {
+		if err == nil {
+			return fmt.Errorf("canonicalized to %s, want an error", got)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return expect("output", string(got), v.Output)
+}
+
+func checkMetadataHash(v MetadataHashVector) error {
+	if err := expect("sha256", crypto.Sha256Hex([]byte(v.Metadata)), v.SHA256); err != nil {
+		return err
+	}
+	enc, err := crypto.ParseLimbEncoding(v.Limbs)
+	if err != nil {
+		return err
+	}
+	p1, p2, err := crypto.SplitMetadataHashWith(v.Metadata, enc)
+	if err != nil {
+		return err
+	}
+	if err := expect("p1", p1.String(), v.P1); err != nil {
+		return err
+	}
+	return expect("p2", p2.String(), v.P2)
+}
+
+func checkCommitment(v CommitmentVector) error {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(v.Metadata), &metadata); err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+	// The prover signs the canonical JSON of the metadata; a vector in any
+	// other form would test a different input than the JS side hashed
+	canonical, _ := crypto.MarshalCanonical(metadata)
+	if string(canonical) != v.Metadata {
+		return fmt.Errorf("metadata is not canonical JSON (want %s)", canonical)
+	}
+
+	p := prover.NewProver()
+	p.KeyID = v.KeyID
+	inputs, err := p.GenerateCircuitInputs(v.Domain, metadata, v.Nullifier, v.Secret, v.TrustMethod)
+	if err != nil {
+		return err
+	}
+	if err := expect("nullifierHash", inputs.NullifierHash, v.NullifierHash); err != nil {
+		return err
+	}
+	if err := expect("audienceHash", inputs.AudienceHash, v.AudienceHash); err != nil {
+		return err
+	}
+	if err := expect("scopeHash", inputs.ScopeHash, v.ScopeHash); err != nil {
+		return err
+	}
+	return expect("commitment", inputs.Commitment, v.Commitment)
+}
+
+func checkHostname(v HostnameVector) error {
+	version := utils.HostnameV1
+	if v.Version != 0 {
+		version = utils.HostnameVersion(v.Version)
+	}
+	got, err := utils.DeriveHostnameFromCommitment(v.Commitment, v.Domain, version)
+	if err != nil {
+		return err
+	}
+	return expect("hostname", got, v.Hostname)
+}
+
+func checkPTX(v PTXVector) error {
+	data, err := hex.DecodeString(v.Hex)
+	if err != nil {
+		return fmt.Errorf("invalid hex: %w", err)
+	}
+	ptxFile, err := ptxloader.ParsePTX(data, ptxloader.LoadOptions{RejectUnknownFields: true})
+	if err != nil {
+		return err
+	}
+
+	if err := expect("domain", ptxFile.GetDohDetails().GetDomainName(), v.Domain); err != nil {
+		return err
+	}
+	if err := expect("metadata", ptxFile.GetSignedMetadata(), v.Metadata); err != nil {
+		return err
+	}
+	if int(ptxFile.GetTrustMethod()) != v.TrustMethod {
+		return fmt.Errorf("trustMethod: got %d, want %d", ptxFile.GetTrustMethod(), v.TrustMethod)
+	}
+	if err := expect("verificationKeyId", ptxFile.GetProof().GetVerificationKeyId(), v.VerificationKeyID); err != nil {
+		return err
+	}
+
+	var pd struct {
+		PublicSignals []string `json:"publicSignals"`
+	}
+	if err := json.Unmarshal(ptxFile.GetProof().GetProofData(), &pd); err != nil {
+		return fmt.Errorf("invalid proof data: %w", err)
+	}
+	if !slices.Equal(pd.PublicSignals, v.PublicSignals) {
+		return fmt.Errorf("publicSignals: got %v, want %v", pd.PublicSignals, v.PublicSignals)
+	}
+
+	if len(pd.PublicSignals) >= 2 {
+		anchor, err := utils.DeriveAnchorRecord(pd.PublicSignals[1], v.Domain, v.Metadata, utils.FormatHostnameVersion(ptxFile.Version()))
+		if err != nil {
+			return err
+		}
+		if err := expect("anchorHostname", anchor.Hostname, v.AnchorHostname); err != nil {
+			return err
+		}
+		if err := expect("anchorValue", anchor.Value, v.AnchorValue); err != nil {
+			return err
+		}
+	}
+
+	// Re-encoding must reproduce the input exactly, header byte included
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(ptxFile)
+	if err != nil {
+		return err
+	}
+	if reencoded := append(append([]byte{}, data[:5]...), payload...); !bytes.Equal(reencoded, data) {
+		return fmt.Errorf("re-encoding differs: got %x", reencoded)
+	}
+	return nil
+}
+
+func parseElement(s string) (*fr.Element, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid field element %q", s)
+	}
+	var e fr.Element
+	e.SetBigInt(n)
+	return &e, nil
+}
+
+func expect(field, got, want string) error {
+	if got != want {
+		return fmt.Errorf("%s: got %s, want %s", field, got, want)
+	}
+	return nil
+}
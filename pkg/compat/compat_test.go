@@ -0,0 +1,35 @@
+package compat
+
+import (
+	"testing"
+)
+
+func TestGoldenVectors(t *testing.T) {
+	v, err := Golden()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Check(v)
+	if len(r.Results) == 0 {
+		t.Fatal("no vectors checked")
+	}
+	for _, res := range r.Failed() {
+		t.Errorf("%s %s (source %s): %s", res.Kind, res.Name, res.Source, res.Detail)
+	}
+}
+
+func TestCheckDetectsDivergence(t *testing.T) {
+	v, err := Golden()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v.Hostnames[0].Hostname = "x-tampered." + v.Hostnames[0].Domain
+	v.MetadataHashes[0].P1, v.MetadataHashes[0].P2 = v.MetadataHashes[0].P2, v.MetadataHashes[0].P1
+
+	failed := Check(v).Failed()
+	if len(failed) != 2 {
+		t.Fatalf("expected 2 failures, got %d: %+v", len(failed), failed)
+	}
+}
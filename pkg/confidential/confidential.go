@@ -0,0 +1,306 @@
+// Package confidential seals selected metadata claims of a PTX so they are
+// not world-readable. The claims are encrypted under a random data key, which
+// is wrapped for each verifier allowed to read them, and replaced in the
+// metadata by a single envelope claim:
+//
+//	"encrypted_claims": {
+//	  "v": 1,
+//	  "claims": ["salary", "ssn"],
+//	  "recipients": [{"kid": "...", "alg": "X25519-HKDF-SHA256-A256GCM", "epk": "...", "key": "..."}],
+//	  "nonce": "...",
+//	  "ciphertext": "..."
+//	}
+//
+// The envelope is part of the metadata the proof and anchor bind, so the
+// ciphertext cannot be swapped. A verifier holding one of the recipient keys
+// opens the envelope and checks the claims as if they were in the clear.
+//
+// Recipient and Key are the extension points: X25519 keys are built in, a
+// KMS or HSM can wrap data keys behind the same interfaces.
+package confidential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+)
+
+// ClaimName is the metadata claim holding the envelope
+const ClaimName = "encrypted_claims"
+
+// Version is the envelope format produced by Seal
+const Version = 1
+
+// dataKeySize is the size of the AES-256 data key
+const dataKeySize = 32
+
+// Reserved lists the claims every verifier checks, with or without a key,
+// which therefore cannot be sealed
+var Reserved = []string{"expiration_timestamp", "additional_domains", "nonce", "audience", "scopes", metaschema.Claim, ClaimName}
+
+var (
+	// ErrNoKey means none of the keys is a recipient of the envelope
+	ErrNoKey = errors.New("no key for the encrypted claims")
+	// ErrDecrypt means the envelope did not decrypt with the matching key
+	ErrDecrypt = errors.New("failed to decrypt the encrypted claims")
+)
+
+// WrappedKey is the data key of an envelope wrapped for one recipient
+type WrappedKey struct {
+	// KeyID identifies the recipient's key
+	KeyID string `json:"kid"`
+	// Alg names the wrapping scheme
+	Alg string `json:"alg"`
+	// EphemeralKey is the sender's ephemeral public key, for schemes that
+	// use one
+	EphemeralKey string `json:"epk,omitempty"`
+	// Key is the wrapped data key
+	Key string `json:"key"`
+}
+
+// Recipient wraps data keys for a verifier
+type Recipient interface {
+	KeyID() string
+	Wrap(dataKey []byte) (*WrappedKey, error)
+}
+
+// Key unwraps the data keys wrapped for it
+type Key interface {
+	KeyID() string
+	Unwrap(wk *WrappedKey) ([]byte, error)
+}
+
+// Envelope is the value of the ClaimName claim
+type Envelope struct {
+	Version    int          `json:"v"`
+	Claims     []string     `json:"claims"`
+	Recipients []WrappedKey `json:"recipients"`
+	Nonce      string       `json:"nonce"`
+	Ciphertext string       `json:"ciphertext"`
+}
+
+// Seal moves the named claims of metadata into an envelope readable by the
+// recipients. It must run before the circuit inputs are generated, so the
+// proof binds the envelope.
+func Seal(metadata map[string]interface{}, claims []string, recipients ...Recipient) error {
+	if len(claims) == 0 {
+		return nil
+	}
+	if len(recipients) == 0 {
+		return errors.New("encrypting claims requires at least one recipient key")
+	}
+	if _, ok := metadata[ClaimName]; ok {
+		return fmt.Errorf("metadata already has %q", ClaimName)
+	}
+	names := slices.Clone(claims)
+	slices.Sort(names)
+	names = slices.Compact(names)
+	sealed := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		if slices.Contains(Reserved, name) {
+			return fmt.Errorf("claim %q cannot be encrypted, every verifier checks it", name)
+		}
+		value, ok := metadata[name]
+		if !ok {
+			return fmt.Errorf("claim %q to encrypt is not in the metadata", name)
+		}
+		sealed[name] = value
+	}
+	plaintext, err := crypto.MarshalCanonical(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal claims: %w", err)
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	env := Envelope{Version: Version, Claims: names}
+	for _, r := range recipients {
+		wk, err := r.Wrap(dataKey)
+		if err != nil {
+			return fmt.Errorf("failed to wrap the data key for %s: %w", r.KeyID(), err)
+		}
+		env.Recipients = append(env.Recipients, *wk)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	env.Nonce = encode(nonce)
+	env.Ciphertext = encode(aead.Seal(nil, nonce, plaintext, env.additionalData()))
+
+	// The envelope is stored as plain JSON values, like the rest of the
+	// metadata
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	for _, name := range names {
+		delete(metadata, name)
+	}
+	metadata[ClaimName] = value
+	return nil
+}
+
+// Open replaces the envelope of metadata by the claims it seals, decrypted
+// with the first key that is a recipient. It returns the names of the
+// claims. Metadata without an envelope is left as is.
+func Open(metadata map[string]interface{}, keys ...Key) ([]string, error) {
+	raw, ok := metadata[ClaimName]
+	if !ok {
+		return nil, nil
+	}
+	env, err := parseEnvelope(raw)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := env.dataKey(keys)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := decode(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope nonce: %w", err)
+	}
+	ciphertext, err := decode(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope ciphertext: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid envelope nonce size")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, env.additionalData())
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	// The claims are sealed in canonical form, which leaves the sender no
+	// other encoding to choose from
+	canonical, err := crypto.CanonicalJSON(plaintext)
+	if err != nil || string(canonical) != string(plaintext) {
+		return nil, errors.New("encrypted claims are not canonical JSON")
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(plaintext, &claims); err != nil {
+		return nil, fmt.Errorf("encrypted claims must be a JSON object: %w", err)
+	}
+	if len(claims) != len(env.Claims) {
+		return nil, errors.New("encrypted claims differ from the envelope's claim list")
+	}
+	for _, name := range env.Claims {
+		if _, ok := claims[name]; !ok {
+			return nil, fmt.Errorf("encrypted claims lack %q", name)
+		}
+		if slices.Contains(Reserved, name) {
+			return nil, fmt.Errorf("claim %q cannot be encrypted", name)
+		}
+		if _, clash := metadata[name]; clash {
+			return nil, fmt.Errorf("claim %q is both encrypted and in the clear", name)
+		}
+	}
+
+	delete(metadata, ClaimName)
+	for name, value := range claims {
+		metadata[name] = value
+	}
+	return env.Claims, nil
+}
+
+// Recipients returns the key IDs an envelope claim is wrapped for
+func Recipients(metadata map[string]interface{}) ([]string, error) {
+	raw, ok := metadata[ClaimName]
+	if !ok {
+		return nil, nil
+	}
+	env, err := parseEnvelope(raw)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(env.Recipients))
+	for i, wk := range env.Recipients {
+		ids[i] = wk.KeyID
+	}
+	return ids, nil
+}
+
+func parseEnvelope(raw interface{}) (*Envelope, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ClaimName, err)
+	}
+	if env.Version != Version {
+		return nil, fmt.Errorf("unsupported %s version %d", ClaimName, env.Version)
+	}
+	if len(env.Claims) == 0 || len(env.Recipients) == 0 {
+		return nil, fmt.Errorf("invalid %s: no claims or recipients", ClaimName)
+	}
+	return &env, nil
+}
+
+// dataKey unwraps the data key with the first key among the recipients
+func (env *Envelope) dataKey(keys []Key) ([]byte, error) {
+	for _, k := range keys {
+		for i := range env.Recipients {
+			wk := &env.Recipients[i]
+			if wk.KeyID != k.KeyID() {
+				continue
+			}
+			dataKey, err := k.Unwrap(wk)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+			}
+			if len(dataKey) != dataKeySize {
+				return nil, ErrDecrypt
+			}
+			return dataKey, nil
+		}
+	}
+	return nil, ErrNoKey
+}
+
+// additionalData binds the format and claim list to the ciphertext
+func (env *Envelope) additionalData() []byte {
+	return fmt.Appendf(nil, "ptx-encrypted-claims-v%d %s", env.Version, strings.Join(env.Claims, ","))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
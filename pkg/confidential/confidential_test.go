@@ -0,0 +1,89 @@
+package confidential_test
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/confidential"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func newKey(t *testing.T) *confidential.X25519Key {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &confidential.X25519Key{Private: priv}
+}
+
+func recipient(k *confidential.X25519Key) *confidential.X25519Recipient {
+	return &confidential.X25519Recipient{Public: k.Private.PublicKey()}
+}
+
+func TestSealOpen(t *testing.T) {
+	key, other := newKey(t), newKey(t)
+	metadata := map[string]interface{}{"role": "validator", "salary": 100.0, "ssn": "123"}
+	if err := confidential.Seal(metadata, []string{"ssn", "salary"}, recipient(other), recipient(key)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := metadata["ssn"]; ok {
+		t.Fatal("sealed claim left in the clear")
+	}
+
+	if _, err := confidential.Open(maps.Clone(metadata), newKey(t)); !errors.Is(err, confidential.ErrNoKey) {
+		t.Fatalf("opened with a foreign key: %v", err)
+	}
+	names, err := confidential.Open(metadata, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || metadata["ssn"] != "123" || metadata["salary"] != 100.0 || metadata["role"] != "validator" {
+		t.Fatalf("opened %v to %v", names, metadata)
+	}
+	if _, ok := metadata[confidential.ClaimName]; ok {
+		t.Fatal("envelope left after opening")
+	}
+
+	if err := confidential.Seal(map[string]interface{}{"nonce": "n"}, []string{"nonce"}, recipient(key)); err == nil {
+		t.Fatal("sealed a reserved claim")
+	}
+}
+
+func TestVerifyEncryptedClaims(t *testing.T) {
+	key := newKey(t)
+	metadata := map[string]interface{}{"role": "validator", "ssn": "123"}
+	if err := confidential.Seal(metadata, []string{"ssn"}, recipient(key)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fixture.Generate(fixture.Options{Seed: 1, Metadata: metadata})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen map[string]interface{}
+	opts := verifier.VerificationOptions{
+		PTXData:      f.PTX,
+		VKData:       f.VK,
+		DNSResolver:  f.Resolver(),
+		MetadataKeys: []confidential.Key{key},
+		PolicyFunc: func(claims map[string]interface{}, _ verifier.VerificationDetails) error {
+			seen = claims
+			return nil
+		},
+	}
+	res, err := verifier.NewPTXVerifier(opts).Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || seen["ssn"] != "123" || len(res.Details.DecryptedClaims) != 1 {
+		t.Fatalf("success %v, errors %v, claims %v", res.Success, res.Errors, seen)
+	}
+
+	opts.MetadataKeys = []confidential.Key{newKey(t)}
+	if res, err = verifier.NewPTXVerifier(opts).Verify(); err != nil || res.Success {
+		t.Fatalf("accepted without the recipient key: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package confidential
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// AlgX25519 wraps data keys with AES-256-GCM under a key derived by HKDF
+// from an ephemeral X25519 exchange with the recipient
+const AlgX25519 = "X25519-HKDF-SHA256-A256GCM"
+
+const wrapInfo = "ptx-encrypted-claims-key"
+
+// X25519Recipient wraps data keys for an X25519 public key
+type X25519Recipient struct {
+	Public *ecdh.PublicKey
+}
+
+// KeyID is the hex encoded first 8 bytes of the SHA-256 of the public key
+func (r *X25519Recipient) KeyID() string {
+	return x25519KeyID(r.Public)
+}
+
+func (r *X25519Recipient) Wrap(dataKey []byte) (*WrappedKey, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := x25519KEK(eph, r.Public, eph.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	// Every wrapping key is used once, so the nonce can be fixed
+	nonce := make([]byte, aead.NonceSize())
+	return &WrappedKey{
+		KeyID:        r.KeyID(),
+		Alg:          AlgX25519,
+		EphemeralKey: encode(eph.PublicKey().Bytes()),
+		Key:          encode(aead.Seal(nil, nonce, dataKey, nil)),
+	}, nil
+}
+
+// X25519Key unwraps data keys wrapped for its public key
+type X25519Key struct {
+	Private *ecdh.PrivateKey
+}
+
+func (k *X25519Key) KeyID() string {
+	return x25519KeyID(k.Private.PublicKey())
+}
+
+func (k *X25519Key) Unwrap(wk *WrappedKey) ([]byte, error) {
+	if wk.Alg != AlgX25519 {
+		return nil, fmt.Errorf("unsupported key wrapping %q", wk.Alg)
+	}
+	epkBytes, err := decode(wk.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	epk, err := ecdh.X25519().NewPublicKey(epkBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	wrapped, err := decode(wk.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	kek, err := x25519KEK(k.Private, epk, epk)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, make([]byte, aead.NonceSize()), wrapped, nil)
+}
+
+// x25519KEK derives the wrapping key from the exchange of priv and peer,
+// salted with the ephemeral public key
+func x25519KEK(priv *ecdh.PrivateKey, peer, ephemeral *ecdh.PublicKey) ([]byte, error) {
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	return hkdf.Key(sha256.New, shared, ephemeral.Bytes(), wrapInfo, dataKeySize)
+}
+
+func x25519KeyID(pub *ecdh.PublicKey) string {
+	sum := sha256.Sum256(pub.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoadRecipient reads a PKIX PEM encoded X25519 public key, as produced by
+// `openssl pkey -pubout`
+func LoadRecipient(path string) (*X25519Recipient, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	pub, ok := key.(*ecdh.PublicKey)
+	if !ok || pub.Curve() != ecdh.X25519() {
+		return nil, errors.New("recipient key is not an X25519 public key")
+	}
+	return &X25519Recipient{Public: pub}, nil
+}
+
+// LoadKey reads a PKCS#8 PEM encoded X25519 private key, as produced by
+// `openssl genpkey -algorithm x25519`
+func LoadKey(path string) (*X25519Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	priv, ok := key.(*ecdh.PrivateKey)
+	if !ok || priv.Curve() != ecdh.X25519() {
+		return nil, errors.New("metadata key is not an X25519 private key")
+	}
+	return &X25519Key{Private: priv}, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	return block, nil
+}
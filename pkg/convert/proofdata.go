@@ -0,0 +1,190 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// Proof formats of a proof_data wrapper
+const (
+	FormatNative  = "native"
+	FormatSnarkJS = "snarkjs"
+)
+
+// ProofFromSnarkJS converts a snarkjs proof.json into a native bn254 Groth16
+// proof
+func ProofFromSnarkJS(proofJSON []byte) (groth16.Proof, error) {
+	cp, err := parser.UnmarshalCircomProofJSON(proofJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snarkjs proof: %w", err)
+	}
+	if cp.Protocol != "" && cp.Protocol != "groth16" {
+		return nil, fmt.Errorf("unsupported snarkjs protocol %q (need groth16)", cp.Protocol)
+	}
+	p, err := parser.ConvertProof(cp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snarkjs proof: %w", err)
+	}
+	return p, nil
+}
+
+// WithSnarkJSProof replaces the proof of a proof_data wrapper with a snarkjs
+// proof.json, keeping its metadata encoding. publicSignals replace the
+// wrapper's signals unless nil. data may be empty to build a new wrapper.
+func WithSnarkJSProof(data, proofJSON []byte, publicSignals []string) ([]byte, error) {
+	if _, err := ProofFromSnarkJS(proofJSON); err != nil {
+		return nil, err
+	}
+	var w proofdata.Wrapper
+	if len(data) > 0 {
+		old, err := proofdata.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof data: %w", err)
+		}
+		w.PublicSignals, w.MetadataEncoding = old.PublicSignals, old.MetadataEncoding
+	}
+	if publicSignals != nil {
+		w.PublicSignals = publicSignals
+	}
+	if len(w.PublicSignals) == 0 {
+		return nil, errors.New("snarkjs proof without public signals")
+	}
+	w.Proof = compactJSON(proofJSON)
+	return json.Marshal(w)
+}
+
+// ProofData rewrites a proof_data wrapper with its proof in the given format,
+// FormatNative or FormatSnarkJS. The public signals and metadata encoding are
+// kept, so the converted proof verifies under the same key in either format.
+// Native proofs are written uncompressed in hex, snarkjs proofs as plain JSON.
+func ProofData(data []byte, format string) ([]byte, error) {
+	w, err := proofdata.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	out := proofdata.Wrapper{PublicSignals: w.PublicSignals, MetadataEncoding: w.MetadataEncoding, Signals: w.Signals}
+
+	switch format {
+	case FormatNative:
+		var proof groth16.Proof
+		if w.IsNative() {
+			raw, err := w.AppendNativeProof(nil)
+			if err != nil {
+				return nil, err
+			}
+			proof = groth16.NewProof(ecc.BN254)
+			if _, err := proof.ReadFrom(bytes.NewReader(raw)); err != nil {
+				return nil, fmt.Errorf("failed to read proof: %w", err)
+			}
+		} else {
+			proofJSON, err := w.SnarkJSProof()
+			if err != nil {
+				return nil, err
+			}
+			if proof, err = ProofFromSnarkJS(proofJSON); err != nil {
+				return nil, err
+			}
+		}
+		var buf bytes.Buffer
+		if _, err := proof.WriteRawTo(&buf); err != nil {
+			return nil, fmt.Errorf("failed to serialize proof: %w", err)
+		}
+		out.Source = proofdata.SourceNative
+		out.ProofHex = hex.EncodeToString(buf.Bytes())
+
+	case FormatSnarkJS:
+		if !w.IsNative() {
+			proofJSON, err := w.SnarkJSProof()
+			if err != nil {
+				return nil, err
+			}
+			out.Proof = compactJSON(proofJSON)
+			break
+		}
+		raw, err := w.AppendNativeProof(nil)
+		if err != nil {
+			return nil, err
+		}
+		proof := groth16.NewProof(ecc.BN254)
+		if _, err := proof.ReadFrom(bytes.NewReader(raw)); err != nil {
+			return nil, fmt.Errorf("failed to read proof: %w", err)
+		}
+		cp, err := ProofToSnarkJS(proof)
+		if err != nil {
+			return nil, err
+		}
+		if out.Proof, err = json.Marshal(cp); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown proof format %q (expected %s or %s)", format, FormatNative, FormatSnarkJS)
+	}
+	return json.Marshal(out)
+}
+
+// snarkJSProofFile is the proof.json written by snarkjs
+type snarkJSProofFile struct {
+	*parser.CircomProof
+	Curve string `json:"curve"`
+}
+
+// ExportSnarkJS converts the proof of a proof_data wrapper, native or
+// snarkjs, into the proof.json and public.json files of the snarkjs
+// pipeline, which "snarkjs groth16 verify" and the JS verifier check against
+// the key converted by VerifyingKeyToSnarkJS. It fails for wrappers whose
+// public signals are external.
+func ExportSnarkJS(data []byte) (proofJSON, publicJSON []byte, err error) {
+	converted, err := ProofData(data, FormatSnarkJS)
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := proofdata.Parse(converted)
+	if err != nil {
+		return nil, nil, err
+	}
+	if w.HasExternalSignals() {
+		return nil, nil, errors.New("public signals are external; restore them before exporting")
+	}
+	cp, err := parser.UnmarshalCircomProofJSON(w.Proof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid snarkjs proof: %w", err)
+	}
+	if proofJSON, err = json.MarshalIndent(snarkJSProofFile{cp, SnarkJSCurve}, "", "  "); err != nil {
+		return nil, nil, err
+	}
+	if publicJSON, err = json.MarshalIndent(w.PublicSignals, "", "  "); err != nil {
+		return nil, nil, err
+	}
+	return proofJSON, publicJSON, nil
+}
+
+// ParsePublicSignals reads a snarkjs public.json
+func ParsePublicSignals(data []byte) ([]string, error) {
+	signals, err := parser.UnmarshalCircomPublicSignalsJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public signals: %w", err)
+	}
+	if len(signals) == 0 {
+		return nil, errors.New("invalid public signals: empty array")
+	}
+	return signals, nil
+}
+
+// compactJSON strips the whitespace of a JSON document, which snarkjs
+// pretty-prints
+func compactJSON(data []byte) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// SnarkJSCurve is the name snarkjs gives the bn254 curve
+const SnarkJSCurve = "bn128"
+
+// ErrCommitments is returned for gnark keys or proofs using Pedersen
+// commitments, which snarkjs cannot express
+var ErrCommitments = errors.New("gnark commitments are not supported by snarkjs")
+
+// ProofToSnarkJS converts a native bn254 Groth16 proof into the snarkjs
+// proof.json layout
+func ProofToSnarkJS(proof groth16.Proof) (*parser.CircomProof, error) {
+	p, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return nil, fmt.Errorf("unsupported proof type %T (need bn254)", proof)
+	}
+	if len(p.Commitments) > 0 {
+		return nil, ErrCommitments
+	}
+
+	return &parser.CircomProof{
+		PiA:      g1(&p.Ar),
+		PiB:      g2(&p.Bs),
+		PiC:      g1(&p.Krs),
+		Protocol: "groth16",
+	}, nil
+}
+
+// VerifyingKeyToSnarkJS converts a native bn254 verifying key into the
+// snarkjs verification_key.json layout
+func VerifyingKeyToSnarkJS(vk groth16.VerifyingKey) (*parser.CircomVerificationKey, error) {
+	k, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported verifying key type %T (need bn254)", vk)
+	}
+	if len(k.PublicAndCommitmentCommitted) > 0 {
+		return nil, ErrCommitments
+	}
+
+	ic := make([][]string, len(k.G1.K))
+	for i := range k.G1.K {
+		ic[i] = g1(&k.G1.K[i])
+	}
+
+	// snarkjs ships e(alpha, beta) precomputed; older verifiers rely on it
+	ab, err := bn254.Pair([]bn254.G1Affine{k.G1.Alpha}, []bn254.G2Affine{k.G2.Beta})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute vk_alphabeta_12: %w", err)
+	}
+
+	return &parser.CircomVerificationKey{
+		Protocol:      "groth16",
+		Curve:         SnarkJSCurve,
+		NPublic:       len(k.G1.K) - 1,
+		VkAlpha1:      g1(&k.G1.Alpha),
+		VkBeta2:       g2(&k.G2.Beta),
+		VkGamma2:      g2(&k.G2.Gamma),
+		VkDelta2:      g2(&k.G2.Delta),
+		IC:            ic,
+		VkAlphabeta12: gt(&ab),
+	}, nil
+}
+
+// ExportSnarkJSKey converts a serialized native bn254 verifying key, as
+// written to native.vk, into the snarkjs verification_key.json file
+func ExportSnarkJSKey(vkData []byte) ([]byte, error) {
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(bytes.NewReader(vkData)); err != nil {
+		return nil, fmt.Errorf("not a native BN254 verification key: %w", err)
+	}
+	cvk, err := VerifyingKeyToSnarkJS(vk)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(cvk, "", "  ")
+}
+
+// g1 encodes an affine point in snarkjs' projective decimal form
+func g1(p *bn254.G1Affine) []string {
+	return []string{dec(p.X.BigInt(new(big.Int))), dec(p.Y.BigInt(new(big.Int))), "1"}
+}
+
+// g2 encodes an affine point as [[x.c0, x.c1], [y.c0, y.c1], [1, 0]]
+func g2(p *bn254.G2Affine) [][]string {
+	return [][]string{
+		{dec(p.X.A0.BigInt(new(big.Int))), dec(p.X.A1.BigInt(new(big.Int)))},
+		{dec(p.Y.A0.BigInt(new(big.Int))), dec(p.Y.A1.BigInt(new(big.Int)))},
+		{"1", "0"},
+	}
+}
+
+// gt encodes a pairing result as [c0, c1], each [b0, b1, b2] of [a0, a1]
+func gt(e *bn254.GT) [][][]string {
+	out := make([][][]string, 2)
+	for i, c := range []*bn254.E6{&e.C0, &e.C1} {
+		out[i] = [][]string{
+			{dec(c.B0.A0.BigInt(new(big.Int))), dec(c.B0.A1.BigInt(new(big.Int)))},
+			{dec(c.B1.A0.BigInt(new(big.Int))), dec(c.B1.A1.BigInt(new(big.Int)))},
+			{dec(c.B2.A0.BigInt(new(big.Int))), dec(c.B2.A1.BigInt(new(big.Int)))},
+		}
+	}
+	return out
+}
+
+func dec(i *big.Int) string {
+	return i.String()
+}
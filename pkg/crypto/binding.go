@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"golang.org/x/text/unicode/norm"
+)
+
+// AudienceHash maps the audience of a token to the audienceHash signal of
+// circuits binding it: SHA256 of the NFC form of the audience, reduced modulo
+// the field. A token without an audience binds zero.
+func AudienceHash(audience string) *fr.Element {
+	if audience == "" {
+		return new(fr.Element)
+	}
+	h, _ := PoseidonHashString(norm.NFC.String(audience))
+	return h
+}
+
+// ScopeHash maps the scopes of a token to the scopeHash signal of circuits
+// binding them: SHA256 of the canonical JSON array of the scopes, in NFC,
+// sorted and without duplicates, reduced modulo the field. A token without
+// scopes binds zero.
+func ScopeHash(scopes []string) *fr.Element {
+	set := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		set = append(set, norm.NFC.String(s))
+	}
+	slices.Sort(set)
+	set = slices.Compact(set)
+	if len(set) == 0 {
+		return new(fr.Element)
+	}
+	data, _ := MarshalCanonical(set)
+	h, _ := PoseidonHashString(string(data))
+	return h
+}
+
+// MetadataBinding returns the audience and scope hashes of signed metadata,
+// from its "audience" string and "scopes" array of strings. Either may be
+// absent, but not of another type.
+func MetadataBinding(metaRaw string) (audience, scope *fr.Element, err error) {
+	var meta struct {
+		Audience *string   `json:"audience"`
+		Scopes   *[]string `json:"scopes"`
+	}
+	if err := json.Unmarshal([]byte(metaRaw), &meta); err != nil {
+		return nil, nil, fmt.Errorf("metadata audience and scopes: %w", err)
+	}
+	audience, scope = AudienceHash(""), ScopeHash(nil)
+	if meta.Audience != nil {
+		audience = AudienceHash(*meta.Audience)
+	}
+	if meta.Scopes != nil {
+		scope = ScopeHash(*meta.Scopes)
+	}
+	return audience, scope, nil
+}
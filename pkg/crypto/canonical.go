@@ -0,0 +1,195 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CanonicalJSON re-serializes a JSON document in canonical form, so that
+// producers serializing the same metadata differently (key order, whitespace,
+// escapes, number formatting) hash the same bytes. The form is the JSON
+// Canonicalization Scheme of RFC 8785, with one addition: strings and object
+// keys are first put in Unicode Normalization Form C, so that "é" as one code
+// point and as "e" plus a combining accent are the same value.
+//
+// In JCS, objects have their members sorted by the UTF-16 code units of the
+// keys and there is no insignificant whitespace. Strings escape only '"',
+// '\' and control characters, and numbers are written as ECMAScript would
+// write the IEEE 754 double they parse to. Documents with duplicate object
+// keys, invalid UTF-8, or numbers that are not finite doubles are rejected.
+func CanonicalJSON(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("canonical JSON: invalid UTF-8")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	if err := canonicalValue(&buf, dec); err != nil {
+		return nil, fmt.Errorf("canonical JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("canonical JSON: trailing data after document")
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalCanonical serializes v with encoding/json and canonicalizes the
+// result
+func MarshalCanonical(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalJSON(data)
+}
+
+func canonicalValue(buf *bytes.Buffer, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			return canonicalArray(buf, dec)
+		}
+		return canonicalObject(buf, dec)
+	case string:
+		writeCanonicalString(buf, norm.NFC.String(t))
+	case json.Number:
+		s, err := canonicalNumber(t)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case nil:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+func canonicalArray(buf *bytes.Buffer, dec *json.Decoder) error {
+	buf.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := canonicalValue(buf, dec); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	_, err := dec.Token()
+	return err
+}
+
+func canonicalObject(buf *bytes.Buffer, dec *json.Decoder) error {
+	type member struct {
+		key   string
+		utf16 []uint16
+		value []byte
+	}
+	var members []member
+	seen := map[string]bool{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := norm.NFC.String(tok.(string))
+		if seen[key] {
+			return fmt.Errorf("duplicate key %q", key)
+		}
+		seen[key] = true
+
+		var value bytes.Buffer
+		if err := canonicalValue(&value, dec); err != nil {
+			return err
+		}
+		members = append(members, member{key, utf16.Encode([]rune(key)), value.Bytes()})
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	slices.SortFunc(members, func(a, b member) int {
+		return slices.Compare(a.utf16, b.utf16)
+	})
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeCanonicalString(buf, m.key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeCanonicalString writes s as JSON.stringify does
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\b':
+			buf.WriteString(`\b`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\f':
+			buf.WriteString(`\f`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalNumber formats a number as ECMAScript's Number.prototype.toString
+// formats the double it parses to
+func canonicalNumber(n json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("number %s is not a finite double", n)
+	}
+	if f == 0 {
+		// Includes -0
+		return "0", nil
+	}
+	if abs := math.Abs(f); abs >= 1e21 || abs < 1e-6 {
+		// Shortest digits in exponential form, with the exponent written
+		// without leading zeros ("1e-7", "1.5e+21")
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		mantissa, exp, _ := strings.Cut(s, "e")
+		return mantissa + "e" + exp[:1] + strings.TrimLeft(exp[1:], "0"), nil
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
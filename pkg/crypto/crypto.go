@@ -37,23 +37,21 @@ func Sha256Hex(data []byte) string {
 	return hex.EncodeToString(Sha256(data))
 }
 
-// SplitHashToFieldElements splits a 256-bit hash (hex string) into two 128-bit chunks
+// SplitHashToFieldElements splits a 256-bit hash (hex string) into two 128-bit
+// chunks using CanonicalLimbEncoding
 func SplitHashToFieldElements(hexString string) (*fr.Element, *fr.Element) {
-	fullValue := new(big.Int)
-	fullValue.SetString(hexString, 16)
-
-	mask128 := new(big.Int).Lsh(big.NewInt(1), 128)
-	mask128.Sub(mask128, big.NewInt(1))
-
-	p1Int := new(big.Int).And(fullValue, mask128)
-	p2Int := new(big.Int).Rsh(fullValue, 128)
-	p2Int.And(p2Int, mask128)
-
-	var p1, p2 fr.Element
-	p1.SetBigInt(p1Int)
-	p2.SetBigInt(p2Int)
-
-	return &p1, &p2
+	digest, err := hex.DecodeString(hexString)
+	if err != nil || len(digest) != 32 {
+		// Keep accepting other lengths as an integer truncated to 256 bits
+		n, _ := new(big.Int).SetString(hexString, 16)
+		if n == nil {
+			n = new(big.Int)
+		}
+		n.Mod(n, new(big.Int).Lsh(big.NewInt(1), 256))
+		digest = n.FillBytes(make([]byte, 32))
+	}
+	p1, p2, _ := SplitDigest(digest, CanonicalLimbEncoding)
+	return p1, p2
 }
 
 // Base27 encodes a big integer into a base27 string using the alphabet "abcdefghijklmnopqrstuvwxyz-"
@@ -110,18 +108,17 @@ func DeriveHostnameFromCommitment(commitment *fr.Element, domain string) (string
 func PoseidonHashString(s string) (*fr.Element, error) {
 	// Convert string to field element via SHA256 -> mod SNARK_FIELD
 	hashBytes := sha256.Sum256([]byte(s))
-	hashInt := new(big.Int).SetBytes(hashBytes[:])
-	// Note: SetBigInt automatically reduces mod field size
 
+	// Note: SetBytes reduces mod field size
 	var result fr.Element
-	result.SetBigInt(hashInt)
+	result.SetBytes(hashBytes[:])
 
 	return &result, nil
 }
 
-// SplitMetadataHash computes SHA256 of metadata and splits into two 128-bit parts
+// SplitMetadataHash computes SHA256 of metadata and splits into two 128-bit
+// parts using CanonicalLimbEncoding
 func SplitMetadataHash(metaRaw string) (*fr.Element, *fr.Element) {
-	hashBytes := sha256.Sum256([]byte(metaRaw))
-	hashHex := hex.EncodeToString(hashBytes[:])
-	return SplitHashToFieldElements(hashHex)
+	p1, p2, _ := SplitMetadataHashWith(metaRaw, CanonicalLimbEncoding)
+	return p1, p2
 }
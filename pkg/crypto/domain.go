@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"golang.org/x/net/idna"
+)
+
+// domainProfile maps and validates domain names as resolvers look them up:
+// UTS #46 non-transitional processing (IDNA 2008, so "ß" is kept rather than
+// mapped to "ss"), case folding, the Bidi rule and DNS length limits.
+// The STD3 character rules are replaced by validASCII so that underscores,
+// as in _acme.example.com, stay allowed.
+var domainProfile = idna.New(
+	idna.MapForLookup(),
+	idna.Transitional(false),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+	idna.StrictDomainName(false),
+)
+
+// NormalizeDomain returns the canonical form of a domain name, which is what
+// gets hashed into the fqdn signal and used for the anchor hostname: lower
+// case, without the trailing dot, and with internationalized labels in their
+// punycode (xn--) form. "Example.COM." and "example.com" normalize to the
+// same name, and so do "Bücher.example" and "xn--bcher-kva.example".
+func NormalizeDomain(domain string) (string, error) {
+	name := strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if name == "" {
+		return "", fmt.Errorf("empty domain name")
+	}
+	ascii, err := domainProfile.ToASCII(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid domain name %q: %w", domain, err)
+	}
+	if !validASCII(ascii) {
+		return "", fmt.Errorf("invalid domain name %q: only letters, digits, '-' and '_' are allowed in labels", domain)
+	}
+	return ascii, nil
+}
+
+func validASCII(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// HashDomain normalizes a domain and maps it to the fqdn field element
+func HashDomain(domain string) (*fr.Element, error) {
+	name, err := NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	return PoseidonHashString(name)
+}
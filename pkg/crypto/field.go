@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// maxFieldDigits is the number of decimal digits of SNARK_FIELD_SIZE. Longer
+// integers are rejected before they are parsed.
+const maxFieldDigits = 77
+
+var (
+	// ErrNonCanonicalSignal is returned for public signals that are not a
+	// plain decimal integer: empty, signed, hex, with leading zeros or with
+	// any other non-digit character
+	ErrNonCanonicalSignal = errors.New("public signal is not a canonical decimal integer")
+	// ErrSignalOutOfField is returned for public signals not strictly less
+	// than the BN254 scalar field. Such values would be reduced modulo the
+	// field, letting several encodings stand for the same signal.
+	ErrSignalOutOfField = errors.New("public signal is not less than the BN254 scalar field")
+)
+
+// ParseFieldElement parses s as a canonical decimal field element: digits
+// only, no sign or leading zeros, and strictly less than SNARK_FIELD_SIZE
+func ParseFieldElement(s string) (*big.Int, error) {
+	e, err := ParseFr(s)
+	if err != nil {
+		return nil, err
+	}
+	return e.BigInt(new(big.Int)), nil
+}
+
+// ParseFr is ParseFieldElement returning an fr.Element. It accumulates the
+// digits in 64-bit limbs and never allocates, so it is the one to use on the
+// verification path.
+func ParseFr(s string) (fr.Element, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return fr.Element{}, ErrNonCanonicalSignal
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return fr.Element{}, ErrNonCanonicalSignal
+		}
+	}
+	// A canonical integer with more digits is larger than the field
+	if len(s) > maxFieldDigits {
+		return fr.Element{}, ErrSignalOutOfField
+	}
+
+	// n is a 256-bit integer, least significant limb first. 77 digits fit,
+	// as 10^77 < 2^256.
+	var n [4]uint64
+	for i := 0; i < len(s); i++ {
+		carry := uint64(s[i] - '0')
+		for j := range n {
+			hi, lo := bits.Mul64(n[j], 10)
+			var c uint64
+			n[j], c = bits.Add64(lo, carry, 0)
+			carry = hi + c
+		}
+	}
+
+	var be [fr.Bytes]byte
+	for j := range n {
+		binary.BigEndian.PutUint64(be[fr.Bytes-8*(j+1):], n[j])
+	}
+	// Element rejects values that are not reduced modulo the field
+	e, err := fr.BigEndian.Element(&be)
+	if err != nil {
+		return fr.Element{}, ErrSignalOutOfField
+	}
+	return e, nil
+}
+
+// ValidatePublicSignals checks that every signal is a canonical field element
+func ValidatePublicSignals(signals []string) error {
+	for i, s := range signals {
+		if _, err := ParseFr(s); err != nil {
+			return fmt.Errorf("signal %d: %w", i, err)
+		}
+	}
+	return nil
+}
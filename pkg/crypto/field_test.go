@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+func TestParseFieldElement(t *testing.T) {
+	r := fr.Modulus()
+	rMinus1 := new(big.Int).Sub(r, big.NewInt(1)).String()
+	rPlus1 := new(big.Int).Add(r, big.NewInt(1)).String()
+	pow256 := new(big.Int).Lsh(big.NewInt(1), 256)
+	pow256Minus1 := new(big.Int).Sub(pow256, big.NewInt(1)).String()
+
+	tests := []struct {
+		in  string
+		err error
+	}{
+		{in: "0"},
+		{in: "1"},
+		{in: "10"},
+		{in: "18446744073709551616"}, // 2^64, carries into the second limb
+		{in: rMinus1},
+		{in: "", err: ErrNonCanonicalSignal},
+		{in: "00", err: ErrNonCanonicalSignal},
+		{in: "01", err: ErrNonCanonicalSignal},
+		{in: "-1", err: ErrNonCanonicalSignal},
+		{in: "+1", err: ErrNonCanonicalSignal},
+		{in: "0x10", err: ErrNonCanonicalSignal},
+		{in: " 1", err: ErrNonCanonicalSignal},
+		{in: "1 ", err: ErrNonCanonicalSignal},
+		{in: "1e3", err: ErrNonCanonicalSignal},
+		{in: "1.0", err: ErrNonCanonicalSignal},
+		{in: "١", err: ErrNonCanonicalSignal},
+		{in: "0" + rMinus1, err: ErrNonCanonicalSignal},
+		{in: pow256.String() + "x", err: ErrNonCanonicalSignal},
+		{in: r.String(), err: ErrSignalOutOfField},
+		{in: rPlus1, err: ErrSignalOutOfField},
+		{in: strings.Repeat("9", maxFieldDigits), err: ErrSignalOutOfField},
+		{in: pow256Minus1, err: ErrSignalOutOfField},
+		{in: pow256.String(), err: ErrSignalOutOfField},
+		{in: strings.Repeat("9", 1000), err: ErrSignalOutOfField},
+	}
+	for _, tt := range tests {
+		got, err := ParseFieldElement(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseFieldElement(%q) error = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if tt.err == nil && got.String() != tt.in {
+			t.Errorf("ParseFieldElement(%q) = %s", tt.in, got)
+		}
+
+		e, err := ParseFr(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseFr(%q) error = %v, want %v", tt.in, err, tt.err)
+		} else if tt.err == nil && e.BigInt(new(big.Int)).String() != tt.in {
+			t.Errorf("ParseFr(%q) = %s", tt.in, e.BigInt(new(big.Int)))
+		}
+	}
+}
+
+func TestValidatePublicSignals(t *testing.T) {
+	if err := ValidatePublicSignals([]string{"1", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	err := ValidatePublicSignals([]string{"1", fr.Modulus().String()})
+	if !errors.Is(err, ErrSignalOutOfField) || !strings.HasPrefix(err.Error(), "signal 1:") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func BenchmarkParseFr(b *testing.B) {
+	s := new(big.Int).Sub(fr.Modulus(), big.NewInt(1)).String()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := ParseFr(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
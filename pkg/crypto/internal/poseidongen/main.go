@@ -0,0 +1,383 @@
+// Command poseidongen generates the Poseidon constant tables used by
+// pkg/crypto. It derives them the way the reference implementation and
+// circomlib do: round constants and a Cauchy MDS matrix are drawn from the
+// Grain LFSR seeded with the instance parameters, then transformed into the
+// optimized form (constants moved out of the partial rounds, sparse partial
+// round matrices) that PoseidonHash evaluates.
+//
+// Usage (see the go:generate directive in pkg/crypto/poseidon.go):
+//
+//	go run ./internal/poseidongen -o poseidon_constants.bin
+//
+// The output is a sequence of tables, one per state width t:
+//
+//	t uint8 | nRoundsF uint8 | nRoundsP uint16 | C | S | M | P
+//
+// where C has nRoundsF*t+nRoundsP elements, S (2t-1)*nRoundsP, and M and P t*t
+// each, row-major. Every element is 32 bytes big-endian and canonical.
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+const (
+	// fieldBits is the bit length of the BN254 scalar field
+	fieldBits = 254
+	// nRoundsF is the number of full rounds for every width
+	nRoundsF = 8
+)
+
+// nRoundsP is the number of partial rounds for t = 2..17, for 128-bit
+// security with the x^5 S-box, as used by circomlib
+var nRoundsP = []int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65, 70, 60, 64, 68}
+
+func main() {
+	out := flag.String("o", "poseidon_constants.bin", "output file")
+	minT := flag.Int("min-width", 2, "smallest state width")
+	maxT := flag.Int("max-width", 17, "largest state width")
+	flag.Parse()
+
+	if *minT < 2 || *maxT > len(nRoundsP)+1 || *minT > *maxT {
+		fmt.Fprintf(os.Stderr, "widths must be within 2..%d\n", len(nRoundsP)+1)
+		os.Exit(1)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	w := bufio.NewWriter(f)
+	for t := *minT; t <= *maxT; t++ {
+		writeTable(w, t, nRoundsP[t-2], optimize(t, nRoundsF, nRoundsP[t-2]))
+	}
+	if err := w.Flush(); err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// tables are the optimized constants of one width, in the layout
+// PoseidonHash reads them
+type tables struct {
+	c, s []fr.Element
+	m, p [][]fr.Element
+}
+
+func writeTable(w *bufio.Writer, t, rp int, tb tables) {
+	w.WriteByte(byte(t))
+	w.WriteByte(nRoundsF)
+	binary.Write(w, binary.BigEndian, uint16(rp))
+	put := func(v []fr.Element) {
+		for i := range v {
+			b := v[i].Bytes()
+			w.Write(b[:])
+		}
+	}
+	put(tb.c)
+	put(tb.s)
+	for _, row := range tb.m {
+		put(row)
+	}
+	for _, row := range tb.p {
+		put(row)
+	}
+}
+
+// grain is the Grain LFSR of the Poseidon reference implementation
+type grain struct {
+	state []byte
+}
+
+func newGrain(t, rf, rp int) *grain {
+	g := &grain{}
+	push := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			g.state = append(g.state, byte(v>>i)&1)
+		}
+	}
+	push(1, 2)          // prime field
+	push(0, 4)          // x^alpha S-box
+	push(fieldBits, 12) // field size
+	push(t, 12)
+	push(rf, 10)
+	push(rp, 10)
+	push(1<<30-1, 30)
+	for i := 0; i < 160; i++ {
+		g.step()
+	}
+	return g
+}
+
+func (g *grain) step() byte {
+	s := g.state
+	b := s[62] ^ s[51] ^ s[38] ^ s[23] ^ s[13] ^ s[0]
+	copy(s, s[1:])
+	s[len(s)-1] = b
+	return b
+}
+
+// bit returns the next output bit. Bits are produced in pairs and the second
+// one is kept only if the first is set.
+func (g *grain) bit() byte {
+	for g.step() == 0 {
+		g.step()
+	}
+	return g.step()
+}
+
+func (g *grain) bits(n int) *big.Int {
+	v := new(big.Int)
+	for i := 0; i < n; i++ {
+		v.Lsh(v, 1)
+		v.SetBit(v, 0, uint(g.bit()))
+	}
+	return v
+}
+
+// element draws a field element, rejecting values not below the modulus
+func (g *grain) element() fr.Element {
+	for {
+		v := g.bits(fieldBits)
+		if v.Cmp(fr.Modulus()) < 0 {
+			var e fr.Element
+			e.SetBigInt(v)
+			return e
+		}
+	}
+}
+
+// reduced draws a field element reduced modulo the field
+func (g *grain) reduced() fr.Element {
+	var e fr.Element
+	e.SetBigInt(g.bits(fieldBits))
+	return e
+}
+
+// raw returns the round constants, one vector per round, and the MDS matrix
+// A such that a round maps the state x to A·x
+func raw(t, rf, rp int) ([][]fr.Element, [][]fr.Element) {
+	g := newGrain(t, rf, rp)
+	c := make([][]fr.Element, rf+rp)
+	for r := range c {
+		c[r] = make([]fr.Element, t)
+		for i := range c[r] {
+			c[r][i] = g.element()
+		}
+	}
+
+	for {
+		xs := make([]fr.Element, 2*t)
+		for !distinct(xs) {
+			for i := range xs {
+				xs[i] = g.reduced()
+			}
+		}
+		a, ok := cauchy(xs[:t], xs[t:])
+		if ok {
+			return c, a
+		}
+	}
+}
+
+func distinct(xs []fr.Element) bool {
+	seen := make(map[fr.Element]bool, len(xs))
+	for _, x := range xs {
+		if seen[x] {
+			return false
+		}
+		seen[x] = true
+	}
+	return true
+}
+
+// cauchy returns the matrix 1/(x_i+y_j), or false if a sum is zero
+func cauchy(xs, ys []fr.Element) ([][]fr.Element, bool) {
+	m := newMatrix(len(xs))
+	for i := range xs {
+		for j := range ys {
+			var s fr.Element
+			s.Add(&xs[i], &ys[j])
+			if s.IsZero() {
+				return nil, false
+			}
+			m[i][j].Inverse(&s)
+		}
+	}
+	return m, true
+}
+
+// optimize computes the constants of the optimized permutation. Writing
+// d_i = A⁻¹·c_i, a round constant can be added after the S-box of the
+// previous round instead of before the S-box of its own round. In partial
+// rounds only the first component of d_i has to stay after the S-box; the
+// rest is pushed back to the last full round. Each partial round matrix is
+// then split into a sparse matrix times diag(1, Â), and the diagonal part is
+// moved back the same way, leaving the pre-sparse matrix P in the last full
+// round of the first half.
+func optimize(t, rf, rp int) tables {
+	cs, a := raw(t, rf, rp)
+	aInv := invert(a)
+	half := rf / 2
+
+	d := make([][]fr.Element, len(cs))
+	for i := 1; i < len(cs); i++ {
+		d[i] = mulVec(aInv, cs[i])
+	}
+	scalars := make([]fr.Element, rp)
+	for i := half + rp - 1; i >= half; i-- {
+		v := d[i+1]
+		scalars[i-half] = v[0]
+		rest := make([]fr.Element, t)
+		copy(rest[1:], v[1:])
+		back := mulVec(aInv, rest)
+		for j := range d[i] {
+			d[i][j].Add(&d[i][j], &back[j])
+		}
+		d[i+1] = make([]fr.Element, t)
+		d[i+1][0] = v[0]
+	}
+
+	var tb tables
+	tb.c = append(tb.c, cs[0]...)
+	for r := 1; r <= half; r++ {
+		tb.c = append(tb.c, d[r]...)
+	}
+	tb.c = append(tb.c, scalars...)
+	for r := half + rp + 1; r < rf+rp; r++ {
+		tb.c = append(tb.c, d[r]...)
+	}
+
+	sparse := make([][]fr.Element, rp)
+	cur := a
+	for i := rp - 1; i >= 0; i-- {
+		hat := newMatrix(t - 1)
+		for r := 1; r < t; r++ {
+			copy(hat[r-1], cur[r][1:])
+		}
+		vHat := mulVec(transpose(invert(hat)), cur[0][1:])
+		row := append([]fr.Element{cur[0][0]}, vHat...)
+		for r := 1; r < t; r++ {
+			row = append(row, cur[r][0])
+		}
+		sparse[i] = row
+
+		diag := identity(t)
+		for r := 1; r < t; r++ {
+			copy(diag[r][1:], hat[r-1])
+		}
+		cur = mul(diag, a)
+	}
+	for _, s := range sparse {
+		tb.s = append(tb.s, s...)
+	}
+
+	// PoseidonHash multiplies the state as a row vector
+	tb.m = transpose(a)
+	tb.p = transpose(cur)
+	return tb
+}
+
+func newMatrix(n int) [][]fr.Element {
+	m := make([][]fr.Element, n)
+	for i := range m {
+		m[i] = make([]fr.Element, n)
+	}
+	return m
+}
+
+func identity(n int) [][]fr.Element {
+	m := newMatrix(n)
+	for i := range m {
+		m[i][i].SetOne()
+	}
+	return m
+}
+
+func transpose(a [][]fr.Element) [][]fr.Element {
+	m := newMatrix(len(a))
+	for i := range a {
+		for j := range a[i] {
+			m[j][i] = a[i][j]
+		}
+	}
+	return m
+}
+
+func mul(a, b [][]fr.Element) [][]fr.Element {
+	m := newMatrix(len(a))
+	var term fr.Element
+	for i := range a {
+		for j := range b[0] {
+			for k := range b {
+				term.Mul(&a[i][k], &b[k][j])
+				m[i][j].Add(&m[i][j], &term)
+			}
+		}
+	}
+	return m
+}
+
+func mulVec(a [][]fr.Element, v []fr.Element) []fr.Element {
+	out := make([]fr.Element, len(a))
+	var term fr.Element
+	for i := range a {
+		for j := range v {
+			term.Mul(&a[i][j], &v[j])
+			out[i].Add(&out[i], &term)
+		}
+	}
+	return out
+}
+
+// invert returns the inverse of a by Gauss-Jordan elimination. The matrices
+// inverted here are MDS or submatrices of MDS matrices and so are invertible.
+func invert(a [][]fr.Element) [][]fr.Element {
+	n := len(a)
+	m := newMatrix(n)
+	for i := range a {
+		copy(m[i], a[i])
+	}
+	inv := identity(n)
+
+	for c := 0; c < n; c++ {
+		r := c
+		for m[r][c].IsZero() {
+			r++
+		}
+		m[c], m[r] = m[r], m[c]
+		inv[c], inv[r] = inv[r], inv[c]
+
+		var pivot fr.Element
+		pivot.Inverse(&m[c][c])
+		for j := 0; j < n; j++ {
+			m[c][j].Mul(&m[c][j], &pivot)
+			inv[c][j].Mul(&inv[c][j], &pivot)
+		}
+		for r := 0; r < n; r++ {
+			if r == c || m[r][c].IsZero() {
+				continue
+			}
+			f := m[r][c]
+			var term fr.Element
+			for j := 0; j < n; j++ {
+				term.Mul(&f, &m[c][j])
+				m[r][j].Sub(&m[r][j], &term)
+				term.Mul(&f, &inv[c][j])
+				inv[r][j].Sub(&inv[r][j], &term)
+			}
+		}
+	}
+	return inv
+}
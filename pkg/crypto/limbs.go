@@ -0,0 +1,102 @@
+package crypto
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// LimbEncoding selects how a 256-bit digest is split into the two 128-bit
+// field elements the circuit takes as metadataHash_p1 and metadataHash_p2.
+// Values are versions and must never be renumbered.
+type LimbEncoding uint8
+
+const (
+	// LimbsLowHigh reads the digest as a big-endian integer and sets p1 to
+	// its low 128 bits (the last 16 bytes) and p2 to its high 128 bits. This
+	// is the encoding of the sdv_poseidon_v1 circuit.
+	LimbsLowHigh LimbEncoding = 1
+	// LimbsHighLow sets p1 to the first 16 bytes and p2 to the last 16
+	// bytes. It was assumed by the legacy signal scan and is only accepted
+	// there for old proofs.
+	LimbsHighLow LimbEncoding = 2
+)
+
+// CanonicalLimbEncoding is the encoding used by the prover
+const CanonicalLimbEncoding = LimbsLowHigh
+
+func (e LimbEncoding) String() string {
+	switch e {
+	case LimbsLowHigh:
+		return "low-high"
+	case LimbsHighLow:
+		return "high-low"
+	default:
+		return fmt.Sprintf("LimbEncoding(%d)", uint8(e))
+	}
+}
+
+// ParseLimbEncoding parses "low-high" or "high-low". An empty string selects
+// CanonicalLimbEncoding.
+func ParseLimbEncoding(s string) (LimbEncoding, error) {
+	switch s {
+	case "":
+		return CanonicalLimbEncoding, nil
+	case "low-high":
+		return LimbsLowHigh, nil
+	case "high-low":
+		return LimbsHighLow, nil
+	default:
+		return 0, fmt.Errorf("unknown limb encoding %q (want low-high or high-low)", s)
+	}
+}
+
+// SplitDigest splits a 32-byte digest into two 128-bit field elements
+func SplitDigest(digest []byte, enc LimbEncoding) (*fr.Element, *fr.Element, error) {
+	if len(digest) != 32 {
+		return nil, nil, fmt.Errorf("digest must be 32 bytes, got %d", len(digest))
+	}
+
+	high, low := digest[:16], digest[16:]
+
+	// Both halves are below 2^128 and need no reduction
+	var p1, p2 fr.Element
+	switch enc {
+	case LimbsLowHigh:
+		p1.SetBytes(low)
+		p2.SetBytes(high)
+	case LimbsHighLow:
+		p1.SetBytes(high)
+		p2.SetBytes(low)
+	default:
+		return nil, nil, fmt.Errorf("unsupported limb encoding %s", enc)
+	}
+	return &p1, &p2, nil
+}
+
+// SplitMetadataHashWith computes SHA256 of metadata and splits it with enc
+func SplitMetadataHashWith(metaRaw string, enc LimbEncoding) (*fr.Element, *fr.Element, error) {
+	return SplitDigest(Sha256([]byte(metaRaw)), enc)
+}
+
+// PackedChunkBytes is the number of bytes packed into one field element by
+// PackBytes. 31 bytes always fit below the modulus.
+const PackedChunkBytes = 31
+
+// PackBytes packs data into dst, PackedChunkBytes per element. Each chunk is
+// read as a big-endian integer and the last one is padded with zero bytes on
+// the right; elements past the end of data are set to zero.
+func PackBytes(dst []fr.Element, data []byte) error {
+	if len(data) > len(dst)*PackedChunkBytes {
+		return fmt.Errorf("data is %d bytes, at most %d fit in %d elements", len(data), len(dst)*PackedChunkBytes, len(dst))
+	}
+	var chunk [PackedChunkBytes]byte
+	for i := range dst {
+		chunk = [PackedChunkBytes]byte{}
+		if off := i * PackedChunkBytes; off < len(data) {
+			copy(chunk[:], data[off:])
+		}
+		dst[i].SetBytes(chunk[:])
+	}
+	return nil
+}
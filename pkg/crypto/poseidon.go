@@ -1,22 +1,112 @@
 package crypto
 
 import (
+	_ "embed"
+	"encoding/binary"
+	"errors"
 	"fmt"
-	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
-// Poseidon parameters - matches Circom implementation
-var nRoundsP = []int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65, 70, 60, 64, 68}
+//go:generate go run ./internal/poseidongen -o poseidon_constants.bin
+
+// poseidonConstants holds the Circom-compatible constants for state widths 2
+// to 17 (1 to 16 inputs), as written by internal/poseidongen. Elements are
+// stored big-endian and decoded once at package init.
+//
+//go:embed poseidon_constants.bin
+var poseidonConstants []byte
+
+// PoseidonParams holds the constants of one state width in the optimized
+// form of circomlib's poseidon.circom
+type PoseidonParams struct {
+	T        int            // State width (nInputs + 1)
+	NRoundsF int            // Full rounds
+	NRoundsP int            // Partial rounds
+	C        []fr.Element   // Round constants
+	M        [][]fr.Element // MDS matrix
+	P        [][]fr.Element // Pre-sparse matrix
+	S        []fr.Element   // Sparse matrix elements
+}
+
+// maxPoseidonWidth bounds the state width so that the state fits in a fixed
+// array on the stack
+const maxPoseidonWidth = 17
+
+var poseidonTables [maxPoseidonWidth + 1]*PoseidonParams
+
+// PoseidonParameters returns the constants for state width t. They are shared
+// and must not be modified.
+func PoseidonParameters(t int) (*PoseidonParams, error) {
+	if t < 0 || t >= len(poseidonTables) || poseidonTables[t] == nil {
+		return nil, fmt.Errorf("unsupported t value: %d", t)
+	}
+	return poseidonTables[t], nil
+}
+
+func init() {
+	if err := loadPoseidonTables(poseidonConstants); err != nil {
+		panic("crypto: " + err.Error())
+	}
+}
+
+// loadPoseidonTables decodes the tables in the layout documented in
+// internal/poseidongen
+func loadPoseidonTables(data []byte) error {
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return errors.New("truncated Poseidon constants header")
+		}
+		t, rf, rp := int(data[0]), int(data[1]), int(binary.BigEndian.Uint16(data[2:4]))
+		data = data[4:]
+		if t < 2 || t > maxPoseidonWidth {
+			return fmt.Errorf("unsupported Poseidon width %d", t)
+		}
+
+		next := func(n int) ([]fr.Element, error) {
+			if len(data) < n*fr.Bytes {
+				return nil, fmt.Errorf("truncated Poseidon constants for t=%d", t)
+			}
+			v := make([]fr.Element, n)
+			for i := range v {
+				var err error
+				if v[i], err = fr.BigEndian.Element((*[fr.Bytes]byte)(data[i*fr.Bytes:])); err != nil {
+					return nil, fmt.Errorf("Poseidon constant for t=%d: %w", t, err)
+				}
+			}
+			data = data[n*fr.Bytes:]
+			return v, nil
+		}
+		matrix := func() ([][]fr.Element, error) {
+			flat, err := next(t * t)
+			if err != nil {
+				return nil, err
+			}
+			m := make([][]fr.Element, t)
+			for i := range m {
+				m[i] = flat[i*t : (i+1)*t]
+			}
+			return m, nil
+		}
 
-// getFr converts hex string to fr.Element
-func getFr(hexStr string) *fr.Element {
-	bi := new(big.Int)
-	bi.SetString(hexStr[2:], 16) // Skip 0x prefix
-	var f fr.Element
-	f.SetBigInt(bi)
-	return &f
+		params := &PoseidonParams{T: t, NRoundsF: rf, NRoundsP: rp}
+		var err error
+		if params.C, err = next(rf*t + rp); err != nil {
+			return err
+		}
+		if params.S, err = next((2*t - 1) * rp); err != nil {
+			return err
+		}
+		if params.M, err = matrix(); err != nil {
+			return err
+		}
+		if params.P, err = matrix(); err != nil {
+			return err
+		}
+		poseidonTables[t] = params
+	}
+	return nil
 }
 
 // PoseidonHash computes Poseidon hash of field elements using Circom-compatible parameters
@@ -25,132 +115,120 @@ func PoseidonHash(inputs []*fr.Element) (*fr.Element, error) {
 	nInputs := len(inputs)
 	t := nInputs + 1
 
-	var c, s []string
-	var m, p [][]string
-
-	switch t {
-	case 2:
-		c = poseidonC2
-		m = poseidonM2
-		p = poseidonP2
-		s = poseidonS2
-	case 4:
-		c = poseidonC4
-		m = poseidonM4
-		p = poseidonP4
-		s = poseidonS4
-	case 5:
-		c = poseidonC5
-		m = poseidonM5
-		p = poseidonP5
-		s = poseidonS5
-	default:
+	params, err := PoseidonParameters(t)
+	if err != nil {
 		return nil, fmt.Errorf("unsupported number of inputs: %d (t=%d)", nInputs, t)
 	}
+	c, s, m, p := params.C, params.S, params.M, params.P
+
+	nRoundsF := params.NRoundsF
+	nRoundsP := params.NRoundsP
 
-	nRoundsF := 8
-	nRoundsP := nRoundsP[t-2]
+	// The state and the mix output live on the stack and are swapped after
+	// every mix
+	var bufA, bufB [maxPoseidonWidth]fr.Element
+	state, next := bufA[:t], bufB[:t]
 
 	// Helper: S-box (x^5)
-	sBox := func(x *fr.Element) *fr.Element {
-		x2 := new(fr.Element).Mul(x, x)
-		x4 := new(fr.Element).Mul(x2, x2)
-		return new(fr.Element).Mul(x4, x)
+	sBox := func(x *fr.Element) {
+		var x2 fr.Element
+		x2.Square(x)
+		x2.Square(&x2)
+		x.Mul(x, &x2)
 	}
 
 	// Helper: Add round constants
-	ark := func(state []*fr.Element, r int) {
+	ark := func(r int) {
 		for i := 0; i < t; i++ {
-			state[i].Add(state[i], getFr(c[i+r]))
+			state[i].Add(&state[i], &c[i+r])
 		}
 	}
 
 	// Helper: MDS mix
-	mix := func(state []*fr.Element, matrix [][]string) []*fr.Element {
-		result := make([]*fr.Element, t)
+	mix := func(matrix [][]fr.Element) {
+		var term fr.Element
 		for i := 0; i < t; i++ {
-			result[i] = new(fr.Element).SetZero()
+			next[i].SetZero()
 			for j := 0; j < t; j++ {
-				term := new(fr.Element).Mul(state[j], getFr(matrix[j][i]))
-				result[i].Add(result[i], term)
+				term.Mul(&state[j], &matrix[j][i])
+				next[i].Add(&next[i], &term)
 			}
 		}
-		return result
+		state, next = next, state
 	}
 
 	// Helper: Sparse mix for partial rounds
-	mixS := func(state []*fr.Element, r int) []*fr.Element {
-		result := make([]*fr.Element, t)
+	mixS := func(r int) {
+		var term fr.Element
 		sOffset := (t*2 - 1) * r
 
 		// First element is a dot product
-		result[0] = new(fr.Element).SetZero()
+		next[0].SetZero()
 		for i := 0; i < t; i++ {
-			term := new(fr.Element).Mul(state[i], getFr(s[sOffset+i]))
-			result[0].Add(result[0], term)
+			term.Mul(&state[i], &s[sOffset+i])
+			next[0].Add(&next[0], &term)
 		}
 
 		// Remaining elements
 		for i := 1; i < t; i++ {
-			result[i] = new(fr.Element).Add(state[i], new(fr.Element).Mul(state[0], getFr(s[sOffset+t+i-1])))
+			term.Mul(&state[0], &s[sOffset+t+i-1])
+			next[i].Add(&state[i], &term)
 		}
-
-		return result
+		state, next = next, state
 	}
 
 	// Initialize state: [initialState=0, inputs[0], inputs[1], ...]
-	state := make([]*fr.Element, t)
-	state[0] = new(fr.Element).SetZero()
 	for i := 0; i < nInputs; i++ {
-		state[i+1] = new(fr.Element).Set(inputs[i])
+		state[i+1].Set(inputs[i])
 	}
 
 	// === Following the exact poseidon.circom PoseidonEx algorithm ===
 
 	// Initial ark at round 0
-	ark(state, 0)
+	ark(0)
 
 	// First half of full rounds (nRoundsF/2 - 1 rounds)
 	for r := 0; r < nRoundsF/2-1; r++ {
 		for i := 0; i < t; i++ {
-			state[i] = sBox(state[i])
+			sBox(&state[i])
 		}
-		ark(state, (r+1)*t)
-		state = mix(state, m)
+		ark((r + 1) * t)
+		mix(m)
 	}
 
 	// Middle full round with S-box, ark, and P-matrix mix
 	for i := 0; i < t; i++ {
-		state[i] = sBox(state[i])
+		sBox(&state[i])
 	}
-	ark(state, (nRoundsF/2)*t)
-	state = mix(state, p)
+	ark((nRoundsF / 2) * t)
+	mix(p)
 
 	// Partial rounds
 	for r := 0; r < nRoundsP; r++ {
-		state[0] = sBox(state[0])
+		sBox(&state[0])
 		// Add round constant to first element only
-		state[0].Add(state[0], getFr(c[(nRoundsF/2+1)*t+r]))
-		state = mixS(state, r)
+		state[0].Add(&state[0], &c[(nRoundsF/2+1)*t+r])
+		mixS(r)
 	}
 
 	// Second half of full rounds (nRoundsF/2 - 1 rounds)
 	for r := 0; r < nRoundsF/2-1; r++ {
 		for i := 0; i < t; i++ {
-			state[i] = sBox(state[i])
+			sBox(&state[i])
 		}
-		ark(state, (nRoundsF/2+1)*t+nRoundsP+r*t)
-		state = mix(state, m)
+		ark((nRoundsF/2+1)*t + nRoundsP + r*t)
+		mix(m)
 	}
 
 	// Final full round: S-box only, then final mix with M
 	for i := 0; i < t; i++ {
-		state[i] = sBox(state[i])
+		sBox(&state[i])
 	}
-	state = mix(state, m)
+	mix(m)
 
 	// Return first element of the state (equivalent to mixLast in Circom)
-	return state[0], nil
+	out := state[0]
+	return &out, nil
 }
 
 // CircuitHash is an alias for PoseidonHash for compatibility
@@ -0,0 +1,109 @@
+package crypto
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// poseidonVector is a circomlibjs poseidon() result, decimal encoded
+type poseidonVector struct {
+	Inputs []string `json:"inputs"`
+	Hash   string   `json:"hash"`
+}
+
+func loadPoseidonVectors(t testing.TB) []poseidonVector {
+	data, err := os.ReadFile(filepath.Join("testdata", "poseidon_vectors.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []poseidonVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	return vectors
+}
+
+// TestPoseidonReferenceVectors compares PoseidonHash with circomlibjs. The
+// arities without a vector are covered by the in-circuit comparison in
+// pkg/circuit/poseidon, whose tables are shared.
+func TestPoseidonReferenceVectors(t *testing.T) {
+	for _, v := range loadPoseidonVectors(t) {
+		inputs := make([]*fr.Element, len(v.Inputs))
+		for i, s := range v.Inputs {
+			e, err := ParseFr(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inputs[i] = &e
+		}
+		got, err := PoseidonHash(inputs)
+		if err != nil {
+			t.Fatalf("poseidon(%v): %v", v.Inputs, err)
+		}
+		if got.String() != v.Hash {
+			t.Errorf("poseidon(%v) = %s, want %s", v.Inputs, got, v.Hash)
+		}
+	}
+}
+
+func TestPoseidonArities(t *testing.T) {
+	for n := 0; n <= maxPoseidonWidth; n++ {
+		inputs := make([]*fr.Element, n)
+		for i := range inputs {
+			inputs[i] = new(fr.Element).SetUint64(uint64(i + 1))
+		}
+		_, err := PoseidonHash(inputs)
+		if supported := n >= 1 && n < maxPoseidonWidth; supported != (err == nil) {
+			t.Errorf("%d inputs: err = %v, supported = %v", n, err, supported)
+		}
+	}
+}
+
+// FuzzPoseidonHash splits the input into field elements, reduced modulo the
+// field, and checks that hashing is deterministic, leaves the inputs alone
+// and rejects exactly the unsupported arities
+func FuzzPoseidonHash(f *testing.F) {
+	f.Add([]byte{1})
+	f.Add(make([]byte, 2*fr.Bytes))
+	f.Add(make([]byte, 17*fr.Bytes))
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var inputs, copies []*fr.Element
+		for len(data) > 0 {
+			n := min(len(data), fr.Bytes)
+			e := new(fr.Element).SetBytes(data[:n])
+			inputs = append(inputs, e)
+			copies = append(copies, new(fr.Element).Set(e))
+			data = data[n:]
+		}
+
+		h1, err := PoseidonHash(inputs)
+		if supported := len(inputs) >= 1 && len(inputs) < maxPoseidonWidth; supported != (err == nil) {
+			t.Fatalf("%d inputs: err = %v", len(inputs), err)
+		}
+		if err != nil {
+			return
+		}
+		for i := range inputs {
+			if !inputs[i].Equal(copies[i]) {
+				t.Fatalf("input %d modified", i)
+			}
+		}
+		h2, _ := PoseidonHash(copies)
+		if !h1.Equal(h2) {
+			t.Fatalf("hash not deterministic: %s != %s", h1, h2)
+		}
+
+		// Changing one input changes the hash
+		one := fr.One()
+		copies[0].Add(copies[0], &one)
+		if h3, _ := PoseidonHash(copies); h1.Equal(h3) {
+			t.Fatalf("collision on a changed input: %s", h1)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+// Package discovery fetches issuer configuration documents, the PTX
+// counterpart of OIDC discovery. An issuer publishes
+// https://<domain>/.well-known/ptx-configuration:
+//
+//	{
+//	  "issuer": "example.com",
+//	  "keys": [
+//	    {"id": "sdv_poseidon_v1", "circuitHash": "41b7...", "url": "https://example.com/keys/sdv_poseidon_v1.vk", "sha256": "9f2c..."}
+//	  ],
+//	  "trustMethods": ["DOH"]
+//	}
+//
+// so that verifiers can select the verification key of a token from its
+// anchor domain instead of being configured with every issuer's keys.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+const (
+	// WellKnownPath is where an issuer serves its Configuration
+	WellKnownPath = "/.well-known/ptx-configuration"
+	// DefaultMaxAge is how long a configuration is reused before it is
+	// fetched again
+	DefaultMaxAge = 15 * time.Minute
+	// MaxDocumentSize bounds a configuration document
+	MaxDocumentSize = 64 << 10
+)
+
+// Configuration is an issuer's discovery document
+type Configuration struct {
+	Issuer string `json:"issuer"`
+	Keys   []Key  `json:"keys"`
+	// TrustMethods lists the PTX trust methods the issuer uses, as enum
+	// names or aliases (e.g. "DOH", "well-known"). Empty means any.
+	TrustMethods []string `json:"trustMethods,omitempty"`
+}
+
+// Key is a verification key published by an issuer. The fields mirror a
+// verifier.KeyEntry served by URL.
+type Key struct {
+	ID string `json:"id"`
+	// Circuit is the PTX verification key ID the key belongs to, if it
+	// differs from ID
+	Circuit string `json:"circuit,omitempty"`
+	// CircuitHash is the constraint system hash of the key's circuit. It is
+	// required, so that an issuer cannot publish a key for another circuit.
+	CircuitHash string `json:"circuitHash"`
+	URL         string `json:"url"`
+	// SHA256 or SignatureKey pins the key; at least one is required
+	SHA256 string `json:"sha256,omitempty"`
+	// SignatureKey is an ed25519 public key (base64 in JSON)
+	SignatureKey []byte    `json:"signatureKey,omitempty"`
+	NotBefore    time.Time `json:"notBefore,omitempty"`
+	NotAfter     time.Time `json:"notAfter,omitempty"`
+}
+
+// Validate checks that every key is addressed by an https URL, pinned by a
+// hash or signature key and bound to a circuit hash
+func (c *Configuration) Validate() error {
+	if len(c.Keys) == 0 {
+		return errors.New("configuration lists no keys")
+	}
+	for _, k := range c.Keys {
+		if k.ID == "" {
+			return errors.New("key without id")
+		}
+		if !strings.HasPrefix(k.URL, "https://") {
+			return fmt.Errorf("key %q: url must use https", k.ID)
+		}
+		if k.SHA256 == "" && len(k.SignatureKey) == 0 {
+			return fmt.Errorf("key %q: a sha256 or signatureKey pin is required", k.ID)
+		}
+		if !circuit.IsHash(k.CircuitHash) {
+			return fmt.Errorf("key %q: circuitHash must be a lowercase hex SHA-256", k.ID)
+		}
+	}
+	return nil
+}
+
+// SupportsTrustMethod reports whether the issuer uses the named trust method
+func (c *Configuration) SupportsTrustMethod(name string) bool {
+	if len(c.TrustMethods) == 0 {
+		return true
+	}
+	want, err := ptx.ParseTrustMethod(name)
+	for _, m := range c.TrustMethods {
+		got, gerr := ptx.ParseTrustMethod(m)
+		if strings.EqualFold(m, name) || (err == nil && gerr == nil && got == want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Client fetches and caches configuration documents per domain. When a
+// refresh fails the previous document keeps being used.
+type Client struct {
+	HTTP *http.Client
+	// MaxAge defaults to DefaultMaxAge
+	MaxAge time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedConfig
+}
+
+type cachedConfig struct {
+	cfg       *Configuration
+	fetchedAt time.Time
+}
+
+func NewClient() *Client {
+	return &Client{HTTP: outbound.Client(10 * time.Second)}
+}
+
+// Discover returns the configuration of the issuer at domain
+func (c *Client) Discover(ctx context.Context, domain string) (*Configuration, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if domain == "" || strings.ContainsAny(domain, "/:@?#") {
+		return nil, fmt.Errorf("invalid issuer domain %q", domain)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.cache[domain]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < c.maxAge() {
+		return cached.cfg, nil
+	}
+
+	cfg, err := c.fetch(ctx, domain)
+	if err != nil {
+		if ok {
+			return cached.cfg, nil
+		}
+		return nil, fmt.Errorf("discovery for %s failed: %w", domain, err)
+	}
+
+	c.mu.Lock()
+	if c.cache == nil {
+		c.cache = map[string]cachedConfig{}
+	}
+	c.cache[domain] = cachedConfig{cfg: cfg, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return cfg, nil
+}
+
+func (c *Client) fetch(ctx context.Context, domain string) (*Configuration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+domain+WellKnownPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := c.HTTP
+	if client == nil {
+		client = outbound.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxDocumentSize {
+		return nil, fmt.Errorf("configuration exceeds %d bytes", MaxDocumentSize)
+	}
+
+	var cfg Configuration
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (c *Client) maxAge() time.Duration {
+	if c.MaxAge > 0 {
+		return c.MaxAge
+	}
+	return DefaultMaxAge
+}
@@ -0,0 +1,184 @@
+package discovery
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const circuitHash = "41b7c3a9d0e5f2c8b6a1d4e7f0c3b9a2d5e8f1c4b7a0d3e6f9c2b5a8d1e4f7c0"
+
+// issuer serves a configuration document for example.com, the name the
+// httptest certificate is issued for
+type issuer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	doc      string
+	status   int
+	requests int
+}
+
+func newIssuer(t *testing.T, doc string) (*issuer, *Client) {
+	s := &issuer{doc: doc}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		if r.URL.Path != WellKnownPath {
+			http.NotFound(w, r)
+			return
+		}
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			return
+		}
+		w.Write([]byte(s.doc))
+	}))
+	t.Cleanup(s.Close)
+
+	tr := s.Client().Transport.(*http.Transport).Clone()
+	tr.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, s.Listener.Addr().String())
+	}
+	return s, &Client{HTTP: &http.Client{Transport: tr}}
+}
+
+func (s *issuer) set(doc string, status int) {
+	s.mu.Lock()
+	s.doc, s.status = doc, status
+	s.mu.Unlock()
+}
+
+func (s *issuer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func document(t *testing.T, keys ...Key) string {
+	t.Helper()
+	data, err := json.Marshal(Configuration{Issuer: "example.com", Keys: keys, TrustMethods: []string{"DOH"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func pinnedKey() Key {
+	return Key{
+		ID:          "2026",
+		Circuit:     "sdv_poseidon_v1",
+		CircuitHash: circuitHash,
+		URL:         "https://example.com/keys/2026.vk",
+		SHA256:      strings.Repeat("ab", 32),
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	s, c := newIssuer(t, document(t, pinnedKey()))
+	cfg, err := c.Discover(context.Background(), "Example.COM.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Keys) != 1 || cfg.Keys[0].CircuitHash != circuitHash || !cfg.SupportsTrustMethod("doh") {
+		t.Errorf("Discover = %+v", cfg)
+	}
+
+	signed := pinnedKey()
+	signed.SHA256, signed.SignatureKey = "", make([]byte, ed25519.PublicKeySize)
+	s.set(document(t, signed), 0)
+	if _, err := (&Client{HTTP: c.HTTP}).Discover(context.Background(), "example.com"); err != nil {
+		t.Errorf("key pinned by its signature key: %v", err)
+	}
+}
+
+func TestDiscoverRejectsDocument(t *testing.T) {
+	key := func(fn func(*Key)) string {
+		k := pinnedKey()
+		fn(&k)
+		return document(t, k)
+	}
+	for name, doc := range map[string]string{
+		"no keys":           document(t),
+		"no id":             key(func(k *Key) { k.ID = "" }),
+		"http url":          key(func(k *Key) { k.URL = "http://example.com/keys/2026.vk" }),
+		"file url":          key(func(k *Key) { k.URL = "file:///keys/2026.vk" }),
+		"unpinned":          key(func(k *Key) { k.SHA256 = "" }),
+		"no circuit hash":   key(func(k *Key) { k.CircuitHash = "" }),
+		"upper case hash":   key(func(k *Key) { k.CircuitHash = strings.ToUpper(circuitHash) }),
+		"short hash":        key(func(k *Key) { k.CircuitHash = circuitHash[:32] }),
+		"not json":          "<html>",
+		"oversize document": `{"issuer": "` + strings.Repeat("a", MaxDocumentSize) + `"}`,
+	} {
+		_, c := newIssuer(t, doc)
+		if cfg, err := c.Discover(context.Background(), "example.com"); err == nil {
+			t.Errorf("%s: Discover = %+v", name, cfg)
+		}
+	}
+}
+
+func TestDiscoverDomain(t *testing.T) {
+	s, c := newIssuer(t, document(t, pinnedKey()))
+	for _, domain := range []string{"", "example.com:8443", "example.com/x", "user@example.com", "example.com?x", "example.com#x"} {
+		if _, err := c.Discover(context.Background(), domain); err == nil {
+			t.Errorf("Discover(%q) succeeded", domain)
+		}
+	}
+	if n := s.count(); n != 0 {
+		t.Errorf("%d requests for invalid domains", n)
+	}
+}
+
+func TestDiscoverCache(t *testing.T) {
+	s, c := newIssuer(t, document(t, pinnedKey()))
+	if _, err := c.Discover(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Discover(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.count(); n != 1 {
+		t.Errorf("fresh configuration fetched again: %d requests", n)
+	}
+
+	// A failed refresh keeps the previous document
+	c.MaxAge = time.Nanosecond
+	s.set("", http.StatusInternalServerError)
+	cfg, err := c.Discover(context.Background(), "example.com")
+	if err != nil || len(cfg.Keys) != 1 {
+		t.Errorf("failed refresh: Discover = %+v, %v", cfg, err)
+	}
+	if n := s.count(); n != 2 {
+		t.Errorf("stale configuration not refreshed: %d requests", n)
+	}
+
+	// Nothing to fall back to
+	if _, err := (&Client{HTTP: c.HTTP}).Discover(context.Background(), "example.com"); err == nil {
+		t.Error("Discover succeeded on a server error")
+	}
+}
+
+func TestSupportsTrustMethod(t *testing.T) {
+	cfg := &Configuration{TrustMethods: []string{"DOH", "well-known"}}
+	for name, ok := range map[string]bool{
+		"DOH":        true,
+		"doh":        true,
+		"well-known": true,
+		"GIST":       false,
+		"ethereum":   false,
+	} {
+		if got := cfg.SupportsTrustMethod(name); got != ok {
+			t.Errorf("SupportsTrustMethod(%q) = %v", name, got)
+		}
+	}
+	if !(&Configuration{}).SupportsTrustMethod("GIST") {
+		t.Error("empty trust methods do not allow any method")
+	}
+}
@@ -0,0 +1,462 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+// DefaultEndpoint is the DoH resolver used when none is configured.
+// Cloudflare is used as a robust public resolver.
+const DefaultEndpoint = "https://cloudflare-dns.com/dns-query"
+
+const defaultTimeout = 10 * time.Second
+
+// Format selects the DoH dialect spoken to the resolver
+type Format int
+
+const (
+	// FormatJSON uses the Cloudflare/Google JSON API (application/dns-json)
+	FormatJSON Format = iota
+	// FormatWire uses RFC 8484 binary messages POSTed as application/dns-message
+	FormatWire
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatWire:
+		return "wire"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ParseFormat parses "json" or "wire"
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "json":
+		return FormatJSON, nil
+	case "wire", "dns-message":
+		return FormatWire, nil
+	default:
+		return FormatJSON, fmt.Errorf("unknown DoH format %q (expected json or wire)", s)
+	}
+}
+
+// maxResponseSize bounds the size of a DoH response body
+const maxResponseSize = 64 << 10
+
+// ClientConfig configures a DoH client. The zero value uses Cloudflare with
+// HTTP/2, keep-alives and the proxy from the environment.
+type ClientConfig struct {
+	// Endpoint is the DoH resolver URL
+	Endpoint string
+	// Format selects the JSON API or RFC 8484 wire format
+	Format Format
+	// Timeout bounds a whole query including connection setup
+	Timeout time.Duration
+	// Network restricts the transport to "tcp4" or "tcp6". Empty means both.
+	Network string
+	// ProxyURL overrides the proxy of the shared outbound configuration
+	// (see package outbound), by default the HTTP(S)_PROXY environment
+	// variables
+	ProxyURL string
+	// CAFile is a PEM bundle used instead of the roots of the shared
+	// outbound configuration
+	CAFile string
+	// DisableHTTP2 forces HTTP/1.1
+	DisableHTTP2 bool
+	// MaxIdleConns bounds the pool of idle keep-alive connections
+	MaxIdleConns int
+	// Privacy enables privacy mode: padded queries spread over several
+	// resolvers at staggered times. See Privacy.
+	Privacy *Privacy
+}
+
+// Timing holds connection diagnostics for a single DoH query. Durations are
+// zero for phases skipped because a pooled connection was reused.
+type Timing struct {
+	DNSLookup    time.Duration `json:"dnsLookupNs"`
+	Connect      time.Duration `json:"connectNs"`
+	TLSHandshake time.Duration `json:"tlsHandshakeNs"`
+	FirstByte    time.Duration `json:"firstByteNs"`
+	Total        time.Duration `json:"totalNs"`
+	Reused       bool          `json:"reused"`
+	Protocol     string        `json:"protocol"`
+	RemoteAddr   string        `json:"remoteAddr,omitempty"`
+}
+
+// Client performs DoH queries over a shared, pooled HTTP transport
+type Client struct {
+	endpoint string
+	format   Format
+	http     *http.Client
+	privacy  *Privacy
+}
+
+var (
+	defaultClientMu sync.RWMutex
+	defaultClient   *Client
+)
+
+// NewClient builds a client from cfg
+func NewClient(cfg ClientConfig) (*Client, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DoH endpoint: %w", err)
+	}
+
+	if cfg.Format != FormatJSON && cfg.Format != FormatWire {
+		return nil, fmt.Errorf("unsupported DoH format %v", cfg.Format)
+	}
+
+	var privacy *Privacy
+	if cfg.Privacy != nil {
+		if err := cfg.Privacy.validate(); err != nil {
+			return nil, err
+		}
+		p := *cfg.Privacy
+		p.Endpoints = append([]string(nil), p.Endpoints...)
+		privacy = &p
+	}
+
+	switch cfg.Network {
+	case "", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("unsupported network %q (expected tcp4 or tcp6)", cfg.Network)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	shared := outbound.Default()
+	tlsConfig, err := shared.TLSConfig(endpointURL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CAFile != "" {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	proxy, err := shared.Proxy()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	// A custom dialer is only installed when the network is pinned: on js/wasm
+	// any dial hook makes net/http bypass the Fetch API, which is the only
+	// transport available there
+	var dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	if cfg.Network == "tcp4" || cfg.Network == "tcp6" {
+		dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+		network := cfg.Network
+		dial = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = 16
+	}
+
+	transport := &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dial,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: timeout,
+		ForceAttemptHTTP2:   !cfg.DisableHTTP2,
+		MaxIdleConns:        maxIdle,
+		MaxIdleConnsPerHost: maxIdle,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map disables the HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &Client{
+		endpoint: endpoint,
+		format:   cfg.Format,
+		http:     &http.Client{Transport: transport, Timeout: timeout},
+		privacy:  privacy,
+	}, nil
+}
+
+// DefaultClient returns the process-wide client, creating it on first use
+func DefaultClient() *Client {
+	defaultClientMu.RLock()
+	c := defaultClient
+	defaultClientMu.RUnlock()
+	if c != nil {
+		return c
+	}
+
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	if defaultClient == nil {
+		// The zero config cannot fail
+		defaultClient, _ = NewClient(ClientConfig{})
+	}
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client used by the package-level helpers
+func SetDefaultClient(c *Client) {
+	defaultClientMu.Lock()
+	defaultClient = c
+	defaultClientMu.Unlock()
+}
+
+// Endpoint returns the resolver URL
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// Format returns the DoH dialect used by the client
+func (c *Client) Format() Format {
+	return c.format
+}
+
+// GetTXT returns all TXT records for hostname
+func (c *Client) GetTXT(ctx context.Context, hostname string) ([]string, error) {
+	records, _, err := c.GetTXTWithTiming(ctx, hostname)
+	return records, err
+}
+
+// GetTXTWithTiming returns all TXT records for hostname along with connection
+// timings for the query
+func (c *Client) GetTXTWithTiming(ctx context.Context, hostname string) ([]string, *Timing, error) {
+	l, err := c.LookupTXT(ctx, hostname)
+	return l.Records, l.Timing, err
+}
+
+// Resolver looks up TXT records. Client implements it over DoH; callers that
+// fetched the answer themselves (e.g. in a browser) can use StaticResolver.
+type Resolver interface {
+	LookupTXT(ctx context.Context, hostname string) (*TXTLookup, error)
+}
+
+// TXTLookup is the outcome of a single TXT query, including the raw resolver
+// response for audit purposes
+type TXTLookup struct {
+	Records []string
+	Timing  *Timing
+	// Source identifies where the answer came from (the DoH endpoint URL)
+	Source string
+	// Format is the DoH dialect of Response
+	Format string
+	// Response is the undecoded response body. It is nil if no response was
+	// received.
+	Response    []byte
+	ContentType string
+}
+
+// LookupTXT queries the TXT records for hostname. The returned lookup is never
+// nil, so timings and any raw response are available even on error.
+func (c *Client) LookupTXT(ctx context.Context, hostname string) (*TXTLookup, error) {
+	endpoint := c.pickEndpoint()
+	l := &TXTLookup{Timing: &Timing{}, Source: endpoint, Format: c.format.String()}
+
+	req, err := c.newTXTRequest(ctx, endpoint, hostname)
+	if err != nil {
+		return l, err
+	}
+	if err := c.stagger(ctx); err != nil {
+		return l, err
+	}
+
+	start := time.Now()
+	defer func() { l.Timing.Total = time.Since(start) }()
+
+	resp, err := c.do(req, l.Timing, start)
+	if err != nil {
+		return l, err
+	}
+	defer resp.Body.Close()
+
+	l.ContentType = resp.Header.Get("Content-Type")
+	if resp.StatusCode != 200 {
+		return l, fmt.Errorf("DoH request failed with status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return l, err
+	}
+	if len(body) > maxResponseSize {
+		return l, fmt.Errorf("DoH response exceeds %d bytes", maxResponseSize)
+	}
+	l.Response = body
+
+	if c.format == FormatWire {
+		l.Records, err = parseWireTXT(body)
+	} else {
+		l.Records, err = parseJSONTXT(body)
+	}
+	return l, err
+}
+
+func (c *Client) newTXTRequest(ctx context.Context, endpoint, hostname string) (*http.Request, error) {
+	if c.format == FormatWire {
+		query, err := encodeQuery(hostname, typeTXT)
+		if err != nil {
+			return nil, err
+		}
+		if c.privacy != nil {
+			query = padQuery(query, PaddingBlockSize)
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(query))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/dns-message")
+		req.Header.Set("Accept", "application/dns-message")
+		return req, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	q.Set("name", hostname)
+	q.Set("type", "TXT")
+	u.RawQuery = q.Encode()
+	if c.privacy != nil {
+		padURL(u, PaddingBlockSize)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/dns-json")
+	return req, nil
+}
+
+func parseJSONTXT(body []byte) ([]string, error) {
+	var dohResp DoHResponse
+	if err := json.Unmarshal(body, &dohResp); err != nil {
+		return nil, err
+	}
+
+	if dohResp.Status != 0 {
+		// Status 0 is No Error.
+		return nil, nil
+	}
+
+	var txtRecords []string
+	for _, ans := range dohResp.Answer {
+		if ans.Type == typeTXT {
+			// Join multi-string records and strip quotes
+			txtRecords = append(txtRecords, parseTXTData(ans.Data))
+		}
+	}
+	return txtRecords, nil
+}
+
+func parseWireTXT(body []byte) ([]string, error) {
+	msg, err := decodeResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Rcode != 0 {
+		// Same as a non-zero Status in the JSON API
+		return nil, nil
+	}
+
+	var txtRecords []string
+	for _, ans := range msg.Answers {
+		if ans.Type != typeTXT || ans.Class != classIN {
+			continue
+		}
+		val, err := txtData(ans.Data)
+		if err != nil {
+			return nil, err
+		}
+		txtRecords = append(txtRecords, val)
+	}
+	return txtRecords, nil
+}
+
+// do sends req with an httptrace attached that fills in t. Total is left to
+// the caller since it includes reading the body.
+func (c *Client) do(req *http.Request, t *Timing, start time.Time) (*http.Response, error) {
+	var dnsStart, connStart, tlsStart time.Time
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			if !dnsStart.IsZero() {
+				t.DNSLookup = time.Since(dnsStart)
+			}
+		},
+		ConnectStart: func(_, _ string) { connStart = time.Now() },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil && !connStart.IsZero() {
+				t.Connect = time.Since(connStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil && !tlsStart.IsZero() {
+				t.TLSHandshake = time.Since(tlsStart)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.Reused = info.Reused
+			if info.Conn != nil {
+				t.RemoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		GotFirstResponseByte: func() { t.FirstByte = time.Since(start) },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	t.Protocol = resp.Proto
+	return resp, nil
+}
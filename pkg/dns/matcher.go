@@ -0,0 +1,118 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MatchMode selects how a TXT record value is compared against the expected
+// anchor value
+type MatchMode int
+
+const (
+	// MatchExact requires the record value to equal the expected value
+	MatchExact MatchMode = iota
+	// MatchPrefix requires the record value to start with the expected value,
+	// allowing publishers to append data after the anchor
+	MatchPrefix
+)
+
+func (m MatchMode) String() string {
+	switch m {
+	case MatchExact:
+		return "exact"
+	case MatchPrefix:
+		return "prefix"
+	default:
+		return fmt.Sprintf("MatchMode(%d)", int(m))
+	}
+}
+
+// ParseMatchMode parses "exact" or "prefix"
+func ParseMatchMode(s string) (MatchMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "exact":
+		return MatchExact, nil
+	case "prefix":
+		return MatchPrefix, nil
+	default:
+		return MatchExact, fmt.Errorf("unknown DNS match mode %q (expected exact or prefix)", s)
+	}
+}
+
+// AnchorMatcher compares TXT record values against an expected anchor value.
+//
+// The expected value is a hex digest. Records may carry it as hex (in any
+// case) or as the standard or URL-safe base64 encoding of the digest bytes.
+// Multi-string TXT records must already be concatenated, which GetTXT does.
+type AnchorMatcher struct {
+	Expected string
+	Mode     MatchMode
+}
+
+// NewAnchorMatcher returns a matcher for the expected hex value
+func NewAnchorMatcher(expected string, mode MatchMode) *AnchorMatcher {
+	return &AnchorMatcher{Expected: expected, Mode: mode}
+}
+
+// Match reports whether a single record value matches the expected anchor
+func (m *AnchorMatcher) Match(record string) bool {
+	record = strings.TrimSpace(record)
+	expected := strings.TrimSpace(m.Expected)
+	if expected == "" {
+		return false
+	}
+
+	if m.compare(strings.ToLower(record), strings.ToLower(expected)) {
+		return true
+	}
+
+	// base64 encoded digest
+	want, err := hex.DecodeString(expected)
+	if err != nil {
+		return false
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if got, ok := decodeBase64Prefix(enc, record, len(want), m.Mode); ok && bytes.Equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny reports whether any of the records match
+func (m *AnchorMatcher) MatchAny(records []string) bool {
+	for _, r := range records {
+		if m.Match(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *AnchorMatcher) compare(record, expected string) bool {
+	if m.Mode == MatchPrefix {
+		return strings.HasPrefix(record, expected)
+	}
+	return record == expected
+}
+
+// decodeBase64Prefix decodes record with enc. In prefix mode only the leading
+// characters covering n bytes are decoded.
+func decodeBase64Prefix(enc *base64.Encoding, record string, n int, mode MatchMode) ([]byte, bool) {
+	if mode == MatchPrefix {
+		l := enc.EncodedLen(n)
+		if len(record) < l {
+			return nil, false
+		}
+		record = record[:l]
+	}
+	got, err := enc.DecodeString(record)
+	if err != nil {
+		return nil, false
+	}
+	return got, true
+}
@@ -0,0 +1,117 @@
+package dns
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestAnchorMatcher(t *testing.T) {
+	digest := sha256.Sum256([]byte("anchor"))
+	other := sha256.Sum256([]byte("other"))
+	expected := hex.EncodeToString(digest[:])
+	std := base64.StdEncoding.EncodeToString(digest[:])
+	rawStd := base64.RawStdEncoding.EncodeToString(digest[:])
+	url := base64.URLEncoding.EncodeToString(digest[:])
+	rawURL := base64.RawURLEncoding.EncodeToString(digest[:])
+	if !strings.ContainsAny(std, "+/") || !strings.ContainsAny(url, "-_") {
+		t.Fatal("digest does not tell the base64 alphabets apart")
+	}
+
+	tests := []struct {
+		name   string
+		record string
+		exact  bool
+		prefix bool
+	}{
+		{name: "hex", record: expected, exact: true, prefix: true},
+		{name: "upper hex", record: strings.ToUpper(expected), exact: true, prefix: true},
+		{name: "padded by spaces", record: "  " + expected + " ", exact: true, prefix: true},
+		{name: "std base64", record: std, exact: true, prefix: true},
+		{name: "raw std base64", record: rawStd, exact: true, prefix: true},
+		{name: "url base64", record: url, exact: true, prefix: true},
+		{name: "raw url base64", record: rawURL, exact: true, prefix: true},
+		{name: "hex with suffix", record: expected + ";v=1", prefix: true},
+		{name: "base64 with suffix", record: std + ";v=1", prefix: true},
+		{name: "hex prefix of the value", record: expected[:len(expected)-2]},
+		{name: "base64 prefix of the value", record: rawStd[:len(rawStd)-4]},
+		{name: "base64 with invalid suffix", record: rawStd + "*", prefix: true},
+		{name: "base64 with invalid character", record: "*" + rawStd[1:]},
+		{name: "base64 with a wrong byte", record: "A" + rawStd[1:] + "A"},
+		{name: "other digest", record: hex.EncodeToString(other[:])},
+		{name: "other digest base64", record: base64.StdEncoding.EncodeToString(other[:])},
+		{name: "suffix before the value", record: "v=1;" + expected},
+		{name: "empty", record: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewAnchorMatcher(expected, MatchExact).Match(tt.record); got != tt.exact {
+				t.Errorf("exact match = %v, want %v", got, tt.exact)
+			}
+			if got := NewAnchorMatcher(expected, MatchPrefix).Match(tt.record); got != tt.prefix {
+				t.Errorf("prefix match = %v, want %v", got, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestAnchorMatcherEmptyExpected(t *testing.T) {
+	for _, mode := range []MatchMode{MatchExact, MatchPrefix} {
+		m := NewAnchorMatcher(" ", mode)
+		if m.MatchAny([]string{"", " ", "abc"}) {
+			t.Errorf("%s: empty expected value matched", mode)
+		}
+	}
+}
+
+func TestAnchorMatcherMatchAny(t *testing.T) {
+	m := NewAnchorMatcher("abcd", MatchExact)
+	if !m.MatchAny([]string{"v=spf1 -all", "ABCD"}) {
+		t.Error("second record not matched")
+	}
+	if m.MatchAny([]string{"v=spf1 -all", "abcde"}) || m.MatchAny(nil) {
+		t.Error("no record should match")
+	}
+}
+
+func TestParseMatchMode(t *testing.T) {
+	for s, want := range map[string]MatchMode{"": MatchExact, "exact": MatchExact, " Prefix ": MatchPrefix} {
+		if got, err := ParseMatchMode(s); err != nil || got != want {
+			t.Errorf("ParseMatchMode(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseMatchMode("suffix"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
+
+func TestParseTXTData(t *testing.T) {
+	tests := []struct {
+		data, want string
+	}{
+		{`abc`, `abc`},
+		{`  abc  `, `abc`},
+		{`"abc"`, `abc`},
+		{`"abc" "def"`, `abcdef`},
+		{`"abc"   "def"  "g"`, `abcdefg`},
+		{`"a b" "c"`, `a bc`},
+		{`""`, ``},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"back\\slash"`, `back\slash`},
+		{`"\065\066C"`, `ABC`},
+		{`"\0659"`, `A9`},
+		{`"\256"`, `256`},
+		{`"\12"`, `12`},
+		{`"trailing\`, `trailing\`},
+		{`"unterminated`, `unterminated`},
+		// Unquoted data is not unescaped
+		{`\065`, `\065`},
+	}
+	for _, tt := range tests {
+		if got := parseTXTData(tt.data); got != tt.want {
+			t.Errorf("parseTXTData(%s) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
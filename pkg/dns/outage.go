@@ -0,0 +1,162 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutagePolicy decides how an anchor check ends when the DoH resolver cannot
+// be reached. It does not apply when the resolver answers without the anchor.
+type OutagePolicy int
+
+const (
+	// OutageFailClosed rejects the PTX
+	OutageFailClosed OutagePolicy = iota
+	// OutageFailOpen accepts the PTX with a warning, leaving the proof and
+	// metadata checks as the only guarantee
+	OutageFailOpen
+	// OutageAcceptIfCached accepts the PTX only if its anchor was found in
+	// DNS within the AnchorCache's MaxAge
+	OutageAcceptIfCached
+)
+
+func (p OutagePolicy) String() string {
+	switch p {
+	case OutageFailClosed:
+		return "fail-closed"
+	case OutageFailOpen:
+		return "fail-open"
+	case OutageAcceptIfCached:
+		return "accept-if-cached"
+	default:
+		return fmt.Sprintf("OutagePolicy(%d)", int(p))
+	}
+}
+
+// ParseOutagePolicy parses "fail-closed", "fail-open" or "accept-if-cached"
+func ParseOutagePolicy(s string) (OutagePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "fail-closed":
+		return OutageFailClosed, nil
+	case "fail-open", "fail-open-with-warning":
+		return OutageFailOpen, nil
+	case "accept-if-cached", "cached":
+		return OutageAcceptIfCached, nil
+	default:
+		return OutageFailClosed, fmt.Errorf("unknown DNS outage policy %q (expected fail-closed, fail-open or accept-if-cached)", s)
+	}
+}
+
+const (
+	// DefaultAnchorMaxAge is how long an anchor found in DNS is accepted
+	// from the cache during an outage
+	DefaultAnchorMaxAge = 24 * time.Hour
+	// DefaultAnchorCacheSize bounds the number of cached anchors
+	DefaultAnchorCacheSize = 100000
+)
+
+// AnchorCache remembers when anchors were last found in DNS, for
+// OutageAcceptIfCached. Entries are keyed by hostname and anchor value, so a
+// cached hostname never vouches for a different record.
+type AnchorCache struct {
+	// MaxAge defaults to DefaultAnchorMaxAge
+	MaxAge time.Duration
+	// MaxEntries defaults to DefaultAnchorCacheSize
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewAnchorCache returns a cache accepting anchors seen within maxAge
+func NewAnchorCache(maxAge time.Duration) *AnchorCache {
+	return &AnchorCache{MaxAge: maxAge}
+}
+
+var (
+	defaultAnchorCacheMu sync.Mutex
+	defaultAnchorCache   *AnchorCache
+)
+
+// DefaultAnchorCache returns the process-wide anchor cache
+func DefaultAnchorCache() *AnchorCache {
+	defaultAnchorCacheMu.Lock()
+	defer defaultAnchorCacheMu.Unlock()
+	if defaultAnchorCache == nil {
+		defaultAnchorCache = NewAnchorCache(DefaultAnchorMaxAge)
+	}
+	return defaultAnchorCache
+}
+
+// SetDefaultAnchorCache replaces the cache returned by DefaultAnchorCache
+func SetDefaultAnchorCache(c *AnchorCache) {
+	defaultAnchorCacheMu.Lock()
+	defaultAnchorCache = c
+	defaultAnchorCacheMu.Unlock()
+}
+
+// Add records that value was found at hostname
+func (c *AnchorCache) Add(hostname, value string) {
+	now := time.Now()
+	key := anchorKey(hostname, value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]time.Time{}
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries() {
+		c.evict(now)
+	}
+	c.entries[key] = now
+}
+
+// Lookup returns when value was last found at hostname, if that is within
+// MaxAge
+func (c *AnchorCache) Lookup(hostname, value string) (time.Time, bool) {
+	c.mu.Lock()
+	seen, ok := c.entries[anchorKey(hostname, value)]
+	c.mu.Unlock()
+	if !ok || time.Since(seen) > c.maxAge() {
+		return time.Time{}, false
+	}
+	return seen, true
+}
+
+// evict drops expired entries, or the oldest one if none has expired
+func (c *AnchorCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for k, seen := range c.entries {
+		if now.Sub(seen) > c.maxAge() {
+			delete(c.entries, k)
+			continue
+		}
+		if oldestKey == "" || seen.Before(oldest) {
+			oldestKey, oldest = k, seen
+		}
+	}
+	if len(c.entries) >= c.maxEntries() {
+		delete(c.entries, oldestKey)
+	}
+}
+
+func (c *AnchorCache) maxAge() time.Duration {
+	if c.MaxAge > 0 {
+		return c.MaxAge
+	}
+	return DefaultAnchorMaxAge
+}
+
+func (c *AnchorCache) maxEntries() int {
+	if c.MaxEntries > 0 {
+		return c.MaxEntries
+	}
+	return DefaultAnchorCacheSize
+}
+
+func anchorKey(hostname, value string) string {
+	return strings.ToLower(strings.TrimSuffix(hostname, ".")) + " " + strings.ToLower(value)
+}
@@ -0,0 +1,111 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PaddingBlockSize is the block size queries are padded to in privacy mode,
+// as RFC 8467 recommends for clients
+const PaddingBlockSize = 128
+
+// Privacy configures the privacy mode of a Client, which makes it harder for
+// resolver operators to correlate verifications with the tokens checked:
+//
+//   - queries are padded to a multiple of PaddingBlockSize, wire-format
+//     queries with the EDNS(0) padding option (RFC 7830, RFC 8467) and JSON
+//     queries with a random_padding parameter, so their size does not reveal
+//     the length of the hostname
+//   - each query goes to a resolver picked at random among the endpoint of
+//     the client and Endpoints, so no single operator sees every lookup
+//   - each query is delayed by a random duration up to MaxStagger, so
+//     lookups do not line up with the presentations that caused them
+type Privacy struct {
+	// Endpoints are further DoH resolvers, speaking the format of the client
+	Endpoints []string
+	// MaxStagger bounds the random delay before each query. Zero sends
+	// queries at once.
+	MaxStagger time.Duration
+}
+
+func (p *Privacy) validate() error {
+	for _, e := range p.Endpoints {
+		if u, err := url.Parse(e); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid DoH endpoint %q", e)
+		}
+	}
+	if p.MaxStagger < 0 {
+		return fmt.Errorf("negative DoH stagger %v", p.MaxStagger)
+	}
+	return nil
+}
+
+// pickEndpoint returns the resolver for the next query
+func (c *Client) pickEndpoint() string {
+	if c.privacy == nil || len(c.privacy.Endpoints) == 0 {
+		return c.endpoint
+	}
+	i := rand.IntN(len(c.privacy.Endpoints) + 1)
+	if i == 0 {
+		return c.endpoint
+	}
+	return c.privacy.Endpoints[i-1]
+}
+
+// stagger waits for the random delay of privacy mode, or until ctx is done
+func (c *Client) stagger(ctx context.Context) error {
+	if c.privacy == nil || c.privacy.MaxStagger <= 0 {
+		return nil
+	}
+	t := time.NewTimer(rand.N(c.privacy.MaxStagger))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+const (
+	typeOPT = 41
+	// optPadding is the EDNS(0) option code of RFC 7830
+	optPadding = 12
+	// optRRLen is the size of an OPT record with an empty name and one
+	// option header
+	optRRLen = 1 + 2 + 2 + 4 + 2 + 4
+	// ednsPayloadSize is the UDP payload size advertised in the OPT record
+	ednsPayloadSize = 4096
+)
+
+// padQuery appends an OPT record to a query built by encodeQuery, whose
+// padding option brings the message to a multiple of block bytes
+func padQuery(msg []byte, block int) []byte {
+	pad := (block - (len(msg)+optRRLen)%block) % block
+	binary.BigEndian.PutUint16(msg[10:], 1) // ARCOUNT
+	msg = append(msg, 0)                    // root name
+	msg = binary.BigEndian.AppendUint16(msg, typeOPT)
+	msg = binary.BigEndian.AppendUint16(msg, ednsPayloadSize)
+	msg = binary.BigEndian.AppendUint32(msg, 0) // extended RCODE, version, flags
+	msg = binary.BigEndian.AppendUint16(msg, uint16(4+pad))
+	msg = binary.BigEndian.AppendUint16(msg, optPadding)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(pad))
+	return append(msg, make([]byte, pad)...)
+}
+
+// padURL adds a random_padding parameter to a JSON API query so that the
+// length of its URL is a multiple of block. Resolvers that do not know the
+// parameter ignore it.
+func padURL(u *url.URL, block int) {
+	q := u.Query()
+	q.Set("random_padding", "")
+	u.RawQuery = q.Encode()
+	pad := (block - len(u.String())%block) % block
+	q.Set("random_padding", strings.Repeat("X", pad))
+	u.RawQuery = q.Encode()
+}
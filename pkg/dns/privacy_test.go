@@ -0,0 +1,108 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPaddedQuerySizes(t *testing.T) {
+	for _, name := range []string{
+		"a.example",
+		"ptx-0123456789abcdef.example.com",
+		strings.Repeat("x", 63) + "." + strings.Repeat("y", 63) + ".example.org",
+	} {
+		query, err := encodeQuery(name, typeTXT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		padded := padQuery(append([]byte(nil), query...), PaddingBlockSize)
+		if len(padded)%PaddingBlockSize != 0 {
+			t.Errorf("%s: padded query is %d bytes", name, len(padded))
+		}
+		if len(padded)-len(query) < optRRLen || len(padded)-len(query) >= optRRLen+PaddingBlockSize {
+			t.Errorf("%s: %d bytes of padding for a %d byte query", name, len(padded)-len(query), len(query))
+		}
+
+		// One OPT record whose padding option fills the rest
+		if binary.BigEndian.Uint16(padded[10:]) != 1 {
+			t.Fatalf("%s: ARCOUNT not set", name)
+		}
+		opt := padded[len(query):]
+		if opt[0] != 0 || binary.BigEndian.Uint16(opt[1:]) != typeOPT {
+			t.Fatalf("%s: not an OPT record: %x", name, opt[:3])
+		}
+		rdlen := int(binary.BigEndian.Uint16(opt[9:]))
+		if code, n := binary.BigEndian.Uint16(opt[11:]), int(binary.BigEndian.Uint16(opt[13:])); code != optPadding || n != rdlen-4 || len(opt) != optRRLen+n {
+			t.Errorf("%s: padding option %d of %d bytes in %d", name, code, n, len(opt))
+		}
+	}
+
+	// Hostnames of different lengths look the same on the wire
+	short, _ := encodeQuery("a.example", typeTXT)
+	long, _ := encodeQuery("ptx-0123456789abcdefghijklmnopqrstuvwxyz.example.com", typeTXT)
+	if a, b := len(padQuery(short, PaddingBlockSize)), len(padQuery(long, PaddingBlockSize)); a != b {
+		t.Errorf("padded sizes %d and %d differ", a, b)
+	}
+}
+
+func TestPaddedURL(t *testing.T) {
+	for _, name := range []string{"a.example", "ptx-0123456789abcdef.example.com"} {
+		u, _ := url.Parse(DefaultEndpoint + "?name=" + name + "&type=TXT")
+		padURL(u, PaddingBlockSize)
+		if len(u.String())%PaddingBlockSize != 0 {
+			t.Errorf("%s: padded URL is %d bytes", name, len(u.String()))
+		}
+		if u.Query().Get("name") != name {
+			t.Errorf("%s: padding changed the query: %s", name, u)
+		}
+	}
+}
+
+func TestPrivacyMode(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	handler := func(id string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			hits[id]++
+			mu.Unlock()
+			if len(body)%PaddingBlockSize != 0 {
+				t.Errorf("query of %d bytes", len(body))
+			}
+			// Echo the header and question back as an empty answer
+			resp := append([]byte(nil), body...)
+			binary.BigEndian.PutUint16(resp[2:], flagQR|flagRD)
+			w.Header().Set("Content-Type", "application/dns-message")
+			w.Write(resp)
+		}
+	}
+	a := httptest.NewServer(handler("a"))
+	defer a.Close()
+	b := httptest.NewServer(handler("b"))
+	defer b.Close()
+
+	c, err := NewClient(ClientConfig{Endpoint: a.URL, Format: FormatWire, Privacy: &Privacy{Endpoints: []string{b.URL}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 40 {
+		if _, err := c.LookupTXT(context.Background(), "ptx.example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits["a"] == 0 || hits["b"] == 0 {
+		t.Errorf("resolvers not randomized: %v", hits)
+	}
+
+	if _, err := NewClient(ClientConfig{Privacy: &Privacy{Endpoints: []string{"not a url"}}}); err == nil {
+		t.Error("accepted an invalid endpoint")
+	}
+}
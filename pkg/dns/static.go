@@ -0,0 +1,23 @@
+package dns
+
+import (
+	"context"
+	"strings"
+)
+
+// StaticResolver answers TXT queries from records fetched out of band. If
+// Hostname is set, queries for other names return no records.
+type StaticResolver struct {
+	Hostname string
+	Records  []string
+}
+
+// LookupTXT implements Resolver
+func (r StaticResolver) LookupTXT(_ context.Context, hostname string) (*TXTLookup, error) {
+	l := &TXTLookup{Timing: &Timing{}, Source: "static", Format: "records"}
+	if r.Hostname != "" && !strings.EqualFold(strings.TrimSuffix(r.Hostname, "."), strings.TrimSuffix(hostname, ".")) {
+		return l, nil
+	}
+	l.Records = append([]string(nil), r.Records...)
+	return l, nil
+}
@@ -0,0 +1,48 @@
+package dns
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseTXTData joins the character-strings of a single TXT answer.
+//
+// A TXT record is made of one or more strings of at most 255 bytes each.
+// DoH JSON resolvers present them as space separated quoted strings, e.g.
+// "abc" "def", which together form the record value "abcdef". Escapes are
+// in presentation format (\" and \DDD). Unquoted data is returned as is.
+func parseTXTData(data string) string {
+	data = strings.TrimSpace(data)
+	if !strings.HasPrefix(data, "\"") {
+		return data
+	}
+
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case !inQuote:
+			// Whitespace between character-strings
+		case c == '\\' && i+1 < len(data):
+			if i+3 < len(data) && isDigit(data[i+1]) && isDigit(data[i+2]) && isDigit(data[i+3]) {
+				if n, err := strconv.Atoi(data[i+1 : i+4]); err == nil && n <= 255 {
+					b.WriteByte(byte(n))
+					i += 3
+					continue
+				}
+			}
+			i++
+			b.WriteByte(data[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
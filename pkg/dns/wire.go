@@ -0,0 +1,208 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Minimal DNS message codec for RFC 8484 wire-format queries. Only what is
+// needed to ask a single question and read TXT answers is implemented.
+
+const (
+	typeTXT  = 16
+	classIN  = 1
+	flagRD   = 1 << 8
+	maskRC   = 0x000f
+	flagQR   = 1 << 15
+	flagTC   = 1 << 9
+	hdrLen   = 12
+	maxLabel = 63
+	maxName  = 255
+	// maxPointers bounds compression pointer chains
+	maxPointers = 32
+)
+
+var errShortMessage = errors.New("dns: message truncated")
+
+// wireAnswer is a resource record from the answer section
+type wireAnswer struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte
+}
+
+// wireResponse is a parsed DNS response
+type wireResponse struct {
+	ID      uint16
+	Rcode   int
+	Answers []wireAnswer
+}
+
+// encodeQuery builds a recursive query for name and qtype. The ID is zero as
+// recommended by RFC 8484 so responses stay cacheable.
+func encodeQuery(name string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, hdrLen, hdrLen+len(name)+6)
+	binary.BigEndian.PutUint16(msg[2:], flagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+
+	var err error
+	msg, err = appendName(msg, name)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	return msg, nil
+}
+
+func appendName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name)+2 > maxName {
+		return nil, fmt.Errorf("dns: name too long: %q", name)
+	}
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > maxLabel {
+				return nil, fmt.Errorf("dns: invalid label in %q", name)
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+	return append(msg, 0), nil
+}
+
+// decodeResponse parses a DNS response message
+func decodeResponse(msg []byte) (*wireResponse, error) {
+	if len(msg) < hdrLen {
+		return nil, errShortMessage
+	}
+
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&flagQR == 0 {
+		return nil, errors.New("dns: message is not a response")
+	}
+	if flags&flagTC != 0 {
+		return nil, errors.New("dns: response truncated by resolver")
+	}
+
+	res := &wireResponse{
+		ID:    binary.BigEndian.Uint16(msg[0:]),
+		Rcode: int(flags & maskRC),
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := hdrLen
+	for i := 0; i < qdcount; i++ {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4 // QTYPE, QCLASS
+		if off > len(msg) {
+			return nil, errShortMessage
+		}
+	}
+
+	for i := 0; i < ancount; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next
+		if off+10 > len(msg) {
+			return nil, errShortMessage
+		}
+		rr := wireAnswer{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(msg[off:]),
+			Class: binary.BigEndian.Uint16(msg[off+2:]),
+			TTL:   binary.BigEndian.Uint32(msg[off+4:]),
+		}
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, errShortMessage
+		}
+		rr.Data = msg[off : off+rdlen]
+		off += rdlen
+		res.Answers = append(res.Answers, rr)
+	}
+
+	return res, nil
+}
+
+// readName reads a possibly compressed domain name at off and returns it with
+// the offset just past its encoding in the message
+func readName(msg []byte, off int) (string, int, error) {
+	var b strings.Builder
+	end := -1
+	pointers := 0
+
+	for {
+		if off >= len(msg) {
+			return "", 0, errShortMessage
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			name := b.String()
+			if name == "" {
+				name = "."
+			}
+			return name, end, nil
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return "", 0, errShortMessage
+			}
+			if pointers++; pointers > maxPointers {
+				return "", 0, errors.New("dns: too many compression pointers")
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			// RFC 1035 4.1.4: a pointer refers to a prior occurrence
+			ptr := int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			if ptr >= off {
+				return "", 0, errors.New("dns: compression pointer does not point backwards")
+			}
+			off = ptr
+		case l&0xc0 != 0:
+			return "", 0, fmt.Errorf("dns: unsupported label type 0x%x", l&0xc0)
+		default:
+			off++
+			if off+l > len(msg) {
+				return "", 0, errShortMessage
+			}
+			if b.Len()+l+1 > maxName {
+				return "", 0, errors.New("dns: name too long")
+			}
+			b.Write(msg[off : off+l])
+			b.WriteByte('.')
+			off += l
+		}
+	}
+}
+
+// txtData joins the character-strings of TXT RDATA
+func txtData(rdata []byte) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(rdata); {
+		l := int(rdata[i])
+		i++
+		if i+l > len(rdata) {
+			return "", errShortMessage
+		}
+		b.Write(rdata[i : i+l])
+		i += l
+	}
+	return b.String(), nil
+}
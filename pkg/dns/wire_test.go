@@ -0,0 +1,237 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// wireRR is an answer record. rdlenOffset is added to its RDLENGTH.
+type wireRR struct {
+	name        []byte
+	typ, class  uint16
+	ttl         uint32
+	rdata       []byte
+	rdlenOffset int
+}
+
+// wireMessage builds a message with the given header flags, one question for
+// name and the answers
+func wireMessage(t testing.TB, flags uint16, name string, answers ...wireRR) []byte {
+	msg := make([]byte, hdrLen)
+	binary.BigEndian.PutUint16(msg[0:], 0x1234)
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(answers)))
+	msg, err := appendName(msg, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg = binary.BigEndian.AppendUint16(msg, typeTXT)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	for _, rr := range answers {
+		msg = append(msg, rr.name...)
+		msg = binary.BigEndian.AppendUint16(msg, rr.typ)
+		msg = binary.BigEndian.AppendUint16(msg, rr.class)
+		msg = binary.BigEndian.AppendUint32(msg, rr.ttl)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(rr.rdata)+rr.rdlenOffset))
+		msg = append(msg, rr.rdata...)
+	}
+	return msg
+}
+
+// txtRR is a TXT answer owned by the question name, through a compression
+// pointer
+func txtRR(strs ...string) wireRR {
+	var rdata []byte
+	for _, s := range strs {
+		rdata = append(rdata, byte(len(s)))
+		rdata = append(rdata, s...)
+	}
+	return wireRR{name: []byte{0xc0, hdrLen}, typ: typeTXT, class: classIN, ttl: 300, rdata: rdata}
+}
+
+const respFlags = flagQR | flagRD
+
+func TestDecodeResponse(t *testing.T) {
+	msg := wireMessage(t, respFlags, "_ptx.example.com", txtRR("ptx=", "abc"), txtRR(strings.Repeat("x", 255), "y"))
+	res, err := decodeResponse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ID != 0x1234 || res.Rcode != 0 || len(res.Answers) != 2 {
+		t.Fatalf("decoded %+v", res)
+	}
+	if a := res.Answers[0]; a.Name != "_ptx.example.com." || a.Type != typeTXT || a.Class != classIN || a.TTL != 300 {
+		t.Errorf("answer %+v", a)
+	}
+
+	// Character-strings of one record are joined, records are not
+	records, err := parseWireTXT(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ptx=abc", strings.Repeat("x", 255) + "y"}; !slices.Equal(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestParseWireTXTSkipsOtherRecords(t *testing.T) {
+	cname := wireRR{name: []byte{0xc0, hdrLen}, typ: 5, class: classIN, rdata: []byte{0}}
+	chaos := txtRR("chaos")
+	chaos.class = 3
+	records, err := parseWireTXT(wireMessage(t, respFlags, "example.com", cname, chaos, txtRR("in")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(records, []string{"in"}) {
+		t.Errorf("records = %q", records)
+	}
+}
+
+func TestParseWireTXTRcode(t *testing.T) {
+	msg := wireMessage(t, respFlags|3, "example.com", txtRR("ignored"))
+	res, err := decodeResponse(msg)
+	if err != nil || res.Rcode != 3 {
+		t.Fatalf("rcode %v, err %v", res, err)
+	}
+	// NXDOMAIN is no records, like a non-zero Status in the JSON API
+	if records, err := parseWireTXT(msg); err != nil || records != nil {
+		t.Errorf("records = %q, err = %v", records, err)
+	}
+}
+
+func TestDecodeResponseErrors(t *testing.T) {
+	valid := wireMessage(t, respFlags, "example.com", txtRR("abc"))
+	question := hdrLen + len("example.com") + 2 + 4
+
+	short := txtRR("abc")
+	short.rdlenOffset = 1
+	pointerTail := wireMessage(t, respFlags, "example.com")
+	binary.BigEndian.PutUint16(pointerTail[6:], 1)
+	pointerTail = append(pointerTail, 0xc0)
+
+	tests := []struct {
+		name  string
+		msg   []byte
+		short bool
+	}{
+		{name: "empty", msg: nil, short: true},
+		{name: "header", msg: valid[:hdrLen-1], short: true},
+		{name: "question name", msg: valid[:hdrLen+4], short: true},
+		{name: "question type", msg: valid[:question-1], short: true},
+		{name: "answer name", msg: valid[:question+1], short: true},
+		{name: "answer fields", msg: valid[:question+2+9], short: true},
+		{name: "rdata", msg: valid[:len(valid)-1], short: true},
+		{name: "rdlength", msg: wireMessage(t, respFlags, "example.com", short), short: true},
+		{name: "pointer byte", msg: pointerTail, short: true},
+		{name: "query", msg: wireMessage(t, flagRD, "example.com")},
+		{name: "TC", msg: wireMessage(t, respFlags|flagTC, "example.com", txtRR("abc"))},
+		{name: "self pointer", msg: wireMessage(t, respFlags, "example.com", wireRR{name: []byte{0xc0, byte(question)}})},
+		{name: "forward pointer", msg: wireMessage(t, respFlags, "example.com", wireRR{name: []byte{0xc0, byte(question + 2)}})},
+		{name: "label type", msg: wireMessage(t, respFlags, "example.com", wireRR{name: []byte{0x40, 0}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := decodeResponse(tt.msg)
+			if err == nil {
+				t.Fatalf("decoded %+v", res)
+			}
+			if tt.short != errors.Is(err, errShortMessage) {
+				t.Errorf("err = %v, truncation expected %v", err, tt.short)
+			}
+		})
+	}
+}
+
+func TestReadNamePointers(t *testing.T) {
+	// "a." at hdrLen, then a chain of pointers each to the previous one
+	chain := func(n int) ([]byte, int) {
+		msg := append(make([]byte, hdrLen), 1, 'a', 0)
+		prev := hdrLen
+		for range n {
+			next := len(msg)
+			msg = binary.BigEndian.AppendUint16(msg, 0xc000|uint16(prev))
+			prev = next
+		}
+		return msg, prev
+	}
+
+	msg, start := chain(maxPointers)
+	name, end, err := readName(msg, start)
+	if err != nil || name != "a." || end != start+2 {
+		t.Fatalf("readName = %q, %d, %v", name, end, err)
+	}
+	msg, start = chain(maxPointers + 1)
+	if _, _, err := readName(msg, start); err == nil {
+		t.Fatalf("%d chained pointers accepted", maxPointers+1)
+	}
+}
+
+func TestReadNameLength(t *testing.T) {
+	root := append(make([]byte, hdrLen), 0)
+	if name, end, err := readName(root, hdrLen); err != nil || name != "." || end != hdrLen+1 {
+		t.Errorf("root: %q, %d, %v", name, end, err)
+	}
+
+	// Four 63-byte labels spell a 256-byte name
+	msg := make([]byte, hdrLen)
+	for range 4 {
+		msg = append(msg, maxLabel)
+		msg = append(msg, strings.Repeat("x", maxLabel)...)
+	}
+	msg = append(msg, 0)
+	if _, _, err := readName(msg, hdrLen); err == nil {
+		t.Error("name over 255 bytes accepted")
+	}
+}
+
+func TestTXTData(t *testing.T) {
+	for _, tt := range []struct {
+		rdata []byte
+		want  string
+		ok    bool
+	}{
+		{rdata: nil, want: "", ok: true},
+		{rdata: []byte{0}, want: "", ok: true},
+		{rdata: []byte{2, 'a', 'b', 1, 'c'}, want: "abc", ok: true},
+		{rdata: []byte{3, 'a', 'b'}},
+		{rdata: []byte{1, 'a', 1}},
+	} {
+		got, err := txtData(tt.rdata)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("txtData(%v) = %q, %v", tt.rdata, got, err)
+		}
+	}
+}
+
+func FuzzDecodeResponse(f *testing.F) {
+	valid := wireMessage(f, respFlags, "_ptx.example.com", txtRR("ptx=", "abc"), txtRR("x"))
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add(valid[:hdrLen])
+	f.Add([]byte{})
+	f.Add(wireMessage(f, respFlags, "example.com", wireRR{name: []byte{0xc0, hdrLen + 17}}))
+	f.Add(wireMessage(f, respFlags|flagTC|2, "example.com"))
+
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		res, err := decodeResponse(msg)
+		if err != nil {
+			return
+		}
+		if len(res.Answers) > int(binary.BigEndian.Uint16(msg[6:])) {
+			t.Fatalf("%d answers decoded", len(res.Answers))
+		}
+		for _, a := range res.Answers {
+			if len(a.Name) > maxName {
+				t.Fatalf("name of %d bytes decoded", len(a.Name))
+			}
+		}
+		records, err := parseWireTXT(msg)
+		if err == nil && len(records) > len(res.Answers) {
+			t.Fatalf("%d records from %d answers", len(records), len(res.Answers))
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package dnsprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+const cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+// Cloudflare manages records through the Cloudflare v4 API using a scoped
+// API token with DNS edit permission for the zone
+type Cloudflare struct {
+	ZoneID   string
+	APIToken string
+	Client   *http.Client
+}
+
+func NewCloudflare(zoneID string, apiToken string) *Cloudflare {
+	return &Cloudflare{ZoneID: zoneID, APIToken: apiToken, Client: outbound.DefaultClient}
+}
+
+func (c *Cloudflare) Name() string {
+	return "cloudflare"
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type cloudflareResponse struct {
+	Success bool              `json:"success"`
+	Errors  []json.RawMessage `json:"errors"`
+	Result  json.RawMessage   `json:"result"`
+}
+
+func (c *Cloudflare) UpsertTXT(ctx context.Context, name string, value string, ttl int) error {
+	if c.ZoneID == "" || c.APIToken == "" {
+		return fmt.Errorf("cloudflare: zone ID and API token are required")
+	}
+
+	// Look for an existing record first so repeated runs are idempotent
+	q := url.Values{}
+	q.Set("type", "TXT")
+	q.Set("name", name)
+	var existing []cloudflareRecord
+	if err := c.do(ctx, http.MethodGet, "/zones/"+c.ZoneID+"/dns_records?"+q.Encode(), nil, &existing); err != nil {
+		return err
+	}
+	for _, r := range existing {
+		if strings.Trim(r.Content, "\"") == value {
+			return nil
+		}
+	}
+
+	record := cloudflareRecord{Type: "TXT", Name: name, Content: value, TTL: ttl}
+	return c.do(ctx, http.MethodPost, "/zones/"+c.ZoneID+"/dns_records", record, nil)
+}
+
+func (c *Cloudflare) do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cloudflareAPI+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.Client
+	if client == nil {
+		client = outbound.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var cfResp cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cfResp); err != nil {
+		return fmt.Errorf("cloudflare: invalid response (status %d): %w", resp.StatusCode, err)
+	}
+	if !cfResp.Success {
+		return fmt.Errorf("cloudflare: request failed with status %d: %s", resp.StatusCode, joinRaw(cfResp.Errors))
+	}
+	if out != nil {
+		return json.Unmarshal(cfResp.Result, out)
+	}
+	return nil
+}
+
+func joinRaw(msgs []json.RawMessage) string {
+	parts := make([]string, len(msgs))
+	for i, m := range msgs {
+		parts[i] = string(m)
+	}
+	return strings.Join(parts, "; ")
+}
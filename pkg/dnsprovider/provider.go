@@ -0,0 +1,61 @@
+package dnsprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+)
+
+// DefaultTTL is the TTL used for anchor records when none is configured
+const DefaultTTL = 300
+
+// Provider creates TXT records through a DNS provider's management API
+type Provider interface {
+	// Name returns a short identifier for the provider
+	Name() string
+	// UpsertTXT creates the TXT record name with the given value, leaving it
+	// untouched if an identical record already exists
+	UpsertTXT(ctx context.Context, name string, value string, ttl int) error
+}
+
+// WaitResult describes how long it took for a record to become visible
+type WaitResult struct {
+	Attempts int
+	Elapsed  time.Duration
+}
+
+// WaitForTXT polls DoH until a TXT record at hostname matches expected, the
+// context is cancelled, or its deadline expires
+func WaitForTXT(ctx context.Context, hostname string, expected string, interval time.Duration) (*WaitResult, error) {
+	start := time.Now()
+	res := &WaitResult{}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	matcher := dns.NewAnchorMatcher(expected, dns.MatchExact)
+	var lastErr error
+	for {
+		res.Attempts++
+		records, err := dns.GetTXT(hostname)
+		if err != nil {
+			lastErr = err
+		}
+		if matcher.MatchAny(records) {
+			res.Elapsed = time.Since(start)
+			return res, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			res.Elapsed = time.Since(start)
+			if lastErr != nil {
+				return res, fmt.Errorf("record not visible after %d attempts: %w (last lookup error: %v)", res.Attempts, ctx.Err(), lastErr)
+			}
+			return res, fmt.Errorf("record not visible after %d attempts: %w", res.Attempts, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package dnsprovider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// RFC2136 sends a dynamic update to an authoritative server by shelling out
+// to nsupdate, which handles TSIG signing
+type RFC2136 struct {
+	// Server is the authoritative server, optionally with ":port"
+	Server string
+	// Zone is the zone to update. If empty nsupdate infers it.
+	Zone string
+	// KeyFile is a TSIG key file passed to nsupdate -k
+	KeyFile string
+}
+
+func NewRFC2136(server string, zone string, keyFile string) *RFC2136 {
+	return &RFC2136{Server: server, Zone: zone, KeyFile: keyFile}
+}
+
+func (r *RFC2136) Name() string {
+	return "rfc2136"
+}
+
+func (r *RFC2136) UpsertTXT(ctx context.Context, name string, value string, ttl int) error {
+	if r.Server == "" {
+		return fmt.Errorf("rfc2136: server is required")
+	}
+	if _, err := exec.LookPath("nsupdate"); err != nil {
+		return fmt.Errorf("rfc2136: nsupdate not found in PATH")
+	}
+
+	fqdn := strings.TrimSuffix(name, ".") + "."
+	server, port := r.Server, ""
+	if i := strings.LastIndex(server, ":"); i > 0 && !strings.Contains(server[i:], "]") {
+		server, port = server[:i], server[i+1:]
+	}
+
+	var script bytes.Buffer
+	fmt.Fprintf(&script, "server %s %s\n", server, port)
+	if r.Zone != "" {
+		fmt.Fprintf(&script, "zone %s\n", r.Zone)
+	}
+	// Replace any existing values at the name with the anchor value
+	fmt.Fprintf(&script, "update delete %s TXT\n", fqdn)
+	fmt.Fprintf(&script, "update add %s %s TXT %s\n", fqdn, strconv.Itoa(ttl), strconv.Quote(value))
+	script.WriteString("send\n")
+
+	var args []string
+	if r.KeyFile != "" {
+		args = append(args, "-k", r.KeyFile)
+	}
+	cmd := exec.CommandContext(ctx, "nsupdate", args...)
+	cmd.Stdin = &script
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rfc2136: nsupdate failed: %v, output: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package dnsprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+const (
+	route53Endpoint = "https://route53.amazonaws.com"
+	route53Region   = "us-east-1"
+)
+
+// Route53 manages records in an AWS Route 53 hosted zone. Requests are signed
+// with AWS Signature Version 4 using static credentials.
+type Route53 struct {
+	HostedZoneID    string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Client          *http.Client
+}
+
+// NewRoute53FromEnv reads credentials from the standard AWS environment variables
+func NewRoute53FromEnv(hostedZoneID string) *Route53 {
+	return &Route53{
+		HostedZoneID:    strings.TrimPrefix(hostedZoneID, "/hostedzone/"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Client:          outbound.DefaultClient,
+	}
+}
+
+func (r *Route53) Name() string {
+	return "route53"
+}
+
+type route53ChangeRequest struct {
+	XMLName     xml.Name `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	ChangeBatch struct {
+		Changes []route53Change `xml:"Changes>Change"`
+	}
+}
+
+type route53Change struct {
+	Action            string
+	ResourceRecordSet struct {
+		Name            string
+		Type            string
+		TTL             int
+		ResourceRecords []route53Record `xml:"ResourceRecords>ResourceRecord"`
+	}
+}
+
+type route53Record struct {
+	Value string
+}
+
+// UpsertTXT replaces the record set at name with a single TXT value
+func (r *Route53) UpsertTXT(ctx context.Context, name string, value string, ttl int) error {
+	if r.HostedZoneID == "" || r.AccessKeyID == "" || r.SecretAccessKey == "" {
+		return fmt.Errorf("route53: hosted zone ID and AWS credentials are required")
+	}
+
+	var change route53Change
+	change.Action = "UPSERT"
+	change.ResourceRecordSet.Name = name
+	change.ResourceRecordSet.Type = "TXT"
+	change.ResourceRecordSet.TTL = ttl
+	change.ResourceRecordSet.ResourceRecords = []route53Record{{Value: `"` + value + `"`}}
+
+	var reqBody route53ChangeRequest
+	reqBody.ChangeBatch.Changes = []route53Change{change}
+
+	body, err := xml.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+
+	path := "/2013-04-01/hostedzone/" + r.HostedZoneID + "/rrset"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, route53Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	r.sign(req, body, time.Now().UTC())
+
+	client := r.Client
+	if client == nil {
+		client = outbound.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("route53: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("route53: request failed with status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+// sign adds SigV4 headers for the route53 service
+func (r *Route53) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if r.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", r.SessionToken)
+	}
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	if r.SessionToken != "" {
+		signedHeaders += ";x-amz-security-token"
+		canonicalHeaders += "x-amz-security-token:" + r.SessionToken + "\n"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + route53Region + "/route53/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+r.SecretAccessKey), date)
+	key = hmacSHA256(key, route53Region)
+	key = hmacSHA256(key, "route53")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		r.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,230 @@
+//go:build ethereum
+
+// Package ethanchor reads PTX anchors from Ethereum through a JSON-RPC
+// endpoint: ENS text records of the PTX domain, or a registry contract
+// mapping commitments to metadata hashes. It is only built with the ethereum
+// build tag.
+//
+// Only eth_call is used, against the latest block, so any node or provider
+// endpoint works and no account or signing is involved.
+package ethanchor
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+const (
+	// ENSRegistry is the address of the ENS registry on mainnet and the
+	// public testnets
+	ENSRegistry = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+	// DefaultTimeout bounds a JSON-RPC request
+	DefaultTimeout = 10 * time.Second
+	// maxResponseSize caps how much of a JSON-RPC response is read
+	maxResponseSize = 1 << 20
+)
+
+var (
+	selectorResolver = selector("resolver(bytes32)")
+	selectorText     = selector("text(bytes32,string)")
+	// selectorAnchors is the function registry contracts implement:
+	// anchors(uint256 commitment) returns (bytes32 metadataHash)
+	selectorAnchors = selector("anchors(uint256)")
+
+	// ErrNoResolver is returned for ENS names without a resolver
+	ErrNoResolver = errors.New("ENS name has no resolver")
+)
+
+var defaultHTTP = outbound.Client(DefaultTimeout)
+
+// Client calls contracts through an Ethereum JSON-RPC endpoint
+type Client struct {
+	// URL is the JSON-RPC endpoint
+	URL string
+	// HTTP defaults to a client with DefaultTimeout
+	HTTP *http.Client
+}
+
+// Text returns the text record key of the ENS name, empty when unset
+func (c *Client) Text(ctx context.Context, name, key string) (string, error) {
+	node := Namehash(name)
+	out, err := c.call(ctx, ENSRegistry, append(selectorResolver, node...))
+	if err != nil {
+		return "", err
+	}
+	if len(out) != 32 {
+		return "", errors.New("invalid ENS registry response")
+	}
+	resolver := out[12:]
+	if bytes.Equal(resolver, make([]byte, 20)) {
+		return "", ErrNoResolver
+	}
+
+	// text(bytes32 node, string key): the string is encoded after the head
+	data := append(append([]byte{}, selectorText...), node...)
+	data = append(data, word(big.NewInt(64))...)
+	data = append(data, encodeBytes([]byte(key))...)
+	out, err = c.call(ctx, "0x"+hex.EncodeToString(resolver), data)
+	if err != nil {
+		return "", err
+	}
+	return decodeString(out)
+}
+
+// Anchor returns the metadata hash a registry contract maps commitment to,
+// zero when it is not anchored
+func (c *Client) Anchor(ctx context.Context, registry string, commitment *big.Int) ([]byte, error) {
+	if commitment.Sign() < 0 || commitment.BitLen() > 256 {
+		return nil, errors.New("commitment out of range")
+	}
+	out, err := c.call(ctx, registry, append(append([]byte{}, selectorAnchors...), word(commitment)...))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != 32 {
+		return nil, errors.New("invalid registry response")
+	}
+	return out, nil
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call runs eth_call against the latest block and returns the return data
+func (c *Client) call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	if !IsAddress(to) {
+		return nil, fmt.Errorf("invalid contract address %q", to)
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "eth_call",
+		Params: []interface{}{
+			map[string]string{"to": to, "data": "0x" + hex.EncodeToString(data)},
+			"latest",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := c.HTTP
+	if client == nil {
+		client = defaultHTTP
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("JSON-RPC request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JSON-RPC request failed: HTTP %s", resp.Status)
+	}
+
+	var r rpcResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid JSON-RPC response: %w", err)
+	}
+	if r.Error != nil {
+		return nil, fmt.Errorf("eth_call failed: %s (code %d)", r.Error.Message, r.Error.Code)
+	}
+	out, err := hex.DecodeString(strings.TrimPrefix(r.Result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid eth_call result: %w", err)
+	}
+	return out, nil
+}
+
+// Namehash returns the ENS namehash of a normalized name (EIP-137)
+func Namehash(name string) []byte {
+	node := make([]byte, 32)
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = keccak(node, keccak([]byte(labels[i])))
+	}
+	return node
+}
+
+// IsAddress reports whether s is a 0x prefixed hex contract address
+func IsAddress(s string) bool {
+	h, ok := strings.CutPrefix(s, "0x")
+	if !ok || len(h) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(h)
+	return err == nil
+}
+
+func keccak(parts ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func selector(signature string) []byte {
+	return keccak([]byte(signature))[:4]
+}
+
+// word ABI encodes an unsigned integer into a 32 byte word
+func word(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, 32))
+}
+
+// encodeBytes ABI encodes the tail of a dynamic bytes or string argument
+func encodeBytes(b []byte) []byte {
+	out := word(big.NewInt(int64(len(b))))
+	padded := make([]byte, (len(b)+31)/32*32)
+	copy(padded, b)
+	return append(out, padded...)
+}
+
+// decodeString decodes a single ABI encoded string return value
+func decodeString(out []byte) (string, error) {
+	if len(out) < 64 {
+		return "", errors.New("invalid string return value")
+	}
+	offset := new(big.Int).SetBytes(out[:32])
+	if !offset.IsInt64() || offset.Int64() > int64(len(out)-32) {
+		return "", errors.New("invalid string return value")
+	}
+	start := offset.Int64()
+	n := new(big.Int).SetBytes(out[start : start+32])
+	if !n.IsInt64() || n.Int64() > int64(len(out))-start-32 {
+		return "", errors.New("invalid string return value")
+	}
+	return string(out[start+32 : start+32+n.Int64()]), nil
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBufferSize is the number of events an Emitter queues before it
+// starts dropping them
+const DefaultBufferSize = 1024
+
+// DefaultPublishTimeout bounds a single Sink.Publish call
+const DefaultPublishTimeout = 5 * time.Second
+
+// Event is the outcome of a single verification
+type Event struct {
+	Time    time.Time `json:"time"`
+	Success bool      `json:"success"`
+	// Code is the verifier error code, empty on success
+	Code   string   `json:"code,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+	// Warnings lists checks passed in a degraded mode, e.g. a DNS anchor
+	// accepted during a resolver outage
+	Warnings      []string `json:"warnings,omitempty"`
+	Domain        string   `json:"domain,omitempty"`
+	NullifierHash string   `json:"nullifierHash,omitempty"`
+	LatencyMs     float64  `json:"latencyMs"`
+	// PTXHash is the SHA-256 (hex) of the presented PTX file
+	PTXHash string `json:"ptxHash,omitempty"`
+	// Caller identifies who requested the verification, see
+	// verifier.VerificationOptions.Caller
+	Caller string `json:"caller,omitempty"`
+}
+
+// Sink delivers events to a downstream system. Implementations for message
+// brokers such as NATS or Kafka only need to publish the JSON encoding of the
+// event on their subject or topic.
+type Sink interface {
+	Publish(ctx context.Context, ev Event) error
+}
+
+// SinkFunc adapts a function to the Sink interface
+type SinkFunc func(ctx context.Context, ev Event) error
+
+func (f SinkFunc) Publish(ctx context.Context, ev Event) error {
+	return f(ctx, ev)
+}
+
+// Fanout publishes every event to each sink in turn and returns the first
+// error. All sinks are attempted.
+func Fanout(sinks ...Sink) Sink {
+	return SinkFunc(func(ctx context.Context, ev Event) error {
+		var first error
+		for _, s := range sinks {
+			if err := s.Publish(ctx, ev); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
+
+// Emitter queues events and publishes them to a Sink from a background
+// goroutine, so a slow or unavailable sink never delays verification. Events
+// are dropped when the queue is full.
+type Emitter struct {
+	sink    Sink
+	timeout time.Duration
+	queue   chan Event
+	done    chan struct{}
+	once    sync.Once
+	mu      sync.RWMutex
+	closed  bool
+
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	onError func(error)
+}
+
+// EmitterOption configures an Emitter
+type EmitterOption func(*Emitter)
+
+// WithBufferSize sets the queue length. Defaults to DefaultBufferSize.
+func WithBufferSize(n int) EmitterOption {
+	return func(e *Emitter) {
+		if n > 0 {
+			e.queue = make(chan Event, n)
+		}
+	}
+}
+
+// WithPublishTimeout bounds each Publish call. Defaults to
+// DefaultPublishTimeout.
+func WithPublishTimeout(d time.Duration) EmitterOption {
+	return func(e *Emitter) {
+		if d > 0 {
+			e.timeout = d
+		}
+	}
+}
+
+// WithErrorHandler is called with every failed Publish
+func WithErrorHandler(fn func(error)) EmitterOption {
+	return func(e *Emitter) {
+		e.onError = fn
+	}
+}
+
+// NewEmitter starts an Emitter publishing to sink. Close must be called to
+// flush queued events.
+func NewEmitter(sink Sink, opts ...EmitterOption) *Emitter {
+	e := &Emitter{
+		sink:    sink,
+		timeout: DefaultPublishTimeout,
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.queue == nil {
+		e.queue = make(chan Event, DefaultBufferSize)
+	}
+
+	go e.run()
+	return e
+}
+
+func (e *Emitter) run() {
+	defer close(e.done)
+	for ev := range e.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		err := e.sink.Publish(ctx, ev)
+		cancel()
+		if err != nil {
+			e.failed.Add(1)
+			if e.onError != nil {
+				e.onError(err)
+			}
+		}
+	}
+}
+
+// Emit queues ev without blocking. It reports false if the event was dropped
+// because the queue is full or the Emitter is closed.
+func (e *Emitter) Emit(ev Event) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		e.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case e.queue <- ev:
+		return true
+	default:
+		e.dropped.Add(1)
+		return false
+	}
+}
+
+// Close stops accepting events and waits until queued events are published
+// or ctx is done
+func (e *Emitter) Close(ctx context.Context) error {
+	e.once.Do(func() {
+		e.mu.Lock()
+		e.closed = true
+		close(e.queue)
+		e.mu.Unlock()
+	})
+
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped returns the number of events discarded because the queue was full
+func (e *Emitter) Dropped() uint64 {
+	return e.dropped.Load()
+}
+
+// Failed returns the number of events the sink failed to publish
+func (e *Emitter) Failed() uint64 {
+	return e.failed.Load()
+}
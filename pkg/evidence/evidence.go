@@ -0,0 +1,266 @@
+package evidence
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+)
+
+// Version is the bundle format version
+const Version = 1
+
+// SignatureAlgorithm is the only supported signature scheme
+const SignatureAlgorithm = "ed25519"
+
+var (
+	ErrUnsigned         = errors.New("evidence bundle is not signed")
+	ErrBadSignature     = errors.New("evidence bundle signature is invalid")
+	ErrUnsupportedAlgo  = errors.New("unsupported evidence signature algorithm")
+	ErrUnsupportedKey   = errors.New("evidence signing key must be ed25519")
+	ErrNoPEMBlock       = errors.New("no PEM block found in key file")
+	ErrUnknownBundleVer = errors.New("unknown evidence bundle version")
+	ErrNonCanonical     = errors.New("evidence bundle holds content outside its encoding")
+)
+
+// Bundle is a record of a single verification: what was checked, what the
+// network returned and what was decided. It is meant to be archived so that
+// an acceptance can be justified later.
+type Bundle struct {
+	Version    int       `json:"version"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+
+	PTX             PTX      `json:"ptx"`
+	VerificationKey *Key     `json:"verificationKey,omitempty"`
+	PublicSignals   []string `json:"publicSignals,omitempty"`
+	DNS             *DNS     `json:"dns,omitempty"`
+	Verdict         Verdict  `json:"verdict"`
+
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// PTX identifies the verified file
+type PTX struct {
+	Path   string `json:"path,omitempty"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Key identifies the verification key used for the proof
+type Key struct {
+	ID     string `json:"id,omitempty"`
+	Path   string `json:"path,omitempty"`
+	SHA256 string `json:"sha256"`
+}
+
+// DNS records the anchor lookups
+type DNS struct {
+	Hostname  string   `json:"hostname"`
+	Expected  string   `json:"expected"`
+	MatchMode string   `json:"matchMode"`
+	Lookups   []Lookup `json:"lookups"`
+}
+
+// Lookup is a single DoH exchange. Response holds the raw body as returned
+// by the resolver and is base64 encoded in JSON.
+type Lookup struct {
+	Endpoint    string    `json:"endpoint"`
+	Format      string    `json:"format"`
+	Time        time.Time `json:"time"`
+	DurationMs  float64   `json:"durationMs"`
+	ContentType string    `json:"contentType,omitempty"`
+	Response    []byte    `json:"response,omitempty"`
+	Records     []string  `json:"records,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Verdict is the final outcome of the verification
+type Verdict struct {
+	Success  bool     `json:"success"`
+	DNSValid bool     `json:"dnsValid"`
+	ZKValid  bool     `json:"zkValid"`
+	Semantic bool     `json:"semantic"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Signature is an ed25519 signature over the bundle encoded without the
+// signature field
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey []byte `json:"publicKey"`
+	Value     []byte `json:"value"`
+}
+
+// New starts a bundle timestamped now
+func New() *Bundle {
+	return &Bundle{Version: Version, StartedAt: time.Now().UTC()}
+}
+
+// SetPTX records the hash of the raw PTX file bytes
+func (b *Bundle) SetPTX(path string, data []byte) {
+	b.PTX = PTX{Path: path, Size: len(data), SHA256: Hash(data)}
+}
+
+// Hash returns the hex SHA-256 of data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// signingPayload is the canonical encoding covered by the signature. Parse
+// only accepts bundles whose content it covers in full.
+func (b *Bundle) signingPayload() ([]byte, error) {
+	unsigned := *b
+	unsigned.Signature = nil
+	return json.Marshal(&unsigned)
+}
+
+// Sign signs the bundle with key, replacing any previous signature
+func (b *Bundle) Sign(key ed25519.PrivateKey) error {
+	payload, err := b.signingPayload()
+	if err != nil {
+		return err
+	}
+	b.Signature = &Signature{
+		Algorithm: SignatureAlgorithm,
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Value:     ed25519.Sign(key, payload),
+	}
+	return nil
+}
+
+// VerifySignature checks the embedded signature. If trusted is non-nil the
+// signing key must also equal it; otherwise the embedded key is used, which
+// only proves the bundle has not been altered since it was signed.
+func (b *Bundle) VerifySignature(trusted ed25519.PublicKey) error {
+	if b.Version != Version {
+		return fmt.Errorf("%w: %d", ErrUnknownBundleVer, b.Version)
+	}
+	if b.Signature == nil {
+		return ErrUnsigned
+	}
+	if b.Signature.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlgo, b.Signature.Algorithm)
+	}
+	if len(b.Signature.PublicKey) != ed25519.PublicKeySize {
+		return ErrBadSignature
+	}
+	pub := ed25519.PublicKey(b.Signature.PublicKey)
+	if trusted != nil && !pub.Equal(trusted) {
+		return fmt.Errorf("%w: signed by an untrusted key", ErrBadSignature)
+	}
+
+	payload, err := b.signingPayload()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, payload, b.Signature.Value) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// WriteFile writes the bundle as indented JSON
+func (b *Bundle) WriteFile(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ReadFile loads a bundle written by WriteFile
+func ReadFile(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a bundle. The signature covers the bundle as re-encoded
+// after decoding, so anything decoding drops or folds (unknown fields,
+// trailing data, keys matched case-insensitively) would be unsigned yet
+// shown by other JSON readers. Such bundles are rejected with
+// ErrNonCanonical.
+func Parse(data []byte) (*Bundle, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var b Bundle
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to parse evidence bundle: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%w: trailing data", ErrNonCanonical)
+	}
+
+	encoded, err := json.Marshal(&b)
+	if err != nil {
+		return nil, err
+	}
+	var got, want any
+	if err := json.Unmarshal(data, &got); err != nil {
+		return nil, fmt.Errorf("failed to parse evidence bundle: %w", err)
+	}
+	if err := json.Unmarshal(encoded, &want); err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(got, want) {
+		return nil, ErrNonCanonical
+	}
+	return &b, nil
+}
+
+// LoadSigningKey reads a PKCS#8 PEM encoded ed25519 private key, as produced
+// by `openssl genpkey -algorithm ed25519`
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return edKey, nil
+}
+
+// LoadPublicKey reads a PKIX PEM encoded ed25519 public key
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return edKey, nil
+}
@@ -0,0 +1,193 @@
+package evidence
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testBundle() *Bundle {
+	b := New()
+	b.SetPTX("token.ptx", []byte("ptx"))
+	b.VerificationKey = &Key{ID: "sdv_poseidon_v1", SHA256: Hash([]byte("vk"))}
+	b.PublicSignals = []string{"1", "2"}
+	b.DNS = &DNS{
+		Hostname:  "abc.example.com",
+		Expected:  "abc",
+		MatchMode: "exact",
+		Lookups: []Lookup{{
+			Endpoint:   "https://dns.example/dns-query",
+			Format:     "json",
+			Time:       time.Now().UTC(),
+			DurationMs: 12.5,
+			Response:   []byte(`{"Status":0}`),
+			Records:    []string{"abc"},
+		}},
+	}
+	b.Verdict = Verdict{Success: true, DNSValid: true, ZKValid: true, Semantic: true}
+	b.FinishedAt = time.Now().UTC()
+	return b
+}
+
+func signedFile(t *testing.T) ([]byte, ed25519.PrivateKey) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := testBundle()
+	if err := b.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "evidence.json")
+	if err := b.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, key
+}
+
+func TestSignVerify(t *testing.T) {
+	data, key := signedFile(t)
+	b, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.VerifySignature(nil); err != nil {
+		t.Errorf("self-check: %v", err)
+	}
+	if err := b.VerifySignature(key.Public().(ed25519.PublicKey)); err != nil {
+		t.Errorf("trusted key: %v", err)
+	}
+
+	_, other, _ := ed25519.GenerateKey(nil)
+	if err := b.VerifySignature(other.Public().(ed25519.PublicKey)); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("untrusted key: err = %v", err)
+	}
+
+	unsigned := testBundle()
+	if err := unsigned.VerifySignature(nil); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("unsigned: err = %v", err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	data, _ := signedFile(t)
+	tests := []struct {
+		name   string
+		tamper func(b *Bundle)
+		err    error
+	}{
+		{name: "verdict", tamper: func(b *Bundle) { b.Verdict.Success = false }, err: ErrBadSignature},
+		{name: "records", tamper: func(b *Bundle) { b.DNS.Lookups[0].Records = []string{"forged"} }, err: ErrBadSignature},
+		{name: "ptx hash", tamper: func(b *Bundle) { b.PTX.SHA256 = Hash([]byte("other")) }, err: ErrBadSignature},
+		{name: "signature", tamper: func(b *Bundle) { b.Signature.Value[0] ^= 1 }, err: ErrBadSignature},
+		{name: "key size", tamper: func(b *Bundle) { b.Signature.PublicKey = b.Signature.PublicKey[1:] }, err: ErrBadSignature},
+		{name: "algorithm", tamper: func(b *Bundle) { b.Signature.Algorithm = "rsa" }, err: ErrUnsupportedAlgo},
+		{name: "version", tamper: func(b *Bundle) { b.Version = 2 }, err: ErrUnknownBundleVer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(b)
+			if err := b.VerifySignature(nil); !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestParseRejectsUnsignedContent adds content to a signed file that decoding
+// would drop, so the signature would not cover it
+func TestParseRejectsUnsignedContent(t *testing.T) {
+	data, _ := signedFile(t)
+	insert := func(field string) []byte {
+		return bytes.Replace(data, []byte("{\n"), []byte("{\n  "+field+",\n"), 1)
+	}
+
+	tests := map[string][]byte{
+		"unknown field":        insert(`"note": "accepted by hand"`),
+		"unknown nested field": bytes.Replace(data, []byte(`"success": true`), []byte(`"success": true, "override": true`), 1),
+		"folded key":           insert(`"VERDICT": {"success": false}`),
+		"trailing data":        append(append([]byte{}, data...), []byte(`{"version": 1}`)...),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if !json.Valid(data) && name != "trailing data" {
+				t.Fatalf("test input is not JSON: %s", data)
+			}
+			if _, err := Parse(data); err == nil {
+				t.Error("parsed")
+			}
+		})
+	}
+
+	// Re-indenting does not change the content
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse(compact.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.VerifySignature(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	write := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSigningKey(write("key.pem", "PRIVATE KEY", privDER))
+	if err != nil || !loaded.Equal(key) {
+		t.Fatalf("LoadSigningKey = %v", err)
+	}
+	loadedPub, err := LoadPublicKey(write("pub.pem", "PUBLIC KEY", pubDER))
+	if err != nil || !loadedPub.Equal(pub) {
+		t.Fatalf("LoadPublicKey = %v", err)
+	}
+
+	notPEM := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(notPEM, []byte(strings.Repeat("x", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSigningKey(notPEM); !errors.Is(err, ErrNoPEMBlock) {
+		t.Errorf("LoadSigningKey(not PEM) = %v", err)
+	}
+	if _, err := LoadPublicKey(notPEM); !errors.Is(err, ErrNoPEMBlock) {
+		t.Errorf("LoadPublicKey(not PEM) = %v", err)
+	}
+}
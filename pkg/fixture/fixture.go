@@ -0,0 +1,365 @@
+// Package fixture generates valid PTX files, matching verification keys and
+// DNS answers from a fixed seed, for integration tests that should not run a
+// real trusted setup or shell out to snarkjs.
+//
+// The keys come from a setup whose toxic waste is derived from the seed, so
+// anyone can forge proofs under them. They must never be trusted outside of
+// tests.
+package fixture
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	mrand "math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/convert"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Style selects the proof_data encoding of the generated PTX
+type Style string
+
+const (
+	// StyleNative is the "gnark_native" wrapper accepted by the verifier
+	StyleNative Style = "native"
+	// StyleSnarkJS is the legacy {proof, publicSignals} wrapper written by
+	// the snarkjs pipeline, with a matching verification_key.json
+	StyleSnarkJS Style = "snarkjs"
+)
+
+// IssuedAt is the default issued_at of generated PTX files, fixed so output
+// is byte-stable for a given seed
+var IssuedAt = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// File names written by WriteDir
+const (
+	PTXFile      = "token.ptx"
+	NativeVKFile = "native.vk"
+	CircomVKFile = "verification_key.json"
+	DNSFile      = "dns.json"
+	InputsFile   = "inputs.json"
+)
+
+// Options configures Generate. Zero values select the defaults.
+type Options struct {
+	Seed  int64
+	Style Style
+	// Domain defaults to "example.com"
+	Domain string
+	// Metadata defaults to {"role": "validator"}
+	Metadata map[string]interface{}
+	// TrustMethod defaults to DOH (1)
+	TrustMethod int
+	// IssuedAt defaults to the package IssuedAt
+	IssuedAt time.Time
+}
+
+// Fixture is a generated PTX with everything needed to verify it offline
+type Fixture struct {
+	Style Style
+	PTX   []byte
+	// VK is the native gnark verification key
+	VK []byte
+	// CircomVK is the snarkjs verification_key.json, set for StyleSnarkJS
+	CircomVK []byte
+	Inputs   *prover.CircuitInputs
+	Anchor   *utils.AnchorRecord
+}
+
+// Resolver answers the anchor lookup of the fixture
+func (f *Fixture) Resolver() dns.StaticResolver {
+	return dns.StaticResolver{Hostname: f.Anchor.Hostname, Records: []string{f.Anchor.Value}}
+}
+
+// DNSAnswers maps the anchor hostname to its TXT records
+func (f *Fixture) DNSAnswers() map[string][]string {
+	return map[string][]string{f.Anchor.Hostname: {f.Anchor.Value}}
+}
+
+// Generate builds a fixture. The same options always produce the same bytes.
+func Generate(opts Options) (*Fixture, error) {
+	opts = opts.withDefaults()
+	if opts.Style != StyleNative && opts.Style != StyleSnarkJS {
+		return nil, fmt.Errorf("unknown fixture style %q", opts.Style)
+	}
+
+	stream := newStream(opts.Seed)
+	nullifier, secret := stream.element(), stream.element()
+
+	p := prover.NewProver()
+	inputs, err := p.GenerateCircuitInputs(opts.Domain, opts.Metadata, nullifier, secret, opts.TrustMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := setup(opts.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment := inputs.Assignment()
+	w, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, fmt.Errorf("witness creation failed: %w", err)
+	}
+	var proof groth16.Proof
+	err = withSeededRand(stream, func() error {
+		var err error
+		proof, err = groth16.Prove(keys.ccs, keys.pk, w)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("proving failed: %w", err)
+	}
+
+	f := &Fixture{Style: opts.Style, VK: keys.vk, Inputs: inputs}
+
+	var proofData []byte
+	switch opts.Style {
+	case StyleNative:
+		proofData, err = prover.NativeProofData(proof, inputs)
+	case StyleSnarkJS:
+		proofData, f.CircomVK, err = snarkJSProofData(proof, keys.parsedVK, inputs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.PTX, err = buildPTX(p, proofData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Anchor, err = utils.DeriveAnchorRecord(inputs.Commitment, opts.Domain, mustMarshal(opts.Metadata), utils.FormatHostnameVersion(ptxloader.FormatVersion))
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// WriteDir writes the fixture files into dir, creating it if needed
+func (f *Fixture) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	dnsJSON, err := json.MarshalIndent(f.DNSAnswers(), "", "  ")
+	if err != nil {
+		return err
+	}
+	inputsJSON, err := json.MarshalIndent(f.Inputs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		PTXFile:      f.PTX,
+		NativeVKFile: f.VK,
+		DNSFile:      dnsJSON,
+		InputsFile:   inputsJSON,
+	}
+	if f.CircomVK != nil {
+		files[CircomVKFile] = f.CircomVK
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o Options) withDefaults() Options {
+	if o.Style == "" {
+		o.Style = StyleNative
+	}
+	if o.Domain == "" {
+		o.Domain = "example.com"
+	}
+	if o.Metadata == nil {
+		o.Metadata = map[string]interface{}{"role": "validator"}
+	}
+	if o.TrustMethod == 0 {
+		o.TrustMethod = 1
+	}
+	if o.IssuedAt.IsZero() {
+		o.IssuedAt = IssuedAt
+	}
+	return o
+}
+
+// buildPTX wraps proofData in a PTX file with a fixed issued_at
+func buildPTX(p *prover.Prover, proofData []byte, opts Options) ([]byte, error) {
+	data, err := p.CreatePtxFile(proofData, opts.Metadata, opts.Domain, opts.TrustMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	ptxFile, err := ptxloader.ParsePTX(data, ptxloader.LoadOptions{})
+	if err != nil {
+		return nil, err
+	}
+	ptxFile.IssuedAt = timestamppb.New(opts.IssuedAt)
+
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(ptxFile)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, data[:5]...), payload...), nil
+}
+
+func snarkJSProofData(proof groth16.Proof, vk groth16.VerifyingKey, inputs *prover.CircuitInputs) ([]byte, []byte, error) {
+	cp, err := convert.ProofToSnarkJS(proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	cvk, err := convert.VerifyingKeyToSnarkJS(vk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proofData, err := json.Marshal(struct {
+		PublicSignals []string    `json:"publicSignals"`
+		Proof         interface{} `json:"proof"`
+	}{inputs.PublicSignals(), cp})
+	if err != nil {
+		return nil, nil, err
+	}
+	vkJSON, err := json.MarshalIndent(cvk, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return proofData, vkJSON, nil
+}
+
+func mustMarshal(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+type keyPair struct {
+	ccs      constraint.ConstraintSystem
+	pk       groth16.ProvingKey
+	vk       []byte
+	parsedVK groth16.VerifyingKey
+}
+
+var (
+	ccsOnce sync.Once
+	ccs     constraint.ConstraintSystem
+	ccsErr  error
+
+	// keys caches setups by seed; a setup takes about a second
+	keysMu sync.Mutex
+	keys   = map[int64]*keyPair{}
+)
+
+// Keys returns the compiled circuit and the key pair Generate uses for seed,
+// for tests proving their own inputs under reproducible keys
+func Keys(seed int64) (constraint.ConstraintSystem, groth16.ProvingKey, groth16.VerifyingKey, error) {
+	kp, err := setup(seed)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return kp.ccs, kp.pk, kp.parsedVK, nil
+}
+
+// setup returns the key pair derived from seed
+func setup(seed int64) (*keyPair, error) {
+	ccsOnce.Do(func() {
+		var c circuit.DoHCircuit
+		ccs, ccsErr = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &c)
+	})
+	if ccsErr != nil {
+		return nil, fmt.Errorf("circuit compilation failed: %w", ccsErr)
+	}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if kp, ok := keys[seed]; ok {
+		return kp, nil
+	}
+
+	// The setup stream is independent of the proving stream so that proofs
+	// do not depend on whether the keys were cached
+	var pk groth16.ProvingKey
+	var vk groth16.VerifyingKey
+	err := withSeededRand(newStream(^seed), func() error {
+		var err error
+		pk, vk, err = groth16.Setup(ccs)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup failed: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	kp := &keyPair{ccs: ccs, pk: pk, vk: buf.Bytes(), parsedVK: vk}
+	keys[seed] = kp
+	return kp, nil
+}
+
+// stream is a ChaCha8 byte stream keyed by a seed
+type stream struct {
+	*mrand.ChaCha8
+}
+
+func newStream(seed int64) stream {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(seed))
+	return stream{mrand.NewChaCha8(sha256.Sum256(append([]byte("ptx-fixture"), b[:]...)))}
+}
+
+// element returns a uniformly random field element in decimal
+func (s stream) element() string {
+	var buf [32]byte
+	s.Read(buf[:])
+	var e fr.Element
+	e.SetBigInt(new(big.Int).SetBytes(buf[:]))
+	return e.String()
+}
+
+// randMu serializes swaps of crypto/rand.Reader
+var randMu sync.Mutex
+
+// withSeededRand runs fn with crypto/rand.Reader replaced by s. gnark samples
+// setup and proving randomness from crypto/rand without a way to inject a
+// source, so this is the only way to make its output reproducible. Other
+// goroutines reading crypto/rand meanwhile also get the seeded stream, which
+// is acceptable only because fixtures are test-only.
+func withSeededRand(s stream, fn func() error) error {
+	randMu.Lock()
+	defer randMu.Unlock()
+
+	orig := rand.Reader
+	rand.Reader = s
+	defer func() { rand.Reader = orig }()
+	return fn()
+}
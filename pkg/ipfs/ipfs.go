@@ -0,0 +1,115 @@
+// Package ipfs addresses and fetches PTX anchor documents on IPFS. Documents
+// are single blocks addressed by a CIDv1 with the raw codec and a sha2-256
+// multihash, the CID `ipfs add --cid-version 1` gives a small file, so the
+// prover can compute it offline and the verifier can check what a gateway
+// returns without trusting it.
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+const (
+	// DefaultGateway is the public gateway used when none is configured
+	DefaultGateway = "https://ipfs.io"
+	// DefaultTimeout bounds a gateway request
+	DefaultTimeout = 10 * time.Second
+	// maxBlockSize caps a fetched block, the largest block gateways serve
+	maxBlockSize = 1 << 20
+)
+
+// CIDv1 header of a raw block with a 32 byte sha2-256 multihash
+var rawSHA256Prefix = []byte{0x01, 0x55, 0x12, 0x20}
+
+var (
+	lowerBase32 = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+	ErrUnsupportedCID = errors.New("unsupported CID (want a base32 CIDv1 with the raw codec and a sha2-256 multihash)")
+)
+
+var defaultHTTP = outbound.Client(DefaultTimeout)
+
+// CID returns the CIDv1 addressing data as a raw block
+func CID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "b" + lowerBase32.EncodeToString(append(append([]byte{}, rawSHA256Prefix...), sum[:]...))
+}
+
+// Digest returns the SHA-256 digest a CID addresses
+func Digest(cid string) ([]byte, error) {
+	enc, ok := strings.CutPrefix(cid, "b")
+	if !ok {
+		return nil, ErrUnsupportedCID
+	}
+	raw, err := lowerBase32.DecodeString(enc)
+	if err != nil || len(raw) != len(rawSHA256Prefix)+sha256.Size || !bytes.HasPrefix(raw, rawSHA256Prefix) {
+		return nil, ErrUnsupportedCID
+	}
+	return raw[len(rawSHA256Prefix):], nil
+}
+
+// Gateway fetches blocks from an IPFS HTTP gateway
+type Gateway struct {
+	// URL is the gateway root, e.g. https://ipfs.io. Empty means
+	// DefaultGateway.
+	URL string
+	// HTTP defaults to a client with DefaultTimeout
+	HTTP *http.Client
+}
+
+// URLFor returns the gateway URL of the block addressed by cid
+func (g *Gateway) URLFor(cid string) string {
+	root := g.URL
+	if root == "" {
+		root = DefaultGateway
+	}
+	return strings.TrimRight(root, "/") + "/ipfs/" + cid + "?format=raw"
+}
+
+// Fetch returns the block addressed by cid. Its content is checked against
+// the CID, so the gateway need not be trusted.
+func (g *Gateway) Fetch(ctx context.Context, cid string) ([]byte, error) {
+	digest, err := Digest(cid)
+	if err != nil {
+		return nil, err
+	}
+	client := g.HTTP
+	if client == nil {
+		client = defaultHTTP
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.URLFor(cid), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.ipld.raw")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBlockSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBlockSize {
+		return nil, errors.New("block larger than 1 MiB")
+	}
+	if sum := sha256.Sum256(data); !bytes.Equal(sum[:], digest) {
+		return nil, errors.New("gateway returned content that does not match the CID")
+	}
+	return data, nil
+}
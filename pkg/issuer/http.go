@@ -0,0 +1,156 @@
+package issuer
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/proto"
+)
+
+// IssuePath is where Handler accepts issuance requests
+const IssuePath = "/v1/issue"
+
+// ContentType is the media type of issuance requests and responses, the
+// binary protobuf encoding
+const ContentType = "application/x-protobuf"
+
+// MaxRequestSize bounds the body of an issuance request
+const MaxRequestSize = 128 << 10
+
+// Error codes of rejected requests
+const (
+	CodeBadRequest   = "bad_request"
+	CodeUnauthorized = "unauthorized"
+	CodeRejected     = "rejected"
+	CodeInternal     = "internal_error"
+)
+
+// ErrorBody is returned with every non-200 response
+type ErrorBody struct {
+	Error Error `json:"error"`
+}
+
+// Error describes a request that was not approved
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Handler serves POST IssuePath. When token is set, requests must carry it
+// as a bearer token.
+func (i *Issuer) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+IssuePath, func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				writeError(w, http.StatusUnauthorized, Error{Code: CodeUnauthorized, Message: "missing or invalid bearer token"})
+				return
+			}
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxRequestSize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: "failed to read request: " + err.Error()})
+			return
+		}
+		var req ptx.IssuanceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: "invalid issuance request: " + err.Error()})
+			return
+		}
+
+		resp, err := i.Issue(r.Context(), &req)
+		var policyErr *PolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			writeError(w, http.StatusForbidden, Error{Code: CodeRejected, Message: policyErr.Reason})
+			return
+		case errors.Is(err, ErrPublish):
+			writeError(w, http.StatusBadGateway, Error{Code: CodeInternal, Message: err.Error()})
+			return
+		case err != nil:
+			writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: err.Error()})
+			return
+		}
+		out, err := proto.Marshal(resp)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, Error{Code: CodeInternal, Message: err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(out)
+	})
+	return mux
+}
+
+func writeError(w http.ResponseWriter, status int, e Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorBody{Error: e})
+}
+
+// Client sends issuance requests to an issuer
+type Client struct {
+	// URL is the base URL of the issuer, e.g. "https://issuer.example.com"
+	URL string
+	// Token, when set, is sent as a bearer token
+	Token string
+	// HTTPClient defaults to outbound.DefaultClient
+	HTTPClient *http.Client
+}
+
+// Request sends req to the issuer. Requests the issuer does not approve fail
+// with an *Error.
+func (c *Client) Request(ctx context.Context, req *ptx.IssuanceRequest) (*ptx.IssuanceResponse, error) {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+IssuePath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", ContentType)
+	if c.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = outbound.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, MaxRequestSize))
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		var e ErrorBody
+		if json.Unmarshal(data, &e) == nil && e.Error.Code != "" {
+			return nil, &e.Error
+		}
+		return nil, fmt.Errorf("issuer returned %s", httpResp.Status)
+	}
+	var resp ptx.IssuanceResponse
+	if err := proto.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("invalid issuance response: %w", err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,185 @@
+// Package issuer splits issuance like a certificate signing request: a
+// holder computes the commitment of the token it wants over the requested
+// metadata and sends it in a ptx.IssuanceRequest, without its nullifier or
+// secret. The issuer, owning the anchor domain, checks the request against
+// its Policy and anchors the commitment, by signing it with its anchor key
+// (LABEL_FIXED) or publishing the DNS record (LABEL_COMMITMENT). The holder
+// then proves and assembles the PTX itself.
+//
+// The proof binds the commitment to the metadata, so an anchor made for a
+// request only validates a PTX with the approved metadata: the issuer needs
+// no proof to trust the request.
+package issuer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dnsprovider"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrPublish wraps the failure to publish the anchor record of an approved
+// request
+var ErrPublish = errors.New("failed to publish anchor")
+
+// NewRequest computes the issuance request of the token the holder of
+// nullifier and secret will prove with the circuit of keyID
+func NewRequest(keyID, domain string, metadata map[string]interface{}, nullifier, secret string, trustMethod int) (*ptx.IssuanceRequest, error) {
+	p := &prover.Prover{KeyID: keyID}
+	inputs, err := p.GenerateCircuitInputs(domain, metadata, nullifier, secret, trustMethod)
+	if err != nil {
+		return nil, err
+	}
+	metaBytes, err := crypto.MarshalCanonical(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	domain, err = crypto.NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	return &ptx.IssuanceRequest{
+		DomainName:        domain,
+		TrustMethod:       ptx.TrustMethod(trustMethod),
+		VerificationKeyId: keyID,
+		Metadata:          string(metaBytes),
+		Commitment:        inputs.Commitment,
+		NullifierHash:     inputs.NullifierHash,
+	}, nil
+}
+
+// Issuer approves issuance requests for the domains of its policy
+type Issuer struct {
+	Policy *Policy
+	// AnchorKey, when set, anchors tokens under the fixed label _ptx.<domain>,
+	// which must publish its public key: the issuer signs each commitment
+	// and publishes nothing per token
+	AnchorKey ed25519.PrivateKey
+	// Publisher publishes the commitment label anchor records of DOH tokens
+	// when there is no AnchorKey. Without it, the records are published out
+	// of band and responses say so.
+	Publisher dnsprovider.Provider
+	TTL       int
+}
+
+// Issue checks req against the policy and anchors its commitment. Requests
+// the policy rejects fail with a *PolicyError.
+func (i *Issuer) Issue(ctx context.Context, req *ptx.IssuanceRequest) (*ptx.IssuanceResponse, error) {
+	domain, err := crypto.NormalizeDomain(req.DomainName)
+	if err != nil {
+		return nil, err
+	}
+	if req.TrustMethod == ptx.TrustMethod_METHOD_UNSPECIFIED || !req.TrustMethod.IsValid() {
+		return nil, fmt.Errorf("invalid trust method %d", req.TrustMethod)
+	}
+	for _, s := range []struct{ name, value string }{{"commitment", req.Commitment}, {"nullifier hash", req.NullifierHash}} {
+		if _, err := crypto.ParseFr(s.value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", s.name, err)
+		}
+	}
+	if len(req.Metadata) > prover.MaxMetadataSize {
+		return nil, fmt.Errorf("metadata is %d bytes, the limit is %d", len(req.Metadata), prover.MaxMetadataSize)
+	}
+	// The anchor value hashes the metadata bytes, so they must already be
+	// in the canonical form the holder proves over
+	canonical, err := crypto.CanonicalJSON([]byte(req.Metadata))
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	if string(canonical) != req.Metadata {
+		return nil, errors.New("metadata is not in canonical JSON form")
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(canonical, &metadata); err != nil {
+		return nil, fmt.Errorf("metadata must be a JSON object: %w", err)
+	}
+	// The policy is written against the current schema
+	if _, err := metaschema.Normalize(metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	now := time.Now()
+	if err := i.Policy.check(req, domain, metadata, now); err != nil {
+		return nil, err
+	}
+
+	resp := &ptx.IssuanceResponse{
+		SignedMetadata: req.Metadata,
+		IssuedAt:       timestamppb.New(now),
+		FormatVersion:  ptxloader.FormatVersion,
+	}
+	if i.AnchorKey != nil {
+		payload, err := utils.AnchorSigningPayload(req.Commitment, domain, req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		resp.LabelMode = ptx.LabelMode_LABEL_FIXED
+		resp.AnchorSignature = ed25519.Sign(i.AnchorKey, payload)
+		return resp, nil
+	}
+	if i.Publisher != nil && req.TrustMethod == ptx.TrustMethod_DOH {
+		record, err := utils.DeriveAnchorRecord(req.Commitment, domain, req.Metadata, utils.FormatHostnameVersion(resp.FormatVersion))
+		if err != nil {
+			return nil, err
+		}
+		if err := i.Publisher.UpsertTXT(ctx, record.Hostname, record.Value, i.TTL); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrPublish, err)
+		}
+		resp.AnchorPublished = true
+	}
+	return resp, nil
+}
+
+// Assemble proves the token of req, approved by resp, with the holder's
+// nullifier and secret and returns the PTX file. backend defaults to
+// prover.NewNative().
+func Assemble(ctx context.Context, req *ptx.IssuanceRequest, resp *ptx.IssuanceResponse, nullifier, secret string, backend prover.ProverBackend) ([]byte, error) {
+	if resp.SignedMetadata != req.Metadata {
+		return nil, errors.New("the issuer approved other metadata than requested")
+	}
+	// Numbers are kept exact so the metadata re-encodes to the same bytes
+	dec := json.NewDecoder(strings.NewReader(resp.SignedMetadata))
+	dec.UseNumber()
+	var metadata map[string]interface{}
+	if err := dec.Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("invalid signed metadata: %w", err)
+	}
+
+	// The token must use the label the issuer published under
+	p := &prover.Prover{KeyID: req.VerificationKeyId, FormatVersion: max(resp.FormatVersion, 1)}
+	inputs, err := p.GenerateCircuitInputs(req.DomainName, metadata, nullifier, secret, int(req.TrustMethod))
+	if err != nil {
+		return nil, err
+	}
+	if inputs.Commitment != req.Commitment {
+		return nil, errors.New("nullifier and secret do not match the commitment of the request")
+	}
+	if resp.LabelMode == ptx.LabelMode_LABEL_FIXED {
+		if len(resp.AnchorSignature) != ed25519.SignatureSize {
+			return nil, errors.New("issuance response has no anchor signature")
+		}
+		p.FixedLabels = []ptx.TrustMethod{ptx.TrustMethod_DOH}
+		p.AnchorSignature = resp.AnchorSignature
+	}
+
+	if backend == nil {
+		backend = prover.NewNative()
+	}
+	proofData, err := backend.Prove(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("proof generation failed: %w", err)
+	}
+	return p.CreatePtxFile(proofData, metadata, req.DomainName, int(req.TrustMethod))
+}
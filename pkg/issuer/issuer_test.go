@@ -0,0 +1,124 @@
+package issuer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// keyBackend proves with the fixture keys
+type keyBackend struct {
+	ccs constraint.ConstraintSystem
+	pk  groth16.ProvingKey
+}
+
+func (b keyBackend) Name() string { return "fixture" }
+
+func (b keyBackend) Prove(ctx context.Context, inputs *prover.CircuitInputs) ([]byte, error) {
+	return prover.NewProver().ProveWithKey(b.ccs, b.pk, inputs)
+}
+
+func testIssuer(t *testing.T) (*Issuer, ed25519.PublicKey) {
+	policy := &Policy{
+		Domains:       []string{"Example.com."},
+		Claims:        map[string]interface{}{"issuer": "acme"},
+		AllowedClaims: []string{"scopes"},
+		Scopes:        []string{"read"},
+		MaxLifetime:   "24h",
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	return &Issuer{Policy: policy, AnchorKey: key}, pub
+}
+
+func TestIssueAndAssemble(t *testing.T) {
+	iss, pub := testIssuer(t)
+	srv := httptest.NewServer(iss.Handler("s3cret"))
+	defer srv.Close()
+
+	metadata := map[string]interface{}{
+		"issuer":               "acme",
+		"scopes":               []interface{}{"read"},
+		"expiration_timestamp": time.Now().Add(time.Hour).Unix(),
+	}
+	req, err := NewRequest("", "example.com", metadata, "11", "22", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&Client{URL: srv.URL}).Request(context.Background(), req); err == nil {
+		t.Fatal("request without the bearer token approved")
+	}
+	client := &Client{URL: srv.URL, Token: "s3cret"}
+	resp, err := client.Request(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ccs, pk, vk, err := fixture.Keys(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Assemble(context.Background(), req, resp, "11", "23", keyBackend{ccs, pk}); err == nil {
+		t.Fatal("assembled with the wrong secret")
+	}
+	data, err := Assemble(context.Background(), req, resp, "11", "22", keyBackend{ccs, pk})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vkData bytes.Buffer
+	if _, err := vk.WriteTo(&vkData); err != nil {
+		t.Fatal(err)
+	}
+	record, err := utils.FixedAnchorRecord("example.com", pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := verifier.VerifyBytes(data, vkData.Bytes(), verifier.BytesOptions{TXTRecords: []string{record.Value}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("assembled PTX rejected: %v", result.Errors)
+	}
+}
+
+func TestIssuePolicy(t *testing.T) {
+	iss, _ := testIssuer(t)
+	exp := time.Now().Add(time.Hour).Unix()
+	for name, tc := range map[string]struct {
+		domain   string
+		metadata map[string]interface{}
+	}{
+		"other domain":   {"example.org", map[string]interface{}{"issuer": "acme", "expiration_timestamp": exp}},
+		"missing claim":  {"example.com", map[string]interface{}{"expiration_timestamp": exp}},
+		"unknown claim":  {"example.com", map[string]interface{}{"issuer": "acme", "role": "admin", "expiration_timestamp": exp}},
+		"unknown scope":  {"example.com", map[string]interface{}{"issuer": "acme", "scopes": []interface{}{"write"}, "expiration_timestamp": exp}},
+		"no expiration":  {"example.com", map[string]interface{}{"issuer": "acme"}},
+		"too long-lived": {"example.com", map[string]interface{}{"issuer": "acme", "expiration_timestamp": time.Now().Add(48 * time.Hour).Unix()}},
+	} {
+		req, err := NewRequest("", tc.domain, tc.metadata, "11", "22", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var policyErr *PolicyError
+		if _, err := iss.Issue(context.Background(), req); !errors.As(err, &policyErr) {
+			t.Errorf("%s: err = %v, want a policy rejection", name, err)
+		}
+	}
+}
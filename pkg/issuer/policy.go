@@ -0,0 +1,181 @@
+package issuer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// Policy decides which issuance requests an issuer approves. It is loaded
+// from JSON:
+//
+//	{
+//	  "domains": ["example.com"],
+//	  "claims": {"issuer": "acme"},
+//	  "allowedClaims": ["audience", "scopes", "nonce"],
+//	  "scopes": ["read", "write"],
+//	  "maxLifetime": "720h"
+//	}
+type Policy struct {
+	// Domains lists the domains the issuer anchors tokens on
+	Domains []string `json:"domains"`
+	// TrustMethods lists the accepted trust methods by name, only DOH when
+	// empty
+	TrustMethods []string `json:"trustMethods,omitempty"`
+	// KeyIDs lists the accepted verification key IDs, any registered one
+	// when empty
+	KeyIDs []string `json:"keyIds,omitempty"`
+	// Claims maps metadata claims to the exact value they must carry
+	Claims map[string]interface{} `json:"claims,omitempty"`
+	// AllowedClaims lists the other claims a request may carry, besides
+	// "expiration_timestamp" and "meta_version". Empty allows any claim.
+	AllowedClaims []string `json:"allowedClaims,omitempty"`
+	// Scopes lists the values the "scopes" claim may contain
+	Scopes []string `json:"scopes,omitempty"`
+	// MaxLifetime, a Go duration, requires an "expiration_timestamp" no
+	// further away than this
+	MaxLifetime string `json:"maxLifetime,omitempty"`
+
+	maxLifetime time.Duration
+}
+
+// LoadPolicy reads a policy from a JSON file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse issuer policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate normalizes the domains and checks the other rules
+func (p *Policy) Validate() error {
+	if len(p.Domains) == 0 {
+		return errors.New("issuer policy: no domains")
+	}
+	for i, d := range p.Domains {
+		name, err := crypto.NormalizeDomain(d)
+		if err != nil {
+			return fmt.Errorf("issuer policy: %w", err)
+		}
+		p.Domains[i] = name
+	}
+	for _, name := range p.TrustMethods {
+		if _, err := ptx.ParseTrustMethod(name); err != nil {
+			return fmt.Errorf("issuer policy: %w", err)
+		}
+	}
+	for _, id := range p.KeyIDs {
+		if !slices.Contains(signals.KeyIDs(), id) {
+			return fmt.Errorf("issuer policy: unknown verification key ID %q", id)
+		}
+	}
+	if p.MaxLifetime != "" {
+		d, err := time.ParseDuration(p.MaxLifetime)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("issuer policy: invalid maxLifetime %q", p.MaxLifetime)
+		}
+		p.maxLifetime = d
+	}
+	return nil
+}
+
+// PolicyError is a request the policy rejects
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return "request rejected: " + e.Reason
+}
+
+func reject(format string, args ...interface{}) error {
+	return &PolicyError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// check applies the policy to a request for domain with the decoded metadata
+func (p *Policy) check(req *ptx.IssuanceRequest, domain string, metadata map[string]interface{}, now time.Time) error {
+	if !slices.Contains(p.Domains, domain) {
+		return reject("domain %q is not issued for", domain)
+	}
+	if !p.allowsTrustMethod(req.TrustMethod) {
+		return reject("trust method %s not allowed", req.TrustMethod)
+	}
+	if len(p.KeyIDs) > 0 && !slices.Contains(p.KeyIDs, keyID(req)) {
+		return reject("verification key ID %q not allowed", keyID(req))
+	}
+
+	for name, want := range p.Claims {
+		got, ok := metadata[name]
+		if !ok {
+			return reject("claim %q missing", name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			return reject("claim %q has value %v, want %v", name, got, want)
+		}
+	}
+	if len(p.AllowedClaims) > 0 {
+		for name := range metadata {
+			if _, fixed := p.Claims[name]; fixed || name == "expiration_timestamp" || name == metaschema.Claim || slices.Contains(p.AllowedClaims, name) {
+				continue
+			}
+			return reject("claim %q not allowed", name)
+		}
+	}
+	if len(p.Scopes) > 0 {
+		scopes, _ := metadata["scopes"].([]interface{})
+		for _, s := range scopes {
+			name, _ := s.(string)
+			if !slices.Contains(p.Scopes, name) {
+				return reject("scope %v not allowed", s)
+			}
+		}
+	}
+
+	if p.maxLifetime > 0 {
+		exp, ok := metadata["expiration_timestamp"].(float64)
+		if !ok {
+			return reject("expiration_timestamp required (at most %s from now)", p.maxLifetime)
+		}
+		if limit := now.Add(p.maxLifetime); time.Unix(int64(exp), 0).After(limit) {
+			return reject("expiration_timestamp beyond %s", limit.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+func (p *Policy) allowsTrustMethod(m ptx.TrustMethod) bool {
+	if len(p.TrustMethods) == 0 {
+		return m == ptx.TrustMethod_DOH
+	}
+	for _, name := range p.TrustMethods {
+		if want, err := ptx.ParseTrustMethod(name); err == nil && want == m {
+			return true
+		}
+	}
+	return false
+}
+
+// keyID returns the verification key ID of req, defaulted
+func keyID(req *ptx.IssuanceRequest) string {
+	if req.VerificationKeyId == "" {
+		return signals.DefaultVerificationKeyID
+	}
+	return req.VerificationKeyId
+}
@@ -0,0 +1,61 @@
+package jesuit_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/jesuit"
+)
+
+func ExampleProve() {
+	tok, err := jesuit.Prove(context.Background(), jesuit.ProveRequest{
+		Domain:   "example.com",
+		Metadata: map[string]interface{}{"role": "validator"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("token.ptx", tok.PTX, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	// The issuer of example.com publishes the anchor
+	fmt.Printf("%s TXT %q\n", tok.Anchor.Hostname, tok.Anchor.Value)
+}
+
+func ExampleVerify() {
+	f, err := fixture.Generate(fixture.Options{Seed: 1})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The anchor record can be fetched up front, or left to a DoH lookup
+	res, err := jesuit.Verify(f.PTX, f.VK, jesuit.BytesOptions{TXTRecords: []string{f.Anchor.Value}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(res.Success, res.Details.Fqdn)
+	// Output: true example.com
+}
+
+func ExampleNewVerifier() {
+	v, err := jesuit.NewVerifier(jesuit.VerifyOptions{
+		VKPath:           "native.vk",
+		NonceDB:          "nonces.db",
+		IntendedAudience: []string{"https://rp.example.com"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer v.Close()
+
+	// Safe to call from concurrent request handlers
+	res, err := v.VerifyFile("token.ptx")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !res.Success {
+		fmt.Println("rejected:", res.Errors)
+	}
+}
@@ -0,0 +1,190 @@
+// Package jesuit is the stable Go API of the PTX toolkit: proving tokens,
+// verifying them and deriving their DNS anchors. Applications should only
+// need this package and the generated ptx types.
+//
+// # Compatibility
+//
+// The identifiers of this package follow semantic versioning with the
+// module: within a major version they are neither removed nor changed
+// incompatibly, and new fields of option structs are optional. The other
+// packages under pkg/ are building blocks of the CLI and the server and may
+// change between minor releases; the types this package aliases from them
+// are covered by the guarantee as far as they are reachable from here.
+// Circuit signal layouts and key fetching are internal.
+package jesuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// APIVersion is the version of this API, which follows the module version
+const APIVersion = "1.0.0"
+
+// Verification key IDs, naming the circuit a token is proven with
+const (
+	// KeyIDDefault is the Poseidon circuit binding the metadata digest
+	KeyIDDefault = signals.DefaultVerificationKeyID
+	// KeyIDMetadataSHA256 hashes the metadata in-circuit (native proving
+	// only, larger keys)
+	KeyIDMetadataSHA256 = signals.MetadataSHA256KeyID
+	// KeyIDScopeBound also binds the audience and scopes in the commitment
+	KeyIDScopeBound = signals.ScopeBoundKeyID
+)
+
+type (
+	// TrustMethod is how a token is anchored
+	TrustMethod = ptx.TrustMethod
+	// AnchorRecord is the TXT record anchoring a token
+	AnchorRecord = utils.AnchorRecord
+	// ProverBackend generates proofs, see NewNativeBackend
+	ProverBackend = prover.ProverBackend
+
+	// VerifyOptions configures NewVerifier
+	VerifyOptions = verifier.VerificationOptions
+	// BytesOptions configures Verify
+	BytesOptions = verifier.BytesOptions
+	// Verifier verifies many tokens under one configuration and is safe for
+	// concurrent use
+	Verifier = verifier.Verifier
+	// Result is the outcome of a verification
+	Result = verifier.VerificationResult
+	// Policy is a declarative relying-party acceptance policy
+	Policy = verifier.Policy
+	// ProofPolicy refuses proof systems, curves and circuit revisions
+	ProofPolicy = verifier.ProofPolicy
+	// ProofPolicyError is the rule of a ProofPolicy a proof violates
+	ProofPolicyError = verifier.ProofPolicyError
+	// ErrorCode classifies why a token was rejected
+	ErrorCode = verifier.ErrorCode
+)
+
+// Trust methods
+const (
+	DOH       = ptx.TrustMethod_DOH
+	WellKnown = ptx.TrustMethod_WELL_KNOWN
+	IPFS      = ptx.TrustMethod_IPFS
+)
+
+// ProveRequest describes the token to prove
+type ProveRequest struct {
+	// Domain is the anchor domain of the issuer
+	Domain string
+	// Metadata are the claims of the token. It is not modified.
+	Metadata map[string]interface{}
+	// ExpiresIn, when positive, sets the "expiration_timestamp" claim
+	ExpiresIn time.Duration
+	// Nullifier and Secret are the holder's secrets, decimal. Both are
+	// generated when empty.
+	Nullifier, Secret string
+	// TrustMethod defaults to DOH
+	TrustMethod TrustMethod
+	// KeyID defaults to KeyIDDefault
+	KeyID string
+	// Backend defaults to NewNativeBackend()
+	Backend ProverBackend
+}
+
+// Token is a proven PTX file and what its holder and issuer need to keep
+type Token struct {
+	// PTX is the serialized PTX file
+	PTX []byte
+	// Nullifier and Secret are the holder's secrets, the generated ones
+	// when the request had none
+	Nullifier, Secret string
+	Commitment        string
+	NullifierHash     string
+	// Anchor is the record the issuer publishes for a DOH token
+	Anchor *AnchorRecord
+}
+
+// NewNativeBackend returns the in-process gnark prover, which uses or
+// creates native.pk and native.vk in the working directory
+func NewNativeBackend() ProverBackend {
+	return prover.NewNative()
+}
+
+// Prove proves the token of req
+func Prove(ctx context.Context, req ProveRequest) (*Token, error) {
+	if req.Domain == "" {
+		return nil, errors.New("domain is required")
+	}
+	if (req.Nullifier == "") != (req.Secret == "") {
+		return nil, errors.New("nullifier and secret must be given together")
+	}
+	tok := &Token{Nullifier: req.Nullifier, Secret: req.Secret}
+	if tok.Nullifier == "" {
+		n, err := crypto.GenerateSecureRandomBigInt()
+		if err != nil {
+			return nil, err
+		}
+		s, err := crypto.GenerateSecureRandomBigInt()
+		if err != nil {
+			return nil, err
+		}
+		tok.Nullifier, tok.Secret = n.String(), s.String()
+	}
+
+	metadata := make(map[string]interface{}, len(req.Metadata)+2)
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	if req.ExpiresIn > 0 {
+		metadata["expiration_timestamp"] = time.Now().Add(req.ExpiresIn).Unix()
+	}
+	if err := metaschema.Stamp(metadata); err != nil {
+		return nil, err
+	}
+	trustMethod := req.TrustMethod
+	if trustMethod == ptx.TrustMethod_METHOD_UNSPECIFIED {
+		trustMethod = DOH
+	}
+
+	p := &prover.Prover{KeyID: req.KeyID}
+	inputs, err := p.GenerateCircuitInputs(req.Domain, metadata, tok.Nullifier, tok.Secret, int(trustMethod))
+	if err != nil {
+		return nil, err
+	}
+	backend := req.Backend
+	if backend == nil {
+		backend = NewNativeBackend()
+	}
+	proofData, err := backend.Prove(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("proof generation failed: %w", err)
+	}
+	if tok.PTX, err = p.CreatePtxFile(proofData, metadata, req.Domain, int(trustMethod)); err != nil {
+		return nil, err
+	}
+	tok.Commitment, tok.NullifierHash = inputs.Commitment, inputs.NullifierHash
+	if tok.Anchor, err = DeriveAnchor(tok.PTX); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// Verify verifies an in-memory PTX file against an in-memory verification
+// key, without touching the filesystem or checking nonces
+func Verify(ptxData, vkData []byte, opts BytesOptions) (*Result, error) {
+	return verifier.VerifyBytes(ptxData, vkData, opts)
+}
+
+// NewVerifier validates opts and returns a Verifier for them
+func NewVerifier(opts VerifyOptions) (*Verifier, error) {
+	return verifier.New(opts)
+}
+
+// DeriveAnchor returns the TXT record a PTX file must be anchored with
+func DeriveAnchor(ptxData []byte) (*AnchorRecord, error) {
+	return verifier.DeriveAnchor(ptxData)
+}
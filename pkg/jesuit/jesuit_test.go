@@ -0,0 +1,55 @@
+package jesuit_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/jesuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// keyBackend proves with the fixture keys
+type keyBackend struct {
+	ccs constraint.ConstraintSystem
+	pk  groth16.ProvingKey
+}
+
+func (b keyBackend) Name() string { return "fixture" }
+
+func (b keyBackend) Prove(ctx context.Context, inputs *prover.CircuitInputs) ([]byte, error) {
+	return prover.NewProver().ProveWithKey(b.ccs, b.pk, inputs)
+}
+
+func TestProveVerify(t *testing.T) {
+	ccs, pk, vk, err := fixture.Keys(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := jesuit.Prove(context.Background(), jesuit.ProveRequest{
+		Domain:   "example.com",
+		Metadata: map[string]interface{}{"role": "validator"},
+		Backend:  keyBackend{ccs, pk},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Nullifier == "" || tok.Commitment == "" {
+		t.Fatal("token without generated secrets or commitment")
+	}
+
+	var vkData bytes.Buffer
+	if _, err := vk.WriteTo(&vkData); err != nil {
+		t.Fatal(err)
+	}
+	res, err := jesuit.Verify(tok.PTX, vkData.Bytes(), jesuit.BytesOptions{TXTRecords: []string{tok.Anchor.Value}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Details.MetaVersion != 1 {
+		t.Fatalf("success %v, metadata version %d: %v", res.Success, res.Details.MetaVersion, res.Errors)
+	}
+}
@@ -0,0 +1,154 @@
+// Package metaschema versions the shape of PTX metadata. Tokens carry their
+// schema version in the "meta_version" claim; tokens without it are version
+// 0, the ad-hoc shape issuers used before. Verifiers Normalize the claims of
+// older tokens to the Current shape with the registered upgraders before
+// checking them, while the proof keeps binding the metadata as issued.
+package metaschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Claim is the metadata claim holding the schema version
+const Claim = "meta_version"
+
+// Current is the schema version new tokens are issued with
+const Current = 1
+
+// MaxSize bounds the canonical metadata of a token, well below the file size
+// verifiers accept (ptxloader.DefaultMaxFileSize)
+const MaxSize = 64 << 10
+
+// Upgrader rewrites claims of one schema version into the next
+type Upgrader func(claims map[string]interface{}) error
+
+var (
+	mu        sync.RWMutex
+	upgraders = map[int]Upgrader{}
+)
+
+// Register installs the upgrader from version from to from+1. It panics if
+// one is already registered, or if from is not below Current.
+func Register(from int, up Upgrader) {
+	mu.Lock()
+	defer mu.Unlock()
+	if from < 0 || from >= Current {
+		panic(fmt.Sprintf("metaschema: no upgrade from version %d", from))
+	}
+	if _, dup := upgraders[from]; dup {
+		panic(fmt.Sprintf("metaschema: upgrader from version %d registered twice", from))
+	}
+	upgraders[from] = up
+}
+
+func init() {
+	Register(0, upgradeV0)
+}
+
+// Version returns the schema version of claims, 0 when they carry none
+func Version(claims map[string]interface{}) (int, error) {
+	raw, ok := claims[Claim]
+	if !ok {
+		return 0, nil
+	}
+	var v float64
+	switch n := raw.(type) {
+	case float64:
+		v = n
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %v", Claim, raw)
+		}
+		v = f
+	case int:
+		v = float64(n)
+	case int64:
+		v = float64(n)
+	default:
+		return 0, fmt.Errorf("invalid %s %v", Claim, raw)
+	}
+	if v != math.Trunc(v) || v < 1 {
+		return 0, fmt.Errorf("invalid %s %v", Claim, raw)
+	}
+	if v > Current {
+		return 0, fmt.Errorf("unsupported %s %v (this build knows up to %d)", Claim, raw, Current)
+	}
+	return int(v), nil
+}
+
+// Check rejects claims of an unknown schema version
+func Check(claims map[string]interface{}) error {
+	_, err := Version(claims)
+	return err
+}
+
+// Stamp sets the version of claims without one to Current, as new tokens are
+// issued
+func Stamp(claims map[string]interface{}) error {
+	if _, ok := claims[Claim]; ok {
+		return Check(claims)
+	}
+	claims[Claim] = Current
+	return nil
+}
+
+// Normalize upgrades claims in place to the Current schema and returns the
+// version they had
+func Normalize(claims map[string]interface{}) (int, error) {
+	from, err := Version(claims)
+	if err != nil {
+		return 0, err
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for v := from; v < Current; v++ {
+		up, ok := upgraders[v]
+		if !ok {
+			return from, fmt.Errorf("no metadata upgrader from version %d", v)
+		}
+		if err := up(claims); err != nil {
+			return from, fmt.Errorf("metadata version %d: %w", v, err)
+		}
+	}
+	if from != Current {
+		claims[Claim] = float64(Current)
+	}
+	return from, nil
+}
+
+// v0Aliases maps the claim names some issuers used before version 1 to the
+// version 1 names
+var v0Aliases = map[string]string{
+	"exp":   "expiration_timestamp",
+	"aud":   "audience",
+	"scope": "scopes",
+}
+
+// upgradeV0 renames the aliased claims and splits a space-separated "scopes"
+// string (OAuth style) into a list
+func upgradeV0(claims map[string]interface{}) error {
+	for alias, name := range v0Aliases {
+		value, ok := claims[alias]
+		if !ok {
+			continue
+		}
+		if _, both := claims[name]; both {
+			return fmt.Errorf("both %q and its alias %q are set", name, alias)
+		}
+		delete(claims, alias)
+		claims[name] = value
+	}
+	if s, ok := claims["scopes"].(string); ok {
+		var scopes []interface{}
+		for _, scope := range strings.Fields(s) {
+			scopes = append(scopes, scope)
+		}
+		claims["scopes"] = scopes
+	}
+	return nil
+}
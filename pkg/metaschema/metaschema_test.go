@@ -0,0 +1,53 @@
+package metaschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	claims := map[string]interface{}{"exp": 1700000000.0, "aud": "rp", "scope": "read write", "role": "admin"}
+	from, err := Normalize(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"expiration_timestamp": 1700000000.0,
+		"audience":             "rp",
+		"scopes":               []interface{}{"read", "write"},
+		"role":                 "admin",
+		Claim:                  float64(Current),
+	}
+	if from != 0 || !reflect.DeepEqual(claims, want) {
+		t.Fatalf("version %d normalized to %v", from, claims)
+	}
+
+	current := map[string]interface{}{Claim: json.Number("1"), "exp": "kept"}
+	if from, err := Normalize(current); err != nil || from != Current || current["exp"] != "kept" {
+		t.Fatalf("current claims changed: %v, %v", current, err)
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	for name, claims := range map[string]map[string]interface{}{
+		"alias clash":     {"exp": 1.0, "expiration_timestamp": 2.0},
+		"future version":  {Claim: float64(Current + 1)},
+		"invalid version": {Claim: "1"},
+		"zero version":    {Claim: 0.0},
+	} {
+		if _, err := Normalize(claims); err == nil {
+			t.Errorf("%s: normalized", name)
+		}
+	}
+}
+
+func TestStamp(t *testing.T) {
+	claims := map[string]interface{}{}
+	if err := Stamp(claims); err != nil || claims[Claim] != Current {
+		t.Fatalf("stamped %v, %v", claims, err)
+	}
+	if err := Stamp(map[string]interface{}{Claim: 99.0}); err == nil {
+		t.Fatal("stamped an unknown version")
+	}
+}
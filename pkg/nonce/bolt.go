@@ -0,0 +1,160 @@
+package nonce
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultCompactionInterval is how often a BoltStore deletes expired nonces
+const DefaultCompactionInterval = 10 * time.Minute
+
+var nonceBucket = []byte("nonces")
+
+// BoltStore keeps nonces in an embedded bbolt database, so that a single
+// verifier keeps its replay protection across restarts without Redis. Only
+// one process may open the file at a time.
+type BoltStore struct {
+	db *bolt.DB
+	// Prefix namespaces the nonce keys, e.g. per tenant
+	Prefix string
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// OpenBolt opens or creates the nonce database at path and deletes expired
+// nonces every compactEvery (DefaultCompactionInterval if zero)
+func OpenBolt(path string, compactEvery time.Duration) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("nonce database %s is locked by another process", path)
+		}
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(nonceBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if compactEvery <= 0 {
+		compactEvery = DefaultCompactionInterval
+	}
+	s := &BoltStore{db: db, stop: make(chan struct{}), done: make(chan struct{})}
+	go s.compactLoop(compactEvery)
+	return s, nil
+}
+
+func (s *BoltStore) CheckAndSetNonce(nonce string, expiresAt time.Time) (bool, error) {
+	now, expirationTimestamp := time.Now().Unix(), expiresAt.Unix()
+	if expirationTimestamp < now {
+		return false, nil // Already expired
+	}
+
+	isNew := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(nonceBucket)
+		key := []byte(s.Prefix + nonce)
+		// Expired nonces count as new until compaction deletes them
+		if v := b.Get(key); len(v) == 8 && int64(binary.BigEndian.Uint64(v)) >= now {
+			return nil
+		}
+		isNew = true
+		return b.Put(key, binary.BigEndian.AppendUint64(nil, uint64(expirationTimestamp)))
+	})
+	if err != nil {
+		return false, err
+	}
+	return isNew, nil
+}
+
+// CheckAndSetNonces records all nonces in a single transaction if none of
+// them has been seen, and returns the index of the first one already seen,
+// or -1
+func (s *BoltStore) CheckAndSetNonces(nonces []string, expiresAt time.Time) (int, error) {
+	now, expirationTimestamp := time.Now().Unix(), expiresAt.Unix()
+	if expirationTimestamp < now {
+		return 0, nil // Already expired
+	}
+
+	seen := -1
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(nonceBucket)
+		for i, n := range nonces {
+			if v := b.Get([]byte(s.Prefix + n)); len(v) == 8 && int64(binary.BigEndian.Uint64(v)) >= now {
+				seen = i
+				return nil
+			}
+		}
+		exp := binary.BigEndian.AppendUint64(nil, uint64(expirationTimestamp))
+		for _, n := range nonces {
+			if err := b.Put([]byte(s.Prefix+n), exp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return seen, nil
+}
+
+// Compact deletes the expired nonces and returns how many it deleted
+func (s *BoltStore) Compact() (int, error) {
+	now := time.Now().Unix()
+	deleted := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(nonceBucket)
+		// Deleting while iterating makes the cursor skip keys, so collect
+		// the expired ones first
+		var expired [][]byte
+		b.ForEach(func(k, v []byte) error {
+			if len(v) != 8 || int64(binary.BigEndian.Uint64(v)) < now {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(expired)
+		return nil
+	})
+	return deleted, err
+}
+
+func (s *BoltStore) compactLoop(every time.Duration) {
+	defer close(s.done)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			s.Compact()
+		}
+	}
+}
+
+// Close stops compaction and closes the database
+func (s *BoltStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+	return s.db.Close()
+}
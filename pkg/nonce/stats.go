@@ -0,0 +1,271 @@
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StatsRetention is how long a NonceStore keeps its per-minute counters of
+// accepted and rejected nonces
+const StatsRetention = time.Hour
+
+const (
+	counterAccepted = "accepted"
+	counterRejected = "rejected"
+	// statsKeyPrefix namespaces the counters among the nonce keys
+	statsKeyPrefix = "ptx:nonce-stats:"
+	// storeKeyPrefix namespaces the keys of the other stores sharing the
+	// Redis deployment, such as ratelimit.KeyPrefix, which Stats skips
+	storeKeyPrefix = "ptx:"
+	// nullifierKeyPrefix is verifier.NullifierKeyPrefix, which this package
+	// cannot import
+	nullifierKeyPrefix = "nullifier:"
+)
+
+// DefaultStatsMaxKeys is how many keys Stats inspects by default
+const DefaultStatsMaxKeys = 100000
+
+// ttlBounds are the upper bounds of the TTL buckets reported by Stats
+var ttlBounds = []time.Duration{time.Minute, time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// StatsOptions bound the work of Stats
+type StatsOptions struct {
+	// Window is how far back rejections are counted, at most (and by
+	// default) StatsRetention
+	Window time.Duration
+	// MaxKeys caps the keys whose TTL is read, DefaultStatsMaxKeys if zero
+	MaxKeys int
+}
+
+// Stats describes the replay window of a NonceStore
+type Stats struct {
+	Keyspace   KeyspaceStats  `json:"keyspace"`
+	Rejections RejectionStats `json:"rejections"`
+}
+
+// KeyspaceStats counts the keys of a NonceStore
+type KeyspaceStats struct {
+	// Nonces are metadata nonces, Nullifiers the nullifier hashes recorded
+	// with TrackNullifiers
+	Nonces     int `json:"nonces"`
+	Nullifiers int `json:"nullifiers"`
+	// TTL buckets the keys by remaining time to live
+	TTL []TTLBucket `json:"ttl"`
+	// NoTTL counts keys that never expire, which the store never writes
+	NoTTL int `json:"noTtl"`
+	// Truncated is set when the scan stopped at StatsOptions.MaxKeys, so the
+	// counts are a lower bound
+	Truncated bool `json:"truncated"`
+}
+
+// TTLBucket counts the keys expiring within MaxSeconds and after the bound
+// of the previous bucket. The last bucket has no bound and MaxSeconds 0.
+type TTLBucket struct {
+	MaxSeconds int64 `json:"maxSeconds,omitempty"`
+	Keys       int   `json:"keys"`
+}
+
+// RejectionStats counts the nonce checks of the last Window. A check is
+// rejected when one of its nonces had been seen before, i.e. a replay.
+type RejectionStats struct {
+	WindowSeconds int64   `json:"windowSeconds"`
+	Accepted      int64   `json:"accepted"`
+	Rejected      int64   `json:"rejected"`
+	Rate          float64 `json:"rate"`
+	// Minutes break the counts down per minute, oldest first
+	Minutes []MinuteCount `json:"minutes"`
+}
+
+// MinuteCount are the nonce checks of the minute starting at Start
+type MinuteCount struct {
+	Start    time.Time `json:"start"`
+	Accepted int64     `json:"accepted"`
+	Rejected int64     `json:"rejected"`
+}
+
+// counterKey is the key of the outcome counter of a minute since the epoch
+func (s *NonceStore) counterKey(outcome string, minute int64) string {
+	return s.key(statsKeyPrefix + outcome + ":" + strconv.FormatInt(minute, 10))
+}
+
+// Stats counts the nonce and nullifier keys of the store by TTL, and the
+// checks it accepted and rejected within opts.Window. Keys are found with
+// SCAN on every node, so the counts are approximate while nonces are
+// recorded. Without a Prefix every key of the database that does not belong
+// to another ptx store counts as a nonce.
+func (s *NonceStore) Stats(ctx context.Context, opts StatsOptions) (*Stats, error) {
+	if opts.Window <= 0 || opts.Window > StatsRetention {
+		opts.Window = StatsRetention
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = DefaultStatsMaxKeys
+	}
+	st := &Stats{}
+	if err := s.keyspaceStats(ctx, opts.MaxKeys, &st.Keyspace); err != nil {
+		return nil, fmt.Errorf("scanning nonce keys: %w", err)
+	}
+	if err := s.rejectionStats(ctx, opts.Window, &st.Rejections); err != nil {
+		return nil, fmt.Errorf("reading rejection counters: %w", err)
+	}
+	return st, nil
+}
+
+func (s *NonceStore) keyspaceStats(ctx context.Context, maxKeys int, ks *KeyspaceStats) error {
+	ks.TTL = make([]TTLBucket, len(ttlBounds)+1)
+	for i, b := range ttlBounds {
+		ks.TTL[i].MaxSeconds = int64(b / time.Second)
+	}
+
+	prefix := s.key("")
+	var mu sync.Mutex
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, escapeGlob(prefix)+"*", 1000).Iterator()
+		for {
+			batch := make([]string, 0, 1000)
+			for len(batch) < cap(batch) && iter.Next(ctx) {
+				if name := strings.TrimPrefix(iter.Val(), prefix); !strings.HasPrefix(name, storeKeyPrefix) {
+					batch = append(batch, iter.Val())
+				}
+			}
+			if err := iter.Err(); err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				return nil
+			}
+			ttls, err := pttls(ctx, client, batch)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			for i, ttl := range ttls {
+				if ks.Nonces+ks.Nullifiers >= maxKeys {
+					ks.Truncated = true
+					break
+				}
+				ks.add(strings.TrimPrefix(batch[i], prefix), ttl)
+			}
+			done := ks.Truncated
+			mu.Unlock()
+			if done {
+				return nil
+			}
+		}
+	}
+
+	if cluster, ok := s.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+	}
+	return scan(ctx, s.client)
+}
+
+// add counts a key by kind and TTL. Keys that expired since the scan found
+// them have a negative TTL other than -1 and are skipped.
+func (ks *KeyspaceStats) add(name string, ttl time.Duration) {
+	switch {
+	case ttl == -1:
+		ks.NoTTL++
+	case ttl < 0:
+		return
+	default:
+		i := 0
+		for i < len(ttlBounds) && ttl > ttlBounds[i] {
+			i++
+		}
+		ks.TTL[i].Keys++
+	}
+	if strings.HasPrefix(name, nullifierKeyPrefix) {
+		ks.Nullifiers++
+	} else {
+		ks.Nonces++
+	}
+}
+
+// pttls reads the TTL of keys in one round trip. PTTL replies -1 for keys
+// without expiry and -2 for missing keys, which are returned as is.
+func pttls(ctx context.Context, client redis.UniversalClient, keys []string) ([]time.Duration, error) {
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, k := range keys {
+			cmds[i] = redis.NewIntCmd(ctx, "PTTL", k)
+			p.Process(ctx, cmds[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ttls := make([]time.Duration, len(keys))
+	for i, c := range cmds {
+		ms := c.Val()
+		if ms >= 0 {
+			ttls[i] = time.Duration(ms) * time.Millisecond
+		} else {
+			ttls[i] = time.Duration(ms)
+		}
+	}
+	return ttls, nil
+}
+
+func (s *NonceStore) rejectionStats(ctx context.Context, window time.Duration, rs *RejectionStats) error {
+	minutes := int64(window / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	now := time.Now().Unix() / 60
+	keys := make([]string, 0, 2*minutes)
+	for m := now - minutes + 1; m <= now; m++ {
+		keys = append(keys, s.counterKey(counterAccepted, m), s.counterKey(counterRejected, m))
+	}
+	// In Redis Cluster the counters share the hash tag of the nonce keys, so
+	// a single MGET reads them all
+	vals, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return err
+	}
+
+	rs.WindowSeconds = minutes * 60
+	rs.Minutes = make([]MinuteCount, 0, minutes)
+	for i := int64(0); i < minutes; i++ {
+		mc := MinuteCount{
+			Start:    time.Unix((now-minutes+1+i)*60, 0).UTC(),
+			Accepted: counterValue(vals[2*i]),
+			Rejected: counterValue(vals[2*i+1]),
+		}
+		rs.Accepted += mc.Accepted
+		rs.Rejected += mc.Rejected
+		rs.Minutes = append(rs.Minutes, mc)
+	}
+	if total := rs.Accepted + rs.Rejected; total > 0 {
+		rs.Rate = float64(rs.Rejected) / float64(total)
+	}
+	return nil
+}
+
+// counterValue parses an MGET reply, nil for a minute without checks
+func counterValue(v interface{}) int64 {
+	s, _ := v.(string)
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// escapeGlob escapes the SCAN MATCH metacharacters of s
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
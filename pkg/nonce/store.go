@@ -4,42 +4,118 @@ import (
 	"context"
 	"time"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/redisconn"
 	"github.com/redis/go-redis/v9"
 )
 
+// setAll records every nonce key of KEYS unless one of them exists,
+// atomically, and counts the outcome in the accepted or rejected counter of
+// the current minute. It returns the 0-based index of the first existing
+// key, or -1.
+//
+// KEYS: nonce keys, then the accepted and rejected counters; ARGV[1]: ttl
+// (ms); ARGV[2]: counter ttl (ms)
+var setAll = redis.NewScript(`
+local n = #KEYS - 2
+for i = 1, n do
+  if redis.call("EXISTS", KEYS[i]) == 1 then
+    redis.call("INCR", KEYS[n + 2])
+    redis.call("PEXPIRE", KEYS[n + 2], ARGV[2])
+    return i - 1
+  end
+end
+for i = 1, n do
+  redis.call("SET", KEYS[i], "1", "PX", ARGV[1])
+end
+redis.call("INCR", KEYS[n + 1])
+redis.call("PEXPIRE", KEYS[n + 1], ARGV[2])
+return -1
+`)
+
 type NonceStore struct {
-	client *redis.Client
+	client redis.UniversalClient
+	// Prefix namespaces the nonce keys, e.g. per tenant
+	Prefix string
 }
 
+// NewNonceStore returns a store in the Redis deployment at url, a single
+// node, cluster or sentinel URL as accepted by redisconn.Open
 func NewNonceStore(url string) (*NonceStore, error) {
-	opts, err := redis.ParseURL(url)
+	client, err := redisconn.Open(url)
 	if err != nil {
 		return nil, err
 	}
-	client := redis.NewClient(opts)
 	return &NonceStore{client: client}, nil
 }
 
-func (s *NonceStore) CheckAndSetNonce(nonce string, expirationTimestamp int64) (bool, error) {
-	ctx := context.Background()
+func (s *NonceStore) CheckAndSetNonce(nonce string, expiresAt time.Time) (bool, error) {
+	seen, err := s.CheckAndSetNonces([]string{nonce}, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return seen < 0, nil
+}
 
-	// Set with expiration (SETNX)
-	now := time.Now().Unix()
-	if expirationTimestamp < now {
-		return false, nil // Already expired
+// CheckAndSetNonces records all nonces until expiresAt if none of
+// them has been seen, in one Lua script so that a crash cannot record some
+// of them only. It returns the index of the first nonce already seen, or -1
+// if all were recorded.
+func (s *NonceStore) CheckAndSetNonces(nonces []string, expiresAt time.Time) (int, error) {
+	ttl := time.Until(expiresAt)
+	if ttl < time.Millisecond {
+		return 0, nil // Already expired
 	}
+	keys := make([]string, len(nonces), len(nonces)+2)
+	for i, n := range nonces {
+		keys[i] = s.key(n)
+	}
+	minute := time.Now().Unix() / 60
+	keys = append(keys, s.counterKey(counterAccepted, minute), s.counterKey(counterRejected, minute))
+	seen, err := setAll.Run(context.Background(), s.client, keys, ttl.Milliseconds(), StatsRetention.Milliseconds()).Int()
+	if err != nil {
+		return 0, err
+	}
+	return seen, nil
+}
 
-	ttl := time.Duration(expirationTimestamp-now) * time.Second
+// WithPrefix returns a view of s whose keys are namespaced by prefix. It
+// shares the connection of s, which its Close closes.
+func (s *NonceStore) WithPrefix(prefix string) *NonceStore {
+	return &NonceStore{client: s.client, Prefix: prefix}
+}
 
-	// SetNX returns true if key was set (new), false if it existed
-	isNew, err := s.client.SetNX(ctx, nonce, "1", ttl).Result()
-	if err != nil {
-		return false, err
+// key namespaces a nonce. In Redis Cluster the keys of a prefix share a hash
+// tag, so that scripts touching several of them run on a single node.
+func (s *NonceStore) key(nonce string) string {
+	if _, ok := s.client.(*redis.ClusterClient); ok {
+		return "{" + s.Prefix + "nonces}" + nonce
 	}
+	return s.Prefix + nonce
+}
 
-	return isNew, nil
+// Ping checks that the Redis deployment is reachable
+func (s *NonceStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
 }
 
 func (s *NonceStore) Close() error {
 	return s.client.Close()
 }
+
+// Store records nonces so that a PTX cannot be replayed. NonceStore (Redis)
+// and BoltStore implement it.
+type Store interface {
+	// CheckAndSetNonce records nonce until expiresAt and reports whether it
+	// had not been seen before. Expired nonces are never recorded.
+	CheckAndSetNonce(nonce string, expiresAt time.Time) (bool, error)
+	// CheckAndSetNonces records several nonces atomically: all of them if
+	// none has been seen, otherwise none. It returns the index of the first
+	// nonce already seen, or -1.
+	CheckAndSetNonces(nonces []string, expiresAt time.Time) (int, error)
+	Close() error
+}
+
+var (
+	_ Store = (*NonceStore)(nil)
+	_ Store = (*BoltStore)(nil)
+)
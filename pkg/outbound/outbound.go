@@ -0,0 +1,288 @@
+// Package outbound holds the proxy and CA configuration shared by every
+// outbound HTTP client: DoH queries, anchor and verification key fetches,
+// issuer discovery, webhooks, TSAs, remote provers and DNS provider APIs.
+// Clients that are not given an http.Client of their own use DefaultClient
+// or Client, whose transport follows the configuration installed with
+// Configure, so a proxy or private CA only has to be set up once.
+package outbound
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Direct as a proxy URL disables proxying, including from the environment
+const Direct = "direct"
+
+// Config configures outbound HTTP. The zero value uses the proxy from the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables and the system
+// roots. It is loaded from JSON:
+//
+//	{
+//	  "proxy": "http://proxy.corp:3128",
+//	  "caFile": "/etc/ssl/corp-ca.pem",
+//	  "destinations": [
+//	    {"host": "cloudflare-dns.com", "proxy": "direct"},
+//	    {"host": "*.internal.corp", "caFile": "/etc/ssl/internal-ca.pem"}
+//	  ]
+//	}
+type Config struct {
+	// ProxyURL overrides the proxy environment variables, Direct disables
+	// proxying
+	ProxyURL string `json:"proxy,omitempty"`
+	// CAFile is a PEM bundle trusted instead of the system roots
+	CAFile string `json:"caFile,omitempty"`
+	// Destinations override the proxy or CA bundle for some hosts. The
+	// first matching entry applies.
+	Destinations []Destination `json:"destinations,omitempty"`
+}
+
+// Destination overrides the configuration for requests to Host, a hostname
+// or "*.example.com" for the subdomains of example.com
+type Destination struct {
+	Host     string `json:"host"`
+	ProxyURL string `json:"proxy,omitempty"`
+	CAFile   string `json:"caFile,omitempty"`
+}
+
+// LoadConfig reads a Config from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse outbound config: %w", err)
+	}
+	return &c, nil
+}
+
+// compiled is a Config with its proxies parsed and CA bundles loaded
+type compiled struct {
+	proxy *url.URL
+	roots *x509.CertPool
+	dests []compiledDestination
+}
+
+type compiledDestination struct {
+	host  string
+	proxy *url.URL
+	roots *x509.CertPool
+}
+
+func (c *Config) compile() (*compiled, error) {
+	var out compiled
+	var err error
+	if out.proxy, err = parseProxy(c.ProxyURL); err != nil {
+		return nil, err
+	}
+	if out.roots, err = loadRoots(c.CAFile); err != nil {
+		return nil, err
+	}
+	for _, d := range c.Destinations {
+		if d.Host == "" {
+			return nil, errors.New("outbound destination without host")
+		}
+		cd := compiledDestination{host: strings.ToLower(d.Host)}
+		if cd.proxy, err = parseProxy(d.ProxyURL); err != nil {
+			return nil, fmt.Errorf("destination %s: %w", d.Host, err)
+		}
+		if cd.roots, err = loadRoots(d.CAFile); err != nil {
+			return nil, fmt.Errorf("destination %s: %w", d.Host, err)
+		}
+		out.dests = append(out.dests, cd)
+	}
+	return &out, nil
+}
+
+// direct is the parsed form of Direct
+var direct = &url.URL{}
+
+func parseProxy(s string) (*url.URL, error) {
+	switch s {
+	case "":
+		return nil, nil
+	case Direct:
+		return direct, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", s)
+	}
+	return u, nil
+}
+
+func loadRoots(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// destination returns the override for host, if any
+func (c *compiled) destination(host string) *compiledDestination {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for i := range c.dests {
+		d := &c.dests[i]
+		if suffix, ok := strings.CutPrefix(d.host, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return d
+			}
+		} else if d.host == host {
+			return d
+		}
+	}
+	return nil
+}
+
+func (c *compiled) proxyFunc(req *http.Request) (*url.URL, error) {
+	p := c.proxy
+	if d := c.destination(req.URL.Hostname()); d != nil && d.proxy != nil {
+		p = d.proxy
+	}
+	switch p {
+	case nil:
+		return http.ProxyFromEnvironment(req)
+	case direct:
+		return nil, nil
+	}
+	return p, nil
+}
+
+// tlsConfig returns the TLS configuration for requests to host
+func (c *compiled) tlsConfig(host string) *tls.Config {
+	roots := c.roots
+	if d := c.destination(host); d != nil && d.roots != nil {
+		roots = d.roots
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots}
+}
+
+// Proxy returns the proxy function of c for an http.Transport
+func (c *Config) Proxy() (func(*http.Request) (*url.URL, error), error) {
+	cc, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	return cc.proxyFunc, nil
+}
+
+// TLSConfig returns the TLS configuration of c for requests to host
+func (c *Config) TLSConfig(host string) (*tls.Config, error) {
+	cc, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	return cc.tlsConfig(host), nil
+}
+
+// NewTransport returns a transport like http.DefaultTransport using the
+// proxies and roots of c. Destinations with their own CA bundle get a
+// transport of their own.
+func (c *Config) NewTransport() (http.RoundTripper, error) {
+	cc, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	newTransport := func(roots *x509.CertPool) *http.Transport {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = cc.proxyFunc
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots}
+		return t
+	}
+	r := &router{compiled: cc, base: newTransport(cc.roots)}
+	for i := range cc.dests {
+		if cc.dests[i].roots != nil {
+			if r.dests == nil {
+				r.dests = map[*compiledDestination]*http.Transport{}
+			}
+			r.dests[&cc.dests[i]] = newTransport(cc.dests[i].roots)
+		}
+	}
+	if r.dests == nil {
+		return r.base, nil
+	}
+	return r, nil
+}
+
+// router sends requests over the transport of their destination
+type router struct {
+	*compiled
+	base  *http.Transport
+	dests map[*compiledDestination]*http.Transport
+}
+
+func (r *router) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t, ok := r.dests[r.destination(req.URL.Hostname())]; ok {
+		return t.RoundTrip(req)
+	}
+	return r.base.RoundTrip(req)
+}
+
+var (
+	defaultMu        sync.RWMutex
+	defaultConfig    = &Config{}
+	defaultTransport http.RoundTripper
+)
+
+// Configure installs c as the configuration of DefaultTransport
+func Configure(c *Config) error {
+	t, err := c.NewTransport()
+	if err != nil {
+		return err
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultConfig, defaultTransport = c, t
+	return nil
+}
+
+// Default returns the configuration installed with Configure
+func Default() *Config {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultConfig
+}
+
+// DefaultTransport sends requests with the configuration installed with
+// Configure, or like http.DefaultTransport before
+var DefaultTransport http.RoundTripper = transport{}
+
+type transport struct{}
+
+func (transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	defaultMu.RLock()
+	t := defaultTransport
+	defaultMu.RUnlock()
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	return t.RoundTrip(req)
+}
+
+// DefaultClient is an http.Client without timeout using DefaultTransport
+var DefaultClient = &http.Client{Transport: DefaultTransport}
+
+// Client returns an http.Client with the given timeout using
+// DefaultTransport
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: DefaultTransport, Timeout: timeout}
+}
@@ -0,0 +1,79 @@
+package outbound
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProxyDestinations(t *testing.T) {
+	c := &Config{
+		ProxyURL: "http://proxy.corp:3128",
+		Destinations: []Destination{
+			{Host: "dns.example", ProxyURL: Direct},
+			{Host: "*.internal.example", ProxyURL: "http://inner.corp:8080"},
+		},
+	}
+	proxy, err := c.Proxy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for target, want := range map[string]string{
+		"https://other.example/x":       "http://proxy.corp:3128",
+		"https://DNS.example/dns-query": "",
+		"https://vk.internal.example/":  "http://inner.corp:8080",
+		"https://internal.example/":     "http://proxy.corp:3128",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		u, err := proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != want {
+			t.Errorf("%s: proxy %q, want %q", target, got, want)
+		}
+	}
+
+	if _, err := (&Config{ProxyURL: "not a url"}).Proxy(); err == nil {
+		t.Error("accepted an invalid proxy URL")
+	}
+}
+
+func TestDestinationCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, block, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(c *Config) error {
+		if err := Configure(c); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := DefaultClient.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	defer Configure(&Config{})
+
+	if err := get(&Config{ProxyURL: Direct}); err == nil {
+		t.Error("trusted the test server without its CA")
+	}
+	if err := get(&Config{ProxyURL: Direct, Destinations: []Destination{{Host: "127.0.0.1", CAFile: caFile}}}); err != nil {
+		t.Errorf("destination CA not trusted: %v", err)
+	}
+	if err := get(&Config{ProxyURL: Direct, Destinations: []Destination{{Host: "other.example", CAFile: caFile}}}); err == nil {
+		t.Error("CA of another destination trusted")
+	}
+}
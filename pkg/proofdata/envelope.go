@@ -0,0 +1,141 @@
+package proofdata
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// EnvelopeSchema is the ptx.ProofEnvelope schema version written and
+// understood by this package
+const EnvelopeSchema = 1
+
+// ErrUnsupportedEnvelope is returned for envelopes declaring a schema or a
+// combination of proof system, curve, format and encoding that is not
+// supported
+var ErrUnsupportedEnvelope = errors.New("unsupported proof envelope")
+
+// envelopeCombination is a supported proof system, curve, format and
+// encoding
+type envelopeCombination struct {
+	system   ptx.ProofSystem
+	curve    ptx.Curve
+	format   ptx.ProofFormat
+	encoding ptx.ProofEncoding
+}
+
+// supportedEnvelopes lists every combination an envelope may declare
+var supportedEnvelopes = []envelopeCombination{
+	{ptx.ProofSystem_GROTH16, ptx.Curve_BN254, ptx.ProofFormat_GNARK_NATIVE, ptx.ProofEncoding_HEX},
+	{ptx.ProofSystem_GROTH16, ptx.Curve_BN254, ptx.ProofFormat_GNARK_NATIVE, ptx.ProofEncoding_BASE64_COMPRESSED},
+	{ptx.ProofSystem_GROTH16, ptx.Curve_BN254, ptx.ProofFormat_SNARKJS, ptx.ProofEncoding_JSON},
+	{ptx.ProofSystem_GROTH16, ptx.Curve_BN254, ptx.ProofFormat_SNARKJS, ptx.ProofEncoding_BASE64_GZIP},
+}
+
+// NewEnvelope describes the proof of a proof_data wrapper for the circuit
+// circuitID. It fails if the wrapper's encoding is unknown.
+func NewEnvelope(data []byte, circuitID string) (*ptx.ProofEnvelope, error) {
+	w, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	env := InferEnvelope(ptx.ProofSystem_GROTH16, w)
+	if env.Encoding == ptx.ProofEncoding_ENCODING_UNSPECIFIED {
+		return nil, fmt.Errorf("%w %q for %s proof", ErrUnknownEncoding, w.Encoding, formatName(env.Format))
+	}
+	env.CircuitId = circuitID
+	return env, nil
+}
+
+// InferEnvelope derives the envelope of a proof stored without one, from
+// the "source" and "encoding" fields of its wrapper. The curve is always
+// BN254, the only curve proofs were written for before the envelope, and
+// the circuit ID is unknown. An unknown encoding is left unspecified.
+func InferEnvelope(system ptx.ProofSystem, w *Wrapper) *ptx.ProofEnvelope {
+	env := &ptx.ProofEnvelope{
+		Schema:      EnvelopeSchema,
+		ProofSystem: system,
+		Curve:       ptx.Curve_BN254,
+		Format:      ptx.ProofFormat_SNARKJS,
+	}
+	if w.IsNative() {
+		env.Format = ptx.ProofFormat_GNARK_NATIVE
+	}
+	switch {
+	case w.IsNative() && (w.Encoding == "" || w.Encoding == EncodingHex):
+		env.Encoding = ptx.ProofEncoding_HEX
+	case w.IsNative() && w.Encoding == EncodingCompressed:
+		env.Encoding = ptx.ProofEncoding_BASE64_COMPRESSED
+	case !w.IsNative() && w.Encoding == "":
+		env.Encoding = ptx.ProofEncoding_JSON
+	case !w.IsNative() && w.Encoding == EncodingGzip:
+		env.Encoding = ptx.ProofEncoding_BASE64_GZIP
+	}
+	return env
+}
+
+// ValidateEnvelope checks the envelope of a proof strictly: its schema must
+// be known, its proof system that of the proof, its combination of proof
+// system, curve, format and encoding supported, its circuit ID set, its
+// circuit hash, if any, well-formed, and the format and encoding those of the
+// proof_data wrapper w. It does not check
+// that the circuit is the one of the verification key.
+func ValidateEnvelope(zk *ptx.ZkProof, w *Wrapper) error {
+	env := zk.GetEnvelope()
+	switch {
+	case env == nil:
+		return errors.New("no proof envelope")
+	case env.GetSchema() == 0:
+		return errors.New("proof envelope has no schema")
+	case env.GetSchema() > EnvelopeSchema:
+		return fmt.Errorf("%w: schema %d (up to %d supported)", ErrUnsupportedEnvelope, env.GetSchema(), EnvelopeSchema)
+	case env.GetProofSystem() != zk.GetProofSystem():
+		return fmt.Errorf("proof envelope declares proof system %s, the proof %s", env.GetProofSystem(), zk.GetProofSystem())
+	}
+	if !envelopeSupported(env) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedEnvelope, describeEnvelope(env))
+	}
+	if env.GetCircuitId() == "" {
+		return errors.New("proof envelope has no circuit ID")
+	}
+	if h := env.GetCircuitHash(); h != "" && !circuit.IsHash(h) {
+		return fmt.Errorf("proof envelope has an invalid circuit hash %q", h)
+	}
+
+	actual := InferEnvelope(zk.GetProofSystem(), w)
+	if actual.Format != env.GetFormat() {
+		return fmt.Errorf("proof envelope declares a %s proof, the proof data holds a %s proof", formatName(env.GetFormat()), formatName(actual.Format))
+	}
+	if actual.Encoding != env.GetEncoding() {
+		return fmt.Errorf("proof envelope declares encoding %s, the proof data has encoding %q", env.GetEncoding(), w.Encoding)
+	}
+	return nil
+}
+
+func envelopeSupported(env *ptx.ProofEnvelope) bool {
+	c := envelopeCombination{env.GetProofSystem(), env.GetCurve(), env.GetFormat(), env.GetEncoding()}
+	for _, s := range supportedEnvelopes {
+		if s == c {
+			return true
+		}
+	}
+	return false
+}
+
+// describeEnvelope names the combination of an envelope, e.g.
+// "GROTH16/BN254/GNARK_NATIVE/HEX"
+func describeEnvelope(env *ptx.ProofEnvelope) string {
+	return fmt.Sprintf("%s/%s/%s/%s", env.GetProofSystem(), env.GetCurve(), env.GetFormat(), env.GetEncoding())
+}
+
+func formatName(f ptx.ProofFormat) string {
+	switch f {
+	case ptx.ProofFormat_GNARK_NATIVE:
+		return "native gnark"
+	case ptx.ProofFormat_SNARKJS:
+		return "snarkjs"
+	}
+	return f.String()
+}
@@ -0,0 +1,283 @@
+// Package proofdata parses and re-encodes the JSON wrapper stored in the
+// proof_data field of a PTX file.
+//
+// Native gnark proofs were historically stored as hex of the uncompressed
+// proof, and snarkjs proofs as a plain JSON object. For size-constrained
+// transports (QR codes, NFC) the proof can instead be stored base64url
+// encoded, either as compressed curve points or gzipped JSON, with the
+// wrapper's "encoding" field declaring which. Public signals are always kept
+// in plain form so the anchor can be derived without decoding the proof.
+package proofdata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// Proof payload encodings
+const (
+	// EncodingHex is the uncompressed native proof as hex in proofHex. It is
+	// the default for native proofs without an encoding field.
+	EncodingHex = "hex"
+	// EncodingCompressed is the native proof with compressed curve points,
+	// base64url encoded without padding in proofBase64
+	EncodingCompressed = "base64-compressed"
+	// EncodingGzip is the snarkjs proof object gzipped and base64url encoded
+	// without padding in proofBase64
+	EncodingGzip = "base64-gzip"
+)
+
+// SourceNative marks proofs produced by the native gnark prover
+const SourceNative = "gnark_native"
+
+// MetadataJCS in the wrapper's "metadataEncoding" field declares that the
+// proof and anchor bind the canonical form of the signed metadata
+// (crypto.CanonicalJSON) rather than its bytes as stored. Wrappers without
+// the field predate canonicalization and bind the stored bytes.
+const MetadataJCS = "jcs"
+
+// SignalsExternal in the wrapper's "signals" field declares that the public
+// signals are not stored: the relying party supplies them out-of-band, so
+// the holder cannot tamper with them and the PTX file is smaller
+const SignalsExternal = "external"
+
+// MaxDecodedSize bounds the decoded proof payload, guarding against gzip
+// bombs
+const MaxDecodedSize = 256 << 10
+
+var (
+	ErrUnknownEncoding = errors.New("unknown proof encoding")
+	ErrDecodedTooLarge = errors.New("decoded proof exceeds maximum size")
+)
+
+var b64 = base64.RawURLEncoding
+
+// Wrapper is the proof_data JSON
+type Wrapper struct {
+	Source        string          `json:"source,omitempty"`
+	Encoding      string          `json:"encoding,omitempty"`
+	PublicSignals []string        `json:"publicSignals,omitempty"`
+	Proof         json.RawMessage `json:"proof,omitempty"`
+	ProofHex      string          `json:"proofHex,omitempty"`
+	ProofBase64   string          `json:"proofBase64,omitempty"`
+	// MetadataEncoding is how the signed metadata was serialized before
+	// hashing: MetadataJCS, or empty for the stored bytes
+	MetadataEncoding string `json:"metadataEncoding,omitempty"`
+	// Signals is SignalsExternal when PublicSignals are supplied by the
+	// relying party, empty when they are stored
+	Signals string `json:"signals,omitempty"`
+}
+
+// Parse decodes a proof_data wrapper. The proof payload itself is only
+// decoded by AppendNativeProof or SnarkJSProof.
+func Parse(data []byte) (*Wrapper, error) {
+	var w Wrapper
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// BoundMetadata returns the metadata string the proof binds, given the
+// signed metadata stored in the PTX file
+func (w *Wrapper) BoundMetadata(signed string) (string, error) {
+	switch w.MetadataEncoding {
+	case "":
+		return signed, nil
+	case MetadataJCS:
+		canonical, err := crypto.CanonicalJSON([]byte(signed))
+		if err != nil {
+			return "", err
+		}
+		return string(canonical), nil
+	default:
+		return "", fmt.Errorf("unknown metadata encoding %q", w.MetadataEncoding)
+	}
+}
+
+// WithMetadataEncoding sets the metadataEncoding field of a proof_data
+// wrapper
+func WithMetadataEncoding(data []byte, encoding string) ([]byte, error) {
+	w, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	w.MetadataEncoding = encoding
+	return json.Marshal(w)
+}
+
+// HasExternalSignals reports whether the public signals are supplied by the
+// relying party rather than stored in the wrapper
+func (w *Wrapper) HasExternalSignals() bool {
+	return w.Signals == SignalsExternal
+}
+
+// WithoutSignals removes the public signals of a proof_data wrapper and
+// marks them external. It returns the new wrapper and the removed signals,
+// which the relying party must be given.
+func WithoutSignals(data []byte) ([]byte, []string, error) {
+	w, err := Parse(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	if w.HasExternalSignals() {
+		return nil, nil, errors.New("public signals are already external")
+	}
+	signals := w.PublicSignals
+	w.PublicSignals, w.Signals = nil, SignalsExternal
+	out, err := json.Marshal(w)
+	return out, signals, err
+}
+
+// WithSignals stores the public signals in a proof_data wrapper whose
+// signals are external
+func WithSignals(data []byte, publicSignals []string) ([]byte, error) {
+	w, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	w.PublicSignals, w.Signals = publicSignals, ""
+	return json.Marshal(w)
+}
+
+// IsNative reports whether the wrapper holds a native gnark proof
+func (w *Wrapper) IsNative() bool {
+	return w.Source == SourceNative
+}
+
+// AppendNativeProof appends the serialized gnark proof to dst. The result is
+// readable by groth16.Proof.ReadFrom whether its points are compressed or not.
+func (w *Wrapper) AppendNativeProof(dst []byte) ([]byte, error) {
+	switch w.Encoding {
+	case "", EncodingHex:
+		if len(w.ProofHex)/2 > MaxDecodedSize {
+			return nil, ErrDecodedTooLarge
+		}
+		return hex.AppendDecode(dst, []byte(w.ProofHex))
+	case EncodingCompressed:
+		if b64.DecodedLen(len(w.ProofBase64)) > MaxDecodedSize {
+			return nil, ErrDecodedTooLarge
+		}
+		return b64.AppendDecode(dst, []byte(w.ProofBase64))
+	default:
+		return nil, fmt.Errorf("%w %q for native proof", ErrUnknownEncoding, w.Encoding)
+	}
+}
+
+// SnarkJSProof returns the snarkjs proof object
+func (w *Wrapper) SnarkJSProof() (json.RawMessage, error) {
+	switch w.Encoding {
+	case "":
+		return w.Proof, nil
+	case EncodingGzip:
+		compressed, err := b64.DecodeString(w.ProofBase64)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		data, err := io.ReadAll(io.LimitReader(zr, MaxDecodedSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > MaxDecodedSize {
+			return nil, ErrDecodedTooLarge
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("%w %q for snarkjs proof", ErrUnknownEncoding, w.Encoding)
+	}
+}
+
+// Compact rewrites a proof_data wrapper in its smallest encoding:
+// EncodingCompressed for native proofs and EncodingGzip otherwise
+func Compact(data []byte) ([]byte, error) {
+	w, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	if w.IsNative() {
+		return Encode(data, EncodingCompressed)
+	}
+	return Encode(data, EncodingGzip)
+}
+
+// Encode rewrites a proof_data wrapper with its proof in the given encoding.
+// Native proofs accept EncodingHex and EncodingCompressed; snarkjs proofs
+// accept "" (plain JSON) and EncodingGzip.
+func Encode(data []byte, encoding string) ([]byte, error) {
+	w, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	if w.Encoding == encoding || (w.Encoding == "" && encoding == EncodingHex && w.IsNative()) {
+		return data, nil
+	}
+
+	out := Wrapper{Source: w.Source, PublicSignals: w.PublicSignals, MetadataEncoding: w.MetadataEncoding, Signals: w.Signals}
+	if w.IsNative() {
+		raw, err := w.AppendNativeProof(nil)
+		if err != nil {
+			return nil, err
+		}
+		proof := groth16.NewProof(ecc.BN254)
+		if _, err := proof.ReadFrom(bytes.NewReader(raw)); err != nil {
+			return nil, fmt.Errorf("failed to read proof: %w", err)
+		}
+
+		var buf bytes.Buffer
+		switch encoding {
+		case EncodingHex:
+			// Keep the legacy form byte-compatible: no encoding field
+			if _, err := proof.WriteRawTo(&buf); err != nil {
+				return nil, err
+			}
+			out.ProofHex = hex.EncodeToString(buf.Bytes())
+		case EncodingCompressed:
+			if _, err := proof.WriteTo(&buf); err != nil {
+				return nil, err
+			}
+			out.Encoding = encoding
+			out.ProofBase64 = b64.EncodeToString(buf.Bytes())
+		default:
+			return nil, fmt.Errorf("%w %q for native proof", ErrUnknownEncoding, encoding)
+		}
+		return json.Marshal(out)
+	}
+
+	proofJSON, err := w.SnarkJSProof()
+	if err != nil {
+		return nil, err
+	}
+	switch encoding {
+	case "":
+		out.Proof = proofJSON
+	case EncodingGzip:
+		var buf bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if _, err := zw.Write(proofJSON); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		out.Encoding = encoding
+		out.ProofBase64 = b64.EncodeToString(buf.Bytes())
+	default:
+		return nil, fmt.Errorf("%w %q for snarkjs proof", ErrUnknownEncoding, encoding)
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,79 @@
+package prover
+
+import (
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Proving backends recorded in BenchmarkResult.Backend
+const (
+	BackendCPU    = "cpu"
+	BackendICICLE = "icicle"
+)
+
+// HasGPU reports whether this binary was built with the icicle build tag and
+// can offload Groth16 MSMs and NTTs to a CUDA device
+const HasGPU = icicleBuild
+
+// gpuState remembers the first GPU failure so later proofs go straight to the
+// CPU instead of failing on the device again
+var gpuState struct {
+	sync.Mutex
+	err error
+}
+
+// GPUError returns the error that disabled GPU proving in this process, or
+// nil if the GPU has not failed
+func GPUError() error {
+	gpuState.Lock()
+	defer gpuState.Unlock()
+	return gpuState.err
+}
+
+// proveHooks replaces the proving and the clock of a Prover. Release code
+// never sets them: whoever knows the randomness of a proof can recover the
+// witness from it.
+type proveHooks struct {
+	prove    func(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, w witness.Witness, inputs *CircuitInputs) (groth16.Proof, error)
+	issuedAt time.Time
+}
+
+// issuedAt returns the issued_at of a new PTX file
+func (p *Prover) issuedAt() *timestamppb.Timestamp {
+	if p.hooks != nil {
+		return timestamppb.New(p.hooks.issuedAt)
+	}
+	return timestamppb.Now()
+}
+
+// groth16Prove proves the witness w of inputs on the GPU when p.GPU is set
+// and the binary supports it, falling back to the CPU on any device error. It
+// returns the backend that produced the proof; callers report a fallback
+// through GPUError.
+func (p *Prover) groth16Prove(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, w witness.Witness, inputs *CircuitInputs) (groth16.Proof, string, error) {
+	if p.hooks != nil {
+		proof, err := p.hooks.prove(ccs, pk, w, inputs)
+		return proof, BackendCPU, err
+	}
+
+	if p.GPU && HasGPU && GPUError() == nil {
+		proof, err := proveICICLE(ccs, pk, w)
+		if err == nil {
+			return proof, BackendICICLE, nil
+		}
+
+		gpuState.Lock()
+		if gpuState.err == nil {
+			gpuState.err = err
+		}
+		gpuState.Unlock()
+	}
+
+	proof, err := groth16.Prove(ccs, pk, w)
+	return proof, BackendCPU, err
+}
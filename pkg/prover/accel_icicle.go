@@ -0,0 +1,26 @@
+//go:build icicle
+
+package prover
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+)
+
+const icicleBuild = true
+
+// proveICICLE proves with gnark's icicle backend. Device initialization
+// panics inside gnark when no usable CUDA device or backend library is found,
+// so panics are turned into errors to allow the CPU fallback.
+func proveICICLE(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, w witness.Witness) (proof groth16.Proof, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			proof, err = nil, fmt.Errorf("icicle: %v", r)
+		}
+	}()
+	return groth16.Prove(ccs, pk, w, backend.WithIcicleAcceleration())
+}
@@ -0,0 +1,17 @@
+//go:build !icicle
+
+package prover
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+)
+
+const icicleBuild = false
+
+func proveICICLE(constraint.ConstraintSystem, groth16.ProvingKey, witness.Witness) (groth16.Proof, error) {
+	return nil, errors.New("built without the icicle build tag")
+}
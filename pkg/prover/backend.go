@@ -0,0 +1,60 @@
+package prover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPublicSignalMismatch is returned when a prover outside this process
+// returns a proof for other public signals than the requested inputs
+var ErrPublicSignalMismatch = errors.New("prover returned other public signals than requested")
+
+// ProverBackend produces the proof_data of a PTX file for a set of circuit
+// inputs
+type ProverBackend interface {
+	// Name returns a short identifier for the backend
+	Name() string
+	// Prove generates a Groth16 proof and returns it in the JSON wrapper
+	// stored as proof_data
+	Prove(ctx context.Context, inputs *CircuitInputs) ([]byte, error)
+}
+
+// Native proves in-process with gnark, using or creating native.pk and
+// native.vk in the working directory
+type Native struct {
+	// GPU requests icicle acceleration, see Prover.GPU
+	GPU bool
+}
+
+func NewNative() *Native {
+	return &Native{}
+}
+
+func (n *Native) Name() string {
+	return "native"
+}
+
+// Prove implements ProverBackend. Proving is not interruptible, so ctx is
+// only checked before it starts.
+func (n *Native) Prove(ctx context.Context, inputs *CircuitInputs) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return (&Prover{GPU: n.GPU}).GenerateProofNative(inputs)
+}
+
+// checkPublicSignals ensures the public signals returned by a backend are
+// the requested ones, so that a misbehaving prover cannot have a proof of
+// other values written into the PTX
+func checkPublicSignals(got, want []string) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("%w: %d signals, want %d", ErrPublicSignalMismatch, len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return fmt.Errorf("%w: signal %d is %q, want %q", ErrPublicSignalMismatch, i, got[i], want[i])
+		}
+	}
+	return nil
+}
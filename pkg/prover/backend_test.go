@@ -0,0 +1,80 @@
+package prover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func testInputs() *CircuitInputs {
+	return &CircuitInputs{
+		NullifierHash:  "1",
+		Commitment:     "2",
+		Fqdn:           "3",
+		MetadataHashP1: "4",
+		MetadataHashP2: "5",
+		TrustMethod:    "1",
+		Nullifier:      "6",
+		Secret:         "7",
+	}
+}
+
+func TestRemotePublicSignals(t *testing.T) {
+	inputs := testInputs()
+	var signals []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"proof":         map[string]interface{}{"protocol": "groth16"},
+			"publicSignals": signals,
+		})
+	}))
+	defer srv.Close()
+	remote := &Remote{URL: srv.URL, Client: srv.Client()}
+
+	signals = inputs.PublicSignals()
+	if _, err := remote.Prove(context.Background(), inputs); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, sigs := range map[string][]string{
+		"other nullifier": {"9", "2", "3", "4", "5", "1"},
+		"missing signal":  {"1", "2", "3", "4", "5"},
+		"extra signal":    {"1", "2", "3", "4", "5", "1", "0"},
+	} {
+		signals = sigs
+		if _, err := remote.Prove(context.Background(), inputs); !errors.Is(err, ErrPublicSignalMismatch) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestWorkdirPublicSignals(t *testing.T) {
+	inputs := testInputs()
+	w, err := newWorkdir(ExecOptions{TempDir: t.TempDir()}, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+	if err := os.WriteFile(w.proof, []byte(`{"protocol":"groth16"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writePublic := func(sigs []string) {
+		data, _ := json.Marshal(sigs)
+		if err := os.WriteFile(w.public, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writePublic(inputs.PublicSignals())
+	if _, err := w.proofData(); err != nil {
+		t.Fatal(err)
+	}
+	writePublic([]string{"1", "2", "3", "4", "5", "2"})
+	if _, err := w.proofData(); !errors.Is(err, ErrPublicSignalMismatch) {
+		t.Errorf("err = %v", err)
+	}
+}
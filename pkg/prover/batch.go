@@ -0,0 +1,68 @@
+package prover
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ProofResult is one proof produced by GenerateProofs
+type ProofResult struct {
+	// Index is the position of the inputs in the slice passed to
+	// GenerateProofs
+	Index int
+	// ProofData is the "gnark_native" proof_data wrapper, nil if Err is set
+	ProofData []byte
+	Err       error
+}
+
+// GenerateProofs proves every entry of inputs natively on a pool of
+// parallelism workers (runtime.NumCPU() if <= 0). The circuit is compiled and
+// the proving key loaded once and shared by all workers. All inputs must be
+// for the circuit of p.KeyID.
+//
+// Results are streamed in completion order, not input order; use
+// ProofResult.Index to match them up. The channel is closed after the last
+// result and must be drained by the caller. A non-nil error means the circuit
+// or keys could not be prepared and no proof was attempted.
+func (p *Prover) GenerateProofs(inputs []*CircuitInputs, parallelism int) (<-chan ProofResult, error) {
+	ccs, err := CompileCircuitFor(p.keyID())
+	if err != nil {
+		return nil, err
+	}
+	pk, _, err := loadOrSetupKeys(ccs, p.keyID())
+	if err != nil {
+		return nil, fmt.Errorf("key setup failed: %w", err)
+	}
+
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+	parallelism = min(parallelism, max(len(inputs), 1))
+
+	jobs := make(chan int)
+	results := make(chan ProofResult, parallelism)
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				data, err := p.ProveWithKey(ccs, pk, inputs[i])
+				results <- ProofResult{Index: i, ProofData: data, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range inputs {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results, nil
+}
@@ -0,0 +1,69 @@
+package prover
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	mrand "math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+)
+
+// SetDeterministic makes the native proofs and PTX files of p byte-stable, for
+// golden files: the proving randomness of each proof is a ChaCha8 stream
+// keyed by seed and the proof's public inputs, every file is issued at
+// issuedAt, and the PTX proto is marshaled deterministically. GPU proving is
+// disabled. It is only compiled into the tests of this package.
+func (p *Prover) SetDeterministic(seed []byte, issuedAt time.Time) error {
+	if len(seed) == 0 {
+		return errors.New("deterministic proving needs a seed")
+	}
+	p.hooks = &proveHooks{
+		prove: func(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, w witness.Witness, inputs *CircuitInputs) (proof groth16.Proof, err error) {
+			err = withRand(proofRand(seed, inputs), func() error {
+				proof, err = groth16.Prove(ccs, pk, w)
+				return err
+			})
+			return proof, err
+		},
+		issuedAt: issuedAt,
+	}
+	return nil
+}
+
+// proofRand returns the randomness source of the proof of inputs, derived
+// from the seed so that proofs do not depend on the order they are made in
+func proofRand(seed []byte, inputs *CircuitInputs) io.Reader {
+	h := sha256.New()
+	h.Write([]byte("ptx-deterministic-proof"))
+	h.Write(seed)
+	for _, s := range inputs.PublicSignals() {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return mrand.NewChaCha8(key)
+}
+
+// randMu serializes swaps of crypto/rand.Reader
+var randMu sync.Mutex
+
+// withRand runs fn with crypto/rand.Reader replaced by r. gnark samples the
+// proving randomness from crypto/rand without a way to inject a source.
+// Other goroutines of the test binary reading crypto/rand meanwhile also get
+// r.
+func withRand(r io.Reader, fn func() error) error {
+	randMu.Lock()
+	defer randMu.Unlock()
+
+	orig := rand.Reader
+	rand.Reader = r
+	defer func() { rand.Reader = orig }()
+	return fn()
+}
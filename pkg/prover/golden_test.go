@@ -0,0 +1,63 @@
+package prover_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGoldenPTX pins the serialization of a native PTX file. If a change to
+// the format is intended, regenerate the file with go test -update.
+func TestGoldenPTX(t *testing.T) {
+	ccs, pk, _, err := fixture.Keys(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]interface{}{"role": "validator", "exp": 1893456000}
+
+	issue := func() []byte {
+		p := prover.NewProver()
+		if err := p.SetDeterministic([]byte("golden"), fixture.IssuedAt); err != nil {
+			t.Fatal(err)
+		}
+		inputs, err := p.GenerateCircuitInputs("example.com", metadata, "1234", "5678", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proofData, err := p.ProveWithKey(ccs, pk, inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := p.CreatePtxFile(proofData, metadata, "example.com", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	got := issue()
+	if !bytes.Equal(got, issue()) {
+		t.Fatal("deterministic proving produced different files")
+	}
+
+	golden := filepath.Join("testdata", "golden.ptx")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("PTX serialization changed (%d bytes, golden %d); run go test ./pkg/prover -update if intended", len(got), len(want))
+	}
+}
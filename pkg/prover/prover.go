@@ -2,17 +2,28 @@ package prover
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
-	"os/exec"
-
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ipfs"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
 	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
@@ -21,6 +32,7 @@ import (
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
@@ -28,8 +40,23 @@ const (
 	nativePKPath = "native.pk"
 )
 
+// KeyPaths returns the native proving and verification key files of the
+// circuit of keyID: native.pk and native.vk for the default circuit, and
+// native_<variant>.pk and .vk for the others
+func KeyPaths(keyID string) (pkPath, vkPath string) {
+	switch keyID {
+	case signals.MetadataSHA256KeyID:
+		return "native_sha256.pk", "native_sha256.vk"
+	case signals.ScopeBoundKeyID:
+		return "native_v3.pk", "native_v3.vk"
+	}
+	return nativePKPath, nativeVKPath
+}
+
 // loadOrSetupKeys loads cached keys or runs setup and caches them
-func loadOrSetupKeys(ccs constraint.ConstraintSystem) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+func loadOrSetupKeys(ccs constraint.ConstraintSystem, keyID string) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	nativePKPath, nativeVKPath := KeyPaths(keyID)
+
 	// Try to load existing keys
 	if _, err := os.Stat(nativeVKPath); err == nil {
 		if _, err := os.Stat(nativePKPath); err == nil {
@@ -89,6 +116,78 @@ func loadOrSetupKeys(ccs constraint.ConstraintSystem) (groth16.ProvingKey, groth
 	return pk, vk, nil
 }
 
+// LoadProvingKey reads a native proving key file
+func LoadProvingKey(path string) (groth16.ProvingKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pk file: %w", err)
+	}
+	defer f.Close()
+
+	pk := groth16.NewProvingKey(ecc.BN254)
+	if _, err := pk.ReadFrom(f); err != nil {
+		return nil, fmt.Errorf("failed to read pk: %w", err)
+	}
+	return pk, nil
+}
+
+// CompileCircuit compiles the DoH circuit to R1CS
+func CompileCircuit() (constraint.ConstraintSystem, error) {
+	return CompileCircuitFor(signals.DefaultVerificationKeyID)
+}
+
+// CompileCircuitFor compiles the circuit of a verification key ID to R1CS
+func CompileCircuitFor(keyID string) (constraint.ConstraintSystem, error) {
+	c, err := circuit.ForKeyID(keyID)
+	if err != nil {
+		return nil, err
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, c)
+	if err != nil {
+		return nil, fmt.Errorf("circuit compilation failed: %w", err)
+	}
+	if _, ok := circuitHashes.Load(keyID); !ok {
+		if hash, err := circuit.Hash(ccs); err == nil {
+			circuitHashes.Store(keyID, hash)
+		}
+	}
+	return ccs, nil
+}
+
+// circuitHashes caches the constraint system hash of every circuit compiled
+// by CompileCircuitFor, by key ID
+var circuitHashes sync.Map
+
+// CircuitHash returns the constraint system hash of the circuit of a
+// verification key ID (see circuit.Hash), compiling it unless it was
+// compiled before
+func CircuitHash(keyID string) (string, error) {
+	if hash, ok := circuitHashes.Load(keyID); ok {
+		return hash.(string), nil
+	}
+	ccs, err := CompileCircuitFor(keyID)
+	if err != nil {
+		return "", err
+	}
+	return circuit.Hash(ccs)
+}
+
+// ProveWithKey proves inputs with an already compiled circuit and loaded
+// proving key and returns the "gnark_native" proof_data wrapper. Unlike
+// GenerateProofNative it never runs a setup.
+func (p *Prover) ProveWithKey(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, inputs *CircuitInputs) ([]byte, error) {
+	witness, err := frontend.NewWitness(inputs.Circuit(), ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, fmt.Errorf("witness creation failed: %w", err)
+	}
+
+	proof, _, err := p.groth16Prove(ccs, pk, witness, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("proving failed: %w", err)
+	}
+	return NativeProofData(proof, inputs)
+}
+
 // CircuitInputs represents the public and private inputs for the SDV circuit
 type CircuitInputs struct {
 	NullifierHash  string `json:"nullifierHash"`
@@ -99,6 +198,27 @@ type CircuitInputs struct {
 	TrustMethod    string `json:"trustMethod"`
 	Nullifier      string `json:"nullifier"`
 	Secret         string `json:"secret"`
+
+	// KeyID selects the circuit, empty for signals.DefaultVerificationKeyID.
+	// It is omitted from the JSON for the default circuit, whose inputs are
+	// passed unchanged to circom witness generators.
+	KeyID string `json:"keyId,omitempty"`
+	// MetadataLength and Metadata are the metadata bytes, packed as by
+	// crypto.PackBytes, for circuits that hash the metadata in-circuit
+	MetadataLength string   `json:"metadataLength,omitempty"`
+	Metadata       []string `json:"metadata,omitempty"`
+	// AudienceHash and ScopeHash bind the audience and scopes of the token,
+	// for circuits folding them into the context hash
+	AudienceHash string `json:"audienceHash,omitempty"`
+	ScopeHash    string `json:"scopeHash,omitempty"`
+}
+
+// keyID returns the verification key ID of the circuit the inputs are for
+func (inputs *CircuitInputs) keyID() string {
+	if inputs.KeyID == "" {
+		return signals.DefaultVerificationKeyID
+	}
+	return inputs.KeyID
 }
 
 // BenchmarkResult holds timing statistics
@@ -106,15 +226,128 @@ type BenchmarkResult struct {
 	CompileTimeMs float64
 	WitnessTimeMs float64
 	ProveTimeMs   float64
+	// Backend is the hardware that produced the proof: BackendCPU or
+	// BackendICICLE
+	Backend string
 }
 
 // Prover handles the proof generation process
-type Prover struct{}
+type Prover struct {
+	// GPU requests icicle acceleration for native proving. It only takes
+	// effect in binaries built with the icicle tag (see HasGPU) and falls
+	// back to the CPU when no device is usable.
+	GPU bool
+	// KeyID selects the circuit to prove with and is written to the PTX
+	// file. Empty means signals.DefaultVerificationKeyID;
+	// signals.MetadataSHA256KeyID hashes the metadata in-circuit and
+	// signals.ScopeBoundKeyID binds the audience and scopes in the
+	// commitment.
+	KeyID string
+	// AdditionalAnchors lists further trust methods publishing the DNS
+	// anchor record on the same domain, for redundancy:
+	// ptx.TrustMethod_WELL_KNOWN, ptx.TrustMethod_IPFS whose anchor
+	// document the issuer pins under the CID written to the file, or
+	// ptx.TrustMethod_ETHEREUM.
+	AdditionalAnchors []ptx.TrustMethod
+	// EthereumRegistry is the registry contract ptx.TrustMethod_ETHEREUM
+	// anchors name. Empty anchors in the ENS text records of the domain.
+	EthereumRegistry string
+	// AnchorPolicy says whether any or all anchors must hold the record
+	AnchorPolicy ptx.AnchorPolicy
+	// TSA, when set, timestamps every PTX file at creation so its issuance
+	// time can be proven independently of the issuer's clock
+	TSA *tsa.Client
+	// FixedLabels lists the trust methods, ptx.TrustMethod_DOH or
+	// ptx.TrustMethod_WELL_KNOWN, whose anchor lives at the fixed label
+	// _ptx.<domain> instead of a label derived from the commitment. The
+	// record there publishes AnchorKey, which signs every token.
+	FixedLabels []ptx.TrustMethod
+	// AnchorKey signs tokens anchored under a fixed label
+	AnchorKey ed25519.PrivateKey
+	// AnchorSignature is used instead of signing with AnchorKey, for tokens
+	// whose anchor signature an issuer made (see package issuer)
+	AnchorSignature []byte
+
+	// FormatVersion is the PTX format version written, ptxloader.FormatVersion
+	// if zero. Version 1 keeps the Base27 anchor labels that verifiers
+	// predating version 2 look up.
+	FormatVersion uint32
+
+	// hooks is only set by tests of this package, to make proofs and files
+	// byte-stable
+	hooks *proveHooks
+}
+
+// labelMode returns the label mode of the anchors of trust method m
+func (p *Prover) labelMode(m ptx.TrustMethod) ptx.LabelMode {
+	if slices.Contains(p.FixedLabels, m) {
+		return ptx.LabelMode_LABEL_FIXED
+	}
+	return ptx.LabelMode_LABEL_COMMITMENT
+}
+
+// formatVersion returns the PTX format version the prover writes
+func (p *Prover) formatVersion() uint32 {
+	if p.FormatVersion == 0 {
+		return ptxloader.FormatVersion
+	}
+	return p.FormatVersion
+}
+
+// keyID returns the verification key ID the prover issues proofs under
+func (p *Prover) keyID() string {
+	if p.KeyID == "" {
+		return signals.DefaultVerificationKeyID
+	}
+	return p.KeyID
+}
 
 func NewProver() *Prover {
 	return &Prover{}
 }
 
+// MaxMetadataSize bounds the canonical metadata of a token, see
+// metaschema.MaxSize
+const MaxMetadataSize = metaschema.MaxSize
+
+// ValidateSecretInput checks the nullifier or secret of a credential, called
+// name in errors: a decimal integer without sign or leading zeros, non-zero
+// and less than the BN254 scalar field modulus. Without it, unparsable values
+// would silently become zero and large ones would be reduced modulo the
+// field. The value itself is never included in the error.
+func ValidateSecretInput(name, s string) error {
+	e, err := crypto.ParseFr(s)
+	switch {
+	case errors.Is(err, crypto.ErrSignalOutOfField):
+		return fmt.Errorf("%s must be less than the BN254 scalar field modulus %s", name, crypto.SNARK_FIELD_SIZE)
+	case err != nil:
+		return fmt.Errorf("%s must be a decimal integer without sign or leading zeros", name)
+	case e.IsZero():
+		return fmt.Errorf("%s must not be zero", name)
+	}
+	return nil
+}
+
+// DecimalFromHex converts a hexadecimal nullifier or secret, with an optional
+// 0x prefix, to the decimal form GenerateCircuitInputs takes
+func DecimalFromHex(name, s string) (string, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	n, ok := new(big.Int).SetString(digits, 16)
+	if !ok || digits == "" || digits[0] == '+' || digits[0] == '-' {
+		return "", fmt.Errorf("%s must be a hexadecimal integer", name)
+	}
+	return n.String(), nil
+}
+
+// checkTrustMethod rejects trust methods the PTX format does not define, which
+// would produce files no verifier can handle
+func checkTrustMethod(trustMethod int) error {
+	if trustMethod < 0 || trustMethod > math.MaxInt32 || !ptx.TrustMethod(trustMethod).IsValid() {
+		return fmt.Errorf("invalid trust method %d (want 1=DOH, 2=GIST, 3=WELL_KNOWN, 4=IPFS or 5=ETHEREUM)", trustMethod)
+	}
+	return nil
+}
+
 // GenerateCircuitInputs computes the inputs for the SDV circuit based on the provided parameters
 func (p *Prover) GenerateCircuitInputs(
 	domain string,
@@ -123,25 +356,55 @@ func (p *Prover) GenerateCircuitInputs(
 	secret string,
 	trustMethod int,
 ) (*CircuitInputs, error) {
-	// 1. Calculate Metadata Hash
-	metaBytes, err := json.Marshal(metadata)
+	if err := checkTrustMethod(trustMethod); err != nil {
+		return nil, err
+	}
+	if err := ValidateSecretInput("nullifier", nullifier); err != nil {
+		return nil, err
+	}
+	if err := ValidateSecretInput("secret", secret); err != nil {
+		return nil, err
+	}
+
+	if err := metaschema.Check(metadata); err != nil {
+		return nil, err
+	}
+
+	// 1. Calculate Metadata Hash over the canonical JSON
+	metaBytes, err := crypto.MarshalCanonical(metadata)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
 	}
-	metaHex := crypto.Sha256Hex(metaBytes)
-	p1, p2 := crypto.SplitHashToFieldElements(metaHex)
+	if len(metaBytes) > MaxMetadataSize {
+		return nil, fmt.Errorf("metadata is %d bytes in canonical form, the limit is %d", len(metaBytes), MaxMetadataSize)
+	}
+	p1, p2, err := crypto.SplitDigest(crypto.Sha256(metaBytes), crypto.CanonicalLimbEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split metadata hash: %w", err)
+	}
 
-	// 2. FQDN hash
-	domainHashBytes := crypto.Sha256([]byte(domain))
-	// Convert to fr.Element and mod by field size (done automatically by SetBigInt)
-	var fqdnFr fr.Element
-	fqdnFr.SetBigInt(new(big.Int).SetBytes(domainHashBytes))
+	// 2. FQDN hash of the normalized domain, SHA256 mod field size
+	fqdnFr, err := crypto.HashDomain(domain)
+	if err != nil {
+		return nil, err
+	}
 
-	// 3. Context Hash = Hash(fqdn, metaP1, metaP2, trustMethod)
+	// 3. Context Hash = Hash(fqdn, metaP1, metaP2, trustMethod), followed by
+	// the audience and scope hashes for the scope-bound circuit
 	var tmFr fr.Element
 	tmFr.SetInt64(int64(trustMethod))
+	context := []*fr.Element{fqdnFr, p1, p2, &tmFr}
+
+	var audienceFr, scopeFr *fr.Element
+	if p.keyID() == signals.ScopeBoundKeyID {
+		audienceFr, scopeFr, err = crypto.MetadataBinding(string(metaBytes))
+		if err != nil {
+			return nil, err
+		}
+		context = append(context, audienceFr, scopeFr)
+	}
 
-	contextHash, err := crypto.CircuitHash([]*fr.Element{&fqdnFr, p1, p2, &tmFr})
+	contextHash, err := crypto.CircuitHash(context)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute context hash: %w", err)
 	}
@@ -162,7 +425,7 @@ func (p *Prover) GenerateCircuitInputs(
 		return nil, fmt.Errorf("failed to compute nullifier hash: %w", err)
 	}
 
-	return &CircuitInputs{
+	inputs := &CircuitInputs{
 		NullifierHash:  nullifierHash.String(),
 		Commitment:     commitment.String(),
 		Fqdn:           fqdnFr.String(),
@@ -171,117 +434,58 @@ func (p *Prover) GenerateCircuitInputs(
 		TrustMethod:    fmt.Sprintf("%d", trustMethod),
 		Nullifier:      nullifier,
 		Secret:         secret,
-	}, nil
-}
-
-// GenerateProof generates a Groth16 proof using snarkjs shell-out (for Circom compatibility)
-func (p *Prover) GenerateProof(
-	inputs *CircuitInputs,
-	wasmPath string,
-	zkeyPath string,
-) ([]byte, error) {
-	// Strategy: Shell out to snarkjs for robustness and compatibility with Circom artifacts
-
-	// Prepare snarkjs command wrapper
-	// We try to find 'snarkjs' in PATH or use 'npx snarkjs'
-	var snarkjsCmd []string
-	if _, err := exec.LookPath("snarkjs"); err == nil {
-		snarkjsCmd = []string{"snarkjs"}
-	} else if _, err := exec.LookPath("npx"); err == nil {
-		snarkjsCmd = []string{"npx", "snarkjs"}
-	} else {
-		return nil, fmt.Errorf("neither 'snarkjs' nor 'npx' found in PATH. Please install snarkjs")
-	}
-
-	// 1. Write inputs to JSON
-	inputBytes, err := json.Marshal(inputs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal inputs: %w", err)
-	}
-
-	tmpInput, err := os.CreateTemp("", "input-*.json")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp input: %w", err)
-	}
-	defer os.Remove(tmpInput.Name())
-	if _, err := tmpInput.Write(inputBytes); err != nil {
-		return nil, fmt.Errorf("failed to write input: %w", err)
 	}
-	tmpInput.Close()
 
-	// 2. Witness Generation
-	tmpWitness, err := os.CreateTemp("", "witness-.wtns")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp witness: %w", err)
-	}
-	witnessPath := tmpWitness.Name()
-	tmpWitness.Close()
-	defer os.Remove(witnessPath)
-
-	// cmd: snarkjs wtns calculate <wasm> <input> <output>
-	argsWtns := append(snarkjsCmd, "wtns", "calculate", wasmPath, tmpInput.Name(), witnessPath)
-	cmdWtns := exec.Command(argsWtns[0], argsWtns[1:]...)
-	if out, err := cmdWtns.CombinedOutput(); err != nil {
-		return nil, fmt.Errorf("snarkjs witness calculation failed: %v, output: %s", err, out)
-	}
-
-	// 3. Proof Generation
-	tmpProof, err := os.CreateTemp("", "proof-*.json")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp proof: %w", err)
-	}
-	proofPath := tmpProof.Name()
-	tmpProof.Close()
-	defer os.Remove(proofPath)
-
-	tmpPublic, err := os.CreateTemp("", "public-*.json")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp public: %w", err)
-	}
-	publicPath := tmpPublic.Name()
-	tmpPublic.Close()
-	defer os.Remove(publicPath)
+	// 6. Circuit-specific inputs
+	switch keyID := p.keyID(); keyID {
+	case signals.DefaultVerificationKeyID:
+	case signals.MetadataSHA256KeyID:
+		chunks := make([]fr.Element, signals.MetadataSHA256Chunks)
+		if err := crypto.PackBytes(chunks, metaBytes); err != nil {
+			return nil, fmt.Errorf("metadata too long for %s (max %d bytes): %w", keyID, circuit.MaxMetadataBytes, err)
+		}
+		inputs.KeyID = keyID
+		inputs.MetadataLength = fmt.Sprintf("%d", len(metaBytes))
+		for i := range chunks {
+			inputs.Metadata = append(inputs.Metadata, chunks[i].String())
+		}
+	case signals.ScopeBoundKeyID:
+		inputs.KeyID = keyID
+		inputs.AudienceHash = audienceFr.String()
+		inputs.ScopeHash = scopeFr.String()
+	default:
+		return nil, fmt.Errorf("unknown verification key ID %q", keyID)
+	}
+	return inputs, nil
+}
 
-	// cmd: snarkjs groth16 prove <zkey> <witness> <proof.json> <public.json>
-	argsProve := append(snarkjsCmd, "groth16", "prove", zkeyPath, witnessPath, proofPath, publicPath)
-	cmdProve := exec.Command(argsProve[0], argsProve[1:]...)
-	if out, err := cmdProve.CombinedOutput(); err != nil {
-		return nil, fmt.Errorf("snarkjs proving failed: %v, output: %s", err, out)
+// GenerateCircuitInputsWithChallenge is GenerateCircuitInputs for a fresh
+// token answering a verifier's challenge (see package challenge). The
+// challenge becomes the "nonce" of the metadata, so the proof binds it. It
+// returns the metadata with the nonce set, which is what CreatePtxFile must
+// be given; metadata itself is not modified.
+func (p *Prover) GenerateCircuitInputsWithChallenge(
+	domain string,
+	metadata map[string]interface{},
+	nullifier string,
+	secret string,
+	trustMethod int,
+	challenge string,
+) (*CircuitInputs, map[string]interface{}, error) {
+	if challenge == "" {
+		return nil, nil, fmt.Errorf("challenge is empty")
 	}
-
-	// 4. Read Proof
-	proofBytes, err := ioutil.ReadFile(proofPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read proof: %w", err)
+	answered := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		answered[k] = v
 	}
+	answered["nonce"] = challenge
 
-	publicBytes, err := ioutil.ReadFile(publicPath)
+	inputs, err := p.GenerateCircuitInputs(domain, answered, nullifier, secret, trustMethod)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read public signals: %w", err)
+		return nil, nil, err
 	}
-
-	// We need to package them together as expected by verifier?
-	// The internal verifier logic expects a JSON with "proof" (the snarkjs proof object) and "publicSignals" array
-
-	var proofRaw json.RawMessage
-	if err := json.Unmarshal(proofBytes, &proofRaw); err != nil {
-		return nil, fmt.Errorf("failed to parse proof json: %w", err)
-	}
-
-	var publicSigs []string
-	if err := json.Unmarshal(publicBytes, &publicSigs); err != nil {
-		return nil, fmt.Errorf("failed to parse public signals json: %w", err)
-	}
-
-	wrapper := struct {
-		PublicSignals []string        `json:"publicSignals"`
-		Proof         json.RawMessage `json:"proof"`
-	}{
-		PublicSignals: publicSigs,
-		Proof:         proofRaw,
-	}
-
-	return json.Marshal(wrapper)
+	return inputs, answered, nil
 }
 
 // GenerateProofNative generates a proof using purely Go (Gnark)
@@ -289,14 +493,13 @@ func (p *Prover) GenerateProof(
 // NOTE: For a real production system, you would load pre-computed CCS/PK/VK.
 func (p *Prover) GenerateProofNative(inputs *CircuitInputs) ([]byte, error) {
 	// 1. Compile Circuit
-	var dohCircuit circuit.DoHCircuit
-	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &dohCircuit)
+	ccs, err := CompileCircuitFor(inputs.keyID())
 	if err != nil {
-		return nil, fmt.Errorf("circuit compilation failed: %w", err)
+		return nil, err
 	}
 
 	// 2. Setup (with key caching)
-	pk, vk, err := loadOrSetupKeys(ccs)
+	pk, vk, err := loadOrSetupKeys(ccs, inputs.keyID())
 	if err != nil {
 		return nil, fmt.Errorf("key setup failed: %w", err)
 	}
@@ -308,18 +511,7 @@ func (p *Prover) GenerateProofNative(inputs *CircuitInputs) ([]byte, error) {
 
 	// 3. Create Witness
 	// Mapped from inputs
-	assignment := circuit.DoHCircuit{
-		NullifierHash:  fromString(inputs.NullifierHash),
-		Commitment:     fromString(inputs.Commitment),
-		Fqdn:           fromString(inputs.Fqdn),
-		MetadataHashP1: fromString(inputs.MetadataHashP1),
-		MetadataHashP2: fromString(inputs.MetadataHashP2),
-		TrustMethod:    fromString(inputs.TrustMethod),
-		Nullifier:      fromString(inputs.Nullifier),
-		Secret:         fromString(inputs.Secret),
-	}
-
-	witness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	witness, err := frontend.NewWitness(inputs.Circuit(), ecc.BN254.ScalarField())
 	if err != nil {
 		return nil, fmt.Errorf("witness creation failed: %w", err)
 	}
@@ -330,7 +522,7 @@ func (p *Prover) GenerateProofNative(inputs *CircuitInputs) ([]byte, error) {
 	}
 
 	// 4. Prove
-	proof, err := groth16.Prove(ccs, pk, witness)
+	proof, _, err := p.groth16Prove(ccs, pk, witness, inputs)
 	if err != nil {
 		return nil, fmt.Errorf("proving failed: %w", err)
 	}
@@ -360,14 +552,7 @@ func (p *Prover) GenerateProofNative(inputs *CircuitInputs) ([]byte, error) {
 	// For public signals, we can extract them?
 	// Gnark witness is binary.
 	// We can manually construct the list of strings since we have the inputs.
-	publicSigs := []string{
-		inputs.NullifierHash,
-		inputs.Commitment,
-		inputs.Fqdn,
-		inputs.MetadataHashP1,
-		inputs.MetadataHashP2,
-		inputs.TrustMethod,
-	}
+	publicSigs := inputs.PublicSignals()
 
 	// To make it JSON compatible with generic readers, let's encode proof as Base64 or Hex?
 	// The current PTX format stores ProofData as bytes.
@@ -417,34 +602,22 @@ func (p *Prover) BenchmarkNative(inputs *CircuitInputs) (*BenchmarkResult, []byt
 
 	// 1. Compile Circuit
 	start := time.Now()
-	var dohCircuit circuit.DoHCircuit
-	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &dohCircuit)
+	ccs, err := CompileCircuitFor(inputs.keyID())
 	if err != nil {
-		return nil, nil, fmt.Errorf("circuit compilation failed: %w", err)
+		return nil, nil, err
 	}
 	result.CompileTimeMs = float64(time.Since(start).Microseconds()) / 1000.0
 
 	// 2. Setup (we don't benchmark setup as it's typically pre-generated,
 	// but we need the keys)
-	pk, _, err := loadOrSetupKeys(ccs)
+	pk, _, err := loadOrSetupKeys(ccs, inputs.keyID())
 	if err != nil {
 		return nil, nil, fmt.Errorf("key setup failed: %w", err)
 	}
 
 	// 3. Create Witness
 	start = time.Now()
-	assignment := circuit.DoHCircuit{
-		NullifierHash:  fromString(inputs.NullifierHash),
-		Commitment:     fromString(inputs.Commitment),
-		Fqdn:           fromString(inputs.Fqdn),
-		MetadataHashP1: fromString(inputs.MetadataHashP1),
-		MetadataHashP2: fromString(inputs.MetadataHashP2),
-		TrustMethod:    fromString(inputs.TrustMethod),
-		Nullifier:      fromString(inputs.Nullifier),
-		Secret:         fromString(inputs.Secret),
-	}
-
-	witness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	witness, err := frontend.NewWitness(inputs.Circuit(), ecc.BN254.ScalarField())
 	if err != nil {
 		return nil, nil, fmt.Errorf("witness creation failed: %w", err)
 	}
@@ -457,18 +630,91 @@ func (p *Prover) BenchmarkNative(inputs *CircuitInputs) (*BenchmarkResult, []byt
 
 	// 4. Prove
 	start = time.Now()
-	proof, err := groth16.Prove(ccs, pk, witness)
+	proof, backend, err := p.groth16Prove(ccs, pk, witness, inputs)
 	if err != nil {
 		return nil, nil, fmt.Errorf("proving failed: %w", err)
 	}
+	result.Backend = backend
 	result.ProveTimeMs = float64(time.Since(start).Microseconds()) / 1000.0
 
 	// 5. Serialize (identical to GenerateProofNative)
-	buf := new(bytes.Buffer)
-	proof.WriteRawTo(buf)
-	proofBytes := buf.Bytes()
+	proofJSON, err := NativeProofData(proof, inputs)
+	return result, proofJSON, err
+}
+
+// Assignment maps the inputs onto a full circuit witness
+func (inputs *CircuitInputs) Assignment() circuit.DoHCircuit {
+	return circuit.DoHCircuit{
+		NullifierHash:  fromString(inputs.NullifierHash),
+		Commitment:     fromString(inputs.Commitment),
+		Fqdn:           fromString(inputs.Fqdn),
+		MetadataHashP1: fromString(inputs.MetadataHashP1),
+		MetadataHashP2: fromString(inputs.MetadataHashP2),
+		TrustMethod:    fromString(inputs.TrustMethod),
+		Nullifier:      fromString(inputs.Nullifier),
+		Secret:         fromString(inputs.Secret),
+	}
+}
+
+// Circuit maps the inputs onto a full witness of the circuit of their key ID
+func (inputs *CircuitInputs) Circuit() frontend.Circuit {
+	switch inputs.keyID() {
+	case signals.MetadataSHA256KeyID:
+	case signals.ScopeBoundKeyID:
+		return &circuit.ScopeBoundCircuit{
+			NullifierHash:  fromString(inputs.NullifierHash),
+			Commitment:     fromString(inputs.Commitment),
+			Fqdn:           fromString(inputs.Fqdn),
+			MetadataHashP1: fromString(inputs.MetadataHashP1),
+			MetadataHashP2: fromString(inputs.MetadataHashP2),
+			TrustMethod:    fromString(inputs.TrustMethod),
+			AudienceHash:   fromString(inputs.AudienceHash),
+			ScopeHash:      fromString(inputs.ScopeHash),
+			Nullifier:      fromString(inputs.Nullifier),
+			Secret:         fromString(inputs.Secret),
+		}
+	default:
+		assignment := inputs.Assignment()
+		return &assignment
+	}
+	assignment := &circuit.MetadataSHA256Circuit{
+		NullifierHash:  fromString(inputs.NullifierHash),
+		Commitment:     fromString(inputs.Commitment),
+		Fqdn:           fromString(inputs.Fqdn),
+		TrustMethod:    fromString(inputs.TrustMethod),
+		MetadataLength: fromString(inputs.MetadataLength),
+		Nullifier:      fromString(inputs.Nullifier),
+		Secret:         fromString(inputs.Secret),
+	}
+	for i := range assignment.Metadata {
+		assignment.Metadata[i] = 0
+		if i < len(inputs.Metadata) {
+			assignment.Metadata[i] = fromString(inputs.Metadata[i])
+		}
+	}
+	return assignment
+}
 
-	publicSigs := []string{
+// PublicSignals returns the public inputs in circuit order
+func (inputs *CircuitInputs) PublicSignals() []string {
+	if inputs.keyID() == signals.MetadataSHA256KeyID {
+		sigs := []string{
+			inputs.NullifierHash,
+			inputs.Commitment,
+			inputs.Fqdn,
+			inputs.TrustMethod,
+			inputs.MetadataLength,
+		}
+		for i := 0; i < signals.MetadataSHA256Chunks; i++ {
+			chunk := "0"
+			if i < len(inputs.Metadata) {
+				chunk = inputs.Metadata[i]
+			}
+			sigs = append(sigs, chunk)
+		}
+		return sigs
+	}
+	sigs := []string{
 		inputs.NullifierHash,
 		inputs.Commitment,
 		inputs.Fqdn,
@@ -476,6 +722,19 @@ func (p *Prover) BenchmarkNative(inputs *CircuitInputs) (*BenchmarkResult, []byt
 		inputs.MetadataHashP2,
 		inputs.TrustMethod,
 	}
+	if inputs.keyID() == signals.ScopeBoundKeyID {
+		sigs = append(sigs, inputs.AudienceHash, inputs.ScopeHash)
+	}
+	return sigs
+}
+
+// NativeProofData encodes a gnark proof as the "gnark_native" proof_data
+// wrapper stored in PTX files
+func NativeProofData(proof groth16.Proof, inputs *CircuitInputs) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if _, err := proof.WriteRawTo(buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize proof: %w", err)
+	}
 
 	wrapper := struct {
 		Source        string   `json:"source"`
@@ -483,12 +742,10 @@ func (p *Prover) BenchmarkNative(inputs *CircuitInputs) (*BenchmarkResult, []byt
 		ProofHex      string   `json:"proofHex"`
 	}{
 		Source:        "gnark_native",
-		PublicSignals: publicSigs,
-		ProofHex:      fmt.Sprintf("%x", proofBytes),
+		PublicSignals: inputs.PublicSignals(),
+		ProofHex:      fmt.Sprintf("%x", buf.Bytes()),
 	}
-
-	proofJSON, err := json.Marshal(wrapper)
-	return result, proofJSON, err
+	return json.Marshal(wrapper)
 }
 
 func fromString(s string) frontend.Variable {
@@ -497,6 +754,58 @@ func fromString(s string) frontend.Variable {
 	return i
 }
 
+// metadataDomains returns the "additional_domains" claim of a multi-domain
+// PTX
+func metadataDomains(metadata map[string]interface{}) ([]string, error) {
+	switch v := metadata["additional_domains"].(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		domains := make([]string, len(v))
+		for i, d := range v {
+			s, ok := d.(string)
+			if !ok {
+				return nil, fmt.Errorf("additional_domains[%d] is not a string", i)
+			}
+			domains[i] = s
+		}
+		return domains, nil
+	default:
+		return nil, fmt.Errorf("additional_domains must be an array of domain names")
+	}
+}
+
+// metadataExpiration returns the "expiration_timestamp" claim (Unix seconds)
+func metadataExpiration(metadata map[string]interface{}) (time.Time, bool) {
+	switch v := metadata["expiration_timestamp"].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		n, err := v.Int64()
+		return time.Unix(n, 0), err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// proofCommitment returns the commitment public signal of a proof
+func proofCommitment(proofJSON []byte) (string, error) {
+	w, err := proofdata.Parse(proofJSON)
+	if err != nil {
+		return "", err
+	}
+	if len(w.PublicSignals) < 2 {
+		return "", fmt.Errorf("proof has no commitment public signal")
+	}
+	return w.PublicSignals[1], nil
+}
+
 // CreatePtxFile builds and serializes a PtxFile message
 func (p *Prover) CreatePtxFile(
 	proofJSON []byte,
@@ -504,15 +813,57 @@ func (p *Prover) CreatePtxFile(
 	domain string,
 	trustMethod int,
 ) ([]byte, error) {
-	metaBytes, err := json.Marshal(metadata)
+	if err := checkTrustMethod(trustMethod); err != nil {
+		return nil, err
+	}
+
+	// The signed metadata is stored in the canonical form the circuit inputs
+	// hashed, and the wrapper says so
+	metaBytes, err := crypto.MarshalCanonical(metadata)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
 	}
+	proofJSON, err = proofdata.WithMetadataEncoding(proofJSON, proofdata.MetadataJCS)
+	if err != nil {
+		return nil, err
+	}
+	// Store the domain in the form that was hashed into the proof
+	domain, err = crypto.NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	version := p.formatVersion()
+	if version < ptxloader.MinFormatVersion || version > ptxloader.FormatVersion {
+		return nil, fmt.Errorf("unsupported PTX format version %d", version)
+	}
+	hostnames := utils.FormatHostnameVersion(version)
 
+	for _, m := range p.FixedLabels {
+		if m != ptx.TrustMethod_DOH && m != ptx.TrustMethod_WELL_KNOWN {
+			return nil, fmt.Errorf("unsupported fixed label anchor %s (only DOH and WELL_KNOWN)", m)
+		}
+		if p.AnchorKey == nil && p.AnchorSignature == nil {
+			return nil, fmt.Errorf("fixed label anchors require an anchor key")
+		}
+	}
+
+	envelope, err := proofdata.NewEnvelope(proofJSON, p.keyID())
+	if err != nil {
+		return nil, err
+	}
+	// Native proofs name the exact constraint system they were proven with,
+	// so that a verifier built with another circuit definition can tell
+	if envelope.Format == ptx.ProofFormat_GNARK_NATIVE {
+		if envelope.CircuitHash, err = CircuitHash(p.keyID()); err != nil {
+			return nil, err
+		}
+	}
 	proof := &ptx.ZkProof{
 		ProofSystem:       ptx.ProofSystem_GROTH16,
-		VerificationKeyId: "sdv_poseidon_v1",
+		VerificationKeyId: p.keyID(),
 		ProofData:         proofJSON,
+		Envelope:          envelope,
 	}
 
 	ptxFile := &ptx.PtxFile{
@@ -522,17 +873,96 @@ func (p *Prover) CreatePtxFile(
 		Anchor: &ptx.PtxFile_DohDetails{
 			DohDetails: &ptx.DohAnchor{
 				DomainName: domain,
+				LabelMode:  p.labelMode(ptx.TrustMethod_DOH),
 			},
 		},
+		IssuedAt: p.issuedAt(),
+	}
+	if version > 1 {
+		ptxFile.FormatVersion = version
+	}
+
+	for _, m := range p.AdditionalAnchors {
+		a := &ptx.Anchor{TrustMethod: m}
+		switch m {
+		case ptx.TrustMethod_WELL_KNOWN:
+			a.Details = &ptx.Anchor_WellKnownDetails{WellKnownDetails: &ptx.WellKnownAnchor{DomainName: domain, LabelMode: p.labelMode(m)}}
+		case ptx.TrustMethod_IPFS:
+			commitment, err := proofCommitment(proofJSON)
+			if err != nil {
+				return nil, err
+			}
+			record, err := utils.DeriveAnchorRecord(commitment, domain, string(metaBytes), hostnames)
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive anchor: %w", err)
+			}
+			a.Details = &ptx.Anchor_IpfsDetails{IpfsDetails: &ptx.IpfsAnchor{DomainName: domain, Cid: ipfs.CID(record.Document())}}
+		case ptx.TrustMethod_ETHEREUM:
+			a.Details = &ptx.Anchor_EthereumDetails{EthereumDetails: &ptx.EthereumAnchor{DomainName: domain, RegistryAddress: p.EthereumRegistry}}
+		default:
+			return nil, fmt.Errorf("unsupported additional anchor %s (only WELL_KNOWN, IPFS and ETHEREUM)", m)
+		}
+		ptxFile.AdditionalAnchors = append(ptxFile.AdditionalAnchors, a)
+	}
+	if len(ptxFile.AdditionalAnchors) > 0 {
+		ptxFile.AnchorPolicy = p.AnchorPolicy
+	}
+
+	// Mirror the additional domains the metadata binds into the anchor, in
+	// the same order and normalized like the primary domain
+	extra, err := metadataDomains(metadata)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range extra {
+		name, err := crypto.NormalizeDomain(d)
+		if err != nil {
+			return nil, fmt.Errorf("additional domain %q: %w", d, err)
+		}
+		ptxFile.GetDohDetails().AdditionalDomainNames = append(ptxFile.GetDohDetails().AdditionalDomainNames, name)
+	}
+
+	// Mirror the metadata expiration into the typed field. The metadata copy
+	// stays authoritative since it is bound by the proof.
+	if exp, ok := metadataExpiration(metadata); ok {
+		ptxFile.ExpiresAt = timestamppb.New(exp)
+	}
+
+	// Tokens anchored under a fixed label carry the anchor key's signature,
+	// which binds the commitment like a derived label would
+	if len(p.FixedLabels) > 0 && p.AnchorKey == nil {
+		ptxFile.AnchorSignature = p.AnchorSignature
+	} else if len(p.FixedLabels) > 0 {
+		commitment, err := proofCommitment(proofJSON)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := utils.AnchorSigningPayload(commitment, domain, string(metaBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive anchor payload: %w", err)
+		}
+		ptxFile.AnchorSignature = ed25519.Sign(p.AnchorKey, payload)
+	}
+
+	if p.TSA != nil {
+		digest, err := tsa.Imprint(ptxFile)
+		if err != nil {
+			return nil, err
+		}
+		ptxFile.TimestampToken, err = p.TSA.Timestamp(context.Background(), digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to timestamp PTX: %w", err)
+		}
 	}
 
-	serialized, err := proto.Marshal(ptxFile)
+	// Deterministic mode also fixes the field order of the encoding
+	serialized, err := proto.MarshalOptions{Deterministic: p.hooks != nil}.Marshal(ptxFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal PTX proto: %w", err)
 	}
 
-	// finalData = []byte{0x50, 0x54, 0x58, 0x01, 0x00} + serialized
-	finalData := append([]byte{0x50, 0x54, 0x58, 0x01, 0x00}, serialized...)
+	// finalData = []byte{0x50, 0x54, 0x58, version, 0x00} + serialized
+	finalData := append(ptxloader.Header(version), serialized...)
 
 	return finalData, nil
 }
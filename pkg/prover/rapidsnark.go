@@ -0,0 +1,66 @@
+package prover
+
+import "context"
+
+// Rapidsnark proves with the rapidsnark C++ prover from Circom artifacts. It
+// is much faster than snarkjs for the proving step; the witness is computed
+// by a circom C++ witness generator when WitnessBinary is set, or with
+// snarkjs from WasmPath otherwise.
+type Rapidsnark struct {
+	// Binary is the rapidsnark prover, invoked as
+	// <Binary> <zkey> <witness> <proof.json> <public.json>. Defaults to
+	// "rapidsnark".
+	Binary string
+	// WitnessBinary is a circom C++ witness generator, invoked as
+	// <WitnessBinary> <input.json> <witness>
+	WitnessBinary string
+	WasmPath      string
+	ZkeyPath      string
+	Options       ExecOptions
+}
+
+func NewRapidsnark(binary string, wasmPath string, zkeyPath string, opts ExecOptions) *Rapidsnark {
+	return &Rapidsnark{Binary: binary, WasmPath: wasmPath, ZkeyPath: zkeyPath, Options: opts}
+}
+
+func (r *Rapidsnark) Name() string {
+	return "rapidsnark"
+}
+
+// Prove implements ProverBackend
+func (r *Rapidsnark) Prove(ctx context.Context, inputs *CircuitInputs) ([]byte, error) {
+	binary := r.Binary
+	if binary == "" {
+		binary = "rapidsnark"
+	}
+
+	artifacts := []string{r.ZkeyPath}
+	var snarkjsCmd []string
+	if r.WitnessBinary == "" {
+		var err error
+		if snarkjsCmd, err = snarkjsCommand(); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, r.WasmPath)
+	}
+
+	w, err := newWorkdir(r.Options, inputs, artifacts...)
+	if err != nil {
+		return nil, err
+	}
+	defer w.close()
+
+	if r.WitnessBinary != "" {
+		err = w.run(ctx, "witness generation", []string{r.WitnessBinary}, w.input, w.witness)
+	} else {
+		err = w.run(ctx, "snarkjs witness calculation", snarkjsCmd, "wtns", "calculate", r.WasmPath, w.input, w.witness)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.run(ctx, "rapidsnark proving", []string{binary}, r.ZkeyPath, w.witness, w.proof, w.public); err != nil {
+		return nil, err
+	}
+	return w.proofData()
+}
@@ -0,0 +1,134 @@
+package prover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+)
+
+// Remote proves by posting the circuit inputs as JSON to an HTTP prover
+// service. The response body must be a proof_data wrapper, either
+// {"proof": {...}, "publicSignals": [...]} or the native
+// {"source": "gnark_native", "proofHex": "...", "publicSignals": [...]}, in
+// any encoding supported by pkg/proofdata. Its public signals must be those
+// of the inputs.
+//
+// The inputs include the private nullifier and secret, so the URL must use
+// https unless it points at a loopback address.
+type Remote struct {
+	URL string
+	// Header is added to every request, e.g. for authorization
+	Header http.Header
+	Client *http.Client
+	// MaxResponseSize bounds the response body. Defaults to
+	// DefaultMaxProverOutput.
+	MaxResponseSize int64
+}
+
+func NewRemote(rawURL string) *Remote {
+	return &Remote{URL: rawURL, Header: http.Header{}, Client: outbound.DefaultClient}
+}
+
+func (r *Remote) Name() string {
+	return "remote"
+}
+
+// Prove implements ProverBackend
+func (r *Remote) Prove(ctx context.Context, inputs *CircuitInputs) ([]byte, error) {
+	if err := checkProverURL(r.URL); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inputs: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.Header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := r.Client
+	if client == nil {
+		client = outbound.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote prover: %w", err)
+	}
+	defer resp.Body.Close()
+
+	limit := r.MaxResponseSize
+	if limit <= 0 {
+		limit = DefaultMaxProverOutput
+	}
+	data, err := readLimited(resp.Body, limit)
+	if err != nil {
+		return nil, fmt.Errorf("remote prover: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote prover: request failed with status %d: %s", resp.StatusCode, truncate(data, 512))
+	}
+
+	wrapper, err := proofdata.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("remote prover: invalid response: %w", err)
+	}
+	if len(wrapper.PublicSignals) == 0 || (len(wrapper.Proof) == 0 && wrapper.ProofHex == "" && wrapper.ProofBase64 == "") {
+		return nil, errors.New("remote prover: response is missing the proof or public signals")
+	}
+	if err := checkPublicSignals(wrapper.PublicSignals, inputs.PublicSignals()); err != nil {
+		return nil, fmt.Errorf("remote prover: %w", err)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("remote prover: invalid response: %w", err)
+	}
+	return compact.Bytes(), nil
+}
+
+// checkProverURL rejects URLs that would send the private inputs in the clear
+func checkProverURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("remote prover: invalid URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if u.Hostname() == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(u.Hostname()); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return fmt.Errorf("remote prover: %s must use https", rawURL)
+	default:
+		return fmt.Errorf("remote prover: unsupported URL scheme %q", u.Scheme)
+	}
+}
+
+func truncate(data []byte, n int) string {
+	if len(data) > n {
+		return string(data[:n]) + "..."
+	}
+	return string(data)
+}
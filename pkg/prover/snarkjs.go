@@ -0,0 +1,330 @@
+package prover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+)
+
+const (
+	// DefaultExecTimeout bounds each external prover invocation
+	DefaultExecTimeout = 5 * time.Minute
+	// DefaultMaxArtifactSize bounds the circuit wasm and zkey files passed to
+	// external provers
+	DefaultMaxArtifactSize = 1 << 30 // 1 GiB
+	// DefaultMaxProverOutput bounds the proof and public signal files read
+	// back from external provers
+	DefaultMaxProverOutput = 1 << 20 // 1 MiB
+	// maxLoggedOutput bounds the child output kept for error messages
+	maxLoggedOutput = 16 << 10
+)
+
+// ErrProverOutputTooLarge is returned when an external prover writes a proof
+// or public signal file larger than ExecOptions.MaxOutputSize
+var ErrProverOutputTooLarge = errors.New("prover output exceeds maximum size")
+
+// ExecOptions controls the external processes run by the SnarkJS and
+// Rapidsnark backends. Zero values select the defaults.
+type ExecOptions struct {
+	// Timeout bounds each invocation. The process (and its process group on
+	// unix) is killed when it expires.
+	Timeout time.Duration
+	// TempDir is the parent of the private working directory. Defaults to
+	// os.TempDir.
+	TempDir string
+	// MaxArtifactSize bounds the size of the wasm and zkey files
+	MaxArtifactSize int64
+	// MaxOutputSize bounds the size of the proof and public signal files
+	MaxOutputSize int64
+}
+
+func (o *ExecOptions) setDefaults() {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultExecTimeout
+	}
+	if o.MaxArtifactSize <= 0 {
+		o.MaxArtifactSize = DefaultMaxArtifactSize
+	}
+	if o.MaxOutputSize <= 0 {
+		o.MaxOutputSize = DefaultMaxProverOutput
+	}
+}
+
+// SnarkJS proves with snarkjs from Circom wasm and zkey artifacts
+type SnarkJS struct {
+	WasmPath string
+	ZkeyPath string
+	Options  ExecOptions
+}
+
+func NewSnarkJS(wasmPath string, zkeyPath string, opts ExecOptions) *SnarkJS {
+	return &SnarkJS{WasmPath: wasmPath, ZkeyPath: zkeyPath, Options: opts}
+}
+
+func (s *SnarkJS) Name() string {
+	return "snarkjs"
+}
+
+// Prove implements ProverBackend
+func (s *SnarkJS) Prove(ctx context.Context, inputs *CircuitInputs) ([]byte, error) {
+	snarkjsCmd, err := snarkjsCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := newWorkdir(s.Options, inputs, s.WasmPath, s.ZkeyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer w.close()
+
+	// cmd: snarkjs wtns calculate <wasm> <input> <output>
+	if err := w.run(ctx, "snarkjs witness calculation", snarkjsCmd, "wtns", "calculate", s.WasmPath, w.input, w.witness); err != nil {
+		return nil, err
+	}
+	// cmd: snarkjs groth16 prove <zkey> <witness> <proof.json> <public.json>
+	if err := w.run(ctx, "snarkjs proving", snarkjsCmd, "groth16", "prove", s.ZkeyPath, w.witness, w.proof, w.public); err != nil {
+		return nil, err
+	}
+	return w.proofData()
+}
+
+// GenerateProof generates a Groth16 proof using snarkjs shell-out (for Circom compatibility)
+func (p *Prover) GenerateProof(
+	inputs *CircuitInputs,
+	wasmPath string,
+	zkeyPath string,
+) ([]byte, error) {
+	return p.GenerateProofWithOptions(context.Background(), inputs, wasmPath, zkeyPath, ExecOptions{})
+}
+
+// GenerateProofWithOptions generates a Groth16 proof with snarkjs. See
+// SnarkJS.
+func (p *Prover) GenerateProofWithOptions(
+	ctx context.Context,
+	inputs *CircuitInputs,
+	wasmPath string,
+	zkeyPath string,
+	opts ExecOptions,
+) ([]byte, error) {
+	return NewSnarkJS(wasmPath, zkeyPath, opts).Prove(ctx, inputs)
+}
+
+// snarkjsCommand finds 'snarkjs' in PATH or falls back to 'npx snarkjs'
+func snarkjsCommand() ([]string, error) {
+	if _, err := exec.LookPath("snarkjs"); err == nil {
+		return []string{"snarkjs"}, nil
+	}
+	if _, err := exec.LookPath("npx"); err == nil {
+		return []string{"npx", "snarkjs"}, nil
+	}
+	return nil, fmt.Errorf("neither 'snarkjs' nor 'npx' found in PATH. Please install snarkjs")
+}
+
+// workdir holds the files of one external proof. The inputs and witness hold
+// the private nullifier and secret, so every file lives in a private 0700
+// directory that is removed by close, and the secrets are redacted from any
+// process output included in errors.
+type workdir struct {
+	opts    ExecOptions
+	dir     string
+	input   string
+	witness string
+	proof   string
+	public  string
+	redact  *strings.Replacer
+	// signals are the public signals the proof must have
+	signals []string
+}
+
+func newWorkdir(opts ExecOptions, inputs *CircuitInputs, artifacts ...string) (*workdir, error) {
+	// The circom circuit only exists for the default key
+	if id := inputs.keyID(); id != signals.DefaultVerificationKeyID {
+		return nil, fmt.Errorf("circuit %q is only supported by the native prover", id)
+	}
+	opts.setDefaults()
+
+	for _, path := range artifacts {
+		if err := checkArtifact(path, opts.MaxArtifactSize); err != nil {
+			return nil, err
+		}
+	}
+
+	inputBytes, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inputs: %w", err)
+	}
+
+	// MkdirTemp creates the directory with mode 0700
+	dir, err := os.MkdirTemp(opts.TempDir, "jesuit-prover-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	w := &workdir{
+		opts:    opts,
+		dir:     dir,
+		input:   filepath.Join(dir, "input.json"),
+		witness: filepath.Join(dir, "witness.wtns"),
+		proof:   filepath.Join(dir, "proof.json"),
+		public:  filepath.Join(dir, "public.json"),
+		redact:  newSecretRedactor(inputs),
+		signals: inputs.PublicSignals(),
+	}
+	if err := os.WriteFile(w.input, inputBytes, 0o600); err != nil {
+		w.close()
+		return nil, fmt.Errorf("failed to write input: %w", err)
+	}
+	return w, nil
+}
+
+func (w *workdir) close() {
+	os.RemoveAll(w.dir)
+}
+
+// run runs one command, killing it after the configured timeout. Its
+// combined output is only returned, redacted and truncated, when the command
+// fails.
+func (w *workdir) run(ctx context.Context, step string, command []string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.Timeout)
+	defer cancel()
+
+	argv := append(append([]string(nil), command...), args...)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	out := &limitedBuffer{limit: maxLoggedOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Do not wait forever for grandchildren holding the output pipes
+	cmd.WaitDelay = 5 * time.Second
+	killProcessGroup(cmd)
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", w.opts.Timeout)
+	}
+	return fmt.Errorf("%s failed: %v, output: %s", step, err, w.redact.Replace(out.String()))
+}
+
+// proofData reads the snarkjs-style proof.json and public.json and wraps them
+// as the {proof, publicSignals} proof_data the verifier expects. The public
+// signals must be those of the inputs.
+func (w *workdir) proofData() ([]byte, error) {
+	proofBytes, err := readBounded(w.proof, w.opts.MaxOutputSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof: %w", err)
+	}
+
+	publicBytes, err := readBounded(w.public, w.opts.MaxOutputSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public signals: %w", err)
+	}
+
+	var proofRaw json.RawMessage
+	if err := json.Unmarshal(proofBytes, &proofRaw); err != nil {
+		return nil, fmt.Errorf("failed to parse proof json: %w", err)
+	}
+
+	var publicSigs []string
+	if err := json.Unmarshal(publicBytes, &publicSigs); err != nil {
+		return nil, fmt.Errorf("failed to parse public signals json: %w", err)
+	}
+	if err := checkPublicSignals(publicSigs, w.signals); err != nil {
+		return nil, err
+	}
+
+	wrapper := struct {
+		PublicSignals []string        `json:"publicSignals"`
+		Proof         json.RawMessage `json:"proof"`
+	}{
+		PublicSignals: publicSigs,
+		Proof:         proofRaw,
+	}
+
+	return json.Marshal(wrapper)
+}
+
+// newSecretRedactor replaces the private inputs with a placeholder
+func newSecretRedactor(inputs *CircuitInputs) *strings.Replacer {
+	var pairs []string
+	for _, s := range []string{inputs.Nullifier, inputs.Secret} {
+		if s != "" {
+			pairs = append(pairs, s, "[REDACTED]")
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// checkArtifact verifies that a circuit artifact is a regular file within
+// limit bytes
+func checkArtifact(path string, limit int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() > limit {
+		return fmt.Errorf("%s exceeds maximum size: %d bytes (limit %d)", path, info.Size(), limit)
+	}
+	return nil
+}
+
+// readBounded reads a file of at most limit bytes
+func readBounded(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readLimited(f, limit)
+}
+
+// readLimited reads at most limit bytes from r, failing if more are available
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrProverOutputTooLarge, limit)
+	}
+	return data, nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "... (truncated)"
+	}
+	return b.buf.String()
+}
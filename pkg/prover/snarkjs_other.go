@@ -0,0 +1,9 @@
+//go:build !unix
+
+package prover
+
+import "os/exec"
+
+// killProcessGroup is a no-op where process groups are unavailable; the
+// default cancellation kills the direct child only
+func killProcessGroup(cmd *exec.Cmd) {}
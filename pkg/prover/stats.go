@@ -0,0 +1,147 @@
+package prover
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	fcs "github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/profile"
+)
+
+// CircuitStats describes the compiled circuit of a verification key ID. It is
+// what a proposed circuit change is judged by before a new trusted setup.
+type CircuitStats struct {
+	KeyID       string `json:"keyId"`
+	Constraints int    `json:"constraints"`
+	// PublicVariables counts the public inputs, without the constant one
+	// wire of R1CS
+	PublicVariables   int           `json:"publicVariables"`
+	SecretVariables   int           `json:"secretVariables"`
+	InternalVariables int           `json:"internalVariables"`
+	Coefficients      int           `json:"coefficients"`
+	CompileTime       time.Duration `json:"compileTimeNs"`
+	// SolveTime is the time the witness solver took on sample inputs, set
+	// with StatsOptions.Solve
+	SolveTime time.Duration `json:"solveTimeNs,omitempty"`
+	// ConstraintProfile and SolverProfile are the pprof files written with
+	// StatsOptions.ProfileDir
+	ConstraintProfile string `json:"constraintProfile,omitempty"`
+	SolverProfile     string `json:"solverProfile,omitempty"`
+}
+
+// StatsOptions selects the optional measurements of CircuitStatsFor
+type StatsOptions struct {
+	// Solve runs the witness solver on sample inputs and times it
+	Solve bool
+	// ProfileDir, when set, receives <keyID>.constraints.pprof, which
+	// attributes the constraints to the circuit source lines, and with Solve
+	// <keyID>.solve.pprof, a CPU profile of the witness solver. Inspect them
+	// with go tool pprof.
+	ProfileDir string
+}
+
+// CircuitStatsFor compiles the circuit of keyID and reports its size and
+// compile time
+func CircuitStatsFor(keyID string, opts StatsOptions) (*CircuitStats, error) {
+	c, err := circuit.ForKeyID(keyID)
+	if err != nil {
+		return nil, err
+	}
+	stats := &CircuitStats{KeyID: keyID}
+
+	var prof *profile.Profile
+	if opts.ProfileDir != "" {
+		if err := os.MkdirAll(opts.ProfileDir, 0755); err != nil {
+			return nil, err
+		}
+		stats.ConstraintProfile = filepath.Join(opts.ProfileDir, keyID+".constraints.pprof")
+		prof = profile.Start(profile.WithPath(stats.ConstraintProfile))
+	}
+	start := time.Now()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, c)
+	stats.CompileTime = time.Since(start)
+	if prof != nil {
+		prof.Stop()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("circuit compilation failed: %w", err)
+	}
+
+	stats.Constraints = ccs.GetNbConstraints()
+	stats.PublicVariables = ccs.GetNbPublicVariables() - 1
+	stats.SecretVariables = ccs.GetNbSecretVariables()
+	stats.InternalVariables = ccs.GetNbInternalVariables()
+	stats.Coefficients = ccs.GetNbCoefficients()
+
+	if !opts.Solve {
+		return stats, nil
+	}
+
+	p := &Prover{KeyID: keyID}
+	inputs, err := p.GenerateCircuitInputs("example.com", map[string]interface{}{"audience": "stats"}, "1", "2", 1)
+	if err != nil {
+		return nil, fmt.Errorf("sample inputs: %w", err)
+	}
+	w, err := frontend.NewWitness(inputs.Circuit(), ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, fmt.Errorf("witness creation failed: %w", err)
+	}
+
+	if opts.ProfileDir != "" {
+		stats.SolverProfile = filepath.Join(opts.ProfileDir, keyID+".solve.pprof")
+		f, err := os.Create(stats.SolverProfile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return nil, fmt.Errorf("solver profile: %w", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	start = time.Now()
+	if _, err := ccs.Solve(w, solver.OverrideHint(solver.GetHintID(fcs.Bsb22CommitmentComputePlaceholder), sampleCommitment)); err != nil {
+		return nil, fmt.Errorf("witness solving failed: %w", err)
+	}
+	stats.SolveTime = time.Since(start)
+	return stats, nil
+}
+
+// sampleCommitment stands in for the commitment hint of circuits with
+// in-circuit commitments (the SHA-256 circuit), which groth16.Prove computes
+// with the proving key. Any field element lets the solver run; hashing the
+// committed values keeps the work comparable.
+func sampleCommitment(field *big.Int, in []*big.Int, out []*big.Int) error {
+	h := sha256.New()
+	for _, v := range in {
+		h.Write(v.Bytes())
+	}
+	out[0].SetBytes(h.Sum(nil))
+	out[0].Mod(out[0], field)
+	return nil
+}
+
+// AllCircuitStats reports CircuitStatsFor every registered verification key
+// ID, in signals.KeyIDs order
+func AllCircuitStats(opts StatsOptions) ([]*CircuitStats, error) {
+	var all []*CircuitStats
+	for _, keyID := range signals.KeyIDs() {
+		stats, err := CircuitStatsFor(keyID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyID, err)
+		}
+		all = append(all, stats)
+	}
+	return all, nil
+}
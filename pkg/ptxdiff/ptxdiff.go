@@ -0,0 +1,327 @@
+// Package ptxdiff compares two PTX files field by field, together with the
+// values a verifier derives from them (fqdn hash, metadata limbs, anchor
+// hostname), to debug proofs that fail for what looks like the same inputs.
+package ptxdiff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Sections group the compared fields
+const (
+	SectionHeader   = "header"
+	SectionFile     = "file"
+	SectionAnchor   = "anchor"
+	SectionMetadata = "metadata"
+	SectionProof    = "proof"
+	SectionDerived  = "derived"
+)
+
+// Absent is the value of a field one of the files does not have
+const Absent = "(absent)"
+
+// Field is a compared value of both files
+type Field struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	A       string `json:"a"`
+	B       string `json:"b"`
+	Equal   bool   `json:"equal"`
+}
+
+// Report lists the compared fields in a stable order
+type Report struct {
+	Fields []Field `json:"fields"`
+}
+
+// Differences returns the fields that differ
+func (r *Report) Differences() []Field {
+	var diff []Field
+	for _, f := range r.Fields {
+		if !f.Equal {
+			diff = append(diff, f)
+		}
+	}
+	return diff
+}
+
+// Equal reports whether no field differs
+func (r *Report) Equal() bool {
+	return len(r.Differences()) == 0
+}
+
+func (r *Report) add(section, name, a, b string) {
+	r.Fields = append(r.Fields, Field{Section: section, Name: name, A: a, B: b, Equal: a == b})
+}
+
+// Compare parses two serialized PTX files and compares them
+func Compare(a, b []byte) (*Report, error) {
+	fa, err := ptxloader.ParsePTX(a, ptxloader.LoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("first file: %w", err)
+	}
+	fb, err := ptxloader.ParsePTX(b, ptxloader.LoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("second file: %w", err)
+	}
+
+	r := &Report{}
+	r.add(SectionHeader, "magic", hex.EncodeToString(a[:4]), hex.EncodeToString(b[:4]))
+	r.add(SectionHeader, "reserved", hex.EncodeToString(a[4:5]), hex.EncodeToString(b[4:5]))
+	CompareFiles(r, fa, fb)
+	return r, nil
+}
+
+// CompareFiles adds the comparison of two parsed PTX files to r
+func CompareFiles(r *Report, a, b *ptx.PtxFile) {
+	pair := func(section, name string, get func(*ptx.PtxFile) string) {
+		r.add(section, name, get(a), get(b))
+	}
+
+	pair(SectionFile, "trustMethod", func(f *ptx.PtxFile) string { return f.GetTrustMethod().String() })
+	pair(SectionFile, "issuedAt", func(f *ptx.PtxFile) string { return timestamp(f.GetIssuedAt().AsTime(), f.IssuedAt != nil) })
+	pair(SectionFile, "expiresAt", func(f *ptx.PtxFile) string { return timestamp(f.GetExpiresAt().AsTime(), f.ExpiresAt != nil) })
+	pair(SectionFile, "timestampToken", func(f *ptx.PtxFile) string { return digest(f.GetTimestampToken()) })
+	pair(SectionFile, "anchorSignature", func(f *ptx.PtxFile) string { return digest(f.GetAnchorSignature()) })
+
+	pair(SectionAnchor, "domain", func(f *ptx.PtxFile) string { return orAbsent(f.GetDohDetails().GetDomainName()) })
+	pair(SectionAnchor, "labelMode", func(f *ptx.PtxFile) string { return f.GetDohDetails().GetLabelMode().String() })
+	pair(SectionAnchor, "additionalDomains", func(f *ptx.PtxFile) string {
+		return orAbsent(strings.Join(f.GetDohDetails().GetAdditionalDomainNames(), ", "))
+	})
+	pair(SectionAnchor, "anchorPolicy", func(f *ptx.PtxFile) string { return f.GetAnchorPolicy().String() })
+	for i := 0; i < max(len(a.GetAdditionalAnchors()), len(b.GetAdditionalAnchors())); i++ {
+		pair(SectionAnchor, fmt.Sprintf("additionalAnchors[%d]", i), func(f *ptx.PtxFile) string {
+			if i >= len(f.GetAdditionalAnchors()) {
+				return Absent
+			}
+			data, _ := protojson.MarshalOptions{}.Marshal(f.GetAdditionalAnchors()[i])
+			return compact(data)
+		})
+	}
+
+	compareMetadata(r, a.GetSignedMetadata(), b.GetSignedMetadata())
+
+	wa, _ := proofdata.Parse(a.GetProof().GetProofData())
+	wb, _ := proofdata.Parse(b.GetProof().GetProofData())
+	if wa == nil {
+		wa = &proofdata.Wrapper{}
+	}
+	if wb == nil {
+		wb = &proofdata.Wrapper{}
+	}
+	pair(SectionProof, "proofSystem", func(f *ptx.PtxFile) string { return f.GetProof().GetProofSystem().String() })
+	pair(SectionProof, "verificationKeyId", func(f *ptx.PtxFile) string { return orAbsent(f.GetProof().GetVerificationKeyId()) })
+	r.add(SectionProof, "source", orAbsent(wa.Source), orAbsent(wb.Source))
+	r.add(SectionProof, "encoding", orAbsent(wa.Encoding), orAbsent(wb.Encoding))
+	r.add(SectionProof, "metadataEncoding", orAbsent(wa.MetadataEncoding), orAbsent(wb.MetadataEncoding))
+	la, lb := layout(a), layout(b)
+	for i := 0; i < max(len(wa.PublicSignals), len(wb.PublicSignals)); i++ {
+		name := "publicSignals[" + strconv.Itoa(i) + "]"
+		if i < la.Len() && slices.Equal(la.Names, lb.Names) {
+			name += " " + la.Names[i]
+		}
+		r.add(SectionProof, name, signalAt(wa.PublicSignals, i), signalAt(wb.PublicSignals, i))
+	}
+
+	da, db := derive(a, wa, la), derive(b, wb, lb)
+	for i := range da {
+		r.add(SectionDerived, da[i].name, da[i].value, db[i].value)
+	}
+}
+
+// compareMetadata compares the signed metadata as JSON, path by path, or as
+// strings if either is not JSON
+func compareMetadata(r *Report, a, b string) {
+	r.add(SectionMetadata, "sha256", utils.Sha256(a), utils.Sha256(b))
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		r.add(SectionMetadata, "raw", a, b)
+		return
+	}
+	fa, fb := map[string]string{}, map[string]string{}
+	flatten(fa, "$", va)
+	flatten(fb, "$", vb)
+	paths := make([]string, 0, len(fa)+len(fb))
+	for p := range fa {
+		paths = append(paths, p)
+	}
+	for p := range fb {
+		if _, ok := fa[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+	for _, p := range paths {
+		x, ok := fa[p]
+		if !ok {
+			x = Absent
+		}
+		y, ok := fb[p]
+		if !ok {
+			y = Absent
+		}
+		r.add(SectionMetadata, p, x, y)
+	}
+}
+
+// flatten maps every leaf of a JSON value, and every empty object or array,
+// to its path: $.claims.role, $.scopes[0]
+func flatten(out map[string]string, path string, v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			out[path] = "{}"
+		}
+		for k, e := range t {
+			flatten(out, path+"."+k, e)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			out[path] = "[]"
+		}
+		for i, e := range t {
+			flatten(out, path+"["+strconv.Itoa(i)+"]", e)
+		}
+	default:
+		data, _ := json.Marshal(t)
+		out[path] = string(data)
+	}
+}
+
+type derived struct {
+	name, value string
+}
+
+// derive recomputes what a verifier derives from a PTX file, in a fixed
+// order, and checks it against the file's own public signals
+func derive(f *ptx.PtxFile, w *proofdata.Wrapper, l *signals.SignalLayout) []derived {
+	failed := func(err error) string { return "error: " + err.Error() }
+
+	var normalized, fqdn string
+	domain, err := crypto.NormalizeDomain(f.GetDohDetails().GetDomainName())
+	if err != nil {
+		normalized, fqdn = failed(err), failed(err)
+	} else {
+		normalized = domain
+		if h, err := crypto.PoseidonHashString(domain); err == nil {
+			fqdn = h.String()
+		} else {
+			fqdn = failed(err)
+		}
+	}
+
+	var boundHash, p1, p2 string
+	meta, err := w.BoundMetadata(f.GetSignedMetadata())
+	if err != nil {
+		boundHash, p1, p2 = failed(err), failed(err), failed(err)
+	} else {
+		boundHash = utils.Sha256(meta)
+		if l.MetadataChunks > 0 {
+			p1, p2 = "(hashed in-circuit)", "(hashed in-circuit)"
+		} else if h1, h2, err := crypto.SplitMetadataHashWith(meta, l.Limbs); err == nil {
+			p1, p2 = h1.String(), h2.String()
+		} else {
+			p1, p2 = failed(err), failed(err)
+		}
+	}
+
+	hostname, value := Absent, Absent
+	if len(w.PublicSignals) >= 2 && f.GetDohDetails() != nil {
+		if f.GetDohDetails().GetLabelMode() == ptx.LabelMode_LABEL_FIXED {
+			hostname, value = utils.FixedAnchorLabel+"."+normalized, "(anchor key)"
+		} else if record, err := utils.DeriveAnchorRecord(w.PublicSignals[1], domain, meta, utils.FormatHostnameVersion(f.Version())); err == nil {
+			hostname, value = record.Hostname, record.Value
+		} else {
+			hostname, value = failed(err), failed(err)
+		}
+	}
+
+	mismatches := "none"
+	if ps, err := signals.ParsePublicSignals(w.PublicSignals); err != nil {
+		mismatches = failed(err)
+	} else {
+		sig := signals.NewPTXSignals(domain, meta, f.GetTrustMethod())
+		sig.Layout = l
+		if m := sig.VerifyParsed(ps).Mismatches(); len(m) > 0 {
+			mismatches = strings.Join(m, ", ")
+		}
+	}
+
+	return []derived{
+		{"normalizedDomain", normalized},
+		{"fqdnHash", fqdn},
+		{"boundMetadataSha256", boundHash},
+		{"limbEncoding", l.Limbs.String()},
+		{"metadataHashP1", p1},
+		{"metadataHashP2", p2},
+		{"anchorHostname", hostname},
+		{"anchorValue", value},
+		{"signalMismatches", mismatches},
+	}
+}
+
+// layout returns the signal layout of the file's verification key, or the
+// default one for unregistered keys
+func layout(f *ptx.PtxFile) *signals.SignalLayout {
+	if l, ok := signals.LayoutFor(f.GetProof().GetVerificationKeyId()); ok {
+		return l
+	}
+	l, _ := signals.LayoutFor(signals.DefaultVerificationKeyID)
+	return l
+}
+
+func signalAt(s []string, i int) string {
+	if i >= len(s) {
+		return Absent
+	}
+	return s[i]
+}
+
+func timestamp(t time.Time, set bool) string {
+	if !set {
+		return Absent
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// digest stands for binary fields, which are only compared by hash
+func digest(data []byte) string {
+	if len(data) == 0 {
+		return Absent
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+func orAbsent(s string) string {
+	if s == "" {
+		return Absent
+	}
+	return s
+}
+
+// compact removes the whitespace protojson inserts at random
+func compact(data []byte) string {
+	var v interface{}
+	if json.Unmarshal(data, &v) != nil {
+		return string(data)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
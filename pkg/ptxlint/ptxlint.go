@@ -0,0 +1,223 @@
+// Package ptxlint checks a PTX file for common issuance mistakes without any
+// network access or proof verification, so that it can gate issuance in CI.
+// Every finding carries a stable check ID and a severity.
+package ptxlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// Severity ranks findings. Verifiers reject PTX files with error findings;
+// warnings are accepted but weaken the token.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// rank orders severities from the least to the most severe
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// ParseSeverity parses a severity name
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(s); sev {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (want error, warning or info)", s)
+}
+
+// Check IDs, stable across releases so that CI gates can allow-list them
+const (
+	CheckMissingExpiration  = "missing-expiration"
+	CheckMissingNonce       = "missing-nonce"
+	CheckMetadataInvalid    = "metadata-invalid"
+	CheckMetadataSize       = "metadata-oversized"
+	CheckMetadataCanonical  = "metadata-not-canonical"
+	CheckTrustMethod        = "unknown-trust-method"
+	CheckProofSystem        = "unsupported-proof-system"
+	CheckProofData          = "proof-data-invalid"
+	CheckMissingEnvelope    = "missing-proof-envelope"
+	CheckProofEnvelope      = "proof-envelope-invalid"
+	CheckVerificationKeyID  = "unknown-verification-key"
+	CheckSignalCount        = "public-signal-count"
+	CheckSignalOverField    = "public-signal-over-field"
+	CheckExternalSignals    = "external-signals"
+	CheckMissingDoHDetails  = "missing-doh-details"
+	CheckExpirationMismatch = "expiration-mismatch"
+)
+
+// Finding is one problem found in a PTX file
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Report lists the findings of a PTX file, most severe first
+type Report struct {
+	Findings []Finding `json:"findings"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+}
+
+// Fails reports whether a finding is at least as severe as threshold
+func (r *Report) Fails(threshold Severity) bool {
+	return slices.ContainsFunc(r.Findings, func(f Finding) bool {
+		return f.Severity.rank() >= threshold.rank()
+	})
+}
+
+func (r *Report) add(check string, sev Severity, format string, args ...any) {
+	r.Findings = append(r.Findings, Finding{Check: check, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	switch sev {
+	case SeverityError:
+		r.Errors++
+	case SeverityWarning:
+		r.Warnings++
+	}
+}
+
+// Lint parses a serialized PTX file and checks it. It only fails if the file
+// cannot be parsed at all.
+func Lint(data []byte) (*Report, error) {
+	f, err := ptxloader.ParsePTX(data, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, err
+	}
+	return LintFile(f), nil
+}
+
+// LintFile checks a parsed PTX file
+func LintFile(f *ptx.PtxFile) *Report {
+	r := &Report{Findings: []Finding{}}
+
+	if _, ok := ptx.TrustMethod_name[int32(f.GetTrustMethod())]; !ok || f.GetTrustMethod() == ptx.TrustMethod_METHOD_UNSPECIFIED {
+		r.add(CheckTrustMethod, SeverityError, "trust method %d is not one verifiers know", f.GetTrustMethod())
+	}
+	if f.GetDohDetails().GetDomainName() == "" {
+		r.add(CheckMissingDoHDetails, SeverityError, "no DoH anchor domain")
+	}
+
+	bound := lintProof(r, f)
+	lintMetadata(r, f, bound)
+
+	slices.SortStableFunc(r.Findings, func(a, b Finding) int {
+		return b.Severity.rank() - a.Severity.rank()
+	})
+	return r
+}
+
+// lintProof checks the proof and returns the metadata string it binds
+func lintProof(r *Report, f *ptx.PtxFile) string {
+	signed := f.GetSignedMetadata()
+	proof := f.GetProof()
+	if proof == nil {
+		r.add(CheckProofData, SeverityError, "no proof")
+		return signed
+	}
+	if proof.GetProofSystem() != ptx.ProofSystem_GROTH16 {
+		r.add(CheckProofSystem, SeverityError, "proof system %s is not verified (only GROTH16)", proof.GetProofSystem())
+	}
+	w, err := proofdata.Parse(proof.GetProofData())
+	if err != nil {
+		r.add(CheckProofData, SeverityError, "proof data is not valid JSON: %v", err)
+		return signed
+	}
+	bound, err := w.BoundMetadata(signed)
+	if err != nil {
+		r.add(CheckProofData, SeverityError, "%v", err)
+		bound = signed
+	}
+
+	keyID := proof.GetVerificationKeyId()
+	layout, ok := signals.LayoutFor(keyID)
+	if !ok {
+		r.add(CheckVerificationKeyID, SeverityError, "verification key ID %q has no known signal layout (known: %v)", keyID, signals.KeyIDs())
+	}
+	if env := proof.GetEnvelope(); env == nil {
+		r.add(CheckMissingEnvelope, SeverityWarning, "no proof envelope; verifiers infer the proof format and strict mode rejects the file")
+	} else if err := proofdata.ValidateEnvelope(proof, w); err != nil {
+		r.add(CheckProofEnvelope, SeverityError, "%v", err)
+	} else if ok && env.GetCircuitId() != keyID {
+		r.add(CheckProofEnvelope, SeverityError, "envelope declares circuit %q, the verification key ID is %q", env.GetCircuitId(), keyID)
+	}
+	if w.HasExternalSignals() {
+		r.add(CheckExternalSignals, SeverityInfo, "public signals are supplied by the relying party and were not checked")
+		return bound
+	}
+	if ok && len(w.PublicSignals) != layout.Len() {
+		r.add(CheckSignalCount, SeverityError, "%d public signals, the layout of %s has %d", len(w.PublicSignals), keyID, layout.Len())
+	}
+	for i, s := range w.PublicSignals {
+		if _, err := crypto.ParseFr(s); err != nil {
+			name := fmt.Sprintf("signal %d", i)
+			if ok && i < layout.Len() {
+				name += " (" + layout.Names[i] + ")"
+			}
+			r.add(CheckSignalOverField, SeverityError, "%s is not a canonical BN254 field element: %v", name, err)
+		}
+	}
+	return bound
+}
+
+// lintMetadata checks the signed metadata, bound being the form the proof
+// binds
+func lintMetadata(r *Report, f *ptx.PtxFile, bound string) {
+	signed := f.GetSignedMetadata()
+	if len(bound) > metaschema.MaxSize {
+		r.add(CheckMetadataSize, SeverityError, "metadata is %d bytes, verifiers accept at most %d", len(bound), metaschema.MaxSize)
+	}
+	if layout, ok := signals.LayoutFor(f.GetProof().GetVerificationKeyId()); ok && layout.MetadataChunks > 0 {
+		if limit := layout.MetadataChunks * crypto.PackedChunkBytes; len(bound) > limit {
+			r.add(CheckMetadataSize, SeverityError, "metadata is %d bytes, the circuit hashes at most %d", len(bound), limit)
+		}
+	}
+
+	var meta map[string]interface{}
+	if err := json.Unmarshal([]byte(signed), &meta); err != nil {
+		r.add(CheckMetadataInvalid, SeverityError, "signed metadata is not a JSON object: %v", err)
+		return
+	}
+	if canonical, err := crypto.CanonicalJSON([]byte(signed)); err != nil {
+		r.add(CheckMetadataInvalid, SeverityError, "signed metadata cannot be canonicalized: %v", err)
+	} else if string(canonical) != signed && bound == signed {
+		r.add(CheckMetadataCanonical, SeverityWarning, "signed metadata is not in canonical JSON form, so tools re-serializing it derive a different anchor record")
+	}
+
+	exp, hasExp := meta["expiration_timestamp"]
+	switch {
+	case !hasExp && f.GetExpiresAt() == nil:
+		r.add(CheckMissingExpiration, SeverityWarning, "token never expires")
+	case hasExp && f.GetExpiresAt() != nil:
+		if n, ok := exp.(float64); !ok || int64(n) != f.GetExpiresAt().GetSeconds() {
+			r.add(CheckExpirationMismatch, SeverityError, "expires_at does not match metadata expiration_timestamp %v", exp)
+		}
+	case hasExp:
+		if _, ok := exp.(float64); !ok {
+			r.add(CheckMetadataInvalid, SeverityError, "expiration_timestamp %v is not a number", exp)
+		}
+	}
+	if nonce, _ := meta["nonce"].(string); nonce == "" {
+		r.add(CheckMissingNonce, SeverityWarning, "no nonce, so replays can only be caught by nullifier tracking")
+	}
+}
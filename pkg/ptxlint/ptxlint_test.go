@@ -0,0 +1,94 @@
+package ptxlint
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLint(t *testing.T) {
+	fx, err := fixture.Generate(fixture.Options{Seed: 1, Metadata: map[string]interface{}{
+		"expiration_timestamp": 4102444800,
+		"nonce":                "n-1",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clean, err := ptxloader.ParsePTX(fx.PTX, ptxloader.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := LintFile(clean); len(r.Findings) != 0 {
+		t.Fatalf("clean fixture has findings: %+v", r.Findings)
+	}
+
+	setSignal := func(i int, v string) func(*ptx.PtxFile) {
+		return func(f *ptx.PtxFile) {
+			var pd map[string]interface{}
+			json.Unmarshal(f.Proof.ProofData, &pd)
+			pd["publicSignals"].([]interface{})[i] = v
+			f.Proof.ProofData, _ = json.Marshal(pd)
+		}
+	}
+	for _, tc := range []struct {
+		check  string
+		sev    Severity
+		mutate func(*ptx.PtxFile)
+	}{
+		{CheckMissingExpiration, SeverityWarning, func(f *ptx.PtxFile) {
+			f.SignedMetadata = `{"nonce":"n-1"}`
+			f.ExpiresAt = nil
+		}},
+		{CheckMissingNonce, SeverityWarning, func(f *ptx.PtxFile) {
+			f.SignedMetadata = strings.Replace(f.SignedMetadata, `"nonce":"n-1"`, `"nonce":""`, 1)
+		}},
+		// Only without metadataEncoding, as verifiers canonicalize it for
+		// proofs binding the JCS form
+		{CheckMetadataCanonical, SeverityWarning, func(f *ptx.PtxFile) {
+			var pd map[string]interface{}
+			json.Unmarshal(f.Proof.ProofData, &pd)
+			delete(pd, "metadataEncoding")
+			f.Proof.ProofData, _ = json.Marshal(pd)
+			f.SignedMetadata = " " + f.SignedMetadata
+		}},
+		{CheckMetadataInvalid, SeverityError, func(f *ptx.PtxFile) { f.SignedMetadata = "[1]" }},
+		{CheckMetadataSize, SeverityError, func(f *ptx.PtxFile) {
+			f.SignedMetadata = `{"pad":"` + strings.Repeat("a", 70000) + `"}`
+		}},
+		{CheckTrustMethod, SeverityError, func(f *ptx.PtxFile) { f.TrustMethod = 42 }},
+		{CheckVerificationKeyID, SeverityError, func(f *ptx.PtxFile) { f.Proof.VerificationKeyId = "sdv_unknown" }},
+		{CheckSignalCount, SeverityError, func(f *ptx.PtxFile) {
+			var pd map[string]interface{}
+			json.Unmarshal(f.Proof.ProofData, &pd)
+			pd["publicSignals"] = pd["publicSignals"].([]interface{})[:4]
+			f.Proof.ProofData, _ = json.Marshal(pd)
+		}},
+		// The BN254 scalar field modulus itself
+		{CheckSignalOverField, SeverityError, setSignal(5, "21888242871839275222246405745257275088548364400416034343698204186575808495617")},
+		{CheckProofSystem, SeverityError, func(f *ptx.PtxFile) { f.Proof.ProofSystem = ptx.ProofSystem_PLONK }},
+		{CheckMissingEnvelope, SeverityWarning, func(f *ptx.PtxFile) { f.Proof.Envelope = nil }},
+		{CheckProofEnvelope, SeverityError, func(f *ptx.PtxFile) { f.Proof.Envelope.Curve = ptx.Curve_BLS12_381 }},
+	} {
+		t.Run(tc.check, func(t *testing.T) {
+			f := proto.Clone(clean).(*ptx.PtxFile)
+			tc.mutate(f)
+			r := LintFile(f)
+			i := slices.IndexFunc(r.Findings, func(x Finding) bool { return x.Check == tc.check })
+			if i < 0 {
+				t.Fatalf("no %s finding: %+v", tc.check, r.Findings)
+			}
+			if r.Findings[i].Severity != tc.sev {
+				t.Errorf("severity %s, want %s", r.Findings[i].Severity, tc.sev)
+			}
+			if !r.Fails(tc.sev) || (tc.sev == SeverityWarning && r.Fails(SeverityError)) {
+				t.Errorf("Fails does not match the findings: %+v", r.Findings)
+			}
+		})
+	}
+}
@@ -4,36 +4,206 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"os"
 
 	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-var MagicHeader = []byte{0x50, 0x54, 0x58, 0x01}
+// FormatVersion is the PTX format version provers write, the last byte of
+// the magic header. Version 2 changed the anchor hostname derivation (see
+// utils.HostnameV2).
+const FormatVersion = 0x02
 
-// LoadPTX reads and parses a PTX file
+// MinFormatVersion is the oldest format version this loader reads
+const MinFormatVersion = 0x01
+
+var MagicHeader = []byte{0x50, 0x54, 0x58, FormatVersion}
+
+// magicPrefix is the part of the magic header common to all versions
+var magicPrefix = MagicHeader[:3]
+
+// Header returns the magic header and reserved byte of a PTX file with the
+// given format version
+func Header(version uint32) []byte {
+	return []byte{0x50, 0x54, 0x58, byte(version), 0x00}
+}
+
+// headerSize is the magic header plus the reserved byte written by the prover
+const headerSize = 5
+
+const (
+	// DefaultMaxFileSize bounds the size of a PTX file read from disk
+	DefaultMaxFileSize = 1 << 20 // 1 MiB
+	// DefaultMaxProofSize bounds the size of the embedded proof payload
+	DefaultMaxProofSize = 256 << 10 // 256 KiB
+)
+
+var (
+	ErrInvalidMagic  = errors.New("invalid PTX magic header")
+	ErrTruncated     = errors.New("truncated PTX file")
+	ErrFileTooLarge  = errors.New("PTX file exceeds maximum size")
+	ErrProofTooLarge = errors.New("PTX proof payload exceeds maximum size")
+	ErrUnknownFields = errors.New("PTX file contains unknown protobuf fields")
+	ErrVersion       = errors.New("unsupported PTX format version")
+)
+
+// LoadOptions controls the limits applied while reading and parsing a PTX file
+type LoadOptions struct {
+	// MaxFileSize is the maximum accepted file size in bytes (0 = no limit)
+	MaxFileSize int64
+	// MaxProofSize is the maximum accepted proof_data size in bytes (0 = no limit)
+	MaxProofSize int
+	// RejectUnknownFields fails parsing if the protobuf message carries fields
+	// not known to this version of the schema
+	RejectUnknownFields bool
+}
+
+// DefaultLoadOptions returns the limits used by LoadPTX
+func DefaultLoadOptions() LoadOptions {
+	return LoadOptions{
+		MaxFileSize:  DefaultMaxFileSize,
+		MaxProofSize: DefaultMaxProofSize,
+	}
+}
+
+// LoadPTX reads and parses a PTX file using the default limits
 func LoadPTX(filePath string) (*ptx.PtxFile, error) {
-	data, err := ioutil.ReadFile(filePath)
+	return LoadPTXWithOptions(filePath, DefaultLoadOptions())
+}
+
+// LoadPTXWithOptions reads and parses a PTX file. The file size is checked
+// before any data is read so oversized inputs are never loaded into memory.
+func LoadPTXWithOptions(filePath string, opts LoadOptions) (*ptx.PtxFile, error) {
+	data, err := ReadFile(filePath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePTX(data, opts)
+}
+
+// ReadFile returns the raw bytes of a PTX file, enforcing opts.MaxFileSize
+// without parsing them
+func ReadFile(filePath string, opts LoadOptions) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if opts.MaxFileSize > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode().IsRegular() && info.Size() > opts.MaxFileSize {
+			return nil, fmt.Errorf("%w: %d bytes (limit %d)", ErrFileTooLarge, info.Size(), opts.MaxFileSize)
+		}
+	}
+
+	return readLimited(f, opts.MaxFileSize)
+}
+
+// ReadAll returns the raw bytes of a PTX file read from r, such as standard
+// input, enforcing opts.MaxFileSize
+func ReadAll(r io.Reader, opts LoadOptions) ([]byte, error) {
+	return readLimited(r, opts.MaxFileSize)
+}
+
+// readLimited reads at most limit bytes from r, failing if more are available.
+// This also covers non-regular files whose size cannot be known upfront.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
 	if err != nil {
 		return nil, err
 	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrFileTooLarge, limit)
+	}
+	return data, nil
+}
+
+// ParsePTX parses an in-memory PTX file
+func ParsePTX(data []byte, opts LoadOptions) (*ptx.PtxFile, error) {
+	if opts.MaxFileSize > 0 && int64(len(data)) > opts.MaxFileSize {
+		return nil, fmt.Errorf("%w: %d bytes (limit %d)", ErrFileTooLarge, len(data), opts.MaxFileSize)
+	}
 
-	if len(data) < 4 || !bytes.Equal(data[:4], MagicHeader) {
-		return nil, errors.New("invalid PTX magic header")
+	if len(data) < len(MagicHeader) || !bytes.Equal(data[:len(magicPrefix)], magicPrefix) {
+		return nil, ErrInvalidMagic
+	}
+	version := uint32(data[len(magicPrefix)])
+	if version < MinFormatVersion || version > FormatVersion {
+		return nil, fmt.Errorf("%w %d (this build reads %d to %d)", ErrVersion, version, MinFormatVersion, FormatVersion)
+	}
+
+	// The prover writes the magic header followed by one reserved byte
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("%w: missing header byte", ErrTruncated)
 	}
 
-	// Experimental: Try skipping 5 bytes if 4 fails?
-	// Based on hexdump showing AB at byte 4 (0-indexed 4, 1-indexed 5)
-	// PTX\x01 (0,1,2,3). Byte 4 is AB.
-	// 0x08 is at Byte 5.
-	// Tag 1 (08) -> Byte 5.
-	// So we should skip 5 bytes.
-	payload := data[5:]
+	payload := data[headerSize:]
 	ptxFile := &ptx.PtxFile{}
 	if err := proto.Unmarshal(payload, ptxFile); err != nil {
 		return nil, fmt.Errorf("failed to parse PTX protobuf: %w", err)
 	}
 
+	// Issuer signatures and timestamps cover the field but not the header
+	if ptxFile.Version() != version {
+		return nil, fmt.Errorf("%w: header says %d, the file %d", ErrVersion, version, ptxFile.Version())
+	}
+
+	if opts.RejectUnknownFields && hasUnknownFields(ptxFile.ProtoReflect()) {
+		return nil, ErrUnknownFields
+	}
+
+	if opts.MaxProofSize > 0 {
+		if size := len(ptxFile.GetProof().GetProofData()); size > opts.MaxProofSize {
+			return nil, fmt.Errorf("%w: %d bytes (limit %d)", ErrProofTooLarge, size, opts.MaxProofSize)
+		}
+	}
+
 	return ptxFile, nil
 }
+
+// hasUnknownFields reports whether m or any message nested in it carries
+// unknown fields
+func hasUnknownFields(m protoreflect.Message) bool {
+	if len(m.GetUnknown()) > 0 {
+		return true
+	}
+
+	found := false
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				if hasUnknownFields(list.Get(i).Message()) {
+					found = true
+					return false
+				}
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				if hasUnknownFields(mv.Message()) {
+					found = true
+				}
+				return !found
+			})
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			if hasUnknownFields(v.Message()) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,113 @@
+package ptxloader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func samplePTX(t testing.TB, proofData []byte) []byte {
+	t.Helper()
+	payload, err := proto.Marshal(&ptx.PtxFile{
+		TrustMethod:    ptx.TrustMethod_DOH,
+		SignedMetadata: `{"role":"validator"}`,
+		Proof: &ptx.ZkProof{
+			ProofSystem:       ptx.ProofSystem_GROTH16,
+			VerificationKeyId: "sdv_poseidon_v1",
+			ProofData:         proofData,
+		},
+		Anchor: &ptx.PtxFile_DohDetails{DohDetails: &ptx.DohAnchor{DomainName: "example.com"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append([]byte{0x50, 0x54, 0x58, 0x01, 0x00}, payload...)
+}
+
+func TestParsePTXLimits(t *testing.T) {
+	valid := samplePTX(t, []byte(`{"source":"gnark_native"}`))
+	withUnknown := protowire.AppendTag(append([]byte{}, valid...), 99, protowire.BytesType)
+	withUnknown = protowire.AppendBytes(withUnknown, []byte("future"))
+	withVersion := func(header byte, field uint64) []byte {
+		data := append([]byte{}, valid...)
+		data[3] = header
+		if field != 0 {
+			data = protowire.AppendTag(data, 13, protowire.VarintType)
+			data = protowire.AppendVarint(data, field)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		opts LoadOptions
+		want error
+	}{
+		{"valid", valid, DefaultLoadOptions(), nil},
+		{"bad magic", []byte("PK\x03\x04payload"), DefaultLoadOptions(), ErrInvalidMagic},
+		{"header only", valid[:4], DefaultLoadOptions(), ErrTruncated},
+		{"version 2", withVersion(2, 2), DefaultLoadOptions(), nil},
+		{"future version", withVersion(3, 3), DefaultLoadOptions(), ErrVersion},
+		{"version 2 header on a version 1 file", withVersion(2, 0), DefaultLoadOptions(), ErrVersion},
+		{"version 1 header on a version 2 file", withVersion(1, 2), DefaultLoadOptions(), ErrVersion},
+		{"file too large", valid, LoadOptions{MaxFileSize: 8}, ErrFileTooLarge},
+		{"proof too large", samplePTX(t, make([]byte, 64)), LoadOptions{MaxProofSize: 32}, ErrProofTooLarge},
+		{"unknown fields tolerated", withUnknown, DefaultLoadOptions(), nil},
+		{"unknown fields rejected", withUnknown, LoadOptions{RejectUnknownFields: true}, ErrUnknownFields},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePTX(tt.data, tt.opts)
+			if tt.want == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadPTXRejectsOversizedFileBeforeReading(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.ptx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sparse file: reported size is large but nothing is written
+	if err := f.Truncate(DefaultMaxFileSize + 1); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := LoadPTX(path); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func FuzzParsePTX(f *testing.F) {
+	valid := samplePTX(f, []byte(`{"source":"gnark_native","publicSignals":["1","2"]}`))
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add(valid[:5])
+	f.Add([]byte{})
+	f.Add([]byte("PTX\x01"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		opts := LoadOptions{MaxFileSize: 1 << 16, MaxProofSize: 1 << 12, RejectUnknownFields: true}
+		ptxFile, err := ParsePTX(data, opts)
+		if err != nil {
+			return
+		}
+		if len(ptxFile.GetProof().GetProofData()) > opts.MaxProofSize {
+			t.Fatalf("proof payload of %d bytes accepted", len(ptxFile.GetProof().GetProofData()))
+		}
+	})
+}
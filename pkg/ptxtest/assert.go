@@ -0,0 +1,75 @@
+package ptxtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// AssertAccepted fails the test unless res accepts the PTX with valid DNS
+// and ZK checks
+func AssertAccepted(t testing.TB, res *verifier.VerificationResult) {
+	t.Helper()
+	if res == nil {
+		t.Fatal("ptxtest: no verification result")
+	}
+	if !res.Success {
+		t.Fatalf("ptxtest: PTX rejected (%s): %s", res.Code, strings.Join(res.Errors, "; "))
+	}
+	if !res.Dns.Valid {
+		t.Errorf("ptxtest: DNS anchor invalid: %s", res.Dns.Error)
+	}
+	if !res.Zk.Valid {
+		t.Errorf("ptxtest: proof invalid: %s", res.Zk.Error)
+	}
+}
+
+// AssertRejected fails the test unless res rejects the PTX with code
+func AssertRejected(t testing.TB, res *verifier.VerificationResult, code verifier.ErrorCode) {
+	t.Helper()
+	if res == nil {
+		t.Fatal("ptxtest: no verification result")
+	}
+	if res.Success {
+		t.Fatalf("ptxtest: PTX accepted, want rejection with %s", code)
+	}
+	if res.Code != code {
+		t.Fatalf("ptxtest: PTX rejected with %s, want %s: %s", res.Code, code, strings.Join(res.Errors, "; "))
+	}
+}
+
+// AssertError fails the test unless one of the errors of res contains substr
+func AssertError(t testing.TB, res *verifier.VerificationResult, substr string) {
+	t.Helper()
+	for _, e := range res.Errors {
+		if strings.Contains(e, substr) {
+			return
+		}
+	}
+	t.Fatalf("ptxtest: no error contains %q, got %q", substr, res.Errors)
+}
+
+// AssertWarning fails the test unless one of the warnings of res contains
+// substr
+func AssertWarning(t testing.TB, res *verifier.VerificationResult, substr string) {
+	t.Helper()
+	for _, w := range res.Warnings {
+		if strings.Contains(w, substr) {
+			return
+		}
+	}
+	t.Fatalf("ptxtest: no warning contains %q, got %q", substr, res.Warnings)
+}
+
+// AssertDetails fails the test unless res verified the PTX for domain with
+// the given metadata JSON
+func AssertDetails(t testing.TB, res *verifier.VerificationResult, domain, metadataJSON string) {
+	t.Helper()
+	if res.Details.Fqdn != domain {
+		t.Errorf("ptxtest: fqdn %q, want %q", res.Details.Fqdn, domain)
+	}
+	if res.Details.MetadataJSON != metadataJSON {
+		t.Errorf("ptxtest: metadata %s, want %s", res.Details.MetadataJSON, metadataJSON)
+	}
+}
@@ -0,0 +1,108 @@
+package ptxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+)
+
+// DoHServer is a DoH resolver speaking the dns-json API over plain HTTP. It
+// answers TXT queries from the records set on it and can simulate outages.
+type DoHServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	records map[string][]string
+	status  int
+	queries atomic.Int64
+}
+
+// NewDoHServer starts a DoH server without records. Close it when done.
+func NewDoHServer() *DoHServer {
+	s := &DoHServer{records: map[string][]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Set replaces the TXT records of hostname
+func (s *DoHServer) Set(hostname string, records ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[canonicalName(hostname)] = append([]string(nil), records...)
+}
+
+// Remove deletes the records of hostname, so queries for it get NXDOMAIN
+func (s *DoHServer) Remove(hostname string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, canonicalName(hostname))
+}
+
+// FailWith makes every query fail with the HTTP status code, simulating a
+// resolver outage. Zero restores normal answers.
+func (s *DoHServer) FailWith(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// Queries returns the number of queries received
+func (s *DoHServer) Queries() int {
+	return int(s.queries.Load())
+}
+
+// Resolver returns a DoH client querying the server
+func (s *DoHServer) Resolver() dns.Resolver {
+	c, err := dns.NewClient(dns.ClientConfig{Endpoint: s.URL, DisableHTTP2: true})
+	if err != nil {
+		// The configuration is fixed, so this cannot fail
+		panic(err)
+	}
+	return c
+}
+
+func (s *DoHServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.queries.Add(1)
+	s.mu.Lock()
+	status := s.status
+	records, found := s.records[canonicalName(r.URL.Query().Get("name"))]
+	s.mu.Unlock()
+
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if r.Method != http.MethodGet || r.URL.Query().Get("name") == "" {
+		http.Error(w, "only dns-json GET queries are supported", http.StatusBadRequest)
+		return
+	}
+
+	var resp dns.DoHResponse
+	if !found {
+		resp.Status = 3 // NXDOMAIN
+	}
+	if qtype := r.URL.Query().Get("type"); found && (qtype == "TXT" || qtype == "16") {
+		resp.Answer = make([]struct {
+			Name string `json:"name"`
+			Type int    `json:"type"`
+			Data string `json:"data"`
+		}, len(records))
+		for i, rec := range records {
+			resp.Answer[i].Name = r.URL.Query().Get("name")
+			resp.Answer[i].Type = 16
+			resp.Answer[i].Data = strconv.Quote(rec)
+		}
+	}
+	w.Header().Set("Content-Type", "application/dns-json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func canonicalName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
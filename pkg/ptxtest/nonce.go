@@ -0,0 +1,81 @@
+package ptxtest
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryNonceStore is an in-memory verifier.MultiNonceStore
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]int64
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// NewMemoryNonceStore returns an empty store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: map[string]int64{}}
+}
+
+// CheckAndSetNonce records nonce until expiresAt and reports
+// whether it was unseen. Like the Redis store it rejects nonces that have
+// already expired and forgets recorded ones once they expire.
+func (s *MemoryNonceStore) CheckAndSetNonce(nonce string, expiresAt time.Time) (bool, error) {
+	now, expirationTimestamp := s.now(), expiresAt.Unix()
+	if expirationTimestamp < now {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if exp, ok := s.nonces[nonce]; ok && exp > now {
+		return false, nil
+	}
+	s.nonces[nonce] = expirationTimestamp
+	return true, nil
+}
+
+// CheckAndSetNonces records all nonces if none of them was seen, and
+// returns the index of the first one seen, or -1
+func (s *MemoryNonceStore) CheckAndSetNonces(nonces []string, expiresAt time.Time) (int, error) {
+	now, expirationTimestamp := s.now(), expiresAt.Unix()
+	if expirationTimestamp < now {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, n := range nonces {
+		if exp, ok := s.nonces[n]; ok && exp > now {
+			return i, nil
+		}
+	}
+	for _, n := range nonces {
+		s.nonces[n] = expirationTimestamp
+	}
+	return -1, nil
+}
+
+// Seen reports whether nonce is recorded and not expired
+func (s *MemoryNonceStore) Seen(nonce string) bool {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.nonces[nonce]
+	return ok && exp > now
+}
+
+// Reset forgets all nonces
+func (s *MemoryNonceStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.nonces)
+}
+
+func (s *MemoryNonceStore) now() int64 {
+	if s.Now != nil {
+		return s.Now().Unix()
+	}
+	return time.Now().Unix()
+}
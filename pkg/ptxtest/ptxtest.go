@@ -0,0 +1,94 @@
+// Package ptxtest runs the full verification pipeline hermetically, for
+// integration tests of services that accept PTX files. An Env bundles a fake
+// DoH resolver, an in-memory nonce store and a deterministic prover:
+//
+//	env := ptxtest.New(t)
+//	tok := env.Issue(fixture.Options{Metadata: map[string]interface{}{"role": "admin", "nonce": "n1"}})
+//	ptxtest.AssertAccepted(t, env.Verify(tok))
+//	ptxtest.AssertRejected(t, env.Verify(tok), verifier.CodeNonceReplayed)
+//
+// Tokens are proven with the fixture package, under keys set up from a fixed
+// seed. The setup runs once per seed and process (about a second) and
+// anyone can forge proofs under these keys, so they must never be trusted
+// outside of tests.
+package ptxtest
+
+import (
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// Env is a hermetic verification environment
+type Env struct {
+	t     testing.TB
+	DoH   *DoHServer
+	Nonce *MemoryNonceStore
+}
+
+// Token is an issued PTX file with its verification key
+type Token = fixture.Fixture
+
+// New starts an environment, which is torn down when the test ends
+func New(t testing.TB) *Env {
+	t.Helper()
+	env := &Env{t: t, DoH: NewDoHServer(), Nonce: NewMemoryNonceStore()}
+	t.Cleanup(env.DoH.Close)
+	return env
+}
+
+// Issue proves a PTX file and publishes its anchor on the DoH server.
+// The same options always produce the same token.
+func (e *Env) Issue(opts fixture.Options) *Token {
+	e.t.Helper()
+	tok := e.Prove(opts)
+	e.Publish(tok)
+	return tok
+}
+
+// Prove proves a PTX file without publishing its anchor
+func (e *Env) Prove(opts fixture.Options) *Token {
+	e.t.Helper()
+	tok, err := fixture.Generate(opts)
+	if err != nil {
+		e.t.Fatalf("ptxtest: failed to prove token: %v", err)
+	}
+	return tok
+}
+
+// Publish sets the anchor record of tok on the DoH server
+func (e *Env) Publish(tok *Token) {
+	e.DoH.Set(tok.Anchor.Hostname, tok.Anchor.Value)
+}
+
+// Revoke removes the anchor record of tok from the DoH server
+func (e *Env) Revoke(tok *Token) {
+	e.DoH.Remove(tok.Anchor.Hostname)
+}
+
+// Options returns the options verifying tok against the environment.
+// Snarkjs-style tokens are verified under the same native key.
+func (e *Env) Options(tok *Token) verifier.VerificationOptions {
+	return verifier.VerificationOptions{
+		PTXData:     tok.PTX,
+		VKData:      tok.VK,
+		DNSResolver: e.DoH.Resolver(),
+		NonceStore:  e.Nonce,
+	}
+}
+
+// Verify runs the verifier on tok. Each option function may adjust the
+// options first, e.g. to set an intended scope.
+func (e *Env) Verify(tok *Token, opts ...func(*verifier.VerificationOptions)) *verifier.VerificationResult {
+	e.t.Helper()
+	o := e.Options(tok)
+	for _, fn := range opts {
+		fn(&o)
+	}
+	res, err := verifier.NewPTXVerifier(o).Verify()
+	if err != nil {
+		e.t.Fatalf("ptxtest: verification error: %v", err)
+	}
+	return res
+}
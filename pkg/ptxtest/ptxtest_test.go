@@ -0,0 +1,55 @@
+package ptxtest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func TestEnv(t *testing.T) {
+	env := New(t)
+	tok := env.Issue(fixture.Options{Metadata: map[string]interface{}{"role": "validator", "nonce": "n-1"}})
+
+	res := env.Verify(tok)
+	AssertAccepted(t, res)
+	AssertDetails(t, res, "example.com", `{"nonce":"n-1","role":"validator"}`)
+	if !env.Nonce.Seen("n-1") {
+		t.Error("nonce not recorded")
+	}
+
+	AssertRejected(t, env.Verify(tok), verifier.CodeNonceReplayed)
+
+	env.Nonce.Reset()
+	env.Revoke(tok)
+	AssertRejected(t, env.Verify(tok), verifier.CodeDNSAnchor)
+
+	env.Nonce.Reset()
+	env.Publish(tok)
+	env.DoH.FailWith(http.StatusServiceUnavailable)
+	AssertRejected(t, env.Verify(tok), verifier.CodeDNSAnchor)
+	if env.DoH.Queries() == 0 {
+		t.Error("DoH server was not queried")
+	}
+}
+
+func TestTrackNullifiers(t *testing.T) {
+	env := New(t)
+	track := func(o *verifier.VerificationOptions) { o.TrackNullifiers = true }
+	meta := map[string]interface{}{"role": "validator", "nonce": "shared"}
+	first := env.Issue(fixture.Options{Seed: 1, Metadata: meta})
+	second := env.Issue(fixture.Options{Seed: 2, Metadata: meta})
+
+	res := env.Verify(first, track)
+	AssertAccepted(t, res)
+	AssertRejected(t, env.Verify(first, track), verifier.CodeNullifierReplayed)
+
+	// The nonce is taken, so the nullifier of the second token must not be
+	// recorded either
+	res = env.Verify(second, track)
+	AssertRejected(t, res, verifier.CodeNonceReplayed)
+	if env.Nonce.Seen(verifier.NullifierKeyPrefix + res.Details.NullifierHash) {
+		t.Error("nullifier recorded although the nonce was replayed")
+	}
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests per Per, with bursts of up to Requests. A zero Limit
+// disables limiting.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled reports whether l limits anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// rate returns the refill rate in tokens per millisecond
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / float64(l.Per.Milliseconds())
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// Validate rejects negative limits and windows too short to refill
+func (l Limit) Validate() error {
+	if l.Requests < 0 || l.Per < 0 {
+		return errors.New("rate limit must not be negative")
+	}
+	if l.Requests > 0 && l.Per < time.Millisecond {
+		return errors.New("rate limit window must be at least 1ms")
+	}
+	return nil
+}
+
+// Decision is the outcome of Allow
+type Decision struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long until a token is available when not allowed
+	RetryAfter time.Duration
+}
+
+// Limiter is a keyed token bucket
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Decision, error)
+	// Ping checks that the bucket store is reachable
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// memoryLimiter keeps buckets in process memory. It is used when no Redis
+// server is configured, so limits are per instance.
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration
+}
+
+// maxMemoryBuckets triggers a sweep of buckets that have refilled completely
+const maxMemoryBuckets = 10000
+
+// NewMemory returns an in-process Limiter
+func NewMemory() Limiter {
+	return &memoryLimiter{buckets: make(map[string]*bucket)}
+}
+
+func (m *memoryLimiter) Allow(_ context.Context, key string, limit Limit) (Decision, error) {
+	if !limit.Enabled() {
+		return Decision{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	b, ok := m.buckets[key]
+	if !ok {
+		if len(m.buckets) >= maxMemoryBuckets {
+			m.sweep(now)
+		}
+		b = &bucket{tokens: float64(limit.Requests), last: now, idle: limit.Per}
+		m.buckets[key] = b
+	}
+
+	elapsed := float64(now.Sub(b.last).Milliseconds())
+	b.tokens = min(float64(limit.Requests), b.tokens+elapsed*limit.rate())
+	b.last = now
+	return take(&b.tokens, limit), nil
+}
+
+// sweep drops buckets untouched long enough to be full again
+func (m *memoryLimiter) sweep(now time.Time) {
+	for k, b := range m.buckets {
+		if now.Sub(b.last) > b.idle {
+			delete(m.buckets, k)
+		}
+	}
+}
+
+func (m *memoryLimiter) Ping(context.Context) error {
+	return nil
+}
+
+func (m *memoryLimiter) Close() error {
+	return nil
+}
+
+// take consumes one token if available
+func take(tokens *float64, limit Limit) Decision {
+	if *tokens >= 1 {
+		*tokens--
+		return Decision{Allowed: true, Remaining: int(*tokens)}
+	}
+	wait := time.Duration((1-*tokens)/limit.rate()) * time.Millisecond
+	return Decision{RetryAfter: wait}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/redisconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix namespaces bucket keys in Redis
+const KeyPrefix = "ptx:rl:"
+
+// tokenBucket refills and takes from a bucket atomically. Buckets expire once
+// they would be full again so idle keys do not accumulate.
+//
+// KEYS[1] bucket key; ARGV: burst, rate (tokens/ms), now (ms)
+var tokenBucket = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate))
+return {allowed, tostring(tokens)}
+`)
+
+type redisLimiter struct {
+	client redis.UniversalClient
+}
+
+// NewRedis returns a Limiter sharing buckets across instances through the
+// Redis deployment at url (see redisconn.Open)
+func NewRedis(url string) (Limiter, error) {
+	client, err := redisconn.Open(url)
+	if err != nil {
+		return nil, err
+	}
+	return &redisLimiter{client: client}, nil
+}
+
+func (r *redisLimiter) Allow(ctx context.Context, key string, limit Limit) (Decision, error) {
+	if !limit.Enabled() {
+		return Decision{Allowed: true}, nil
+	}
+
+	now := time.Now().UnixMilli()
+	res, err := tokenBucket.Run(ctx, r.client, []string{KeyPrefix + key}, limit.Requests, limit.rate(), now).Slice()
+	if err != nil {
+		return Decision{}, err
+	}
+
+	allowed, _ := res[0].(int64)
+	var tokens float64
+	if s, ok := res[1].(string); ok {
+		tokens, _ = strconv.ParseFloat(s, 64)
+	}
+	if allowed == 1 {
+		return Decision{Allowed: true, Remaining: int(tokens)}, nil
+	}
+	return take(&tokens, limit), nil
+}
+
+func (r *redisLimiter) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *redisLimiter) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,149 @@
+// Package redisconn opens the Redis client shared by the nonce store, rate
+// limiter and challenge store. One URL selects the topology:
+//
+//	redis://host:6379/0                                single node
+//	rediss+cluster://host:6379?addr=host2:6379         Redis Cluster, seed nodes
+//	redis+sentinel://host:26379?master_name=mymaster   Sentinel, sentinel nodes
+//
+// rediss schemes use TLS. The query accepts the go-redis options of the
+// topology (pool_size, max_active_conns, min_idle_conns, dial_timeout, ...)
+// plus tls_ca_file, tls_cert_file, tls_key_file and tls_server_name, so that
+// private CAs and client certificates need no code. REDIS_USERNAME and
+// REDIS_PASSWORD authenticate when the URL carries no credentials, which keeps
+// secrets out of command lines.
+package redisconn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Topologies selected by the URL scheme suffix
+const (
+	TopologySingle   = ""
+	TopologyCluster  = "cluster"
+	TopologySentinel = "sentinel"
+)
+
+// tlsParams are the query parameters read here rather than by go-redis
+var tlsParams = []string{"tls_ca_file", "tls_cert_file", "tls_key_file", "tls_server_name"}
+
+// Open returns a client for the Redis deployment at rawURL. It does not
+// connect; use Ping to check that the deployment is reachable.
+func Open(rawURL string) (redis.UniversalClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	scheme, topology, _ := strings.Cut(u.Scheme, "+")
+
+	q := u.Query()
+	params := make(map[string]string, len(tlsParams))
+	for _, name := range tlsParams {
+		params[name] = q.Get(name)
+		q.Del(name)
+	}
+	u.Scheme = scheme
+	u.RawQuery = q.Encode()
+	username, password := os.Getenv("REDIS_USERNAME"), os.Getenv("REDIS_PASSWORD")
+
+	switch topology {
+	case TopologySingle:
+		opts, err := redis.ParseURL(u.String())
+		if err != nil {
+			return nil, err
+		}
+		if opts.TLSConfig, err = configureTLS(opts.TLSConfig, params); err != nil {
+			return nil, err
+		}
+		if opts.Username == "" && opts.Password == "" {
+			opts.Username, opts.Password = username, password
+		}
+		return redis.NewClient(opts), nil
+
+	case TopologyCluster:
+		opts, err := redis.ParseClusterURL(u.String())
+		if err != nil {
+			return nil, err
+		}
+		if opts.TLSConfig, err = configureTLS(opts.TLSConfig, params); err != nil {
+			return nil, err
+		}
+		if opts.Username == "" && opts.Password == "" {
+			opts.Username, opts.Password = username, password
+		}
+		return redis.NewClusterClient(opts), nil
+
+	case TopologySentinel:
+		opts, err := redis.ParseFailoverURL(u.String())
+		if err != nil {
+			return nil, err
+		}
+		if opts.MasterName == "" {
+			return nil, errors.New("redis: sentinel URL needs master_name")
+		}
+		if opts.TLSConfig, err = configureTLS(opts.TLSConfig, params); err != nil {
+			return nil, err
+		}
+		// The same credentials usually protect the sentinels and the master
+		if opts.Username == "" && opts.Password == "" {
+			opts.Username, opts.Password = username, password
+		}
+		if opts.SentinelUsername == "" && opts.SentinelPassword == "" {
+			opts.SentinelUsername, opts.SentinelPassword = username, password
+		}
+		return redis.NewFailoverClient(opts), nil
+
+	default:
+		return nil, fmt.Errorf("redis: unknown topology %q (expected %s or %s)", topology, TopologyCluster, TopologySentinel)
+	}
+}
+
+// configureTLS applies the tls_* parameters to the TLS configuration of a
+// rediss URL
+func configureTLS(cfg *tls.Config, params map[string]string) (*tls.Config, error) {
+	set := false
+	for _, v := range params {
+		set = set || v != ""
+	}
+	if !set {
+		return cfg, nil
+	}
+	if cfg == nil {
+		return nil, errors.New("redis: tls_* parameters need a rediss:// URL")
+	}
+
+	if name := params["tls_server_name"]; name != "" {
+		cfg.ServerName = name
+	}
+	if file := params["tls_ca_file"]; file != "" {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis: no certificates in %s", file)
+		}
+		cfg.RootCAs = pool
+	}
+	cert, key := params["tls_cert_file"], params["tls_key_file"]
+	if (cert == "") != (key == "") {
+		return nil, errors.New("redis: tls_cert_file and tls_key_file must be set together")
+	}
+	if cert != "" {
+		pair, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return cfg, nil
+}
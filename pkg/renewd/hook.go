@@ -0,0 +1,106 @@
+package renewd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+// DefaultHookTimeout bounds a single hook notification
+const DefaultHookTimeout = 30 * time.Second
+
+// Hook is notified of failed renewals
+type Hook interface {
+	// Name returns a short identifier for the hook
+	Name() string
+	Notify(ctx context.Context, r Result) error
+}
+
+// WebhookHook POSTs failed results as JSON to URL
+type WebhookHook struct {
+	URL string
+	// Secret signs the body into events.SignatureHeader, like the
+	// verification webhooks
+	Secret []byte
+	// Client defaults to outbound.DefaultClient
+	Client *http.Client
+}
+
+func (h *WebhookHook) Name() string {
+	return "webhook"
+}
+
+// Notify implements Hook. Any non-2xx response is an error.
+func (h *WebhookHook) Notify(ctx context.Context, r Result) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultHookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(h.Secret) > 0 {
+		req.Header.Set(events.SignatureHeader, events.Sign(h.Secret, body))
+	}
+
+	client := h.Client
+	if client == nil {
+		client = outbound.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// CommandHook runs a shell command for each failed result, with the result
+// JSON on its standard input and PTX_RENEW_TOKEN, PTX_RENEW_DOMAIN,
+// PTX_RENEW_STAGE and PTX_RENEW_ERROR in its environment
+type CommandHook struct {
+	Command string
+}
+
+func (h *CommandHook) Name() string {
+	return "command"
+}
+
+// Notify implements Hook. A non-zero exit status is an error.
+func (h *CommandHook) Notify(ctx context.Context, r Result) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"PTX_RENEW_TOKEN="+r.Token,
+		"PTX_RENEW_DOMAIN="+r.Domain,
+		"PTX_RENEW_STAGE="+r.Stage,
+		"PTX_RENEW_ERROR="+r.Error,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+// Package renewd renews PTX tokens before they expire: it periodically
+// re-proves every token close to its expiration with the nullifier and secret
+// it was minted with (see wallet.Renew), publishes the anchor record of the
+// renewed token through a DNS provider, and only then replaces the token in
+// its source. Failures are reported to hooks, so an operator learns about a
+// token that could not be renewed before it expires.
+package renewd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dnsprovider"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/wallet"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// DefaultInterval is how often a Daemon checks its tokens when no interval
+// is configured
+const DefaultInterval = 10 * time.Minute
+
+// Stages of a renewal, reported in Result.Stage
+const (
+	StageLoad    = "load"
+	StageRenew   = "renew"
+	StagePublish = "publish"
+	StageStore   = "store"
+)
+
+// Source holds the tokens a Daemon renews
+type Source interface {
+	// Tokens returns the current tokens. It is called on every check, so
+	// tokens added in the meantime are picked up.
+	Tokens() ([]*wallet.Token, error)
+	// Store replaces old with renewed, which has the same name
+	Store(old, renewed *wallet.Token) error
+}
+
+// Result is the outcome of a token in one check. Tokens that are not due are
+// not reported.
+type Result struct {
+	Time   time.Time `json:"time"`
+	Token  string    `json:"token,omitempty"`
+	Domain string    `json:"domain,omitempty"`
+	// Stage is where a renewal failed, empty on success
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error,omitempty"`
+	// ExpiresAt is the expiration of the renewed token, or of the token
+	// that failed to renew
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Commitment is the commitment of the renewed token
+	Commitment string `json:"commitment,omitempty"`
+	// Anchor is the anchor record of the renewed token. Unpublished is set
+	// when the daemon did not publish it: without a DNS provider, or for a
+	// trust method other than DOH. It must then be published by other means.
+	Anchor      string `json:"anchor,omitempty"`
+	Unpublished bool   `json:"unpublished,omitempty"`
+}
+
+// Failed reports whether the renewal failed
+func (r *Result) Failed() bool {
+	return r.Stage != ""
+}
+
+// Daemon renews the tokens of a Source
+type Daemon struct {
+	Source Source
+	// Renew configures the renewals. Its ExpiresIn zero keeps the lifetime
+	// of each token.
+	Renew wallet.RenewOptions
+	// RenewBefore is how long before its expiration a token is renewed.
+	// Zero renews once a third of the lifetime is left.
+	RenewBefore time.Duration
+	// Interval between checks, DefaultInterval if zero
+	Interval time.Duration
+
+	// Publisher, when set, publishes the anchor records of renewed DOH
+	// tokens with TTL
+	Publisher dnsprovider.Provider
+	TTL       int
+	// WaitTimeout, when positive, waits up to this long for the published
+	// record to be visible through DoH before the token is replaced,
+	// polling every PollInterval
+	WaitTimeout  time.Duration
+	PollInterval time.Duration
+
+	// Hooks are notified of failures. A failure repeating with the same
+	// error on the following checks is only notified once.
+	Hooks []Hook
+	// OnResult, when set, is called with every result, and OnError with
+	// the errors of hooks
+	OnResult func(Result)
+	OnError  func(error)
+
+	// notified is the last error notified per token
+	notified map[string]string
+}
+
+// Run checks the tokens immediately and then every Interval until ctx is
+// done
+func (d *Daemon) Run(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		d.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check renews every token that is due and returns the results
+func (d *Daemon) Check(ctx context.Context) []Result {
+	var results []Result
+	tokens, err := d.Source.Tokens()
+	if err != nil {
+		results = append(results, d.failure(nil, StageLoad, err))
+	}
+	for _, t := range tokens {
+		if ctx.Err() != nil {
+			break
+		}
+		if !d.due(t) {
+			continue
+		}
+		results = append(results, d.renew(ctx, t))
+	}
+	for _, r := range results {
+		d.report(ctx, r)
+	}
+	return results
+}
+
+// due reports whether t expires within the renewal window
+func (d *Daemon) due(t *wallet.Token) bool {
+	if t.ExpiresAt == nil {
+		return false
+	}
+	before := d.RenewBefore
+	if before <= 0 {
+		before = t.ExpiresAt.Sub(t.IssuedAt) / 3
+	}
+	return !time.Now().Before(t.ExpiresAt.Add(-before))
+}
+
+func (d *Daemon) renew(ctx context.Context, t *wallet.Token) Result {
+	renewed, err := wallet.Renew(ctx, t, d.Renew)
+	if err != nil {
+		return d.failure(t, StageRenew, err)
+	}
+	record, err := renewed.Anchor()
+	if err != nil {
+		return d.failure(t, StageRenew, err)
+	}
+	r := Result{
+		Time:       time.Now(),
+		Token:      renewed.Name,
+		Domain:     renewed.Domain,
+		ExpiresAt:  renewed.ExpiresAt,
+		Commitment: renewed.Commitment,
+		Anchor:     record.Hostname + " TXT " + record.Value,
+	}
+
+	switch publish, err := publishable(renewed); {
+	case err != nil:
+		return d.failure(t, StageRenew, err)
+	case !publish:
+		// Fixed label anchors do not change with the commitment
+		r.Anchor = ""
+	case d.Publisher == nil || renewed.TrustMethod != int(ptx.TrustMethod_DOH):
+		r.Unpublished = true
+	default:
+		if err := d.Publisher.UpsertTXT(ctx, record.Hostname, record.Value, d.TTL); err != nil {
+			return d.failure(t, StagePublish, err)
+		}
+		if d.WaitTimeout > 0 {
+			poll := d.PollInterval
+			if poll <= 0 {
+				poll = 10 * time.Second
+			}
+			waitCtx, cancel := context.WithTimeout(ctx, d.WaitTimeout)
+			_, err := dnsprovider.WaitForTXT(waitCtx, record.Hostname, record.Value, poll)
+			cancel()
+			if err != nil {
+				return d.failure(t, StagePublish, err)
+			}
+		}
+	}
+
+	if err := d.Source.Store(t, renewed); err != nil {
+		return d.failure(t, StageStore, err)
+	}
+	return r
+}
+
+// publishable reports whether the anchor record of t derives from its
+// commitment, i.e. must be published again after a renewal
+func publishable(t *wallet.Token) (bool, error) {
+	f, err := ptxloader.ParsePTX(t.PTX, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return false, err
+	}
+	return f.GetDohDetails().GetLabelMode() != ptx.LabelMode_LABEL_FIXED, nil
+}
+
+func (d *Daemon) failure(t *wallet.Token, stage string, err error) Result {
+	r := Result{Time: time.Now(), Stage: stage, Error: err.Error()}
+	if t != nil {
+		r.Token, r.Domain, r.ExpiresAt = t.Name, t.Domain, t.ExpiresAt
+	}
+	return r
+}
+
+// report passes r to OnResult and failures to the hooks
+func (d *Daemon) report(ctx context.Context, r Result) {
+	if d.OnResult != nil {
+		d.OnResult(r)
+	}
+	if d.notified == nil {
+		d.notified = make(map[string]string)
+	}
+	if !r.Failed() {
+		delete(d.notified, r.Token)
+		return
+	}
+	msg := r.Stage + ": " + r.Error
+	if d.notified[r.Token] == msg {
+		return
+	}
+	d.notified[r.Token] = msg
+
+	var errs []error
+	for _, h := range d.Hooks {
+		if err := h.Notify(ctx, r); err != nil {
+			errs = append(errs, fmt.Errorf("%s hook: %w", h.Name(), err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil && d.OnError != nil {
+		d.OnError(err)
+	}
+}
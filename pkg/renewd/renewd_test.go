@@ -0,0 +1,126 @@
+package renewd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/wallet"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// keyBackend proves with the fixture keys
+type keyBackend struct {
+	ccs constraint.ConstraintSystem
+	pk  groth16.ProvingKey
+}
+
+func (b keyBackend) Name() string { return "fixture" }
+
+func (b keyBackend) Prove(ctx context.Context, inputs *prover.CircuitInputs) ([]byte, error) {
+	return prover.NewProver().ProveWithKey(b.ccs, b.pk, inputs)
+}
+
+type recordingProvider map[string]string
+
+func (p recordingProvider) Name() string { return "recording" }
+
+func (p recordingProvider) UpsertTXT(ctx context.Context, name, value string, ttl int) error {
+	p[name] = value
+	return nil
+}
+
+type countingHook struct{ results []Result }
+
+func (h *countingHook) Name() string { return "counting" }
+
+func (h *countingHook) Notify(ctx context.Context, r Result) error {
+	h.results = append(h.results, r)
+	return nil
+}
+
+// writeFixture writes an expired fixture token to dir, with its secrets
+// unless withSecrets is false
+func writeFixture(t *testing.T, dir, name string, seed int64, withSecrets bool) *fixture.Fixture {
+	f, err := fixture.Generate(fixture.Options{
+		Seed:     seed,
+		Metadata: map[string]interface{}{"expiration_timestamp": fixture.IssuedAt.Unix() + 3600},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".ptx"), f.PTX, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if withSecrets {
+		secrets, _ := json.Marshal(map[string]string{"nullifier": f.Inputs.Nullifier, "secret": f.Inputs.Secret})
+		if err := os.WriteFile(filepath.Join(dir, name+SecretsSuffix), secrets, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func TestCheckRenewsAndPublishes(t *testing.T) {
+	dir := t.TempDir()
+	f := writeFixture(t, dir, "token", 1, true)
+	ccs, pk, _, err := fixture.Keys(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published := recordingProvider{}
+	hook := &countingHook{}
+	d := &Daemon{
+		Source:    &DirSource{Dir: dir},
+		Renew:     wallet.RenewOptions{Backend: keyBackend{ccs, pk}},
+		Publisher: published,
+		Hooks:     []Hook{hook},
+	}
+	results := d.Check(context.Background())
+	if len(results) != 1 || results[0].Failed() || results[0].Unpublished {
+		t.Fatalf("results = %+v", results)
+	}
+
+	renewed, err := (&DirSource{Dir: dir}).Tokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed[0].Commitment == f.Inputs.Commitment || renewed[0].NullifierHash != f.Inputs.NullifierHash {
+		t.Fatal("token file was not renewed under the same nullifier")
+	}
+	record, _ := renewed[0].Anchor()
+	if published[record.Hostname] != record.Value {
+		t.Fatalf("anchor %s not published: %v", record.Hostname, published)
+	}
+
+	// The renewed token has its full lifetime left
+	if results := d.Check(context.Background()); len(results) != 0 {
+		t.Fatalf("renewed token renewed again: %+v", results)
+	}
+	if len(hook.results) != 0 {
+		t.Fatalf("hook notified of %+v", hook.results)
+	}
+}
+
+func TestCheckNotifiesFailureOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "token", 1, false)
+
+	hook := &countingHook{}
+	d := &Daemon{Source: &DirSource{Dir: dir}, Hooks: []Hook{hook}}
+	for range 2 {
+		results := d.Check(context.Background())
+		if len(results) != 1 || results[0].Stage != StageRenew {
+			t.Fatalf("results = %+v", results)
+		}
+	}
+	if len(hook.results) != 1 || hook.results[0].Token != "token" {
+		t.Fatalf("hook notified of %+v, want one failure", hook.results)
+	}
+}
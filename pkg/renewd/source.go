@@ -0,0 +1,139 @@
+package renewd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/wallet"
+)
+
+// WalletSource renews the tokens of a wallet file. The wallet is opened
+// again for every check and every store, so tokens added or removed with the
+// wallet commands meanwhile are respected.
+type WalletSource struct {
+	Path       string
+	Passphrase []byte
+	// ExportDir, when set, receives <name>.ptx for every renewed token
+	ExportDir string
+}
+
+// Tokens implements Source
+func (s *WalletSource) Tokens() ([]*wallet.Token, error) {
+	w, err := wallet.Open(s.Path, s.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return w.Tokens, nil
+}
+
+// Store implements Source
+func (s *WalletSource) Store(old, renewed *wallet.Token) error {
+	w, err := wallet.Open(s.Path, s.Passphrase)
+	if err != nil {
+		return err
+	}
+	current, err := w.Get(old.Name)
+	if err != nil {
+		return err
+	}
+	if current.Commitment != old.Commitment {
+		return fmt.Errorf("token %q changed in the wallet during the renewal", old.Name)
+	}
+	if err := w.Replace(renewed); err != nil {
+		return err
+	}
+	if err := w.Save(); err != nil {
+		return err
+	}
+	if s.ExportDir == "" {
+		return nil
+	}
+	return writeFile(filepath.Join(s.ExportDir, renewed.Name+".ptx"), renewed.PTX)
+}
+
+// SecretsSuffix names the file holding the nullifier and secret of a PTX
+// file in a DirSource: token.ptx has its secrets in token.secrets.json
+const SecretsSuffix = ".secrets.json"
+
+// DirSource renews the PTX files of a directory in place. The secrets of
+// <name>.ptx are read from <name>.secrets.json, a JSON object with
+// "nullifier" and "secret" strings such as the output of prove --output
+// json; files without it are reported when due but cannot be renewed.
+type DirSource struct {
+	Dir string
+}
+
+// Tokens implements Source. Tokens are named after their file.
+func (s *DirSource) Tokens() ([]*wallet.Token, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.ptx"))
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*wallet.Token
+	var errs []error
+	for _, path := range paths {
+		t, err := s.load(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, errors.Join(errs...)
+}
+
+func (s *DirSource) load(path string) (*wallet.Token, error) {
+	data, err := ptxloader.ReadFile(path, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, err
+	}
+	var secrets struct {
+		Nullifier string `json:"nullifier"`
+		Secret    string `json:"secret"`
+	}
+	raw, err := os.ReadFile(strings.TrimSuffix(path, ".ptx") + SecretsSuffix)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(raw, &secrets); err != nil {
+			return nil, fmt.Errorf("invalid secrets file: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	t, err := wallet.TokenFromPTX(data, secrets.Nullifier, secrets.Secret)
+	if err != nil {
+		return nil, err
+	}
+	t.Name = strings.TrimSuffix(filepath.Base(path), ".ptx")
+	return t, nil
+}
+
+// Store implements Source, replacing the PTX file of old
+func (s *DirSource) Store(old, renewed *wallet.Token) error {
+	return writeFile(filepath.Join(s.Dir, old.Name+".ptx"), renewed.PTX)
+}
+
+// writeFile replaces path atomically
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".renewd-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,316 @@
+// Package resilience retries calls to external dependencies (the DoH
+// resolver, the nonce store, anchor gateways) with exponential backoff and
+// jitter, and stops calling an endpoint that keeps failing with a circuit
+// breaker, so that a resolver hiccup does not fail a verification and an
+// outage does not hold every verification up for its timeouts.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBaseDelay is the backoff before the first retry
+	DefaultBaseDelay = 100 * time.Millisecond
+	// DefaultMaxDelay caps the backoff between retries
+	DefaultMaxDelay = 2 * time.Second
+	// DefaultCooldown is how long an open breaker rejects calls
+	DefaultCooldown = 30 * time.Second
+)
+
+// DefaultPolicy retries transient failures twice. Breakers are off: their
+// threshold depends on the traffic of the deployment.
+var DefaultPolicy = Policy{Retries: 2}
+
+// Policy configures retries and circuit breaking
+type Policy struct {
+	// Retries is the number of additional attempts after a transient failure
+	Retries int `json:"retries"`
+	// BaseDelay is the backoff before the first retry, doubling after each
+	// one. Defaults to DefaultBaseDelay.
+	BaseDelay time.Duration `json:"baseDelay,omitempty"`
+	// MaxDelay caps the backoff. Defaults to DefaultMaxDelay.
+	MaxDelay time.Duration `json:"maxDelay,omitempty"`
+	// FailureThreshold consecutive failed calls open the breaker of an
+	// endpoint. Zero disables breakers.
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// Cooldown is how long an open breaker rejects calls before it lets one
+	// through to probe the endpoint. Defaults to DefaultCooldown.
+	Cooldown time.Duration `json:"cooldown,omitempty"`
+}
+
+// Validate rejects negative settings
+func (p Policy) Validate() error {
+	for _, f := range []struct {
+		name  string
+		value int64
+	}{
+		{"Retries", int64(p.Retries)},
+		{"BaseDelay", int64(p.BaseDelay)},
+		{"MaxDelay", int64(p.MaxDelay)},
+		{"FailureThreshold", int64(p.FailureThreshold)},
+		{"Cooldown", int64(p.Cooldown)},
+	} {
+		if f.value < 0 {
+			return fmt.Errorf("resilience: %s must not be negative", f.name)
+		}
+	}
+	return nil
+}
+
+// Delay returns the backoff before retry n (0-based): a uniformly random
+// duration up to BaseDelay*2^n, capped at MaxDelay ("full jitter"), so that
+// verifiers failing together do not retry together
+func (p Policy) Delay(n int) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	if max <= 0 {
+		max = DefaultMaxDelay
+	}
+	d := max
+	if n < 32 && base<<n > 0 && base<<n < max {
+		d = base << n
+	}
+	return rand.N(d) + 1
+}
+
+func (p Policy) cooldown() time.Duration {
+	if p.Cooldown > 0 {
+		return p.Cooldown
+	}
+	return DefaultCooldown
+}
+
+// ErrOpen is returned without calling the endpoint while its breaker is open
+var ErrOpen = errors.New("circuit breaker open")
+
+// permanent marks an error that retrying cannot fix
+type permanent struct{ err error }
+
+func (p permanent) Error() string { return p.err.Error() }
+func (p permanent) Unwrap() error { return p.err }
+
+// Permanent marks err as not worth retrying, e.g. an HTTP 404. The endpoint
+// answered, so it does not count against its breaker either. Do returns err
+// unwrapped.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanent{err}
+}
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// Closed breakers let calls through
+	Closed State = iota
+	// Open breakers reject calls until their cooldown has passed
+	Open
+	// HalfOpen breakers let one call through: its success closes the
+	// breaker, its failure opens it again
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// MarshalText encodes s as its name
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Stats are the counters of one endpoint
+type Stats struct {
+	Endpoint string `json:"endpoint"`
+	State    State  `json:"state"`
+	// ConsecutiveFailures since the last success
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// Calls counts attempts, including retries
+	Calls    uint64 `json:"calls"`
+	Failures uint64 `json:"failures"`
+	Retries  uint64 `json:"retries"`
+	// Rejected counts calls refused by the open breaker
+	Rejected uint64 `json:"rejected"`
+	// Opened counts how often the breaker opened
+	Opened uint64 `json:"opened"`
+}
+
+// breaker is the state of one endpoint
+type breaker struct {
+	stats    Stats
+	openedAt time.Time
+	// probing is set while the one call of a half-open breaker runs
+	probing bool
+}
+
+// Breakers holds the breakers and counters of the endpoints called through
+// it. It is safe for concurrent use; verifications sharing it share the
+// breakers.
+type Breakers struct {
+	mu sync.Mutex
+	m  map[string]*breaker
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewBreakers returns an empty set of breakers
+func NewBreakers() *Breakers {
+	return &Breakers{m: map[string]*breaker{}, now: time.Now}
+}
+
+var defaultBreakers = NewBreakers()
+
+// DefaultBreakers returns the process-wide breakers
+func DefaultBreakers() *Breakers {
+	return defaultBreakers
+}
+
+// Stats returns the counters of every endpoint called so far, sorted by
+// endpoint
+func (b *Breakers) Stats() []Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]Stats, 0, len(b.m))
+	for _, br := range b.m {
+		out = append(out, br.stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Endpoint < out[j].Endpoint })
+	return out
+}
+
+// State returns the breaker state of endpoint
+func (b *Breakers) State(endpoint string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if br, ok := b.m[endpoint]; ok {
+		return br.stats.State
+	}
+	return Closed
+}
+
+func (b *Breakers) get(endpoint string) *breaker {
+	br, ok := b.m[endpoint]
+	if !ok {
+		br = &breaker{stats: Stats{Endpoint: endpoint}}
+		b.m[endpoint] = br
+	}
+	return br
+}
+
+// acquire reports whether a call to endpoint may proceed
+func (b *Breakers) acquire(p Policy, endpoint string, retry bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br := b.get(endpoint)
+	if p.FailureThreshold > 0 {
+		switch br.stats.State {
+		case Open:
+			if b.now().Sub(br.openedAt) < p.cooldown() {
+				br.stats.Rejected++
+				return false
+			}
+			br.stats.State = HalfOpen
+		case HalfOpen:
+			if br.probing {
+				br.stats.Rejected++
+				return false
+			}
+		}
+		br.probing = br.stats.State == HalfOpen
+	}
+	br.stats.Calls++
+	if retry {
+		br.stats.Retries++
+	}
+	return true
+}
+
+// release records the outcome of a call acquired for endpoint
+func (b *Breakers) release(p Policy, endpoint string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br := b.get(endpoint)
+	br.probing = false
+	if !failed {
+		br.stats.ConsecutiveFailures = 0
+		br.stats.State = Closed
+		return
+	}
+	br.stats.Failures++
+	br.stats.ConsecutiveFailures++
+	if p.FailureThreshold > 0 && (br.stats.State == HalfOpen || br.stats.ConsecutiveFailures >= p.FailureThreshold) {
+		if br.stats.State != Open {
+			br.stats.Opened++
+		}
+		br.stats.State = Open
+		br.openedAt = b.now()
+	}
+}
+
+// abort ends a call acquired for endpoint without an outcome
+func (b *Breakers) abort(endpoint string) {
+	b.mu.Lock()
+	b.get(endpoint).probing = false
+	b.mu.Unlock()
+}
+
+// Do calls fn for endpoint, retrying transient errors under p with backoff.
+// Errors marked Permanent are returned at once. While the breaker of
+// endpoint is open Do fails with an error wrapping ErrOpen without calling
+// fn. A nil b uses DefaultBreakers.
+func (b *Breakers) Do(ctx context.Context, p Policy, endpoint string, fn func(context.Context) error) error {
+	if b == nil {
+		b = defaultBreakers
+	}
+	var err error
+	for attempt := 0; attempt == 0 || attempt <= p.Retries; attempt++ {
+		if attempt > 0 {
+			t := time.NewTimer(p.Delay(attempt - 1))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return err
+			case <-t.C:
+			}
+		}
+		if !b.acquire(p, endpoint, attempt > 0) {
+			return fmt.Errorf("%s: %w", endpoint, ErrOpen)
+		}
+		err = fn(ctx)
+		var perm permanent
+		switch {
+		case err == nil:
+			b.release(p, endpoint, false)
+			return nil
+		case errors.As(err, &perm):
+			b.release(p, endpoint, false)
+			return perm.err
+		case ctx.Err() != nil:
+			// Our deadline or cancellation says nothing about the endpoint
+			b.abort(endpoint)
+			return err
+		}
+		b.release(p, endpoint, true)
+	}
+	return err
+}
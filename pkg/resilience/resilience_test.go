@@ -0,0 +1,99 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("connection reset")
+
+func TestDoRetries(t *testing.T) {
+	b := NewBreakers()
+	p := Policy{Retries: 2, BaseDelay: time.Millisecond}
+
+	calls := 0
+	err := b.Do(context.Background(), p, "dns", func(context.Context) error {
+		if calls++; calls < 3 {
+			return errFlaky
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err %v after %d calls, want success after 3", err, calls)
+	}
+
+	calls = 0
+	notFound := errors.New("HTTP 404")
+	err = b.Do(context.Background(), p, "dns", func(context.Context) error {
+		calls++
+		return Permanent(notFound)
+	})
+	if err != notFound || calls != 1 {
+		t.Errorf("permanent error: err %v after %d calls", err, calls)
+	}
+
+	st := b.Stats()[0]
+	if st.Calls != 4 || st.Failures != 2 || st.Retries != 2 || st.ConsecutiveFailures != 0 {
+		t.Errorf("stats %+v", st)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	b := NewBreakers()
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+	p := Policy{FailureThreshold: 2, Cooldown: time.Minute}
+	fail := func(context.Context) error { return errFlaky }
+	ok := func(context.Context) error { return nil }
+
+	for range 2 {
+		if err := b.Do(context.Background(), p, "redis", fail); err != errFlaky {
+			t.Fatalf("err %v", err)
+		}
+	}
+	if b.State("redis") != Open {
+		t.Fatalf("state %v after 2 failures", b.State("redis"))
+	}
+	if err := b.Do(context.Background(), p, "redis", ok); !errors.Is(err, ErrOpen) {
+		t.Fatalf("open breaker let a call through: %v", err)
+	}
+	if err := b.Do(context.Background(), p, "other", ok); err != nil {
+		t.Fatalf("breakers not per endpoint: %v", err)
+	}
+
+	// After the cooldown one probe goes through; its failure reopens
+	now = now.Add(time.Minute)
+	if err := b.Do(context.Background(), p, "redis", fail); err != errFlaky {
+		t.Fatalf("probe not let through: %v", err)
+	}
+	if err := b.Do(context.Background(), p, "redis", ok); !errors.Is(err, ErrOpen) {
+		t.Fatalf("failed probe did not reopen: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := b.Do(context.Background(), p, "redis", ok); err != nil {
+		t.Fatal(err)
+	}
+	if b.State("redis") != Closed {
+		t.Errorf("state %v after a successful probe", b.State("redis"))
+	}
+	if st := b.Stats()[1]; st.Rejected != 2 || st.Opened != 2 {
+		t.Errorf("stats %+v", st)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	p := Policy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for n, max := range []time.Duration{10, 20, 40, 50, 50, 50} {
+		max *= time.Millisecond
+		for range 100 {
+			if d := p.Delay(n); d <= 0 || d > max {
+				t.Fatalf("Delay(%d) = %v, want (0, %v]", n, d, max)
+			}
+		}
+	}
+	if d := p.Delay(100); d > p.MaxDelay {
+		t.Errorf("Delay(100) = %v overflows the cap", d)
+	}
+}
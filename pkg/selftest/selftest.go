@@ -0,0 +1,270 @@
+// Package selftest runs the full prove/verify pipeline against a deployed set
+// of keys: it issues a throwaway credential, proves it natively, writes the
+// PTX to disk and verifies it again. A passing run shows that the binary, its
+// proving key, its verification key and its circuit agree with each other.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// DefaultDomain is the domain the throwaway credential is issued for
+const DefaultDomain = "selftest.example"
+
+// Stage names, in the order they run
+const (
+	StageCredential = "credential"
+	StageCompile    = "compile"
+	StageKeys       = "keys"
+	StageProve      = "prove"
+	StageWrite      = "write_ptx"
+	StageDNS        = "dns"
+	StageVerify     = "verify"
+)
+
+// Config configures Run. Zero values select the defaults.
+type Config struct {
+	// PKPath is the native proving key. Defaults to "native.pk".
+	PKPath string
+	// VKPath is the native verification key. Defaults to "native.vk".
+	VKPath string
+	// Domain defaults to DefaultDomain
+	Domain string
+	// StubDNS answers the anchor lookup locally. Otherwise the DNS stage
+	// queries the configured DoH resolver for the anchor hostname; it passes
+	// if the resolver answers at all, since the throwaway anchor is never
+	// published.
+	StubDNS bool
+	// Resolver is used for the DNS stage when StubDNS is false. Defaults to
+	// dns.DefaultClient.
+	Resolver dns.Resolver
+	// TempDir is where the PTX is written. Defaults to os.TempDir.
+	TempDir string
+}
+
+// Stage is the outcome of one step of the self-test
+type Stage struct {
+	Name       string  `json:"name"`
+	OK         bool    `json:"ok"`
+	Skipped    bool    `json:"skipped,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+	Detail     string  `json:"detail,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of a self-test run
+type Report struct {
+	OK      bool    `json:"ok"`
+	Stages  []Stage `json:"stages"`
+	TotalMs float64 `json:"totalMs"`
+}
+
+// run holds the state threaded through the stages
+type run struct {
+	cfg     Config
+	report  *Report
+	inputs  *prover.CircuitInputs
+	meta    map[string]interface{}
+	ccs     constraint.ConstraintSystem
+	pk      groth16.ProvingKey
+	proof   []byte
+	ptxPath string
+	anchor  *utils.AnchorRecord
+}
+
+// Run executes every stage in order. A failed stage marks the remaining ones
+// as skipped. Run only returns an error if ctx is cancelled.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if cfg.PKPath == "" {
+		cfg.PKPath = "native.pk"
+	}
+	if cfg.VKPath == "" {
+		cfg.VKPath = "native.vk"
+	}
+	if cfg.Domain == "" {
+		cfg.Domain = DefaultDomain
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = dns.DefaultClient()
+	}
+
+	r := &run{cfg: cfg, report: &Report{OK: true}}
+	defer r.cleanup()
+
+	stages := []struct {
+		name string
+		fn   func(context.Context) (string, error)
+	}{
+		{StageCredential, r.credential},
+		{StageCompile, r.compile},
+		{StageKeys, r.keys},
+		{StageProve, r.prove},
+		{StageWrite, r.write},
+		{StageDNS, r.dns},
+		{StageVerify, r.verify},
+	}
+
+	start := time.Now()
+	for _, s := range stages {
+		if !r.report.OK {
+			r.report.Stages = append(r.report.Stages, Stage{Name: s.name, Skipped: true})
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		t := time.Now()
+		detail, err := s.fn(ctx)
+		stage := Stage{
+			Name:       s.name,
+			OK:         err == nil,
+			DurationMs: msSince(t),
+			Detail:     detail,
+		}
+		if err != nil {
+			stage.Error = err.Error()
+			r.report.OK = false
+		}
+		r.report.Stages = append(r.report.Stages, stage)
+	}
+	r.report.TotalMs = msSince(start)
+	return r.report, nil
+}
+
+func (r *run) credential(context.Context) (string, error) {
+	n, err := crypto.GenerateSecureRandomBigInt()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nullifier: %w", err)
+	}
+	s, err := crypto.GenerateSecureRandomBigInt()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	r.meta = map[string]interface{}{
+		"selftest":             true,
+		"expiration_timestamp": time.Now().Add(time.Hour).Unix(),
+	}
+	r.inputs, err = prover.NewProver().GenerateCircuitInputs(r.cfg.Domain, r.meta, n.String(), s.String(), 1)
+	if err != nil {
+		return "", err
+	}
+	return "domain " + r.cfg.Domain, nil
+}
+
+func (r *run) compile(context.Context) (string, error) {
+	ccs, err := prover.CompileCircuit()
+	if err != nil {
+		return "", err
+	}
+	r.ccs = ccs
+	return fmt.Sprintf("%d constraints", ccs.GetNbConstraints()), nil
+}
+
+func (r *run) keys(context.Context) (string, error) {
+	pk, err := prover.LoadProvingKey(r.cfg.PKPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(r.cfg.VKPath); err != nil {
+		return "", fmt.Errorf("failed to open vk file: %w", err)
+	}
+	r.pk = pk
+	return fmt.Sprintf("%s, %s", r.cfg.PKPath, r.cfg.VKPath), nil
+}
+
+func (r *run) prove(context.Context) (string, error) {
+	proof, err := prover.NewProver().ProveWithKey(r.ccs, r.pk, r.inputs)
+	if err != nil {
+		return "", err
+	}
+	r.proof = proof
+	return "", nil
+}
+
+func (r *run) write(context.Context) (string, error) {
+	data, err := prover.NewProver().CreatePtxFile(r.proof, r.meta, r.cfg.Domain, 1)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(r.cfg.TempDir, "jesuit-selftest-*.ptx")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	r.ptxPath = f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write PTX: %w", err)
+	}
+
+	// Read it back so the loader is part of the round trip
+	ptxFile, err := ptxloader.LoadPTX(r.ptxPath)
+	if err != nil {
+		return "", err
+	}
+	r.anchor, err = utils.DeriveAnchorRecord(r.inputs.Commitment, r.cfg.Domain, ptxFile.GetSignedMetadata(), utils.FormatHostnameVersion(ptxFile.Version()))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d bytes", len(data)), nil
+}
+
+func (r *run) dns(ctx context.Context) (string, error) {
+	if r.cfg.StubDNS {
+		return "stubbed", nil
+	}
+	l, err := r.cfg.Resolver.LookupTXT(ctx, r.anchor.Hostname)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("resolver %s answered with %d records", l.Source, len(l.Records)), nil
+}
+
+func (r *run) verify(context.Context) (string, error) {
+	// The anchor of a throwaway credential is never published, so the
+	// verifier always gets it from a static resolver. The DNS stage above
+	// covers the live resolver.
+	res, err := verifier.NewPTXVerifier(verifier.VerificationOptions{
+		FilePath:    r.ptxPath,
+		VKPath:      r.cfg.VKPath,
+		DNSResolver: dns.StaticResolver{Hostname: r.anchor.Hostname, Records: []string{r.anchor.Value}},
+	}).Verify()
+	if err != nil {
+		return "", err
+	}
+	if !res.Success {
+		if len(res.Errors) == 0 {
+			return "", errors.New("verification failed")
+		}
+		return "", fmt.Errorf("%s: %s", res.Code, res.Errors[0])
+	}
+	return fmt.Sprintf("proof checked in %.2fms", res.Timing.ZK.DurationMs()), nil
+}
+
+func (r *run) cleanup() {
+	if r.ptxPath != "" {
+		os.Remove(r.ptxPath)
+	}
+}
+
+func msSince(t time.Time) float64 {
+	return float64(time.Since(t).Microseconds()) / 1000
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+const (
+	// DefaultHealthTimeout bounds the dependency checks of a probe
+	DefaultHealthTimeout = 5 * time.Second
+	// DefaultDoHProbeHostname is looked up to check that the DoH resolver
+	// answers. Any answer, including NXDOMAIN, counts.
+	DefaultDoHProbeHostname = "example.com"
+)
+
+// Component statuses
+const (
+	StatusOK = "ok"
+	// StatusStarting is reported while the circuit is being compiled. It
+	// fails readiness but not liveness.
+	StatusStarting = "starting"
+	StatusFail     = "fail"
+)
+
+// HealthReport is the body of /healthz and /readyz
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// ComponentHealth is the outcome of one dependency check
+type ComponentHealth struct {
+	Status    string  `json:"status"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// circuitState is the background compilation started by New
+type circuitState struct {
+	done        chan struct{}
+	constraints int
+	err         error
+}
+
+func (s *Server) compileCircuit() {
+	s.circuit.constraints, s.circuit.err = verifier.CompileCircuit()
+	close(s.circuit.done)
+}
+
+// handleHealthz is the liveness probe: the verification keys load and the
+// circuit has not failed to compile. External dependencies are left to
+// /readyz so that an outage does not restart every instance.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.writeHealth(w, r, false)
+}
+
+// handleReadyz is the readiness probe: every component, including Redis and
+// the DoH resolver, must be ok
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	s.writeHealth(w, r, true)
+}
+
+func (s *Server) writeHealth(w http.ResponseWriter, r *http.Request, ready bool) {
+	timeout := s.cfg.HealthTimeout
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	snap := s.snap.Load()
+	checks := map[string]func(context.Context) (string, error){
+		"circuit": s.checkCircuit,
+		"config":  s.checkConfig,
+	}
+	// The server keys are only checked if some request can use them
+	serverKeys := len(snap.tenants) == 0
+	for id, t := range snap.tenants {
+		if t.hasKeys() {
+			checks["verification_key:"+id] = s.checkKeys(t.VKPath, t.VK, t.KeySet)
+		} else {
+			serverKeys = true
+		}
+	}
+	if serverKeys {
+		checks["verification_key"] = s.checkKeys(s.cfg.VKPath, snap.vk, snap.keySet)
+	}
+	if ready {
+		if s.cfg.RedisURL != "" {
+			checks["redis"] = s.checkRedis
+		}
+		checks["doh"] = s.checkDoH
+	}
+
+	report := HealthReport{Status: StatusOK, Components: make(map[string]ComponentHealth, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := runCheck(ctx, check)
+			mu.Lock()
+			report.Components[name] = c
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for _, c := range report.Components {
+		if c.Status == StatusFail || (ready && c.Status != StatusOK) {
+			report.Status = StatusFail
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, status, report)
+}
+
+// errStarting marks a component that is not ready yet
+var errStarting = errors.New("circuit compilation in progress")
+
+func runCheck(ctx context.Context, check func(context.Context) (string, error)) ComponentHealth {
+	start := time.Now()
+	type result struct {
+		detail string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		detail, err := check(ctx)
+		done <- result{detail, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = ctx.Err()
+	}
+
+	c := ComponentHealth{Status: StatusOK, Detail: res.detail, LatencyMs: time.Since(start).Seconds() * 1000}
+	switch {
+	case res.err == errStarting:
+		c.Status = StatusStarting
+	case res.err != nil:
+		c.Status = StatusFail
+		c.Error = res.err.Error()
+	}
+	return c
+}
+
+func (s *Server) checkCircuit(context.Context) (string, error) {
+	select {
+	case <-s.circuit.done:
+	default:
+		return "", errStarting
+	}
+	if s.circuit.err != nil {
+		return "", s.circuit.err
+	}
+	return fmt.Sprintf("%d constraints", s.circuit.constraints), nil
+}
+
+func (s *Server) checkKeys(vkPath string, vk []byte, ks *verifier.KeySet) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		opts := s.cfg.Options
+		opts.VKPath = vkPath
+		opts.VKData = vk
+		opts.KeySet = ks
+		if err := verifier.CheckKeys(opts); err != nil {
+			return "", err
+		}
+		if ks != nil {
+			return fmt.Sprintf("%d keys", len(ks.Keys)), nil
+		}
+		return "", nil
+	}
+}
+
+// checkConfig reports the reload counters. A failed reload leaves the
+// previous configuration serving, so it is not a failure of the server.
+func (s *Server) checkConfig(context.Context) (string, error) {
+	st := s.ReloadStats()
+	detail := fmt.Sprintf("loaded %s, %d reloads, %d failed", st.LoadedAt.UTC().Format(time.RFC3339), st.Reloads, st.Failures)
+	if st.LastError != "" {
+		detail += "; last reload failed: " + st.LastError
+	}
+	return detail, nil
+}
+
+func (s *Server) checkRedis(ctx context.Context) (string, error) {
+	return "", s.limiter.Ping(ctx)
+}
+
+func (s *Server) checkDoH(ctx context.Context) (string, error) {
+	resolver := s.cfg.Options.DNSResolver
+	if resolver == nil {
+		resolver = dns.DefaultClient()
+	}
+	hostname := s.cfg.DoHProbeHostname
+	if hostname == "" {
+		hostname = DefaultDoHProbeHostname
+	}
+	lookup, err := resolver.LookupTXT(ctx, hostname)
+	if err != nil {
+		return "", err
+	}
+	return lookup.Source, nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/session"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func TestIntrospect(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minter := &session.Minter{Key: key, Issuer: "https://verifier.example"}
+	s := &Server{cfg: Config{Session: minter, MaxBodySize: DefaultMaxBodySize}}
+
+	res := &verifier.VerificationResult{Success: true, Details: verifier.VerificationDetails{NullifierHash: "42"}}
+	mint := func(m *session.Minter, now time.Time) string {
+		c, err := m.NewClaims(res, "", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok, err := m.Mint(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	introspect := func(body, contentType string) (int, IntrospectResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/introspect", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		s.handleIntrospect(rec, req)
+		var out IntrospectResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, out
+	}
+	jsonToken := func(tok string) string {
+		data, _ := json.Marshal(map[string]string{"token": tok})
+		return string(data)
+	}
+
+	now := time.Now()
+	tok := mint(minter, now)
+	code, out := introspect(jsonToken(tok), "application/json")
+	if code != http.StatusOK || !out.Active || out.Claims == nil || out.Subject != "42" {
+		t.Fatalf("own token: %d %+v", code, out)
+	}
+	code, out = introspect(url.Values{"token": {tok}}.Encode(), "application/x-www-form-urlencoded")
+	if code != http.StatusOK || !out.Active {
+		t.Errorf("form: %d %+v", code, out)
+	}
+
+	for name, tok := range map[string]string{
+		"foreign issuer": mint(&session.Minter{Key: key, Issuer: "https://other.example"}, now),
+		"foreign key":    mint(&session.Minter{Key: otherKey, Issuer: minter.Issuer}, now),
+		"expired":        mint(minter, now.Add(-time.Hour)),
+		"garbage":        "a.b.c",
+	} {
+		code, out := introspect(jsonToken(tok), "application/json")
+		if code != http.StatusOK || out.Active || out.Claims != nil {
+			t.Errorf("%s: %d %+v", name, code, out)
+		}
+	}
+
+	if code, _ := introspect(`{}`, "application/json"); code != http.StatusBadRequest {
+		t.Errorf("missing token: %d", code)
+	}
+}
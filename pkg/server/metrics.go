@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/resilience"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// stageLatency sums the time spent in each verification stage, see
+// verifier.Timing
+type stageLatency struct {
+	mu     sync.Mutex
+	names  []string
+	sum    map[string]float64
+	counts map[string]uint64
+}
+
+// observe adds the stages of t, Total included
+func (l *stageLatency) observe(t *verifier.Timing) {
+	if t == nil {
+		return
+	}
+	stages := append(t.Stages(), verifier.NamedStage{Name: "total", Stage: t.Total})
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sum == nil {
+		l.sum = make(map[string]float64)
+		l.counts = make(map[string]uint64)
+	}
+	for _, s := range stages {
+		if _, ok := l.counts[s.Name]; !ok {
+			l.names = append(l.names, s.Name)
+		}
+		l.sum[s.Name] += s.DurationMs() / 1000
+		l.counts[s.Name]++
+	}
+}
+
+// write appends the latencies as a Prometheus summary without quantiles
+func (l *stageLatency) write(b *strings.Builder) {
+	const name = "ptx_verify_stage_seconds"
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(b, "# HELP %s Time spent in each verification stage.\n# TYPE %s summary\n", name, name)
+	for _, stage := range l.names {
+		fmt.Fprintf(b, "%s_sum{stage=%s} %s\n", name, strconv.Quote(stage), strconv.FormatFloat(l.sum[stage], 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{stage=%s} %d\n", name, strconv.Quote(stage), l.counts[stage])
+	}
+}
+
+// handleMetrics exposes the state of the external dependencies in the
+// Prometheus text format: per endpoint, the circuit breaker state and the
+// call, failure, retry and rejection counters, the time spent in each
+// verification stage, and the state of the verification queue
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	breakers := s.cfg.Options.Breakers
+	if breakers == nil {
+		breakers = resilience.DefaultBreakers()
+	}
+	stats := breakers.Stats()
+
+	var b strings.Builder
+	metric := func(name, kind, help string, value func(resilience.Stats) string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, st := range stats {
+			fmt.Fprintf(&b, "%s{endpoint=%s} %s\n", name, strconv.Quote(st.Endpoint), value(st))
+		}
+	}
+	count := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	metric("ptx_breaker_state", "gauge", "Circuit breaker state (0 closed, 1 open, 2 half-open).",
+		func(st resilience.Stats) string { return strconv.Itoa(int(st.State)) })
+	metric("ptx_dependency_consecutive_failures", "gauge", "Failed calls since the last success.",
+		func(st resilience.Stats) string { return strconv.Itoa(st.ConsecutiveFailures) })
+	metric("ptx_dependency_calls_total", "counter", "Calls, including retries.",
+		func(st resilience.Stats) string { return count(st.Calls) })
+	metric("ptx_dependency_failures_total", "counter", "Failed calls.",
+		func(st resilience.Stats) string { return count(st.Failures) })
+	metric("ptx_dependency_retries_total", "counter", "Retries after a transient failure.",
+		func(st resilience.Stats) string { return count(st.Retries) })
+	metric("ptx_breaker_rejected_total", "counter", "Calls refused by an open breaker.",
+		func(st resilience.Stats) string { return count(st.Rejected) })
+	metric("ptx_breaker_opened_total", "counter", "Times the breaker opened.",
+		func(st resilience.Stats) string { return count(st.Opened) })
+
+	s.stages.write(&b)
+	s.queue.write(&b)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
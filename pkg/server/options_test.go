@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/nonce"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func TestOptionsIgnoreSecuritySettings(t *testing.T) {
+	s := &Server{cfg: Config{VKPath: "server.vk", NonceDB: "nonce.db"}}
+	req := &VerifyRequest{PTX: []byte("ptx"), BytesOptions: verifier.BytesOptions{
+		DNSMatch:         "prefix",
+		LegacySignalScan: true,
+		TXTRecords:       []string{"forged"},
+		StrictMode:       true,
+		IntendedScope:    []string{"orders:read"},
+	}}
+	opts, err := s.options(req, &snapshot{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.DNSMatchMode != dns.MatchExact || opts.LegacySignalScan || opts.DNSResolver != nil {
+		t.Errorf("request loosened the server configuration: %+v", opts)
+	}
+	if opts.VKPath != "server.vk" || opts.NonceDB != "nonce.db" {
+		t.Errorf("keys or nonce store not from the server: %+v", opts)
+	}
+	if !opts.StrictMode || len(opts.IntendedScope) != 1 {
+		t.Errorf("request settings not applied: %+v", opts)
+	}
+
+	// The server configuration applies whatever the request says
+	s.cfg.Options.DNSMatchMode = dns.MatchPrefix
+	if opts, _ := s.options(&VerifyRequest{PTX: []byte("ptx")}, &snapshot{}, nil); opts.DNSMatchMode != dns.MatchPrefix {
+		t.Errorf("DNSMatchMode = %v, want the server's", opts.DNSMatchMode)
+	}
+}
+
+func TestOptionsShareNonceStore(t *testing.T) {
+	st, err := nonce.NewNonceStore("redis://127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	s := &Server{cfg: Config{RedisURL: "redis://127.0.0.1:1"}, nonces: st}
+	req := &VerifyRequest{PTX: []byte("ptx")}
+
+	for _, tt := range []struct {
+		tenant *Tenant
+		prefix string
+	}{
+		{prefix: ""},
+		{tenant: &Tenant{ID: "acme"}, prefix: "acme:"},
+		{tenant: &Tenant{ID: "acme", NoncePrefix: "a/"}, prefix: "a/"},
+	} {
+		opts, err := s.options(req, &snapshot{}, tt.tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shared, ok := opts.NonceStore.(*nonce.NonceStore)
+		if !ok || opts.RedisURL != "" || shared.Prefix != tt.prefix {
+			t.Errorf("tenant %v: NonceStore %#v, RedisURL %q, want the shared store with prefix %q", tt.tenant, opts.NonceStore, opts.RedisURL, tt.prefix)
+		}
+	}
+}
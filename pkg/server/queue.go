@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQueueDepth is how many verifications may wait for a worker by
+// default
+const DefaultQueueDepth = 64
+
+// errQueueFull is returned by acquire when QueueDepth requests are already
+// waiting
+var errQueueFull = errors.New("verification queue is full")
+
+// workQueue bounds the verifications running at once, each of which compiles
+// and solves a witness, and the requests waiting for one of them. Requests
+// arriving at a full queue are refused rather than buffered, so a burst
+// cannot exhaust memory.
+type workQueue struct {
+	slots chan struct{}
+	depth int
+
+	mu       sync.Mutex
+	waiting  int
+	waitSum  float64
+	waits    uint64
+	rejected map[string]uint64
+}
+
+func newWorkQueue(workers, depth int) *workQueue {
+	return &workQueue{
+		slots:    make(chan struct{}, workers),
+		depth:    depth,
+		rejected: map[string]uint64{},
+	}
+}
+
+// acquire waits for a worker until ctx is done, and returns the function
+// releasing it. It fails at once with errQueueFull when the queue is full,
+// and with the error of ctx when its deadline passes first.
+func (q *workQueue) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	select {
+	case q.slots <- struct{}{}:
+		q.observe(start)
+		return q.release, nil
+	default:
+	}
+
+	q.mu.Lock()
+	if q.waiting >= q.depth {
+		q.rejected["full"]++
+		q.mu.Unlock()
+		return nil, errQueueFull
+	}
+	q.waiting++
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		q.waiting--
+		q.mu.Unlock()
+	}()
+	select {
+	case q.slots <- struct{}{}:
+		q.observe(start)
+		return q.release, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		q.rejected["deadline"]++
+		q.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (q *workQueue) release() {
+	<-q.slots
+}
+
+// observe records the time a request waited for its worker
+func (q *workQueue) observe(start time.Time) {
+	q.mu.Lock()
+	q.waitSum += time.Since(start).Seconds()
+	q.waits++
+	q.mu.Unlock()
+}
+
+// write appends the queue metrics in the Prometheus text format
+func (q *workQueue) write(b *strings.Builder) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	gauge := func(name, help string, v int) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, v)
+	}
+	gauge("ptx_queue_workers", "Verifications allowed to run at once.", cap(q.slots))
+	gauge("ptx_queue_running", "Verifications running.", len(q.slots))
+	gauge("ptx_queue_capacity", "Requests allowed to wait for a worker.", q.depth)
+	gauge("ptx_queue_depth", "Requests waiting for a worker.", q.waiting)
+
+	const wait = "ptx_queue_wait_seconds"
+	fmt.Fprintf(b, "# HELP %s Time requests waited for a worker.\n# TYPE %s summary\n", wait, wait)
+	fmt.Fprintf(b, "%s_sum %s\n%s_count %d\n", wait, strconv.FormatFloat(q.waitSum, 'g', -1, 64), wait, q.waits)
+
+	const rejected = "ptx_queue_rejected_total"
+	fmt.Fprintf(b, "# HELP %s Requests refused because the queue was full or their deadline passed while waiting.\n# TYPE %s counter\n", rejected, rejected)
+	for _, reason := range []string{"full", "deadline"} {
+		fmt.Fprintf(b, "%s{reason=%s} %d\n", rejected, strconv.Quote(reason), q.rejected[reason])
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// snapshot is the reloadable part of the configuration: keys, policy and
+// tenants. Requests read it through an atomic pointer, so a reload swaps all
+// of it at once and never exposes a half-updated key registry.
+type snapshot struct {
+	// vk is the contents of Config.VKPath. It is nil when a key set is used
+	// or the file does not exist yet, in which case the verifier falls back
+	// to VKPath.
+	vk       []byte
+	keySet   *verifier.KeySet
+	policy   verifier.PolicyFunc
+	tenants  map[string]*Tenant
+	loadedAt time.Time
+
+	// stamps records the watched files as they were when loaded
+	mu     sync.Mutex
+	stamps map[string]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// ReloadStats counts configuration reloads since the server started
+type ReloadStats struct {
+	Reloads    uint64    `json:"reloads"`
+	Failures   uint64    `json:"failures"`
+	LoadedAt   time.Time `json:"loadedAt"`
+	LastError  string    `json:"lastError,omitempty"`
+	LastFailed time.Time `json:"lastFailed,omitempty"`
+}
+
+// load reads the key, key set, policy and tenant files of the configuration
+// and checks that every key parses
+func (s *Server) load() (*snapshot, error) {
+	snap := &snapshot{policy: s.cfg.PolicyFunc, loadedAt: time.Now()}
+	var files []string
+
+	snap.keySet = s.cfg.KeySet
+	if s.cfg.KeySetPath != "" {
+		ks, err := verifier.LoadKeySet(s.cfg.KeySetPath)
+		if err == nil {
+			err = ks.ReadKeys()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key set: %w", err)
+		}
+		snap.keySet = ks
+		files = append(files, s.cfg.KeySetPath)
+	}
+	if snap.keySet != nil {
+		if err := verifier.CheckKeys(verifier.VerificationOptions{KeySet: snap.keySet}); err != nil {
+			return nil, err
+		}
+		for _, k := range snap.keySet.Keys {
+			files = append(files, nonEmpty(k.Path)...)
+		}
+	} else if s.cfg.VKPath != "" {
+		data, err := os.ReadFile(s.cfg.VKPath)
+		switch {
+		case err == nil:
+			if err := verifier.CheckKeys(verifier.VerificationOptions{VKData: data}); err != nil {
+				return nil, err
+			}
+			snap.vk = data
+		case !errors.Is(err, os.ErrNotExist) || s.snap.Load() != nil:
+			// A key that disappears on reload is an error, not a request
+			// for setup
+			return nil, fmt.Errorf("failed to read verification key: %w", err)
+		}
+		files = append(files, s.cfg.VKPath)
+	}
+
+	if s.cfg.PolicyPath != "" {
+		policy, err := verifier.LoadPolicy(s.cfg.PolicyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load policy: %w", err)
+		}
+		snap.policy = verifier.AllPolicies(s.cfg.PolicyFunc, policy.Func())
+		files = append(files, s.cfg.PolicyPath)
+	}
+
+	tenants := s.cfg.Tenants
+	if s.cfg.TenantsPath != "" {
+		var err error
+		if tenants, err = LoadTenants(s.cfg.TenantsPath); err != nil {
+			return nil, fmt.Errorf("failed to load tenants: %w", err)
+		}
+		files = append(files, s.cfg.TenantsPath)
+	}
+	index, err := indexTenants(tenants)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tenants {
+		if t.hasKeys() {
+			opts := verifier.VerificationOptions{VKPath: t.VKPath, VKData: t.VK, KeySet: t.KeySet}
+			if err := verifier.CheckKeys(opts); err != nil {
+				return nil, fmt.Errorf("tenant %q: %w", t.ID, err)
+			}
+		}
+		files = append(files, t.files()...)
+	}
+	snap.tenants = index
+
+	snap.stamps = make(map[string]fileStamp, len(files))
+	for _, f := range files {
+		snap.stamps[f] = stampOf(f)
+	}
+	return snap, nil
+}
+
+// Reload reads the configured files again and swaps them in atomically. On
+// error the server keeps running with the previous configuration. Requests in
+// flight finish with the configuration they started with.
+func (s *Server) Reload() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	snap, err := s.load()
+	if err != nil {
+		s.reloads.Failures++
+		s.reloads.LastError = err.Error()
+		s.reloads.LastFailed = time.Now()
+		return err
+	}
+	s.snap.Store(snap)
+	s.reloads.Reloads++
+	s.reloads.LoadedAt = snap.loadedAt
+	s.reloads.LastError = ""
+	return nil
+}
+
+// ReloadStats returns the reload counters
+func (s *Server) ReloadStats() ReloadStats {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	return s.reloads
+}
+
+// Watch reloads the configuration whenever one of its files changes, checking
+// every interval until ctx is done. onReload, if set, is called with the
+// outcome of every reload.
+func (s *Server) Watch(ctx context.Context, interval time.Duration, onReload func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !s.snap.Load().changed() {
+			continue
+		}
+		err := s.Reload()
+		if onReload != nil {
+			onReload(err)
+		}
+		if err != nil {
+			// Do not retry a broken file until it changes again
+			s.snap.Load().restamp()
+		}
+	}
+}
+
+// changed reports whether a watched file was modified since it was loaded
+func (snap *snapshot) changed() bool {
+	snap.mu.Lock()
+	defer snap.mu.Unlock()
+	for f, st := range snap.stamps {
+		if stampOf(f) != st {
+			return true
+		}
+	}
+	return false
+}
+
+// restamp records the current state of the watched files
+func (snap *snapshot) restamp() {
+	snap.mu.Lock()
+	defer snap.mu.Unlock()
+	for f := range snap.stamps {
+		snap.stamps[f] = stampOf(f)
+	}
+}
+
+// stampOf returns the modification time and size of a file, or the zero stamp
+// if it cannot be read
+func stampOf(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
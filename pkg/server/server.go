@@ -0,0 +1,595 @@
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/challenge"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/nonce"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ratelimit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/session"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// DefaultMaxBodySize bounds a request body. JSON requests carry the PTX in
+// base64 so they may be larger than the PTX size limit.
+const DefaultMaxBodySize = 2 * ptxloader.DefaultMaxFileSize
+
+// Error codes returned in ErrorBody. Rejections by the verifier itself are
+// reported in the VerificationResult instead.
+const (
+	CodeBadRequest  = "bad_request"
+	CodeRateLimited = "rate_limited"
+	CodeInternal    = "internal_error"
+	// CodeOverloaded refuses a request arriving at a full verification
+	// queue (429)
+	CodeOverloaded = "server_overloaded"
+	// CodeRequestTimeout refuses a request whose RequestTimeout passed
+	// while it waited for a worker (503)
+	CodeRequestTimeout = "request_timeout"
+)
+
+// Config configures a Server. The files named by VKPath, KeySetPath,
+// PolicyPath and TenantsPath are read by New and again by Reload.
+type Config struct {
+	// VKPath is the native verification key. Ignored when KeySet is set.
+	VKPath string
+	KeySet *verifier.KeySet
+	// KeySetPath is a key set file, used instead of KeySet
+	KeySetPath string
+	// PolicyPath is a Policy file applied together with PolicyFunc
+	PolicyPath string
+	// RedisURL enables nonce replay protection and shares rate limit
+	// buckets across instances. Without it buckets are kept in memory.
+	RedisURL string
+	// NonceDB is an embedded nonce database file, used for nonces instead
+	// of Redis by single-instance servers
+	NonceDB string
+	// NullifierLimit bounds presentations of the same nullifier hash
+	NullifierLimit ratelimit.Limit
+	// DomainLimit bounds presentations of tokens for the same domain
+	DomainLimit ratelimit.Limit
+	// MaxBodySize defaults to DefaultMaxBodySize
+	MaxBodySize int64
+	PolicyFunc  verifier.PolicyFunc
+	Events      *events.Emitter
+	// Options is the base configuration every verification starts from
+	Options verifier.VerificationOptions
+	// Tenants makes the server multi-tenant. Every request must then name
+	// one of them, and is verified with its keys, defaults, nonce namespace
+	// and policy. Rate limit buckets are kept per tenant.
+	Tenants []*Tenant
+	// TenantsPath is a tenants file (see LoadTenants), used instead of
+	// Tenants
+	TenantsPath string
+	// TenantHeader defaults to DefaultTenantHeader
+	TenantHeader string
+	// HealthTimeout bounds the checks of /healthz and /readyz. Defaults to
+	// DefaultHealthTimeout.
+	HealthTimeout time.Duration
+	// DoHProbeHostname is looked up by /readyz. Defaults to
+	// DefaultDoHProbeHostname.
+	DoHProbeHostname string
+	// ChallengeTTL enables challenge-response mode: POST /v1/challenge
+	// issues challenges valid for this long, and every PTX must answer one
+	// with its metadata nonce. Challenges are kept in Redis with RedisURL,
+	// in memory otherwise.
+	ChallengeTTL time.Duration
+	// Session mints a session token for every accepted PTX and enables
+	// POST /v1/introspect, so that downstream services need not verify
+	// the proof again on every request
+	Session *session.Minter
+	// Workers bounds the verifications running at once. Defaults to
+	// GOMAXPROCS.
+	Workers int
+	// QueueDepth bounds the requests waiting for a worker, further ones
+	// are refused with CodeOverloaded. Defaults to DefaultQueueDepth.
+	QueueDepth int
+	// RequestTimeout bounds the time from the arrival of a request to its
+	// result, queueing included. The verification gets what is left of it
+	// as its MaxDuration. Zero means no deadline.
+	RequestTimeout time.Duration
+}
+
+// Server verifies PTX files over HTTP
+type Server struct {
+	cfg     Config
+	limiter ratelimit.Limiter
+	mux     *http.ServeMux
+	snap    atomic.Pointer[snapshot]
+	circuit circuitState
+	// challenges is set in challenge-response mode
+	challenges challenge.Store
+	// nonces is the Redis nonce store shared by all verifications, set
+	// with RedisURL
+	nonces *nonce.NonceStore
+
+	reloadMu sync.Mutex
+	reloads  ReloadStats
+
+	// stages accumulates the stage latencies of verifications
+	stages stageLatency
+	queue  *workQueue
+}
+
+// New builds a Server. Close releases the rate limiter connection.
+func New(cfg Config) (*Server, error) {
+	for _, l := range []ratelimit.Limit{cfg.NullifierLimit, cfg.DomainLimit} {
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+	if cfg.TenantHeader == "" {
+		cfg.TenantHeader = DefaultTenantHeader
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	if cfg.QueueDepth <= 0 {
+		cfg.QueueDepth = DefaultQueueDepth
+	}
+	s := &Server{cfg: cfg, mux: http.NewServeMux(), queue: newWorkQueue(cfg.Workers, cfg.QueueDepth)}
+	snap, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.snap.Store(snap)
+	s.reloads.LoadedAt = snap.loadedAt
+
+	s.limiter = ratelimit.NewMemory()
+	if cfg.RedisURL != "" {
+		s.limiter, err = ratelimit.NewRedis(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure rate limiter: %w", err)
+		}
+	}
+
+	if cfg.RedisURL != "" {
+		s.nonces, err = nonce.NewNonceStore(cfg.RedisURL)
+		if err != nil {
+			s.limiter.Close()
+			return nil, fmt.Errorf("failed to configure nonce store: %w", err)
+		}
+	}
+
+	if cfg.ChallengeTTL > 0 {
+		s.challenges = challenge.NewMemory()
+		if cfg.RedisURL != "" {
+			s.challenges, err = challenge.NewRedis(cfg.RedisURL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to configure challenge store: %w", err)
+			}
+		}
+		s.mux.HandleFunc("POST /v1/challenge", s.handleChallenge)
+		s.mux.HandleFunc("POST /v1/tenants/{tenant}/challenge", s.handleChallenge)
+	}
+	if cfg.Session != nil {
+		s.mux.HandleFunc("POST /v1/introspect", s.handleIntrospect)
+	}
+
+	s.circuit.done = make(chan struct{})
+	go s.compileCircuit()
+
+	s.mux.HandleFunc("POST /v1/verify", s.handleVerify)
+	s.mux.HandleFunc("POST /v1/tenants/{tenant}/verify", s.handleVerify)
+	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
+	s.mux.HandleFunc("GET /readyz", s.handleReadyz)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close releases resources held by the server
+func (s *Server) Close() error {
+	if s.challenges != nil {
+		s.challenges.Close()
+	}
+	if s.nonces != nil {
+		s.nonces.Close()
+	}
+	return s.limiter.Close()
+}
+
+// VerifyRequest is the JSON form of a verification request. Requests with an
+// application/octet-stream body carry the raw PTX and use default options.
+type VerifyRequest struct {
+	// PTX is the PTX file (base64 in JSON)
+	PTX []byte `json:"ptx"`
+	verifier.BytesOptions
+}
+
+// ErrorBody is returned with every non-200 response
+type ErrorBody struct {
+	Error Error `json:"error"`
+}
+
+// Error describes a request that was not verified
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Scope names the exhausted limit ("nullifier" or "domain") when Code
+	// is CodeRateLimited
+	Scope        string `json:"scope,omitempty"`
+	RetryAfterMs int64  `json:"retryAfterMs,omitempty"`
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	snap := s.snap.Load()
+	tenant, status, e := s.tenantOf(r, snap)
+	if status != 0 {
+		writeError(w, status, e)
+		return
+	}
+
+	req, err := s.decodeRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: err.Error()})
+		return
+	}
+
+	opts, err := s.options(req, snap, tenant)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: err.Error()})
+		return
+	}
+	opts.Caller = callerOf(r)
+
+	sub, err := subjectOf(req.PTX)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: err.Error()})
+		return
+	}
+
+	if e, ok := s.checkLimits(r.Context(), sub, tenant); !ok {
+		s.emitRefused(start, sub, opts.Caller, CodeRateLimited, e.Message)
+		status := http.StatusTooManyRequests
+		if e.Code == CodeInternal {
+			status = http.StatusServiceUnavailable
+		} else {
+			w.Header().Set("Retry-After", strconv.FormatInt((e.RetryAfterMs+999)/1000, 10))
+		}
+		writeError(w, status, e)
+		return
+	}
+
+	ctx := r.Context()
+	if s.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, start.Add(s.cfg.RequestTimeout))
+		defer cancel()
+	}
+	release, err := s.queue.acquire(ctx)
+	if err != nil {
+		e, status := Error{Code: CodeOverloaded, Message: "too many verifications in progress, retry later"}, http.StatusTooManyRequests
+		if !errors.Is(err, errQueueFull) {
+			e, status = Error{Code: CodeRequestTimeout, Message: "request deadline passed while waiting for a worker"}, http.StatusServiceUnavailable
+		}
+		s.emitRefused(start, sub, opts.Caller, e.Code, e.Message)
+		w.Header().Set("Retry-After", "1")
+		writeError(w, status, e)
+		return
+	}
+	defer release()
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); opts.MaxDuration <= 0 || left < opts.MaxDuration {
+			opts.MaxDuration = max(left, time.Millisecond)
+		}
+	}
+
+	res, err := verifier.NewPTXVerifier(opts).Verify()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, Error{Code: string(verifier.CodeLoadFailed), Message: err.Error()})
+		return
+	}
+	s.stages.observe(res.Timing)
+	if !res.Success || s.cfg.Session == nil {
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	tok, err := s.mintSession(res, tenant)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, Error{Code: CodeInternal, Message: "failed to mint session token: " + err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, VerifyResponse{VerificationResult: res, Session: tok})
+}
+
+// emitRefused reports a request refused before verification
+func (s *Server) emitRefused(start time.Time, sub subject, caller, code, message string) {
+	if s.cfg.Events == nil {
+		return
+	}
+	s.cfg.Events.Emit(events.Event{
+		Time:          start,
+		Code:          code,
+		Errors:        []string{message},
+		Domain:        sub.Domain,
+		NullifierHash: sub.NullifierHash,
+		LatencyMs:     time.Since(start).Seconds() * 1000,
+		PTXHash:       sub.PTXHash,
+		Caller:        caller,
+	})
+}
+
+// Warmup loads the verification keys of the server and of its tenants,
+// compiles their circuits and opens the connection to the DoH resolver, see
+// verifier.Warmup. serve calls it before accepting requests.
+func (s *Server) Warmup(ctx context.Context) error {
+	snap := s.snap.Load()
+	opts := s.cfg.Options
+	opts.VKPath = s.cfg.VKPath
+	opts.VKData = snap.vk
+	opts.KeySet = snap.keySet
+	errs := []error{verifier.Warmup(ctx, opts)}
+	for _, t := range snap.tenants {
+		if !t.hasKeys() {
+			continue
+		}
+		topts := verifier.VerificationOptions{VKPath: t.VKPath, VKData: t.VK, KeySet: t.KeySet, DNSResolver: opts.DNSResolver}
+		if err := verifier.Warmup(ctx, topts); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", t.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyResponse is the verification result with the session token minted
+// for an accepted PTX, when sessions are enabled
+type VerifyResponse struct {
+	*verifier.VerificationResult
+	Session *SessionToken `json:"session,omitempty"`
+}
+
+// SessionToken is a session JWT (see package session)
+type SessionToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (s *Server) mintSession(res *verifier.VerificationResult, tenant *Tenant) (*SessionToken, error) {
+	id := ""
+	if tenant != nil {
+		id = tenant.ID
+	}
+	claims, err := s.cfg.Session.NewClaims(res, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	tok, err := s.cfg.Session.Mint(claims)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionToken{Token: tok, ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC()}, nil
+}
+
+// IntrospectResponse is returned by POST /v1/introspect, after RFC 7662:
+// Active is false for tokens that are invalid or expired, and the claims are
+// only set for active tokens
+type IntrospectResponse struct {
+	Active bool `json:"active"`
+	*session.Claims
+}
+
+// handleIntrospect checks a session token minted by this server. The token
+// is read from the "token" form field or a JSON body {"token": "..."}.
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, s.cfg.MaxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: "failed to read request: " + err.Error()})
+		return
+	}
+	var req struct {
+		Token string `json:"token"`
+	}
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: "invalid form: " + err.Error()})
+			return
+		}
+		req.Token = form.Get("token")
+	} else if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: "invalid request JSON: " + err.Error()})
+		return
+	}
+	if req.Token == "" {
+		writeError(w, http.StatusBadRequest, Error{Code: CodeBadRequest, Message: "token is required"})
+		return
+	}
+
+	pub := s.cfg.Session.Key.Public().(ed25519.PublicKey)
+	claims, err := session.Verify(req.Token, pub, time.Now())
+	if err != nil || claims.Issuer != s.cfg.Session.Issuer {
+		writeJSON(w, http.StatusOK, IntrospectResponse{})
+		return
+	}
+	writeJSON(w, http.StatusOK, IntrospectResponse{Active: true, Claims: claims})
+}
+
+// ChallengeResponse is returned by POST /v1/challenge. The holder proves a
+// fresh PTX with Challenge as its metadata nonce before ExpiresAt.
+type ChallengeResponse struct {
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	tenant, status, e := s.tenantOf(r, s.snap.Load())
+	if status != 0 {
+		writeError(w, status, e)
+		return
+	}
+	expiresAt := time.Now().Add(s.cfg.ChallengeTTL)
+	c, err := s.challengesOf(tenant).Issue(r.Context(), s.cfg.ChallengeTTL)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, Error{Code: CodeInternal, Message: "failed to issue challenge: " + err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, ChallengeResponse{Challenge: c, ExpiresAt: expiresAt})
+}
+
+// challengesOf returns the challenges of a tenant, so that a challenge is
+// only answered where it was issued
+func (s *Server) challengesOf(tenant *Tenant) challenge.Scoped {
+	scope := ""
+	if tenant != nil {
+		scope = "tenant:" + tenant.ID + ":"
+	}
+	return challenge.Scoped{Store: s.challenges, Scope: scope}
+}
+
+// decodeRequest reads a JSON or raw PTX body
+func (s *Server) decodeRequest(r *http.Request) (*VerifyRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, s.cfg.MaxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct == "application/octet-stream" {
+		return &VerifyRequest{PTX: body}, nil
+	}
+
+	var req VerifyRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid request JSON: %w", err)
+	}
+	if len(req.PTX) == 0 {
+		return nil, errors.New("ptx is required")
+	}
+	return &req, nil
+}
+
+// options merges the request options into the server configuration and the
+// tenant's, if any. Settings that affect security (keys, nonce store, DNS
+// resolution and matching, signal layout) cannot be overridden by the
+// caller, so TXTRecords, DNSMatchMode and LegacySignalScan are ignored and a
+// request policy can only add to the server and tenant policies.
+func (s *Server) options(req *VerifyRequest, snap *snapshot, tenant *Tenant) (verifier.VerificationOptions, error) {
+	ro, err := req.BytesOptions.VerificationOptions()
+	if err != nil {
+		return verifier.VerificationOptions{}, err
+	}
+
+	opts := s.cfg.Options
+	opts.PTXData = req.PTX
+	opts.IntendedScope = ro.IntendedScope
+	opts.IntendedAudience = ro.IntendedAudience
+	opts.StrictMode = opts.StrictMode || ro.StrictMode
+	opts.Evidence = ro.Evidence
+	// A request may tighten the server's budget, never extend it
+	if ro.MaxDuration > 0 && (opts.MaxDuration <= 0 || ro.MaxDuration < opts.MaxDuration) {
+		opts.MaxDuration = ro.MaxDuration
+	}
+	opts.VKPath = s.cfg.VKPath
+	opts.VKData = snap.vk
+	opts.KeySet = snap.keySet
+	// The embedded database is opened once per process by the verifier
+	opts.NonceDB = s.cfg.NonceDB
+	opts.Events = s.cfg.Events
+	opts.PolicyFunc = verifier.AllPolicies(snap.policy, ro.PolicyFunc)
+
+	if tenant != nil {
+		if tenant.hasKeys() {
+			opts.VKPath = tenant.VKPath
+			opts.VKData = tenant.VK
+			opts.KeySet = tenant.KeySet
+		}
+		if len(opts.IntendedScope) == 0 {
+			opts.IntendedScope = tenant.IntendedScope
+		}
+		if len(opts.IntendedAudience) == 0 {
+			opts.IntendedAudience = tenant.IntendedAudience
+		}
+		opts.NonceKeyPrefix = tenant.noncePrefix()
+		opts.PolicyFunc = verifier.AllPolicies(opts.PolicyFunc, tenant.policy())
+	}
+	if s.nonces != nil {
+		opts.NonceStore = s.nonces.WithPrefix(opts.NonceKeyPrefix)
+	}
+	if s.challenges != nil {
+		opts.Challenges = s.challengesOf(tenant)
+	}
+	return opts, nil
+}
+
+// checkLimits takes a token from the nullifier and domain buckets of the
+// tenant
+func (s *Server) checkLimits(ctx context.Context, sub subject, tenant *Tenant) (Error, bool) {
+	prefix := ""
+	if tenant != nil {
+		prefix = "tenant:" + tenant.ID + ":"
+	}
+	checks := []struct {
+		scope string
+		value string
+		limit ratelimit.Limit
+	}{
+		{"nullifier", sub.NullifierHash, s.cfg.NullifierLimit},
+		{"domain", sub.Domain, s.cfg.DomainLimit},
+	}
+	for _, c := range checks {
+		if !c.limit.Enabled() || c.value == "" {
+			continue
+		}
+		d, err := s.limiter.Allow(ctx, prefix+c.scope+":"+c.value, c.limit)
+		if err != nil {
+			return Error{Code: CodeInternal, Message: "rate limiter unavailable: " + err.Error()}, false
+		}
+		if !d.Allowed {
+			return Error{
+				Code:         CodeRateLimited,
+				Message:      fmt.Sprintf("too many presentations for this %s (limit %s)", c.scope, c.limit),
+				Scope:        c.scope,
+				RetryAfterMs: d.RetryAfter.Milliseconds(),
+			}, false
+		}
+	}
+	return Error{}, true
+}
+
+// callerOf identifies the client of r by the subject of its TLS client
+// certificate, or by its address
+func callerOf(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.String()
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func writeError(w http.ResponseWriter, status int, e Error) {
+	writeJSON(w, status, ErrorBody{Error: e})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+)
+
+// subject identifies who is presenting a token, for rate limiting. It is read
+// before verification, so it is only trusted as a bucket key.
+type subject struct {
+	Domain        string
+	NullifierHash string
+	PTXHash       string
+}
+
+func subjectOf(data []byte) (subject, error) {
+	ptxFile, err := ptxloader.ParsePTX(data, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return subject{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	sub := subject{PTXHash: hex.EncodeToString(sum[:])}
+	sub.Domain = strings.ToLower(ptxFile.GetDohDetails().GetDomainName())
+
+	var pd struct {
+		PublicSignals []string `json:"publicSignals"`
+	}
+	if err := json.Unmarshal(ptxFile.GetProof().GetProofData(), &pd); err == nil && len(pd.PublicSignals) > 0 {
+		sub.NullifierHash = pd.PublicSignals[0]
+	}
+	return sub, nil
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// DefaultTenantHeader selects the tenant of a POST /v1/verify request
+const DefaultTenantHeader = "X-PTX-Tenant"
+
+// Error codes for tenant selection
+const (
+	CodeTenantRequired = "tenant_required"
+	CodeUnknownTenant  = "unknown_tenant"
+)
+
+// Tenant is one tenant of a multi-tenant server. Settings left empty fall back
+// to the server configuration. Tenants are loaded from JSON:
+//
+//	{
+//	  "tenants": [
+//	    {"id": "payments", "keyset": "keys/payments.json", "intendedAudience": ["payments.internal"]},
+//	    {"id": "search", "vk": "keys/search.vk", "policy": {"trustMethods": ["DOH"]}}
+//	  ]
+//	}
+type Tenant struct {
+	ID string `json:"id"`
+	// VKPath is the tenant's native verification key. Ignored when KeySet
+	// is set.
+	VKPath string `json:"vk,omitempty"`
+	// VK is the contents of VKPath, read by LoadTenants. When set VKPath is
+	// not read again.
+	VK []byte `json:"-"`
+	// KeySetPath is loaded into KeySet by LoadTenants
+	KeySetPath string           `json:"keyset,omitempty"`
+	KeySet     *verifier.KeySet `json:"-"`
+	// IntendedScope and IntendedAudience apply when a request does not set
+	// its own
+	IntendedScope    []string `json:"intendedScope,omitempty"`
+	IntendedAudience []string `json:"intendedAudience,omitempty"`
+	// NoncePrefix namespaces the tenant's nonces in Redis. Defaults to
+	// "<id>:".
+	NoncePrefix string `json:"noncePrefix,omitempty"`
+	// Policy and PolicyFunc apply in addition to the server policy
+	Policy     *verifier.Policy    `json:"policy,omitempty"`
+	PolicyFunc verifier.PolicyFunc `json:"-"`
+}
+
+// LoadTenants reads tenants from a JSON file. Relative key paths are resolved
+// against the directory of the file, and the key files are read so that the
+// tenants are unaffected by later changes to them until they are loaded again.
+func LoadTenants(path string) ([]*Tenant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Tenants []*Tenant `json:"tenants"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants: %w", err)
+	}
+	if len(file.Tenants) == 0 {
+		return nil, errors.New("no tenants defined")
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p != "" && !filepath.IsAbs(p) {
+			return filepath.Join(dir, p)
+		}
+		return p
+	}
+	for _, t := range file.Tenants {
+		t.VKPath = resolve(t.VKPath)
+		t.KeySetPath = resolve(t.KeySetPath)
+		if t.KeySetPath != "" {
+			ks, err := verifier.LoadKeySet(t.KeySetPath)
+			if err == nil {
+				err = ks.ReadKeys()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q: failed to load key set: %w", t.ID, err)
+			}
+			t.KeySet = ks
+		} else if t.VKPath != "" {
+			if t.VK, err = os.ReadFile(t.VKPath); err != nil {
+				return nil, fmt.Errorf("tenant %q: failed to read verification key: %w", t.ID, err)
+			}
+		}
+		if t.Policy != nil {
+			if err := t.Policy.Validate(); err != nil {
+				return nil, fmt.Errorf("tenant %q: %w", t.ID, err)
+			}
+		}
+	}
+	return file.Tenants, nil
+}
+
+// hasKeys reports whether the tenant overrides the server keys
+func (t *Tenant) hasKeys() bool {
+	return t.KeySet != nil || len(t.VK) > 0 || t.VKPath != ""
+}
+
+// files lists the tenant's key files
+func (t *Tenant) files() []string {
+	if t.KeySet == nil {
+		return nonEmpty(t.VKPath)
+	}
+	files := nonEmpty(t.KeySetPath)
+	for _, k := range t.KeySet.Keys {
+		files = append(files, nonEmpty(k.Path)...)
+	}
+	return files
+}
+
+func (t *Tenant) policy() verifier.PolicyFunc {
+	if t.Policy == nil {
+		return t.PolicyFunc
+	}
+	return verifier.AllPolicies(t.Policy.Func(), t.PolicyFunc)
+}
+
+func (t *Tenant) noncePrefix() string {
+	if t.NoncePrefix != "" {
+		return t.NoncePrefix
+	}
+	return t.ID + ":"
+}
+
+// indexTenants maps tenants by ID, rejecting duplicates
+func indexTenants(tenants []*Tenant) (map[string]*Tenant, error) {
+	m := make(map[string]*Tenant, len(tenants))
+	for _, t := range tenants {
+		if t.ID == "" {
+			return nil, errors.New("tenant without id")
+		}
+		if _, ok := m[t.ID]; ok {
+			return nil, fmt.Errorf("duplicate tenant id %q", t.ID)
+		}
+		m[t.ID] = t
+	}
+	return m, nil
+}
+
+// tenantOf returns the tenant a request is addressed to, from the path of
+// /v1/tenants/{tenant}/verify or the tenant header. It returns nil on a
+// single-tenant server.
+func (s *Server) tenantOf(r *http.Request, snap *snapshot) (*Tenant, int, Error) {
+	id := r.PathValue("tenant")
+	if id == "" {
+		id = r.Header.Get(s.cfg.TenantHeader)
+	}
+
+	if len(snap.tenants) == 0 {
+		if id != "" {
+			return nil, http.StatusNotFound, Error{Code: CodeUnknownTenant, Message: "server is not multi-tenant"}
+		}
+		return nil, 0, Error{}
+	}
+	if id == "" {
+		return nil, http.StatusBadRequest, Error{Code: CodeTenantRequired, Message: "tenant is required (" + s.cfg.TenantHeader + " header or /v1/tenants/{tenant}/verify)"}
+	}
+	t, ok := snap.tenants[id]
+	if !ok {
+		return nil, http.StatusNotFound, Error{Code: CodeUnknownTenant, Message: fmt.Sprintf("unknown tenant %q", id)}
+	}
+	return t, 0, Error{}
+}
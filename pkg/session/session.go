@@ -0,0 +1,162 @@
+// Package session mints short-lived session tokens for verified PTX files.
+// A session token is a JWT signed with the verification server's ed25519 key
+// (alg EdDSA) that carries the verified claims, so downstream services can
+// trust a presentation for a few minutes without verifying the proof again.
+package session
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/transport"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// DefaultTTL is the lifetime of a session token
+const DefaultTTL = 5 * time.Minute
+
+var (
+	ErrMalformed   = errors.New("malformed session token")
+	ErrSignature   = errors.New("session token signature is invalid")
+	ErrExpired     = errors.New("session token expired")
+	ErrNotYet      = errors.New("session token is not valid yet")
+	ErrUnsupported = errors.New("unsupported session token algorithm")
+)
+
+// Claims are the JWT claims of a session token. The subject is the nullifier
+// hash of the PTX, which identifies the holder across presentations.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	NotBefore int64  `json:"nbf"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+	// Tenant is the tenant the PTX was verified for, if any
+	Tenant string `json:"tenant,omitempty"`
+	// PTX holds the claims of the verified PTX
+	PTX *transport.Claims `json:"ptx"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// Minter signs session tokens
+type Minter struct {
+	Key ed25519.PrivateKey
+	// Issuer is the iss claim
+	Issuer string
+	// TTL defaults to DefaultTTL. Tokens never outlive the PTX they were
+	// minted for.
+	TTL time.Duration
+}
+
+// KeyID identifies the minter's key in the token header: the first 8 bytes
+// of the SHA-256 of the public key, in hex
+func (m *Minter) KeyID() string {
+	sum := sha256.Sum256(m.Key.Public().(ed25519.PublicKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewClaims returns the session claims of a successful verification at now
+func (m *Minter) NewClaims(res *verifier.VerificationResult, tenant string, now time.Time) (*Claims, error) {
+	if !res.Success {
+		return nil, errors.New("cannot mint a session for a rejected PTX")
+	}
+	ttl := m.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	exp := now.Add(ttl)
+	if e := res.Details.ExpiresAt; e != nil && e.Before(exp) {
+		exp = *e
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	ptx := transport.NewClaims(res)
+	return &Claims{
+		Issuer:    m.Issuer,
+		Subject:   ptx.NullifierHash,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: exp.Unix(),
+		ID:        base64.RawURLEncoding.EncodeToString(id),
+		Tenant:    tenant,
+		PTX:       ptx,
+	}, nil
+}
+
+// Mint signs claims into a compact JWT
+func (m *Minter) Mint(c *Claims) (string, error) {
+	h, err := json.Marshal(header{Alg: "EdDSA", Typ: "JWT", Kid: m.KeyID()})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(body)
+	sig := ed25519.Sign(m.Key, []byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks the signature and validity period of a session token minted
+// under pub and returns its claims
+func Verify(token string, pub ed25519.PublicKey, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	if h.Alg != "EdDSA" {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupported, h.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !ed25519.Verify(pub, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrSignature
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, err
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return nil, ErrExpired
+	}
+	if now.Unix() < c.NotBefore {
+		return nil, ErrNotYet
+	}
+	return &c, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package session
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func newMinter(t *testing.T) *Minter {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Minter{Key: key, Issuer: "https://verifier.example"}
+}
+
+func accepted(expiresAt *time.Time) *verifier.VerificationResult {
+	return &verifier.VerificationResult{
+		Success: true,
+		Details: verifier.VerificationDetails{Fqdn: "example.com", NullifierHash: "42", ExpiresAt: expiresAt},
+	}
+}
+
+func mint(t *testing.T, m *Minter, res *verifier.VerificationResult, now time.Time) (string, *Claims) {
+	c, err := m.NewClaims(res, "acme", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := m.Mint(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok, c
+}
+
+func TestMintVerify(t *testing.T) {
+	m := newMinter(t)
+	now := time.Unix(1_700_000_000, 0)
+	tok, _ := mint(t, m, accepted(nil), now)
+
+	c, err := Verify(tok, m.Key.Public().(ed25519.PublicKey), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Issuer != m.Issuer || c.Subject != "42" || c.Tenant != "acme" || c.PTX.Domain != "example.com" {
+		t.Errorf("claims %+v", c)
+	}
+	if c.IssuedAt != now.Unix() || c.NotBefore != now.Unix() || c.ExpiresAt != now.Add(DefaultTTL).Unix() || c.ID == "" {
+		t.Errorf("validity %+v", c)
+	}
+
+	var h header
+	if err := decodeSegment(strings.Split(tok, ".")[0], &h); err != nil || h.Alg != "EdDSA" || h.Kid != m.KeyID() {
+		t.Errorf("header %+v, %v", h, err)
+	}
+}
+
+func TestNewClaims(t *testing.T) {
+	m := newMinter(t)
+	m.TTL = time.Hour
+	now := time.Unix(1_700_000_000, 0)
+
+	_, c := mint(t, m, accepted(nil), now)
+	if c.ExpiresAt != now.Add(time.Hour).Unix() {
+		t.Errorf("exp = %d, want now + TTL", c.ExpiresAt)
+	}
+
+	// Sessions do not outlive the PTX
+	ptxExp := now.Add(10 * time.Minute)
+	_, c = mint(t, m, accepted(&ptxExp), now)
+	if c.ExpiresAt != ptxExp.Unix() {
+		t.Errorf("exp = %d, want the PTX expiration %d", c.ExpiresAt, ptxExp.Unix())
+	}
+	later := now.Add(2 * time.Hour)
+	if _, c = mint(t, m, accepted(&later), now); c.ExpiresAt != now.Add(time.Hour).Unix() {
+		t.Errorf("exp = %d, want now + TTL", c.ExpiresAt)
+	}
+
+	if _, err := m.NewClaims(&verifier.VerificationResult{}, "", now); err == nil {
+		t.Error("session minted for a rejected PTX")
+	}
+}
+
+func TestVerifyValidity(t *testing.T) {
+	m := newMinter(t)
+	pub := m.Key.Public().(ed25519.PublicKey)
+	now := time.Unix(1_700_000_000, 0)
+	tok, c := mint(t, m, accepted(nil), now)
+	exp := time.Unix(c.ExpiresAt, 0)
+
+	for _, tt := range []struct {
+		at  time.Time
+		err error
+	}{
+		{at: now},
+		{at: exp.Add(-time.Second)},
+		{at: exp, err: ErrExpired},
+		{at: exp.Add(time.Hour), err: ErrExpired},
+		{at: now.Add(-time.Second), err: ErrNotYet},
+	} {
+		if _, err := Verify(tok, pub, tt.at); !errors.Is(err, tt.err) {
+			t.Errorf("at %v: err = %v, want %v", tt.at.Sub(now), err, tt.err)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	m := newMinter(t)
+	pub := m.Key.Public().(ed25519.PublicKey)
+	now := time.Unix(1_700_000_000, 0)
+	tok, c := mint(t, m, accepted(nil), now)
+	parts := strings.Split(tok, ".")
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	tampered := *c
+	tampered.Subject = "43"
+	other := newMinter(t)
+	otherTok, _ := mint(t, other, accepted(nil), now)
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "alg none", token: segment(header{Alg: "none", Typ: "JWT"}) + "." + parts[1] + ".", err: ErrUnsupported},
+		{name: "alg HS256", token: segment(header{Alg: "HS256", Typ: "JWT"}) + "." + parts[1] + "." + parts[2], err: ErrUnsupported},
+		{name: "tampered payload", token: parts[0] + "." + segment(tampered) + "." + parts[2], err: ErrSignature},
+		{name: "tampered header", token: segment(header{Alg: "EdDSA", Typ: "JWT", Kid: "x"}) + "." + parts[1] + "." + parts[2], err: ErrSignature},
+		{name: "wrong key", token: otherTok, err: ErrSignature},
+		{name: "no signature", token: parts[0] + "." + parts[1], err: ErrMalformed},
+		{name: "extra segment", token: tok + ".x", err: ErrMalformed},
+		{name: "bad base64", token: parts[0] + "." + parts[1] + ".!", err: ErrMalformed},
+		{name: "bad header", token: "e30." + parts[1] + "." + parts[2], err: ErrUnsupported},
+		{name: "header not JSON", token: "eA." + parts[1] + "." + parts[2], err: ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := Verify(tt.token, pub, now); !errors.Is(err, tt.err) {
+				t.Errorf("Verify = %+v, %v, want %v", c, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// Claims are the verified contents of a PTX token
+type Claims struct {
+	Domain        string `json:"domain"`
+	TrustMethod   string `json:"trustMethod"`
+	NullifierHash string `json:"nullifierHash"`
+	Commitment    string `json:"commitment"`
+	// Metadata is the decoded signed metadata
+	Metadata  map[string]interface{} `json:"metadata"`
+	IssuedAt  *time.Time             `json:"issuedAt,omitempty"`
+	ExpiresAt *time.Time             `json:"expiresAt,omitempty"`
+	// KeyID is the key set entry that verified the proof, if any
+	KeyID string `json:"keyId,omitempty"`
+	// AdditionalDomains are the further anchor domains of a multi-domain
+	// PTX
+	AdditionalDomains []string `json:"additionalDomains,omitempty"`
+}
+
+// NewClaims extracts the claims of a successful verification
+func NewClaims(res *verifier.VerificationResult) *Claims {
+	d := res.Details
+	c := &Claims{
+		Domain:        d.Fqdn,
+		TrustMethod:   d.TrustMethod,
+		NullifierHash: d.NullifierHash,
+		Commitment:    d.Commitment,
+		IssuedAt:      d.IssuedAt,
+		ExpiresAt:     d.ExpiresAt,
+		KeyID:         res.Zk.KeyID,
+	}
+	c.AdditionalDomains = d.AdditionalDomains
+	// The metadata was already parsed by the verifier
+	json.Unmarshal([]byte(d.MetadataJSON), &c.Metadata)
+	return c
+}
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx carrying c
+func NewContext(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, c)
+}
+
+// FromContext returns the claims stored by Middleware
+func FromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	return c, ok
+}
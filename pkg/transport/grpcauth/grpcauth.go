@@ -0,0 +1,198 @@
+// Package grpcauth authenticates gRPC calls with PTX tokens. The token is
+// carried in the ptx-token metadata entry, chunked like the HTTP header of
+// pkg/transport.
+package grpcauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/transport"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Config configures the server interceptors
+type Config struct {
+	// Options is the base configuration of every verification. PTXData,
+	// IntendedScope and IntendedAudience are set per call, and tokens must
+	// carry the scopes and audience claims the method requires.
+	Options verifier.VerificationOptions
+	// Scopes returns the scopes accepted for a method. Defaults to
+	// MethodScopes.
+	Scopes func(fullMethod string) []string
+	// Audiences returns the audiences accepted for a method. Defaults to
+	// MethodAudience.
+	Audiences func(fullMethod string) []string
+	// Exempt skips authentication for a method, e.g. health checks
+	Exempt func(fullMethod string) bool
+}
+
+// MethodScopes accepts a token scoped to the service or to the method itself:
+// "/pkg.Service/Method" accepts "pkg.Service" and "pkg.Service/Method".
+func MethodScopes(fullMethod string) []string {
+	name := strings.TrimPrefix(fullMethod, "/")
+	service, _, _ := strings.Cut(name, "/")
+	return []string{service, name}
+}
+
+// MethodAudience accepts a token issued for the service of a method,
+// "pkg.Service" for "/pkg.Service/Method"
+func MethodAudience(fullMethod string) []string {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return []string{service}
+}
+
+// UnaryServerInterceptor verifies the token of each unary call and stores the
+// transport.Claims in the handler's context
+func UnaryServerInterceptor(cfg Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := cfg.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor verifies the token once when a stream is opened
+func StreamServerInterceptor(cfg Config) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := cfg.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (cfg Config) authenticate(ctx context.Context, fullMethod string) (_ context.Context, err error) {
+	if cfg.Exempt != nil && cfg.Exempt(fullMethod) {
+		return ctx, nil
+	}
+	// grpc-go does not recover handler panics, so a token tripping one
+	// would take the server down
+	defer func() {
+		if r := recover(); r != nil {
+			err = status.Error(codes.Internal, fmt.Sprintf("verification failed: %v", r))
+		}
+	}()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	data, err := transport.Extract(carrier(md))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	scopes, audiences := cfg.Scopes, cfg.Audiences
+	if scopes == nil {
+		scopes = MethodScopes
+	}
+	if audiences == nil {
+		audiences = MethodAudience
+	}
+
+	opts := cfg.Options
+	opts.PTXData = data
+	opts.IntendedScope = scopes(fullMethod)
+	opts.IntendedAudience = audiences(fullMethod)
+	// A token without the claims would otherwise skip the checks
+	opts.RequireScope = true
+	opts.RequireAudience = true
+	claims, authErr := transport.Verify(opts)
+	if authErr != nil {
+		msg := authErr.Message
+		if len(authErr.Errors) > 0 {
+			msg = strings.Join(authErr.Errors, "; ")
+		}
+		return nil, status.Error(statusCode(authErr.Status), authErr.Code+": "+msg)
+	}
+	return transport.NewContext(ctx, claims), nil
+}
+
+func statusCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Unauthenticated
+	}
+}
+
+// TokenSource returns the PTX file to present on a call
+type TokenSource func(ctx context.Context) ([]byte, error)
+
+// StaticToken presents the same PTX file on every call
+func StaticToken(ptxData []byte) TokenSource {
+	return func(context.Context) ([]byte, error) {
+		return ptxData, nil
+	}
+}
+
+// UnaryClientInterceptor attaches a token to every unary call
+func UnaryClientInterceptor(src TokenSource) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := attach(ctx, src)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor attaches a token to every stream
+func StreamClientInterceptor(src TokenSource) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := attach(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func attach(ctx context.Context, src TokenSource) (context.Context, error) {
+	data, err := src(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "failed to obtain PTX token: "+err.Error())
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	transport.Inject(carrier(md), data, 0)
+	return metadata.NewOutgoingContext(ctx, md), nil
+}
+
+// carrier adapts metadata to transport.Carrier. Keys are lowercased by
+// metadata.MD.
+type carrier metadata.MD
+
+func (c carrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c carrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c carrier) Del(key string) {
+	metadata.MD(c).Delete(key)
+}
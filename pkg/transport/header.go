@@ -0,0 +1,137 @@
+// Package transport carries PTX tokens over HTTP. A token is stapled to a
+// request in the Ptx-Token header, and Middleware verifies it before the
+// wrapped handler runs.
+package transport
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+)
+
+const (
+	// HeaderName carries the base64url encoded PTX file
+	HeaderName = "Ptx-Token"
+	// ChunksHeaderName holds the number of chunks when the token is split
+	// over Ptx-Token-0 ... Ptx-Token-<n-1>
+	ChunksHeaderName = "Ptx-Token-Chunks"
+	// DefaultChunkSize keeps each header value well below the 8 KiB line
+	// limit common to proxies and servers
+	DefaultChunkSize = 4096
+	// MaxChunks bounds the number of chunk headers read from a request
+	MaxChunks = 64
+)
+
+var (
+	ErrNoToken        = errors.New("no PTX token in request")
+	ErrMalformedToken = errors.New("malformed PTX token")
+)
+
+// EncodeToken encodes a PTX file as unpadded base64url
+func EncodeToken(ptxData []byte) string {
+	return base64.RawURLEncoding.EncodeToString(ptxData)
+}
+
+// DecodeToken decodes a token produced by EncodeToken. Padding is tolerated.
+func DecodeToken(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if s == "" {
+		return nil, ErrNoToken
+	}
+	if base64.RawURLEncoding.DecodedLen(len(s)) > ptxloader.DefaultMaxFileSize {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrMalformedToken, ptxloader.DefaultMaxFileSize)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+	return data, nil
+}
+
+// Carrier holds the token headers. http.Header implements it; other
+// transports such as gRPC metadata adapt to it.
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Del(key string)
+}
+
+// SetHeader staples a PTX file to h. Tokens longer than chunkSize (or
+// DefaultChunkSize if <= 0) are split over numbered headers. Any token
+// already present is replaced.
+func SetHeader(h http.Header, ptxData []byte, chunkSize int) {
+	Inject(h, ptxData, chunkSize)
+}
+
+// FromHeader returns the PTX file stapled to h. It returns ErrNoToken if
+// there is none and wraps ErrMalformedToken if it cannot be decoded.
+func FromHeader(h http.Header) ([]byte, error) {
+	return Extract(h)
+}
+
+// Inject is SetHeader for any Carrier
+func Inject(c Carrier, ptxData []byte, chunkSize int) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	clearToken(c)
+
+	enc := EncodeToken(ptxData)
+	if len(enc) <= chunkSize {
+		c.Set(HeaderName, enc)
+		return
+	}
+
+	n := 0
+	for ; len(enc) > 0; n++ {
+		size := min(chunkSize, len(enc))
+		c.Set(chunkHeader(n), enc[:size])
+		enc = enc[size:]
+	}
+	c.Set(ChunksHeaderName, strconv.Itoa(n))
+}
+
+// Extract is FromHeader for any Carrier
+func Extract(c Carrier) ([]byte, error) {
+	if v := c.Get(HeaderName); v != "" {
+		return DecodeToken(v)
+	}
+
+	count := c.Get(ChunksHeaderName)
+	if count == "" {
+		return nil, ErrNoToken
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 || n > MaxChunks {
+		return nil, fmt.Errorf("%w: invalid %s %q", ErrMalformedToken, ChunksHeaderName, count)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		chunk := c.Get(chunkHeader(i))
+		if chunk == "" {
+			return nil, fmt.Errorf("%w: missing chunk %d of %d", ErrMalformedToken, i, n)
+		}
+		sb.WriteString(chunk)
+	}
+	return DecodeToken(sb.String())
+}
+
+func clearToken(c Carrier) {
+	c.Del(HeaderName)
+	if n, err := strconv.Atoi(c.Get(ChunksHeaderName)); err == nil {
+		for i := 0; i < min(n, MaxChunks); i++ {
+			c.Del(chunkHeader(i))
+		}
+	}
+	c.Del(ChunksHeaderName)
+}
+
+func chunkHeader(i int) string {
+	return HeaderName + "-" + strconv.Itoa(i)
+}
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// Error codes for requests rejected before verification. Rejections by the
+// verifier use its verifier.ErrorCode.
+const (
+	CodeTokenMissing   = "token_missing"
+	CodeTokenMalformed = "token_malformed"
+)
+
+// Config configures Middleware
+type Config struct {
+	// Options is the base configuration of every verification. PTXData,
+	// IntendedScope and IntendedAudience are set per request. Verification
+	// keys are cached across requests by the verifier.
+	Options verifier.VerificationOptions
+	// Scope and Audience are required of every token. A token without the
+	// claim is rejected when they are set.
+	Scope    []string
+	Audience []string
+	// Realm is advertised in the WWW-Authenticate header of 401 responses
+	Realm string
+}
+
+// ErrorBody is returned with every 401, 403 and 503 response
+type ErrorBody struct {
+	Error *AuthError `json:"error"`
+}
+
+// AuthError describes a request that was not authenticated
+type AuthError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Errors lists every failed check of the verifier
+	Errors []string `json:"errors,omitempty"`
+	// Status is the HTTP status the error is reported with
+	Status int `json:"-"`
+}
+
+func (e *AuthError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Middleware verifies the PTX token of each request. Requests with a valid
+// token reach next with the Claims in their context; all others are rejected
+// with a JSON ErrorBody:
+//
+//   - 401 if the token is missing, malformed or does not verify
+//   - 403 if it verifies but fails the scope, audience or policy checks
+//   - 503 if the nonce store is unavailable
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, err := FromHeader(r.Header)
+			if err != nil {
+				code := CodeTokenMalformed
+				if errors.Is(err, ErrNoToken) {
+					code = CodeTokenMissing
+				}
+				writeError(w, cfg.Realm, &AuthError{Code: code, Message: err.Error(), Status: http.StatusUnauthorized})
+				return
+			}
+
+			opts := cfg.Options
+			opts.PTXData = data
+			opts.IntendedScope = cfg.Scope
+			opts.IntendedAudience = cfg.Audience
+			// A token without the claims would otherwise skip the checks
+			opts.RequireScope = opts.RequireScope || len(cfg.Scope) > 0
+			opts.RequireAudience = opts.RequireAudience || len(cfg.Audience) > 0
+			claims, authErr := Verify(opts)
+			if authErr != nil {
+				writeError(w, cfg.Realm, authErr)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		})
+	}
+}
+
+// Verify runs the verifier and classifies a rejection by the status it should
+// be reported with
+func Verify(opts verifier.VerificationOptions) (*Claims, *AuthError) {
+	res, err := verifier.NewPTXVerifier(opts).Verify()
+	if err != nil {
+		return nil, &AuthError{Code: string(verifier.CodeLoadFailed), Message: err.Error(), Status: http.StatusUnauthorized}
+	}
+	if !res.Success {
+		errs := res.Errors
+		if res.Dns.Error != "" {
+			errs = append(errs, "DNS: "+res.Dns.Error)
+		}
+		return nil, &AuthError{
+			Code:    string(res.Code),
+			Message: "PTX token rejected",
+			Errors:  errs,
+			Status:  statusFor(res.Code),
+		}
+	}
+	return NewClaims(res), nil
+}
+
+func statusFor(code verifier.ErrorCode) int {
+	switch code {
+	case verifier.CodeScopeMismatch, verifier.CodeAudienceMismatch, verifier.CodePolicyRejected, verifier.CodeProofPolicy:
+		return http.StatusForbidden
+	case verifier.CodeNonceStore, verifier.CodeTimeout:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+func writeError(w http.ResponseWriter, realm string, e *AuthError) {
+	if e.Status == http.StatusUnauthorized {
+		challenge := "PTX"
+		if realm != "" {
+			challenge += ` realm="` + realm + `"`
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(ErrorBody{Error: e})
+}
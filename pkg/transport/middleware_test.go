@@ -0,0 +1,102 @@
+//go:build !(js && wasm)
+
+package transport_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/transport"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func TestMiddleware(t *testing.T) {
+	env := ptxtest.New(t)
+	issue := func(seed int64, meta map[string]interface{}) *ptxtest.Token {
+		meta["role"] = "validator"
+		return env.Issue(fixture.Options{Seed: seed, Metadata: meta})
+	}
+	scoped := issue(1, map[string]interface{}{"scopes": []interface{}{"orders:read"}, "audience": "orders"})
+	noScope := issue(2, map[string]interface{}{"audience": "orders"})
+	noAudience := issue(3, map[string]interface{}{"scopes": []interface{}{"orders:read"}})
+	otherScope := issue(4, map[string]interface{}{"scopes": []interface{}{"orders:write"}, "audience": "orders"})
+	withNonce := issue(5, map[string]interface{}{"scopes": []interface{}{"orders:read"}, "audience": "orders", "nonce": "n-1"})
+	unanchored := issue(6, map[string]interface{}{"scopes": []interface{}{"orders:read"}, "audience": "orders"})
+	env.Revoke(unanchored)
+
+	// The nonce database cannot be opened in a missing directory
+	unavailable := filepath.Join(t.TempDir(), "missing", "nonce.db")
+	serve := func(tok *ptxtest.Token, nonceDB string) (*httptest.ResponseRecorder, *transport.Claims) {
+		// Each seed proves under its own key
+		vk := scoped
+		if tok != nil {
+			vk = tok
+		}
+		opts := env.Options(vk)
+		opts.PTXData = nil
+		if nonceDB != "" {
+			opts.NonceStore, opts.NonceDB = nil, nonceDB
+		}
+		h := transport.Middleware(transport.Config{
+			Options:  opts,
+			Scope:    []string{"orders:read"},
+			Audience: []string{"orders"},
+			Realm:    "orders",
+		})
+		var claims *transport.Claims
+		handler := h(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, _ = transport.FromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if tok != nil {
+			transport.SetHeader(req.Header, tok.PTX, 0)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec, claims
+	}
+
+	rec, claims := serve(scoped, "")
+	if rec.Code != http.StatusOK || claims == nil || claims.Domain != "example.com" {
+		t.Fatalf("accepted token: %d %s", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name    string
+		tok     *ptxtest.Token
+		nonceDB string
+		status  int
+		code    string
+	}{
+		{name: "no token", status: http.StatusUnauthorized, code: transport.CodeTokenMissing},
+		{name: "unanchored", tok: unanchored, status: http.StatusUnauthorized, code: string(verifier.CodeDNSAnchor)},
+		{name: "no scope claim", tok: noScope, status: http.StatusForbidden, code: string(verifier.CodeScopeMismatch)},
+		{name: "no audience claim", tok: noAudience, status: http.StatusForbidden, code: string(verifier.CodeAudienceMismatch)},
+		{name: "other scope", tok: otherScope, status: http.StatusForbidden, code: string(verifier.CodeScopeMismatch)},
+		{name: "nonce store down", tok: withNonce, nonceDB: unavailable, status: http.StatusServiceUnavailable, code: string(verifier.CodeNonceStore)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, claims := serve(tt.tok, tt.nonceDB)
+			if rec.Code != tt.status || claims != nil {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			var body transport.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == nil {
+				t.Fatalf("body %s: %v", rec.Body, err)
+			}
+			if body.Error.Code != tt.code {
+				t.Errorf("code = %q, want %q", body.Error.Code, tt.code)
+			}
+			challenge := rec.Header().Get("WWW-Authenticate")
+			if (tt.status == http.StatusUnauthorized) != (challenge == `PTX realm="orders"`) {
+				t.Errorf("WWW-Authenticate = %q", challenge)
+			}
+		})
+	}
+}
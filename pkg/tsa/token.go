@@ -0,0 +1,318 @@
+package tsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"time"
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidRSAPSS        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// Info is what a verified timestamp token attests
+type Info struct {
+	// Time is when the TSA timestamped the digest
+	Time time.Time
+	// Accuracy is the TSA's bound on the error of Time, zero if unstated
+	Accuracy time.Duration
+	Serial   *big.Int
+	Policy   asn1.ObjectIdentifier
+	// Signer is the TSA certificate that signed the token
+	Signer *x509.Certificate
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContent     encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,explicit,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+func (a accuracy) duration() time.Duration {
+	return time.Duration(a.Seconds)*time.Second + time.Duration(a.Millis)*time.Millisecond + time.Duration(a.Micros)*time.Microsecond
+}
+
+// token is a parsed TimeStampToken
+type token struct {
+	info  tstInfo
+	sd    signedData
+	certs []*x509.Certificate
+}
+
+func parseToken(der []byte) (*token, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("invalid timestamp token: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("invalid timestamp token: trailing data")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("invalid timestamp token: content type %v is not signed data", ci.ContentType)
+	}
+
+	var t token
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &t.sd); err != nil {
+		return nil, fmt.Errorf("invalid timestamp token signed data: %w", err)
+	}
+	if !t.sd.EncapContent.ContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("invalid timestamp token: content type %v is not TSTInfo", t.sd.EncapContent.ContentType)
+	}
+	if _, err := asn1.Unmarshal(t.sd.EncapContent.Content, &t.info); err != nil {
+		return nil, fmt.Errorf("invalid timestamp token TSTInfo: %w", err)
+	}
+	if len(t.sd.Certificates.Bytes) > 0 {
+		certs, err := x509.ParseCertificates(t.sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp token certificates: %w", err)
+		}
+		t.certs = certs
+	}
+	return &t, nil
+}
+
+// Verify checks that token is a timestamp of digest, a SHA-256 hash, signed
+// by a TSA certificate valid for timestamping that chains to roots at the
+// time of the timestamp. A nil roots uses the system roots.
+func Verify(der, digest []byte, roots *x509.CertPool) (*Info, error) {
+	t, err := parseToken(der)
+	if err != nil {
+		return nil, err
+	}
+	mi := t.info.MessageImprint
+	if !mi.HashAlgorithm.Algorithm.Equal(oidSHA256) {
+		return nil, fmt.Errorf("timestamp imprint uses %v, not SHA-256", mi.HashAlgorithm.Algorithm)
+	}
+	if !bytes.Equal(mi.HashedMessage, digest) {
+		return nil, errors.New("timestamp is for another payload")
+	}
+
+	if len(t.sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("timestamp token has %d signers, want 1", len(t.sd.SignerInfos))
+	}
+	si := t.sd.SignerInfos[0]
+	signer := t.signer(si.SID)
+	if signer == nil {
+		return nil, errors.New("timestamp token does not include the TSA certificate")
+	}
+	if err := t.checkSignature(si, signer); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(signer.ExtKeyUsage, x509.ExtKeyUsageTimeStamping) {
+		return nil, errors.New("TSA certificate is not valid for timestamping")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range t.certs {
+		if c != signer {
+			intermediates.AddCert(c)
+		}
+	}
+	_, err = signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   t.info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TSA certificate not trusted: %w", err)
+	}
+
+	return &Info{
+		Time:     t.info.GenTime,
+		Accuracy: t.info.Accuracy.duration(),
+		Serial:   t.info.SerialNumber,
+		Policy:   t.info.Policy,
+		Signer:   signer,
+	}, nil
+}
+
+// signer returns the certificate sid names, by issuer and serial number or
+// by subject key identifier
+func (t *token) signer(sid asn1.RawValue) *x509.Certificate {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, c := range t.certs {
+			if len(c.SubjectKeyId) > 0 && bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c
+			}
+		}
+		return nil
+	}
+	var ias issuerAndSerial
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return nil
+	}
+	for _, c := range t.certs {
+		if bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) && c.SerialNumber.Cmp(ias.Serial) == 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+// checkSignature verifies the signed attributes of si, which must bind the
+// TSTInfo content, under the key of cert
+func (t *token) checkSignature(si signerInfo, cert *x509.Certificate) error {
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return errors.New("timestamp token has no signed attributes")
+	}
+	hash, err := hashFor(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	// The signature covers the attributes with their SET OF tag rather than
+	// the implicit [0] they are stored under
+	signed := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil {
+		return fmt.Errorf("invalid timestamp signed attributes: %w", err)
+	}
+	var contentType asn1.ObjectIdentifier
+	var digest []byte
+	for _, a := range attrs {
+		switch {
+		case a.Type.Equal(oidContentType):
+			_, err = asn1.Unmarshal(a.Values.Bytes, &contentType)
+		case a.Type.Equal(oidMessageDigest):
+			_, err = asn1.Unmarshal(a.Values.Bytes, &digest)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid timestamp signed attribute %v: %w", a.Type, err)
+		}
+	}
+	if !contentType.Equal(oidTSTInfo) {
+		return errors.New("timestamp signed attributes do not name TSTInfo content")
+	}
+	h := hash.New()
+	h.Write(t.sd.EncapContent.Content)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return errors.New("timestamp signed attributes do not match the TSTInfo content")
+	}
+
+	algo, err := signatureAlgorithm(cert, hash, si.SignatureAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	if err := cert.CheckSignature(algo, signed, si.Signature); err != nil {
+		return fmt.Errorf("timestamp signature invalid: %w", err)
+	}
+	return nil
+}
+
+func hashFor(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported timestamp digest algorithm %v", oid)
+}
+
+// signatureAlgorithm maps the key of cert, the signer digest and the CMS
+// signature algorithm to the x509 algorithm checking the signature. CMS names
+// RSA signatures by the key algorithm alone, so the digest decides.
+func signatureAlgorithm(cert *x509.Certificate, hash crypto.Hash, sigAlg asn1.ObjectIdentifier) (x509.SignatureAlgorithm, error) {
+	byHash := func(algos ...x509.SignatureAlgorithm) x509.SignatureAlgorithm {
+		return algos[slices.Index([]crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512}, hash)]
+	}
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		if sigAlg.Equal(oidRSAPSS) {
+			return byHash(x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS), nil
+		}
+		return byHash(x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA), nil
+	case x509.ECDSA:
+		return byHash(x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512), nil
+	case x509.Ed25519:
+		return x509.PureEd25519, nil
+	}
+	return 0, fmt.Errorf("unsupported TSA key algorithm %v", cert.PublicKeyAlgorithm)
+}
+
+// LoadRoots reads a PEM bundle of TSA root certificates
+func LoadRoots(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TSA roots: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,170 @@
+// Package tsa timestamps PTX files with an RFC 3161 Time-Stamping Authority
+// and verifies the resulting tokens. A TSA signs the SHA-256 of the PTX
+// payload (see Imprint) together with its own clock, which proves the file
+// existed at that time whatever issued_at claims.
+//
+// Only what PTX needs is implemented: SHA-256 message imprints, and CMS
+// SignedData tokens with signed attributes and the TSA certificate included,
+// signed with RSA (PKCS #1 v1.5 or PSS), ECDSA or Ed25519.
+package tsa
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultTimeout bounds a request to the TSA
+	DefaultTimeout = 10 * time.Second
+	// maxResponseSize caps how much of a TSA response is read
+	maxResponseSize = 64 << 10
+)
+
+var defaultHTTP = outbound.Client(DefaultTimeout)
+
+// Imprint returns the digest a PTX file is timestamped over: the SHA-256 of
+// its deterministic serialization with the timestamp token and the issuer
+// signature cleared
+func Imprint(f *ptx.PtxFile) ([]byte, error) {
+	payload := proto.Clone(f).(*ptx.PtxFile)
+	payload.TimestampToken = nil
+	payload.IssuerSignature = nil
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize PTX payload: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// Client requests timestamps from a TSA over HTTP (RFC 3161 section 3.4)
+type Client struct {
+	// URL is the TSA endpoint, e.g. https://freetsa.org/tsr
+	URL string
+	// HTTP defaults to a client with DefaultTimeout
+	HTTP *http.Client
+	// Policy requests a TSA policy. Empty leaves the choice to the TSA.
+	Policy asn1.ObjectIdentifier
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status pkiStatusInfo
+	Token  asn1.RawValue `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// PKIStatus values granting a timestamp
+const (
+	statusGranted         = 0
+	statusGrantedWithMods = 1
+)
+
+// Timestamp asks the TSA to timestamp digest, a SHA-256 hash, and returns the
+// DER encoded TimeStampToken. The token is checked to answer the request but
+// its signature is not verified, see Verify.
+func (c *Client) Timestamp(ctx context.Context, digest []byte) ([]byte, error) {
+	if len(digest) != sha256.Size {
+		return nil, fmt.Errorf("digest must be a SHA-256 hash, got %d bytes", len(digest))
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	req, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: sha256Algorithm, HashedMessage: digest},
+		ReqPolicy:      c.Policy,
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid TSA response: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("invalid TSA response: trailing data")
+	}
+	if s := resp.Status.Status; s != statusGranted && s != statusGrantedWithMods {
+		msg := fmt.Sprintf("TSA rejected the request (status %d)", s)
+		if len(resp.Status.StatusString) > 0 {
+			msg += ": " + strings.Join(resp.Status.StatusString, "; ")
+		}
+		return nil, errors.New(msg)
+	}
+	if len(resp.Token.FullBytes) == 0 {
+		return nil, errors.New("TSA response carries no token")
+	}
+
+	tok, err := parseToken(resp.Token.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(tok.info.MessageImprint.HashedMessage, digest) {
+		return nil, errors.New("TSA timestamped another digest")
+	}
+	if tok.info.Nonce == nil || tok.info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("TSA response does not answer the request nonce")
+	}
+	return resp.Token.FullBytes, nil
+}
+
+func (c *Client) post(ctx context.Context, query []byte) ([]byte, error) {
+	client := c.HTTP
+	if client == nil {
+		client = defaultHTTP
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+	req.Header.Set("Accept", "application/timestamp-reply")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("TSA request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TSA request failed: HTTP %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
+
+// sha256Algorithm identifies SHA-256, with the NULL parameters most TSAs
+// send
+var sha256Algorithm = pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
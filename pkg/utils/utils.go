@@ -1,10 +1,17 @@
 package utils
 
 import (
+	"crypto/ed25519"
 	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
 )
 
 // Sha256 returns the hex string of the SHA256 hash of the input string
@@ -43,13 +50,72 @@ func Base27(hexStr string) string {
 	return string(result)
 }
 
-// DeriveHostnameFromCommitment derives the hostname from the commitment
-func DeriveHostnameFromCommitment(commitmentStr string, domain string) (string, error) {
+// HostnameVersion selects how the commitment label of an anchor hostname is
+// derived
+type HostnameVersion int
+
+const (
+	// HostnameV1 labels are "x-" and the Base27 SHA256 of the commitment.
+	// Their length varies with the hash and they may end with a hyphen, which
+	// some DNS providers reject.
+	HostnameV1 HostnameVersion = 1
+	// HostnameV2 labels are "x2-" and the unpadded lower case base32 of the
+	// first 20 bytes of the SHA256 of the commitment: always 35 characters of
+	// [a-z2-7], unaffected by DNS case folding
+	HostnameV2 HostnameVersion = 2
+)
+
+// hostnameV2Bytes is how much of the hash a v2 label keeps, 160 bits
+const hostnameV2Bytes = 20
+
+const (
+	// MaxLabelLength is the RFC 1035 limit on the length of a DNS label
+	MaxLabelLength = 63
+	// MaxHostnameLength is the RFC 1035 limit on the length of a name in
+	// presentation form, without the trailing dot
+	MaxHostnameLength = 253
+	// MinLabelHashLength is the fewest hash characters the length fallback of
+	// DeriveHostnameFromCommitment keeps, about 80 bits
+	MinLabelHashLength = 16
+)
+
+// ErrHostnameTooLong is returned when a domain leaves no room for a
+// commitment label of MinLabelHashLength characters
+var ErrHostnameTooLong = errors.New("anchor hostname exceeds the DNS length limits")
+
+var labelEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// FormatHostnameVersion returns the hostname derivation of PTX files with the
+// given format version
+func FormatHostnameVersion(formatVersion uint32) HostnameVersion {
+	if formatVersion >= 2 {
+		return HostnameV2
+	}
+	return HostnameV1
+}
+
+// DeriveHostnameFromCommitment derives the hostname from the commitment.
+//
+// The hostname must satisfy the RFC 1035 limits of MaxLabelLength per label
+// and MaxHostnameLength in total. Derived labels fit in a label, but under a
+// long domain the hostname can exceed the total, and resolvers refuse such
+// queries. As a fallback the hash encoding of the label is then cut to the
+// length that fits, keeping its prefix ("x-" or "x2-"), so prover and
+// verifier agree on the shorter name. Domains leaving room for fewer than
+// MinLabelHashLength hash characters fail with ErrHostnameTooLong.
+func DeriveHostnameFromCommitment(commitmentStr string, domain string, version HostnameVersion) (string, error) {
+	if version != HostnameV1 && version != HostnameV2 {
+		return "", fmt.Errorf("unknown hostname version %d", version)
+	}
+	domain, err := crypto.NormalizeDomain(domain)
+	if err != nil {
+		return "", err
+	}
+
 	// 1. Parse Decimal String to BigInt
-	n := new(big.Int)
-	n.SetString(commitmentStr, 10)
-	if n.Sign() == 0 && commitmentStr != "0" {
-		return "", fmt.Errorf("failed to parse commitment: %s", commitmentStr)
+	n, err := crypto.ParseFieldElement(commitmentStr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse commitment %q: %w", commitmentStr, err)
 	}
 
 	// 2. To 32-bytes Little Endian Buffer
@@ -66,10 +132,118 @@ func DeriveHostnameFromCommitment(commitmentStr string, domain string) (string,
 
 	// 3. SHA256
 	hashBytes := sha256.Sum256(bytes)
-	hashHex := hex.EncodeToString(hashBytes[:])
 
-	// 4. Base27 of hash
-	encoded := Base27(hashHex)
+	// 4. Base27 (v1) or base32 (v2) of hash
+	prefix, encoded := "x-", Base27(hex.EncodeToString(hashBytes[:]))
+	if version == HostnameV2 {
+		prefix, encoded = "x2-", labelEncoding.EncodeToString(hashBytes[:hostnameV2Bytes])
+	}
 
-	return fmt.Sprintf("x-%s.%s", encoded, domain), nil
+	// 5. Length fallback
+	if room := MaxHostnameLength - len(prefix) - 1 - len(domain); len(encoded) > room {
+		if room < MinLabelHashLength {
+			return "", fmt.Errorf("%w: domain %q is %d characters long", ErrHostnameTooLong, domain, len(domain))
+		}
+		encoded = encoded[:room]
+	}
+	if len(prefix)+len(encoded) > MaxLabelLength {
+		return "", fmt.Errorf("%w: %d character label", ErrHostnameTooLong, len(prefix)+len(encoded))
+	}
+
+	return prefix + encoded + "." + domain, nil
+}
+
+// AnchorRecord is the DNS TXT record a domain owner publishes to anchor a PTX
+type AnchorRecord struct {
+	Hostname string
+	Value    string
+}
+
+// DeriveAnchorRecord returns the TXT hostname and expected record value for a
+// commitment, anchor domain and the signed metadata string stored in the PTX,
+// with the hostname derivation of the PTX format version
+func DeriveAnchorRecord(commitmentStr string, domain string, metadataRaw string, version HostnameVersion) (*AnchorRecord, error) {
+	hostname, err := DeriveHostnameFromCommitment(commitmentStr, domain, version)
+	if err != nil {
+		return nil, err
+	}
+	return &AnchorRecord{
+		Hostname: hostname,
+		Value:    Sha256(metadataRaw),
+	}, nil
+}
+
+// WellKnownAnchorPath is where a domain serves anchor records for the
+// WELL_KNOWN trust method, one file per record
+const WellKnownAnchorPath = "/.well-known/ptx-anchors/"
+
+// WellKnownURL returns the HTTPS URL serving the record for the WELL_KNOWN
+// trust method: the first label of the hostname under WellKnownAnchorPath of
+// its domain. The file holds the record value, or several values one per
+// line.
+func (r *AnchorRecord) WellKnownURL() string {
+	label, domain, _ := strings.Cut(r.Hostname, ".")
+	return "https://" + domain + WellKnownAnchorPath + label
+}
+
+// Document returns the anchor document holding the record for the IPFS trust
+// method: "ptx-anchor-v1\n<hostname>\n<value>\n"
+func (r *AnchorRecord) Document() []byte {
+	return []byte("ptx-anchor-v1\n" + r.Hostname + "\n" + r.Value + "\n")
+}
+
+// ENSTextKey returns the key of the ENS text record holding the record value
+// for the ETHEREUM trust method: "ptx:" and the first label of the hostname
+func (r *AnchorRecord) ENSTextKey() string {
+	label, _, _ := strings.Cut(r.Hostname, ".")
+	return "ptx:" + label
+}
+
+// FixedAnchorLabel is the label of fixed anchor records, which publish the
+// issuer's anchor key instead of a per-token digest
+const FixedAnchorLabel = "_ptx"
+
+// fixedAnchorPrefix starts the value of a fixed anchor record
+const fixedAnchorPrefix = "v=ptx1; k="
+
+// FixedAnchorRecord returns the record a domain provisions once to anchor
+// every token signed by key: "v=ptx1; k=<base64 key>" at _ptx.<domain>
+func FixedAnchorRecord(domain string, key ed25519.PublicKey) (*AnchorRecord, error) {
+	domain, err := crypto.NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	return &AnchorRecord{
+		Hostname: FixedAnchorLabel + "." + domain,
+		Value:    fixedAnchorPrefix + base64.StdEncoding.EncodeToString(key),
+	}, nil
+}
+
+// ParseFixedAnchorKey returns the anchor key published by a fixed anchor
+// record value
+func ParseFixedAnchorKey(value string) (ed25519.PublicKey, bool) {
+	enc, ok := strings.CutPrefix(strings.TrimSpace(value), fixedAnchorPrefix)
+	if !ok {
+		return nil, false
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(enc))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, false
+	}
+	return key, true
+}
+
+// AnchorSigningPayload is what the anchor key signs for a token anchored
+// under a fixed label. Like a commitment label it binds the commitment, the
+// domain and the signed metadata.
+func AnchorSigningPayload(commitmentStr string, domain string, metadataRaw string) ([]byte, error) {
+	domain, err := crypto.NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	n, err := crypto.ParseFieldElement(commitmentStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commitment %q: %w", commitmentStr, err)
+	}
+	return []byte("ptx-anchor-v1\n" + domain + "\n" + n.String() + "\n" + Sha256(metadataRaw)), nil
 }
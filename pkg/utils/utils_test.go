@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHostnameLengthFallback(t *testing.T) {
+	const commitment = "4275998959503387931846795458644675446474402044967322435176051352005705477775"
+	long := strings.Join([]string{strings.Repeat("a", 63), strings.Repeat("b", 63), strings.Repeat("c", 63), strings.Repeat("d", 24), "example"}, ".")
+	tooLong := strings.Join([]string{strings.Repeat("a", 63), strings.Repeat("b", 63), strings.Repeat("c", 63), strings.Repeat("d", 40), "example"}, ".")
+
+	for _, version := range []HostnameVersion{HostnameV1, HostnameV2} {
+		full, err := DeriveHostnameFromCommitment(commitment, "example.com", version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		label, _, _ := strings.Cut(full, ".")
+
+		got, err := DeriveHostnameFromCommitment(commitment, long, version)
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		if len(got) != MaxHostnameLength {
+			t.Errorf("v%d: %d character hostname, want the cut to fill %d", version, len(got), MaxHostnameLength)
+		}
+		cut, _, _ := strings.Cut(got, ".")
+		if !strings.HasPrefix(label, cut) {
+			t.Errorf("v%d: cut label %q is not a prefix of %q", version, cut, label)
+		}
+
+		if _, err := DeriveHostnameFromCommitment(commitment, tooLong, version); !errors.Is(err, ErrHostnameTooLong) {
+			t.Errorf("v%d: got %v for a %d character domain, want ErrHostnameTooLong", version, err, len(tooLong))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/resilience"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+const (
+	// defaultAnchorTimeout bounds the fetch of a well-known anchor
+	defaultAnchorTimeout = 10 * time.Second
+	// maxWellKnownAnchorSize caps how much of a well-known anchor file is read
+	maxWellKnownAnchorSize = 64 << 10
+)
+
+// HasEthereum reports whether this binary was built with the ethereum build
+// tag and checks ETHEREUM anchors
+const HasEthereum = ethereumBuild
+
+var defaultAnchorHTTP = outbound.Client(defaultAnchorTimeout)
+
+// AnchorResult is the outcome of checking one of the additional anchors of a
+// PTX file
+type AnchorResult struct {
+	TrustMethod string `json:"trustMethod"`
+	// Location is the URL the record was fetched from
+	Location    string  `json:"location,omitempty"`
+	Valid       bool    `json:"valid"`
+	Error       string  `json:"error,omitempty"`
+	FetchTimeMs float64 `json:"fetchTimeMs"`
+}
+
+// verifyAnchors checks the additional anchors of a PTX file, in file order.
+// They must publish the record of the DNS anchor under the same domain.
+func (v *PTXVerifier) verifyAnchors(ptxFile *ptx.PtxFile) []AnchorResult {
+	anchors := ptxFile.GetAdditionalAnchors()
+	results := make([]AnchorResult, len(anchors))
+	domain, _ := crypto.NormalizeDomain(ptxFile.GetDohDetails().GetDomainName())
+	for i, a := range anchors {
+		results[i] = v.verifyAnchor(ptxFile, a, domain)
+	}
+	return results
+}
+
+func (v *PTXVerifier) verifyAnchor(ptxFile *ptx.PtxFile, a *ptx.Anchor, domain string) AnchorResult {
+	res := AnchorResult{TrustMethod: a.GetTrustMethod().String()}
+	wk, ipfs, eth := a.GetWellKnownDetails(), a.GetIpfsDetails(), a.GetEthereumDetails()
+	var name string
+	switch {
+	case a.GetTrustMethod() == ptx.TrustMethod_WELL_KNOWN && wk != nil:
+		name = wk.GetDomainName()
+	case a.GetTrustMethod() == ptx.TrustMethod_IPFS && ipfs != nil:
+		name = ipfs.GetDomainName()
+	case a.GetTrustMethod() == ptx.TrustMethod_ETHEREUM && eth != nil:
+		name = eth.GetDomainName()
+	default:
+		res.Error = "Unsupported anchor (only WELL_KNOWN, IPFS and ETHEREUM anchors can be added to the DNS anchor)"
+		return res
+	}
+	if d, err := crypto.NormalizeDomain(name); err != nil || d != domain {
+		res.Error = fmt.Sprintf("Anchor domain %q does not match the PTX domain", name)
+		return res
+	}
+	if ipfs != nil {
+		return v.verifyIPFSAnchor(ptxFile, ipfs, domain, res)
+	}
+	if eth != nil {
+		return v.verifyEthereumAnchor(ptxFile, eth, domain, res)
+	}
+	record, matcher, err := anchorFor(ptxFile, domain, wk.GetLabelMode(), v.Options.DNSMatchMode)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Location = record.WellKnownURL()
+	start := time.Now()
+	values, err := v.fetchWellKnownAnchor(res.Location)
+	res.FetchTimeMs = time.Since(start).Seconds() * 1000
+	if err != nil {
+		res.Error = "Fetch failed: " + err.Error()
+		return res
+	}
+	if matcher.MatchAny(values) {
+		res.Valid = true
+		return res
+	}
+	res.Error = "No matching anchor record found (Expected: " + record.Value + ")"
+	return res
+}
+
+// fetchWellKnownAnchor returns the non-empty lines of a well-known anchor file
+func (v *PTXVerifier) fetchWellKnownAnchor(url string) ([]string, error) {
+	client, err := v.anchorHTTP()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = defaultAnchorHTTP
+	}
+	ctx, cancel := context.WithTimeout(v.context(), defaultAnchorTimeout)
+	defer cancel()
+	var values []string
+	err = v.call(ctx, urlEndpoint("well-known", url), func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return resilience.Permanent(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("HTTP %s", resp.Status)
+			// A missing anchor file stays missing; overload and server
+			// errors may pass
+			if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+				return resilience.Permanent(err)
+			}
+			return err
+		}
+
+		values = nil
+		scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxWellKnownAnchorSize))
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				values = append(values, line)
+			}
+		}
+		return scanner.Err()
+	})
+	return values, err
+}
+
+// anchorWarnings lists the anchors that failed when the anchor policy still
+// accepted the PTX
+func anchorWarnings(d DnsResult, anchors []AnchorResult) []string {
+	var warnings []string
+	if !d.Valid {
+		warnings = append(warnings, "DNS anchor not verified ("+d.Error+"); accepted through another anchor")
+	}
+	for _, a := range anchors {
+		if !a.Valid {
+			warnings = append(warnings, a.TrustMethod+" anchor not verified ("+a.Error+")")
+		}
+	}
+	return warnings
+}
+
+// anchorsHold applies the anchor policy of a PTX file to the outcome of its
+// DNS anchor and additional anchors. Unknown policies require every anchor.
+func anchorsHold(policy ptx.AnchorPolicy, dnsValid bool, anchors []AnchorResult) bool {
+	all, any := dnsValid, dnsValid
+	for _, a := range anchors {
+		all = all && a.Valid
+		any = any || a.Valid
+	}
+	if policy == ptx.AnchorPolicy_ANCHOR_POLICY_ANY {
+		return any
+	}
+	return all
+}
@@ -0,0 +1,195 @@
+package verifier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// PairingBench measures the Groth16 pairing check of one PTX alone. The PTX,
+// proof, verification key and public witness are loaded and derived once by
+// NewPairingBench, exactly as Verify derives them, so that each run times the
+// cryptography rather than parsing, key loading or I/O. Verification times
+// published for this verifier are measured with it.
+type PairingBench struct {
+	// KeyID is the key set entry verifying the proof, empty without a key
+	// set
+	KeyID string
+	// SetupMs is the time NewPairingBench took
+	SetupMs float64
+
+	vk    *PreparedVK
+	proof groth16.Proof
+	pw    *publicWitness
+	// scratch receives the fixed pairing lines on every run
+	scratch []lines
+}
+
+// BenchResult summarizes the runs of a PairingBench
+type BenchResult struct {
+	Runs     int     `json:"runs"`
+	KeyID    string  `json:"keyId,omitempty"`
+	SetupMs  float64 `json:"setupMs"`
+	MinMs    float64 `json:"minMs"`
+	MedianMs float64 `json:"medianMs"`
+	MeanMs   float64 `json:"meanMs"`
+	StdDevMs float64 `json:"stdDevMs"`
+	MaxMs    float64 `json:"maxMs"`
+	// AllocsPerRun and BytesPerRun are the heap allocations of a run
+	AllocsPerRun float64 `json:"allocsPerRun"`
+	BytesPerRun  float64 `json:"bytesPerRun"`
+}
+
+// NewPairingBench prepares the pairing check of the native proof of the PTX
+// in opts (PTXData or FilePath) under its keys (VKPath, VKData or KeySet).
+// Keys are not discovered. It fails unless the proof verifies, so that a
+// benchmark cannot time a rejection.
+func NewPairingBench(opts VerificationOptions) (*PairingBench, error) {
+	return NewPTXVerifier(opts).pairingBench()
+}
+
+func (v *PTXVerifier) pairingBench() (*PairingBench, error) {
+	start := time.Now()
+	loadOpts := ptxloader.DefaultLoadOptions()
+	data := v.Options.PTXData
+	if data == nil {
+		var err error
+		if data, err = ptxloader.ReadFile(v.Options.FilePath, loadOpts); err != nil {
+			return nil, fmt.Errorf("failed to load PTX file: %w", err)
+		}
+	}
+	ptxFile, err := ptxloader.ParsePTX(data, loadOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PTX file: %w", err)
+	}
+	if code, msg := v.applyExternalInputs(ptxFile); code != "" {
+		return nil, errors.New(msg)
+	}
+	proof := ptxFile.GetProof()
+	if proof.GetProofSystem() != ptx.ProofSystem_GROTH16 {
+		return nil, fmt.Errorf("proof system %s is not benchmarked (only GROTH16)", proof.GetProofSystem())
+	}
+	wrapper, err := proofdata.Parse(proof.GetProofData())
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof wrapper: %w", err)
+	}
+	envelope, err := v.proofEnvelope(proof, wrapper)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof envelope: %w", err)
+	}
+	if envelope.GetFormat() != ptx.ProofFormat_GNARK_NATIVE {
+		return nil, errors.New("only native gnark proofs are benchmarked")
+	}
+	metaRaw, err := SignedMetadata(ptxFile)
+	if err != nil {
+		return nil, err
+	}
+	domain, err := crypto.NormalizeDomain(ptxFile.GetDohDetails().GetDomainName())
+	if err != nil {
+		return nil, fmt.Errorf("invalid anchor domain: %w", err)
+	}
+
+	proofBytes, err := wrapper.AppendNativeProof(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
+	}
+	b := &PairingBench{proof: groth16.NewProof(ecc.BN254), scratch: make([]lines, 0, 2)}
+	if _, err := b.proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
+		return nil, fmt.Errorf("failed to deserialize proof: %w", err)
+	}
+
+	keyID := proof.GetVerificationKeyId()
+	keys, err := v.candidateKeys(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load VK: %w", err)
+	}
+	layout := v.witnessLayout(keyID)
+	// The witness is kept by the bench rather than returned to the pool
+	if b.pw, err = getPublicWitness(layout.Len()); err != nil {
+		return nil, err
+	}
+	if err := fillNativeWitness(b.pw, layout, wrapper.PublicSignals, domain, metaRaw, ptxFile.GetTrustMethod()); err != nil {
+		return nil, err
+	}
+	if err := b.selectKey(keys, layout, metaRaw, v.limbEncodings(keyID)); err != nil {
+		return nil, err
+	}
+	b.SetupMs = time.Since(start).Seconds() * 1000
+	return b, nil
+}
+
+// selectKey finds the key and metadata limb encoding under which the proof
+// verifies, as Verify tries them, and keeps them for the runs
+func (b *PairingBench) selectKey(keys []candidateKey, layout *signals.SignalLayout, metaRaw string, encodings []crypto.LimbEncoding) error {
+	var err error
+	for _, enc := range encodings {
+		if err := fillMetadataHash(b.pw, layout, metaRaw, enc); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err = k.vk.Verify(b.proof, b.pw.w); err == nil {
+				b.vk, b.KeyID = k.vk, k.id
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("proof does not verify: %w", err)
+}
+
+// Run checks the proof n times and summarizes the time of each check. The
+// allocations are read from the runtime around all runs. Runs reuse the
+// buffers of the bench, so Run must not be called concurrently.
+func (b *PairingBench) Run(n int) (*BenchResult, error) {
+	if n <= 0 {
+		return nil, errors.New("at least one run is required")
+	}
+	times := make([]float64, n)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := range times {
+		start := time.Now()
+		if err := b.vk.verify(b.proof, b.pw.w, b.scratch); err != nil {
+			return nil, err
+		}
+		times[i] = time.Since(start).Seconds() * 1000
+	}
+	runtime.ReadMemStats(&after)
+
+	r := &BenchResult{
+		Runs:         n,
+		KeyID:        b.KeyID,
+		SetupMs:      b.SetupMs,
+		AllocsPerRun: float64(after.Mallocs-before.Mallocs) / float64(n),
+		BytesPerRun:  float64(after.TotalAlloc-before.TotalAlloc) / float64(n),
+	}
+	var sum float64
+	for _, t := range times {
+		sum += t
+	}
+	r.MeanMs = sum / float64(n)
+	var sq float64
+	for _, t := range times {
+		sq += (t - r.MeanMs) * (t - r.MeanMs)
+	}
+	r.StdDevMs = math.Sqrt(sq / float64(n))
+	slices.Sort(times)
+	r.MinMs, r.MaxMs = times[0], times[n-1]
+	r.MedianMs = times[n/2]
+	if n%2 == 0 {
+		r.MedianMs = (times[n/2-1] + times[n/2]) / 2
+	}
+	return r, nil
+}
@@ -0,0 +1,45 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPairingBench(t *testing.T) {
+	f, err := fixture.Generate(fixture.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := verifier.NewPairingBench(verifier.VerificationOptions{PTXData: f.PTX, VKData: f.VK})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := b.Run(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Runs != 5 || r.MinMs <= 0 || r.MinMs > r.MedianMs || r.MedianMs > r.MaxMs {
+		t.Errorf("implausible result %+v", r)
+	}
+
+	// A proof that does not verify is not benchmarked
+	file, err := ptxloader.ParsePTX(f.PTX, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.GetDohDetails().DomainName = "other.example"
+	body, err := proto.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append(ptxloader.Header(ptxloader.FormatVersion), body...)
+	if _, err := verifier.NewPairingBench(verifier.VerificationOptions{PTXData: tampered, VKData: f.VK}); err == nil {
+		t.Error("benchmarked a proof for another domain")
+	}
+}
@@ -0,0 +1,37 @@
+package verifier
+
+import (
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// audienceBound reports whether the audienceHash signal of a proof binds one
+// of the intended audiences. Proofs whose circuit does not bind the audience
+// pass; their audience is only checked in the metadata.
+func (v *PTXVerifier) audienceBound(ptxFile *ptx.PtxFile) bool {
+	proof := ptxFile.GetProof()
+	layout, ok := signals.LayoutFor(v.circuitID(proof.GetVerificationKeyId()))
+	if !ok || !layout.BindsScope() {
+		return true
+	}
+	w, err := proofdata.Parse(proof.GetProofData())
+	if err != nil {
+		return false
+	}
+	sig, ok := layout.Lookup(w.PublicSignals, signals.SignalAudienceHash)
+	if !ok {
+		return false
+	}
+	bound, err := crypto.ParseFr(sig)
+	if err != nil || bound.IsZero() {
+		return false
+	}
+	for _, aud := range v.Options.IntendedAudience {
+		if crypto.AudienceHash(aud).Equal(&bound) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package verifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+)
+
+// BytesOptions configures VerifyBytes. It is decoded from JSON by the
+// embedded (js/wasm and C) entry points.
+type BytesOptions struct {
+	IntendedScope    []string `json:"intendedScope,omitempty"`
+	IntendedAudience []string `json:"intendedAudience,omitempty"`
+	StrictMode       bool     `json:"strict,omitempty"`
+	DNSAfterProof    bool     `json:"dnsAfterProof,omitempty"`
+	// DNSMatch is "exact" (default) or "prefix"
+	DNSMatch string `json:"dnsMatch,omitempty"`
+	// TXTRecords is a pre-fetched answer for the anchor hostname. When nil the
+	// anchor is looked up over DoH; an empty list means no record exists.
+	TXTRecords       []string `json:"txtRecords,omitempty"`
+	LegacySignalScan bool     `json:"legacySignalScan,omitempty"`
+	Evidence         bool     `json:"evidence,omitempty"`
+	// Policy is applied once all other checks have passed
+	Policy *Policy `json:"policy,omitempty"`
+	// MaxDurationMs bounds the verification, see
+	// VerificationOptions.MaxDuration
+	MaxDurationMs int64 `json:"maxDurationMs,omitempty"`
+}
+
+// VerifyBytes verifies an in-memory PTX file against an in-memory
+// verification key. It never touches the filesystem and does not check
+// nonces, so replay protection is left to the caller.
+func VerifyBytes(ptxData, vkData []byte, opts BytesOptions) (*VerificationResult, error) {
+	if len(ptxData) == 0 {
+		return nil, fmt.Errorf("failed to load PTX file: empty input")
+	}
+	if len(vkData) == 0 {
+		return nil, fmt.Errorf("verification key is required")
+	}
+
+	vopts, err := opts.VerificationOptions()
+	if err != nil {
+		return nil, err
+	}
+	vopts.PTXData = ptxData
+	vopts.VKData = vkData
+
+	return NewPTXVerifier(vopts).Verify()
+}
+
+// VerificationOptions converts opts. The PTX and key inputs are left unset.
+func (opts BytesOptions) VerificationOptions() (VerificationOptions, error) {
+	matchMode, err := dns.ParseMatchMode(opts.DNSMatch)
+	if err != nil {
+		return VerificationOptions{}, err
+	}
+
+	vopts := VerificationOptions{
+		IntendedScope:    opts.IntendedScope,
+		IntendedAudience: opts.IntendedAudience,
+		StrictMode:       opts.StrictMode,
+		DNSAfterProof:    opts.DNSAfterProof,
+		DNSMatchMode:     matchMode,
+		LegacySignalScan: opts.LegacySignalScan,
+		Evidence:         opts.Evidence,
+	}
+	if opts.MaxDurationMs < 0 {
+		return VerificationOptions{}, fmt.Errorf("maxDurationMs must not be negative")
+	}
+	vopts.MaxDuration = time.Duration(opts.MaxDurationMs) * time.Millisecond
+	if opts.Policy != nil {
+		if err := opts.Policy.Validate(); err != nil {
+			return VerificationOptions{}, err
+		}
+		vopts.PolicyFunc = opts.Policy.Func()
+	}
+	if opts.TXTRecords != nil {
+		vopts.DNSResolver = dns.StaticResolver{Records: opts.TXTRecords}
+	}
+	return vopts, nil
+}
+
+// DeriveAnchor returns the TXT hostname and value a PTX file must be anchored
+// with, so callers can fetch the record before calling VerifyBytes
+func DeriveAnchor(ptxData []byte) (*utils.AnchorRecord, error) {
+	ptxFile, err := ptxloader.ParsePTX(ptxData, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PTX file: %w", err)
+	}
+	return anchorRecord(ptxFile)
+}
@@ -0,0 +1,130 @@
+package verifier
+
+import (
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+)
+
+// ErrorCode classifies why a PTX was rejected. It is stable across releases
+// so that downstream systems can match on it instead of error messages.
+type ErrorCode string
+
+const (
+	CodeLoadFailed       ErrorCode = "load_failed"
+	CodeMetadataInvalid  ErrorCode = "metadata_invalid"
+	CodeTokenValidity    ErrorCode = "token_validity"
+	CodeTimestampInvalid ErrorCode = "timestamp_invalid"
+	CodeScopeMismatch    ErrorCode = "scope_mismatch"
+	CodeAudienceMismatch ErrorCode = "audience_mismatch"
+	CodeNonceStore       ErrorCode = "nonce_store_unavailable"
+	CodeNonceReplayed    ErrorCode = "nonce_replayed"
+	CodeChallengeInvalid ErrorCode = "challenge_invalid"
+	CodeDNSAnchor        ErrorCode = "dns_anchor_invalid"
+	CodeProofInvalid     ErrorCode = "proof_invalid"
+	CodePolicyRejected   ErrorCode = "policy_rejected"
+
+	// CodeTimeout means VerificationOptions.MaxDuration ran out before all
+	// checks completed, see VerificationResult.Deadline
+	CodeTimeout ErrorCode = "verification_timeout"
+
+	// CodeProofPolicy means the proof system, curve or circuit revision is
+	// refused by VerificationOptions.ProofPolicy, see
+	// VerificationResult.ProofPolicyError
+	CodeProofPolicy ErrorCode = "proof_policy_rejected"
+
+	// CodeNullifierReplayed means the PTX was already presented, see
+	// VerificationOptions.TrackNullifiers
+	CodeNullifierReplayed ErrorCode = "nullifier_replayed"
+)
+
+// Exit codes of the machine-readable CLI modes
+const (
+	// ExitAccepted means every check passed
+	ExitAccepted = 0
+	// ExitRejected means the PTX was verified and rejected
+	ExitRejected = 1
+	// ExitError means the PTX could not be verified, e.g. it or its key
+	// failed to load
+	ExitError = 2
+)
+
+// MachineReport is the machine-readable outcome of a timed verification,
+// printed by the CLIs with --machine json. The field names are a stable
+// contract for scripts and the benchmark harness.
+type MachineReport struct {
+	DNSSeconds   float64   `json:"dns_s"`
+	ProofSeconds float64   `json:"proof_s"`
+	OK           bool      `json:"ok"`
+	ErrorCode    ErrorCode `json:"error_code"`
+	// Error describes why the PTX could not be verified
+	Error string `json:"error,omitempty"`
+}
+
+// NewMachineReport summarizes res, taking the times from its DNS and ZK
+// stages
+func NewMachineReport(res *VerificationResult) MachineReport {
+	r := MachineReport{
+		OK:        res.Success,
+		ErrorCode: res.Code,
+	}
+	if t := res.Timing; t != nil {
+		r.DNSSeconds = t.DNS.DurationMs() / 1000
+		r.ProofSeconds = t.ZK.DurationMs() / 1000
+	}
+	return r
+}
+
+// ExitCode returns the process exit code that goes with r
+func (r MachineReport) ExitCode() int {
+	switch {
+	case r.OK:
+		return ExitAccepted
+	case r.ErrorCode == CodeLoadFailed:
+		return ExitError
+	default:
+		return ExitRejected
+	}
+}
+
+// fail marks the result as rejected. Code keeps the first failure since later
+// checks still run and may fail as a consequence of it.
+func (r *VerificationResult) fail(code ErrorCode, msgs ...string) {
+	r.Success = false
+	if r.Code == "" {
+		r.Code = code
+	}
+	r.Errors = append(r.Errors, msgs...)
+}
+
+// newEvent summarizes a verification for the event sink. err is the error
+// returned by verify, in which case res is nil.
+func (v *PTXVerifier) newEvent(res *VerificationResult, err error, start time.Time) events.Event {
+	e := events.Event{
+		Time:      start,
+		LatencyMs: time.Since(start).Seconds() * 1000,
+		PTXHash:   v.ptxHash,
+		Caller:    v.Options.Caller,
+	}
+	if err != nil {
+		e.Code = string(CodeLoadFailed)
+		e.Errors = []string{err.Error()}
+		return e
+	}
+
+	e.Success = res.Success
+	e.Code = string(res.Code)
+	e.Errors = append([]string(nil), res.Errors...)
+	e.Warnings = append([]string(nil), res.Warnings...)
+	if !res.Success && !res.Dns.Valid && res.Dns.Error != "" {
+		e.Errors = append(e.Errors, "DNS: "+res.Dns.Error)
+	}
+	for _, a := range res.Anchors {
+		if !res.Success && !a.Valid {
+			e.Errors = append(e.Errors, a.TrustMethod+": "+a.Error)
+		}
+	}
+	e.Domain = res.Details.Fqdn
+	e.NullifierHash = res.Details.NullifierHash
+	return e
+}
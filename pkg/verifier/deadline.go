@@ -0,0 +1,83 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DeadlineResult reports how far a verification got within
+// VerificationOptions.MaxDuration
+type DeadlineResult struct {
+	MaxDurationMs float64 `json:"maxDurationMs"`
+	// Completed are the stages that finished within the budget
+	Completed []string `json:"completed"`
+	// TimedOut are the stage that was running when the budget ran out, if
+	// any, and the stages that never started
+	TimedOut []string `json:"timedOut"`
+}
+
+// stageOrder is the order in which verify runs the stages of Timing
+var stageOrder = []string{"load", "metadata", "semantic", "zk", "dns", "nonce"}
+
+// context returns the context bounding the running verification
+func (v *PTXVerifier) context() context.Context {
+	if v.ctx == nil {
+		return context.Background()
+	}
+	return v.ctx
+}
+
+// withDeadline bounds the verification by MaxDuration, if set, and returns
+// the function releasing its context
+func (v *PTXVerifier) withDeadline() context.CancelFunc {
+	v.stageCode = ""
+	if v.Options.MaxDuration <= 0 {
+		v.ctx = nil
+		return func() {}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), v.Options.MaxDuration)
+	v.ctx = ctx
+	return cancel
+}
+
+// expired reports whether MaxDuration has run out before the stage next, or
+// before the end of the verification when next is empty.
+// If so it fails res with CodeTimeout and records the stages it completed
+// and those it did not. The code of a failure recorded before the stage cut
+// short began is kept, as that stage did not cause it.
+func (v *PTXVerifier) expired(res *VerificationResult, next string) bool {
+	if v.ctx == nil || v.ctx.Err() == nil {
+		v.stageCode = res.Code
+		return false
+	}
+	budget := float64(v.Options.MaxDuration) / float64(time.Millisecond)
+	d := &DeadlineResult{MaxDurationMs: budget, Completed: []string{}, TimedOut: []string{}}
+	for _, s := range res.Timing.Stages() {
+		if s.StopMs <= budget {
+			d.Completed = append(d.Completed, s.Name)
+		} else {
+			d.TimedOut = append(d.TimedOut, s.Name)
+		}
+	}
+	if i := slices.Index(stageOrder, next); i >= 0 {
+		for _, name := range stageOrder[i:] {
+			if name != "nonce" || v.checksNonce() {
+				d.TimedOut = append(d.TimedOut, name)
+			}
+		}
+	}
+	res.Deadline = d
+	res.fail(CodeTimeout, fmt.Sprintf("Verification exceeded its budget of %s (timed out: %v)", v.Options.MaxDuration, d.TimedOut))
+	if v.stageCode == "" {
+		res.Code = CodeTimeout
+	}
+	return true
+}
+
+// checksNonce reports whether the verification has a nonce stage
+func (v *PTXVerifier) checksNonce() bool {
+	return v.Options.RedisURL != "" || v.Options.NonceDB != "" || v.Options.NonceStore != nil ||
+		v.Options.Challenges != nil
+}
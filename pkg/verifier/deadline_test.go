@@ -0,0 +1,52 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+// hangingResolver answers once its context is done
+type hangingResolver struct{}
+
+func (hangingResolver) LookupTXT(ctx context.Context, hostname string) (*dns.TXTLookup, error) {
+	<-ctx.Done()
+	return &dns.TXTLookup{}, ctx.Err()
+}
+
+func TestMaxDuration(t *testing.T) {
+	env := ptxtest.New(t)
+	tok := env.Issue(fixture.Options{Seed: 1})
+
+	ptxtest.AssertAccepted(t, env.Verify(tok, func(o *verifier.VerificationOptions) {
+		o.MaxDuration = time.Minute
+	}))
+
+	start := time.Now()
+	res := env.Verify(tok, func(o *verifier.VerificationOptions) {
+		o.DNSResolver = hangingResolver{}
+		o.DNSRetries = 3
+		o.MaxDuration = 500 * time.Millisecond
+	})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("verification took %v", elapsed)
+	}
+	ptxtest.AssertRejected(t, res, verifier.CodeTimeout)
+	if res.Deadline == nil {
+		t.Fatal("no deadline report")
+	}
+	if !slices.Equal(res.Deadline.TimedOut, []string{"dns"}) {
+		t.Errorf("timed out = %v, want [dns]", res.Deadline.TimedOut)
+	}
+	if !slices.Contains(res.Deadline.Completed, "zk") || !res.Zk.Valid {
+		t.Errorf("completed = %v, zk = %+v", res.Deadline.Completed, res.Zk)
+	}
+}
@@ -0,0 +1,52 @@
+package verifier
+
+import (
+	"fmt"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// discoverKeys builds a key set from the configuration published by the
+// issuer of domain, rejecting trust methods the issuer does not use and keys
+// that are not for a circuit this verifier has compiled
+func (v *PTXVerifier) discoverKeys(domain string, trustMethod ptx.TrustMethod) (*KeySet, error) {
+	cfg, err := v.Options.Discovery.Discover(v.context(), domain)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.SupportsTrustMethod(trustMethod.String()) {
+		return nil, fmt.Errorf("issuer %s does not use trust method %s", domain, trustMethod)
+	}
+
+	ks := &KeySet{}
+	for _, k := range cfg.Keys {
+		entry := KeyEntry{
+			ID:           k.ID,
+			Circuit:      k.Circuit,
+			CircuitHash:  k.CircuitHash,
+			URL:          k.URL,
+			SHA256:       k.SHA256,
+			SignatureKey: k.SignatureKey,
+			NotBefore:    k.NotBefore,
+			NotAfter:     k.NotAfter,
+		}
+		if _, ok := signals.LayoutFor(entry.circuit()); !ok {
+			return nil, fmt.Errorf("issuer %s: key %q is for unregistered circuit %q", domain, k.ID, entry.circuit())
+		}
+		local, err := circuitHash(entry.circuit())
+		if err != nil {
+			return nil, fmt.Errorf("issuer %s: key %q: %w", domain, k.ID, err)
+		}
+		if k.CircuitHash != local {
+			return nil, fmt.Errorf("issuer %s: key %q is for %s, this verifier's circuit is %s", domain, k.ID,
+				circuit.Identity(entry.circuit(), k.CircuitHash), circuit.Identity(entry.circuit(), local))
+		}
+		ks.Keys = append(ks.Keys, entry)
+	}
+	if err := ks.Validate(); err != nil {
+		return nil, fmt.Errorf("issuer %s: %w", domain, err)
+	}
+	return ks, nil
+}
@@ -0,0 +1,109 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/discovery"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// discoveryClient serves cfg as the configuration of example.com, the name
+// the httptest certificate is issued for
+func discoveryClient(t *testing.T, cfg discovery.Configuration) *discovery.Client {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	tr := srv.Client().Transport.(*http.Transport).Clone()
+	tr.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	return &discovery.Client{HTTP: &http.Client{Transport: tr}}
+}
+
+func TestDiscoverKeys(t *testing.T) {
+	local, err := circuitHash(signals.DefaultVerificationKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := func(fn func(*discovery.Key)) discovery.Key {
+		k := discovery.Key{
+			ID:          "2026",
+			CircuitHash: local,
+			URL:         "https://example.com/keys/2026.vk",
+			SHA256:      strings.Repeat("ab", 32),
+		}
+		if fn != nil {
+			fn(&k)
+		}
+		return k
+	}
+	discover := func(cfg discovery.Configuration, method ptx.TrustMethod) (*KeySet, error) {
+		v := NewPTXVerifier(VerificationOptions{Discovery: discoveryClient(t, cfg)})
+		return v.discoverKeys("example.com", method)
+	}
+
+	ks, err := discover(discovery.Configuration{Keys: []discovery.Key{key(nil)}, TrustMethods: []string{"DOH"}}, ptx.TrustMethod_DOH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.Keys) != 1 || ks.Keys[0].CircuitHash != local || ks.Keys[0].SHA256 == "" {
+		t.Errorf("discovered %+v", ks.Keys)
+	}
+
+	tests := []struct {
+		name   string
+		cfg    discovery.Configuration
+		method ptx.TrustMethod
+	}{
+		{
+			name:   "unsupported trust method",
+			cfg:    discovery.Configuration{Keys: []discovery.Key{key(nil)}, TrustMethods: []string{"DOH"}},
+			method: ptx.TrustMethod_GIST,
+		},
+		{
+			name: "unpinned key",
+			cfg:  discovery.Configuration{Keys: []discovery.Key{key(func(k *discovery.Key) { k.SHA256 = "" })}},
+		},
+		{
+			name: "http key url",
+			cfg:  discovery.Configuration{Keys: []discovery.Key{key(func(k *discovery.Key) { k.URL = "http://example.com/keys/2026.vk" })}},
+		},
+		{
+			name: "no circuit hash",
+			cfg:  discovery.Configuration{Keys: []discovery.Key{key(func(k *discovery.Key) { k.CircuitHash = "" })}},
+		},
+		{
+			name: "other circuit hash",
+			cfg:  discovery.Configuration{Keys: []discovery.Key{key(func(k *discovery.Key) { k.CircuitHash = strings.Repeat("0", 64) })}},
+		},
+		{
+			name: "unregistered circuit",
+			cfg:  discovery.Configuration{Keys: []discovery.Key{key(func(k *discovery.Key) { k.Circuit = "sdv_unknown_v9" })}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == ptx.TrustMethod_METHOD_UNSPECIFIED {
+				method = ptx.TrustMethod_DOH
+			}
+			if ks, err := discover(tt.cfg, method); err == nil {
+				t.Errorf("discovered %+v", ks.Keys)
+			}
+		})
+	}
+}
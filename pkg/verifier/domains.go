@@ -0,0 +1,142 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// MetadataAdditionalDomains is the metadata claim listing the additional
+// domains of a multi-domain PTX, which binds them through the proof
+const MetadataAdditionalDomains = "additional_domains"
+
+// DomainPolicy decides which domains of a multi-domain PTX must publish its
+// anchor record
+type DomainPolicy int
+
+const (
+	// DomainsAll requires the record under every domain
+	DomainsAll DomainPolicy = iota
+	// DomainsAny accepts the record under any one domain, so one domain's
+	// DNS can be down or migrating. Revoking such a PTX means removing the
+	// record from every domain.
+	DomainsAny
+	// DomainsPrimary only checks the primary domain
+	DomainsPrimary
+)
+
+func (p DomainPolicy) String() string {
+	switch p {
+	case DomainsAll:
+		return "all"
+	case DomainsAny:
+		return "any"
+	case DomainsPrimary:
+		return "primary"
+	default:
+		return fmt.Sprintf("DomainPolicy(%d)", int(p))
+	}
+}
+
+// ParseDomainPolicy parses "all", "any" or "primary"
+func ParseDomainPolicy(s string) (DomainPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "all":
+		return DomainsAll, nil
+	case "any":
+		return DomainsAny, nil
+	case "primary":
+		return DomainsPrimary, nil
+	default:
+		return DomainsAll, fmt.Errorf("unknown domain policy %q (expected all, any or primary)", s)
+	}
+}
+
+// DomainResult is the DNS anchor check of an additional domain
+type DomainResult struct {
+	Domain string `json:"domain"`
+	DnsResult
+}
+
+// additionalDomains returns the normalized additional domains of a PTX file.
+// They must be listed in the same order by the metadata, which the proof
+// binds, since the anchor itself is not covered by the proof.
+func additionalDomains(ptxFile *ptx.PtxFile, meta map[string]interface{}) ([]string, error) {
+	names := ptxFile.GetDohDetails().GetAdditionalDomainNames()
+	raw, bound := meta[MetadataAdditionalDomains]
+	if len(names) == 0 && !bound {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if bound && !ok {
+		return nil, fmt.Errorf("Invalid metadata %s: not an array", MetadataAdditionalDomains)
+	}
+	if len(list) != len(names) {
+		return nil, errors.New("Additional domains do not match the metadata " + MetadataAdditionalDomains)
+	}
+
+	domains := make([]string, len(names))
+	for i, name := range names {
+		d, err := crypto.NormalizeDomain(name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid additional domain %q: %w", name, err)
+		}
+		s, _ := list[i].(string)
+		if m, err := crypto.NormalizeDomain(s); err != nil || m != d {
+			return nil, errors.New("Additional domains do not match the metadata " + MetadataAdditionalDomains)
+		}
+		domains[i] = d
+	}
+	return domains, nil
+}
+
+// verifyDomains checks the anchor record under each additional domain. Under
+// DomainsPrimary they are not looked up.
+func (v *PTXVerifier) verifyDomains(ptxFile *ptx.PtxFile, domains []string) []DomainResult {
+	if v.Options.DomainPolicy == DomainsPrimary {
+		return nil
+	}
+	results := make([]DomainResult, len(domains))
+	for i, d := range domains {
+		results[i].Domain = d
+		record, matcher, err := anchorFor(ptxFile, d, ptxFile.GetDohDetails().GetLabelMode(), v.Options.DNSMatchMode)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].DnsResult = v.lookupRecord(record, matcher, nil)
+	}
+	return results
+}
+
+// domainsHold applies the domain policy to the DNS anchor of the primary
+// domain and the results of the additional domains
+func domainsHold(policy DomainPolicy, primary bool, domains []DomainResult) bool {
+	all, any := primary, primary
+	for _, d := range domains {
+		all = all && d.Valid
+		any = any || d.Valid
+	}
+	if policy == DomainsAny {
+		return any
+	}
+	return all
+}
+
+// domainWarnings lists the domains that failed when the domain policy still
+// accepted the PTX
+func domainWarnings(primary DnsResult, domains []DomainResult) []string {
+	var warnings []string
+	if !primary.Valid {
+		warnings = append(warnings, "DNS anchor of the primary domain not verified ("+primary.Error+"); accepted through another domain")
+	}
+	for _, d := range domains {
+		if !d.Valid {
+			warnings = append(warnings, "DNS anchor of "+d.Domain+" not verified ("+d.Error+")")
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,63 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProofEnvelope(t *testing.T) {
+	env := ptxtest.New(t)
+	tok := env.Issue(fixture.Options{Seed: 1})
+
+	with := func(mutate func(*ptx.ZkProof)) *ptxtest.Token {
+		f, err := ptxloader.ParsePTX(tok.PTX, ptxloader.LoadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mutate(f.Proof)
+		payload, err := proto.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := *tok
+		out.PTX = append(append([]byte{}, tok.PTX[:5]...), payload...)
+		return &out
+	}
+
+	for name, tc := range map[string]struct {
+		mutate func(*ptx.ZkProof)
+		err    string
+	}{
+		"future schema":     {func(p *ptx.ZkProof) { p.Envelope.Schema = 2 }, "unsupported proof envelope: schema 2"},
+		"unsupported curve": {func(p *ptx.ZkProof) { p.Envelope.Curve = ptx.Curve_BLS12_381 }, "GROTH16/BLS12_381/GNARK_NATIVE/HEX"},
+		"format mismatch": {func(p *ptx.ZkProof) {
+			p.Envelope.Format, p.Envelope.Encoding = ptx.ProofFormat_SNARKJS, ptx.ProofEncoding_JSON
+		}, "declares a snarkjs proof"},
+		"circuit mismatch": {func(p *ptx.ZkProof) { p.Envelope.CircuitId = "sdv_poseidon_v3" }, `declares circuit "sdv_poseidon_v3"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			res := env.Verify(with(tc.mutate))
+			ptxtest.AssertRejected(t, res, verifier.CodeProofInvalid)
+			ptxtest.AssertError(t, res, tc.err)
+		})
+	}
+
+	t.Run("legacy", func(t *testing.T) {
+		legacy := with(func(p *ptx.ZkProof) { p.Envelope = nil })
+		res := env.Verify(legacy)
+		ptxtest.AssertAccepted(t, res)
+		ptxtest.AssertWarning(t, res, "no proof envelope")
+
+		res = env.Verify(legacy, func(o *verifier.VerificationOptions) { o.StrictMode = true })
+		ptxtest.AssertRejected(t, res, verifier.CodeProofInvalid)
+		ptxtest.AssertError(t, res, "required in strict mode")
+	})
+}
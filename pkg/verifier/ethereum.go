@@ -0,0 +1,111 @@
+//go:build ethereum
+
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ethanchor"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+const ethereumBuild = true
+
+// verifyEthereumAnchor checks an ETHEREUM anchor: the ENS text record of the
+// domain, or the registry contract trusted for the domain
+func (v *PTXVerifier) verifyEthereumAnchor(ptxFile *ptx.PtxFile, a *ptx.EthereumAnchor, domain string, res AnchorResult) AnchorResult {
+	if v.Options.EthereumRPC == "" {
+		res.Error = "No Ethereum JSON-RPC endpoint configured"
+		return res
+	}
+	registry := a.GetRegistryAddress()
+	if registry != "" && !strings.EqualFold(registry, v.Options.EthereumRegistries[domain]) {
+		res.Error = "Registry " + registry + " is not trusted for " + domain
+		return res
+	}
+	// Like the IPFS document, on-chain anchors hold the commitment label
+	// record
+	record, matcher, err := anchorFor(ptxFile, domain, ptx.LabelMode_LABEL_COMMITMENT, v.Options.DNSMatchMode)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	httpClient, err := v.anchorHTTP()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	client := &ethanchor.Client{URL: v.Options.EthereumRPC, HTTP: httpClient}
+	ctx, cancel := context.WithTimeout(v.context(), defaultAnchorTimeout)
+	defer cancel()
+	endpoint := urlEndpoint("ethereum", v.Options.EthereumRPC)
+	if registry == "" {
+		key := record.ENSTextKey()
+		res.Location = "ens://" + domain + "/" + key
+		start := time.Now()
+		var value string
+		err := v.call(ctx, endpoint, func(ctx context.Context) (err error) {
+			value, err = client.Text(ctx, domain, key)
+			return err
+		})
+		res.FetchTimeMs = time.Since(start).Seconds() * 1000
+		if err != nil {
+			res.Error = "ENS lookup failed: " + err.Error()
+			return res
+		}
+		if !matcher.MatchAny([]string{value}) {
+			res.Error = "No matching anchor record found (Expected: " + record.Value + ")"
+			return res
+		}
+		res.Valid = true
+		return res
+	}
+
+	res.Location = "eth://" + registry
+	commitment, err := proofCommitmentSignal(ptxFile)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	start := time.Now()
+	var got []byte
+	err = v.call(ctx, endpoint, func(ctx context.Context) (err error) {
+		got, err = client.Anchor(ctx, registry, commitment)
+		return err
+	})
+	res.FetchTimeMs = time.Since(start).Seconds() * 1000
+	if err != nil {
+		res.Error = "Registry lookup failed: " + err.Error()
+		return res
+	}
+	want, _ := hex.DecodeString(record.Value)
+	if !bytes.Equal(got, want) {
+		res.Error = "Registry does not anchor the commitment (Expected: 0x" + record.Value + ")"
+		return res
+	}
+	res.Valid = true
+	return res
+}
+
+// proofCommitmentSignal returns the commitment public signal of a PTX file
+func proofCommitmentSignal(ptxFile *ptx.PtxFile) (*big.Int, error) {
+	var pd struct {
+		PublicSignals []string `json:"publicSignals"`
+	}
+	if err := json.Unmarshal(ptxFile.GetProof().GetProofData(), &pd); err != nil || len(pd.PublicSignals) < 2 {
+		return nil, errors.New("Failed to parse proof public signals")
+	}
+	n, ok := new(big.Int).SetString(pd.PublicSignals[1], 10)
+	if !ok {
+		return nil, errors.New("Invalid commitment public signal")
+	}
+	return n, nil
+}
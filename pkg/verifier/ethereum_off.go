@@ -0,0 +1,12 @@
+//go:build !ethereum
+
+package verifier
+
+import "github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+
+const ethereumBuild = false
+
+func (v *PTXVerifier) verifyEthereumAnchor(_ *ptx.PtxFile, _ *ptx.EthereumAnchor, _ string, res AnchorResult) AnchorResult {
+	res.Error = "ETHEREUM anchors require a build with -tags ethereum"
+	return res
+}
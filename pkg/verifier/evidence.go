@@ -0,0 +1,98 @@
+package verifier
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// recordInputs adds the PTX hash, verification key fingerprint and public
+// signals to the evidence bundle
+func (v *PTXVerifier) recordInputs(ev *evidence.Bundle, ptxFile *ptx.PtxFile, data []byte) {
+	ev.SetPTX(v.Options.FilePath, data)
+
+	proof := ptxFile.GetProof()
+	if proof == nil {
+		return
+	}
+
+	var pd struct {
+		PublicSignals []string `json:"publicSignals"`
+	}
+	if err := json.Unmarshal(proof.ProofData, &pd); err == nil {
+		ev.PublicSignals = pd.PublicSignals
+	}
+
+	// Fingerprint the key bytes themselves so the bundle pins the exact key.
+	// With a key set the key is only known once the proof has been checked.
+	if v.Options.KeySet != nil || v.Options.Discovery != nil {
+		return
+	}
+	if len(v.Options.VKData) > 0 {
+		ev.VerificationKey = &evidence.Key{
+			ID:     proof.GetVerificationKeyId(),
+			SHA256: evidence.Hash(v.Options.VKData),
+		}
+	} else if vkData, err := os.ReadFile(v.vkPath()); err == nil {
+		ev.VerificationKey = &evidence.Key{
+			ID:     proof.GetVerificationKeyId(),
+			Path:   v.vkPath(),
+			SHA256: evidence.Hash(vkData),
+		}
+	}
+}
+
+func newEvidenceLookup(l *dns.TXTLookup, start time.Time, elapsedMs float64, err error) evidence.Lookup {
+	el := evidence.Lookup{
+		Endpoint:    l.Source,
+		Format:      l.Format,
+		Time:        start.UTC(),
+		DurationMs:  elapsedMs,
+		ContentType: l.ContentType,
+		Response:    l.Response,
+		Records:     l.Records,
+	}
+	if err != nil {
+		el.Error = err.Error()
+	}
+	return el
+}
+
+// finishEvidence records the verdict and signs the bundle if a key is set
+func (v *PTXVerifier) finishEvidence(ev *evidence.Bundle, res *VerificationResult) error {
+	ev.FinishedAt = time.Now().UTC()
+	ev.Verdict = evidence.Verdict{
+		Success:  res.Success,
+		DNSValid: res.Dns.Valid,
+		ZKValid:  res.Zk.Valid,
+		Semantic: res.Zk.Semantic,
+		Errors:   append([]string(nil), res.Errors...),
+		Warnings: append([]string(nil), res.Warnings...),
+	}
+	if !res.Dns.Valid && res.Dns.Error != "" {
+		ev.Verdict.Errors = append(ev.Verdict.Errors, "DNS: "+res.Dns.Error)
+	}
+
+	if ks := v.keySet(); ks != nil && res.Zk.KeyID != "" {
+		for _, k := range ks.Keys {
+			if k.ID != res.Zk.KeyID {
+				continue
+			}
+			data, err := k.data(ks.fetcher())
+			if err != nil {
+				return err
+			}
+			ev.VerificationKey = &evidence.Key{ID: k.ID, Path: cmp.Or(k.Path, k.URL), SHA256: evidence.Hash(data)}
+		}
+	}
+
+	if v.Options.EvidenceKey != nil {
+		return ev.Sign(v.Options.EvidenceKey)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package verifier
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// DefaultMaxClockSkew is how far issued_at may lie in the future before a PTX
+// is rejected, to tolerate issuer clocks running ahead of the verifier
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// defaultNonceTTL is how long a nonce is remembered for tokens without an
+// expiration
+const defaultNonceTTL = 5 * time.Minute
+
+// DefaultMaxNonceTTL bounds how long a nonce is remembered, so that tokens
+// expiring in years do not hold nonce store entries for as long
+const DefaultMaxNonceTTL = 30 * 24 * time.Hour
+
+// validityPeriod is the issued-at / expiration pair of a PTX. Zero values mean
+// the bound is not set.
+type validityPeriod struct {
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// tokenValidity reads the typed issued_at / expires_at fields, falling back to
+// the legacy "expiration_timestamp" metadata claim. The metadata copy is bound
+// by the proof while the typed fields are not, so both must agree when set.
+func tokenValidity(ptxFile *ptx.PtxFile, meta map[string]interface{}) (validityPeriod, error) {
+	var vp validityPeriod
+
+	if ts := ptxFile.GetIssuedAt(); ts != nil {
+		if err := ts.CheckValid(); err != nil {
+			return vp, fmt.Errorf("Invalid issued_at: %w", err)
+		}
+		vp.IssuedAt = ts.AsTime()
+	}
+	if ts := ptxFile.GetExpiresAt(); ts != nil {
+		if err := ts.CheckValid(); err != nil {
+			return vp, fmt.Errorf("Invalid expires_at: %w", err)
+		}
+		vp.ExpiresAt = ts.AsTime()
+	}
+
+	if raw, ok := meta["expiration_timestamp"]; ok {
+		exp, ok := raw.(float64)
+		if !ok || math.IsNaN(exp) || math.IsInf(exp, 0) {
+			return vp, fmt.Errorf("Invalid metadata expiration_timestamp: %v", raw)
+		}
+		metaExp := time.Unix(int64(exp), 0).UTC()
+		if vp.ExpiresAt.IsZero() {
+			vp.ExpiresAt = metaExp
+		} else if vp.ExpiresAt.Unix() != metaExp.Unix() {
+			return vp, fmt.Errorf("expires_at (%s) does not match metadata expiration_timestamp (%s)",
+				vp.ExpiresAt.Format(time.RFC3339), metaExp.Format(time.RFC3339))
+		}
+	}
+
+	if !vp.IssuedAt.IsZero() && !vp.ExpiresAt.IsZero() && !vp.ExpiresAt.After(vp.IssuedAt) {
+		return vp, fmt.Errorf("expires_at (%s) is not after issued_at (%s)",
+			vp.ExpiresAt.Format(time.RFC3339), vp.IssuedAt.Format(time.RFC3339))
+	}
+	return vp, nil
+}
+
+// check returns the reasons the period rejects a token at now. A positive
+// maxLifetime also rejects tokens that expire later than maxLifetime from
+// now, or never.
+func (vp validityPeriod) check(now time.Time, skew, maxLifetime time.Duration) []string {
+	var errs []string
+	if maxLifetime > 0 {
+		if vp.ExpiresAt.IsZero() {
+			errs = append(errs, "PTX token does not expire (maximum lifetime "+maxLifetime.String()+")")
+		} else if vp.ExpiresAt.After(now.Add(maxLifetime)) {
+			errs = append(errs, "PTX token expires at "+vp.ExpiresAt.Format(time.RFC3339)+", beyond the maximum lifetime of "+maxLifetime.String())
+		}
+	}
+	if !vp.ExpiresAt.IsZero() && now.After(vp.ExpiresAt) {
+		errs = append(errs, "PTX token expired at "+vp.ExpiresAt.Format(time.RFC3339))
+	}
+	if !vp.IssuedAt.IsZero() && vp.IssuedAt.After(now.Add(skew)) {
+		errs = append(errs, "PTX token issued in the future ("+vp.IssuedAt.Format(time.RFC3339)+")")
+	}
+	return errs
+}
+
+// nonceExpiry is the time until which a nonce must be remembered: the
+// token's expiration, or defaultNonceTTL from now without one, at most maxTTL
+// from now. clamped reports whether maxTTL cut it short, in which case the
+// token can be replayed once the nonce is forgotten.
+func (vp validityPeriod) nonceExpiry(now time.Time, maxTTL time.Duration) (expiresAt time.Time, clamped bool) {
+	expiresAt = now.Add(defaultNonceTTL)
+	if !vp.ExpiresAt.IsZero() {
+		expiresAt = vp.ExpiresAt
+	}
+	if limit := now.Add(maxTTL); maxTTL > 0 && expiresAt.After(limit) {
+		return limit, true
+	}
+	return expiresAt, false
+}
@@ -0,0 +1,46 @@
+package verifier
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// applyExternalInputs places the public signals and signed metadata the
+// relying party supplied into ptxFile, which then verifies as if it carried
+// them. Values the PTX carries as well must match, so a holder cannot swap
+// them. It returns the failure code and message if they do not.
+func (v *PTXVerifier) applyExternalInputs(ptxFile *ptx.PtxFile) (ErrorCode, string) {
+	if meta := v.Options.ExternalMetadata; meta != "" {
+		if signed := ptxFile.GetSignedMetadata(); signed != "" && signed != meta {
+			return CodeMetadataInvalid, "Signed metadata differs from the expected metadata"
+		}
+		ptxFile.SignedMetadata = meta
+	}
+
+	w, err := proofdata.Parse(ptxFile.GetProof().GetProofData())
+	if err != nil {
+		// Proofs that cannot be parsed fail verification later on
+		return "", ""
+	}
+	external := v.Options.ExternalSignals
+	if external == nil {
+		if w.HasExternalSignals() {
+			return CodeProofInvalid, "PTX carries no public signals; the relying party must supply them (ExternalSignals)"
+		}
+		return "", ""
+	}
+	if !w.HasExternalSignals() && !slices.Equal(w.PublicSignals, external) {
+		return CodeProofInvalid, "Public signals differ from the expected signals"
+	}
+
+	w.PublicSignals, w.Signals = external, ""
+	data, err := json.Marshal(w)
+	if err != nil {
+		return CodeProofInvalid, "Failed to apply expected signals: " + err.Error()
+	}
+	ptxFile.Proof.ProofData = data
+	return "", ""
+}
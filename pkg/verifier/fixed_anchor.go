@@ -0,0 +1,61 @@
+package verifier
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// anchorMatcher recognizes the anchor of a PTX among the values published at
+// its anchor location
+type anchorMatcher interface {
+	MatchAny(values []string) bool
+}
+
+// keyMatcher recognizes a fixed anchor record publishing a key that signed
+// the anchor payload of the PTX
+type keyMatcher struct {
+	payload, signature []byte
+}
+
+// MatchAny implements anchorMatcher
+func (m keyMatcher) MatchAny(values []string) bool {
+	for _, value := range values {
+		if key, ok := utils.ParseFixedAnchorKey(value); ok && ed25519.Verify(key, m.payload, m.signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// fixedAnchor returns the fixed label anchor of a PTX file under domain. The
+// key is only known from the record, so the record value stands for the
+// signature; it identifies the token in the anchor cache.
+func fixedAnchor(ptxFile *ptx.PtxFile, domain, commitment, metaRaw string) (*utils.AnchorRecord, anchorMatcher, error) {
+	sig := ptxFile.GetAnchorSignature()
+	if len(sig) != ed25519.SignatureSize {
+		return nil, nil, errors.New("Fixed label anchor without a valid anchor signature")
+	}
+	// The signature covers the primary domain, and through the metadata any
+	// additional domains
+	payload, err := utils.AnchorSigningPayload(commitment, ptxFile.GetDohDetails().GetDomainName(), metaRaw)
+	if err != nil {
+		return nil, nil, errors.New("Anchor payload derivation failed: " + err.Error())
+	}
+	name, err := crypto.NormalizeDomain(domain)
+	if err != nil {
+		return nil, nil, errors.New("Hostname derivation failed: " + err.Error())
+	}
+
+	sum := sha256.Sum256(sig)
+	record := &utils.AnchorRecord{
+		Hostname: utils.FixedAnchorLabel + "." + name,
+		Value:    "anchor key for signature " + hex.EncodeToString(sum[:]),
+	}
+	return record, keyMatcher{payload: payload, signature: sig}, nil
+}
@@ -0,0 +1,44 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+)
+
+// CheckKeys loads every verification key opts selects, as a readiness check.
+// Keys chosen by discovery are only known per token and are not checked.
+func CheckKeys(opts VerificationOptions) error {
+	return NewPTXVerifier(opts).checkKeys()
+}
+
+func (v *PTXVerifier) checkKeys() error {
+	opts := v.Options
+	if ks := opts.KeySet; ks != nil {
+		for _, k := range ks.Keys {
+			if _, err := k.load(ks.fetcher()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(opts.VKData) > 0 {
+		_, err := cachedVKBytes(opts.VKData)
+		return err
+	}
+	if opts.Discovery != nil && opts.VKPath == "" {
+		return nil
+	}
+	_, err := cachedVK(v.vkPath())
+	return err
+}
+
+// CompileCircuit compiles the DoH circuit, or returns the process-wide
+// compilation if it already ran, and reports its number of constraints
+func CompileCircuit() (int, error) {
+	ccs, err := compiledCircuit(signals.DefaultVerificationKeyID)
+	if err != nil {
+		return 0, err
+	}
+	return ccs.GetNbConstraints(), nil
+}
@@ -0,0 +1,43 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// checkCircuitHash rejects a proof whose envelope declares a circuit hash
+// that none of its candidate keys was set up for. Keys that do not declare a
+// hash accept any.
+func (v *PTXVerifier) checkCircuitHash(keyID string, env *ptx.ProofEnvelope) error {
+	hash := env.GetCircuitHash()
+	ks := v.keySet()
+	if hash == "" || ks == nil {
+		return nil
+	}
+	var want []string
+	for _, k := range ks.Candidates(keyID, time.Now()) {
+		if k.CircuitHash == "" || k.CircuitHash == hash {
+			return nil
+		}
+		want = append(want, circuit.Identity(k.circuit(), k.CircuitHash))
+	}
+	if len(want) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the proof is for %s, the key set has keys for %s", circuit.Identity(env.GetCircuitId(), hash), strings.Join(want, ", "))
+}
+
+// localCircuitHash compares the circuit hash an envelope declares with the
+// circuit of circuitID as compiled by this build. It returns nil when they
+// match or the local circuit cannot be compiled.
+func localCircuitHash(circuitID string, env *ptx.ProofEnvelope) error {
+	local, err := circuitHash(circuitID)
+	if err != nil || local == env.GetCircuitHash() {
+		return nil
+	}
+	return fmt.Errorf("the proof is for %s, this verifier's circuit is %s", circuit.Identity(env.GetCircuitId(), env.GetCircuitHash()), circuit.Identity(circuitID, local))
+}
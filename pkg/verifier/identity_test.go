@@ -0,0 +1,46 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func TestCircuitIdentity(t *testing.T) {
+	env := ptxtest.New(t)
+	tok := env.Issue(fixture.Options{Seed: 1})
+
+	f, err := ptxloader.ParsePTX(tok.PTX, ptxloader.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := f.GetProof().GetEnvelope().GetCircuitHash()
+	if !circuit.IsHash(hash) {
+		t.Fatalf("prover wrote circuit hash %q", hash)
+	}
+
+	res := env.Verify(tok)
+	ptxtest.AssertAccepted(t, res)
+	if want := circuit.Identity(f.GetProof().GetVerificationKeyId(), hash); res.Zk.CircuitIdentity != want {
+		t.Errorf("CircuitIdentity = %q, want %q", res.Zk.CircuitIdentity, want)
+	}
+
+	withKeyHash := func(h string) func(*verifier.VerificationOptions) {
+		return func(o *verifier.VerificationOptions) {
+			o.KeySet = &verifier.KeySet{Keys: []verifier.KeyEntry{{ID: "2026", VK: tok.VK, CircuitHash: h}}}
+			o.VKData = nil
+		}
+	}
+	ptxtest.AssertAccepted(t, env.Verify(tok, withKeyHash(hash)))
+
+	res = env.Verify(tok, withKeyHash(strings.Repeat("0", 64)))
+	ptxtest.AssertRejected(t, res, verifier.CodeProofInvalid)
+	ptxtest.AssertError(t, res, "Circuit identity mismatch")
+}
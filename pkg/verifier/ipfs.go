@@ -0,0 +1,60 @@
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ipfs"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// verifyIPFSAnchor checks an IPFS anchor: its CID must address the anchor
+// document of the PTX, which the gateway must still serve
+func (v *PTXVerifier) verifyIPFSAnchor(ptxFile *ptx.PtxFile, a *ptx.IpfsAnchor, domain string, res AnchorResult) AnchorResult {
+	// The document always holds the commitment label record, a fixed label
+	// anchor has no per-token record to publish
+	record, _, err := anchorFor(ptxFile, domain, ptx.LabelMode_LABEL_COMMITMENT, v.Options.DNSMatchMode)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	doc := record.Document()
+	digest, err := ipfs.Digest(a.GetCid())
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if sum := sha256.Sum256(doc); !bytes.Equal(digest, sum[:]) {
+		res.Error = "CID does not address the anchor document (Expected: " + ipfs.CID(doc) + ")"
+		return res
+	}
+
+	client, err := v.anchorHTTP()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	gw := &ipfs.Gateway{URL: v.Options.IPFSGateway, HTTP: client}
+	res.Location = gw.URLFor(a.GetCid())
+	ctx, cancel := context.WithTimeout(v.context(), defaultAnchorTimeout)
+	defer cancel()
+	start := time.Now()
+	var data []byte
+	err = v.call(ctx, urlEndpoint("ipfs", res.Location), func(ctx context.Context) (err error) {
+		data, err = gw.Fetch(ctx, a.GetCid())
+		return err
+	})
+	res.FetchTimeMs = time.Since(start).Seconds() * 1000
+	if err != nil {
+		res.Error = "Fetch failed: " + err.Error()
+		return res
+	}
+	// Fetch checked the content against the CID, which addresses doc
+	res.Valid = bytes.Equal(data, doc)
+	if !res.Valid {
+		res.Error = "Gateway returned another anchor document"
+	}
+	return res
+}
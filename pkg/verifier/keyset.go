@@ -0,0 +1,216 @@
+package verifier
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/vk"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+)
+
+// KeySet holds several verification keys with validity windows so that a
+// trusted setup can be rotated without running two verifier deployments.
+// Proofs are accepted under any key that is valid at verification time.
+//
+// Key sets are loaded from JSON:
+//
+//	{
+//	  "keys": [
+//	    {"id": "2025", "path": "keys/2025.vk", "notAfter": "2026-02-01T00:00:00Z"},
+//	    {"id": "2026", "path": "keys/2026.vk", "notBefore": "2026-01-01T00:00:00Z"}
+//	  ]
+//	}
+//
+// Relative paths are resolved against the directory of the key set file.
+//
+// Instead of a path, an entry may give the https URL the issuer serves the key
+// at, pinned by hash or by an ed25519 signing key:
+//
+//	{"id": "2027", "url": "https://issuer.example/keys/sdv_poseidon_v1.vk", "sha256": "9f2c..."}
+type KeySet struct {
+	Keys []KeyEntry `json:"keys"`
+	// Fetcher downloads URL entries. Defaults to vk.DefaultFetcher().
+	Fetcher *vk.VKFetcher `json:"-"`
+}
+
+// KeyEntry is a single verification key in a KeySet
+type KeyEntry struct {
+	// ID names this key, e.g. the year of the setup ceremony
+	ID string `json:"id"`
+	// Circuit is the PTX verification key ID the key belongs to. Defaults to
+	// signals.DefaultVerificationKeyID.
+	Circuit string `json:"circuit,omitempty"`
+	// CircuitHash is the constraint system hash of the circuit the key was
+	// set up for (see circuit.Hash). Proofs declaring another hash are
+	// rejected as an identity mismatch before their pairing check.
+	CircuitHash string `json:"circuitHash,omitempty"`
+	// Path is the native verification key file
+	Path string `json:"path,omitempty"`
+	// VK is the serialized key, used instead of Path (base64 in JSON)
+	VK []byte `json:"vk,omitempty"`
+	// URL is an https location of the key, used instead of Path
+	URL string `json:"url,omitempty"`
+	// SHA256 pins the key fetched from URL (hex)
+	SHA256 string `json:"sha256,omitempty"`
+	// SignatureKey is an ed25519 public key (base64 in JSON) that must have
+	// signed the key fetched from URL, see vk.KeySource
+	SignatureKey []byte `json:"signatureKey,omitempty"`
+	// NotBefore and NotAfter bound the validity window. Zero means unbounded.
+	NotBefore time.Time `json:"notBefore,omitempty"`
+	NotAfter  time.Time `json:"notAfter,omitempty"`
+}
+
+// LoadKeySet reads a key set from a JSON file
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ks KeySet
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("failed to parse key set: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range ks.Keys {
+		if p := ks.Keys[i].Path; p != "" && !filepath.IsAbs(p) {
+			ks.Keys[i].Path = filepath.Join(dir, p)
+		}
+	}
+
+	if err := ks.Validate(); err != nil {
+		return nil, err
+	}
+	return &ks, nil
+}
+
+// Validate checks that every key has a unique ID, a key source, a pin for a
+// fetched key and a consistent window
+func (ks *KeySet) Validate() error {
+	if len(ks.Keys) == 0 {
+		return errors.New("key set is empty")
+	}
+
+	seen := map[string]bool{}
+	for _, k := range ks.Keys {
+		if k.ID == "" {
+			return errors.New("key set entry without id")
+		}
+		if seen[k.ID] {
+			return fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		seen[k.ID] = true
+
+		if k.Path == "" && len(k.VK) == 0 && k.URL == "" {
+			return fmt.Errorf("key %q has no path, vk or url", k.ID)
+		}
+		if k.URL != "" && k.SHA256 == "" && len(k.SignatureKey) == 0 {
+			// The key would be trusted on the strength of TLS alone
+			return fmt.Errorf("key %q: url requires a sha256 or signatureKey pin", k.ID)
+		}
+		if k.CircuitHash != "" && !circuit.IsHash(k.CircuitHash) {
+			return fmt.Errorf("key %q: circuitHash must be a lowercase hex SHA-256", k.ID)
+		}
+		if len(k.SignatureKey) > 0 && len(k.SignatureKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("key %q: signatureKey must be %d bytes", k.ID, ed25519.PublicKeySize)
+		}
+		if !k.NotBefore.IsZero() && !k.NotAfter.IsZero() && !k.NotAfter.After(k.NotBefore) {
+			return fmt.Errorf("key %q: notAfter must be after notBefore", k.ID)
+		}
+	}
+	return nil
+}
+
+// ReadKeys reads the key files of the set into memory, so that the set keeps
+// verifying with the keys as read even if the files are replaced
+func (ks *KeySet) ReadKeys() error {
+	for i := range ks.Keys {
+		k := &ks.Keys[i]
+		if len(k.VK) > 0 || k.URL != "" {
+			continue
+		}
+		data, err := os.ReadFile(k.Path)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		k.VK = data
+	}
+	return nil
+}
+
+// ValidAt reports whether the key may be used at t
+func (k KeyEntry) ValidAt(t time.Time) bool {
+	if !k.NotBefore.IsZero() && t.Before(k.NotBefore) {
+		return false
+	}
+	if !k.NotAfter.IsZero() && !t.Before(k.NotAfter) {
+		return false
+	}
+	return true
+}
+
+func (k KeyEntry) circuit() string {
+	if k.Circuit != "" {
+		return k.Circuit
+	}
+	return signals.DefaultVerificationKeyID
+}
+
+// Candidates returns the keys valid at t for a proof carrying the given
+// verification key ID. The ID may name a circuit, in which case every valid
+// key of that circuit is returned, or pin a single key by its own ID.
+func (ks *KeySet) Candidates(keyID string, t time.Time) []KeyEntry {
+	var out []KeyEntry
+	for _, k := range ks.Keys {
+		if (k.ID == keyID || k.circuit() == keyID) && k.ValidAt(t) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func (ks *KeySet) fetcher() *vk.VKFetcher {
+	if ks.Fetcher != nil {
+		return ks.Fetcher
+	}
+	return vk.DefaultFetcher()
+}
+
+// data returns the serialized key
+func (k KeyEntry) data(f *vk.VKFetcher) ([]byte, error) {
+	if len(k.VK) > 0 {
+		return k.VK, nil
+	}
+	if k.URL != "" {
+		key, err := f.Fetch(context.Background(), vk.KeySource{URL: k.URL, SHA256: k.SHA256, SignatureKey: k.SignatureKey})
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		return key.Data, nil
+	}
+	return os.ReadFile(k.Path)
+}
+
+// load returns the parsed key through the process-wide cache
+func (k KeyEntry) load(f *vk.VKFetcher) (*PreparedVK, error) {
+	if len(k.VK) > 0 || k.URL != "" {
+		data, err := k.data(f)
+		if err != nil {
+			return nil, err
+		}
+		return cachedVKBytes(data)
+	}
+	vk, err := cachedVK(k.Path)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", k.ID, err)
+	}
+	return vk, nil
+}
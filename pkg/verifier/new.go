@@ -0,0 +1,185 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+)
+
+// Verifier verifies PTX files under one configuration. Unlike PTXVerifier,
+// which is built for a single PTX and takes its options as given, New
+// validates the configuration once and fixes everything a verification
+// would otherwise resolve from process state: key files are resolved
+// against the working directory at construction and loaded, the nonce store
+// is opened and the anchor cache is its own.
+//
+// A Verifier is safe for concurrent use by multiple goroutines. Close it to
+// release the nonce store.
+type Verifier struct {
+	opts VerificationOptions
+	// keyDir is the directory the default key files are read from
+	keyDir string
+	// nonces is the nonce store opened by New, if any
+	nonces nonceStore
+}
+
+// New validates config and returns a Verifier. config must not set the PTX
+// to verify (FilePath, PTXData), which is passed to Verify. The verification
+// key must exist and is loaded by New.
+func New(config VerificationOptions) (*Verifier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	vf := &Verifier{opts: config}
+
+	if config.VKPath != "" {
+		abs, err := filepath.Abs(config.VKPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve vk path: %w", err)
+		}
+		vf.opts.VKPath = abs
+	} else {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve key directory: %w", err)
+		}
+		vf.keyDir = wd
+	}
+	if err := vf.loadKeys(); err != nil {
+		return nil, err
+	}
+
+	if vf.opts.AnchorCache == nil {
+		vf.opts.AnchorCache = dns.NewAnchorCache(dns.DefaultAnchorMaxAge)
+	}
+	if vf.opts.RedisURL != "" || vf.opts.NonceDB != "" {
+		st, err := openNonceStore(vf.opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to nonce store: %w", err)
+		}
+		vf.nonces = st
+		vf.opts.NonceStore = st
+		vf.opts.RedisURL, vf.opts.NonceDB = "", ""
+	}
+	return vf, nil
+}
+
+// Validate rejects options that contradict each other or cannot work. The
+// PTX inputs (FilePath, PTXData) are not checked.
+func (o VerificationOptions) Validate() error {
+	keySources := 0
+	for _, set := range []bool{o.VKPath != "", len(o.VKData) > 0, o.KeySet != nil} {
+		if set {
+			keySources++
+		}
+	}
+	if keySources > 1 {
+		return errors.New("VKPath, VKData and KeySet are mutually exclusive")
+	}
+	if o.KeySet != nil {
+		if err := o.KeySet.Validate(); err != nil {
+			return fmt.Errorf("key set: %w", err)
+		}
+	}
+
+	nonceStores := 0
+	for _, set := range []bool{o.RedisURL != "", o.NonceDB != "", o.NonceStore != nil} {
+		if set {
+			nonceStores++
+		}
+	}
+	if nonceStores > 1 {
+		return errors.New("RedisURL, NonceDB and NonceStore are mutually exclusive")
+	}
+	if o.TrackNullifiers && nonceStores == 0 {
+		return errors.New("TrackNullifiers requires a nonce store (RedisURL, NonceDB or NonceStore)")
+	}
+	if o.EvidenceKey != nil && !o.Evidence {
+		return errors.New("EvidenceKey is set without Evidence")
+	}
+	if o.SOCKSProxy != "" {
+		if _, err := parseSOCKSProxy(o.SOCKSProxy); err != nil {
+			return err
+		}
+	}
+	if o.ProofPolicy != nil {
+		if err := o.ProofPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Resilience != nil {
+		if err := o.Resilience.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range []struct {
+		name  string
+		value int64
+	}{
+		{"DNSRetries", int64(o.DNSRetries)},
+		{"DNSRetryBackoff", int64(o.DNSRetryBackoff)},
+		{"MaxClockSkew", int64(o.MaxClockSkew)},
+		{"MaxTokenLifetime", int64(o.MaxTokenLifetime)},
+		{"MaxNonceTTL", int64(o.MaxNonceTTL)},
+		{"MaxDuration", int64(o.MaxDuration)},
+	} {
+		if d.value < 0 {
+			return fmt.Errorf("%s must not be negative", d.name)
+		}
+	}
+	return nil
+}
+
+// loadKeys loads the configured verification keys into the process-wide key
+// cache, so that a missing or invalid key fails New rather than Verify
+func (vf *Verifier) loadKeys() error {
+	o := vf.opts
+	switch {
+	case len(o.VKData) > 0:
+		_, err := cachedVKBytes(o.VKData)
+		return err
+	case o.KeySet != nil:
+		// Held in memory, so replacing the files does not change the keys
+		return o.KeySet.ReadKeys()
+	case o.Discovery != nil && o.VKPath == "":
+		return nil
+	}
+	path := o.VKPath
+	if path == "" {
+		path = filepath.Join(vf.keyDir, nativeVKPath)
+	}
+	_, err := cachedVK(path)
+	return err
+}
+
+// Verify verifies the PTX file ptxData
+func (vf *Verifier) Verify(ptxData []byte) (*VerificationResult, error) {
+	if len(ptxData) == 0 {
+		return nil, errors.New("failed to load PTX file: empty input")
+	}
+	return vf.verifier(VerificationOptions{PTXData: ptxData}).Verify()
+}
+
+// VerifyFile verifies the PTX file at path
+func (vf *Verifier) VerifyFile(path string) (*VerificationResult, error) {
+	return vf.verifier(VerificationOptions{FilePath: path}).Verify()
+}
+
+// verifier returns a PTXVerifier for one verification of the input in in
+func (vf *Verifier) verifier(in VerificationOptions) *PTXVerifier {
+	opts := vf.opts
+	opts.FilePath, opts.PTXData = in.FilePath, in.PTXData
+	return &PTXVerifier{Options: opts, keyDir: vf.keyDir}
+}
+
+// Close releases the nonce store opened by New
+func (vf *Verifier) Close() error {
+	if vf.nonces == nil {
+		return nil
+	}
+	return vf.nonces.Close()
+}
@@ -0,0 +1,148 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	for name, opts := range map[string]VerificationOptions{
+		"two key sources":     {VKPath: "native.vk", VKData: []byte{1}},
+		"two nonce stores":    {RedisURL: "redis://localhost", NonceDB: "nonces.db"},
+		"untracked replay":    {TrackNullifiers: true},
+		"unused signing key":  {EvidenceKey: make([]byte, 64)},
+		"negative retries":    {DNSRetries: -1},
+		"http proxy as SOCKS": {SOCKSProxy: "http://127.0.0.1:8080"},
+		"unpinned key url":    {KeySet: &KeySet{Keys: []KeyEntry{{ID: "2027", URL: "https://issuer.example/native.vk"}}}},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	for _, k := range []KeyEntry{
+		{ID: "2027", URL: "https://issuer.example/native.vk", SHA256: strings.Repeat("ab", 32)},
+		{ID: "2027", URL: "https://issuer.example/native.vk", SignatureKey: make([]byte, ed25519.PublicKeySize)},
+	} {
+		if err := (VerificationOptions{KeySet: &KeySet{Keys: []KeyEntry{k}}}).Validate(); err != nil {
+			t.Errorf("pinned key url: %v", err)
+		}
+	}
+	if _, err := New(VerificationOptions{VKPath: filepath.Join(t.TempDir(), "missing.vk")}); err == nil {
+		t.Error("New accepted a missing key file")
+	}
+}
+
+func TestVerifierConcurrent(t *testing.T) {
+	f, err := fixture.Generate(fixture.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(keyDir, nativeVKPath), f.VK, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(keyDir)
+	vf, err := New(VerificationOptions{DNSResolver: f.Resolver()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vf.Close()
+	// The key directory was fixed by New
+	t.Chdir(t.TempDir())
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := vf.Verify(f.PTX)
+			if err != nil {
+				errs <- err.Error()
+			} else if !res.Success {
+				errs <- res.Errors[0]
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Error(e)
+	}
+}
+
+// recordingResolver records the hostnames it is asked for
+type recordingResolver struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *recordingResolver) LookupTXT(ctx context.Context, hostname string) (*dns.TXTLookup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = append(r.names, hostname)
+	return &dns.TXTLookup{}, nil
+}
+
+func TestWarmup(t *testing.T) {
+	f, err := fixture.Generate(fixture.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := &recordingResolver{}
+	vf, err := New(VerificationOptions{VKData: f.VK, DNSResolver: resolver})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vf.Close()
+
+	if err := vf.Warmup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(resolver.names, []string{warmupHostname}) {
+		t.Errorf("resolver asked for %v", resolver.names)
+	}
+	ccsMu.Lock()
+	c, ok := ccsCached[signals.DefaultVerificationKeyID]
+	ccsMu.Unlock()
+	if !ok || c.ccs == nil {
+		t.Error("circuit not compiled")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Warmup(ctx, VerificationOptions{VKData: f.VK, DNSResolver: resolver}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled warm-up returned %v", err)
+	}
+}
+
+func TestMissingKeyNeverSetUp(t *testing.T) {
+	f, err := fixture.Generate(fixture.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	res, err := NewPTXVerifier(VerificationOptions{PTXData: f.PTX, DNSResolver: f.Resolver()}).Verify()
+	if err == nil && res.Success {
+		t.Fatal("verified without a key")
+	}
+	if _, err := os.Stat(nativeVKPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a key was written to %s: %v", nativeVKPath, err)
+	}
+	if err := CheckKeys(VerificationOptions{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("CheckKeys = %v", err)
+	}
+}
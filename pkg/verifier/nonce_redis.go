@@ -0,0 +1,63 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/nonce"
+)
+
+var (
+	// nonceDBs holds the nonce databases opened by this process. A bbolt
+	// file cannot be opened twice, so verifications share them.
+	nonceDBsMu sync.Mutex
+	nonceDBs   = map[string]*nonce.BoltStore{}
+)
+
+// openNonceStore opens the nonce database of opts, or connects to its Redis
+// nonce store, namespacing keys with NonceKeyPrefix
+func openNonceStore(opts VerificationOptions) (nonceStore, error) {
+	if opts.NonceDB != "" {
+		nonceDBsMu.Lock()
+		defer nonceDBsMu.Unlock()
+		db, ok := nonceDBs[opts.NonceDB]
+		if !ok {
+			var err error
+			if db, err = nonce.OpenBolt(opts.NonceDB, 0); err != nil {
+				return nil, err
+			}
+			nonceDBs[opts.NonceDB] = db
+		}
+		return sharedNonceDB{db, opts.NonceKeyPrefix}, nil
+	}
+
+	st, err := nonce.NewNonceStore(opts.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	st.Prefix = opts.NonceKeyPrefix
+	return st, nil
+}
+
+// sharedNonceDB is a namespace of a process-wide nonce database, which stays
+// open when the verification closes it
+type sharedNonceDB struct {
+	db     *nonce.BoltStore
+	prefix string
+}
+
+func (s sharedNonceDB) CheckAndSetNonce(n string, expiresAt time.Time) (bool, error) {
+	return s.db.CheckAndSetNonce(s.prefix+n, expiresAt)
+}
+
+func (s sharedNonceDB) CheckAndSetNonces(nonces []string, expiresAt time.Time) (int, error) {
+	keys := make([]string, len(nonces))
+	for i, n := range nonces {
+		keys[i] = s.prefix + n
+	}
+	return s.db.CheckAndSetNonces(keys, expiresAt)
+}
+
+func (sharedNonceDB) Close() error { return nil }
@@ -0,0 +1,156 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordingStore records the expiry passed by each call site
+type recordingStore struct {
+	*ptxtest.MemoryNonceStore
+	single, multi []time.Time
+}
+
+func (s *recordingStore) CheckAndSetNonce(nonce string, expiresAt time.Time) (bool, error) {
+	s.single = append(s.single, expiresAt)
+	return s.MemoryNonceStore.CheckAndSetNonce(nonce, expiresAt)
+}
+
+func (s *recordingStore) CheckAndSetNonces(nonces []string, expiresAt time.Time) (int, error) {
+	s.multi = append(s.multi, expiresAt)
+	return s.MemoryNonceStore.CheckAndSetNonces(nonces, expiresAt)
+}
+
+func TestNonceExpiry(t *testing.T) {
+	env := ptxtest.New(t)
+	now := time.Now()
+	issue := func(seed int64, expiresIn time.Duration) *ptxtest.Token {
+		meta := map[string]interface{}{"role": "validator", "nonce": "n"}
+		if expiresIn > 0 {
+			meta["expiration_timestamp"] = float64(now.Add(expiresIn).Unix())
+		}
+		return env.Issue(fixture.Options{Seed: seed, Metadata: meta, IssuedAt: now.Add(-time.Minute)})
+	}
+	hour, year := issue(1, time.Hour), issue(2, 365*24*time.Hour)
+	never := issue(3, 0)
+
+	tests := []struct {
+		name   string
+		tok    *ptxtest.Token
+		track  bool
+		maxTTL time.Duration
+		want   time.Duration
+		warn   bool
+	}{
+		{name: "expiration", tok: hour, want: time.Hour},
+		{name: "no expiration", tok: never, want: 5 * time.Minute},
+		{name: "clamped", tok: year, want: verifier.DefaultMaxNonceTTL, warn: true},
+		{name: "custom max", tok: hour, maxTTL: 10 * time.Minute, want: 10 * time.Minute, warn: true},
+		{name: "nullifiers", tok: hour, track: true, want: time.Hour},
+		{name: "nullifiers clamped", tok: year, track: true, maxTTL: 24 * time.Hour, want: 24 * time.Hour, warn: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &recordingStore{MemoryNonceStore: ptxtest.NewMemoryNonceStore()}
+			res := env.Verify(tt.tok, func(o *verifier.VerificationOptions) {
+				o.NonceStore = st
+				o.TrackNullifiers = tt.track
+				o.MaxNonceTTL = tt.maxTTL
+			})
+			ptxtest.AssertAccepted(t, res)
+
+			calls := st.single
+			if tt.track {
+				calls = st.multi
+			}
+			if len(calls) != 1 || len(st.single)+len(st.multi) != 1 {
+				t.Fatalf("single calls %d, multi calls %d", len(st.single), len(st.multi))
+			}
+			if got := calls[0].Sub(now); got < tt.want-time.Minute || got > tt.want+time.Minute {
+				t.Errorf("nonce kept for %s, want %s", got, tt.want)
+			}
+			if warned := len(res.Warnings) > 0; warned != tt.warn {
+				t.Errorf("warnings %q, want warning %v", res.Warnings, tt.warn)
+			}
+		})
+	}
+}
+
+func TestMaxTokenLifetime(t *testing.T) {
+	env := ptxtest.New(t)
+	now := time.Now()
+	meta := map[string]interface{}{"role": "validator", "expiration_timestamp": float64(now.Add(48 * time.Hour).Unix())}
+	tok := env.Issue(fixture.Options{Metadata: meta, IssuedAt: now.Add(-time.Minute)})
+
+	lifetime := func(d time.Duration) func(*verifier.VerificationOptions) {
+		return func(o *verifier.VerificationOptions) { o.MaxTokenLifetime = d }
+	}
+	ptxtest.AssertAccepted(t, env.Verify(tok, lifetime(72*time.Hour)))
+	ptxtest.AssertRejected(t, env.Verify(tok, lifetime(24*time.Hour)), verifier.CodeTokenValidity)
+
+	forever := env.Issue(fixture.Options{Seed: 1, IssuedAt: now.Add(-time.Minute)})
+	ptxtest.AssertRejected(t, env.Verify(forever, lifetime(72*time.Hour)), verifier.CodeTokenValidity)
+}
+
+// TestForgedProofKeepsNullifier presents the public signals of a token with
+// the proof of another one. Nullifier hashes are public, so such a PTX must
+// not consume the nullifier or nonce of the holder.
+func TestForgedProofKeepsNullifier(t *testing.T) {
+	env := ptxtest.New(t)
+	meta := map[string]interface{}{"role": "validator", "nonce": "victim"}
+	tok := env.Issue(fixture.Options{Seed: 1, Metadata: meta})
+	other := env.Issue(fixture.Options{Seed: 2})
+
+	parse := func(data []byte) (*proofdata.Wrapper, func(*proofdata.Wrapper) []byte) {
+		f, err := ptxloader.ParsePTX(data, ptxloader.LoadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := proofdata.Parse(f.GetProof().GetProofData())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w, func(w *proofdata.Wrapper) []byte {
+			if f.Proof.ProofData, err = json.Marshal(w); err != nil {
+				t.Fatal(err)
+			}
+			payload, err := proto.Marshal(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return append(append([]byte{}, data[:5]...), payload...)
+		}
+	}
+	w, encode := parse(tok.PTX)
+	otherW, _ := parse(other.PTX)
+	w.Proof, w.ProofHex, w.ProofBase64 = otherW.Proof, otherW.ProofHex, otherW.ProofBase64
+	forged := *tok
+	forged.PTX = encode(w)
+
+	for name, opt := range map[string]func(*verifier.VerificationOptions){
+		"default":    func(o *verifier.VerificationOptions) { o.TrackNullifiers = true },
+		"StrictMode": func(o *verifier.VerificationOptions) { o.TrackNullifiers, o.StrictMode = true, true },
+	} {
+		t.Run(name, func(t *testing.T) {
+			env.Nonce.Reset()
+			res := env.Verify(&forged, opt)
+			ptxtest.AssertRejected(t, res, verifier.CodeProofInvalid)
+			if env.Nonce.Seen(verifier.NullifierKeyPrefix+res.Details.NullifierHash) || env.Nonce.Seen("victim") {
+				t.Fatal("forged proof consumed the nullifier")
+			}
+
+			ptxtest.AssertAccepted(t, env.Verify(tok, opt))
+			ptxtest.AssertRejected(t, env.Verify(tok, opt), verifier.CodeNullifierReplayed)
+		})
+	}
+}
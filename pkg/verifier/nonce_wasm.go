@@ -0,0 +1,12 @@
+//go:build js && wasm
+
+package verifier
+
+import "errors"
+
+// openNonceStore always fails: there are no raw TCP sockets for Redis nor
+// files for a nonce database in js/wasm. Replay protection has to be
+// enforced by the host.
+func openNonceStore(VerificationOptions) (nonceStore, error) {
+	return nil, errors.New("nonce store is not available in js/wasm builds")
+}
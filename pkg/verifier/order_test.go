@@ -0,0 +1,53 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDNSAfterProof(t *testing.T) {
+	env := ptxtest.New(t)
+	tok := env.Issue(fixture.Options{Seed: 1})
+
+	// A proof system the verifier skips leaves the commitment unverified
+	f, err := ptxloader.ParsePTX(tok.PTX, ptxloader.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Proof.ProofSystem = ptx.ProofSystem_PLONK
+	payload, err := proto.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped := *tok
+	skipped.PTX = append(append([]byte{}, tok.PTX[:5]...), payload...)
+
+	res := env.Verify(&skipped)
+	if !res.Zk.Skipped || res.Dns.DerivedHostname != tok.Anchor.Hostname || env.DoH.Queries() == 0 {
+		t.Fatalf("without DNSAfterProof the anchor should be looked up: %+v", res)
+	}
+
+	for name, opt := range map[string]func(*verifier.VerificationOptions){
+		"DNSAfterProof": func(o *verifier.VerificationOptions) { o.DNSAfterProof = true },
+		"StrictMode":    func(o *verifier.VerificationOptions) { o.StrictMode = true },
+	} {
+		t.Run(name, func(t *testing.T) {
+			queries := env.DoH.Queries()
+			res := env.Verify(&skipped, opt)
+			ptxtest.AssertRejected(t, res, verifier.CodeProofInvalid)
+			if env.DoH.Queries() != queries || res.Dns.DerivedHostname != "" {
+				t.Errorf("anchor of an unverified proof looked up: %+v", res.Dns)
+			}
+
+			ptxtest.AssertAccepted(t, env.Verify(tok, opt))
+		})
+	}
+}
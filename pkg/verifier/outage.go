@@ -0,0 +1,84 @@
+package verifier
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+)
+
+// DNSOutage records how an anchor check was decided while the DoH resolver
+// could not be reached
+type DNSOutage struct {
+	// Policy is the dns.OutagePolicy that applied
+	Policy string `json:"policy"`
+	// Accepted is set when the anchor was accepted without being found in
+	// DNS
+	Accepted bool `json:"accepted"`
+	// CachedAt is when the anchor was last found in DNS, for anchors
+	// accepted from the cache
+	CachedAt *time.Time `json:"cachedAt,omitempty"`
+}
+
+// DNSOutageCounts counts anchor checks decided by the outage policy since
+// the process started
+type DNSOutageCounts struct {
+	Rejected       uint64 `json:"rejected"`
+	AcceptedOpen   uint64 `json:"acceptedOpen"`
+	AcceptedCached uint64 `json:"acceptedCached"`
+}
+
+var outageRejected, outageAcceptedOpen, outageAcceptedCached atomic.Uint64
+
+// DNSOutageStats returns the process-wide outage decision counters
+func DNSOutageStats() DNSOutageCounts {
+	return DNSOutageCounts{
+		Rejected:       outageRejected.Load(),
+		AcceptedOpen:   outageAcceptedOpen.Load(),
+		AcceptedCached: outageAcceptedCached.Load(),
+	}
+}
+
+func (v *PTXVerifier) anchorCache() *dns.AnchorCache {
+	if v.Options.AnchorCache != nil {
+		return v.Options.AnchorCache
+	}
+	return dns.DefaultAnchorCache()
+}
+
+// outagePolicy is the policy in effect. Strict mode always fails closed.
+func (v *PTXVerifier) outagePolicy() dns.OutagePolicy {
+	if v.Options.StrictMode {
+		return dns.OutageFailClosed
+	}
+	return v.Options.DNSOutagePolicy
+}
+
+// decideOutage applies the outage policy to a DNS check in which no lookup
+// got an answer
+func (v *PTXVerifier) decideOutage(res *DnsResult, hostname, expected string) {
+	policy := v.outagePolicy()
+	outage := &DNSOutage{Policy: policy.String()}
+	res.Outage = outage
+
+	switch policy {
+	case dns.OutageFailOpen:
+		outage.Accepted = true
+	case dns.OutageAcceptIfCached:
+		if seen, ok := v.anchorCache().Lookup(hostname, expected); ok {
+			outage.Accepted = true
+			outage.CachedAt = &seen
+		}
+	}
+
+	switch {
+	case !outage.Accepted:
+		outageRejected.Add(1)
+		return
+	case outage.CachedAt != nil:
+		outageAcceptedCached.Add(1)
+	default:
+		outageAcceptedOpen.Add(1)
+	}
+	res.Valid = true
+}
@@ -0,0 +1,142 @@
+package verifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// PolicyFunc is a relying-party acceptance rule. It runs after every
+// cryptographic check has passed and receives the signed metadata claims and
+// the verified details. Returning an error rejects the PTX.
+type PolicyFunc func(claims map[string]interface{}, details VerificationDetails) error
+
+// AllPolicies returns a PolicyFunc that requires every fn to accept
+func AllPolicies(fns ...PolicyFunc) PolicyFunc {
+	return func(claims map[string]interface{}, details VerificationDetails) error {
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			if err := fn(claims, details); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Policy is a declarative set of acceptance rules. Every non-empty rule must
+// hold. It is loaded from JSON:
+//
+//	{
+//	  "trustMethods": ["DOH"],
+//	  "domainSuffixes": [".bank"],
+//	  "claims": {"role": "validator"}
+//	}
+type Policy struct {
+	// TrustMethods lists the accepted trust methods by name, e.g. "DOH" or
+	// "well-known" (see ptx.ParseTrustMethod)
+	TrustMethods []string `json:"trustMethods,omitempty"`
+	// DomainSuffixes lists accepted domain suffixes. ".bank" and "bank" both
+	// match "example.bank" and "bank" itself.
+	DomainSuffixes []string `json:"domainSuffixes,omitempty"`
+	// Claims maps metadata claims to the exact value they must carry
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+// LoadPolicy reads a policy from a JSON file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks that every trust method name is known
+func (p *Policy) Validate() error {
+	for _, name := range p.TrustMethods {
+		if _, err := ptx.ParseTrustMethod(name); err != nil {
+			return fmt.Errorf("policy: %w", err)
+		}
+	}
+	for _, s := range p.DomainSuffixes {
+		if strings.Trim(s, ".") == "" {
+			return errors.New("policy: empty domain suffix")
+		}
+	}
+	return nil
+}
+
+// Func returns the policy as a PolicyFunc
+func (p *Policy) Func() PolicyFunc {
+	return func(claims map[string]interface{}, details VerificationDetails) error {
+		if len(p.TrustMethods) > 0 && !p.allowsTrustMethod(details.TrustMethod) {
+			return fmt.Errorf("trust method %s not allowed", trustMethodName(details.TrustMethod))
+		}
+
+		if len(p.DomainSuffixes) > 0 && !p.allowsDomain(details.Fqdn) {
+			return fmt.Errorf("domain %q not allowed", details.Fqdn)
+		}
+
+		for name, want := range p.Claims {
+			got, ok := claims[name]
+			if !ok {
+				return fmt.Errorf("claim %q missing", name)
+			}
+			if !reflect.DeepEqual(got, want) {
+				return fmt.Errorf("claim %q has value %v, want %v", name, got, want)
+			}
+		}
+		return nil
+	}
+}
+
+func (p *Policy) allowsTrustMethod(method string) bool {
+	got, err := ptx.ParseTrustMethod(method)
+	if err != nil {
+		return false
+	}
+	for _, name := range p.TrustMethods {
+		if want, err := ptx.ParseTrustMethod(name); err == nil && want == got {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) allowsDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, s := range p.DomainSuffixes {
+		s = strings.ToLower(strings.Trim(s, "."))
+		if domain == s || strings.HasSuffix(domain, "."+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustMethodName maps the numeric TrustMethod of VerificationDetails to its
+// enum name
+func trustMethodName(method string) string {
+	n, err := strconv.Atoi(method)
+	if err != nil {
+		return method
+	}
+	return ptx.TrustMethod(n).String()
+}
@@ -0,0 +1,129 @@
+package verifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// The native verification hot path reuses as much as possible between calls:
+// the compiled circuit and prepared verification keys (see PreparedVK) live
+// for the lifetime of the process (see vk_file.go for keys loaded from disk),
+// while proofs, public witnesses and decode buffers are recycled
+// through sync.Pools. This matters in server mode where Verify is called at a
+// high rate with the same key.
+
+var (
+	vkCacheMu sync.Mutex
+	vkCache   = map[string]*PreparedVK{}
+)
+
+// cachedVKBytes parses and prepares a serialized verification key, caching it
+// by content hash so callers passing the same bytes on every request parse it
+// once
+func cachedVKBytes(data []byte) (*PreparedVK, error) {
+	sum := sha256.Sum256(data)
+	key := "sha256:" + hex.EncodeToString(sum[:])
+
+	vkCacheMu.Lock()
+	defer vkCacheMu.Unlock()
+
+	if vk, ok := vkCache[key]; ok {
+		return vk, nil
+	}
+
+	vk, err := ParsePreparedVK(data)
+	if err != nil {
+		return nil, err
+	}
+	vkCache[key] = vk
+	return vk, nil
+}
+
+var proofPool = sync.Pool{
+	New: func() any { return groth16.NewProof(ecc.BN254) },
+}
+
+var decodeBufferPool = sync.Pool{
+	New: func() any { return new([]byte) },
+}
+
+// getDecodeBuffer returns a pooled byte slice of length n
+func getDecodeBuffer(n int) *[]byte {
+	buf := decodeBufferPool.Get().(*[]byte)
+	if cap(*buf) < n {
+		*buf = make([]byte, n)
+	}
+	*buf = (*buf)[:n]
+	return buf
+}
+
+func putDecodeBuffer(buf *[]byte) {
+	decodeBufferPool.Put(buf)
+}
+
+// publicWitness is a reusable public-only witness. vec aliases the witness'
+// underlying vector so values can be set in place.
+type publicWitness struct {
+	w   witness.Witness
+	vec fr.Vector
+}
+
+// publicWitnessPools holds one pool per number of public inputs, so that
+// circuits with different layouts do not share witnesses
+var (
+	publicWitnessPoolsMu sync.Mutex
+	publicWitnessPools   = map[int]*sync.Pool{}
+)
+
+func publicWitnessPool(n int) *sync.Pool {
+	publicWitnessPoolsMu.Lock()
+	defer publicWitnessPoolsMu.Unlock()
+	p, ok := publicWitnessPools[n]
+	if !ok {
+		p = &sync.Pool{}
+		publicWitnessPools[n] = p
+	}
+	return p
+}
+
+// getPublicWitness returns a pooled public witness with n public inputs,
+// allocating a new one if the pool is empty
+func getPublicWitness(n int) (*publicWitness, error) {
+	if pw, ok := publicWitnessPool(n).Get().(*publicWitness); ok {
+		return pw, nil
+	}
+
+	w, err := witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	values := make(chan any, n)
+	for i := 0; i < n; i++ {
+		values <- 0
+	}
+	close(values)
+	if err := w.Fill(n, 0, values); err != nil {
+		return nil, err
+	}
+
+	vec, ok := w.Vector().(fr.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unexpected witness vector type %T", w.Vector())
+	}
+	if len(vec) != n {
+		return nil, fmt.Errorf("unexpected public witness length %d", len(vec))
+	}
+
+	return &publicWitness{w: w, vec: vec}, nil
+}
+
+func putPublicWitness(pw *publicWitness) {
+	publicWitnessPool(len(pw.vec)).Put(pw)
+}
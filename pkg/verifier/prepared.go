@@ -0,0 +1,143 @@
+package verifier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+var (
+	errPairingCheckFailed = errors.New("pairing doesn't match")
+	errProofSubgroup      = errors.New("points in the proof are not in the correct subgroup")
+)
+
+// lines holds the Miller loop line evaluations of a fixed G2 point
+type lines = [2][len(bn254.LoopCounter)]bn254.LineEvaluationAff
+
+// PreparedVK is a BN254 Groth16 verification key prepared for repeated
+// verification. Verifying a proof checks
+//
+//	e(A, B) · e(C, -[δ]₂) · e(Σ xᵢ·[Kᵢ]₁, -[γ]₂) = e([α]₁, [β]₂)
+//
+// gnark computes e(α, β) once per key but evaluates the Miller loop lines of
+// -[δ]₂ and -[γ]₂ on every call. PreparedVK precomputes those lines as well,
+// so only the line for the proof's own B point is computed per verification.
+// The native verify path caches keys in this form.
+type PreparedVK struct {
+	vk        *groth16bn254.VerifyingKey
+	alphaBeta bn254.GT
+	deltaNeg  lines
+	gammaNeg  lines
+}
+
+// PrepareVK precomputes the pairing data of vk. Keys with Pedersen commitments
+// are accepted but verified through gnark, since their check depends on the
+// proof.
+func PrepareVK(vk groth16.VerifyingKey) (*PreparedVK, error) {
+	bvk, ok := vk.(*groth16bn254.VerifyingKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported verification key type %T (only BN254 is supported)", vk)
+	}
+	if len(bvk.G1.K) == 0 {
+		return nil, errors.New("verification key has no public input points")
+	}
+
+	alphaBeta, err := bn254.Pair([]bn254.G1Affine{bvk.G1.Alpha}, []bn254.G2Affine{bvk.G2.Beta})
+	if err != nil {
+		return nil, err
+	}
+	var deltaNeg, gammaNeg bn254.G2Affine
+	deltaNeg.Neg(&bvk.G2.Delta)
+	gammaNeg.Neg(&bvk.G2.Gamma)
+
+	return &PreparedVK{
+		vk:        bvk,
+		alphaBeta: alphaBeta,
+		deltaNeg:  bn254.PrecomputeLines(deltaNeg),
+		gammaNeg:  bn254.PrecomputeLines(gammaNeg),
+	}, nil
+}
+
+// ParsePreparedVK reads a serialized BN254 verification key and prepares it
+func ParsePreparedVK(data []byte) (*PreparedVK, error) {
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to read vk: %w", err)
+	}
+	return PrepareVK(vk)
+}
+
+// VerifyingKey returns the underlying key
+func (p *PreparedVK) VerifyingKey() groth16.VerifyingKey {
+	return p.vk
+}
+
+// Verify checks proof against a public witness. It accepts exactly the proofs
+// groth16.Verify accepts.
+func (p *PreparedVK) Verify(proof groth16.Proof, publicWitness witness.Witness) error {
+	return p.verify(proof, publicWitness, nil)
+}
+
+// verify is Verify copying the fixed lines into scratch, if it has room for
+// them, instead of a new slice. MillerLoopFixedQ overwrites the lines it is
+// given, so they must be copied on every call.
+func (p *PreparedVK) verify(proof groth16.Proof, publicWitness witness.Witness, scratch []lines) error {
+	if len(p.vk.CommitmentKeys) > 0 || len(p.vk.PublicAndCommitmentCommitted) > 0 {
+		return groth16.Verify(proof, p.vk, publicWitness)
+	}
+	publicInputs, ok := publicWitness.Vector().(fr.Vector)
+	if !ok {
+		return errors.New("public witness is not a BN254 vector")
+	}
+
+	bproof, ok := proof.(*groth16bn254.Proof)
+	if !ok {
+		return fmt.Errorf("unsupported proof type %T (only BN254 is supported)", proof)
+	}
+	if len(publicInputs) != len(p.vk.G1.K)-1 {
+		return fmt.Errorf("invalid witness size, got %d, expected %d (public - ONE_WIRE)", len(publicInputs), len(p.vk.G1.K)-1)
+	}
+	if len(bproof.Commitments) > 0 {
+		return errors.New("proof has commitments the verification key does not expect")
+	}
+	if !bproof.Ar.IsInSubGroup() || !bproof.Krs.IsInSubGroup() || !bproof.Bs.IsInSubGroup() {
+		return errProofSubgroup
+	}
+
+	// Σ xᵢ·[Kᵢ]₁ with the one wire contributing [K₀]₁
+	var kSum bn254.G1Jac
+	if _, err := kSum.MultiExp(p.vk.G1.K[1:], publicInputs, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	kSum.AddMixed(&p.vk.G1.K[0])
+	var kSumAff bn254.G1Affine
+	kSumAff.FromJacobian(&kSum)
+
+	// The lines of B depend on the proof. Computing them in projective
+	// coordinates inside MillerLoop is cheaper than precomputing affine lines
+	// for a single use.
+	fixed, err := bn254.MillerLoopFixedQ(
+		[]bn254.G1Affine{bproof.Krs, kSumAff},
+		append(scratch[:0], p.deltaNeg, p.gammaNeg),
+	)
+	if err != nil {
+		return err
+	}
+	variable, err := bn254.MillerLoop([]bn254.G1Affine{bproof.Ar}, []bn254.G2Affine{bproof.Bs})
+	if err != nil {
+		return err
+	}
+
+	result := bn254.FinalExponentiation(&fixed, &variable)
+	if !p.alphaBeta.Equal(&result) {
+		return errPairingCheckFailed
+	}
+	return nil
+}
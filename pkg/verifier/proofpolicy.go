@@ -0,0 +1,111 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// ProofPolicy restricts the proof configurations a relying party accepts,
+// so that older or weaker ones can be refused even though their proofs
+// verify, e.g. to deprecate the v1 circuits in stages. Every non-empty rule
+// must hold.
+type ProofPolicy struct {
+	// AllowedProofSystems lists the accepted proof systems
+	AllowedProofSystems []ptx.ProofSystem
+	// AllowedCurves lists the accepted curves. Proofs without an envelope
+	// are taken to be on the curve proofdata.InferEnvelope infers.
+	AllowedCurves []ptx.Curve
+	// MinCircuitVersion is the lowest accepted circuit revision, the "_vN"
+	// suffix of the circuit ID ("sdv_poseidon_v3" is version 3). Circuits
+	// without a version are refused when it is set.
+	MinCircuitVersion int
+}
+
+// ProofPolicyError is a proof the ProofPolicy refuses
+type ProofPolicyError struct {
+	// Rule is the violated rule: "proofSystem", "curve" or "circuitVersion"
+	Rule string `json:"rule"`
+	// Value is the refused proof system, curve or circuit ID
+	Value string `json:"value"`
+}
+
+func (e *ProofPolicyError) Error() string {
+	switch e.Rule {
+	case "proofSystem":
+		return "proof system " + e.Value + " not allowed"
+	case "curve":
+		return "curve " + e.Value + " not allowed"
+	default:
+		return fmt.Sprintf("circuit %q is older than the minimum circuit version", e.Value)
+	}
+}
+
+// Validate rejects unspecified proof systems and curves and a negative
+// minimum version
+func (p *ProofPolicy) Validate() error {
+	for _, s := range p.AllowedProofSystems {
+		if s == ptx.ProofSystem_SYSTEM_UNSPECIFIED {
+			return errors.New("proof policy: unspecified proof system")
+		}
+	}
+	for _, c := range p.AllowedCurves {
+		if c == ptx.Curve_CURVE_UNSPECIFIED {
+			return errors.New("proof policy: unspecified curve")
+		}
+	}
+	if p.MinCircuitVersion < 0 {
+		return errors.New("proof policy: MinCircuitVersion must not be negative")
+	}
+	return nil
+}
+
+// Check applies the policy to a proof of the circuit circuitID. It returns a
+// *ProofPolicyError if the proof is refused.
+func (p *ProofPolicy) Check(system ptx.ProofSystem, curve ptx.Curve, circuitID string) error {
+	if len(p.AllowedProofSystems) > 0 && !slices.Contains(p.AllowedProofSystems, system) {
+		return &ProofPolicyError{Rule: "proofSystem", Value: system.String()}
+	}
+	if len(p.AllowedCurves) > 0 && !slices.Contains(p.AllowedCurves, curve) {
+		return &ProofPolicyError{Rule: "curve", Value: curve.String()}
+	}
+	if p.MinCircuitVersion > 0 {
+		if version, ok := CircuitVersion(circuitID); !ok || version < p.MinCircuitVersion {
+			return &ProofPolicyError{Rule: "circuitVersion", Value: circuitID}
+		}
+	}
+	return nil
+}
+
+// CircuitVersion returns the revision of a circuit from the "_vN" suffix of
+// its ID
+func CircuitVersion(circuitID string) (int, bool) {
+	i := strings.LastIndex(circuitID, "_v")
+	if i < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(circuitID[i+2:])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// checkProofPolicy applies the ProofPolicy to the proof of a PTX file. Its
+// curve is read from the envelope, or inferred from the proof data for files
+// without one.
+func (v *PTXVerifier) checkProofPolicy(proof *ptx.ZkProof) error {
+	system := proof.GetProofSystem()
+	env := proof.GetEnvelope()
+	if env == nil {
+		if w, err := proofdata.Parse(proof.GetProofData()); err == nil {
+			env = proofdata.InferEnvelope(system, w)
+		}
+	}
+	return v.Options.ProofPolicy.Check(system, env.GetCurve(), v.circuitID(proof.GetVerificationKeyId()))
+}
@@ -0,0 +1,66 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+func TestProofPolicy(t *testing.T) {
+	env := ptxtest.New(t)
+	tok := env.Issue(fixture.Options{Seed: 1})
+
+	withPolicy := func(p verifier.ProofPolicy) func(*verifier.VerificationOptions) {
+		return func(o *verifier.VerificationOptions) { o.ProofPolicy = &p }
+	}
+
+	res := env.Verify(tok, withPolicy(verifier.ProofPolicy{
+		AllowedProofSystems: []ptx.ProofSystem{ptx.ProofSystem_GROTH16},
+		AllowedCurves:       []ptx.Curve{ptx.Curve_BN254},
+		MinCircuitVersion:   1,
+	}))
+	ptxtest.AssertAccepted(t, res)
+
+	for name, tc := range map[string]struct {
+		policy verifier.ProofPolicy
+		rule   string
+	}{
+		"proof system": {verifier.ProofPolicy{AllowedProofSystems: []ptx.ProofSystem{ptx.ProofSystem_PLONK}}, "proofSystem"},
+		"curve":        {verifier.ProofPolicy{AllowedCurves: []ptx.Curve{ptx.Curve_BLS12_381}}, "curve"},
+		"v1 circuit":   {verifier.ProofPolicy{MinCircuitVersion: 3}, "circuitVersion"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			res := env.Verify(tok, withPolicy(tc.policy))
+			ptxtest.AssertRejected(t, res, verifier.CodeProofPolicy)
+			if !res.Zk.Valid {
+				t.Errorf("proof did not verify: %s", res.Zk.Error)
+			}
+			if res.ProofPolicyError == nil || res.ProofPolicyError.Rule != tc.rule {
+				t.Errorf("ProofPolicyError = %+v, want rule %q", res.ProofPolicyError, tc.rule)
+			}
+		})
+	}
+}
+
+func TestProofPolicyCheck(t *testing.T) {
+	p := verifier.ProofPolicy{MinCircuitVersion: 3}
+	if err := p.Check(ptx.ProofSystem_GROTH16, ptx.Curve_BN254, "sdv_poseidon_v3"); err != nil {
+		t.Errorf("v3 refused: %v", err)
+	}
+	for _, id := range []string{"sdv_poseidon_sha256_v1", "sdv_poseidon", "custom_vx"} {
+		var perr *verifier.ProofPolicyError
+		if err := p.Check(ptx.ProofSystem_GROTH16, ptx.Curve_BN254, id); !errors.As(err, &perr) || perr.Value != id {
+			t.Errorf("Check(%q) = %v, want a ProofPolicyError", id, err)
+		}
+	}
+
+	if _, err := verifier.New(verifier.VerificationOptions{ProofPolicy: &verifier.ProofPolicy{MinCircuitVersion: -1}}); err == nil {
+		t.Error("New accepted a negative MinCircuitVersion")
+	}
+}
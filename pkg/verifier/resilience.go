@@ -0,0 +1,56 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/resilience"
+)
+
+// Endpoint names under which external dependencies are retried and broken
+const (
+	endpointNonceStore = "nonce-store"
+	endpointResolver   = "dns"
+)
+
+// call calls an external dependency under the resilience policy of the
+// verification
+func (v *PTXVerifier) call(ctx context.Context, endpoint string, fn func(context.Context) error) error {
+	p := resilience.DefaultPolicy
+	if v.Options.Resilience != nil {
+		p = *v.Options.Resilience
+	}
+	return v.Options.Breakers.Do(ctx, p, endpoint, fn)
+}
+
+// resolverEndpoint names the breaker of a resolver: its DoH endpoint when it
+// has one
+func resolverEndpoint(r dns.Resolver) string {
+	if e, ok := r.(interface{ Endpoint() string }); ok {
+		return endpointResolver + ":" + e.Endpoint()
+	}
+	return endpointResolver
+}
+
+// urlEndpoint names the breaker of an HTTP dependency by kind and host
+func urlEndpoint(kind, rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return kind + ":" + u.Host
+	}
+	return kind
+}
+
+// storeError marks the nonce store errors that are not worth retrying.
+// Recording nonces is not idempotent: once a command may have reached the
+// store, a retry could find the nonce it recorded and report a replay. Only
+// failures to connect are retried.
+func storeError(err error) error {
+	var op *net.OpError
+	if err == nil || (errors.As(err, &op) && op.Op == "dial") {
+		return err
+	}
+	return resilience.Permanent(err)
+}
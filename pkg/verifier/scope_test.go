@@ -0,0 +1,48 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/verifier"
+)
+
+func TestScopeAndAudience(t *testing.T) {
+	env := ptxtest.New(t)
+	issue := func(seed int64, meta map[string]interface{}) *ptxtest.Token {
+		meta["role"] = "validator"
+		return env.Issue(fixture.Options{Seed: seed, Metadata: meta})
+	}
+	scoped := issue(1, map[string]interface{}{"scopes": []interface{}{"pkg.Service"}, "audience": "pkg.Service"})
+	bare := issue(2, map[string]interface{}{})
+	numeric := issue(3, map[string]interface{}{"scopes": []interface{}{1}})
+	scalar := issue(4, map[string]interface{}{"scopes": 1, "audience": 1})
+
+	intended := func(require bool) func(*verifier.VerificationOptions) {
+		return func(o *verifier.VerificationOptions) {
+			o.IntendedScope = []string{"pkg.Service"}
+			o.IntendedAudience = []string{"pkg.Service"}
+			o.RequireScope, o.RequireAudience = require, require
+		}
+	}
+
+	ptxtest.AssertAccepted(t, env.Verify(scoped, intended(true)))
+	ptxtest.AssertRejected(t, env.Verify(scoped, func(o *verifier.VerificationOptions) {
+		o.IntendedScope = []string{"other.Service"}
+	}), verifier.CodeScopeMismatch)
+
+	// Tokens without the claims pass unless they are required
+	ptxtest.AssertAccepted(t, env.Verify(bare, intended(false)))
+	ptxtest.AssertRejected(t, env.Verify(bare, intended(true)), verifier.CodeScopeMismatch)
+	ptxtest.AssertRejected(t, env.Verify(bare, func(o *verifier.VerificationOptions) {
+		o.IntendedAudience = []string{"pkg.Service"}
+		o.RequireAudience = true
+	}), verifier.CodeAudienceMismatch)
+
+	// Claims of the wrong type are rejected, not asserted
+	ptxtest.AssertRejected(t, env.Verify(numeric, intended(false)), verifier.CodeMetadataInvalid)
+	ptxtest.AssertRejected(t, env.Verify(scalar, intended(false)), verifier.CodeMetadataInvalid)
+}
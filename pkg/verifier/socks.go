@@ -0,0 +1,102 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/outbound"
+)
+
+// socksClients are the DoH and anchor clients routed through one SOCKS5
+// proxy. They are shared by the verifications using the proxy, so that
+// connections, and the Tor circuits behind them, are reused.
+type socksClients struct {
+	resolver *dns.Client
+	http     *http.Client
+}
+
+var (
+	socksMu   sync.Mutex
+	socksPool = map[string]*socksClients{}
+)
+
+// parseSOCKSProxy checks that raw is a socks5:// or socks5h:// URL
+func parseSOCKSProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "socks5" && u.Scheme != "socks5h") {
+		return nil, fmt.Errorf("invalid SOCKS proxy %q (expected socks5://host:port)", raw)
+	}
+	return u, nil
+}
+
+// socksClientsFor returns the clients for the SOCKS proxy raw. The DoH
+// client queries the endpoint of dns.DefaultClient; the anchor client keeps
+// the CA bundles of the shared outbound configuration.
+func socksClientsFor(raw string) (*socksClients, error) {
+	if runtime.GOOS == "js" {
+		// The Fetch API ignores proxies, which would silently leak lookups
+		return nil, errors.New("SOCKS proxies are not supported in js/wasm builds")
+	}
+	if _, err := parseSOCKSProxy(raw); err != nil {
+		return nil, err
+	}
+	base := dns.DefaultClient()
+	key := raw + " " + base.Endpoint() + " " + base.Format().String()
+
+	socksMu.Lock()
+	defer socksMu.Unlock()
+	if c, ok := socksPool[key]; ok {
+		return c, nil
+	}
+	resolver, err := dns.NewClient(dns.ClientConfig{Endpoint: base.Endpoint(), Format: base.Format(), ProxyURL: raw})
+	if err != nil {
+		return nil, err
+	}
+	cfg := *outbound.Default()
+	cfg.ProxyURL = raw
+	cfg.Destinations = slices.Clone(cfg.Destinations)
+	for i := range cfg.Destinations {
+		cfg.Destinations[i].ProxyURL = ""
+	}
+	transport, err := cfg.NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	c := &socksClients{resolver: resolver, http: &http.Client{Transport: transport, Timeout: defaultAnchorTimeout}}
+	socksPool[key] = c
+	return c, nil
+}
+
+// resolver returns the resolver for anchor lookups
+func (v *PTXVerifier) resolver() (dns.Resolver, error) {
+	switch {
+	case v.Options.DNSResolver != nil:
+		return v.Options.DNSResolver, nil
+	case v.Options.SOCKSProxy != "":
+		c, err := socksClientsFor(v.Options.SOCKSProxy)
+		if err != nil {
+			return nil, err
+		}
+		return c.resolver, nil
+	}
+	return dns.DefaultClient(), nil
+}
+
+// anchorHTTP returns the client fetching additional anchors. It is nil when
+// the default client of each anchor type should be used.
+func (v *PTXVerifier) anchorHTTP() (*http.Client, error) {
+	if v.Options.AnchorHTTP != nil || v.Options.SOCKSProxy == "" {
+		return v.Options.AnchorHTTP, nil
+	}
+	c, err := socksClientsFor(v.Options.SOCKSProxy)
+	if err != nil {
+		return nil, err
+	}
+	return c.http, nil
+}
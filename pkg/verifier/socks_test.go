@@ -0,0 +1,82 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// socks5Server accepts unauthenticated CONNECT requests, records their
+// targets and forwards every connection to upstream
+func socks5Server(t *testing.T, upstream string) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	targets := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 262)
+				// Greeting: version, methods; reply no authentication
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				io.ReadFull(conn, buf[:buf[1]])
+				conn.Write([]byte{5, 0})
+				// Request: version, CONNECT, reserved, address type
+				if _, err := io.ReadFull(conn, buf[:4]); err != nil || buf[3] != 3 {
+					return
+				}
+				io.ReadFull(conn, buf[:1])
+				host := make([]byte, buf[0])
+				io.ReadFull(conn, host)
+				io.ReadFull(conn, buf[:2])
+				targets <- net.JoinHostPort(string(host), strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+
+				up, err := net.Dial("tcp", upstream)
+				if err != nil {
+					return
+				}
+				defer up.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(up, conn)
+				io.Copy(conn, up)
+			}()
+		}
+	}()
+	return ln.Addr().String(), targets
+}
+
+func TestSOCKSProxy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "abc\n")
+	}))
+	defer srv.Close()
+	proxy, targets := socks5Server(t, srv.Listener.Addr().String())
+
+	v := NewPTXVerifier(VerificationOptions{SOCKSProxy: "socks5://" + proxy})
+	values, err := v.fetchWellKnownAnchor("http://anchors.example/.well-known/ptx-anchor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values[0] != "abc" {
+		t.Fatalf("got %v", values)
+	}
+	// The hostname went to the proxy unresolved
+	if target := <-targets; target != "anchors.example:80" {
+		t.Errorf("proxy asked for %s", target)
+	}
+}
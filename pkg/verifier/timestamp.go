@@ -0,0 +1,46 @@
+package verifier
+
+import (
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// TimestampResult is the outcome of checking the RFC 3161 timestamp of a PTX
+// file
+type TimestampResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// Time is when the TSA saw the PTX, the latest it can have been issued
+	Time *time.Time `json:"time,omitempty"`
+	// Authority is the subject of the TSA certificate
+	Authority string `json:"authority,omitempty"`
+	Serial    string `json:"serial,omitempty"`
+}
+
+// verifyTimestamp checks the timestamp token of a PTX file against its
+// payload and the TSA roots. The PTX cannot have been issued after it was
+// timestamped, so an issued_at later than the timestamp is rejected.
+func (v *PTXVerifier) verifyTimestamp(ptxFile *ptx.PtxFile, issuedAt time.Time, skew time.Duration) *TimestampResult {
+	res := &TimestampResult{}
+	digest, err := tsa.Imprint(ptxFile)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	info, err := tsa.Verify(ptxFile.GetTimestampToken(), digest, v.Options.TSARoots)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Time = &info.Time
+	res.Authority = info.Signer.Subject.String()
+	res.Serial = info.Serial.String()
+	if !issuedAt.IsZero() && issuedAt.After(info.Time.Add(info.Accuracy+skew)) {
+		res.Error = "issued_at (" + issuedAt.Format(time.RFC3339) + ") is after the timestamp (" + info.Time.UTC().Format(time.RFC3339) + ")"
+		return res
+	}
+	res.Valid = true
+	return res
+}
@@ -0,0 +1,100 @@
+package verifier
+
+import "time"
+
+// Stage is the span of one verification stage, as offsets from the start of
+// the verification. Both stamps are read from the monotonic clock, so stages
+// can be ordered and compared even if the wall clock is adjusted meanwhile.
+type Stage struct {
+	StartMs float64 `json:"startMs"`
+	StopMs  float64 `json:"stopMs"`
+}
+
+// DurationMs returns how long the stage took
+func (s *Stage) DurationMs() float64 {
+	if s == nil {
+		return 0
+	}
+	return s.StopMs - s.StartMs
+}
+
+// Timing attributes the latency of a verification to its stages. A stage is
+// nil when the verification did not reach it, or skipped it.
+type Timing struct {
+	// Load is reading and parsing the PTX
+	Load *Stage `json:"load,omitempty"`
+	// Metadata covers the metadata, validity, timestamp, scope and audience
+	// checks
+	Metadata *Stage `json:"metadata,omitempty"`
+	// Nonce covers the nonce, nullifier and challenge stores
+	Nonce *Stage `json:"nonce,omitempty"`
+	// DNS covers every anchor check, retries and backoff included
+	DNS *Stage `json:"dns,omitempty"`
+	// Semantic is parsing the proof and matching its public signals
+	Semantic *Stage `json:"semantic,omitempty"`
+	// ZK is the pairing check, key loading included
+	ZK *Stage `json:"zk,omitempty"`
+	// Total spans the whole verification
+	Total *Stage `json:"total,omitempty"`
+
+	origin  time.Time
+	running *Stage
+}
+
+// newTiming starts the clock of a verification
+func newTiming() *Timing {
+	return &Timing{Total: &Stage{}, origin: time.Now()}
+}
+
+// begin ends the running stage, if any, and starts *stage
+func (t *Timing) begin(stage **Stage) {
+	t.end()
+	t.running = &Stage{StartMs: t.sinceMs()}
+	*stage = t.running
+}
+
+// end stops the running stage, if any
+func (t *Timing) end() {
+	if t.running != nil {
+		t.running.StopMs = t.sinceMs()
+		t.running = nil
+	}
+}
+
+// finish stops the running stage and the Total stage
+func (t *Timing) finish() {
+	t.end()
+	t.Total.StopMs = t.sinceMs()
+}
+
+func (t *Timing) sinceMs() float64 {
+	return time.Since(t.origin).Seconds() * 1000
+}
+
+// Stages returns the stages that ran, by name, in the order they ran.
+// Total is not included.
+func (t *Timing) Stages() []NamedStage {
+	if t == nil {
+		return nil
+	}
+	var out []NamedStage
+	for _, s := range []NamedStage{
+		{"load", t.Load},
+		{"metadata", t.Metadata},
+		{"semantic", t.Semantic},
+		{"zk", t.ZK},
+		{"dns", t.DNS},
+		{"nonce", t.Nonce},
+	} {
+		if s.Stage != nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// NamedStage is a Stage together with its name in Timing
+type NamedStage struct {
+	Name string
+	*Stage
+}
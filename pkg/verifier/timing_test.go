@@ -0,0 +1,40 @@
+//go:build !(js && wasm)
+
+package verifier_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxtest"
+)
+
+func TestTiming(t *testing.T) {
+	env := ptxtest.New(t)
+	res := env.Verify(env.Issue(fixture.Options{Seed: 1}))
+	ptxtest.AssertAccepted(t, res)
+
+	tm := res.Timing
+	if tm == nil || tm.Total == nil {
+		t.Fatal("no timing")
+	}
+	var names []string
+	prev := 0.0
+	for _, s := range tm.Stages() {
+		names = append(names, s.Name)
+		if s.StartMs < prev || s.StopMs < s.StartMs {
+			t.Errorf("stage %s [%v, %v] overlaps the previous one ending at %v", s.Name, s.StartMs, s.StopMs, prev)
+		}
+		prev = s.StopMs
+	}
+	if want := []string{"load", "metadata", "semantic", "zk", "dns", "nonce"}; !slices.Equal(names, want) {
+		t.Fatalf("stages = %v, want %v", names, want)
+	}
+	if tm.Total.StopMs < prev {
+		t.Errorf("total ends at %v before the last stage at %v", tm.Total.StopMs, prev)
+	}
+	if tm.ZK.DurationMs() <= 0 {
+		t.Errorf("zk stage took %v", tm.ZK.DurationMs())
+	}
+}
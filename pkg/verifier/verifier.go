@@ -2,114 +2,353 @@ package verifier
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/big"
-	"os"
+	"net/http"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/confidential"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/discovery"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
-	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/nonce"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/events"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/evidence"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/metaschema"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
-	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/resilience"
 	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
 	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
-	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
-	"github.com/consensys/gnark/constraint"
-	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/frontend/cs/r1cs"
 )
 
-const nativeVKPath = "native.vk"
-
-// loadCachedVK loads the verification key from cache or runs setup if not found
-func loadCachedVK(ccs constraint.ConstraintSystem) (groth16.VerifyingKey, error) {
-	// Try to load existing VK
-	if _, err := os.Stat(nativeVKPath); err == nil {
-		vkFile, err := os.Open(nativeVKPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open vk file: %w", err)
-		}
-		defer vkFile.Close()
-
-		vk := groth16.NewVerifyingKey(ecc.BN254)
-		if _, err := vk.ReadFrom(vkFile); err != nil {
-			return nil, fmt.Errorf("failed to read vk: %w", err)
-		}
-		return vk, nil
-	}
+const (
+	nativeVKPath           = "native.vk"
+	nativeSHA256VKPath     = "native_sha256.vk"
+	nativeScopeBoundVKPath = "native_v3.vk"
+	defaultDNSRetryBackoff = time.Second
+)
 
-	// VK doesn't exist, must generate (first run or keys missing)
-	// Note: This will create different keys than the prover if called first!
-	_, vk, err := groth16.Setup(ccs)
-	if err != nil {
-		return nil, fmt.Errorf("setup failed: %w", err)
-	}
+// NonceStore records nonces so that a PTX cannot be replayed
+type NonceStore interface {
+	// CheckAndSetNonce records nonce until expiresAt and reports whether it
+	// had not been seen before. Expired nonces are never recorded.
+	CheckAndSetNonce(nonce string, expiresAt time.Time) (bool, error)
+}
 
-	// Save VK for future use
-	vkFile, err := os.Create(nativeVKPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create vk file: %w", err)
-	}
-	defer vkFile.Close()
+// MultiNonceStore is a NonceStore recording several nonces atomically, all
+// of them or none, which TrackNullifiers requires
+type MultiNonceStore interface {
+	NonceStore
+	// CheckAndSetNonces records all nonces until expiresAt if none has been
+	// seen before. It returns the index of the first nonce
+	// already seen, or -1 if all were recorded.
+	CheckAndSetNonces(nonces []string, expiresAt time.Time) (int, error)
+}
 
-	if _, err := vk.WriteTo(vkFile); err != nil {
-		return nil, fmt.Errorf("failed to write vk: %w", err)
-	}
+// NullifierKeyPrefix distinguishes the nullifier hashes recorded with
+// TrackNullifiers from metadata nonces in the nonce store
+const NullifierKeyPrefix = "nullifier:"
 
-	return vk, nil
+// nonceStore is a MultiNonceStore opened by the verifier, which closes it
+type nonceStore interface {
+	MultiNonceStore
+	Close() error
 }
 
 type VerificationOptions struct {
-	FilePath         string
+	FilePath string
+	// PTXData is the raw PTX file. When set FilePath is not read.
+	PTXData          []byte
 	IntendedScope    []string
 	IntendedAudience []string
 	StrictMode       bool
 	RedisURL         string
-	Verbose          bool
+	// NonceStore records nonces instead of the Redis store at RedisURL,
+	// e.g. an in-memory store in tests. It is not closed by the verifier.
+	NonceStore NonceStore
+	// NonceDB is an embedded nonce database file used instead of Redis, so
+	// that a single verifier keeps replay protection across restarts. It is
+	// opened once per process and shared by all verifications.
+	NonceDB string
+	// TrackNullifiers records the nullifier hash of every PTX in the nonce
+	// store, together with its metadata nonce in one atomic operation, so
+	// that each token is accepted once until it expires (or for 5 minutes
+	// without an expiry). An injected NonceStore must be a MultiNonceStore.
+	TrackNullifiers bool
+	// NonceKeyPrefix namespaces nonces in Redis or NonceDB so that verifiers sharing a
+	// store do not see each other's nonces
+	NonceKeyPrefix string
+	// ExternalSignals are the public signals the relying party expects,
+	// supplied out-of-band (e.g. recorded at enrollment) for PTX files that
+	// only carry the proof (proofdata.SignalsExternal). A PTX that carries
+	// signals anyway must carry exactly these.
+	ExternalSignals []string
+	// ExternalMetadata is the signed metadata (claims JSON) the relying
+	// party expects, for PTX files without signed_metadata. A PTX that
+	// carries metadata anyway must carry exactly this.
+	ExternalMetadata string
+	// MetadataKeys open the encrypted claims of PTX files sealed for them
+	// (see package confidential), which are then checked like the others.
+	// Without keys the envelope is left in the claims unread.
+	MetadataKeys []confidential.Key
+	// Challenges enables challenge-response (holder binding): the metadata
+	// nonce must be a challenge the store issued and has not seen answered,
+	// so the PTX was proven for this presentation. See package challenge.
+	Challenges ChallengeStore
+	Verbose    bool
+	// VKPath is the native verification key file. Defaults to "native.vk",
+	// "native_sha256.vk" for proofs under signals.MetadataSHA256KeyID and
+	// "native_v3.vk" for proofs under signals.ScopeBoundKeyID.
+	VKPath string
+	// VKData is a serialized native verification key. When set VKPath is not
+	// read; this is the only way to supply a key in js/wasm builds.
+	VKData []byte
+	// KeySet replaces VKPath/VKData with a set of rotating keys. A proof is
+	// accepted under any key valid at verification time.
+	KeySet *KeySet
+	// Discovery selects keys from the configuration the issuer publishes at
+	// https://<domain>/.well-known/ptx-configuration when no KeySet is set.
+	// The issuer then chooses the keys its own tokens are checked against.
+	Discovery *discovery.Client
+	// DNSRetries is the number of additional DNS lookups performed when the
+	// anchor record is missing or the lookup fails
+	DNSRetries int
+	// DNSRetryBackoff is the delay before the first retry. It doubles after
+	// each attempt. Defaults to one second.
+	DNSRetryBackoff time.Duration
+	// Resilience retries transient failures of the DNS resolver, the nonce
+	// store and anchor fetches with jittered backoff, and configures their
+	// circuit breakers. Defaults to resilience.DefaultPolicy. DNSRetries
+	// come on top, for anchors that are not published yet.
+	Resilience *resilience.Policy
+	// Breakers holds the circuit breakers and counters of those
+	// dependencies. Defaults to resilience.DefaultBreakers(), which the
+	// server exposes at /metrics.
+	Breakers *resilience.Breakers
+	// DNSMatchMode controls how TXT record values are compared against the
+	// expected anchor. Defaults to exact matching.
+	DNSMatchMode dns.MatchMode
+	// DNSAfterProof only checks the anchors once the proof has verified.
+	// Their hostname derives from the commitment signal the proof carries,
+	// which is otherwise trusted as is, e.g. when the proof system is not
+	// supported and its verification skipped; such PTX files are then
+	// rejected. StrictMode implies it.
+	DNSAfterProof bool
+	// DNSOutagePolicy decides the anchor check when the resolver cannot be
+	// reached. Defaults to failing closed; StrictMode always fails closed.
+	DNSOutagePolicy dns.OutagePolicy
+	// AnchorCache remembers anchors found in DNS for
+	// dns.OutageAcceptIfCached. Defaults to dns.DefaultAnchorCache().
+	AnchorCache *dns.AnchorCache
+	// DNSResolver answers anchor lookups. Defaults to the shared
+	// dns.DefaultClient.
+	DNSResolver dns.Resolver
+	// SOCKSProxy routes the anchor lookups and fetches of this verifier
+	// through a SOCKS5 proxy ("socks5://127.0.0.1:9050" for Tor), so that
+	// network observers do not learn which derived hostnames, and thus which
+	// commitments, it checks. Hostnames are resolved by the proxy. It
+	// applies to the DoH client and anchor client the verifier creates: a
+	// DNSResolver or AnchorHTTP set here is used as given.
+	SOCKSProxy string
+	// DomainPolicy decides which domains of a PTX anchored under several
+	// domains must publish the record. Defaults to DomainsAll.
+	DomainPolicy DomainPolicy
+	// AnchorHTTP fetches the well-known anchors of PTX files that carry
+	// additional anchors. Defaults to a client with a 10 second timeout.
+	AnchorHTTP *http.Client
+	// IPFSGateway fetches the IPFS anchors of PTX files. Defaults to
+	// ipfs.DefaultGateway through AnchorHTTP.
+	IPFSGateway string
+	// EthereumRPC is the JSON-RPC endpoint reading ETHEREUM anchors, which
+	// are only checked in builds with the ethereum tag
+	EthereumRPC string
+	// EthereumRegistries maps normalized domains to the address of the
+	// registry contract trusted to anchor their tokens. ETHEREUM anchors
+	// naming any other registry are rejected.
+	EthereumRegistries map[string]string
+	// Evidence records an audit bundle of the verification in
+	// VerificationResult.Evidence
+	Evidence bool
+	// EvidenceKey signs the evidence bundle when set
+	EvidenceKey ed25519.PrivateKey
+	// MaxClockSkew is how far issued_at may be in the future. Defaults to
+	// DefaultMaxClockSkew.
+	MaxClockSkew time.Duration
+	// MaxTokenLifetime rejects tokens that expire more than this after
+	// verification, or that do not expire. Zero allows any expiration.
+	MaxTokenLifetime time.Duration
+	// MaxNonceTTL bounds how long nonces are remembered. Tokens expiring
+	// later can be replayed once their nonce is forgotten, which is reported
+	// as a warning. Defaults to DefaultMaxNonceTTL.
+	MaxNonceTTL time.Duration
+	// TSARoots are the roots RFC 3161 timestamp tokens must chain to.
+	// Defaults to the system roots.
+	TSARoots *x509.CertPool
+	// RequireTimestamp rejects PTX files without a timestamp token.
+	// Tokens that are present are always checked.
+	RequireTimestamp bool
+	// RequireScope rejects PTX files without a scopes claim when
+	// IntendedScope is set. Otherwise such tokens skip the scope check.
+	RequireScope bool
+	// RequireAudience rejects PTX files without an audience claim when
+	// IntendedAudience is set
+	RequireAudience bool
+	// LegacySignalScan matches public signals by scanning for expected values
+	// instead of using the signal layout of the proof's verification key.
+	// Only needed for old proofs issued under an unregistered key ID.
+	// StrictMode ignores it and pins every signal to its layout index.
+	LegacySignalScan bool
+	// PolicyFunc applies custom acceptance rules once all other checks have
+	// passed. See Policy for a declarative form.
+	PolicyFunc PolicyFunc
+	// ProofPolicy refuses proof systems, curves and circuit revisions the
+	// relying party no longer accepts, even when the proof verifies
+	ProofPolicy *ProofPolicy
+	// Events receives the outcome of every verification. Publishing happens
+	// in the background and never delays Verify.
+	Events *events.Emitter
+	// Caller identifies who requested the verification (a client
+	// certificate subject, service name or address). It is only recorded in
+	// events.
+	Caller string
+	// MaxDuration bounds the whole Verify call. Lookups and fetches still
+	// running when it runs out are cancelled, the stages not started are
+	// skipped, and the partial result is rejected with CodeTimeout. Zero
+	// means no bound.
+	MaxDuration time.Duration
+}
+
+// ChallengeStore consumes the challenges issued to holders, see
+// challenge.Scoped
+type ChallengeStore interface {
+	// Consume reports whether challenge was issued and has not expired or
+	// been answered yet, and marks it answered
+	Consume(ctx context.Context, challenge string) (bool, error)
 }
 
 type VerificationResult struct {
-	Success bool
-	Errors  []string
-	Dns     DnsResult
-	Zk      ZkResult
-	Details VerificationDetails
+	Success bool                `json:"success"`
+	Errors  []string            `json:"errors"`
+	Dns     DnsResult           `json:"dns"`
+	Zk      ZkResult            `json:"zk"`
+	Details VerificationDetails `json:"details"`
+	// Domains holds the DNS anchor results of the additional domains of the
+	// PTX, which combine with Dns under the DomainPolicy
+	Domains []DomainResult `json:"domains,omitempty"`
+	// Anchors holds the results of the additional anchors of the PTX, which
+	// combine with Dns under the PTX anchor policy
+	Anchors []AnchorResult `json:"anchors,omitempty"`
+	// Evidence is the audit bundle, set when VerificationOptions.Evidence is
+	// enabled
+	Evidence *evidence.Bundle `json:"evidence,omitempty"`
+	// Code classifies the first failed check. It is empty on success.
+	Code ErrorCode `json:"code,omitempty"`
+	// PolicyError is the reason the PolicyFunc rejected the PTX
+	PolicyError string `json:"policyError,omitempty"`
+	// ProofPolicyError is the rule of the ProofPolicy the proof violates
+	ProofPolicyError *ProofPolicyError `json:"proofPolicyError,omitempty"`
+	// Timestamp is the result of the RFC 3161 timestamp check, set when the
+	// PTX carries a timestamp token
+	Timestamp *TimestampResult `json:"timestamp,omitempty"`
+	// Warnings lists checks that were passed in a degraded mode, such as a
+	// DNS anchor accepted during a resolver outage
+	Warnings []string `json:"warnings,omitempty"`
+	// Timing attributes the verification latency to its stages
+	Timing *Timing `json:"timing,omitempty"`
+	// Deadline lists the stages completed and timed out when MaxDuration
+	// ran out
+	Deadline *DeadlineResult `json:"deadline,omitempty"`
 }
 
 type VerificationDetails struct {
-	Fqdn           string
-	FqdnHash       string
-	MetadataJSON   string
-	MetadataHashP1 string
-	MetadataHashP2 string
-	TrustMethod    string
-	NullifierHash  string
-	Commitment     string
+	Fqdn           string `json:"fqdn"`
+	FqdnHash       string `json:"fqdnHash"`
+	MetadataJSON   string `json:"metadataJson"`
+	MetadataHashP1 string `json:"metadataHashP1"`
+	MetadataHashP2 string `json:"metadataHashP2"`
+	TrustMethod    string `json:"trustMethod"`
+	NullifierHash  string `json:"nullifierHash"`
+	Commitment     string `json:"commitment"`
+	// IssuedAt and ExpiresAt are nil when the PTX does not carry them
+	IssuedAt  *time.Time `json:"issuedAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// AdditionalDomains are the further anchor domains of the PTX
+	AdditionalDomains []string `json:"additionalDomains,omitempty"`
+	// DecryptedClaims names the claims opened with MetadataKeys. MetadataJSON
+	// keeps the encrypted form the proof binds.
+	DecryptedClaims []string `json:"decryptedClaims,omitempty"`
+	// MetaVersion is the metadata schema version the PTX was issued with,
+	// 0 for unversioned metadata. The claims are checked upgraded to
+	// metaschema.Current.
+	MetaVersion int `json:"metaVersion"`
 }
 
 type DnsResult struct {
-	Valid           bool
-	Error           string
-	DerivedHostname string
-	FetchTimeMs     float64
+	Valid           bool   `json:"valid"`
+	Error           string `json:"error,omitempty"`
+	DerivedHostname string `json:"derivedHostname"`
+	// FetchTimeMs is the total time spent in DNS lookups, excluding backoff.
+	//
+	// Deprecated: use VerificationResult.Timing, whose DNS stage covers
+	// every anchor check.
+	FetchTimeMs float64 `json:"fetchTimeMs"`
+	// Attempts is the number of lookups performed
+	Attempts int `json:"attempts"`
+	// AttemptTimesMs holds the duration of each lookup
+	AttemptTimesMs []float64 `json:"attemptTimesMs,omitempty"`
+	// Timing holds connection diagnostics for the last lookup
+	Timing *dns.Timing `json:"timing,omitempty"`
+	// Outage is set when no lookup got an answer from the resolver and the
+	// DNSOutagePolicy decided the check
+	Outage *DNSOutage `json:"outage,omitempty"`
 }
 
 type ZkResult struct {
-	Valid       bool
-	Skipped     bool
-	Semantic    bool
-	Error       string
-	ProofTimeMs float64
+	Valid    bool   `json:"valid"`
+	Skipped  bool   `json:"skipped"`
+	Semantic bool   `json:"semantic"`
+	Error    string `json:"error,omitempty"`
+	// ProofTimeMs is the time spent in the pairing check.
+	//
+	// Deprecated: use the ZK stage of VerificationResult.Timing.
+	ProofTimeMs float64 `json:"proofTimeMs"`
+	// SemanticReport is the per-signal breakdown of the semantic check. It is
+	// nil if the check did not run.
+	SemanticReport *signals.VerificationResult `json:"semanticReport,omitempty"`
+	// KeyID is the key set entry that verified the proof. It is empty when
+	// no key set is configured.
+	KeyID string `json:"keyId,omitempty"`
+	// CircuitIdentity is the identity of the circuit the proof declares,
+	// see circuit.Identity. It is empty for proofs without a circuit hash.
+	CircuitIdentity string `json:"circuitIdentity,omitempty"`
 }
 
 type PTXVerifier struct {
 	Options VerificationOptions
+
+	// discovered is the key set built from the issuer configuration
+	discovered *KeySet
+	// ptxHash fingerprints the loaded PTX for events
+	ptxHash string
+	// keyDir, when set, is the directory of the default key files instead
+	// of the working directory (see Verifier)
+	keyDir string
+	// ctx carries the MaxDuration deadline of the running verification
+	ctx context.Context
+	// stageCode is the result code when the running stage began
+	stageCode ErrorCode
 }
 
 func NewPTXVerifier(opts VerificationOptions) *PTXVerifier {
@@ -117,127 +356,235 @@ func NewPTXVerifier(opts VerificationOptions) *PTXVerifier {
 }
 
 func (v *PTXVerifier) Verify() (*VerificationResult, error) {
+	start := time.Now()
+	var ev *evidence.Bundle
+	if v.Options.Evidence {
+		ev = evidence.New()
+	}
+
+	cancel := v.withDeadline()
+	res, err := v.verify(ev)
+	cancel()
+	if v.Options.Events != nil {
+		v.Options.Events.Emit(v.newEvent(res, err, start))
+	}
+	if err != nil || ev == nil {
+		return res, err
+	}
+
+	if err := v.finishEvidence(ev, res); err != nil {
+		return nil, fmt.Errorf("failed to build evidence bundle: %w", err)
+	}
+	res.Evidence = ev
+	return res, nil
+}
+
+// verify runs all checks. Evidence is recorded into ev when it is non-nil.
+func (v *PTXVerifier) verify(ev *evidence.Bundle) (*VerificationResult, error) {
 	res := &VerificationResult{
 		Success: true,
 		Errors:  []string{},
+		Timing:  newTiming(),
 	}
+	defer res.Timing.finish()
 
 	// 1. Load PTX
-	ptxFile, err := ptxloader.LoadPTX(v.Options.FilePath)
+	res.Timing.begin(&res.Timing.Load)
+	loadOpts := ptxloader.DefaultLoadOptions()
+	data := v.Options.PTXData
+	if data == nil {
+		var err error
+		data, err = ptxloader.ReadFile(v.Options.FilePath, loadOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load PTX file: %w", err)
+		}
+	}
+	if v.Options.Events != nil {
+		sum := sha256.Sum256(data)
+		v.ptxHash = hex.EncodeToString(sum[:])
+	}
+	ptxFile, err := ptxloader.ParsePTX(data, loadOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load PTX file: %w", err)
 	}
+	if code, msg := v.applyExternalInputs(ptxFile); code != "" {
+		res.fail(code, msg)
+		return res, nil
+	}
+	if ev != nil {
+		v.recordInputs(ev, ptxFile, data)
+	}
 
 	// 2. Metadata & Semantic Checks
-	metaRaw := ptxFile.GetSignedMetadata()
+	if v.expired(res, "metadata") {
+		return res, nil
+	}
+	res.Timing.begin(&res.Timing.Metadata)
 	var meta map[string]interface{}
-	if err := json.Unmarshal([]byte(metaRaw), &meta); err != nil {
-		res.Success = false
-		res.Errors = append(res.Errors, "Invalid metadata JSON")
+	if err := json.Unmarshal([]byte(ptxFile.GetSignedMetadata()), &meta); err != nil {
+		res.fail(CodeMetadataInvalid, "Invalid metadata JSON")
 		return res, nil
 	}
+	// metaRaw is the form the proof and anchor bind
+	metaRaw, err := SignedMetadata(ptxFile)
+	if err != nil {
+		res.fail(CodeMetadataInvalid, "Invalid metadata: "+err.Error())
+		return res, nil
+	}
+	if len(metaRaw) > metaschema.MaxSize {
+		res.fail(CodeMetadataInvalid, fmt.Sprintf("Metadata is %d bytes, the limit is %d", len(metaRaw), metaschema.MaxSize))
+		return res, nil
+	}
+	// The proof binds the envelope of encrypted claims; once opened they
+	// are checked like the claims in the clear
+	var decrypted []string
+	if len(v.Options.MetadataKeys) > 0 {
+		decrypted, err = confidential.Open(meta, v.Options.MetadataKeys...)
+		if err != nil {
+			res.fail(CodeMetadataInvalid, "Encrypted claims: "+err.Error())
+		}
+	}
+	// Claims are checked in the current schema, whichever the token was
+	// issued with
+	metaVersion, err := metaschema.Normalize(meta)
+	if err != nil {
+		res.fail(CodeMetadataInvalid, "Invalid metadata: "+err.Error())
+	}
+	domains, err := additionalDomains(ptxFile, meta)
+	if err != nil {
+		res.fail(CodeMetadataInvalid, err.Error())
+	}
 
 	// Check Expiration
-	if exp, ok := meta["expiration_timestamp"].(float64); ok {
-		if time.Now().Unix() > int64(exp) {
-			res.Success = false
-			res.Errors = append(res.Errors, "PTX token expired")
+	now := time.Now()
+	validity, err := tokenValidity(ptxFile, meta)
+	if err != nil {
+		res.fail(CodeTokenValidity, err.Error())
+	}
+	skew := v.Options.MaxClockSkew
+	if skew <= 0 {
+		skew = DefaultMaxClockSkew
+	}
+	if errs := validity.check(now, skew, v.Options.MaxTokenLifetime); len(errs) > 0 {
+		res.fail(CodeTokenValidity, errs...)
+	}
+
+	// Check the RFC 3161 timestamp, which bounds the issuance time by the
+	// clock of a TSA rather than the issuer's
+	if len(ptxFile.GetTimestampToken()) > 0 {
+		res.Timestamp = v.verifyTimestamp(ptxFile, validity.IssuedAt, skew)
+		if !res.Timestamp.Valid {
+			res.fail(CodeTimestampInvalid, "Timestamp invalid: "+res.Timestamp.Error)
 		}
+	} else if v.Options.RequireTimestamp {
+		res.fail(CodeTimestampInvalid, "Missing RFC 3161 timestamp")
 	}
 
 	// Check Scope
 	if len(v.Options.IntendedScope) > 0 {
-		if scopes, ok := meta["scopes"].([]interface{}); ok {
-			found := false
+		switch scopes := meta["scopes"].(type) {
+		case nil:
+			if v.Options.RequireScope {
+				res.fail(CodeScopeMismatch, "Scope mismatch (no scopes claim)")
+			}
+		case []interface{}:
+			found, valid := false, true
 			for _, s := range scopes {
-				for _, req := range v.Options.IntendedScope {
-					if s.(string) == req {
-						found = true
-						break
-					}
+				scope, ok := s.(string)
+				if !ok {
+					valid = false
+					break
+				}
+				if slices.Contains(v.Options.IntendedScope, scope) {
+					found = true
 				}
 			}
-			if !found {
-				res.Success = false
-				res.Errors = append(res.Errors, "Scope mismatch")
+			if !valid {
+				res.fail(CodeMetadataInvalid, "Invalid scopes claim: not a list of strings")
+			} else if !found {
+				res.fail(CodeScopeMismatch, "Scope mismatch")
 			}
+		default:
+			res.fail(CodeMetadataInvalid, "Invalid scopes claim: not a list of strings")
 		}
 	}
 
-	// Check Audience
+	// Check Audience. For circuits binding the audience it is also checked
+	// on the proof's audienceHash signal, which does not depend on the
+	// metadata and which a token without an audience never passes.
 	if len(v.Options.IntendedAudience) > 0 {
-		if aud, ok := meta["audience"].(string); ok {
-			found := false
-			for _, req := range v.Options.IntendedAudience {
-				if aud == req {
-					found = true
-					break
-				}
-			}
-			if !found {
-				res.Success = false
-				res.Errors = append(res.Errors, "Audience mismatch")
-			}
+		claim, present := meta["audience"]
+		aud, ok := claim.(string)
+		if present && !ok {
+			res.fail(CodeMetadataInvalid, "Invalid audience claim: not a string")
+		} else if !present && v.Options.RequireAudience {
+			res.fail(CodeAudienceMismatch, "Audience mismatch (no audience claim)")
+		} else if ok && !slices.Contains(v.Options.IntendedAudience, aud) {
+			res.fail(CodeAudienceMismatch, "Audience mismatch")
+		} else if !v.audienceBound(ptxFile) {
+			res.fail(CodeAudienceMismatch, "Audience mismatch (not bound by the proof)")
 		}
 	}
 
-	// Nonce Check
-	if v.Options.RedisURL != "" {
-		if nonceVal, ok := meta["nonce"].(string); ok {
-			st, err := nonce.NewNonceStore(v.Options.RedisURL)
-			if err != nil {
-				res.Success = false
-				res.Errors = append(res.Errors, "Failed to connect to nonce store: "+err.Error())
-				return res, nil
-			}
-			defer st.Close()
-
-			// Use expiration from metadata or default to 5 min TTL
-			var exp int64 = 300
-			if e, ok := meta["expiration_timestamp"].(float64); ok {
-				exp = int64(e)
-			}
-
-			valid, err := st.CheckAndSetNonce(nonceVal, exp)
-			if err != nil || !valid {
-				res.Success = false
-				res.Errors = append(res.Errors, "Nonce invalid or replayed")
-			}
+	// 3. ZK Verification. It runs before the anchor checks, which derive the
+	// anchor hostname from the commitment signal of the proof.
+	res.Timing.end()
+	if v.expired(res, "semantic") {
+		return res, nil
+	}
+	res.Zk = v.verifyProof(ptxFile, metaRaw, res.Timing)
+	if !res.Zk.Valid && !res.Zk.Skipped {
+		res.fail(CodeProofInvalid, "ZK proof invalid: "+res.Zk.Error)
+	} else if res.Zk.Valid && ptxFile.GetProof().GetEnvelope() == nil {
+		res.Warnings = append(res.Warnings, "Proof format inferred from the proof data; the PTX carries no proof envelope")
+	}
+	// A proof the policy refuses is rejected whether or not it verified
+	if v.Options.ProofPolicy != nil && ptxFile.GetProof() != nil {
+		if err := v.checkProofPolicy(ptxFile.GetProof()); err != nil {
+			errors.As(err, &res.ProofPolicyError)
+			res.fail(CodeProofPolicy, "Proof policy rejected: "+err.Error())
 		}
 	}
 
-	// 3. DNS Verification
-	res.Dns = v.verifyDNS(ptxFile)
-	if !res.Dns.Valid {
-		res.Success = false
+	// 4. DNS Verification, together with any additional domains and anchors
+	if !res.Zk.Valid && v.dnsAfterProof() {
+		if res.Zk.Skipped {
+			res.fail(CodeProofInvalid, "ZK proof not verified: "+res.Zk.Error)
+		}
+		res.Dns = DnsResult{Error: errUnverifiedCommitment}
+	} else if v.expired(res, "dns") {
+		return res, nil
+	} else {
+		res.Timing.begin(&res.Timing.DNS)
+		v.verifyAnchoring(res, ptxFile, domains, ev)
+		res.Timing.end()
 	}
 
-	// 4. ZK Verification
-	res.Zk = v.verifyProof(ptxFile, metaRaw)
-	if !res.Zk.Valid && !res.Zk.Skipped {
-		res.Success = false
-		res.Errors = append(res.Errors, "ZK proof invalid: "+res.Zk.Error)
+	// Nonce Check. It runs last, so that only a token whose proof and
+	// anchors verified consumes its nonce, nullifier or challenge: these are
+	// public, and a forged PTX carrying them must not burn them for the
+	// holder.
+	if res.Success && v.checksNonce() {
+		if v.expired(res, "nonce") {
+			return res, nil
+		}
+		if !v.checkReplay(res, ptxFile, meta, validity, now) {
+			return res, nil
+		}
+		res.Timing.end()
 	}
 
 	// 5. Populate Details for verbose output
 	// Try to get nullifierHash and commitment from proof if possible
-	nullifierHash := ""
-	commitment := ""
-	proof := ptxFile.GetProof()
-	if proof != nil {
-		var pd struct {
-			PublicSignals []string `json:"publicSignals"`
-		}
-		if err := json.Unmarshal(proof.ProofData, &pd); err == nil && len(pd.PublicSignals) >= 2 {
-			nullifierHash = pd.PublicSignals[0]
-			commitment = pd.PublicSignals[1]
-		}
-	}
+	nullifierHash, commitment := proofSignals(ptxFile)
 
 	domain := ""
 	if ptxFile.GetDohDetails() != nil {
 		domain = ptxFile.GetDohDetails().GetDomainName()
 	}
+	if name, err := crypto.NormalizeDomain(domain); err == nil {
+		domain = name
+	}
 	fqdnHash, _ := crypto.PoseidonHashString(domain)
 	metaP1, metaP2 := crypto.SplitMetadataHash(metaRaw)
 
@@ -251,66 +598,325 @@ func (v *PTXVerifier) Verify() (*VerificationResult, error) {
 		NullifierHash:  nullifierHash,
 		Commitment:     commitment,
 	}
+	res.Details.AdditionalDomains = domains
+	res.Details.DecryptedClaims = decrypted
+	res.Details.MetaVersion = metaVersion
+	if !validity.IssuedAt.IsZero() {
+		res.Details.IssuedAt = &validity.IssuedAt
+	}
+	if !validity.ExpiresAt.IsZero() {
+		res.Details.ExpiresAt = &validity.ExpiresAt
+	}
+
+	// 6. Relying-party policy, only evaluated over verified claims
+	if v.expired(res, "") {
+		return res, nil
+	}
+	if res.Success && v.Options.PolicyFunc != nil {
+		if err := v.Options.PolicyFunc(meta, res.Details); err != nil {
+			res.PolicyError = err.Error()
+			res.fail(CodePolicyRejected, "Policy rejected: "+err.Error())
+		}
+	}
 
 	return res, nil
 }
 
-func (v *PTXVerifier) verifyDNS(ptxFile *ptx.PtxFile) DnsResult {
+// errUnverifiedCommitment is the DNS error of PTX files whose anchors were
+// not checked because their proof did not verify
+const errUnverifiedCommitment = "Not checked: the anchor hostname derives from the commitment of an unverified proof"
+
+// dnsAfterProof reports whether the anchor checks need a verified proof
+func (v *PTXVerifier) dnsAfterProof() bool {
+	return v.Options.DNSAfterProof || v.Options.StrictMode
+}
+
+// checkReplay checks the nonce against the nonce store and the challenge
+// store and records it. Tracked nullifier hashes are recorded together with
+// the nonce, so that a crash cannot leave only one of them registered. It
+// returns false if the verification cannot go on.
+func (v *PTXVerifier) checkReplay(res *VerificationResult, ptxFile *ptx.PtxFile, meta map[string]interface{}, validity validityPeriod, now time.Time) bool {
+	res.Timing.begin(&res.Timing.Nonce)
+	if v.Options.RedisURL != "" || v.Options.NonceDB != "" || v.Options.NonceStore != nil {
+		nonceVal, hasNonce := meta["nonce"].(string)
+		nullifierHash, _ := proofSignals(ptxFile)
+		trackNullifier := v.Options.TrackNullifiers && nullifierHash != ""
+		if trackNullifier && strings.HasPrefix(nonceVal, NullifierKeyPrefix) {
+			res.fail(CodeMetadataInvalid, "Nonce uses the reserved prefix "+NullifierKeyPrefix)
+		} else if trackNullifier && !res.Zk.Valid {
+			// A skipped proof leaves the nullifier unverified
+			res.fail(CodeProofInvalid, "Nullifier not tracked: the proof was not verified")
+		} else if hasNonce || trackNullifier {
+			var st NonceStore = v.Options.NonceStore
+			if st == nil {
+				opened, err := openNonceStore(v.Options)
+				if err != nil {
+					res.fail(CodeNonceStore, "Failed to connect to nonce store: "+err.Error())
+					return false
+				}
+				defer opened.Close()
+				st = opened
+			}
+
+			// Remember the nonce until expiration, or for 5 minutes
+			maxTTL := v.Options.MaxNonceTTL
+			if maxTTL <= 0 {
+				maxTTL = DefaultMaxNonceTTL
+			}
+			expiry, clamped := validity.nonceExpiry(now, maxTTL)
+			if clamped {
+				res.Warnings = append(res.Warnings, "Nonce remembered until "+expiry.UTC().Format(time.RFC3339)+" only, before the token expires (MaxNonceTTL)")
+			}
+			if !trackNullifier {
+				var valid bool
+				err := v.call(v.context(), endpointNonceStore, func(context.Context) (err error) {
+					valid, err = st.CheckAndSetNonce(nonceVal, expiry)
+					return storeError(err)
+				})
+				if err != nil || !valid {
+					res.fail(CodeNonceReplayed, "Nonce invalid or replayed")
+				}
+			} else if multi, ok := st.(MultiNonceStore); !ok {
+				res.fail(CodeNonceStore, "Nonce store cannot track nullifiers (not a MultiNonceStore)")
+				return false
+			} else {
+				keys := []string{NullifierKeyPrefix + nullifierHash}
+				if hasNonce {
+					keys = append(keys, nonceVal)
+				}
+				var seen int
+				err := v.call(v.context(), endpointNonceStore, func(context.Context) (err error) {
+					seen, err = multi.CheckAndSetNonces(keys, expiry)
+					return storeError(err)
+				})
+				if err != nil {
+					res.fail(CodeNonceReplayed, "Nonce invalid or replayed")
+				} else if seen == 0 {
+					res.fail(CodeNullifierReplayed, "Nullifier already presented")
+				} else if seen > 0 {
+					res.fail(CodeNonceReplayed, "Nonce invalid or replayed")
+				}
+			}
+		}
+	}
+
+	// Challenge Check: the nonce, bound by the proof, must answer a
+	// challenge issued by this verifier
+	if v.Options.Challenges != nil {
+		challenge, _ := meta["nonce"].(string)
+		if challenge == "" {
+			res.fail(CodeChallengeInvalid, "Missing challenge (metadata nonce)")
+		} else if ok, err := v.Options.Challenges.Consume(v.context(), challenge); err != nil {
+			res.fail(CodeNonceStore, "Failed to check challenge: "+err.Error())
+			return false
+		} else if !ok {
+			res.fail(CodeChallengeInvalid, "Challenge unknown, expired or already answered")
+		}
+	}
+	return true
+}
+
+// legacySignalScan reports whether public signals are matched by value scan
+// instead of by layout index
+func (v *PTXVerifier) legacySignalScan() bool {
+	return v.Options.LegacySignalScan && !v.Options.StrictMode
+}
+
+// verifyAnchoring checks the DNS anchor of the PTX together with those of its
+// additional domains and its additional anchors, and records the outcome in
+// res
+func (v *PTXVerifier) verifyAnchoring(res *VerificationResult, ptxFile *ptx.PtxFile, domains []string, ev *evidence.Bundle) {
+	res.Dns = v.verifyDNS(ptxFile, ev)
+	dnsValid := res.Dns.Valid
+	if len(domains) > 0 {
+		res.Domains = v.verifyDomains(ptxFile, domains)
+		dnsValid = domainsHold(v.Options.DomainPolicy, res.Dns.Valid, res.Domains)
+		if dnsValid {
+			res.Warnings = append(res.Warnings, domainWarnings(res.Dns, res.Domains)...)
+		}
+	}
+	anchored := dnsValid
+	if len(ptxFile.GetAdditionalAnchors()) > 0 {
+		res.Anchors = v.verifyAnchors(ptxFile)
+		anchored = anchorsHold(ptxFile.GetAnchorPolicy(), dnsValid, res.Anchors)
+		if anchored {
+			res.Warnings = append(res.Warnings, anchorWarnings(res.Dns, res.Anchors)...)
+		}
+	}
+	if !anchored {
+		res.fail(CodeDNSAnchor)
+	} else if o := res.Dns.Outage; o != nil && res.Dns.Valid {
+		msg := "DNS anchor not verified, resolver unreachable (" + res.Dns.Error + "); accepted by " + o.Policy + " policy"
+		if o.CachedAt != nil {
+			msg += ", last seen " + o.CachedAt.UTC().Format(time.RFC3339)
+		}
+		res.Warnings = append(res.Warnings, msg)
+	}
+}
+
+// proofSignals returns the nullifier hash and commitment of the proof, or
+// empty strings if its proof data has fewer signals
+func proofSignals(ptxFile *ptx.PtxFile) (nullifierHash, commitment string) {
+	var pd struct {
+		PublicSignals []string `json:"publicSignals"`
+	}
+	if err := json.Unmarshal(ptxFile.GetProof().GetProofData(), &pd); err == nil && len(pd.PublicSignals) >= 2 {
+		return pd.PublicSignals[0], pd.PublicSignals[1]
+	}
+	return "", ""
+}
+
+// SignedMetadata returns the signed metadata of a PTX file in the form its
+// proof and DNS anchor bind: the canonical JSON when the proof_data wrapper
+// declares proofdata.MetadataJCS, the stored string otherwise
+func SignedMetadata(ptxFile *ptx.PtxFile) (string, error) {
+	signed := ptxFile.GetSignedMetadata()
+	w, err := proofdata.Parse(ptxFile.GetProof().GetProofData())
+	if err != nil {
+		// Proofs that cannot be parsed fail verification later on
+		return signed, nil
+	}
+	return w.BoundMetadata(signed)
+}
+
+// anchorRecord derives the DNS anchor a PTX file must be published under
+func anchorRecord(ptxFile *ptx.PtxFile) (*utils.AnchorRecord, error) {
 	doh := ptxFile.GetDohDetails()
 	if doh == nil {
-		return DnsResult{Error: "No DoH details found"}
+		return nil, errors.New("No DoH details found")
 	}
+	record, _, err := anchorFor(ptxFile, doh.GetDomainName(), doh.GetLabelMode(), dns.MatchExact)
+	return record, err
+}
 
+// anchorFor derives the anchor of a PTX file under domain, its primary domain
+// or one of its additional domains, in the given label mode. It also returns
+// the matcher recognizing the anchor among the values published there.
+func anchorFor(ptxFile *ptx.PtxFile, domain string, label ptx.LabelMode, mode dns.MatchMode) (*utils.AnchorRecord, anchorMatcher, error) {
 	com := ptxFile.GetProof()
 	if com == nil {
-		return DnsResult{Error: "No proof found for commitment extraction"}
+		return nil, nil, errors.New("No proof found for commitment extraction")
 	}
 
 	var pd struct {
 		PublicSignals []string `json:"publicSignals"`
 	}
 	if err := json.Unmarshal(com.ProofData, &pd); err != nil {
-		return DnsResult{Error: "Failed to parse proof public signals"}
+		return nil, nil, errors.New("Failed to parse proof public signals")
 	}
 
 	if len(pd.PublicSignals) < 2 {
-		return DnsResult{Error: "Insufficient public signals for commitment extraction"}
+		return nil, nil, errors.New("Insufficient public signals for commitment extraction")
 	}
 	commitment := pd.PublicSignals[1]
 
-	hostname, err := utils.DeriveHostnameFromCommitment(commitment, doh.GetDomainName())
+	metaRaw, err := SignedMetadata(ptxFile)
 	if err != nil {
-		return DnsResult{Error: "Hostname derivation failed: " + err.Error()}
+		return nil, nil, errors.New("Invalid metadata: " + err.Error())
+	}
+	if label == ptx.LabelMode_LABEL_FIXED {
+		return fixedAnchor(ptxFile, domain, commitment, metaRaw)
 	}
 
 	// Expected content in TXT record is SHA256 of metadata
-	expected := utils.Sha256(ptxFile.GetSignedMetadata())
-
-	// Check DNS
-	startTime := time.Now()
-	txt, err := dns.GetTXT(hostname)
-	elapsed := time.Since(startTime).Seconds() * 1000
+	record, err := utils.DeriveAnchorRecord(commitment, domain, metaRaw, utils.FormatHostnameVersion(ptxFile.Version()))
+	if err != nil {
+		return nil, nil, errors.New("Hostname derivation failed: " + err.Error())
+	}
+	return record, dns.NewAnchorMatcher(record.Value, mode), nil
+}
 
+func (v *PTXVerifier) verifyDNS(ptxFile *ptx.PtxFile, ev *evidence.Bundle) DnsResult {
+	doh := ptxFile.GetDohDetails()
+	if doh == nil {
+		return DnsResult{Error: "No DoH details found"}
+	}
+	record, matcher, err := anchorFor(ptxFile, doh.GetDomainName(), doh.GetLabelMode(), v.Options.DNSMatchMode)
 	if err != nil {
-		return DnsResult{Valid: false, Error: "DNS Lookup failed: " + err.Error(), DerivedHostname: hostname, FetchTimeMs: elapsed}
+		return DnsResult{Error: err.Error()}
+	}
+	return v.lookupRecord(record, matcher, ev)
+}
+
+// lookupRecord looks up an anchor record in DNS, with retries and the outage
+// policy. The lookups are recorded into ev when it is non-nil.
+func (v *PTXVerifier) lookupRecord(record *utils.AnchorRecord, matcher anchorMatcher, ev *evidence.Bundle) DnsResult {
+	hostname, expected := record.Hostname, record.Value
+
+	// Check DNS, retrying to absorb propagation delay right after issuance
+	res := DnsResult{DerivedHostname: hostname}
+	backoff := v.Options.DNSRetryBackoff
+	if backoff <= 0 {
+		backoff = defaultDNSRetryBackoff
 	}
 
-	found := false
-	for _, record := range txt {
-		if strings.Contains(record, expected) {
-			found = true
-			break
+	resolver, err := v.resolver()
+	if err != nil {
+		res.Error = "DNS Lookup failed: " + err.Error()
+		return res
+	}
+	endpoint := resolverEndpoint(resolver)
+	if ev != nil {
+		ev.DNS = &evidence.DNS{
+			Hostname:  hostname,
+			Expected:  expected,
+			MatchMode: v.Options.DNSMatchMode.String(),
 		}
 	}
+	answered := false
+	for attempt := 0; attempt <= v.Options.DNSRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-v.context().Done():
+				res.Error = "DNS Lookup failed: " + v.context().Err().Error()
+				return res
+			}
+			backoff *= 2
+		}
+
+		// Transient failures are retried right away under the resilience
+		// policy, each try counting as an attempt
+		var txt []string
+		err := v.call(v.context(), endpoint, func(ctx context.Context) error {
+			startTime := time.Now()
+			lookup, err := resolver.LookupTXT(ctx, hostname)
+			elapsed := time.Since(startTime).Seconds() * 1000
+			txt = lookup.Records
+			res.Timing = lookup.Timing
+			if ev != nil {
+				ev.DNS.Lookups = append(ev.DNS.Lookups, newEvidenceLookup(lookup, startTime, elapsed, err))
+			}
 
-	if found {
-		return DnsResult{Valid: true, DerivedHostname: hostname, FetchTimeMs: elapsed}
+			res.Attempts++
+			res.AttemptTimesMs = append(res.AttemptTimesMs, elapsed)
+			res.FetchTimeMs += elapsed
+			return err
+		})
+		if err != nil {
+			res.Error = "DNS Lookup failed: " + err.Error()
+			continue
+		}
+		answered = true
+
+		if matcher.MatchAny(txt) {
+			res.Valid = true
+			res.Error = ""
+			if v.outagePolicy() == dns.OutageAcceptIfCached {
+				v.anchorCache().Add(hostname, expected)
+			}
+			return res
+		}
+		res.Error = "No matching TXT record found (Expected: " + expected + ")"
 	}
 
-	return DnsResult{Valid: false, Error: "No matching TXT record found (Expected: " + expected + ")", DerivedHostname: hostname, FetchTimeMs: elapsed}
+	if !answered {
+		v.decideOutage(&res, hostname, expected)
+	}
+	return res
 }
 
-func (v *PTXVerifier) verifyProof(ptxFile *ptx.PtxFile, metaRaw string) ZkResult {
+func (v *PTXVerifier) verifyProof(ptxFile *ptx.PtxFile, metaRaw string, t *Timing) ZkResult {
 	proof := ptxFile.GetProof()
 	if proof == nil {
 		return ZkResult{Valid: false, Error: "No proof present"}
@@ -320,65 +926,116 @@ func (v *PTXVerifier) verifyProof(ptxFile *ptx.PtxFile, metaRaw string) ZkResult
 	if proof.GetProofSystem() != ptx.ProofSystem_GROTH16 {
 		return ZkResult{Skipped: true, Valid: false, Error: "Unsupported Proof System (only Groth16 supported)"}
 	}
+	defer t.end()
+	t.begin(&t.Semantic)
 
-	// Parse Proof Data to detect source
-	var wrapper struct {
-		Source        string          `json:"source"`
-		PublicSignals []string        `json:"publicSignals"`
-		Proof         json.RawMessage `json:"proof"`
-		ProofHex      string          `json:"proofHex"`
-	}
-	if err := json.Unmarshal(proof.ProofData, &wrapper); err != nil {
+	wrapper, err := proofdata.Parse(proof.ProofData)
+	if err != nil {
 		return ZkResult{Valid: false, Error: "Invalid proof wrapper JSON"}
 	}
+	envelope, err := v.proofEnvelope(proof, wrapper)
+	if err != nil {
+		return ZkResult{Valid: false, Error: "Invalid proof envelope: " + err.Error()}
+	}
+	if err := v.checkCircuitHash(proof.GetVerificationKeyId(), envelope); err != nil {
+		return ZkResult{Valid: false, Error: "Circuit identity mismatch: " + err.Error()}
+	}
+	// Reject malleable encodings before any signal is compared or placed in
+	// the witness
+	parsedSignals, err := signals.ParsePublicSignals(wrapper.PublicSignals)
+	if err != nil {
+		return ZkResult{Valid: false, Error: "Invalid public signals: " + err.Error()}
+	}
 
+	// The fqdn signal and everything below use the normalized domain, so a
+	// PTX naming "Example.COM." matches a proof for "example.com"
 	domain := ""
 	if ptxFile.GetDohDetails() != nil {
-		domain = ptxFile.GetDohDetails().GetDomainName()
+		domain, err = crypto.NormalizeDomain(ptxFile.GetDohDetails().GetDomainName())
+		if err != nil {
+			return ZkResult{Valid: false, Error: "Invalid anchor domain: " + err.Error()}
+		}
+	}
+
+	if v.Options.Discovery != nil && v.Options.KeySet == nil {
+		ks, err := v.discoverKeys(domain, ptxFile.GetTrustMethod())
+		if err != nil {
+			return ZkResult{Valid: false, Error: "Key discovery failed: " + err.Error()}
+		}
+		v.discovered = ks
 	}
 
 	// Semantic Verification (same for both proof types)
 	sig := signals.NewPTXSignals(domain, metaRaw, ptxFile.GetTrustMethod())
-	semVerify := sig.VerifyAgainstProof(wrapper.PublicSignals)
+	sig.LegacyScan = v.legacySignalScan()
+	sig.Pinned = !sig.LegacyScan
+	if !sig.LegacyScan {
+		layout, ok := signals.LayoutFor(v.circuitID(proof.GetVerificationKeyId()))
+		if !ok {
+			return ZkResult{Valid: false, Error: fmt.Sprintf("No public signal layout registered for verification key %q", proof.GetVerificationKeyId())}
+		}
+		sig.Layout = layout
+	}
+	semVerify := sig.VerifyParsed(parsedSignals)
 
 	if !semVerify.AllValid {
-		return ZkResult{Valid: false, Semantic: false, Error: "Semantic verification failed"}
+		return ZkResult{
+			Valid:          false,
+			Semantic:       false,
+			Error:          "Semantic verification failed (mismatched: " + strings.Join(semVerify.Mismatches(), ", ") + ")",
+			SemanticReport: &semVerify,
+		}
 	}
 
-	// Branch based on proof source
-	if wrapper.Source == "gnark_native" {
+	// Branch based on the proof format the envelope declares
+	t.begin(&t.ZK)
+	var res ZkResult
+	switch envelope.GetFormat() {
+	case ptx.ProofFormat_GNARK_NATIVE:
 		// For native Gnark proofs, re-derive public signals from PTX data
 		// Only nullifierHash and commitment come from the proof
-		return v.verifyNativeGnarkProof(wrapper.ProofHex, wrapper.PublicSignals, domain, metaRaw, ptxFile.GetTrustMethod())
+		res = v.verifyNativeGnarkProof(proof.GetVerificationKeyId(), wrapper, domain, metaRaw, ptxFile.GetTrustMethod())
+	default:
+		res = ZkResult{Valid: false, Error: "Unsupported proof format " + envelope.GetFormat().String() + " (legacy Circom proofs no longer supported)"}
 	}
-
-	return ZkResult{Valid: false, Error: "Unsupported proof source (legacy Circom proofs no longer supported)"}
+	// A proof of another circuit definition fails the pairing check; say so
+	// rather than leaving it unexplained
+	if !res.Valid && envelope.GetCircuitHash() != "" {
+		if err := localCircuitHash(v.circuitID(proof.GetVerificationKeyId()), envelope); err != nil {
+			res.Error = "Circuit identity mismatch: " + err.Error()
+		}
+	}
+	if h := envelope.GetCircuitHash(); h != "" {
+		res.CircuitIdentity = circuit.Identity(envelope.GetCircuitId(), h)
+	}
+	res.Semantic = true
+	res.SemanticReport = &semVerify
+	return res
 }
 
-func (v *PTXVerifier) verifyNativeGnarkProof(proofHex string, proofSignals []string, domain string, metaRaw string, trustMethod ptx.TrustMethod) ZkResult {
+func (v *PTXVerifier) verifyNativeGnarkProof(keyID string, pd *proofdata.Wrapper, domain string, metaRaw string, trustMethod ptx.TrustMethod) ZkResult {
 	startTime := time.Now()
+	proofSignals := pd.PublicSignals
 
-	// Decode proof bytes from hex
-	proofBytes, err := hex.DecodeString(proofHex)
+	// Decode proof bytes (hex or base64, see proofdata) into a pooled buffer
+	buf := getDecodeBuffer(0)
+	defer putDecodeBuffer(buf)
+	proofBytes, err := pd.AppendNativeProof(*buf)
 	if err != nil {
-		return ZkResult{Valid: false, Error: "Failed to decode proof hex: " + err.Error()}
+		return ZkResult{Valid: false, Error: "Failed to decode proof: " + err.Error()}
 	}
+	*buf = proofBytes
 
-	// Compile the same circuit to get the constraint system
-	var dohCircuit circuit.DoHCircuit
-	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &dohCircuit)
-	if err != nil {
-		return ZkResult{Valid: false, Error: "Circuit compilation failed: " + err.Error()}
-	}
-
-	// Load cached VK (must match the prover's VK)
-	gnarkVK, err := loadCachedVK(ccs)
+	// Load cached VKs (must match the prover's VK). Keys are parsed once per
+	// path and the circuit is only compiled if a setup has to be run.
+	keys, err := v.candidateKeys(keyID)
 	if err != nil {
 		return ZkResult{Valid: false, Error: "Failed to load VK: " + err.Error()}
 	}
 
 	// Reconstruct the proof from bytes
-	proof := groth16.NewProof(ecc.BN254)
+	proof := proofPool.Get().(groth16.Proof)
+	defer proofPool.Put(proof)
 	_, err = proof.ReadFrom(bytes.NewReader(proofBytes))
 	if err != nil {
 		return ZkResult{Valid: false, Error: "Failed to deserialize proof: " + err.Error()}
@@ -386,61 +1043,231 @@ func (v *PTXVerifier) verifyNativeGnarkProof(proofHex string, proofSignals []str
 
 	// RE-DERIVE public signals from PTX data (SECURITY CRITICAL)
 	// Only nullifierHash and commitment come from the proof
-	// fqdn, metadataHashP1, metadataHashP2, trustMethod are derived from PTX file
+	// fqdn, the metadata signals and trustMethod are derived from PTX file
+	layout := v.witnessLayout(keyID)
+	pw, err := getPublicWitness(layout.Len())
+	if err != nil {
+		return ZkResult{Valid: false, Error: "Witness creation failed: " + err.Error()}
+	}
+	defer putPublicWitness(pw)
+	if err := fillNativeWitness(pw, layout, proofSignals, domain, metaRaw, trustMethod); err != nil {
+		return ZkResult{Valid: false, Error: err.Error()}
+	}
 
-	if len(proofSignals) < 2 {
-		return ZkResult{Valid: false, Error: "Insufficient public signals in proof (need nullifierHash and commitment)"}
+	// Verify the proof. During a rotation overlap more than one key is valid
+	// and the proof is accepted under the first one that verifies it.
+	for _, enc := range v.limbEncodings(keyID) {
+		if err := fillMetadataHash(pw, layout, metaRaw, enc); err != nil {
+			return ZkResult{Valid: false, Error: err.Error()}
+		}
+
+		for _, k := range keys {
+			err = k.vk.Verify(proof, pw.w)
+			if err == nil {
+				elapsed := time.Since(startTime).Seconds() * 1000
+				return ZkResult{Valid: true, Semantic: true, ProofTimeMs: elapsed, KeyID: k.id}
+			}
+		}
 	}
+	elapsed := time.Since(startTime).Seconds() * 1000
+
+	if len(keys) > 1 {
+		return ZkResult{Valid: false, ProofTimeMs: elapsed, Error: fmt.Sprintf("Native Gnark verification failed under all %d valid keys: %v", len(keys), err)}
+	}
+	return ZkResult{Valid: false, ProofTimeMs: elapsed, Error: "Native Gnark verification failed: " + err.Error()}
+}
 
+// fillNativeWitness sets the public inputs of a native proof: nullifierHash
+// and commitment from the proof's signals, the others re-derived from the
+// PTX. The metadata hash limbs are set by fillMetadataHash.
+func fillNativeWitness(pw *publicWitness, layout *signals.SignalLayout, proofSignals []string, domain, metaRaw string, trustMethod ptx.TrustMethod) error {
 	// Get nullifierHash and commitment from proof (these are the actual proof outputs)
-	nullifierHash := proofSignals[0]
-	commitment := proofSignals[1]
+	nullifierHash, ok := layout.Lookup(proofSignals, signals.SignalNullifierHash)
+	commitment, ok2 := layout.Lookup(proofSignals, signals.SignalCommitment)
+	if !ok || !ok2 {
+		return errors.New("Insufficient public signals in proof (need nullifierHash and commitment)")
+	}
 
 	// Re-derive fqdn hash using Poseidon (same as prover)
 	fqdnHash, err := crypto.PoseidonHashString(domain)
 	if err != nil {
-		return ZkResult{Valid: false, Error: "Failed to compute fqdn hash: " + err.Error()}
+		return fmt.Errorf("Failed to compute fqdn hash: %w", err)
 	}
 
-	// Re-derive metadata hash parts
-	metaP1, metaP2 := crypto.SplitMetadataHash(metaRaw)
-
-	// Build public witness with re-derived signals
-	assignment := circuit.DoHCircuit{
-		NullifierHash:  fromStringV(nullifierHash),
-		Commitment:     fromStringV(commitment),
-		Fqdn:           fqdnHash,
-		MetadataHashP1: metaP1,
-		MetadataHashP2: metaP2,
-		TrustMethod:    int(trustMethod),
-		// Private inputs not needed for public witness
-		Nullifier: 0,
-		Secret:    0,
+	index := func(name string) int {
+		i, _ := layout.Index(name)
+		return i
 	}
-
-	witness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	pw.vec[index(signals.SignalNullifierHash)], err = crypto.ParseFr(nullifierHash)
 	if err != nil {
-		return ZkResult{Valid: false, Error: "Witness creation failed: " + err.Error()}
+		return fmt.Errorf("Invalid nullifierHash signal: %w", err)
+	}
+	pw.vec[index(signals.SignalCommitment)], err = crypto.ParseFr(commitment)
+	if err != nil {
+		return fmt.Errorf("Invalid commitment signal: %w", err)
 	}
+	pw.vec[index(signals.SignalFqdn)].Set(fqdnHash)
+	pw.vec[index(signals.SignalTrustMethod)].SetUint64(uint64(trustMethod))
+	if layout.MetadataChunks > 0 {
+		if err := layout.FillMetadata(pw.vec, metaRaw); err != nil {
+			return fmt.Errorf("Failed to pack metadata: %w", err)
+		}
+	}
+	if layout.BindsScope() {
+		if err := layout.FillBinding(pw.vec, metaRaw); err != nil {
+			return fmt.Errorf("Failed to derive audience and scope hashes: %w", err)
+		}
+	}
+	return nil
+}
 
-	publicWitness, err := witness.Public()
+// fillMetadataHash re-derives the metadata hash limbs in enc, unless the
+// circuit hashes the metadata itself
+func fillMetadataHash(pw *publicWitness, layout *signals.SignalLayout, metaRaw string, enc crypto.LimbEncoding) error {
+	if layout.MetadataChunks > 0 {
+		return nil
+	}
+	metaP1, metaP2, err := crypto.SplitMetadataHashWith(metaRaw, enc)
 	if err != nil {
-		return ZkResult{Valid: false, Error: "Public witness extraction failed: " + err.Error()}
+		return fmt.Errorf("Failed to split metadata hash: %w", err)
 	}
+	i1, _ := layout.Index(signals.SignalMetadataHashP1)
+	i2, _ := layout.Index(signals.SignalMetadataHashP2)
+	pw.vec[i1].Set(metaP1)
+	pw.vec[i2].Set(metaP2)
+	return nil
+}
 
-	// Verify the proof
-	err = groth16.Verify(proof, gnarkVK, publicWitness)
-	elapsed := time.Since(startTime).Seconds() * 1000
+// witnessLayout returns the public input layout of the circuit of keyID.
+// Proofs under unregistered key IDs are assumed to use the default circuit.
+func (v *PTXVerifier) witnessLayout(keyID string) *signals.SignalLayout {
+	if layout, ok := signals.LayoutFor(v.circuitID(keyID)); ok {
+		return layout
+	}
+	layout, _ := signals.LayoutFor(signals.DefaultVerificationKeyID)
+	return layout
+}
 
-	if err != nil {
-		return ZkResult{Valid: false, Error: "Native Gnark verification failed: " + err.Error()}
+// limbEncodings returns the metadata limb encodings a proof under keyID may
+// use. Proofs without a registered layout predate versioned encodings, so
+// both orders are tried for them.
+func (v *PTXVerifier) limbEncodings(keyID string) []crypto.LimbEncoding {
+	if layout, ok := signals.LayoutFor(v.circuitID(keyID)); ok && !v.legacySignalScan() {
+		return []crypto.LimbEncoding{layout.Limbs}
 	}
+	return []crypto.LimbEncoding{crypto.CanonicalLimbEncoding, crypto.LimbsHighLow}
+}
 
-	return ZkResult{Valid: true, Semantic: true, ProofTimeMs: elapsed}
+type candidateKey struct {
+	id string
+	vk *PreparedVK
 }
 
-func fromStringV(s string) frontend.Variable {
-	var i big.Int
-	i.SetString(s, 10)
-	return i
+// candidateKeys returns the keys a proof issued under keyID may verify
+// against: the valid entries of the key set, or the single configured key
+func (v *PTXVerifier) candidateKeys(keyID string) ([]candidateKey, error) {
+	ks := v.keySet()
+	if ks == nil {
+		vk, err := v.verifyingKey(keyID)
+		if err != nil {
+			return nil, err
+		}
+		return []candidateKey{{vk: vk}}, nil
+	}
+
+	entries := ks.Candidates(keyID, time.Now())
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no currently valid key for %q in key set", keyID)
+	}
+
+	var keys []candidateKey
+	var lastErr error
+	for _, e := range entries {
+		vk, err := e.load(ks.fetcher())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		keys = append(keys, candidateKey{id: e.ID, vk: vk})
+	}
+	if len(keys) == 0 {
+		return nil, lastErr
+	}
+	return keys, nil
+}
+
+// proofEnvelope returns the envelope of a proof after validating it, see
+// proofdata.ValidateEnvelope, and checking that it names the circuit of the
+// proof's verification key. Files written before envelopes get the envelope
+// inferred from their proof data, except in strict mode.
+func (v *PTXVerifier) proofEnvelope(proof *ptx.ZkProof, w *proofdata.Wrapper) (*ptx.ProofEnvelope, error) {
+	env := proof.GetEnvelope()
+	if env == nil {
+		if v.Options.StrictMode {
+			return nil, errors.New("no proof envelope (required in strict mode)")
+		}
+		return proofdata.InferEnvelope(proof.GetProofSystem(), w), nil
+	}
+	if err := proofdata.ValidateEnvelope(proof, w); err != nil {
+		return nil, err
+	}
+	keyID := proof.GetVerificationKeyId()
+	if circuit := v.circuitID(keyID); env.GetCircuitId() != circuit {
+		return nil, fmt.Errorf("envelope declares circuit %q, verification key %q is for circuit %q", env.GetCircuitId(), keyID, circuit)
+	}
+	return env, nil
+}
+
+// circuitID maps a proof's verification key ID to the circuit it belongs to.
+// Key set entries may be referenced by their own ID.
+func (v *PTXVerifier) circuitID(keyID string) string {
+	if ks := v.keySet(); ks != nil {
+		for _, k := range ks.Keys {
+			if k.ID == keyID {
+				return k.circuit()
+			}
+		}
+	}
+	return keyID
+}
+
+// keySet returns the configured or discovered key set, or nil if a single
+// key is used
+func (v *PTXVerifier) keySet() *KeySet {
+	if v.Options.KeySet != nil {
+		return v.Options.KeySet
+	}
+	return v.discovered
+}
+
+// verifyingKey returns the parsed key from VKData, or from the key file of
+// the circuit of keyID
+func (v *PTXVerifier) verifyingKey(keyID string) (*PreparedVK, error) {
+	if len(v.Options.VKData) > 0 {
+		return cachedVKBytes(v.Options.VKData)
+	}
+	circuitID := keyID
+	if _, ok := signals.LayoutFor(circuitID); !ok {
+		circuitID = signals.DefaultVerificationKeyID
+	}
+	return cachedVK(v.vkPathFor(circuitID))
+}
+
+func (v *PTXVerifier) vkPath() string {
+	return v.vkPathFor(signals.DefaultVerificationKeyID)
+}
+
+// vkPathFor returns the configured key file, or the default key file of the
+// circuit of circuitID
+func (v *PTXVerifier) vkPathFor(circuitID string) string {
+	if v.Options.VKPath != "" {
+		return v.Options.VKPath
+	}
+	switch circuitID {
+	case signals.MetadataSHA256KeyID:
+		return filepath.Join(v.keyDir, nativeSHA256VKPath)
+	case signals.ScopeBoundKeyID:
+		return filepath.Join(v.keyDir, nativeScopeBoundVKPath)
+	}
+	return filepath.Join(v.keyDir, nativeVKPath)
 }
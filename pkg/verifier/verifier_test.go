@@ -0,0 +1,495 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/crypto"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/dns"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ipfs"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/resilience"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+type nativeFixture struct {
+	proof   *proofdata.Wrapper
+	domain  string
+	metaRaw string
+}
+
+// newNativeFixture generates keys and a native proof in a temporary working
+// directory
+func newNativeFixture(b *testing.B) nativeFixture {
+	b.Helper()
+	b.Chdir(b.TempDir())
+
+	p := prover.NewProver()
+	metadata := map[string]interface{}{"role": "validator"}
+	inputs, err := p.GenerateCircuitInputs("example.com", metadata, "12345", "67890", 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	proofJSON, err := p.GenerateProofNative(inputs)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	wrapper, err := proofdata.Parse(proofJSON)
+	if err != nil {
+		b.Fatal(err)
+	}
+	metaRaw, _ := crypto.MarshalCanonical(metadata)
+
+	return nativeFixture{
+		proof:   wrapper,
+		domain:  "example.com",
+		metaRaw: string(metaRaw),
+	}
+}
+
+// resetCaches drops the process-wide circuit and key caches
+func resetCaches() {
+	ccsMu.Lock()
+	ccsCached = map[string]*compilation{}
+	ccsMu.Unlock()
+	vkCacheMu.Lock()
+	vkCache = map[string]*PreparedVK{}
+	vkCacheMu.Unlock()
+	publicWitnessPoolsMu.Lock()
+	publicWitnessPools = map[int]*sync.Pool{}
+	publicWitnessPoolsMu.Unlock()
+}
+
+func BenchmarkVerifyNativeGnarkProof(b *testing.B) {
+	fx := newNativeFixture(b)
+	v := NewPTXVerifier(VerificationOptions{})
+
+	// Warm the caches once so the loop measures the steady state
+	if res := v.verifyNativeGnarkProof(signals.DefaultVerificationKeyID, fx.proof, fx.domain, fx.metaRaw, ptx.TrustMethod_DOH); !res.Valid {
+		b.Fatalf("fixture proof invalid: %s", res.Error)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res := v.verifyNativeGnarkProof(signals.DefaultVerificationKeyID, fx.proof, fx.domain, fx.metaRaw, ptx.TrustMethod_DOH)
+		if !res.Valid {
+			b.Fatal(res.Error)
+		}
+	}
+}
+
+// BenchmarkVerifyNativeGnarkProofCold drops all caches and pools before every
+// verification, approximating the allocation profile before object reuse.
+func BenchmarkVerifyNativeGnarkProofCold(b *testing.B) {
+	fx := newNativeFixture(b)
+	v := NewPTXVerifier(VerificationOptions{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resetCaches()
+		res := v.verifyNativeGnarkProof(signals.DefaultVerificationKeyID, fx.proof, fx.domain, fx.metaRaw, ptx.TrustMethod_DOH)
+		if !res.Valid {
+			b.Fatal(res.Error)
+		}
+	}
+}
+
+// pairingCircuit has as many public inputs as the DoH circuit, so the
+// pairing benchmarks below measure the same verification work
+type pairingCircuit struct {
+	Public [6]frontend.Variable `gnark:",public"`
+	Secret frontend.Variable
+}
+
+func (c *pairingCircuit) Define(api frontend.API) error {
+	for _, p := range c.Public {
+		api.AssertIsEqual(api.Mul(c.Secret, c.Secret), p)
+	}
+	return nil
+}
+
+type pairingFixture struct {
+	vk    groth16.VerifyingKey
+	proof groth16.Proof
+	pw    witness.Witness
+	wrong witness.Witness
+}
+
+func newPairingFixture(tb testing.TB) pairingFixture {
+	tb.Helper()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &pairingCircuit{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	assign := func(secret, public int) witness.Witness {
+		a := &pairingCircuit{Secret: secret}
+		for i := range a.Public {
+			a.Public[i] = public
+		}
+		w, err := frontend.NewWitness(a, ecc.BN254.ScalarField())
+		if err != nil {
+			tb.Fatal(err)
+		}
+		return w
+	}
+	full := assign(3, 9)
+	proof, err := groth16.Prove(ccs, pk, full)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	pw, _ := full.Public()
+	wrong, _ := assign(4, 16).Public()
+	return pairingFixture{vk: vk, proof: proof, pw: pw, wrong: wrong}
+}
+
+func TestPreparedVKMatchesGroth16(t *testing.T) {
+	fx := newPairingFixture(t)
+	pvk, err := PrepareVK(fx.vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := groth16.Verify(fx.proof, fx.vk, fx.pw); err != nil {
+		t.Fatalf("groth16.Verify rejected a valid proof: %v", err)
+	}
+	if err := pvk.Verify(fx.proof, fx.pw); err != nil {
+		t.Fatalf("PreparedVK rejected a valid proof: %v", err)
+	}
+	if err := pvk.Verify(fx.proof, fx.wrong); err == nil {
+		t.Fatal("PreparedVK accepted a proof for the wrong public inputs")
+	}
+}
+
+// BenchmarkGroth16Verify and BenchmarkPreparedVKVerify compare gnark's
+// verification with the prepared key used by the native verify path
+func BenchmarkGroth16Verify(b *testing.B) {
+	fx := newPairingFixture(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := groth16.Verify(fx.proof, fx.vk, fx.pw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPreparedVKVerify(b *testing.B) {
+	fx := newPairingFixture(b)
+	pvk, err := PrepareVK(fx.vk)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pvk.Verify(fx.proof, fx.pw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// roundTripFunc serves HTTP requests in-process
+type roundTripFunc func(*http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r), nil
+}
+
+func TestAdditionalAnchors(t *testing.T) {
+	wellKnown := func(domain string) *ptx.Anchor {
+		return &ptx.Anchor{
+			TrustMethod: ptx.TrustMethod_WELL_KNOWN,
+			Details:     &ptx.Anchor_WellKnownDetails{WellKnownDetails: &ptx.WellKnownAnchor{DomainName: domain}},
+		}
+	}
+	ptxFile := &ptx.PtxFile{
+		TrustMethod:       ptx.TrustMethod_DOH,
+		Proof:             &ptx.ZkProof{ProofData: []byte(`{"publicSignals":["1","2"]}`)},
+		SignedMetadata:    `{"role":"validator"}`,
+		Anchor:            &ptx.PtxFile_DohDetails{DohDetails: &ptx.DohAnchor{DomainName: "example.com"}},
+		AdditionalAnchors: []*ptx.Anchor{wellKnown("Example.COM."), wellKnown("evil.example")},
+	}
+	record, err := anchorRecord(ptxFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := NewPTXVerifier(VerificationOptions{AnchorHTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) *http.Response {
+		rec := httptest.NewRecorder()
+		if r.URL.String() == record.WellKnownURL() {
+			rec.WriteString("unrelated\n" + record.Value + "\n")
+		} else {
+			rec.WriteHeader(http.StatusNotFound)
+		}
+		return rec.Result()
+	})}})
+	results := v.verifyAnchors(ptxFile)
+	if len(results) != 2 {
+		t.Fatalf("got %d anchor results, want 2", len(results))
+	}
+	if !results[0].Valid {
+		t.Errorf("well-known anchor on the PTX domain rejected: %s", results[0].Error)
+	}
+	if results[1].Valid || results[1].Location != "" {
+		t.Errorf("anchor on another domain was checked: %+v", results[1])
+	}
+
+	if !anchorsHold(ptx.AnchorPolicy_ANCHOR_POLICY_ANY, false, results) {
+		t.Error("any: one valid anchor should suffice")
+	}
+	if anchorsHold(ptx.AnchorPolicy_ANCHOR_POLICY_ALL, true, results) {
+		t.Error("all: a failed anchor should reject")
+	}
+	if !anchorsHold(ptx.AnchorPolicy_ANCHOR_POLICY_ALL, true, results[:1]) {
+		t.Error("all: every anchor valid should accept")
+	}
+}
+
+func TestIPFSAnchor(t *testing.T) {
+	ptxFile := &ptx.PtxFile{
+		TrustMethod:    ptx.TrustMethod_DOH,
+		Proof:          &ptx.ZkProof{ProofData: []byte(`{"publicSignals":["1","2"]}`)},
+		SignedMetadata: `{"role":"validator"}`,
+		Anchor:         &ptx.PtxFile_DohDetails{DohDetails: &ptx.DohAnchor{DomainName: "example.com"}},
+	}
+	record, err := anchorRecord(ptxFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := record.Document()
+	cid := ipfs.CID(doc)
+	ptxFile.AdditionalAnchors = []*ptx.Anchor{{
+		TrustMethod: ptx.TrustMethod_IPFS,
+		Details:     &ptx.Anchor_IpfsDetails{IpfsDetails: &ptx.IpfsAnchor{DomainName: "example.com", Cid: cid}},
+	}}
+
+	served := doc
+	v := NewPTXVerifier(VerificationOptions{IPFSGateway: "https://gw.example", AnchorHTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) *http.Response {
+		rec := httptest.NewRecorder()
+		if r.URL.String() == "https://gw.example/ipfs/"+cid+"?format=raw" {
+			rec.Write(served)
+		} else {
+			rec.WriteHeader(http.StatusNotFound)
+		}
+		return rec.Result()
+	})}})
+	if res := v.verifyAnchors(ptxFile); !res[0].Valid {
+		t.Fatalf("IPFS anchor rejected: %s", res[0].Error)
+	}
+
+	// The gateway is not trusted
+	served = []byte("ptx-anchor-v1\nforged\n")
+	if res := v.verifyAnchors(ptxFile); res[0].Valid {
+		t.Error("content not matching the CID accepted")
+	}
+	// The CID must address the document of this PTX
+	ptxFile.AdditionalAnchors[0].GetIpfsDetails().Cid = ipfs.CID(served)
+	if res := v.verifyAnchors(ptxFile); res[0].Valid || res[0].Location != "" {
+		t.Errorf("CID of another document accepted: %+v", res[0])
+	}
+}
+
+func TestAdditionalDomains(t *testing.T) {
+	ptxFile := &ptx.PtxFile{
+		TrustMethod:    ptx.TrustMethod_DOH,
+		Proof:          &ptx.ZkProof{ProofData: []byte(`{"publicSignals":["1","2"]}`)},
+		SignedMetadata: `{"additional_domains":["Example.ORG.","example.net"]}`,
+		Anchor: &ptx.PtxFile_DohDetails{DohDetails: &ptx.DohAnchor{
+			DomainName:            "example.com",
+			AdditionalDomainNames: []string{"example.org", "example.net"},
+		}},
+	}
+	meta := map[string]interface{}{"additional_domains": []interface{}{"Example.ORG.", "example.net"}}
+	domains, err := additionalDomains(ptxFile, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 2 || domains[0] != "example.org" {
+		t.Fatalf("got domains %v", domains)
+	}
+	for _, m := range []map[string]interface{}{
+		{},
+		{"additional_domains": []interface{}{"example.net", "example.org"}},
+		{"additional_domains": "example.org"},
+	} {
+		if _, err := additionalDomains(ptxFile, m); err == nil {
+			t.Errorf("domains not bound by metadata %v accepted", m)
+		}
+	}
+
+	record, _, err := anchorFor(ptxFile, "example.org", ptx.LabelMode_LABEL_COMMITMENT, dns.MatchExact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewPTXVerifier(VerificationOptions{DNSResolver: dns.StaticResolver{Hostname: record.Hostname, Records: []string{record.Value}}})
+	results := v.verifyDomains(ptxFile, domains)
+	if len(results) != 2 || !results[0].Valid || results[1].Valid {
+		t.Fatalf("got domain results %+v", results)
+	}
+
+	if !domainsHold(DomainsAny, false, results) {
+		t.Error("any: one valid domain should suffice")
+	}
+	if domainsHold(DomainsAll, true, results) {
+		t.Error("all: a failed domain should reject")
+	}
+	v.Options.DomainPolicy = DomainsPrimary
+	if results := v.verifyDomains(ptxFile, domains); !domainsHold(DomainsPrimary, true, results) {
+		t.Error("primary: the primary domain alone should accept")
+	}
+}
+
+// flakyResolver fails its first lookups
+type flakyResolver struct {
+	dns.StaticResolver
+	failures int
+}
+
+func (r *flakyResolver) LookupTXT(ctx context.Context, hostname string) (*dns.TXTLookup, error) {
+	if r.failures > 0 {
+		r.failures--
+		return &dns.TXTLookup{Timing: &dns.Timing{}}, errors.New("connection reset")
+	}
+	return r.StaticResolver.LookupTXT(ctx, hostname)
+}
+
+func TestLookupResilience(t *testing.T) {
+	record := &utils.AnchorRecord{Hostname: "ptx.example.com", Value: "abc"}
+	resolver := &flakyResolver{StaticResolver: dns.StaticResolver{Records: []string{"abc"}}, failures: 1}
+	v := NewPTXVerifier(VerificationOptions{
+		DNSResolver: resolver,
+		Resilience:  &resilience.Policy{Retries: 1, BaseDelay: time.Millisecond},
+		Breakers:    resilience.NewBreakers(),
+	})
+	if res := v.lookupRecord(record, dns.NewAnchorMatcher("abc", dns.MatchExact), nil); !res.Valid || res.Attempts != 2 {
+		t.Fatalf("transient failure not retried: %+v", res)
+	}
+
+	// An open breaker is an outage, without calling the resolver
+	resolver.failures = 1
+	v.Options.Resilience = &resilience.Policy{FailureThreshold: 1}
+	v.lookupRecord(record, dns.NewAnchorMatcher("abc", dns.MatchExact), nil)
+	res := v.lookupRecord(record, dns.NewAnchorMatcher("abc", dns.MatchExact), nil)
+	if res.Valid || res.Attempts != 0 || !strings.Contains(res.Error, resilience.ErrOpen.Error()) {
+		t.Fatalf("open breaker: %+v", res)
+	}
+}
+
+func TestFixedLabelAnchor(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	ptxFile := &ptx.PtxFile{
+		TrustMethod:    ptx.TrustMethod_DOH,
+		Proof:          &ptx.ZkProof{ProofData: []byte(`{"publicSignals":["1","2"]}`)},
+		SignedMetadata: `{"role":"admin"}`,
+		Anchor: &ptx.PtxFile_DohDetails{DohDetails: &ptx.DohAnchor{
+			DomainName: "example.com",
+			LabelMode:  ptx.LabelMode_LABEL_FIXED,
+		}},
+	}
+	payload, err := utils.AnchorSigningPayload("2", "example.com", ptxFile.SignedMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ptxFile.AnchorSignature = ed25519.Sign(key, payload)
+
+	lookup := func(k ed25519.PublicKey) DnsResult {
+		record, err := utils.FixedAnchorRecord("example.com", k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := NewPTXVerifier(VerificationOptions{DNSResolver: dns.StaticResolver{Hostname: record.Hostname, Records: []string{"unrelated", record.Value}}})
+		return v.verifyDNS(ptxFile, nil)
+	}
+	if res := lookup(pub); !res.Valid {
+		t.Fatalf("fixed anchor not verified: %s", res.Error)
+	}
+	if lookup(other).Valid {
+		t.Error("fixed anchor of another key accepted")
+	}
+
+	// The signature binds the metadata
+	ptxFile.SignedMetadata = `{"role":"user"}`
+	if lookup(pub).Valid {
+		t.Error("fixed anchor accepted for other metadata")
+	}
+	ptxFile.AnchorSignature = nil
+	if res := lookup(pub); res.Valid || res.Error == "" {
+		t.Error("fixed anchor accepted without a signature")
+	}
+}
+
+func TestScopeBoundProof(t *testing.T) {
+	t.Chdir(t.TempDir())
+	resetCaches()
+	defer resetCaches()
+
+	p := prover.NewProver()
+	p.KeyID = signals.ScopeBoundKeyID
+	metadata := map[string]interface{}{"audience": "api.example.com", "scopes": []interface{}{"read"}}
+	inputs, err := p.GenerateCircuitInputs("example.com", metadata, "12345", "67890", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofJSON, err := p.GenerateProofNative(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapper, err := proofdata.Parse(proofJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metaRaw, _ := crypto.MarshalCanonical(metadata)
+
+	v := NewPTXVerifier(VerificationOptions{IntendedAudience: []string{"api.example.com"}})
+	if res := v.verifyNativeGnarkProof(signals.ScopeBoundKeyID, wrapper, "example.com", string(metaRaw), ptx.TrustMethod_DOH); !res.Valid {
+		t.Fatalf("scope-bound proof rejected: %s", res.Error)
+	}
+
+	// Presenting the proof with another audience signal breaks the semantic
+	// check
+	layout, _ := signals.LayoutFor(signals.ScopeBoundKeyID)
+	i, _ := layout.Index(signals.SignalAudienceHash)
+	tampered := append([]string(nil), wrapper.PublicSignals...)
+	tampered[i] = crypto.AudienceHash("other.example.com").String()
+	sig := signals.NewPTXSignals("example.com", string(metaRaw), ptx.TrustMethod_DOH)
+	sig.Layout = layout
+	if got := sig.VerifyAgainstProof(tampered); got.AllValid || got.AudienceHash {
+		t.Errorf("audience of another token accepted: %+v", got)
+	}
+
+	ptxFile := &ptx.PtxFile{Proof: &ptx.ZkProof{VerificationKeyId: signals.ScopeBoundKeyID, ProofData: proofJSON}}
+	if !v.audienceBound(ptxFile) {
+		t.Error("intended audience not found in the audienceHash signal")
+	}
+	v.Options.IntendedAudience = []string{"other.example.com"}
+	if v.audienceBound(ptxFile) {
+		t.Error("audienceHash signal matched another audience")
+	}
+}
@@ -0,0 +1,115 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/circuit"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// ccsCached holds one compilation per circuit, keyed by the key ID of
+// the circuit
+var (
+	ccsMu     sync.Mutex
+	ccsCached = map[string]*compilation{}
+)
+
+type compilation struct {
+	once sync.Once
+	ccs  constraint.ConstraintSystem
+	err  error
+
+	// hash is the constraint system hash, computed on first use
+	hashOnce sync.Once
+	hash     string
+	hashErr  error
+}
+
+// compiledCircuit compiles the circuit of a key ID once and returns the
+// cached result
+func compiledCircuit(circuitID string) (constraint.ConstraintSystem, error) {
+	ccsMu.Lock()
+	c, ok := ccsCached[circuitID]
+	if !ok {
+		c = &compilation{}
+		ccsCached[circuitID] = c
+	}
+	ccsMu.Unlock()
+
+	c.once.Do(func() {
+		var def frontend.Circuit
+		if def, c.err = circuit.ForKeyID(circuitID); c.err == nil {
+			c.ccs, c.err = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, def)
+		}
+	})
+	return c.ccs, c.err
+}
+
+// circuitHash returns the constraint system hash of the circuit of a key ID
+// as compiled by this build, see circuit.Hash
+func circuitHash(circuitID string) (string, error) {
+	ccs, err := compiledCircuit(circuitID)
+	if err != nil {
+		return "", err
+	}
+	ccsMu.Lock()
+	c := ccsCached[circuitID]
+	ccsMu.Unlock()
+	c.hashOnce.Do(func() {
+		c.hash, c.hashErr = circuit.Hash(ccs)
+	})
+	return c.hash, c.hashErr
+}
+
+// cachedVK returns the verification key stored at path, parsing it only once.
+// Keys are cached by absolute path so a change of working directory does not
+// return a key loaded from elsewhere. A missing file is an error: verifiers
+// never run a setup, whose key would not match the prover's.
+func cachedVK(path string) (*PreparedVK, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve vk path: %w", err)
+	}
+
+	vkCacheMu.Lock()
+	defer vkCacheMu.Unlock()
+
+	if vk, ok := vkCache[abs]; ok {
+		return vk, nil
+	}
+
+	vk, err := loadVKFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	prepared, err := PrepareVK(vk)
+	if err != nil {
+		return nil, err
+	}
+	vkCache[abs] = prepared
+	return prepared, nil
+}
+
+// loadVKFile reads the verification key at path
+func loadVKFile(path string) (groth16.VerifyingKey, error) {
+	vkFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("verification key: %w", err)
+	}
+	defer vkFile.Close()
+
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(vkFile); err != nil {
+		return nil, fmt.Errorf("failed to read vk: %w", err)
+	}
+	return vk, nil
+}
@@ -0,0 +1,17 @@
+//go:build js && wasm
+
+package verifier
+
+import "errors"
+
+var errNoFilesystem = errors.New("verification key files are not available in js/wasm builds; set VKData")
+
+// cachedVK is unavailable without a filesystem. Keys must be passed as VKData.
+func cachedVK(string) (*PreparedVK, error) {
+	return nil, errNoFilesystem
+}
+
+// circuitHash is unavailable: js/wasm builds do not compile circuits
+func circuitHash(string) (string, error) {
+	return "", errors.New("circuits are not compiled in js/wasm builds")
+}
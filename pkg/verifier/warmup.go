@@ -0,0 +1,92 @@
+//go:build !(js && wasm)
+
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/internal/signals"
+)
+
+// warmupHostname is looked up to open the connection to the DoH resolver.
+// Any answer, including NXDOMAIN, counts.
+const warmupHostname = "example.com"
+
+// Warmup loads and prepares the verification keys opts selects, pairing
+// lines included, compiles their circuits, fills the witness and proof pools
+// and opens a connection to the DoH resolver, so that the first
+// verification runs at steady-state speed rather than paying for all of it.
+// Everything it loads is cached process-wide. A step that fails does not
+// stop the others; the failures are returned joined.
+func Warmup(ctx context.Context, opts VerificationOptions) error {
+	return NewPTXVerifier(opts).warmup(ctx)
+}
+
+// Warmup warms up the configuration of vf, see the Warmup function
+func (vf *Verifier) Warmup(ctx context.Context) error {
+	return vf.verifier(VerificationOptions{}).warmup(ctx)
+}
+
+func (v *PTXVerifier) warmup(ctx context.Context) error {
+	var errs []error
+	if err := v.checkKeys(); err != nil {
+		errs = append(errs, fmt.Errorf("verification keys: %w", err))
+	}
+
+	for _, id := range v.warmupCircuits() {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if _, err := compiledCircuit(id); err != nil {
+			errs = append(errs, fmt.Errorf("circuit %s: %w", id, err))
+			continue
+		}
+		if layout, ok := signals.LayoutFor(id); ok {
+			pw, err := getPublicWitness(layout.Len())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("circuit %s: %w", id, err))
+				continue
+			}
+			putPublicWitness(pw)
+		}
+	}
+	proofPool.Put(proofPool.Get())
+	putDecodeBuffer(getDecodeBuffer(0))
+
+	if err := v.warmupResolver(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("DoH resolver: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// warmupCircuits returns the circuits of the configured keys. Keys chosen by
+// discovery are only known per token, so the default circuit stands in for
+// them.
+func (v *PTXVerifier) warmupCircuits() []string {
+	ks := v.Options.KeySet
+	if ks == nil {
+		return []string{signals.DefaultVerificationKeyID}
+	}
+	var ids []string
+	seen := map[string]bool{}
+	for _, k := range ks.Keys {
+		if id := k.circuit(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// warmupResolver looks up warmupHostname, which leaves a connection to the
+// resolver in the pool of its HTTP client
+func (v *PTXVerifier) warmupResolver(ctx context.Context) error {
+	resolver, err := v.resolver()
+	if err != nil {
+		return err
+	}
+	_, err = resolver.LookupTXT(ctx, warmupHostname)
+	return err
+}
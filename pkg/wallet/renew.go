@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/tsa"
+	"github.com/Stygian-Inc/ptx-jesuit-go/ptx"
+)
+
+// RenewOptions configures Renew
+type RenewOptions struct {
+	// ExpiresIn is the lifetime of the renewed token. Zero keeps the
+	// lifetime of the old one.
+	ExpiresIn time.Duration
+	// AnchorKey signs tokens anchored under a fixed label, see
+	// prover.Prover.AnchorKey
+	AnchorKey ed25519.PrivateKey
+	// TSA, when set, timestamps the renewed PTX file
+	TSA *tsa.Client
+	// Backend proves the renewed token, prover.NewNative() if nil
+	Backend prover.ProverBackend
+}
+
+// Renew re-issues t with a new expiration under the same nullifier and
+// secret, so the renewed token keeps the nullifier hash of t: relying parties
+// tracking nullifiers see the same holder. The metadata, domain, trust method,
+// circuit and anchors are kept, except that a "nonce" claim is replaced with
+// a fresh one. The commitment changes with the metadata, so the anchor record
+// of the renewed token must be published before it is used.
+//
+// The returned token has the name of t and t's commitment appended to its
+// lineage; t itself is not modified.
+func Renew(ctx context.Context, t *Token, opts RenewOptions) (*Token, error) {
+	if !t.HasSecrets() {
+		return nil, fmt.Errorf("token %q has no stored nullifier and secret", t.Name)
+	}
+	old, err := ptxloader.ParsePTX(t.PTX, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, err
+	}
+	oldProof, err := proofdata.Parse(old.GetProof().GetProofData())
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+
+	now := time.Now()
+	lifetime := opts.ExpiresIn
+	if lifetime <= 0 {
+		if t.ExpiresAt == nil || t.IssuedAt.IsZero() {
+			return nil, errors.New("token has no expiration to renew, give an explicit lifetime")
+		}
+		lifetime = t.ExpiresAt.Sub(t.IssuedAt)
+	}
+	metadata, err := t.metadataMap()
+	if err != nil {
+		return nil, err
+	}
+	metadata["expiration_timestamp"] = now.Add(lifetime).Unix()
+	if _, ok := metadata["nonce"]; ok {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		metadata["nonce"] = hex.EncodeToString(nonce)
+	}
+
+	p := &prover.Prover{
+		KeyID:        t.KeyID,
+		AnchorPolicy: old.AnchorPolicy,
+		TSA:          opts.TSA,
+	}
+	for _, a := range old.AdditionalAnchors {
+		p.AdditionalAnchors = append(p.AdditionalAnchors, a.TrustMethod)
+		if a.GetEthereumDetails() != nil {
+			p.EthereumRegistry = a.GetEthereumDetails().RegistryAddress
+		}
+		if a.GetWellKnownDetails().GetLabelMode() == ptx.LabelMode_LABEL_FIXED {
+			p.FixedLabels = append(p.FixedLabels, ptx.TrustMethod_WELL_KNOWN)
+		}
+	}
+	if old.GetDohDetails().GetLabelMode() == ptx.LabelMode_LABEL_FIXED {
+		p.FixedLabels = append(p.FixedLabels, ptx.TrustMethod_DOH)
+	}
+	if len(p.FixedLabels) > 0 {
+		if opts.AnchorKey == nil {
+			return nil, errors.New("token is anchored under a fixed label, renewing it requires the anchor key")
+		}
+		p.AnchorKey = opts.AnchorKey
+	}
+
+	inputs, err := t.inputs(metadata)
+	if err != nil {
+		return nil, err
+	}
+	backend := opts.Backend
+	if backend == nil {
+		backend = prover.NewNative()
+	}
+	proofData, err := backend.Prove(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("proof generation failed: %w", err)
+	}
+	if oldProof.HasExternalSignals() {
+		if proofData, _, err = proofdata.WithoutSignals(proofData); err != nil {
+			return nil, err
+		}
+	}
+	data, err := p.CreatePtxFile(proofData, metadata, t.Domain, t.TrustMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	renewed, err := TokenFromPTX(data, t.Nullifier, t.Secret)
+	if err != nil {
+		return nil, err
+	}
+	renewed.Name = t.Name
+	renewed.Previous = append(slices.Clip(t.Previous), t.Commitment)
+	return renewed, nil
+}
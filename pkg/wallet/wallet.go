@@ -0,0 +1,348 @@
+// Package wallet keeps a holder's PTX tokens together with the nullifiers and
+// secrets they were minted with, in one file encrypted under a passphrase.
+// Without the secrets a token cannot be renewed, and without the wallet they
+// end up scattered over shell histories and notes.
+//
+// The file is AES-256-GCM encrypted with a key derived from the passphrase by
+// scrypt. It is rewritten atomically with mode 0600 on every Save.
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/proofdata"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/ptxloader"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/utils"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnv names the environment variable the CLI reads the wallet
+// passphrase from
+const PassphraseEnv = "PTX_WALLET_PASSPHRASE"
+
+// ErrBadPassphrase is returned by Open when the passphrase does not decrypt
+// the wallet, or the file was tampered with
+var ErrBadPassphrase = errors.New("wallet: wrong passphrase or corrupted file")
+
+// ErrNotFound is returned for unknown token names
+var ErrNotFound = errors.New("wallet: no such token")
+
+// scrypt parameters of new wallets, the 2017 interactive recommendation
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Token is a stored PTX with what is needed to renew it
+type Token struct {
+	Name        string `json:"name"`
+	Domain      string `json:"domain"`
+	TrustMethod int    `json:"trustMethod"`
+	KeyID       string `json:"keyId"`
+	// Metadata is the signed metadata JSON
+	Metadata  string     `json:"metadata"`
+	Audience  string     `json:"audience,omitempty"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	IssuedAt  time.Time  `json:"issuedAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Commitment and NullifierHash are the public signals of the proof
+	Commitment    string `json:"commitment"`
+	NullifierHash string `json:"nullifierHash"`
+	// Nullifier and Secret are the private inputs, empty if unknown
+	Nullifier string `json:"nullifier,omitempty"`
+	Secret    string `json:"secret,omitempty"`
+	PTX       []byte `json:"ptx"`
+	// Previous lists the commitments of the tokens this one renewed, oldest
+	// first. Renewals keep the nullifier, so they share NullifierHash.
+	Previous []string  `json:"previous,omitempty"`
+	AddedAt  time.Time `json:"addedAt"`
+}
+
+// HasSecrets reports whether the token can be renewed
+func (t *Token) HasSecrets() bool {
+	return t.Nullifier != "" && t.Secret != ""
+}
+
+// Expired reports whether the token expired at now
+func (t *Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Anchor returns the DNS anchor record the token needs published
+func (t *Token) Anchor() (*utils.AnchorRecord, error) {
+	ptxFile, err := ptxloader.ParsePTX(t.PTX, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, err
+	}
+	return utils.DeriveAnchorRecord(t.Commitment, t.Domain, t.Metadata, utils.FormatHostnameVersion(ptxFile.Version()))
+}
+
+// TokenFromPTX describes the PTX file data. When nullifier and secret are
+// given, they must be the ones the token was minted with.
+func TokenFromPTX(data []byte, nullifier, secret string) (*Token, error) {
+	ptxFile, err := ptxloader.ParsePTX(data, ptxloader.DefaultLoadOptions())
+	if err != nil {
+		return nil, err
+	}
+	t := &Token{
+		Domain:      ptxFile.GetDohDetails().GetDomainName(),
+		TrustMethod: int(ptxFile.TrustMethod),
+		KeyID:       ptxFile.GetProof().GetVerificationKeyId(),
+		Metadata:    ptxFile.SignedMetadata,
+		PTX:         data,
+		Nullifier:   nullifier,
+		Secret:      secret,
+		AddedAt:     time.Now().UTC(),
+	}
+	if ptxFile.IssuedAt != nil {
+		t.IssuedAt = ptxFile.IssuedAt.AsTime()
+	}
+	if ptxFile.ExpiresAt != nil {
+		exp := ptxFile.ExpiresAt.AsTime()
+		t.ExpiresAt = &exp
+	}
+
+	var claims struct {
+		Audience string   `json:"audience"`
+		Scopes   []string `json:"scopes"`
+	}
+	if err := json.Unmarshal([]byte(t.Metadata), &claims); err != nil {
+		return nil, fmt.Errorf("invalid signed metadata: %w", err)
+	}
+	t.Audience, t.Scopes = claims.Audience, claims.Scopes
+
+	w, err := proofdata.Parse(ptxFile.GetProof().GetProofData())
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof data: %w", err)
+	}
+	if len(w.PublicSignals) >= 2 {
+		t.NullifierHash, t.Commitment = w.PublicSignals[0], w.PublicSignals[1]
+	}
+
+	if (nullifier == "") != (secret == "") {
+		return nil, errors.New("nullifier and secret must be given together")
+	}
+	if nullifier == "" {
+		if t.Commitment == "" {
+			return nil, errors.New("token carries no public signals; give its nullifier and secret")
+		}
+		return t, nil
+	}
+	metadata, err := t.metadataMap()
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := t.inputs(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if t.Commitment != "" && (inputs.Commitment != t.Commitment || inputs.NullifierHash != t.NullifierHash) {
+		return nil, errors.New("nullifier and secret do not match the commitment of the token")
+	}
+	t.Commitment, t.NullifierHash = inputs.Commitment, inputs.NullifierHash
+	return t, nil
+}
+
+// metadataMap decodes the signed metadata, keeping numbers exact
+func (t *Token) metadataMap() (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(t.Metadata)))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid signed metadata: %w", err)
+	}
+	return m, nil
+}
+
+// inputs computes the circuit inputs of the token's secrets for metadata
+func (t *Token) inputs(metadata map[string]interface{}) (*prover.CircuitInputs, error) {
+	p := &prover.Prover{KeyID: t.KeyID}
+	return p.GenerateCircuitInputs(t.Domain, metadata, t.Nullifier, t.Secret, t.TrustMethod)
+}
+
+// envelope is the on-disk form of a wallet
+type envelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Wallet is an open wallet file
+type Wallet struct {
+	Tokens []*Token
+
+	path string
+	env  envelope
+	aead cipher.AEAD
+}
+
+// DefaultPath returns the wallet file in the user's configuration directory
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "jesuit", "wallet.enc")
+}
+
+// Open decrypts the wallet at path with passphrase. A missing file opens an
+// empty wallet, created by the first Save.
+func Open(path string, passphrase []byte) (*Wallet, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("wallet: empty passphrase")
+	}
+	w := &Wallet{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		w.env = envelope{Version: 1, KDF: "scrypt", N: scryptN, R: scryptR, P: scryptP, Salt: make([]byte, 16)}
+		if _, err := rand.Read(w.env.Salt); err != nil {
+			return nil, err
+		}
+		return w, w.deriveKey(passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &w.env); err != nil {
+		return nil, fmt.Errorf("wallet: invalid file %s: %w", path, err)
+	}
+	if w.env.Version != 1 || w.env.KDF != "scrypt" {
+		return nil, fmt.Errorf("wallet: unsupported format version %d (%s)", w.env.Version, w.env.KDF)
+	}
+	if err := w.deriveKey(passphrase); err != nil {
+		return nil, err
+	}
+	plain, err := w.aead.Open(nil, w.env.Nonce, w.env.Ciphertext, w.additionalData())
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+	if err := json.Unmarshal(plain, &w.Tokens); err != nil {
+		return nil, fmt.Errorf("wallet: invalid contents: %w", err)
+	}
+	return w, nil
+}
+
+func (w *Wallet) deriveKey(passphrase []byte) error {
+	key, err := scrypt.Key(passphrase, w.env.Salt, w.env.N, w.env.R, w.env.P, 32)
+	if err != nil {
+		return fmt.Errorf("wallet: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	w.aead, err = cipher.NewGCM(block)
+	return err
+}
+
+// additionalData binds the KDF parameters to the ciphertext
+func (w *Wallet) additionalData() []byte {
+	return fmt.Appendf(nil, "ptx-wallet-v%d %s %d %d %d", w.env.Version, w.env.KDF, w.env.N, w.env.R, w.env.P)
+}
+
+// Save encrypts the wallet with a fresh nonce and replaces the file
+func (w *Wallet) Save() error {
+	plain, err := json.Marshal(w.Tokens)
+	if err != nil {
+		return err
+	}
+	w.env.Nonce = make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(w.env.Nonce); err != nil {
+		return err
+	}
+	w.env.Ciphertext = w.aead.Seal(nil, w.env.Nonce, plain, w.additionalData())
+	data, err := json.MarshalIndent(w.env, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), ".wallet-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), w.path)
+}
+
+// Add stores t, named after its domain unless it has a name. Names are
+// unique; a second token for a domain becomes <domain>-2 and so on.
+func (w *Wallet) Add(t *Token) error {
+	if t.Name == "" {
+		t.Name = t.Domain
+		for i := 2; w.index(t.Name) >= 0; i++ {
+			t.Name = fmt.Sprintf("%s-%d", t.Domain, i)
+		}
+	}
+	if w.index(t.Name) >= 0 {
+		return fmt.Errorf("wallet: a token named %q already exists", t.Name)
+	}
+	for _, other := range w.Tokens {
+		if other.Commitment == t.Commitment {
+			return fmt.Errorf("wallet: token already stored as %q", other.Name)
+		}
+	}
+	w.Tokens = append(w.Tokens, t)
+	return nil
+}
+
+// Get returns the token called name
+func (w *Wallet) Get(name string) (*Token, error) {
+	i := w.index(name)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return w.Tokens[i], nil
+}
+
+// Replace swaps the token called t.Name for t, e.g. after a renewal
+func (w *Wallet) Replace(t *Token) error {
+	i := w.index(t.Name)
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrNotFound, t.Name)
+	}
+	w.Tokens[i] = t
+	return nil
+}
+
+// Remove deletes the token called name
+func (w *Wallet) Remove(name string) error {
+	i := w.index(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	w.Tokens = slices.Delete(w.Tokens, i, i+1)
+	return nil
+}
+
+func (w *Wallet) index(name string) int {
+	return slices.IndexFunc(w.Tokens, func(t *Token) bool { return t.Name == name })
+}
@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/fixture"
+	"github.com/Stygian-Inc/ptx-jesuit-go/pkg/prover"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+func TestWalletRoundTrip(t *testing.T) {
+	f, err := fixture.Generate(fixture.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := TokenFromPTX(f.PTX, f.Inputs.Nullifier, f.Inputs.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Commitment != f.Inputs.Commitment || token.Domain != "example.com" {
+		t.Fatalf("token = %+v", token)
+	}
+
+	path := filepath.Join(t.TempDir(), "wallet.enc")
+	w, err := Open(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(token); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, []byte("battery staple")); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatalf("wrong passphrase: err = %v, want ErrBadPassphrase", err)
+	}
+	w, err = Open(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := w.Get("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Secret != f.Inputs.Secret || string(got.PTX) != string(f.PTX) {
+		t.Fatal("token changed by the round trip")
+	}
+}
+
+func TestTokenFromPTXRejectsWrongSecret(t *testing.T) {
+	f, err := fixture.Generate(fixture.Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TokenFromPTX(f.PTX, f.Inputs.Nullifier, "12345"); err == nil {
+		t.Fatal("mismatched secret accepted")
+	}
+}
+
+// keyBackend proves with the fixture keys
+type keyBackend struct {
+	ccs constraint.ConstraintSystem
+	pk  groth16.ProvingKey
+}
+
+func (b keyBackend) Name() string { return "fixture" }
+
+func (b keyBackend) Prove(ctx context.Context, inputs *prover.CircuitInputs) ([]byte, error) {
+	return prover.NewProver().ProveWithKey(b.ccs, b.pk, inputs)
+}
+
+func TestRenewKeepsNullifier(t *testing.T) {
+	f, err := fixture.Generate(fixture.Options{
+		Seed:     1,
+		Metadata: map[string]interface{}{"audience": "rp", "expiration_timestamp": 1735693200},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := TokenFromPTX(f.PTX, f.Inputs.Nullifier, f.Inputs.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccs, pk, _, err := fixture.Keys(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewed, err := Renew(context.Background(), token, RenewOptions{Backend: keyBackend{ccs, pk}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.NullifierHash != token.NullifierHash {
+		t.Error("renewal changed the nullifier hash")
+	}
+	if renewed.Commitment == token.Commitment {
+		t.Error("renewal kept the commitment")
+	}
+	if len(renewed.Previous) != 1 || renewed.Previous[0] != token.Commitment {
+		t.Errorf("lineage = %v", renewed.Previous)
+	}
+	// The fixture lifetime is the hour between its issued_at and expiry
+	if d := time.Until(*renewed.ExpiresAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("renewed token expires in %s", d)
+	}
+}
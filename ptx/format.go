@@ -0,0 +1,10 @@
+package ptx
+
+// Version returns the format version of x: its format_version, or 1 for
+// files written before the field existed
+func (x *PtxFile) Version() uint32 {
+	if v := x.GetFormatVersion(); v != 0 {
+		return v
+	}
+	return 1
+}
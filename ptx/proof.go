@@ -0,0 +1,26 @@
+package ptx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseProofSystem parses a proof system by its enum name ("GROTH16") in any
+// case. SYSTEM_UNSPECIFIED is not accepted.
+func ParseProofSystem(s string) (ProofSystem, error) {
+	v, ok := ProofSystem_value[strings.ToUpper(strings.TrimSpace(s))]
+	if !ok || ProofSystem(v) == ProofSystem_SYSTEM_UNSPECIFIED {
+		return ProofSystem_SYSTEM_UNSPECIFIED, fmt.Errorf("unknown proof system %q (want groth16, plonk or stark)", s)
+	}
+	return ProofSystem(v), nil
+}
+
+// ParseCurve parses a curve by its enum name ("BN254") in any case.
+// CURVE_UNSPECIFIED is not accepted.
+func ParseCurve(s string) (Curve, error) {
+	v, ok := Curve_value[strings.ToUpper(strings.TrimSpace(s))]
+	if !ok || Curve(v) == Curve_CURVE_UNSPECIFIED {
+		return Curve_CURVE_UNSPECIFIED, fmt.Errorf("unknown curve %q (want bn254 or bls12_381)", s)
+	}
+	return Curve(v), nil
+}
@@ -5,7 +5,8 @@
 // non-interactive proof container designed for verifiable claims.
 //
 // A valid PTX file is a binary file composed of two parts:
-// 1. A 4-byte magic header: "PTX\x01" (Hex: 50 54 58 01)
+// 1. A 4-byte magic header: "PTX" and the format version, "PTX\x02"
+//    (Hex: 50 54 58 02) for files written by current provers
 // 2. The serialized Protobuf message for the PtxFile defined below.
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
@@ -19,6 +20,7 @@ package ptx
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -31,6 +33,163 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Curve defines the pairing-friendly curves of proofs.
+type Curve int32
+
+const (
+	Curve_CURVE_UNSPECIFIED Curve = 0 // Invalid, must be explicitly set.
+	Curve_BN254             Curve = 1
+	Curve_BLS12_381         Curve = 2
+)
+
+// Enum value maps for Curve.
+var (
+	Curve_name = map[int32]string{
+		0: "CURVE_UNSPECIFIED",
+		1: "BN254",
+		2: "BLS12_381",
+	}
+	Curve_value = map[string]int32{
+		"CURVE_UNSPECIFIED": 0,
+		"BN254":             1,
+		"BLS12_381":         2,
+	}
+)
+
+func (x Curve) Enum() *Curve {
+	p := new(Curve)
+	*p = x
+	return p
+}
+
+func (x Curve) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Curve) Descriptor() protoreflect.EnumDescriptor {
+	return file_ptx_proto_enumTypes[0].Descriptor()
+}
+
+func (Curve) Type() protoreflect.EnumType {
+	return &file_ptx_proto_enumTypes[0]
+}
+
+func (x Curve) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Curve.Descriptor instead.
+func (Curve) EnumDescriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{0}
+}
+
+// ProofFormat defines the serializations of a proof object.
+type ProofFormat int32
+
+const (
+	ProofFormat_FORMAT_UNSPECIFIED ProofFormat = 0 // Invalid, must be explicitly set.
+	ProofFormat_GNARK_NATIVE       ProofFormat = 1 // gnark's binary proof serialization.
+	ProofFormat_SNARKJS            ProofFormat = 2 // The snarkjs proof.json object.
+)
+
+// Enum value maps for ProofFormat.
+var (
+	ProofFormat_name = map[int32]string{
+		0: "FORMAT_UNSPECIFIED",
+		1: "GNARK_NATIVE",
+		2: "SNARKJS",
+	}
+	ProofFormat_value = map[string]int32{
+		"FORMAT_UNSPECIFIED": 0,
+		"GNARK_NATIVE":       1,
+		"SNARKJS":            2,
+	}
+)
+
+func (x ProofFormat) Enum() *ProofFormat {
+	p := new(ProofFormat)
+	*p = x
+	return p
+}
+
+func (x ProofFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProofFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_ptx_proto_enumTypes[1].Descriptor()
+}
+
+func (ProofFormat) Type() protoreflect.EnumType {
+	return &file_ptx_proto_enumTypes[1]
+}
+
+func (x ProofFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProofFormat.Descriptor instead.
+func (ProofFormat) EnumDescriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{1}
+}
+
+// ProofEncoding defines how the proof object is stored in the proof_data
+// JSON wrapper.
+type ProofEncoding int32
+
+const (
+	ProofEncoding_ENCODING_UNSPECIFIED ProofEncoding = 0 // Invalid, must be explicitly set.
+	ProofEncoding_HEX                  ProofEncoding = 1 // Uncompressed gnark proof as hex in "proofHex".
+	ProofEncoding_BASE64_COMPRESSED    ProofEncoding = 2 // Compressed gnark proof, base64url in "proofBase64".
+	ProofEncoding_JSON                 ProofEncoding = 3 // snarkjs proof object in "proof".
+	ProofEncoding_BASE64_GZIP          ProofEncoding = 4 // Gzipped snarkjs proof object, base64url in "proofBase64".
+)
+
+// Enum value maps for ProofEncoding.
+var (
+	ProofEncoding_name = map[int32]string{
+		0: "ENCODING_UNSPECIFIED",
+		1: "HEX",
+		2: "BASE64_COMPRESSED",
+		3: "JSON",
+		4: "BASE64_GZIP",
+	}
+	ProofEncoding_value = map[string]int32{
+		"ENCODING_UNSPECIFIED": 0,
+		"HEX":                  1,
+		"BASE64_COMPRESSED":    2,
+		"JSON":                 3,
+		"BASE64_GZIP":          4,
+	}
+)
+
+func (x ProofEncoding) Enum() *ProofEncoding {
+	p := new(ProofEncoding)
+	*p = x
+	return p
+}
+
+func (x ProofEncoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProofEncoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_ptx_proto_enumTypes[2].Descriptor()
+}
+
+func (ProofEncoding) Type() protoreflect.EnumType {
+	return &file_ptx_proto_enumTypes[2]
+}
+
+func (x ProofEncoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProofEncoding.Descriptor instead.
+func (ProofEncoding) EnumDescriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{2}
+}
+
 // TrustMethod defines the public, auditable system used to anchor the commitment.
 type TrustMethod int32
 
@@ -38,6 +197,9 @@ const (
 	TrustMethod_METHOD_UNSPECIFIED TrustMethod = 0 // Invalid, must be explicitly set.
 	TrustMethod_DOH                TrustMethod = 1 // DNS TXT Record method via Domain of Interest.
 	TrustMethod_GIST               TrustMethod = 2 // GitHub Gist method.
+	TrustMethod_WELL_KNOWN         TrustMethod = 3 // HTTPS /.well-known/ file method.
+	TrustMethod_IPFS               TrustMethod = 4 // Content-addressed document on IPFS.
+	TrustMethod_ETHEREUM           TrustMethod = 5 // ENS text record or registry contract.
 )
 
 // Enum value maps for TrustMethod.
@@ -46,11 +208,17 @@ var (
 		0: "METHOD_UNSPECIFIED",
 		1: "DOH",
 		2: "GIST",
+		3: "WELL_KNOWN",
+		4: "IPFS",
+		5: "ETHEREUM",
 	}
 	TrustMethod_value = map[string]int32{
 		"METHOD_UNSPECIFIED": 0,
 		"DOH":                1,
 		"GIST":               2,
+		"WELL_KNOWN":         3,
+		"IPFS":               4,
+		"ETHEREUM":           5,
 	}
 )
 
@@ -65,11 +233,11 @@ func (x TrustMethod) String() string {
 }
 
 func (TrustMethod) Descriptor() protoreflect.EnumDescriptor {
-	return file_ptx_proto_enumTypes[0].Descriptor()
+	return file_ptx_proto_enumTypes[3].Descriptor()
 }
 
 func (TrustMethod) Type() protoreflect.EnumType {
-	return &file_ptx_proto_enumTypes[0]
+	return &file_ptx_proto_enumTypes[3]
 }
 
 func (x TrustMethod) Number() protoreflect.EnumNumber {
@@ -78,7 +246,113 @@ func (x TrustMethod) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use TrustMethod.Descriptor instead.
 func (TrustMethod) EnumDescriptor() ([]byte, []int) {
-	return file_ptx_proto_rawDescGZIP(), []int{0}
+	return file_ptx_proto_rawDescGZIP(), []int{3}
+}
+
+// LabelMode selects where an anchor record lives under its domain.
+type LabelMode int32
+
+const (
+	// A label derived from the commitment, holding SHA256(metadata): one
+	// record per token. Format version 2 files use
+	// x2-<base32(sha256(commitment)[:20])>.<domain>, 35 characters of lower
+	// case base32; version 1 files x-<base27(sha256(commitment))>.<domain>.
+	// When the hostname would exceed 253 characters, the encoded hash is cut
+	// to fit; domains leaving fewer than 16 characters for it are invalid.
+	LabelMode_LABEL_COMMITMENT LabelMode = 0
+	// _ptx.<domain>, holding "v=ptx1; k=<base64 ed25519 public key>": one
+	// record, provisioned once, publishing the issuer's anchor key. Each
+	// token then carries the key's signature over
+	// "ptx-anchor-v1\n<domain>\n<commitment>\n<SHA256(metadata)>", which binds
+	// the commitment as the commitment label does. Several records allow key
+	// rotation.
+	LabelMode_LABEL_FIXED LabelMode = 1
+)
+
+// Enum value maps for LabelMode.
+var (
+	LabelMode_name = map[int32]string{
+		0: "LABEL_COMMITMENT",
+		1: "LABEL_FIXED",
+	}
+	LabelMode_value = map[string]int32{
+		"LABEL_COMMITMENT": 0,
+		"LABEL_FIXED":      1,
+	}
+)
+
+func (x LabelMode) Enum() *LabelMode {
+	p := new(LabelMode)
+	*p = x
+	return p
+}
+
+func (x LabelMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LabelMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_ptx_proto_enumTypes[4].Descriptor()
+}
+
+func (LabelMode) Type() protoreflect.EnumType {
+	return &file_ptx_proto_enumTypes[4]
+}
+
+func (x LabelMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LabelMode.Descriptor instead.
+func (LabelMode) EnumDescriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{4}
+}
+
+// AnchorPolicy defines how many anchors must hold the commitment record.
+type AnchorPolicy int32
+
+const (
+	AnchorPolicy_ANCHOR_POLICY_ANY AnchorPolicy = 0 // At least one anchor must match.
+	AnchorPolicy_ANCHOR_POLICY_ALL AnchorPolicy = 1 // Every anchor must match.
+)
+
+// Enum value maps for AnchorPolicy.
+var (
+	AnchorPolicy_name = map[int32]string{
+		0: "ANCHOR_POLICY_ANY",
+		1: "ANCHOR_POLICY_ALL",
+	}
+	AnchorPolicy_value = map[string]int32{
+		"ANCHOR_POLICY_ANY": 0,
+		"ANCHOR_POLICY_ALL": 1,
+	}
+)
+
+func (x AnchorPolicy) Enum() *AnchorPolicy {
+	p := new(AnchorPolicy)
+	*p = x
+	return p
+}
+
+func (x AnchorPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AnchorPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_ptx_proto_enumTypes[5].Descriptor()
+}
+
+func (AnchorPolicy) Type() protoreflect.EnumType {
+	return &file_ptx_proto_enumTypes[5]
+}
+
+func (x AnchorPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AnchorPolicy.Descriptor instead.
+func (AnchorPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{5}
 }
 
 // ProofSystem defines the supported zero-knowledge proof systems.
@@ -118,11 +392,11 @@ func (x ProofSystem) String() string {
 }
 
 func (ProofSystem) Descriptor() protoreflect.EnumDescriptor {
-	return file_ptx_proto_enumTypes[1].Descriptor()
+	return file_ptx_proto_enumTypes[6].Descriptor()
 }
 
 func (ProofSystem) Type() protoreflect.EnumType {
-	return &file_ptx_proto_enumTypes[1]
+	return &file_ptx_proto_enumTypes[6]
 }
 
 func (x ProofSystem) Number() protoreflect.EnumNumber {
@@ -131,7 +405,7 @@ func (x ProofSystem) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ProofSystem.Descriptor instead.
 func (ProofSystem) EnumDescriptor() ([]byte, []int) {
-	return file_ptx_proto_rawDescGZIP(), []int{1}
+	return file_ptx_proto_rawDescGZIP(), []int{6}
 }
 
 // PtxFile is the root message of the entire file format. It encapsulates
@@ -164,8 +438,36 @@ type PtxFile struct {
 	// (e.g., a university) to trust that the proof originated from a known
 	// intermediary (e.g., Common App).
 	IssuerSignature *IssuerSignature `protobuf:"bytes,6,opt,name=issuer_signature,json=issuerSignature,proto3" json:"issuer_signature,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// The time at which the proof was generated.
+	IssuedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=issued_at,json=issuedAt,proto3" json:"issued_at,omitempty"`
+	// The time after which verifiers MUST reject the proof. Unset means the
+	// proof does not expire. These timestamps are not covered by the proof;
+	// issuers SHOULD keep a copy in the metadata ("expiration_timestamp",
+	// Unix seconds), which verifiers require to match when both are present.
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// OPTIONAL: further anchors publishing the same commitment record, so that
+	// a misconfiguration of one of them does not invalidate the proof. They
+	// MUST name the same domain as the primary anchor above.
+	AdditionalAnchors []*Anchor `protobuf:"bytes,9,rep,name=additional_anchors,json=additionalAnchors,proto3" json:"additional_anchors,omitempty"`
+	// How the primary and additional anchors combine. Ignored when there are
+	// no additional anchors.
+	AnchorPolicy AnchorPolicy `protobuf:"varint,10,opt,name=anchor_policy,json=anchorPolicy,proto3,enum=ptx.v1.AnchorPolicy" json:"anchor_policy,omitempty"`
+	// OPTIONAL: an RFC 3161 TimeStampToken (a DER encoded CMS ContentInfo)
+	// from a Time-Stamping Authority, proving the file existed at the time it
+	// names independently of the issuer's clock. Its message imprint is the
+	// SHA-256 of the deterministically serialized PtxFile with this field and
+	// 'issuer_signature' cleared.
+	TimestampToken []byte `protobuf:"bytes,11,opt,name=timestamp_token,json=timestampToken,proto3" json:"timestamp_token,omitempty"`
+	// The ed25519 signature of the issuer's anchor key over the anchor
+	// payload (see LabelMode), for anchors using LABEL_FIXED.
+	AnchorSignature []byte `protobuf:"bytes,12,opt,name=anchor_signature,json=anchorSignature,proto3" json:"anchor_signature,omitempty"`
+	// The format version, which MUST match the last byte of the magic
+	// header. Unset means version 1. Version 2 derives the commitment label of
+	// anchor hostnames with the fixed length base32 scheme (see LabelMode);
+	// verifiers keep accepting version 1 files.
+	FormatVersion uint32 `protobuf:"varint,13,opt,name=format_version,json=formatVersion,proto3" json:"format_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PtxFile) Reset() {
@@ -251,6 +553,55 @@ func (x *PtxFile) GetIssuerSignature() *IssuerSignature {
 	return nil
 }
 
+func (x *PtxFile) GetIssuedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.IssuedAt
+	}
+	return nil
+}
+
+func (x *PtxFile) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *PtxFile) GetAdditionalAnchors() []*Anchor {
+	if x != nil {
+		return x.AdditionalAnchors
+	}
+	return nil
+}
+
+func (x *PtxFile) GetAnchorPolicy() AnchorPolicy {
+	if x != nil {
+		return x.AnchorPolicy
+	}
+	return AnchorPolicy_ANCHOR_POLICY_ANY
+}
+
+func (x *PtxFile) GetTimestampToken() []byte {
+	if x != nil {
+		return x.TimestampToken
+	}
+	return nil
+}
+
+func (x *PtxFile) GetAnchorSignature() []byte {
+	if x != nil {
+		return x.AnchorSignature
+	}
+	return nil
+}
+
+func (x *PtxFile) GetFormatVersion() uint32 {
+	if x != nil {
+		return x.FormatVersion
+	}
+	return 0
+}
+
 type isPtxFile_Anchor interface {
 	isPtxFile_Anchor()
 }
@@ -267,37 +618,36 @@ func (*PtxFile_DohDetails) isPtxFile_Anchor() {}
 
 func (*PtxFile_GistDetails) isPtxFile_Anchor() {}
 
-// ZkProof encapsulates the proof data and the necessary context for verification.
-type ZkProof struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The underlying ZKP system used to generate this proof. The verifier MUST
-	// use this to select the correct verification algorithm.
-	ProofSystem ProofSystem `protobuf:"varint,1,opt,name=proof_system,json=proofSystem,proto3,enum=ptx.v1.ProofSystem" json:"proof_system,omitempty"`
-	// An identifier for the verification key (and its associated circuit).
-	// This could be a hash of the VK, a URL, or a versioned name (e.g.,
-	// "doh-v1.0-main"). The verifier uses this to fetch or select the correct
-	// verification key for the specified proof_system.
-	VerificationKeyId string `protobuf:"bytes,2,opt,name=verification_key_id,json=verificationKeyId,proto3" json:"verification_key_id,omitempty"`
-	// The raw proof data, serialized according to the specified proof_system.
-	ProofData     []byte `protobuf:"bytes,3,opt,name=proof_data,json=proofData,proto3" json:"proof_data,omitempty"`
+// Anchor is an additional location of the commitment record.
+type Anchor struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	TrustMethod TrustMethod            `protobuf:"varint,1,opt,name=trust_method,json=trustMethod,proto3,enum=ptx.v1.TrustMethod" json:"trust_method,omitempty"`
+	// Types that are valid to be assigned to Details:
+	//
+	//	*Anchor_DohDetails
+	//	*Anchor_GistDetails
+	//	*Anchor_WellKnownDetails
+	//	*Anchor_IpfsDetails
+	//	*Anchor_EthereumDetails
+	Details       isAnchor_Details `protobuf_oneof:"details"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ZkProof) Reset() {
-	*x = ZkProof{}
+func (x *Anchor) Reset() {
+	*x = Anchor{}
 	mi := &file_ptx_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ZkProof) String() string {
+func (x *Anchor) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ZkProof) ProtoMessage() {}
+func (*Anchor) ProtoMessage() {}
 
-func (x *ZkProof) ProtoReflect() protoreflect.Message {
+func (x *Anchor) ProtoReflect() protoreflect.Message {
 	mi := &file_ptx_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -309,80 +659,336 @@ func (x *ZkProof) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ZkProof.ProtoReflect.Descriptor instead.
-func (*ZkProof) Descriptor() ([]byte, []int) {
+// Deprecated: Use Anchor.ProtoReflect.Descriptor instead.
+func (*Anchor) Descriptor() ([]byte, []int) {
 	return file_ptx_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *ZkProof) GetProofSystem() ProofSystem {
+func (x *Anchor) GetTrustMethod() TrustMethod {
 	if x != nil {
-		return x.ProofSystem
+		return x.TrustMethod
 	}
-	return ProofSystem_SYSTEM_UNSPECIFIED
+	return TrustMethod_METHOD_UNSPECIFIED
 }
 
-func (x *ZkProof) GetVerificationKeyId() string {
+func (x *Anchor) GetDetails() isAnchor_Details {
 	if x != nil {
-		return x.VerificationKeyId
+		return x.Details
 	}
-	return ""
+	return nil
 }
 
-func (x *ZkProof) GetProofData() []byte {
+func (x *Anchor) GetDohDetails() *DohAnchor {
 	if x != nil {
-		return x.ProofData
+		if x, ok := x.Details.(*Anchor_DohDetails); ok {
+			return x.DohDetails
+		}
 	}
 	return nil
 }
 
-// IssuerSignature encapsulates an X.509 signature and the certificate chain
-// needed to verify it, leveraging the existing WebPKI trust infrastructure.
-type IssuerSignature struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// An identifier for the signature algorithm used, corresponding to standards
-	// recognized by X.509, e.g., "SHA256withRSA".
-	SignatureAlgorithm string `protobuf:"bytes,1,opt,name=signature_algorithm,json=signatureAlgorithm,proto3" json:"signature_algorithm,omitempty"`
-	// The raw signature bytes. This signature is calculated over the entire
-	// serialized PtxFile message, with this 'issuer_signature' field itself
-	// being empty during the calculation to create a canonical payload.
-	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
-	// The chain of X.509 certificates required to verify the signature.
-	// The first certificate MUST be the leaf certificate whose private key
-	// created the signature. Each subsequent certificate certifies the one
-	// preceding it. Certificates should be in the binary DER format.
-	CertificateChain [][]byte `protobuf:"bytes,3,rep,name=certificate_chain,json=certificateChain,proto3" json:"certificate_chain,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
-}
-
-func (x *IssuerSignature) Reset() {
-	*x = IssuerSignature{}
-	mi := &file_ptx_proto_msgTypes[2]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Anchor) GetGistDetails() *GistAnchor {
+	if x != nil {
+		if x, ok := x.Details.(*Anchor_GistDetails); ok {
+			return x.GistDetails
+		}
+	}
+	return nil
 }
 
-func (x *IssuerSignature) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Anchor) GetWellKnownDetails() *WellKnownAnchor {
+	if x != nil {
+		if x, ok := x.Details.(*Anchor_WellKnownDetails); ok {
+			return x.WellKnownDetails
+		}
+	}
+	return nil
 }
 
-func (*IssuerSignature) ProtoMessage() {}
-
-func (x *IssuerSignature) ProtoReflect() protoreflect.Message {
-	mi := &file_ptx_proto_msgTypes[2]
+func (x *Anchor) GetIpfsDetails() *IpfsAnchor {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
+		if x, ok := x.Details.(*Anchor_IpfsDetails); ok {
+			return x.IpfsDetails
 		}
-		return ms
 	}
-	return mi.MessageOf(x)
+	return nil
+}
+
+func (x *Anchor) GetEthereumDetails() *EthereumAnchor {
+	if x != nil {
+		if x, ok := x.Details.(*Anchor_EthereumDetails); ok {
+			return x.EthereumDetails
+		}
+	}
+	return nil
+}
+
+type isAnchor_Details interface {
+	isAnchor_Details()
+}
+
+type Anchor_DohDetails struct {
+	DohDetails *DohAnchor `protobuf:"bytes,2,opt,name=doh_details,json=dohDetails,proto3,oneof"`
+}
+
+type Anchor_GistDetails struct {
+	GistDetails *GistAnchor `protobuf:"bytes,3,opt,name=gist_details,json=gistDetails,proto3,oneof"`
+}
+
+type Anchor_WellKnownDetails struct {
+	WellKnownDetails *WellKnownAnchor `protobuf:"bytes,4,opt,name=well_known_details,json=wellKnownDetails,proto3,oneof"`
+}
+
+type Anchor_IpfsDetails struct {
+	IpfsDetails *IpfsAnchor `protobuf:"bytes,5,opt,name=ipfs_details,json=ipfsDetails,proto3,oneof"`
+}
+
+type Anchor_EthereumDetails struct {
+	EthereumDetails *EthereumAnchor `protobuf:"bytes,6,opt,name=ethereum_details,json=ethereumDetails,proto3,oneof"`
+}
+
+func (*Anchor_DohDetails) isAnchor_Details() {}
+
+func (*Anchor_GistDetails) isAnchor_Details() {}
+
+func (*Anchor_WellKnownDetails) isAnchor_Details() {}
+
+func (*Anchor_IpfsDetails) isAnchor_Details() {}
+
+func (*Anchor_EthereumDetails) isAnchor_Details() {}
+
+// ZkProof encapsulates the proof data and the necessary context for verification.
+type ZkProof struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The underlying ZKP system used to generate this proof. The verifier MUST
+	// use this to select the correct verification algorithm.
+	ProofSystem ProofSystem `protobuf:"varint,1,opt,name=proof_system,json=proofSystem,proto3,enum=ptx.v1.ProofSystem" json:"proof_system,omitempty"`
+	// An identifier for the verification key (and its associated circuit).
+	// This could be a hash of the VK, a URL, or a versioned name (e.g.,
+	// "doh-v1.0-main"). The verifier uses this to fetch or select the correct
+	// verification key for the specified proof_system.
+	VerificationKeyId string `protobuf:"bytes,2,opt,name=verification_key_id,json=verificationKeyId,proto3" json:"verification_key_id,omitempty"`
+	// The raw proof data, serialized according to the specified proof_system.
+	ProofData []byte `protobuf:"bytes,3,opt,name=proof_data,json=proofData,proto3" json:"proof_data,omitempty"`
+	// Describes how proof_data is to be read. Verifiers reject envelopes of an
+	// unknown schema and combinations they do not support rather than guess.
+	// Files written before the envelope have none; their format is inferred
+	// from proof_data.
+	Envelope      *ProofEnvelope `protobuf:"bytes,4,opt,name=envelope,proto3" json:"envelope,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ZkProof) Reset() {
+	*x = ZkProof{}
+	mi := &file_ptx_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ZkProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZkProof) ProtoMessage() {}
+
+func (x *ZkProof) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZkProof.ProtoReflect.Descriptor instead.
+func (*ZkProof) Descriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ZkProof) GetProofSystem() ProofSystem {
+	if x != nil {
+		return x.ProofSystem
+	}
+	return ProofSystem_SYSTEM_UNSPECIFIED
+}
+
+func (x *ZkProof) GetVerificationKeyId() string {
+	if x != nil {
+		return x.VerificationKeyId
+	}
+	return ""
+}
+
+func (x *ZkProof) GetProofData() []byte {
+	if x != nil {
+		return x.ProofData
+	}
+	return nil
+}
+
+func (x *ZkProof) GetEnvelope() *ProofEnvelope {
+	if x != nil {
+		return x.Envelope
+	}
+	return nil
+}
+
+// ProofEnvelope declares the format of ZkProof.proof_data.
+type ProofEnvelope struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The envelope schema version, currently 1.
+	Schema uint32 `protobuf:"varint,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	// MUST equal ZkProof.proof_system.
+	ProofSystem ProofSystem `protobuf:"varint,2,opt,name=proof_system,json=proofSystem,proto3,enum=ptx.v1.ProofSystem" json:"proof_system,omitempty"`
+	// The curve of the proof and its verification key.
+	Curve Curve `protobuf:"varint,3,opt,name=curve,proto3,enum=ptx.v1.Curve" json:"curve,omitempty"`
+	// The serialization of the proof object inside proof_data.
+	Format ProofFormat `protobuf:"varint,4,opt,name=format,proto3,enum=ptx.v1.ProofFormat" json:"format,omitempty"`
+	// How the proof object is encoded in the proof_data JSON wrapper.
+	Encoding ProofEncoding `protobuf:"varint,5,opt,name=encoding,proto3,enum=ptx.v1.ProofEncoding" json:"encoding,omitempty"`
+	// The circuit the proof belongs to, e.g. "sdv_poseidon_v1". It selects the
+	// public signal layout, and MUST be the circuit of verification_key_id.
+	CircuitId string `protobuf:"bytes,6,opt,name=circuit_id,json=circuitId,proto3" json:"circuit_id,omitempty"`
+	// The SHA-256 (lowercase hex) of the compiled constraint system of the
+	// circuit, identifying its exact definition. Set for native gnark proofs;
+	// a verifier whose circuit hashes differently reports an identity
+	// mismatch.
+	CircuitHash   string `protobuf:"bytes,7,opt,name=circuit_hash,json=circuitHash,proto3" json:"circuit_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProofEnvelope) Reset() {
+	*x = ProofEnvelope{}
+	mi := &file_ptx_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProofEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProofEnvelope) ProtoMessage() {}
+
+func (x *ProofEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProofEnvelope.ProtoReflect.Descriptor instead.
+func (*ProofEnvelope) Descriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProofEnvelope) GetSchema() uint32 {
+	if x != nil {
+		return x.Schema
+	}
+	return 0
+}
+
+func (x *ProofEnvelope) GetProofSystem() ProofSystem {
+	if x != nil {
+		return x.ProofSystem
+	}
+	return ProofSystem_SYSTEM_UNSPECIFIED
+}
+
+func (x *ProofEnvelope) GetCurve() Curve {
+	if x != nil {
+		return x.Curve
+	}
+	return Curve_CURVE_UNSPECIFIED
+}
+
+func (x *ProofEnvelope) GetFormat() ProofFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ProofFormat_FORMAT_UNSPECIFIED
+}
+
+func (x *ProofEnvelope) GetEncoding() ProofEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return ProofEncoding_ENCODING_UNSPECIFIED
+}
+
+func (x *ProofEnvelope) GetCircuitId() string {
+	if x != nil {
+		return x.CircuitId
+	}
+	return ""
+}
+
+func (x *ProofEnvelope) GetCircuitHash() string {
+	if x != nil {
+		return x.CircuitHash
+	}
+	return ""
+}
+
+// IssuerSignature encapsulates an X.509 signature and the certificate chain
+// needed to verify it, leveraging the existing WebPKI trust infrastructure.
+type IssuerSignature struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// An identifier for the signature algorithm used, corresponding to standards
+	// recognized by X.509, e.g., "SHA256withRSA".
+	SignatureAlgorithm string `protobuf:"bytes,1,opt,name=signature_algorithm,json=signatureAlgorithm,proto3" json:"signature_algorithm,omitempty"`
+	// The raw signature bytes. This signature is calculated over the entire
+	// serialized PtxFile message, with this 'issuer_signature' field itself
+	// being empty during the calculation to create a canonical payload.
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	// The chain of X.509 certificates required to verify the signature.
+	// The first certificate MUST be the leaf certificate whose private key
+	// created the signature. Each subsequent certificate certifies the one
+	// preceding it. Certificates should be in the binary DER format.
+	CertificateChain [][]byte `protobuf:"bytes,3,rep,name=certificate_chain,json=certificateChain,proto3" json:"certificate_chain,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *IssuerSignature) Reset() {
+	*x = IssuerSignature{}
+	mi := &file_ptx_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssuerSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuerSignature) ProtoMessage() {}
+
+func (x *IssuerSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
 // Deprecated: Use IssuerSignature.ProtoReflect.Descriptor instead.
 func (*IssuerSignature) Descriptor() ([]byte, []int) {
-	return file_ptx_proto_rawDescGZIP(), []int{2}
+	return file_ptx_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *IssuerSignature) GetSignatureAlgorithm() string {
@@ -410,14 +1016,23 @@ func (x *IssuerSignature) GetCertificateChain() [][]byte {
 type DohAnchor struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The fully qualified domain name that anchors the proof, e.g., "example.com".
-	DomainName    string `protobuf:"bytes,1,opt,name=domain_name,json=domainName,proto3" json:"domain_name,omitempty"`
+	DomainName string `protobuf:"bytes,1,opt,name=domain_name,json=domainName,proto3" json:"domain_name,omitempty"`
+	// OPTIONAL: further domains of the same issuer (SAN-style), e.g.
+	// "example.org". Each publishes the record derived for its own name, and
+	// the verifier's domain policy decides which must. The proof only binds
+	// 'domain_name', so the same names MUST be listed, in order, in the
+	// signed metadata's "additional_domains" array.
+	AdditionalDomainNames []string `protobuf:"bytes,2,rep,name=additional_domain_names,json=additionalDomainNames,proto3" json:"additional_domain_names,omitempty"`
+	// Where the record lives under each domain. LABEL_FIXED anchors require
+	// PtxFile.anchor_signature.
+	LabelMode     LabelMode `protobuf:"varint,3,opt,name=label_mode,json=labelMode,proto3,enum=ptx.v1.LabelMode" json:"label_mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DohAnchor) Reset() {
 	*x = DohAnchor{}
-	mi := &file_ptx_proto_msgTypes[3]
+	mi := &file_ptx_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -429,7 +1044,7 @@ func (x *DohAnchor) String() string {
 func (*DohAnchor) ProtoMessage() {}
 
 func (x *DohAnchor) ProtoReflect() protoreflect.Message {
-	mi := &file_ptx_proto_msgTypes[3]
+	mi := &file_ptx_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -442,7 +1057,7 @@ func (x *DohAnchor) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DohAnchor.ProtoReflect.Descriptor instead.
 func (*DohAnchor) Descriptor() ([]byte, []int) {
-	return file_ptx_proto_rawDescGZIP(), []int{3}
+	return file_ptx_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *DohAnchor) GetDomainName() string {
@@ -452,6 +1067,20 @@ func (x *DohAnchor) GetDomainName() string {
 	return ""
 }
 
+func (x *DohAnchor) GetAdditionalDomainNames() []string {
+	if x != nil {
+		return x.AdditionalDomainNames
+	}
+	return nil
+}
+
+func (x *DohAnchor) GetLabelMode() LabelMode {
+	if x != nil {
+		return x.LabelMode
+	}
+	return LabelMode_LABEL_COMMITMENT
+}
+
 // GistAnchor contains the details required for the GIST (GitHub Gist) trust method.
 type GistAnchor struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -463,7 +1092,7 @@ type GistAnchor struct {
 
 func (x *GistAnchor) Reset() {
 	*x = GistAnchor{}
-	mi := &file_ptx_proto_msgTypes[4]
+	mi := &file_ptx_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -475,7 +1104,7 @@ func (x *GistAnchor) String() string {
 func (*GistAnchor) ProtoMessage() {}
 
 func (x *GistAnchor) ProtoReflect() protoreflect.Message {
-	mi := &file_ptx_proto_msgTypes[4]
+	mi := &file_ptx_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -488,7 +1117,7 @@ func (x *GistAnchor) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GistAnchor.ProtoReflect.Descriptor instead.
 func (*GistAnchor) Descriptor() ([]byte, []int) {
-	return file_ptx_proto_rawDescGZIP(), []int{4}
+	return file_ptx_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GistAnchor) GetGistUrl() string {
@@ -498,11 +1127,384 @@ func (x *GistAnchor) GetGistUrl() string {
 	return ""
 }
 
+// WellKnownAnchor contains the details required for the WELL_KNOWN trust method.
+// The record is served over HTTPS at
+// https://<domain_name>/.well-known/ptx-anchors/<label>, where <label> is the
+// first label of the DoH anchor hostname.
+type WellKnownAnchor struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The fully qualified domain name that anchors the proof, e.g., "example.com".
+	DomainName string `protobuf:"bytes,1,opt,name=domain_name,json=domainName,proto3" json:"domain_name,omitempty"`
+	// Which file the record is served from: the commitment label, or
+	// "_ptx" for LABEL_FIXED.
+	LabelMode     LabelMode `protobuf:"varint,2,opt,name=label_mode,json=labelMode,proto3,enum=ptx.v1.LabelMode" json:"label_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WellKnownAnchor) Reset() {
+	*x = WellKnownAnchor{}
+	mi := &file_ptx_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WellKnownAnchor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WellKnownAnchor) ProtoMessage() {}
+
+func (x *WellKnownAnchor) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WellKnownAnchor.ProtoReflect.Descriptor instead.
+func (*WellKnownAnchor) Descriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WellKnownAnchor) GetDomainName() string {
+	if x != nil {
+		return x.DomainName
+	}
+	return ""
+}
+
+func (x *WellKnownAnchor) GetLabelMode() LabelMode {
+	if x != nil {
+		return x.LabelMode
+	}
+	return LabelMode_LABEL_COMMITMENT
+}
+
+// IpfsAnchor contains the details required for the IPFS trust method. The
+// anchor is the document "ptx-anchor-v1\n<hostname>\n<value>\n" holding the
+// DoH anchor record of the token (commitment label), addressed by its CIDv1
+// (raw codec, sha2-256) and fetched through an IPFS gateway. It shows the
+// document is published and pinned rather than control of the domain.
+type IpfsAnchor struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The fully qualified domain name that anchors the proof, e.g., "example.com".
+	DomainName string `protobuf:"bytes,1,opt,name=domain_name,json=domainName,proto3" json:"domain_name,omitempty"`
+	// The CID of the anchor document, e.g., "bafkrei...".
+	Cid           string `protobuf:"bytes,2,opt,name=cid,proto3" json:"cid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IpfsAnchor) Reset() {
+	*x = IpfsAnchor{}
+	mi := &file_ptx_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IpfsAnchor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IpfsAnchor) ProtoMessage() {}
+
+func (x *IpfsAnchor) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IpfsAnchor.ProtoReflect.Descriptor instead.
+func (*IpfsAnchor) Descriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IpfsAnchor) GetDomainName() string {
+	if x != nil {
+		return x.DomainName
+	}
+	return ""
+}
+
+func (x *IpfsAnchor) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+// EthereumAnchor contains the details required for the ETHEREUM trust method,
+// read through a JSON-RPC endpoint. Without a registry the anchor is the ENS
+// text record "ptx:<label>" of the domain, holding the TXT value, where
+// <label> is the first label of the DoH anchor hostname. With a registry it
+// is the bytes32 the contract's anchors(uint256 commitment) function returns,
+// the SHA256 of the metadata; verifiers only accept registries they trust for
+// the domain.
+type EthereumAnchor struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The fully qualified domain name that anchors the proof, e.g., "example.com".
+	// It is also the ENS name holding the text record.
+	DomainName string `protobuf:"bytes,1,opt,name=domain_name,json=domainName,proto3" json:"domain_name,omitempty"`
+	// OPTIONAL: the 0x prefixed address of the registry contract.
+	RegistryAddress string `protobuf:"bytes,2,opt,name=registry_address,json=registryAddress,proto3" json:"registry_address,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *EthereumAnchor) Reset() {
+	*x = EthereumAnchor{}
+	mi := &file_ptx_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EthereumAnchor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EthereumAnchor) ProtoMessage() {}
+
+func (x *EthereumAnchor) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EthereumAnchor.ProtoReflect.Descriptor instead.
+func (*EthereumAnchor) Descriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EthereumAnchor) GetDomainName() string {
+	if x != nil {
+		return x.DomainName
+	}
+	return ""
+}
+
+func (x *EthereumAnchor) GetRegistryAddress() string {
+	if x != nil {
+		return x.RegistryAddress
+	}
+	return ""
+}
+
+// IssuanceRequest is sent by a holder to an issuer, like a certificate
+// signing request, to have a token anchored on the issuer's domain. It
+// carries the commitment of the token the holder will prove, computed over
+// the requested metadata, but never the nullifier or secret. Because the
+// proof binds the commitment to the metadata, an anchor the issuer creates
+// for this commitment only ever validates a PTX with exactly this metadata.
+// Transported as the binary protobuf encoding, without the PTX header.
+type IssuanceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The domain the holder asks to be anchored on, e.g., "example.com".
+	DomainName string `protobuf:"bytes,1,opt,name=domain_name,json=domainName,proto3" json:"domain_name,omitempty"`
+	// The trust method of the token.
+	TrustMethod TrustMethod `protobuf:"varint,2,opt,name=trust_method,json=trustMethod,proto3,enum=ptx.v1.TrustMethod" json:"trust_method,omitempty"`
+	// The verification key ID of the circuit the holder proves with.
+	VerificationKeyId string `protobuf:"bytes,3,opt,name=verification_key_id,json=verificationKeyId,proto3" json:"verification_key_id,omitempty"`
+	// The requested metadata, in canonical JSON: the exact signed_metadata
+	// of the token.
+	Metadata string `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// The commitment and nullifier hash public signals (decimal) of the proof
+	// the holder will produce.
+	Commitment    string `protobuf:"bytes,5,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	NullifierHash string `protobuf:"bytes,6,opt,name=nullifier_hash,json=nullifierHash,proto3" json:"nullifier_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssuanceRequest) Reset() {
+	*x = IssuanceRequest{}
+	mi := &file_ptx_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssuanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuanceRequest) ProtoMessage() {}
+
+func (x *IssuanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuanceRequest.ProtoReflect.Descriptor instead.
+func (*IssuanceRequest) Descriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IssuanceRequest) GetDomainName() string {
+	if x != nil {
+		return x.DomainName
+	}
+	return ""
+}
+
+func (x *IssuanceRequest) GetTrustMethod() TrustMethod {
+	if x != nil {
+		return x.TrustMethod
+	}
+	return TrustMethod_METHOD_UNSPECIFIED
+}
+
+func (x *IssuanceRequest) GetVerificationKeyId() string {
+	if x != nil {
+		return x.VerificationKeyId
+	}
+	return ""
+}
+
+func (x *IssuanceRequest) GetMetadata() string {
+	if x != nil {
+		return x.Metadata
+	}
+	return ""
+}
+
+func (x *IssuanceRequest) GetCommitment() string {
+	if x != nil {
+		return x.Commitment
+	}
+	return ""
+}
+
+func (x *IssuanceRequest) GetNullifierHash() string {
+	if x != nil {
+		return x.NullifierHash
+	}
+	return ""
+}
+
+// IssuanceResponse is an issuer's approval of an IssuanceRequest. The holder
+// assembles the PTX from its proof, signed_metadata and these anchor details.
+type IssuanceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The approved metadata, identical to the requested metadata.
+	SignedMetadata string `protobuf:"bytes,1,opt,name=signed_metadata,json=signedMetadata,proto3" json:"signed_metadata,omitempty"`
+	// Where the anchor of the token lives.
+	LabelMode LabelMode `protobuf:"varint,2,opt,name=label_mode,json=labelMode,proto3,enum=ptx.v1.LabelMode" json:"label_mode,omitempty"`
+	// For LABEL_FIXED, the signature of the issuer's anchor key over the
+	// anchor payload, to be stored as the PtxFile anchor_signature.
+	AnchorSignature []byte `protobuf:"bytes,3,opt,name=anchor_signature,json=anchorSignature,proto3" json:"anchor_signature,omitempty"`
+	// For LABEL_COMMITMENT, whether the issuer has published the anchor
+	// record. When false the issuer publishes it out of band.
+	AnchorPublished bool `protobuf:"varint,4,opt,name=anchor_published,json=anchorPublished,proto3" json:"anchor_published,omitempty"`
+	// The time the issuer approved the request.
+	IssuedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=issued_at,json=issuedAt,proto3" json:"issued_at,omitempty"`
+	// The PTX format version the holder writes the token with, which decides
+	// the label the issuer published under. Unset means version 1.
+	FormatVersion uint32 `protobuf:"varint,6,opt,name=format_version,json=formatVersion,proto3" json:"format_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssuanceResponse) Reset() {
+	*x = IssuanceResponse{}
+	mi := &file_ptx_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssuanceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuanceResponse) ProtoMessage() {}
+
+func (x *IssuanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ptx_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuanceResponse.ProtoReflect.Descriptor instead.
+func (*IssuanceResponse) Descriptor() ([]byte, []int) {
+	return file_ptx_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IssuanceResponse) GetSignedMetadata() string {
+	if x != nil {
+		return x.SignedMetadata
+	}
+	return ""
+}
+
+func (x *IssuanceResponse) GetLabelMode() LabelMode {
+	if x != nil {
+		return x.LabelMode
+	}
+	return LabelMode_LABEL_COMMITMENT
+}
+
+func (x *IssuanceResponse) GetAnchorSignature() []byte {
+	if x != nil {
+		return x.AnchorSignature
+	}
+	return nil
+}
+
+func (x *IssuanceResponse) GetAnchorPublished() bool {
+	if x != nil {
+		return x.AnchorPublished
+	}
+	return false
+}
+
+func (x *IssuanceResponse) GetIssuedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.IssuedAt
+	}
+	return nil
+}
+
+func (x *IssuanceResponse) GetFormatVersion() uint32 {
+	if x != nil {
+		return x.FormatVersion
+	}
+	return 0
+}
+
 var File_ptx_proto protoreflect.FileDescriptor
 
 const file_ptx_proto_rawDesc = "" +
 	"\n" +
-	"\tptx.proto\x12\x06ptx.v1\"\xce\x02\n" +
+	"\tptx.proto\x12\x06ptx.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb7\x05\n" +
 	"\aPtxFile\x126\n" +
 	"\ftrust_method\x18\x01 \x01(\x0e2\x13.ptx.v1.TrustMethodR\vtrustMethod\x12%\n" +
 	"\x05proof\x18\x02 \x01(\v2\x0f.ptx.v1.ZkProofR\x05proof\x12'\n" +
@@ -510,27 +1512,114 @@ const file_ptx_proto_rawDesc = "" +
 	"\vdoh_details\x18\x04 \x01(\v2\x11.ptx.v1.DohAnchorH\x00R\n" +
 	"dohDetails\x127\n" +
 	"\fgist_details\x18\x05 \x01(\v2\x12.ptx.v1.GistAnchorH\x00R\vgistDetails\x12B\n" +
-	"\x10issuer_signature\x18\x06 \x01(\v2\x17.ptx.v1.IssuerSignatureR\x0fissuerSignatureB\b\n" +
-	"\x06anchor\"\x90\x01\n" +
+	"\x10issuer_signature\x18\x06 \x01(\v2\x17.ptx.v1.IssuerSignatureR\x0fissuerSignature\x127\n" +
+	"\tissued_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\bissuedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12=\n" +
+	"\x12additional_anchors\x18\t \x03(\v2\x0e.ptx.v1.AnchorR\x11additionalAnchors\x129\n" +
+	"\ranchor_policy\x18\n" +
+	" \x01(\x0e2\x14.ptx.v1.AnchorPolicyR\fanchorPolicy\x12'\n" +
+	"\x0ftimestamp_token\x18\v \x01(\fR\x0etimestampToken\x12)\n" +
+	"\x10anchor_signature\x18\f \x01(\fR\x0fanchorSignature\x12%\n" +
+	"\x0eformat_version\x18\r \x01(\rR\rformatVersionB\b\n" +
+	"\x06anchor\"\x81\x03\n" +
+	"\x06Anchor\x126\n" +
+	"\ftrust_method\x18\x01 \x01(\x0e2\x13.ptx.v1.TrustMethodR\vtrustMethod\x124\n" +
+	"\vdoh_details\x18\x02 \x01(\v2\x11.ptx.v1.DohAnchorH\x00R\n" +
+	"dohDetails\x127\n" +
+	"\fgist_details\x18\x03 \x01(\v2\x12.ptx.v1.GistAnchorH\x00R\vgistDetails\x12G\n" +
+	"\x12well_known_details\x18\x04 \x01(\v2\x17.ptx.v1.WellKnownAnchorH\x00R\x10wellKnownDetails\x127\n" +
+	"\fipfs_details\x18\x05 \x01(\v2\x12.ptx.v1.IpfsAnchorH\x00R\vipfsDetails\x12C\n" +
+	"\x10ethereum_details\x18\x06 \x01(\v2\x16.ptx.v1.EthereumAnchorH\x00R\x0fethereumDetailsB\t\n" +
+	"\adetails\"\xc3\x01\n" +
 	"\aZkProof\x126\n" +
 	"\fproof_system\x18\x01 \x01(\x0e2\x13.ptx.v1.ProofSystemR\vproofSystem\x12.\n" +
 	"\x13verification_key_id\x18\x02 \x01(\tR\x11verificationKeyId\x12\x1d\n" +
 	"\n" +
-	"proof_data\x18\x03 \x01(\fR\tproofData\"\x8d\x01\n" +
+	"proof_data\x18\x03 \x01(\fR\tproofData\x121\n" +
+	"\benvelope\x18\x04 \x01(\v2\x15.ptx.v1.ProofEnvelopeR\benvelope\"\xa6\x02\n" +
+	"\rProofEnvelope\x12\x16\n" +
+	"\x06schema\x18\x01 \x01(\rR\x06schema\x126\n" +
+	"\fproof_system\x18\x02 \x01(\x0e2\x13.ptx.v1.ProofSystemR\vproofSystem\x12#\n" +
+	"\x05curve\x18\x03 \x01(\x0e2\r.ptx.v1.CurveR\x05curve\x12+\n" +
+	"\x06format\x18\x04 \x01(\x0e2\x13.ptx.v1.ProofFormatR\x06format\x121\n" +
+	"\bencoding\x18\x05 \x01(\x0e2\x15.ptx.v1.ProofEncodingR\bencoding\x12\x1d\n" +
+	"\n" +
+	"circuit_id\x18\x06 \x01(\tR\tcircuitId\x12!\n" +
+	"\fcircuit_hash\x18\a \x01(\tR\vcircuitHash\"\x8d\x01\n" +
 	"\x0fIssuerSignature\x12/\n" +
 	"\x13signature_algorithm\x18\x01 \x01(\tR\x12signatureAlgorithm\x12\x1c\n" +
 	"\tsignature\x18\x02 \x01(\fR\tsignature\x12+\n" +
-	"\x11certificate_chain\x18\x03 \x03(\fR\x10certificateChain\",\n" +
+	"\x11certificate_chain\x18\x03 \x03(\fR\x10certificateChain\"\x96\x01\n" +
 	"\tDohAnchor\x12\x1f\n" +
 	"\vdomain_name\x18\x01 \x01(\tR\n" +
-	"domainName\"'\n" +
+	"domainName\x126\n" +
+	"\x17additional_domain_names\x18\x02 \x03(\tR\x15additionalDomainNames\x120\n" +
+	"\n" +
+	"label_mode\x18\x03 \x01(\x0e2\x11.ptx.v1.LabelModeR\tlabelMode\"'\n" +
 	"\n" +
 	"GistAnchor\x12\x19\n" +
-	"\bgist_url\x18\x01 \x01(\tR\agistUrl*8\n" +
+	"\bgist_url\x18\x01 \x01(\tR\agistUrl\"d\n" +
+	"\x0fWellKnownAnchor\x12\x1f\n" +
+	"\vdomain_name\x18\x01 \x01(\tR\n" +
+	"domainName\x120\n" +
+	"\n" +
+	"label_mode\x18\x02 \x01(\x0e2\x11.ptx.v1.LabelModeR\tlabelMode\"?\n" +
+	"\n" +
+	"IpfsAnchor\x12\x1f\n" +
+	"\vdomain_name\x18\x01 \x01(\tR\n" +
+	"domainName\x12\x10\n" +
+	"\x03cid\x18\x02 \x01(\tR\x03cid\"\\\n" +
+	"\x0eEthereumAnchor\x12\x1f\n" +
+	"\vdomain_name\x18\x01 \x01(\tR\n" +
+	"domainName\x12)\n" +
+	"\x10registry_address\x18\x02 \x01(\tR\x0fregistryAddress\"\xfd\x01\n" +
+	"\x0fIssuanceRequest\x12\x1f\n" +
+	"\vdomain_name\x18\x01 \x01(\tR\n" +
+	"domainName\x126\n" +
+	"\ftrust_method\x18\x02 \x01(\x0e2\x13.ptx.v1.TrustMethodR\vtrustMethod\x12.\n" +
+	"\x13verification_key_id\x18\x03 \x01(\tR\x11verificationKeyId\x12\x1a\n" +
+	"\bmetadata\x18\x04 \x01(\tR\bmetadata\x12\x1e\n" +
+	"\n" +
+	"commitment\x18\x05 \x01(\tR\n" +
+	"commitment\x12%\n" +
+	"\x0enullifier_hash\x18\x06 \x01(\tR\rnullifierHash\"\xa3\x02\n" +
+	"\x10IssuanceResponse\x12'\n" +
+	"\x0fsigned_metadata\x18\x01 \x01(\tR\x0esignedMetadata\x120\n" +
+	"\n" +
+	"label_mode\x18\x02 \x01(\x0e2\x11.ptx.v1.LabelModeR\tlabelMode\x12)\n" +
+	"\x10anchor_signature\x18\x03 \x01(\fR\x0fanchorSignature\x12)\n" +
+	"\x10anchor_published\x18\x04 \x01(\bR\x0fanchorPublished\x127\n" +
+	"\tissued_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bissuedAt\x12%\n" +
+	"\x0eformat_version\x18\x06 \x01(\rR\rformatVersion*8\n" +
+	"\x05Curve\x12\x15\n" +
+	"\x11CURVE_UNSPECIFIED\x10\x00\x12\t\n" +
+	"\x05BN254\x10\x01\x12\r\n" +
+	"\tBLS12_381\x10\x02*D\n" +
+	"\vProofFormat\x12\x16\n" +
+	"\x12FORMAT_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fGNARK_NATIVE\x10\x01\x12\v\n" +
+	"\aSNARKJS\x10\x02*d\n" +
+	"\rProofEncoding\x12\x18\n" +
+	"\x14ENCODING_UNSPECIFIED\x10\x00\x12\a\n" +
+	"\x03HEX\x10\x01\x12\x15\n" +
+	"\x11BASE64_COMPRESSED\x10\x02\x12\b\n" +
+	"\x04JSON\x10\x03\x12\x0f\n" +
+	"\vBASE64_GZIP\x10\x04*`\n" +
 	"\vTrustMethod\x12\x16\n" +
 	"\x12METHOD_UNSPECIFIED\x10\x00\x12\a\n" +
 	"\x03DOH\x10\x01\x12\b\n" +
-	"\x04GIST\x10\x02*H\n" +
+	"\x04GIST\x10\x02\x12\x0e\n" +
+	"\n" +
+	"WELL_KNOWN\x10\x03\x12\b\n" +
+	"\x04IPFS\x10\x04\x12\f\n" +
+	"\bETHEREUM\x10\x05*2\n" +
+	"\tLabelMode\x12\x14\n" +
+	"\x10LABEL_COMMITMENT\x10\x00\x12\x0f\n" +
+	"\vLABEL_FIXED\x10\x01*<\n" +
+	"\fAnchorPolicy\x12\x15\n" +
+	"\x11ANCHOR_POLICY_ANY\x10\x00\x12\x15\n" +
+	"\x11ANCHOR_POLICY_ALL\x10\x01*H\n" +
 	"\vProofSystem\x12\x16\n" +
 	"\x12SYSTEM_UNSPECIFIED\x10\x00\x12\v\n" +
 	"\aGROTH16\x10\x01\x12\t\n" +
@@ -549,29 +1638,62 @@ func file_ptx_proto_rawDescGZIP() []byte {
 	return file_ptx_proto_rawDescData
 }
 
-var file_ptx_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_ptx_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_ptx_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_ptx_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_ptx_proto_goTypes = []any{
-	(TrustMethod)(0),        // 0: ptx.v1.TrustMethod
-	(ProofSystem)(0),        // 1: ptx.v1.ProofSystem
-	(*PtxFile)(nil),         // 2: ptx.v1.PtxFile
-	(*ZkProof)(nil),         // 3: ptx.v1.ZkProof
-	(*IssuerSignature)(nil), // 4: ptx.v1.IssuerSignature
-	(*DohAnchor)(nil),       // 5: ptx.v1.DohAnchor
-	(*GistAnchor)(nil),      // 6: ptx.v1.GistAnchor
+	(Curve)(0),                    // 0: ptx.v1.Curve
+	(ProofFormat)(0),              // 1: ptx.v1.ProofFormat
+	(ProofEncoding)(0),            // 2: ptx.v1.ProofEncoding
+	(TrustMethod)(0),              // 3: ptx.v1.TrustMethod
+	(LabelMode)(0),                // 4: ptx.v1.LabelMode
+	(AnchorPolicy)(0),             // 5: ptx.v1.AnchorPolicy
+	(ProofSystem)(0),              // 6: ptx.v1.ProofSystem
+	(*PtxFile)(nil),               // 7: ptx.v1.PtxFile
+	(*Anchor)(nil),                // 8: ptx.v1.Anchor
+	(*ZkProof)(nil),               // 9: ptx.v1.ZkProof
+	(*ProofEnvelope)(nil),         // 10: ptx.v1.ProofEnvelope
+	(*IssuerSignature)(nil),       // 11: ptx.v1.IssuerSignature
+	(*DohAnchor)(nil),             // 12: ptx.v1.DohAnchor
+	(*GistAnchor)(nil),            // 13: ptx.v1.GistAnchor
+	(*WellKnownAnchor)(nil),       // 14: ptx.v1.WellKnownAnchor
+	(*IpfsAnchor)(nil),            // 15: ptx.v1.IpfsAnchor
+	(*EthereumAnchor)(nil),        // 16: ptx.v1.EthereumAnchor
+	(*IssuanceRequest)(nil),       // 17: ptx.v1.IssuanceRequest
+	(*IssuanceResponse)(nil),      // 18: ptx.v1.IssuanceResponse
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
 }
 var file_ptx_proto_depIdxs = []int32{
-	0, // 0: ptx.v1.PtxFile.trust_method:type_name -> ptx.v1.TrustMethod
-	3, // 1: ptx.v1.PtxFile.proof:type_name -> ptx.v1.ZkProof
-	5, // 2: ptx.v1.PtxFile.doh_details:type_name -> ptx.v1.DohAnchor
-	6, // 3: ptx.v1.PtxFile.gist_details:type_name -> ptx.v1.GistAnchor
-	4, // 4: ptx.v1.PtxFile.issuer_signature:type_name -> ptx.v1.IssuerSignature
-	1, // 5: ptx.v1.ZkProof.proof_system:type_name -> ptx.v1.ProofSystem
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	3,  // 0: ptx.v1.PtxFile.trust_method:type_name -> ptx.v1.TrustMethod
+	9,  // 1: ptx.v1.PtxFile.proof:type_name -> ptx.v1.ZkProof
+	12, // 2: ptx.v1.PtxFile.doh_details:type_name -> ptx.v1.DohAnchor
+	13, // 3: ptx.v1.PtxFile.gist_details:type_name -> ptx.v1.GistAnchor
+	11, // 4: ptx.v1.PtxFile.issuer_signature:type_name -> ptx.v1.IssuerSignature
+	19, // 5: ptx.v1.PtxFile.issued_at:type_name -> google.protobuf.Timestamp
+	19, // 6: ptx.v1.PtxFile.expires_at:type_name -> google.protobuf.Timestamp
+	8,  // 7: ptx.v1.PtxFile.additional_anchors:type_name -> ptx.v1.Anchor
+	5,  // 8: ptx.v1.PtxFile.anchor_policy:type_name -> ptx.v1.AnchorPolicy
+	3,  // 9: ptx.v1.Anchor.trust_method:type_name -> ptx.v1.TrustMethod
+	12, // 10: ptx.v1.Anchor.doh_details:type_name -> ptx.v1.DohAnchor
+	13, // 11: ptx.v1.Anchor.gist_details:type_name -> ptx.v1.GistAnchor
+	14, // 12: ptx.v1.Anchor.well_known_details:type_name -> ptx.v1.WellKnownAnchor
+	15, // 13: ptx.v1.Anchor.ipfs_details:type_name -> ptx.v1.IpfsAnchor
+	16, // 14: ptx.v1.Anchor.ethereum_details:type_name -> ptx.v1.EthereumAnchor
+	6,  // 15: ptx.v1.ZkProof.proof_system:type_name -> ptx.v1.ProofSystem
+	10, // 16: ptx.v1.ZkProof.envelope:type_name -> ptx.v1.ProofEnvelope
+	6,  // 17: ptx.v1.ProofEnvelope.proof_system:type_name -> ptx.v1.ProofSystem
+	0,  // 18: ptx.v1.ProofEnvelope.curve:type_name -> ptx.v1.Curve
+	1,  // 19: ptx.v1.ProofEnvelope.format:type_name -> ptx.v1.ProofFormat
+	2,  // 20: ptx.v1.ProofEnvelope.encoding:type_name -> ptx.v1.ProofEncoding
+	4,  // 21: ptx.v1.DohAnchor.label_mode:type_name -> ptx.v1.LabelMode
+	4,  // 22: ptx.v1.WellKnownAnchor.label_mode:type_name -> ptx.v1.LabelMode
+	3,  // 23: ptx.v1.IssuanceRequest.trust_method:type_name -> ptx.v1.TrustMethod
+	4,  // 24: ptx.v1.IssuanceResponse.label_mode:type_name -> ptx.v1.LabelMode
+	19, // 25: ptx.v1.IssuanceResponse.issued_at:type_name -> google.protobuf.Timestamp
+	26, // [26:26] is the sub-list for method output_type
+	26, // [26:26] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_ptx_proto_init() }
@@ -583,13 +1705,20 @@ func file_ptx_proto_init() {
 		(*PtxFile_DohDetails)(nil),
 		(*PtxFile_GistDetails)(nil),
 	}
+	file_ptx_proto_msgTypes[1].OneofWrappers = []any{
+		(*Anchor_DohDetails)(nil),
+		(*Anchor_GistDetails)(nil),
+		(*Anchor_WellKnownDetails)(nil),
+		(*Anchor_IpfsDetails)(nil),
+		(*Anchor_EthereumDetails)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ptx_proto_rawDesc), len(file_ptx_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   5,
+			NumEnums:      7,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
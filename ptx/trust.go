@@ -0,0 +1,45 @@
+package ptx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// trustMethodAliases are the lower-case names of the trust methods, as used
+// on the command line and in configuration files
+var trustMethodAliases = map[string]TrustMethod{
+	"doh":        TrustMethod_DOH,
+	"gist":       TrustMethod_GIST,
+	"well-known": TrustMethod_WELL_KNOWN,
+	"ipfs":       TrustMethod_IPFS,
+	"ethereum":   TrustMethod_ETHEREUM,
+}
+
+// ParseTrustMethod parses a trust method name: an alias ("doh", "gist",
+// "well-known", "ipfs", "ethereum"), the enum name String returns ("DOH") in any case, or the
+// enum number. Only valid methods are returned, so String and
+// ParseTrustMethod round-trip.
+func ParseTrustMethod(s string) (TrustMethod, error) {
+	name := strings.TrimSpace(s)
+	if m, ok := trustMethodAliases[strings.ToLower(name)]; ok {
+		return m, nil
+	}
+	m := TrustMethod_METHOD_UNSPECIFIED
+	if v, ok := TrustMethod_value[strings.ToUpper(name)]; ok {
+		m = TrustMethod(v)
+	} else if n, err := strconv.ParseInt(name, 10, 32); err == nil {
+		m = TrustMethod(n)
+	}
+	if !m.IsValid() {
+		return TrustMethod_METHOD_UNSPECIFIED, fmt.Errorf("unknown trust method %q (want doh, gist, well-known, ipfs or ethereum)", s)
+	}
+	return m, nil
+}
+
+// IsValid reports whether x is a trust method defined by this version of the
+// format. METHOD_UNSPECIFIED is not valid.
+func (x TrustMethod) IsValid() bool {
+	_, ok := TrustMethod_name[int32(x)]
+	return ok && x != TrustMethod_METHOD_UNSPECIFIED
+}